@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"owipex_reader/internal/device/creator"
+	"owipex_reader/internal/types"
+	"owipex_reader/internal/validation"
+)
+
+// runCommand führt einen Unterbefehl aus. handled ist false, wenn name kein Unterbefehl ist
+// und die Anwendung normal starten soll.
+func runCommand(name string, args []string) (exitCode int, handled bool) {
+	switch name {
+	case "list-types":
+		return runListTypes(args), true
+	case "validate":
+		return runValidate(args), true
+	case "help", "-h", "-help", "--help":
+		printUsage()
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// printUsage gibt die verfügbaren Unterbefehle aus
+func printUsage() {
+	fmt.Println("Verwendung: reader [Befehl]")
+	fmt.Println()
+	fmt.Println("Ohne Befehl startet der Reader.")
+	fmt.Println()
+	fmt.Println("Befehle:")
+	fmt.Println("  list-types [-json] [typ]   Listet die verfügbaren Gerätetypen bzw. beschreibt einen Typ")
+	fmt.Println("  validate [-config datei] [-devices verzeichnis] [-strict] [-json]")
+	fmt.Println("                             Prüft Anwendungs- und Gerätekonfigurationen ohne Hardwarezugriff")
+	fmt.Println("  help                       Zeigt diese Hilfe an")
+}
+
+// newCatalog erstellt den Gerätekatalog mit allen eingebauten Gerätetypen
+func newCatalog() (*creator.Catalog, error) {
+	factory := creator.NewDeviceFactory()
+	if err := creator.RegisterAllSensorTypes(factory); err != nil {
+		return nil, err
+	}
+	if err := creator.RegisterAllActuatorTypes(factory); err != nil {
+		return nil, err
+	}
+	return factory.Catalog(), nil
+}
+
+// runListTypes listet die Gerätetypen des Katalogs als Tabelle oder JSON auf.
+// Wird ein Typ angegeben, wird dessen vollständige Beschreibung als JSON ausgegeben.
+func runListTypes(args []string) int {
+	flags := flag.NewFlagSet("list-types", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "Vollständige Beschreibungen als JSON ausgeben")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	catalog, err := newCatalog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Fehler: %v\n", err)
+		return 1
+	}
+
+	if flags.NArg() > 0 {
+		descriptor, err := catalog.Describe(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Fehler: %v\n", err)
+			return 1
+		}
+		return printJSON(descriptor)
+	}
+
+	descriptors := catalog.Types()
+	if *asJSON {
+		return printJSON(descriptors)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TYP\tKATEGORIE\tPROTOKOLL\tMESSWERTE\tBEFEHLE\tNAME")
+	for _, descriptor := range descriptors {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			descriptor.Type,
+			descriptor.Category,
+			orDash(descriptor.Protocol),
+			orDash(joinReadings(descriptor.Readings)),
+			orDash(joinCommands(descriptor.Commands)),
+			descriptor.Name)
+	}
+	writer.Flush()
+
+	return 0
+}
+
+// runValidate prüft die Anwendungskonfiguration und alle Gerätekonfigurationen gegen die
+// Beschreibungen des Gerätekatalogs. Der Exit-Code ist 1, wenn Fehler gefunden wurden
+// (mit -strict auch bei Warnungen).
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath, "Pfad der Anwendungskonfiguration (leer = nicht prüfen)")
+	devicesPath := flags.String("devices", defaultDevicesPath, "Verzeichnis der Gerätekonfigurationen")
+	strict := flags.Bool("strict", false, "Warnungen wie Fehler behandeln")
+	asJSON := flags.Bool("json", false, "Befunde als JSON ausgeben")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	catalog, err := newCatalog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Fehler: %v\n", err)
+		return 1
+	}
+	report := validation.NewValidator(catalog).Validate(*configPath, *devicesPath)
+
+	if *asJSON {
+		if code := printJSON(report); code != 0 {
+			return code
+		}
+	} else {
+		for _, issue := range report.Issues {
+			fmt.Println(issue)
+		}
+		fmt.Printf("%d Dateien geprüft: %d Fehler, %d Warnungen\n", len(report.Files), report.Errors(), report.Warnings())
+	}
+
+	if report.Failed(*strict) {
+		return 1
+	}
+	return 0
+}
+
+// printJSON gibt einen Wert formatiert als JSON aus
+func printJSON(value interface{}) int {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Fehler beim Erzeugen der JSON-Ausgabe: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+// joinReadings verbindet Messwerttypen zu einer kommagetrennten Liste
+func joinReadings(readings []types.ReadingType) string {
+	names := make([]string, len(readings))
+	for i, reading := range readings {
+		names[i] = string(reading)
+	}
+	return strings.Join(names, ",")
+}
+
+// joinCommands verbindet Befehlstypen zu einer kommagetrennten Liste
+func joinCommands(commands []types.CommandType) string {
+	names := make([]string, len(commands))
+	for i, command := range commands {
+		names[i] = string(command)
+	}
+	return strings.Join(names, ",")
+}
+
+// orDash gibt "-" für leere Werte zurück
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
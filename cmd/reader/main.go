@@ -1,11 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"owipex_reader/internal/adapter"
 	"owipex_reader/internal/config"
@@ -19,6 +19,13 @@ const (
 )
 
 func main() {
+	// Unterbefehle (z.B. "list-types") ausführen, ohne den Reader zu starten
+	if len(os.Args) > 1 {
+		if exitCode, handled := runCommand(os.Args[1], os.Args[2:]); handled {
+			os.Exit(exitCode)
+		}
+	}
+
 	logger := log.New(os.Stdout, "[MainApp] ", log.LstdFlags)
 	logger.Println("Starte Owipex RS485 Reader (Go Version mit neuer Architektur)...")
 
@@ -51,12 +58,15 @@ func main() {
 	}
 
 	// ThingsBoard-Client erstellen
-	tbClient := thingsboard.NewClient(appCfg.ThingsBoard, dataToThingsBoardChan)
+	tbClient := thingsboard.NewClient(appCfg.ThingsBoard, dataToThingsBoardChan, thingsboard.WithEventBus(sensorAdapter.EventBus()))
 
 	// Callback für Attribute-Updates setzen
 	tbClient.SetAttributeCallback(func(attributes map[string]interface{}) {
 		logger.Printf("Shared Attributes Update empfangen: %v", attributes)
 
+		// Sensoren schalten, die über Attribute gesteuert werden (z.B. gpsEnabled)
+		sensorAdapter.ApplySharedAttributes(attributes)
+
 		// Button-Status-Änderung verarbeiten
 		if buttonStatus, ok := attributes["buttonStatus"]; ok {
 			logger.Printf("Button-Status geändert: %v", buttonStatus)
@@ -71,9 +81,14 @@ func main() {
 	})
 
 	// Callback für RPC-Aufrufe setzen
-	tbClient.SetRPCCallback(func(method string, params map[string]interface{}) {
+	tbClient.SetRPCCallback(func(method string, params map[string]interface{}) (interface{}, error) {
 		logger.Printf("RPC-Aufruf empfangen: method=%s, params=%v", method, params)
 
+		// RPCs des Sensor-Adapters (z.B. "describe")
+		if result, handled, err := sensorAdapter.HandleRPC(method, params); handled {
+			return result, err
+		}
+
 		// Beispiel für die Verarbeitung verschiedener RPC-Methoden
 		switch method {
 		case "setSamplingInterval":
@@ -89,7 +104,10 @@ func main() {
 			// Hier kann die Logik für einen Neuaufbau der Verbindung implementiert werden
 		default:
 			logger.Printf("Unbekannte RPC-Methode: %s", method)
+			return nil, fmt.Errorf("unbekannte RPC-Methode: %s", method)
 		}
+
+		return map[string]interface{}{"success": true}, nil
 	})
 
 	// Verbindung zu ThingsBoard herstellen
@@ -111,10 +129,10 @@ func main() {
 
 	logger.Println("Shutdown-Signal empfangen. Stoppe Dienste...")
 
-	tbClient.Stop()
+	// Der SensorAdapter bringt die Aktoren beim Stoppen in ihren sicheren Zustand. Er wird vor
+	// dem ThingsBoard-Client gestoppt, damit dieser seinen Kanal bis zuletzt leert und die
+	// Komponenten des Adapters beim Senden nicht blockieren.
 	sensorAdapter.Stop()
-
-	// Etwas Zeit für graceful Shutdown lassen
-	time.Sleep(2 * time.Second)
+	tbClient.Stop()
 	logger.Println("Anwendung wurde ordnungsgemäß beendet.")
 }
@@ -0,0 +1,419 @@
+package acquisition
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/config"
+)
+
+// defaultReadTimeout begrenzt einen Lesevorgang, wenn kein Zeitlimit konfiguriert ist
+const defaultReadTimeout = 5 * time.Second
+
+// Watchdog überwacht die Leseaufgaben (failsafe.Manager). Jede Aufgabe meldet sich unter
+// eigenem Namen an und bedient ihn nach jedem Termin.
+type Watchdog interface {
+	Register(source string, period time.Duration)
+	Kick(source string)
+	Release(source string)
+}
+
+// task ist der Laufzeitzustand einer eingeplanten Aufgabe. Task, offset, ctx und done werden
+// nach dem Einplanen nicht mehr verändert, die übrigen Felder schützt Scheduler.mutex.
+type task struct {
+	Task
+
+	offset time.Duration
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// done wird geschlossen, wenn die Goroutine der Aufgabe beendet ist
+	done chan struct{}
+
+	stats         Stats
+	totalDuration time.Duration
+	overrunning   bool
+}
+
+// Scheduler führt die Leseaufgaben aus. Jede Aufgabe läuft in einer eigenen Goroutine, ein
+// Gerät wird daher nie parallel zu sich selbst gelesen. Aufgaben am selben Bus warten
+// aufeinander. Dauert ein Lesevorgang über den nächsten Termin hinaus, entfallen die
+// versäumten Termine, statt nachgeholt zu werden.
+type Scheduler struct {
+	readTimeout time.Duration
+	jitter      time.Duration
+	stagger     time.Duration
+	logger      *log.Logger
+
+	mutex    sync.Mutex
+	tasks    map[string]*task
+	buses    map[string]chan struct{}
+	random   *rand.Rand
+	watchdog Watchdog
+	started  bool
+	stopped  bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler erstellt den Scheduler aus der Konfiguration
+func NewScheduler(cfg config.AcquisitionConfig) *Scheduler {
+	readTimeout := time.Duration(cfg.ReadTimeoutSeconds) * time.Second
+	if readTimeout <= 0 {
+		readTimeout = defaultReadTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		readTimeout: readTimeout,
+		jitter:      time.Duration(cfg.JitterMS) * time.Millisecond,
+		stagger:     time.Duration(cfg.StaggerMS) * time.Millisecond,
+		logger:      log.New(os.Stdout, "[Acquisition] ", log.LstdFlags),
+		tasks:       make(map[string]*task),
+		buses:       make(map[string]chan struct{}),
+		random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// SetWatchdog legt fest, wer die Leseaufgaben überwacht. Muss vor Start aufgerufen werden.
+func (s *Scheduler) SetWatchdog(watchdog Watchdog) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.watchdog = watchdog
+}
+
+// Start startet alle eingeplanten Aufgaben
+func (s *Scheduler) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+
+	for _, t := range s.tasks {
+		s.launch(t, nil)
+	}
+	s.logger.Printf("%d Leseaufgaben an %d Bussen eingeplant", len(s.tasks), len(s.busesInUse()))
+}
+
+// Stop bricht laufende Lesevorgänge über ihren Kontext ab und wartet, bis alle Aufgaben
+// beendet sind
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	s.stopped = true
+	s.cancel()
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+}
+
+// Sync gleicht die eingeplanten Aufgaben mit tasks ab: Neue Aufgaben werden eingeplant,
+// Aufgaben mit geändertem Zeitplan neu eingeplant (die Zähler bleiben erhalten) und nicht
+// mehr enthaltene beendet. Ein laufender Lesevorgang einer beendeten Aufgabe wird abgebrochen.
+func (s *Scheduler) Sync(tasks []Task) {
+	sorted := make([]Task, len(tasks))
+	copy(sorted, tasks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	wanted := make(map[string]bool, len(sorted))
+	for _, t := range sorted {
+		if t.Interval <= 0 || t.Read == nil {
+			s.logger.Printf("Leseaufgabe %s ohne Intervall oder Lesefunktion wird übergangen", t.ID)
+			continue
+		}
+		wanted[t.ID] = true
+
+		current, exists := s.tasks[t.ID]
+		if exists && current.Task.sameSchedule(t) {
+			continue
+		}
+
+		var previous chan struct{}
+		if exists {
+			current.cancel()
+			previous = current.done
+			delete(s.tasks, t.ID)
+		}
+
+		rt := s.newTask(t)
+		if exists {
+			rt.stats = current.stats
+			rt.totalDuration = current.totalDuration
+			rt.stats.Running = false
+			s.logger.Printf("Leseaufgabe %s neu eingeplant: Intervall %v, Versatz %v, Bus %s", t.ID, t.Interval, rt.offset, t.busKey())
+		}
+		rt.stats.DeviceID = t.DeviceID
+		rt.stats.Bus = t.busKey()
+		rt.stats.IntervalMS = t.Interval.Milliseconds()
+		rt.stats.OffsetMS = rt.offset.Milliseconds()
+		s.tasks[t.ID] = rt
+
+		if s.started {
+			s.launch(rt, previous)
+		}
+	}
+
+	for id, current := range s.tasks {
+		if !wanted[id] {
+			current.cancel()
+			delete(s.tasks, id)
+			if s.started {
+				s.logger.Printf("Leseaufgabe %s beendet", id)
+			}
+		}
+	}
+}
+
+// Stats gibt die Zähler aller Aufgaben sortiert nach ID zurück
+func (s *Scheduler) Stats() []Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := make([]Stats, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		entry := t.stats
+		if entry.Reads > 0 {
+			entry.AvgDurationMS = (t.totalDuration / time.Duration(entry.Reads)).Milliseconds()
+		}
+		stats = append(stats, entry)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats
+}
+
+// newTask erstellt den Laufzeitzustand einer Aufgabe. Ohne festen Versatz werden die Aufgaben
+// eines Busses im Abstand stagger gestartet. Muss mit gesperrtem Mutex aufgerufen werden.
+func (s *Scheduler) newTask(t Task) *task {
+	offset := t.Offset
+	if offset <= 0 {
+		position := 0
+		for _, other := range s.tasks {
+			if other.busKey() == t.busKey() {
+				position++
+			}
+		}
+		offset = (time.Duration(position) * s.stagger) % t.Interval
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	return &task{
+		Task:   t,
+		offset: offset,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		stats:  Stats{ID: t.ID, DeviceID: t.DeviceID},
+	}
+}
+
+// launch startet die Goroutine einer Aufgabe. Sie beginnt erst, wenn die Goroutine der
+// ersetzten Aufgabe (previous) beendet ist. Muss mit gesperrtem Mutex aufgerufen werden.
+func (s *Scheduler) launch(t *task, previous chan struct{}) {
+	s.wg.Add(1)
+	go s.run(t, previous)
+}
+
+// run führt eine Aufgabe aus, bis ihr Kontext endet
+func (s *Scheduler) run(t *task, previous chan struct{}) {
+	defer s.wg.Done()
+	defer close(t.done)
+
+	if previous != nil {
+		select {
+		case <-previous:
+		case <-t.ctx.Done():
+			return
+		}
+	}
+
+	// Abmelden vor close(t.done), damit sich eine ersetzende Aufgabe danach anmeldet
+	kick := func() {}
+	if watchdog := s.currentWatchdog(); watchdog != nil {
+		source := "acquisition:" + t.ID
+		watchdog.Register(source, t.Interval+s.readTimeout)
+		defer watchdog.Release(source)
+		kick = func() { watchdog.Kick(source) }
+	}
+
+	next := time.Now().Add(t.offset)
+	for {
+		s.setNext(t, next)
+
+		timer := time.NewTimer(time.Until(next) + s.randomJitter(t.Interval))
+		select {
+		case <-t.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		slot := next
+		next = next.Add(t.Interval)
+
+		if t.Ready != nil && !t.Ready() {
+			s.mutex.Lock()
+			t.stats.Deferred++
+			s.mutex.Unlock()
+			next, _ = skipSlots(next, t.Interval, time.Now())
+			kick()
+			continue
+		}
+
+		// Bus belegen, damit kein anderes Gerät an derselben Schnittstelle gleichzeitig liest
+		bus := s.bus(t.busKey())
+		waitStart := time.Now()
+		select {
+		case bus <- struct{}{}:
+		case <-t.ctx.Done():
+			return
+		}
+		started := time.Now()
+		s.begin(t, started, started.Sub(waitStart))
+
+		ctx, cancel := context.WithTimeout(t.ctx, s.readTimeout)
+		err := t.Read(ctx)
+		cancel()
+		<-bus
+
+		if t.ctx.Err() != nil {
+			// Abgebrochen (Stop oder Aufgabe entfernt), kein Fehler des Geräts
+			return
+		}
+
+		finished := time.Now()
+		var skipped int
+		next, skipped = skipSlots(next, t.Interval, finished)
+		s.finish(t, slot, finished, finished.Sub(started), err, skipped)
+		kick()
+	}
+}
+
+// currentWatchdog gibt den Watchdog der Leseaufgaben zurück
+func (s *Scheduler) currentWatchdog() Watchdog {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.watchdog
+}
+
+// skipSlots gibt den ersten Termin nach now zurück, ausgehend vom Termin next im Abstand
+// interval, und die Anzahl der dabei übersprungenen Termine
+func skipSlots(next time.Time, interval time.Duration, now time.Time) (time.Time, int) {
+	skipped := 0
+	for !next.After(now) {
+		next = next.Add(interval)
+		skipped++
+	}
+	return next, skipped
+}
+
+// bus gibt die Sperre eines Busses zurück und legt sie bei Bedarf an
+func (s *Scheduler) bus(key string) chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bus, exists := s.buses[key]
+	if !exists {
+		bus = make(chan struct{}, 1)
+		s.buses[key] = bus
+	}
+	return bus
+}
+
+// busesInUse gibt die Busse der eingeplanten Aufgaben zurück. Muss mit gesperrtem Mutex
+// aufgerufen werden.
+func (s *Scheduler) busesInUse() map[string]bool {
+	buses := make(map[string]bool)
+	for _, t := range s.tasks {
+		buses[t.busKey()] = true
+	}
+	return buses
+}
+
+// randomJitter gibt eine zufällige Verzögerung bis jitter zurück, höchstens ein Viertel des Intervalls
+func (s *Scheduler) randomJitter(interval time.Duration) time.Duration {
+	limit := s.jitter
+	if limit > interval/4 {
+		limit = interval / 4
+	}
+	if limit <= 0 {
+		return 0
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return time.Duration(s.random.Int63n(int64(limit)))
+}
+
+// setNext merkt den nächsten Termin einer Aufgabe
+func (s *Scheduler) setNext(t *task, next time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t.stats.NextRun = &next
+}
+
+// begin vermerkt den Start eines Lesevorgangs und die Wartezeit auf den Bus
+func (s *Scheduler) begin(t *task, started time.Time, wait time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t.stats.Running = true
+	t.stats.LastRun = &started
+	t.stats.LastWaitMS = wait.Milliseconds()
+	if t.stats.LastWaitMS > t.stats.MaxWaitMS {
+		t.stats.MaxWaitMS = t.stats.LastWaitMS
+	}
+}
+
+// finish zählt einen abgeschlossenen Lesevorgang und meldet Beginn und Ende von Überläufen
+func (s *Scheduler) finish(t *task, slot, finished time.Time, duration time.Duration, err error, skipped int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := &t.stats
+	stats.Running = false
+	stats.Reads++
+	stats.LastDurationMS = duration.Milliseconds()
+	if stats.LastDurationMS > stats.MaxDurationMS {
+		stats.MaxDurationMS = stats.LastDurationMS
+	}
+	t.totalDuration += duration
+
+	stats.LastError = ""
+	if err != nil {
+		stats.Failures++
+		stats.LastError = err.Error()
+	}
+
+	if skipped > 0 {
+		stats.Overruns++
+		stats.Skipped += uint64(skipped)
+		if !t.overrunning {
+			s.logger.Printf("Überlauf: %s endete %v nach dem Termin (Lesen %v, Warten auf Bus %s %dms), Intervall %v, %d Termine ausgelassen",
+				t.ID, finished.Sub(slot).Round(time.Millisecond), duration.Round(time.Millisecond), t.busKey(), stats.LastWaitMS, t.Interval, skipped)
+		}
+		t.overrunning = true
+	} else if t.overrunning {
+		s.logger.Printf("%s wieder im Takt", t.ID)
+		t.overrunning = false
+	}
+}
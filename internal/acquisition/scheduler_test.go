@@ -0,0 +1,250 @@
+package acquisition
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/config"
+)
+
+// TestSkipSlots prüft den nächsten Termin und die Zahl der versäumten Termine nach einem
+// Lesevorgang
+func TestSkipSlots(t *testing.T) {
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	interval := 10 * time.Second
+
+	tests := []struct {
+		name        string
+		finished    time.Duration
+		wantNext    time.Duration
+		wantSkipped int
+	}{
+		{"vor dem nächsten Termin", 3 * time.Second, 10 * time.Second, 0},
+		{"knapp vor dem nächsten Termin", 10*time.Second - time.Millisecond, 10 * time.Second, 0},
+		{"genau am nächsten Termin", 10 * time.Second, 20 * time.Second, 1},
+		{"nach dem nächsten Termin", 12 * time.Second, 20 * time.Second, 1},
+		{"mehrere Termine versäumt", 35 * time.Second, 40 * time.Second, 3},
+		{"genau am übernächsten Termin", 20 * time.Second, 30 * time.Second, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// next ist bereits der auf den gelesenen Termin (base) folgende Termin
+			next, skipped := skipSlots(base.Add(interval), interval, base.Add(tt.finished))
+			if want := base.Add(tt.wantNext); !next.Equal(want) {
+				t.Errorf("nächster Termin %v, erwartet %v", next.Sub(base), tt.wantNext)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("%d Termine ausgelassen, erwartet %d", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+// TestFinish prüft die Zähler und Meldungen von Überläufen über eine Folge von Lesevorgängen
+func TestFinish(t *testing.T) {
+	type read struct {
+		duration time.Duration
+		skipped  int
+		err      error
+	}
+
+	tests := []struct {
+		name          string
+		reads         []read
+		wantOverruns  uint64
+		wantSkipped   uint64
+		wantFailures  uint64
+		wantMaxMS     int64
+		wantAvgMS     int64
+		wantOverrun   bool
+		wantOverLogs  int
+		wantBackLogs  int
+		wantLastError string
+	}{
+		{
+			name:      "im Takt",
+			reads:     []read{{duration: 100 * time.Millisecond}, {duration: 300 * time.Millisecond}},
+			wantMaxMS: 300,
+			wantAvgMS: 200,
+		},
+		{
+			// Ein andauernder Überlauf wird nur zu Beginn gemeldet
+			name: "andauernder Überlauf",
+			reads: []read{
+				{duration: 12 * time.Second, skipped: 1},
+				{duration: 25 * time.Second, skipped: 2},
+				{duration: 11 * time.Second, skipped: 1},
+			},
+			wantOverruns: 3,
+			wantSkipped:  4,
+			wantMaxMS:    25000,
+			wantAvgMS:    16000,
+			wantOverrun:  true,
+			wantOverLogs: 1,
+		},
+		{
+			name: "Überlauf und wieder im Takt",
+			reads: []read{
+				{duration: time.Second},
+				{duration: 12 * time.Second, skipped: 1},
+				{duration: time.Second},
+				{duration: 31 * time.Second, skipped: 3},
+				{duration: time.Second},
+			},
+			wantOverruns: 2,
+			wantSkipped:  4,
+			wantMaxMS:    31000,
+			wantAvgMS:    9200,
+			wantOverLogs: 2,
+			wantBackLogs: 2,
+		},
+		{
+			name: "Fehler",
+			reads: []read{
+				{duration: time.Second, err: errors.New("zeitüberschreitung")},
+				{duration: time.Second},
+				{duration: time.Second, err: errors.New("crc-fehler")},
+			},
+			wantFailures:  2,
+			wantMaxMS:     1000,
+			wantAvgMS:     1000,
+			wantLastError: "crc-fehler",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			s := NewScheduler(config.AcquisitionConfig{})
+			s.logger = log.New(&output, "", 0)
+			s.Sync([]Task{{ID: "pump", DeviceID: "pump", Interval: 10 * time.Second, Read: func(ctx context.Context) error { return nil }}})
+
+			rt := s.tasks["pump"]
+			slot := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+			for _, r := range tt.reads {
+				s.begin(rt, slot, 0)
+				s.finish(rt, slot, slot.Add(r.duration), r.duration, r.err, r.skipped)
+			}
+
+			stats := s.Stats()[0]
+			if stats.Reads != uint64(len(tt.reads)) || stats.Failures != tt.wantFailures {
+				t.Errorf("Reads/Failures = %d/%d, erwartet %d/%d", stats.Reads, stats.Failures, len(tt.reads), tt.wantFailures)
+			}
+			if stats.Overruns != tt.wantOverruns || stats.Skipped != tt.wantSkipped {
+				t.Errorf("Overruns/Skipped = %d/%d, erwartet %d/%d", stats.Overruns, stats.Skipped, tt.wantOverruns, tt.wantSkipped)
+			}
+			if stats.MaxDurationMS != tt.wantMaxMS || stats.AvgDurationMS != tt.wantAvgMS {
+				t.Errorf("MaxDurationMS/AvgDurationMS = %d/%d, erwartet %d/%d", stats.MaxDurationMS, stats.AvgDurationMS, tt.wantMaxMS, tt.wantAvgMS)
+			}
+			if stats.Running {
+				t.Errorf("Running nach finish gesetzt")
+			}
+			if stats.LastError != tt.wantLastError {
+				t.Errorf("LastError = %q, erwartet %q", stats.LastError, tt.wantLastError)
+			}
+			if rt.overrunning != tt.wantOverrun {
+				t.Errorf("overrunning = %v, erwartet %v", rt.overrunning, tt.wantOverrun)
+			}
+
+			if got := strings.Count(output.String(), "Überlauf:"); got != tt.wantOverLogs {
+				t.Errorf("%d Überlauf-Meldungen, erwartet %d:\n%s", got, tt.wantOverLogs, output.String())
+			}
+			if got := strings.Count(output.String(), "wieder im Takt"); got != tt.wantBackLogs {
+				t.Errorf("%d Meldungen \"wieder im Takt\", erwartet %d:\n%s", got, tt.wantBackLogs, output.String())
+			}
+		})
+	}
+}
+
+// TestRandomJitter prüft die Begrenzung des Jitters auf ein Viertel des Intervalls
+func TestRandomJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		jitterMS int
+		interval time.Duration
+		limit    time.Duration
+	}{
+		{"ohne Jitter", 0, time.Second, 0},
+		{"konfigurierter Jitter", 100, 10 * time.Second, 100 * time.Millisecond},
+		{"begrenzt auf ein Viertel", 1000, time.Second, 250 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(config.AcquisitionConfig{JitterMS: tt.jitterMS})
+			for i := 0; i < 1000; i++ {
+				jitter := s.randomJitter(tt.interval)
+				if jitter < 0 || (tt.limit == 0 && jitter != 0) || (tt.limit > 0 && jitter >= tt.limit) {
+					t.Fatalf("Jitter %v außerhalb von [0, %v)", jitter, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+// testWatchdog zeichnet An- und Abmeldungen sowie Aufrufe von Kick je Quelle auf
+type testWatchdog struct {
+	mutex      sync.Mutex
+	registered map[string]time.Duration
+	kicks      map[string]int
+	released   []string
+}
+
+// Register merkt die Periode einer Quelle
+func (w *testWatchdog) Register(source string, period time.Duration) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.registered[source] = period
+}
+
+// Kick zählt die Aufrufe einer Quelle
+func (w *testWatchdog) Kick(source string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.kicks[source]++
+}
+
+// Release merkt die abgemeldete Quelle
+func (w *testWatchdog) Release(source string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.released = append(w.released, source)
+}
+
+// TestWatchdogSources prüft, dass jede Leseaufgabe eine eigene Quelle beim Watchdog anmeldet,
+// sie nach jedem Termin bedient, auch wenn das Gerät nicht bereit ist, und beim Beenden abmeldet
+func TestWatchdogSources(t *testing.T) {
+	watchdog := &testWatchdog{registered: make(map[string]time.Duration), kicks: make(map[string]int)}
+	s := NewScheduler(config.AcquisitionConfig{ReadTimeoutSeconds: 1})
+	s.SetWatchdog(watchdog)
+
+	interval := 10 * time.Millisecond
+	s.Sync([]Task{
+		{ID: "ph", DeviceID: "ph", Interval: interval, Read: func(ctx context.Context) error { return errors.New("zeitüberschreitung") }},
+		{ID: "valve_state", DeviceID: "valve", Interval: interval, Read: func(ctx context.Context) error { return nil }, Ready: func() bool { return false }},
+	})
+	s.Start()
+	time.Sleep(100 * time.Millisecond)
+	s.Stop()
+
+	watchdog.mutex.Lock()
+	defer watchdog.mutex.Unlock()
+	for _, source := range []string{"acquisition:ph", "acquisition:valve_state"} {
+		if period := watchdog.registered[source]; period != interval+time.Second {
+			t.Errorf("%s mit Periode %v angemeldet, erwartet %v", source, period, interval+time.Second)
+		}
+		if watchdog.kicks[source] == 0 {
+			t.Errorf("%s hat den Watchdog nie bedient", source)
+		}
+	}
+	if len(watchdog.released) != 2 {
+		t.Errorf("abgemeldet %v, erwartet beide Aufgaben", watchdog.released)
+	}
+}
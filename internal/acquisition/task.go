@@ -0,0 +1,82 @@
+// Package acquisition plant das zyklische Lesen der Geräte. Jede Aufgabe (Messwert eines
+// Sensors, Zustand eines Aktors) hat einen eigenen Zeitplan mit Phasenversatz und Jitter,
+// Geräte an derselben Schnittstelle werden nacheinander gelesen und überlange Lesevorgänge
+// als Überlauf gezählt, statt den nächsten Lesevorgang parallel zu starten.
+package acquisition
+
+import (
+	"context"
+	"time"
+)
+
+// Task ist eine zyklisch auszuführende Leseaufgabe
+type Task struct {
+	// ID ist die eindeutige Kennung der Aufgabe (z.B. "<gerät>" oder "<gerät>_state")
+	ID       string
+	DeviceID string
+
+	// Bus fasst Aufgaben zusammen, die nicht gleichzeitig laufen dürfen (z.B. Modbus-Geräte
+	// an derselben seriellen Schnittstelle). Leer = nur mit Aufgaben desselben Geräts.
+	Bus string
+
+	// Interval ist der Abstand der Lesevorgänge, Offset der Versatz des ersten Lesevorgangs
+	// nach dem Einplanen (0 = automatisch nach Position am Bus)
+	Interval time.Duration
+	Offset   time.Duration
+
+	// Ready prüft vor jedem Termin, ob gelesen werden soll (z.B. Gerät aktiviert, Backoff
+	// eines Offline-Geräts abgelaufen). Nil = immer.
+	Ready func() bool
+
+	// Read führt den Lesevorgang aus. Der Kontext endet nach dem Zeitlimit oder beim Stoppen.
+	Read func(ctx context.Context) error
+}
+
+// busKey gibt den Schlüssel zurück, unter dem die Aufgabe mit anderen serialisiert wird
+func (t Task) busKey() string {
+	if t.Bus != "" {
+		return t.Bus
+	}
+	return "device:" + t.DeviceID
+}
+
+// sameSchedule prüft, ob zwei Aufgaben denselben Zeitplan haben
+func (t Task) sameSchedule(other Task) bool {
+	return t.DeviceID == other.DeviceID && t.busKey() == other.busKey() &&
+		t.Interval == other.Interval && t.Offset == other.Offset
+}
+
+// Stats sind die Zähler und Laufzeiten einer Aufgabe
+type Stats struct {
+	ID         string `json:"id"`
+	DeviceID   string `json:"device_id"`
+	Bus        string `json:"bus"`
+	IntervalMS int64  `json:"interval_ms"`
+	OffsetMS   int64  `json:"offset_ms"`
+
+	// Reads zählt die ausgeführten Lesevorgänge, Failures die fehlgeschlagenen davon
+	Reads    uint64 `json:"reads"`
+	Failures uint64 `json:"failures"`
+
+	// Overruns zählt Lesevorgänge, die erst nach dem folgenden Termin endeten, Skipped die
+	// dadurch ausgelassenen Termine
+	Overruns uint64 `json:"overruns"`
+	Skipped  uint64 `json:"skipped"`
+
+	// Deferred zählt Termine, an denen nicht gelesen werden sollte (deaktiviert, Backoff)
+	Deferred uint64 `json:"deferred"`
+
+	Running bool       `json:"running"`
+	LastRun *time.Time `json:"last_run,omitempty"`
+	NextRun *time.Time `json:"next_run,omitempty"`
+
+	LastDurationMS int64 `json:"last_duration_ms"`
+	AvgDurationMS  int64 `json:"avg_duration_ms"`
+	MaxDurationMS  int64 `json:"max_duration_ms"`
+
+	// Wartezeit auf den Bus (belegt durch andere Geräte an derselben Schnittstelle)
+	LastWaitMS int64 `json:"last_wait_ms"`
+	MaxWaitMS  int64 `json:"max_wait_ms"`
+
+	LastError string `json:"last_error,omitempty"`
+}
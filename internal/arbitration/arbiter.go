@@ -0,0 +1,470 @@
+// Package arbitration entscheidet je Aktor, welcher Auslöser ihn gerade steuern darf. Im
+// Altsystem konnten powerButton, autoSwitch und die Relais-Attribute gleichzeitig auf dasselbe
+// Relais wirken, so dass Dashboard und Regler gegeneinander schalteten. Hier hat jeder Befehl
+// eine Priorität nach seinem Auslöser (Sicherheit > Handbedienung vor Ort > Handbedienung aus
+// ThingsBoard > Regler und Automatik > Zeitplan). Befehle mit niedrigerer Priorität als der
+// aktuelle Besitzer des Aktors und Befehle unbekannter Auslöser werden abgelehnt.
+package arbitration
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/interlock"
+)
+
+// Level ist die Priorität eines Auslösers
+type Level int
+
+// Prioritäten der Auslöser, aufsteigend
+const (
+	LevelNone Level = iota
+	LevelSchedule
+	LevelAutomatic
+	LevelRemoteManual
+	LevelLocalManual
+	LevelSafety
+)
+
+// levelNames sind die Bezeichnungen der Prioritäten in Status und Attributen
+var levelNames = map[Level]string{
+	LevelNone:         "none",
+	LevelSchedule:     "schedule",
+	LevelAutomatic:    "automatic",
+	LevelRemoteManual: "remote_manual",
+	LevelLocalManual:  "local_manual",
+	LevelSafety:       "safety",
+}
+
+// String gibt die Bezeichnung der Priorität zurück
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// LevelOf gibt die Priorität eines Auslösers (command.Origin...) zurück. Unbekannte Auslöser
+// haben die niedrigste Priorität LevelNone und werden von Admit abgelehnt.
+func LevelOf(origin string) Level {
+	switch origin {
+	case command.OriginFailsafe:
+		return LevelSafety
+	case command.OriginLocal:
+		return LevelLocalManual
+	case command.OriginRPC, command.OriginAttribute:
+		return LevelRemoteManual
+	case command.OriginController, command.OriginRule, command.OriginSequence:
+		return LevelAutomatic
+	case command.OriginSchedule:
+		return LevelSchedule
+	default:
+		return LevelNone
+	}
+}
+
+// tickInterval ist das Intervall, in dem abgelaufene Übersteuerungen aufgehoben werden
+const tickInterval = time.Second
+
+// OverriddenError wird zurückgegeben, wenn ein Aktor von einem Auslöser mit höherer Priorität
+// gesteuert wird
+type OverriddenError struct {
+	DeviceID string
+	Level    Level
+	Owner    Claim
+}
+
+func (e *OverriddenError) Error() string {
+	text := fmt.Sprintf("aktor %s wird von %s (%s/%s) gesteuert, Befehl mit Priorität %s abgelehnt",
+		e.DeviceID, e.Owner.Source, e.Owner.Origin, e.Owner.Issuer, e.Level)
+	if e.Owner.Expires != nil {
+		text += fmt.Sprintf(" (bis %s)", e.Owner.Expires.Format(time.RFC3339))
+	}
+	return text
+}
+
+// Claim ist der aktuelle Besitzer eines Aktors
+type Claim struct {
+	DeviceID string     `json:"device_id"`
+	Source   string     `json:"source"`
+	Origin   string     `json:"origin"`
+	Issuer   string     `json:"issuer,omitempty"`
+	Since    time.Time  `json:"since"`
+	Expires  *time.Time `json:"expires,omitempty"`
+
+	level Level
+
+	// reasons sind die Anlässe eines gehaltenen sicheren Zustands (Issuer der Failsafe-Befehle)
+	reasons map[string]bool
+}
+
+// expired prüft, ob eine Übersteuerung abgelaufen ist
+func (c *Claim) expired(now time.Time) bool {
+	return c.Expires != nil && !now.Before(*c.Expires)
+}
+
+// Arbiter führt die Besitzer der Aktoren (command.Arbiter). Eine Handbedienung übersteuert den
+// Aktor unabhängig vom Wert bis zum Ablauf ihrer Zeit oder bis release_override. Regler,
+// Automatik und Zeitpläne besitzen einen Aktor, solange sie ihn eingeschaltet haben; ihr
+// Ausschaltbefehl gibt ihn wieder frei. Sicherheitsbefehle (sichere Zustände) werden immer
+// ausgeführt, heben alle Übersteuerungen auf und halten den Aktor, bis ihr Anlass behoben ist
+// (ReleaseSafety) oder er mit release_override freigegeben wird.
+type Arbiter struct {
+	registry *device.Registry
+	logger   *log.Logger
+	timeouts config.ArbitrationConfig
+
+	// publish meldet den aktuellen Besitzer als Client-Attribute an ThingsBoard
+	publish func(attributes map[string]interface{})
+
+	mutex  sync.Mutex
+	claims map[string]*Claim
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewArbiter erstellt die Vorrangregelung. Die Zeiten der Übersteuerung werden geprüft.
+func NewArbiter(cfg config.ArbitrationConfig, registry *device.Registry, publish func(map[string]interface{})) (*Arbiter, error) {
+	if err := ValidateTimeouts(cfg); err != nil {
+		return nil, err
+	}
+
+	return &Arbiter{
+		registry: registry,
+		logger:   log.New(os.Stdout, "[Arbitration] ", log.LstdFlags),
+		timeouts: cfg,
+		publish:  publish,
+		claims:   make(map[string]*Claim),
+		stopChan: make(chan struct{}),
+	}, nil
+}
+
+// ValidateTimeouts prüft die Zeiten der Übersteuerung
+func ValidateTimeouts(cfg config.ArbitrationConfig) error {
+	check := func(label string, timeouts config.OverrideTimeoutConfig) error {
+		if timeouts.LocalSeconds < 0 || timeouts.RemoteSeconds < 0 {
+			return fmt.Errorf("%s: local_seconds und remote_seconds dürfen nicht negativ sein", label)
+		}
+		return nil
+	}
+
+	if err := check("arbitration.override_timeout", cfg.OverrideTimeout); err != nil {
+		return err
+	}
+	for id, timeouts := range cfg.Devices {
+		if err := check("arbitration.devices."+id, timeouts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start meldet den Anfangszustand aller Aktoren und hebt abgelaufene Übersteuerungen zyklisch auf
+func (a *Arbiter) Start() {
+	attributes := make(map[string]interface{})
+	for _, actor := range a.registry.GetActors() {
+		a.addAttributes(attributes, actor.ID(), nil)
+	}
+	a.send(attributes)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.stopChan:
+				return
+			case now := <-ticker.C:
+				a.Expire(now)
+			}
+		}
+	}()
+}
+
+// Stop beendet die zyklische Prüfung
+func (a *Arbiter) Stop() {
+	close(a.stopChan)
+	a.wg.Wait()
+}
+
+// overrideTimeout gibt die Dauer einer Handbedienung vor Ort bzw. aus ThingsBoard an einem
+// Aktor zurück
+func (a *Arbiter) overrideTimeout(deviceID string, level Level) time.Duration {
+	seconds := a.timeouts.OverrideTimeout.RemoteSeconds
+	if level == LevelLocalManual {
+		seconds = a.timeouts.OverrideTimeout.LocalSeconds
+	}
+	if timeouts, exists := a.timeouts.Devices[deviceID]; exists {
+		if level == LevelLocalManual && timeouts.LocalSeconds > 0 {
+			seconds = timeouts.LocalSeconds
+		} else if level == LevelRemoteManual && timeouts.RemoteSeconds > 0 {
+			seconds = timeouts.RemoteSeconds
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Admit lässt einen Befehl zu, wenn seine Priorität mindestens der des aktuellen Besitzers
+// entspricht (command.Arbiter)
+func (a *Arbiter) Admit(request command.Request) error {
+	if a.timeouts.Disabled {
+		return nil
+	}
+	level := LevelOf(request.Origin)
+	if level == LevelSafety {
+		return nil
+	}
+	if level == LevelNone {
+		return fmt.Errorf("unbekannter Auslöser %q, Befehl an %s abgelehnt", request.Origin, request.DeviceID)
+	}
+
+	now := time.Now()
+	a.mutex.Lock()
+	owner, expired := a.ownerLocked(request.DeviceID, now)
+	var err error
+	if owner != nil && level < owner.level {
+		err = &OverriddenError{DeviceID: request.DeviceID, Level: level, Owner: *owner}
+	}
+	a.mutex.Unlock()
+
+	if expired != nil {
+		a.released(expired, "abgelaufen")
+	}
+	return err
+}
+
+// ownerLocked gibt den aktuellen Besitzer eines Aktors zurück und entfernt eine abgelaufene
+// Übersteuerung (expired), der Aufrufer hält die Sperre
+func (a *Arbiter) ownerLocked(deviceID string, now time.Time) (owner *Claim, expired *Claim) {
+	claim, exists := a.claims[deviceID]
+	if !exists {
+		return nil, nil
+	}
+	if claim.expired(now) {
+		delete(a.claims, deviceID)
+		return nil, claim
+	}
+	return claim, nil
+}
+
+// Executed übernimmt den Auslöser eines ausgeführten Befehls als Besitzer (command.Arbiter)
+func (a *Arbiter) Executed(request command.Request) {
+	if a.timeouts.Disabled {
+		return
+	}
+	level := LevelOf(request.Origin)
+	now := time.Now()
+
+	a.mutex.Lock()
+	previous := a.claims[request.DeviceID]
+	var claim *Claim
+	switch {
+	case level == LevelSafety:
+		// Der sichere Zustand hebt alle Übersteuerungen auf und hält den Aktor für jeden Anlass,
+		// bis dieser behoben ist
+		claim = &Claim{DeviceID: request.DeviceID, Source: level.String(), Origin: request.Origin, Since: now, level: level, reasons: make(map[string]bool)}
+		if previous != nil && previous.level == LevelSafety {
+			claim.Since = previous.Since
+			for reason := range previous.reasons {
+				claim.reasons[reason] = true
+			}
+		}
+		claim.reasons[request.Issuer] = true
+		claim.Issuer = joinReasons(claim.reasons)
+		a.claims[request.DeviceID] = claim
+	case level == LevelLocalManual || level == LevelRemoteManual:
+		claim = &Claim{DeviceID: request.DeviceID, Source: level.String(), Origin: request.Origin, Issuer: request.Issuer, Since: now, level: level}
+		if timeout := a.overrideTimeout(request.DeviceID, level); timeout > 0 {
+			expires := now.Add(timeout)
+			claim.Expires = &expires
+		}
+		a.claims[request.DeviceID] = claim
+	case interlock.IsShutdown(request.Command):
+		// Ausschalten gibt den Aktor frei; höhere Besitzer hätte Admit abgelehnt
+		delete(a.claims, request.DeviceID)
+	default:
+		claim = &Claim{DeviceID: request.DeviceID, Source: level.String(), Origin: request.Origin, Issuer: request.Issuer, Since: now, level: level}
+		if previous != nil && previous.level == level && previous.Origin == request.Origin && previous.Issuer == request.Issuer {
+			claim.Since = previous.Since
+		}
+		a.claims[request.DeviceID] = claim
+	}
+	a.mutex.Unlock()
+
+	if claim == nil {
+		if previous != nil {
+			a.released(previous, fmt.Sprintf("durch %s/%s", request.Origin, request.Issuer))
+		}
+		return
+	}
+	if previous == nil || previous.level != claim.level || previous.Origin != claim.Origin || previous.Issuer != claim.Issuer {
+		a.logger.Printf("Aktor %s wird von %s (%s/%s) gesteuert", claim.DeviceID, claim.Source, claim.Origin, claim.Issuer)
+	}
+	attributes := make(map[string]interface{})
+	a.addAttributes(attributes, claim.DeviceID, claim)
+	a.send(attributes)
+}
+
+// released meldet die Freigabe eines Aktors
+func (a *Arbiter) released(claim *Claim, reason string) {
+	a.logger.Printf("Aktor %s von %s (%s/%s) freigegeben: %s", claim.DeviceID, claim.Source, claim.Origin, claim.Issuer, reason)
+
+	attributes := make(map[string]interface{})
+	a.addAttributes(attributes, claim.DeviceID, nil)
+	a.send(attributes)
+}
+
+// Expire hebt abgelaufene Übersteuerungen auf
+func (a *Arbiter) Expire(now time.Time) {
+	var expired []*Claim
+
+	a.mutex.Lock()
+	for id := range a.claims {
+		if _, claim := a.ownerLocked(id, now); claim != nil {
+			expired = append(expired, claim)
+		}
+	}
+	a.mutex.Unlock()
+
+	for _, claim := range expired {
+		a.released(claim, "abgelaufen")
+	}
+}
+
+// Release hebt die Handbedienung oder den gehaltenen sicheren Zustand eines Aktors auf, damit
+// Regler und Zeitpläne ihn wieder steuern können. Besitzt die Automatik den Aktor, bleibt er
+// unverändert.
+func (a *Arbiter) Release(deviceID, issuer string) (bool, error) {
+	if _, err := a.registry.GetDevice(deviceID); err != nil {
+		return false, err
+	}
+
+	a.mutex.Lock()
+	claim, exists := a.claims[deviceID]
+	releasable := exists && claim.level >= LevelRemoteManual
+	if releasable {
+		delete(a.claims, deviceID)
+	}
+	a.mutex.Unlock()
+
+	if !releasable {
+		return false, nil
+	}
+	a.released(claim, "freigegeben durch "+issuer)
+	return true, nil
+}
+
+// ReleaseSafety hebt das Halten des sicheren Zustands aus dem Anlass reason auf, ohne Angabe
+// von Geräten an allen Aktoren (failsafe.Holder). Ein Aktor wird erst freigegeben, wenn alle
+// Anlässe behoben sind. Zurückgegeben werden die freigegebenen Aktoren.
+func (a *Arbiter) ReleaseSafety(reason string, deviceIDs ...string) []string {
+	wanted := make(map[string]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		wanted[id] = true
+	}
+
+	var released, reduced []*Claim
+	a.mutex.Lock()
+	for id, claim := range a.claims {
+		if claim.level != LevelSafety || !claim.reasons[reason] || (len(wanted) > 0 && !wanted[id]) {
+			continue
+		}
+		if len(claim.reasons) == 1 {
+			delete(a.claims, id)
+			released = append(released, claim)
+			continue
+		}
+
+		remaining := *claim
+		remaining.reasons = make(map[string]bool, len(claim.reasons)-1)
+		for other := range claim.reasons {
+			if other != reason {
+				remaining.reasons[other] = true
+			}
+		}
+		remaining.Issuer = joinReasons(remaining.reasons)
+		a.claims[id] = &remaining
+		reduced = append(reduced, &remaining)
+	}
+	a.mutex.Unlock()
+
+	ids := make([]string, 0, len(released))
+	for _, claim := range released {
+		a.released(claim, reason+" behoben")
+		ids = append(ids, claim.DeviceID)
+	}
+	for _, claim := range reduced {
+		a.logger.Printf("Aktor %s: %s behoben, sicherer Zustand bleibt wegen %s", claim.DeviceID, reason, claim.Issuer)
+		attributes := make(map[string]interface{})
+		a.addAttributes(attributes, claim.DeviceID, claim)
+		a.send(attributes)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// joinReasons gibt die Anlässe eines sicheren Zustands sortiert und durch Kommas getrennt zurück
+func joinReasons(reasons map[string]bool) string {
+	list := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		list = append(list, reason)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// Status gibt die aktuellen Besitzer aller Aktoren zurück, sortiert nach Gerät. Aktoren ohne
+// Besitzer erscheinen mit der Quelle "none".
+func (a *Arbiter) Status() []Claim {
+	now := time.Now()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var claims []Claim
+	for _, actor := range a.registry.GetActors() {
+		claim, exists := a.claims[actor.ID()]
+		if !exists || claim.expired(now) {
+			claims = append(claims, Claim{DeviceID: actor.ID(), Source: LevelNone.String()})
+			continue
+		}
+		claims = append(claims, *claim)
+	}
+
+	sort.Slice(claims, func(i, j int) bool {
+		return claims[i].DeviceID < claims[j].DeviceID
+	})
+	return claims
+}
+
+// addAttributes fügt die Attribute des Besitzers eines Aktors hinzu ("<id>_control_source",
+// "<id>_control_issuer", "<id>_override_expires")
+func (a *Arbiter) addAttributes(attributes map[string]interface{}, deviceID string, claim *Claim) {
+	source, issuer, expires := LevelNone.String(), "", ""
+	if claim != nil {
+		source, issuer = claim.Source, claim.Origin+"/"+claim.Issuer
+		if claim.Expires != nil {
+			expires = claim.Expires.Format(time.RFC3339)
+		}
+	}
+	attributes[deviceID+"_control_source"] = source
+	attributes[deviceID+"_control_issuer"] = issuer
+	attributes[deviceID+"_override_expires"] = expires
+}
+
+// send meldet Attribute an ThingsBoard
+func (a *Arbiter) send(attributes map[string]interface{}) {
+	if a.publish != nil && len(attributes) > 0 {
+		a.publish(attributes)
+	}
+}
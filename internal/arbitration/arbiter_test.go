@@ -0,0 +1,266 @@
+package arbitration
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/types"
+)
+
+// origins ist je Priorität ein Auslöser, aufsteigend
+var origins = []struct {
+	level  Level
+	origin string
+}{
+	{LevelSchedule, command.OriginSchedule},
+	{LevelAutomatic, command.OriginController},
+	{LevelRemoteManual, command.OriginRPC},
+	{LevelLocalManual, command.OriginLocal},
+	{LevelSafety, command.OriginFailsafe},
+}
+
+// newTestArbiter erstellt eine Vorrangregelung ohne gemeldete Attribute
+func newTestArbiter(t *testing.T, cfg config.ArbitrationConfig) *Arbiter {
+	t.Helper()
+
+	arbiter, err := NewArbiter(cfg, device.NewRegistry(), nil)
+	if err != nil {
+		t.Fatalf("NewArbiter: %v", err)
+	}
+	return arbiter
+}
+
+// switchOn ist ein Einschaltbefehl, der den Aktor für seinen Auslöser belegt
+func switchOn(deviceID, origin string) command.Request {
+	return command.Request{DeviceID: deviceID, Command: types.NewCommand(types.CommandTypeSetState, true), Origin: origin, Issuer: "test_" + origin}
+}
+
+// TestLevelOf prüft die Zuordnung der Auslöser zu Prioritäten
+func TestLevelOf(t *testing.T) {
+	tests := []struct {
+		origin string
+		want   Level
+	}{
+		{command.OriginFailsafe, LevelSafety},
+		{command.OriginLocal, LevelLocalManual},
+		{command.OriginRPC, LevelRemoteManual},
+		{command.OriginAttribute, LevelRemoteManual},
+		{command.OriginController, LevelAutomatic},
+		{command.OriginRule, LevelAutomatic},
+		{command.OriginSequence, LevelAutomatic},
+		{command.OriginSchedule, LevelSchedule},
+		{"", LevelNone},
+		{"dashboard", LevelNone},
+	}
+
+	for _, tt := range tests {
+		if got := LevelOf(tt.origin); got != tt.want {
+			t.Errorf("LevelOf(%q) = %s, erwartet %s", tt.origin, got, tt.want)
+		}
+	}
+}
+
+// TestAdmitLevels prüft jedes Paar aus Besitzer und anfragendem Auslöser
+func TestAdmitLevels(t *testing.T) {
+	for _, owner := range origins {
+		for _, requester := range origins {
+			name := fmt.Sprintf("%s gegen %s", requester.level, owner.level)
+			t.Run(name, func(t *testing.T) {
+				arbiter := newTestArbiter(t, config.ArbitrationConfig{})
+				arbiter.Executed(switchOn("pump", owner.origin))
+
+				err := arbiter.Admit(switchOn("pump", requester.origin))
+				admitted := requester.level >= owner.level
+				if admitted && err != nil {
+					t.Fatalf("Befehl abgelehnt: %v", err)
+				}
+				if !admitted {
+					var overridden *OverriddenError
+					if !errors.As(err, &overridden) {
+						t.Fatalf("OverriddenError erwartet, erhalten %v", err)
+					}
+					if overridden.Owner.level != owner.level {
+						t.Errorf("Besitzer %s, erwartet %s", overridden.Owner.level, owner.level)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestAdmitUnknownOrigin prüft, dass unbekannte Auslöser auch an freien Aktoren abgelehnt werden
+func TestAdmitUnknownOrigin(t *testing.T) {
+	arbiter := newTestArbiter(t, config.ArbitrationConfig{})
+
+	if err := arbiter.Admit(switchOn("pump", "dashboard")); err == nil {
+		t.Fatalf("Befehl mit unbekanntem Auslöser zugelassen")
+	}
+
+	arbiter.Executed(switchOn("pump", command.OriginSchedule))
+	if err := arbiter.Admit(switchOn("pump", "")); err == nil {
+		t.Fatalf("Befehl ohne Auslöser gegen einen Zeitplan zugelassen")
+	}
+}
+
+// TestAdmitDisabled prüft, dass ohne Vorrangregelung alle Befehle zugelassen werden
+func TestAdmitDisabled(t *testing.T) {
+	arbiter := newTestArbiter(t, config.ArbitrationConfig{Disabled: true})
+	arbiter.Executed(switchOn("pump", command.OriginLocal))
+
+	for _, origin := range []string{command.OriginSchedule, "dashboard"} {
+		if err := arbiter.Admit(switchOn("pump", origin)); err != nil {
+			t.Errorf("Befehl von %q abgelehnt: %v", origin, err)
+		}
+	}
+}
+
+// TestShutdownReleasesAutomatic prüft, dass Ausschaltbefehle der Automatik den Aktor freigeben,
+// eine Handbedienung aber bestehen bleibt
+func TestShutdownReleasesAutomatic(t *testing.T) {
+	off := func(origin string) command.Request {
+		request := switchOn("pump", origin)
+		request.Command = types.NewCommand(types.CommandTypeSetState, false)
+		return request
+	}
+
+	tests := []struct {
+		name      string
+		origin    string
+		wantOwner bool
+	}{
+		{"Zeitplan", command.OriginSchedule, false},
+		{"Regler", command.OriginController, false},
+		{"Handbedienung aus ThingsBoard", command.OriginRPC, true},
+		{"Handbedienung vor Ort", command.OriginLocal, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arbiter := newTestArbiter(t, config.ArbitrationConfig{})
+			arbiter.Executed(switchOn("pump", tt.origin))
+			arbiter.Executed(off(tt.origin))
+
+			err := arbiter.Admit(switchOn("pump", command.OriginSchedule))
+			if owned := err != nil; owned != tt.wantOwner {
+				t.Errorf("Aktor belegt = %v, erwartet %v (%v)", owned, tt.wantOwner, err)
+			}
+		})
+	}
+}
+
+// TestOverrideExpiry prüft den Ablauf der Handbedienung mit globalen und gerätebezogenen Zeiten
+func TestOverrideExpiry(t *testing.T) {
+	cfg := config.ArbitrationConfig{
+		OverrideTimeout: config.OverrideTimeoutConfig{LocalSeconds: 3600, RemoteSeconds: 1800},
+		Devices: map[string]config.OverrideTimeoutConfig{
+			"valve": {LocalSeconds: 60, RemoteSeconds: 30},
+			"mixer": {RemoteSeconds: 120},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		deviceID string
+		origin   string
+		timeout  time.Duration
+	}{
+		{"vor Ort", "pump", command.OriginLocal, time.Hour},
+		{"ThingsBoard", "pump", command.OriginRPC, 30 * time.Minute},
+		{"Attribut", "pump", command.OriginAttribute, 30 * time.Minute},
+		{"vor Ort je Gerät", "valve", command.OriginLocal, time.Minute},
+		{"ThingsBoard je Gerät", "valve", command.OriginRPC, 30 * time.Second},
+		{"vor Ort ohne Gerätewert", "mixer", command.OriginLocal, time.Hour},
+		{"ThingsBoard mit Gerätewert", "mixer", command.OriginRPC, 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arbiter := newTestArbiter(t, cfg)
+			before := time.Now()
+			arbiter.Executed(switchOn(tt.deviceID, tt.origin))
+
+			claim := arbiter.claims[tt.deviceID]
+			if claim == nil || claim.Expires == nil {
+				t.Fatalf("Handbedienung ohne Ablauf: %+v", claim)
+			}
+			if got := claim.Expires.Sub(before); got < tt.timeout || got > tt.timeout+time.Second {
+				t.Fatalf("Ablauf nach %v, erwartet %v", got, tt.timeout)
+			}
+
+			arbiter.Expire(claim.Expires.Add(-time.Millisecond))
+			if err := arbiter.Admit(switchOn(tt.deviceID, command.OriginController)); err == nil {
+				t.Fatalf("Regler vor Ablauf zugelassen")
+			}
+
+			arbiter.Expire(*claim.Expires)
+			if err := arbiter.Admit(switchOn(tt.deviceID, command.OriginController)); err != nil {
+				t.Fatalf("Regler nach Ablauf abgelehnt: %v", err)
+			}
+		})
+	}
+}
+
+// TestOverrideWithoutExpiry prüft, dass eine Zeit von 0 die Handbedienung bis zur Freigabe hält
+func TestOverrideWithoutExpiry(t *testing.T) {
+	arbiter := newTestArbiter(t, config.ArbitrationConfig{})
+	arbiter.Executed(switchOn("pump", command.OriginLocal))
+
+	arbiter.Expire(time.Now().Add(365 * 24 * time.Hour))
+	if err := arbiter.Admit(switchOn("pump", command.OriginRPC)); err == nil {
+		t.Fatalf("Handbedienung aus ThingsBoard gegen unbefristete Handbedienung vor Ort zugelassen")
+	}
+}
+
+// TestReleaseSafety prüft, dass der sichere Zustand erst nach Behebung aller Anlässe endet
+func TestReleaseSafety(t *testing.T) {
+	arbiter := newTestArbiter(t, config.ArbitrationConfig{})
+
+	arbiter.Executed(switchOn("pump", command.OriginLocal))
+	for _, reason := range []string{"interlock_dry_run", "watchdog"} {
+		request := switchOn("pump", command.OriginFailsafe)
+		request.Issuer = reason
+		arbiter.Executed(request)
+	}
+
+	if released := arbiter.ReleaseSafety("watchdog"); len(released) != 0 {
+		t.Fatalf("freigegeben %v, erwartet keine", released)
+	}
+	if err := arbiter.Admit(switchOn("pump", command.OriginLocal)); err == nil {
+		t.Fatalf("Handbedienung vor Ort während des sicheren Zustands zugelassen")
+	}
+
+	if released := arbiter.ReleaseSafety("interlock_dry_run"); len(released) != 1 || released[0] != "pump" {
+		t.Fatalf("freigegeben %v, erwartet [pump]", released)
+	}
+	if err := arbiter.Admit(switchOn("pump", command.OriginSchedule)); err != nil {
+		t.Fatalf("Zeitplan nach Freigabe abgelehnt: %v", err)
+	}
+}
+
+// TestValidateTimeouts prüft die Ablehnung negativer Zeiten
+func TestValidateTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ArbitrationConfig
+		wantErr bool
+	}{
+		{"Standard", config.ArbitrationConfig{OverrideTimeout: config.OverrideTimeoutConfig{LocalSeconds: 3600, RemoteSeconds: 1800}}, false},
+		{"ohne Ablauf", config.ArbitrationConfig{}, false},
+		{"negativ vor Ort", config.ArbitrationConfig{OverrideTimeout: config.OverrideTimeoutConfig{LocalSeconds: -1}}, true},
+		{"negativ ThingsBoard", config.ArbitrationConfig{OverrideTimeout: config.OverrideTimeoutConfig{RemoteSeconds: -1}}, true},
+		{"negativ je Gerät", config.ArbitrationConfig{Devices: map[string]config.OverrideTimeoutConfig{"pump": {LocalSeconds: -5}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTimeouts(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTimeouts = %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
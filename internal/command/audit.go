@@ -0,0 +1,131 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AuditLog speichert Befehle als JSON Lines in einer Datei. Überschreitet die Datei die
+// Maximalgröße, wird sie nach <pfad>.1 verschoben und neu begonnen.
+type AuditLog struct {
+	path    string
+	maxSize int64
+	mutex   sync.Mutex
+}
+
+// NewAuditLog erstellt ein Audit-Log. maxSize 0 deaktiviert die Rotation.
+func NewAuditLog(path string, maxSize int64) *AuditLog {
+	return &AuditLog{path: path, maxSize: maxSize}
+}
+
+// Path gibt den Pfad der Log-Datei zurück
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// Append hängt einen Eintrag an das Log an
+func (l *AuditLog) Append(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("fehler beim Serialisieren des Audit-Eintrags: %w", err)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("fehler beim Anlegen des Audit-Verzeichnisses: %w", err)
+	}
+	if err := l.rotate(); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("fehler beim Öffnen des Audit-Logs: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("fehler beim Schreiben des Audit-Logs: %w", err)
+	}
+	return nil
+}
+
+// rotate verschiebt die Log-Datei, wenn sie die Maximalgröße erreicht hat
+func (l *AuditLog) rotate() error {
+	if l.maxSize <= 0 {
+		return nil
+	}
+
+	info, err := os.Stat(l.path)
+	if err != nil || info.Size() < l.maxSize {
+		return nil
+	}
+
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return fmt.Errorf("fehler beim Rotieren des Audit-Logs: %w", err)
+	}
+	return nil
+}
+
+// Recent gibt die neuesten Einträge zurück, neueste zuerst. Ist deviceID gesetzt, werden
+// nur Befehle an dieses Gerät berücksichtigt.
+func (l *AuditLog) Recent(limit int, deviceID string) ([]Record, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	records := []Record{}
+	for _, path := range []string{l.path, l.path + ".1"} {
+		entries, err := readRecords(path, deviceID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Neueste Einträge stehen am Ende der Datei
+		for i := len(entries) - 1; i >= 0 && len(records) < limit; i-- {
+			records = append(records, entries[i])
+		}
+		if len(records) >= limit {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// readRecords liest alle Einträge einer Log-Datei. Eine fehlende Datei ist leer,
+// unlesbare Zeilen (z.B. nach einem Stromausfall) werden übersprungen.
+func readRecords(path, deviceID string) ([]Record, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Öffnen des Audit-Logs: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if deviceID != "" && record.DeviceID != deviceID {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen des Audit-Logs: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,86 @@
+// Package command führt Steuerbefehle an Aktoren nacheinander je Gerät aus. Jeder Befehl
+// erhält ein Zeitlimit, wird durch Zurücklesen des Zustands bestätigt und mit Auslöser,
+// Parametern, Ergebnis und Dauer im Audit-Log protokolliert.
+package command
+
+import (
+	"time"
+
+	"owipex_reader/internal/types"
+)
+
+// Auslöser eines Befehls
+const (
+	OriginRPC        = "rpc"
+	OriginAttribute  = "attribute"
+	OriginController = "controller"
+	OriginLocal      = "local"
+	OriginFailsafe   = "failsafe"
+	OriginRule       = "rule"
+	OriginSchedule   = "schedule"
+	OriginSequence   = "sequence"
+)
+
+// Status ist das Ergebnis eines Befehls
+type Status string
+
+const (
+	// StatusSucceeded: Der Befehl wurde ausgeführt und der Zustand bestätigt
+	StatusSucceeded Status = "succeeded"
+	// StatusPending: Der Befehl wurde ausgeführt, das Gerät hat den Zielzustand innerhalb des
+	// Zeitlimits aber noch nicht erreicht (z.B. Fahrt eines Ventils) und überwacht ihn weiter
+	StatusPending Status = "pending"
+	// StatusFailed: Das Gerät hat den Befehl abgelehnt oder den Zustand nicht bestätigt
+	StatusFailed Status = "failed"
+	// StatusTimeout: Der Befehl wurde nicht innerhalb des Zeitlimits abgeschlossen
+	StatusTimeout Status = "timeout"
+	// StatusRejected: Der Befehl wurde nicht ausgeführt (unbekanntes Gerät, Warteschlange voll,
+	// Sicherheitsverriegelung)
+	StatusRejected Status = "rejected"
+)
+
+// Request ist ein Befehl an ein Gerät
+type Request struct {
+	DeviceID string
+	Command  types.Command
+
+	// Origin ist die Art des Auslösers (OriginRPC, OriginController, ...), Issuer der
+	// Auslöser selbst (z.B. Name des Reglers oder der RPC-Methode)
+	Origin string
+	Issuer string
+
+	// Timeout begrenzt Ausführung und Bestätigung (0 = Standard des Managers)
+	Timeout time.Duration
+}
+
+// Arbiter entscheidet, welcher Auslöser ein Gerät gerade steuern darf (z.B. Vorrang einer
+// Handbedienung vor dem Regler). Admit wird unmittelbar vor der Ausführung aufgerufen, ein
+// Fehler lehnt den Befehl ab. Executed meldet einen erfolgreich ausgeführten Befehl.
+type Arbiter interface {
+	Admit(request Request) error
+	Executed(request Request)
+}
+
+// Record ist der Eintrag eines Befehls im Audit-Log
+type Record struct {
+	ID         string                 `json:"id"`
+	DeviceID   string                 `json:"device_id"`
+	Command    types.CommandType      `json:"command"`
+	Value      interface{}            `json:"value,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Origin     string                 `json:"origin"`
+	Issuer     string                 `json:"issuer,omitempty"`
+	Status     Status                 `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	State      interface{}            `json:"state,omitempty"`
+	QueuedAt   time.Time              `json:"queued_at"`
+	StartedAt  time.Time              `json:"started_at,omitempty"`
+	FinishedAt time.Time              `json:"finished_at"`
+	DurationMS int64                  `json:"duration_ms"`
+}
+
+// Succeeded prüft, ob der Befehl erfolgreich ausgeführt wurde. Befehle, deren Zielzustand noch
+// aussteht (StatusPending), gelten als ausgeführt.
+func (r Record) Succeeded() bool {
+	return r.Status == StatusSucceeded || r.Status == StatusPending
+}
@@ -0,0 +1,370 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/interlock"
+	"owipex_reader/internal/types"
+)
+
+// Standardwerte des Managers
+const (
+	DefaultTimeout   = 10 * time.Second
+	DefaultQueueSize = 16
+)
+
+// Manager führt Befehle je Gerät nacheinander aus. Befehle an verschiedene Geräte laufen
+// parallel, Befehle an dasselbe Gerät in der Reihenfolge ihres Eingangs.
+type Manager struct {
+	registry       *device.Registry
+	audit          *AuditLog
+	logger         *log.Logger
+	defaultTimeout time.Duration
+	queueSize      int
+
+	mutex   sync.Mutex
+	queues  map[string]chan *job
+	stopped bool
+
+	// arbiter entscheidet zwischen den Auslösern eines Geräts (siehe SetArbiter)
+	arbiter      Arbiter
+	arbiterMutex sync.RWMutex
+
+	counter  uint64
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Zustände eines Befehls in der Warteschlange (job.state)
+const (
+	jobQueued int32 = iota
+	jobStarted
+	jobExpired
+)
+
+// job ist ein wartender Befehl
+type job struct {
+	request  Request
+	record   Record
+	deadline time.Time
+	done     chan Record
+
+	// state entscheidet, ob der Worker den Befehl ausführt oder Submit ihn nach Ablauf des
+	// Zeitlimits abschließt. Nur wer ihn aus jobQueued umsetzt, ruft finish auf.
+	state int32
+}
+
+// claim übernimmt einen wartenden Befehl. Gibt false zurück, wenn ihn bereits Submit oder
+// ein Worker übernommen hat.
+func (j *job) claim(state int32) bool {
+	return atomic.CompareAndSwapInt32(&j.state, jobQueued, state)
+}
+
+// outcome ist das Ergebnis von Write und Zustandsabfrage
+type outcome struct {
+	state interface{}
+	err   error
+}
+
+// NewManager erstellt einen Manager. audit darf nil sein, dann werden Befehle nur geloggt.
+func NewManager(registry *device.Registry, audit *AuditLog, defaultTimeout time.Duration, queueSize int) *Manager {
+	if defaultTimeout <= 0 {
+		defaultTimeout = DefaultTimeout
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	return &Manager{
+		registry:       registry,
+		audit:          audit,
+		logger:         log.New(os.Stdout, "[Command] ", log.LstdFlags),
+		defaultTimeout: defaultTimeout,
+		queueSize:      queueSize,
+		queues:         make(map[string]chan *job),
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// SetArbiter setzt die Vorrangregelung, die jeder Befehl unmittelbar vor der Ausführung
+// durchläuft. nil entfernt sie.
+func (m *Manager) SetArbiter(arbiter Arbiter) {
+	m.arbiterMutex.Lock()
+	defer m.arbiterMutex.Unlock()
+	m.arbiter = arbiter
+}
+
+// currentArbiter gibt die aktuelle Vorrangregelung zurück
+func (m *Manager) currentArbiter() Arbiter {
+	m.arbiterMutex.RLock()
+	defer m.arbiterMutex.RUnlock()
+	return m.arbiter
+}
+
+// AuditLog gibt das Audit-Log zurück (nil, wenn deaktiviert)
+func (m *Manager) AuditLog() *AuditLog {
+	return m.audit
+}
+
+// Submit stellt einen Befehl in die Warteschlange des Geräts und wartet auf das Ergebnis.
+// Das Zeitlimit gilt ab dem Einreihen: Ein Befehl, der zu lange gewartet hat, wird nicht
+// mehr ausgeführt.
+func (m *Manager) Submit(request Request) Record {
+	timeout := request.Timeout
+	if timeout <= 0 {
+		timeout = m.defaultTimeout
+	}
+
+	now := time.Now()
+	j := &job{
+		request:  request,
+		deadline: now.Add(timeout),
+		done:     make(chan Record, 1),
+		record: Record{
+			ID:         fmt.Sprintf("cmd-%d-%d", now.Unix(), atomic.AddUint64(&m.counter, 1)),
+			DeviceID:   request.DeviceID,
+			Command:    request.Command.Type,
+			Value:      request.Command.Value,
+			Parameters: request.Command.Parameters,
+			Origin:     request.Origin,
+			Issuer:     request.Issuer,
+			QueuedAt:   now,
+		},
+	}
+
+	if _, err := m.writableDevice(request.DeviceID); err != nil {
+		return m.finish(j, StatusRejected, nil, err)
+	}
+
+	queue, err := m.queue(request.DeviceID)
+	if err != nil {
+		return m.finish(j, StatusRejected, nil, err)
+	}
+
+	select {
+	case queue <- j:
+	default:
+		return m.finish(j, StatusRejected, nil, fmt.Errorf("warteschlange von %s ist voll (%d Befehle)", request.DeviceID, m.queueSize))
+	}
+
+	timer := time.NewTimer(time.Until(j.deadline))
+	defer timer.Stop()
+
+	select {
+	case record := <-j.done:
+		return record
+	case <-timer.C:
+		// Das Gerät ist noch mit einem früheren Befehl beschäftigt. Der Befehl wird nicht mehr
+		// ausgeführt, der Worker überspringt ihn.
+		if j.claim(jobExpired) {
+			return m.finish(j, StatusTimeout, nil, fmt.Errorf("zeitlimit abgelaufen, das Gerät bearbeitet noch einen früheren Befehl"))
+		}
+		// Der Befehl läuft bereits und wird spätestens mit Ablauf seines Kontexts abgeschlossen
+		return <-j.done
+	}
+}
+
+// Stop beendet alle Warteschlangen. Laufende Befehle werden abgeschlossen, wartende abgelehnt.
+func (m *Manager) Stop() {
+	m.mutex.Lock()
+	if m.stopped {
+		m.mutex.Unlock()
+		return
+	}
+	m.stopped = true
+	close(m.stopChan)
+	m.mutex.Unlock()
+
+	m.wg.Wait()
+}
+
+// queue gibt die Warteschlange eines Geräts zurück und startet bei Bedarf deren Worker
+func (m *Manager) queue(deviceID string) (chan *job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.stopped {
+		return nil, fmt.Errorf("befehlsverwaltung wurde beendet")
+	}
+
+	queue, exists := m.queues[deviceID]
+	if !exists {
+		queue = make(chan *job, m.queueSize)
+		m.queues[deviceID] = queue
+
+		m.wg.Add(1)
+		go m.worker(queue)
+	}
+	return queue, nil
+}
+
+// worker führt die Befehle einer Warteschlange nacheinander aus
+func (m *Manager) worker(queue chan *job) {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.stopChan:
+			for {
+				select {
+				case j := <-queue:
+					if j.claim(jobExpired) {
+						m.finish(j, StatusRejected, nil, fmt.Errorf("befehlsverwaltung wurde beendet"))
+					}
+				default:
+					return
+				}
+			}
+		case j := <-queue:
+			m.execute(j)
+		}
+	}
+}
+
+// execute führt einen Befehl aus und bestätigt ihn durch Zurücklesen des Zustands. Befehle,
+// die Submit nach Ablauf des Zeitlimits bereits abgeschlossen hat, werden übersprungen.
+func (m *Manager) execute(j *job) {
+	if !j.claim(jobStarted) {
+		return
+	}
+	if time.Now().After(j.deadline) {
+		m.finish(j, StatusTimeout, nil, fmt.Errorf("zeitlimit in der Warteschlange abgelaufen"))
+		return
+	}
+
+	writable, err := m.writableDevice(j.request.DeviceID)
+	if err != nil {
+		m.finish(j, StatusRejected, nil, err)
+		return
+	}
+
+	// Die Vorrangregelung wird im Worker des Geräts geprüft, damit sie die Befehle in der
+	// Reihenfolge ihrer Ausführung sieht
+	arbiter := m.currentArbiter()
+	if arbiter != nil {
+		if err := arbiter.Admit(j.request); err != nil {
+			m.finish(j, StatusRejected, nil, err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), j.deadline)
+	defer cancel()
+	if j.request.Origin == OriginFailsafe {
+		// Sichere Zustände dürfen nicht an einer Verriegelung scheitern
+		ctx = device.WithoutGuard(ctx)
+	}
+
+	j.record.StartedAt = time.Now()
+	result := make(chan outcome, 1)
+	go func() {
+		var o outcome
+		o.err = writable.Write(ctx, j.request.Command)
+		if o.err == nil || errors.Is(o.err, types.ErrPending) {
+			// Aktoren melden eine Abweichung vom befohlenen Zustand als Fehler. Das Zurücklesen
+			// läuft im Kontext des Befehls und endet mit dessen Zeitlimit. Steht der Zielzustand
+			// noch aus, zeigt der zurückgelesene Zustand den Fortschritt.
+			var state interface{}
+			var err error
+			if reader, ok := writable.(types.StateReader); ok {
+				state, err = reader.GetStateContext(ctx)
+			} else if actor, ok := writable.(types.Actor); ok {
+				state, err = actor.GetState()
+			}
+			o.state = state
+			if err != nil || o.err == nil {
+				o.err = err
+			}
+		}
+		result <- o
+	}()
+
+	select {
+	case o := <-result:
+		var blocked *interlock.BlockedError
+		status := StatusSucceeded
+		if errors.As(o.err, &blocked) {
+			status = StatusRejected
+		} else if errors.Is(o.err, types.ErrPending) {
+			status = StatusPending
+		} else if errors.Is(o.err, context.DeadlineExceeded) {
+			status = StatusTimeout
+		} else if o.err != nil {
+			status = StatusFailed
+		}
+		record := m.finish(j, status, o.state, o.err)
+		if arbiter != nil && record.Succeeded() {
+			arbiter.Executed(j.request)
+		}
+
+	case <-ctx.Done():
+		m.finish(j, StatusTimeout, nil, fmt.Errorf("keine Bestätigung innerhalb des Zeitlimits"))
+
+		// Der nächste Befehl an das Gerät darf erst nach Ende des laufenden Zugriffs starten
+		o := <-result
+		m.logger.Printf("Befehl %s an %s nach Zeitüberschreitung beendet: %v", j.record.ID, j.request.DeviceID, o.err)
+	}
+}
+
+// writableDevice sucht ein steuerbares Gerät in der Registry
+func (m *Manager) writableDevice(deviceID string) (types.WritableDevice, error) {
+	dev, err := m.registry.GetDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	writable, ok := dev.(types.WritableDevice)
+	if !ok {
+		return nil, fmt.Errorf("gerät %s nimmt keine Befehle an", deviceID)
+	}
+	return writable, nil
+}
+
+// finish schließt den Eintrag eines Befehls ab, protokolliert ihn und meldet das Ergebnis an
+// Submit und auf dem Event-Bus
+func (m *Manager) finish(j *job, status Status, state interface{}, err error) Record {
+	record := j.record
+	record.Status = status
+	record.State = state
+	record.FinishedAt = time.Now()
+	record.DurationMS = record.FinishedAt.Sub(record.QueuedAt).Milliseconds()
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if record.Status == StatusPending {
+		m.logger.Printf("Befehl %s (%s) an %s von %s/%s ausgeführt, Zielzustand noch nicht erreicht: %s", record.ID, record.Command, record.DeviceID, record.Origin, record.Issuer, record.Error)
+	} else if record.Succeeded() {
+		m.logger.Printf("Befehl %s (%s) an %s von %s/%s ausgeführt in %d ms", record.ID, record.Command, record.DeviceID, record.Origin, record.Issuer, record.DurationMS)
+	} else {
+		m.logger.Printf("Befehl %s (%s) an %s von %s/%s: %s: %s", record.ID, record.Command, record.DeviceID, record.Origin, record.Issuer, record.Status, record.Error)
+	}
+
+	if m.audit != nil {
+		if err := m.audit.Append(record); err != nil {
+			m.logger.Printf("Fehler beim Schreiben des Audit-Logs: %v", err)
+		}
+	}
+
+	// Ergebnis und zurückgelesenen Zustand melden (z.B. für die Schaltspiele der Wartung)
+	m.registry.EventBus().Publish(event.NewCommandAcknowledgedEvent(event.CommandAcknowledged{
+		CommandID: record.ID,
+		DeviceID:  record.DeviceID,
+		Method:    string(record.Command),
+		Success:   record.Succeeded(),
+		Result:    record.Status,
+		Err:       err,
+		State:     record.State,
+	}, record.Origin))
+
+	j.done <- record
+	return record
+}
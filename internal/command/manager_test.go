@@ -0,0 +1,316 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/interlock"
+	"owipex_reader/internal/types"
+)
+
+// testActor ist ein Aktor, dessen Schreiben und Zurücklesen der Test vorgibt
+type testActor struct {
+	id    string
+	write func(ctx context.Context, cmd types.Command) error
+	read  func(ctx context.Context) (interface{}, error)
+
+	mutex    sync.Mutex
+	commands []types.Command
+}
+
+// ID gibt die Kennung des Aktors zurück
+func (a *testActor) ID() string { return a.id }
+
+// Name gibt die Kennung als Anzeigenamen zurück
+func (a *testActor) Name() string { return a.id }
+
+// Type gibt den Gerätetyp zurück
+func (a *testActor) Type() types.DeviceType { return types.TypeActor }
+
+// Metadata gibt keine Metadaten zurück
+func (a *testActor) Metadata() map[string]interface{} { return nil }
+
+// IsEnabled meldet den Aktor als aktiviert
+func (a *testActor) IsEnabled() bool { return true }
+
+// Enable wird ignoriert
+func (a *testActor) Enable(bool) {}
+
+// Close wird ignoriert
+func (a *testActor) Close() error { return nil }
+
+// Write zeichnet den Befehl auf und führt die Schreibfunktion des Tests aus
+func (a *testActor) Write(ctx context.Context, cmd types.Command) error {
+	a.mutex.Lock()
+	a.commands = append(a.commands, cmd)
+	a.mutex.Unlock()
+
+	if a.write != nil {
+		return a.write(ctx, cmd)
+	}
+	return nil
+}
+
+// WriteRaw wird nicht unterstützt
+func (a *testActor) WriteRaw(ctx context.Context, data []byte) error {
+	return errors.New("nicht unterstützt")
+}
+
+// AvailableCommands gibt SET_STATE zurück
+func (a *testActor) AvailableCommands() []types.CommandType {
+	return []types.CommandType{types.CommandTypeSetState}
+}
+
+// GetState liest ohne Kontext zurück; der Manager soll GetStateContext verwenden
+func (a *testActor) GetState() (interface{}, error) {
+	return nil, errors.New("GetState ohne Kontext aufgerufen")
+}
+
+// GetStateContext führt die Lesefunktion des Tests aus (types.StateReader)
+func (a *testActor) GetStateContext(ctx context.Context) (interface{}, error) {
+	if a.read != nil {
+		return a.read(ctx)
+	}
+	return true, nil
+}
+
+// written gibt die Anzahl ausgeführter Befehle zurück
+func (a *testActor) written() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return len(a.commands)
+}
+
+// blockingGuard sperrt jeden Befehl wie eine aktive Verriegelung
+type blockingGuard struct{}
+
+// CheckCommand lehnt jeden Befehl mit einem BlockedError ab
+func (blockingGuard) CheckCommand(deviceID string, cmd types.Command) error {
+	return &interlock.BlockedError{DeviceID: deviceID, Command: cmd.Type, Interlock: "dry_run", Reason: "kein Wasser"}
+}
+
+// newTestManager erstellt einen Manager mit Audit-Log für einen Aktor
+func newTestManager(t *testing.T, actor *testActor, guard device.CommandGuard) (*Manager, *AuditLog) {
+	t.Helper()
+
+	registry := device.NewRegistry()
+	registry.SetCommandGuard(guard)
+	if err := registry.AddDevice(actor); err != nil {
+		t.Fatal(err)
+	}
+	audit := NewAuditLog(filepath.Join(t.TempDir(), "commands.jsonl"), 0)
+	m := NewManager(registry, audit, time.Second, 0)
+
+	t.Cleanup(func() {
+		m.Stop()
+		registry.Close()
+	})
+	return m, audit
+}
+
+// TestSubmit prüft Ergebnis und Status einzelner Befehle
+func TestSubmit(t *testing.T) {
+	tests := []struct {
+		name       string
+		deviceID   string
+		write      func(ctx context.Context, cmd types.Command) error
+		read       func(ctx context.Context) (interface{}, error)
+		guard      device.CommandGuard
+		wantStatus Status
+		wantState  interface{}
+	}{
+		{
+			name: "ausgeführt und im Kontext des Befehls zurückgelesen",
+			read: func(ctx context.Context) (interface{}, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					return nil, errors.New("zurücklesen ohne Zeitlimit")
+				}
+				return true, nil
+			},
+			wantStatus: StatusSucceeded,
+			wantState:  true,
+		},
+		{name: "unbekanntes Gerät", deviceID: "unknown", wantStatus: StatusRejected},
+		{name: "gesperrt durch Verriegelung", guard: blockingGuard{}, wantStatus: StatusRejected},
+		{
+			name:       "Schreibfehler",
+			write:      func(context.Context, types.Command) error { return errors.New("keine Antwort") },
+			wantStatus: StatusFailed,
+		},
+		{
+			name:       "Zustand weicht ab",
+			read:       func(context.Context) (interface{}, error) { return false, errors.New("zustand false statt true") },
+			wantStatus: StatusFailed,
+			wantState:  false,
+		},
+		{
+			name: "Zielzustand steht noch aus",
+			write: func(context.Context, types.Command) error {
+				return fmt.Errorf("%w: ventil fährt noch", types.ErrPending)
+			},
+			read:       func(context.Context) (interface{}, error) { return 40.0, nil },
+			wantStatus: StatusPending,
+			wantState:  40.0,
+		},
+		{
+			name: "Zielzustand steht aus, Zurücklesen schlägt fehl",
+			write: func(context.Context, types.Command) error {
+				return fmt.Errorf("%w: ventil fährt noch", types.ErrPending)
+			},
+			read:       func(context.Context) (interface{}, error) { return nil, errors.New("keine Antwort") },
+			wantStatus: StatusFailed,
+		},
+		{
+			name: "Zurücklesen überschreitet das Zeitlimit",
+			read: func(ctx context.Context) (interface{}, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wantStatus: StatusTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor := &testActor{id: "pump", write: tt.write, read: tt.read}
+			m, audit := newTestManager(t, actor, tt.guard)
+
+			deviceID := tt.deviceID
+			if deviceID == "" {
+				deviceID = actor.id
+			}
+			record := m.Submit(Request{
+				DeviceID: deviceID,
+				Command:  types.NewCommand(types.CommandTypeSetState, true),
+				Origin:   OriginController,
+				Timeout:  50 * time.Millisecond,
+			})
+			if record.Status != tt.wantStatus {
+				t.Fatalf("Status %s (%s), erwartet %s", record.Status, record.Error, tt.wantStatus)
+			}
+			if record.State != tt.wantState {
+				t.Errorf("Zustand %v, erwartet %v", record.State, tt.wantState)
+			}
+
+			logged, err := audit.Recent(10, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(logged) != 1 || logged[0].ID != record.ID || logged[0].Status != record.Status {
+				t.Errorf("Audit-Log %+v, erwartet genau den Eintrag %s mit Status %s", logged, record.ID, record.Status)
+			}
+		})
+	}
+}
+
+// TestSubmitQueuedTimeout prüft, dass ein Befehl hinter einem laufenden Befehl nach Ablauf
+// seines Zeitlimits genau einmal als Zeitüberschreitung protokolliert und nicht mehr ausgeführt wird
+func TestSubmitQueuedTimeout(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	actor := &testActor{id: "pump", write: func(ctx context.Context, cmd types.Command) error {
+		if cmd.Value == "slow" {
+			close(started)
+			<-release
+		}
+		return nil
+	}}
+	m, audit := newTestManager(t, actor, nil)
+
+	slow := make(chan Record, 1)
+	go func() {
+		slow <- m.Submit(Request{DeviceID: "pump", Command: types.NewCommand(types.CommandTypeSetState, "slow"), Origin: OriginController})
+	}()
+	<-started
+
+	begin := time.Now()
+	queued := m.Submit(Request{
+		DeviceID: "pump",
+		Command:  types.NewCommand(types.CommandTypeSetState, true),
+		Origin:   OriginRule,
+		Timeout:  30 * time.Millisecond,
+	})
+	if queued.Status != StatusTimeout {
+		t.Fatalf("Status %s, erwartet %s", queued.Status, StatusTimeout)
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Fatalf("Submit kehrte erst nach %v zurück", elapsed)
+	}
+
+	close(release)
+	if record := <-slow; !record.Succeeded() {
+		t.Fatalf("langsamer Befehl: %s (%s)", record.Status, record.Error)
+	}
+
+	// Der Worker überspringt den abgelaufenen Befehl
+	follow := m.Submit(Request{DeviceID: "pump", Command: types.NewCommand(types.CommandTypeSetState, false), Origin: OriginRule})
+	if !follow.Succeeded() {
+		t.Fatalf("folgender Befehl: %s (%s)", follow.Status, follow.Error)
+	}
+	if n := actor.written(); n != 2 {
+		t.Errorf("%d Befehle geschrieben, erwartet 2", n)
+	}
+
+	logged, err := audit.Recent(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, record := range logged {
+		if record.ID == queued.ID {
+			count++
+			if record.Status != StatusTimeout {
+				t.Errorf("protokollierter Status %s, erwartet %s", record.Status, StatusTimeout)
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("abgelaufener Befehl %d-mal protokolliert, erwartet einmal", count)
+	}
+}
+
+// TestSubmitFailsafeBypassesGuard prüft, dass Befehle des Failsafe-Managers nicht an einer
+// Verriegelung scheitern
+func TestSubmitFailsafeBypassesGuard(t *testing.T) {
+	actor := &testActor{id: "pump"}
+	m, _ := newTestManager(t, actor, blockingGuard{})
+
+	record := m.Submit(Request{DeviceID: "pump", Command: types.NewCommand(types.CommandTypeSetState, false), Origin: OriginFailsafe})
+	if !record.Succeeded() {
+		t.Fatalf("sicherer Zustand: %s (%s)", record.Status, record.Error)
+	}
+}
+
+// TestSubmitPublishesResult prüft, dass das Ergebnis mit dem zurückgelesenen Zustand auf dem
+// Event-Bus gemeldet wird
+func TestSubmitPublishesResult(t *testing.T) {
+	actor := &testActor{id: "pump"}
+	m, _ := newTestManager(t, actor, nil)
+
+	acks := make(chan event.CommandAcknowledged, 1)
+	err := m.registry.EventBus().Subscribe("test", func(e event.Event) {
+		if ack, ok := e.Payload.(event.CommandAcknowledged); ok {
+			acks <- ack
+		}
+	}, event.SubscriberOptions{}, event.TopicCommandAcknowledged)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := m.Submit(Request{DeviceID: "pump", Command: types.NewCommand(types.CommandTypeSetState, true), Origin: OriginRule})
+	select {
+	case ack := <-acks:
+		if ack.CommandID != record.ID || ack.DeviceID != "pump" || !ack.Success || ack.State != true {
+			t.Errorf("Quittung %+v passt nicht zu %+v", ack, record)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("keine Quittung auf dem Event-Bus")
+	}
+}
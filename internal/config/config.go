@@ -46,12 +46,587 @@ type ThingsBoardConfig struct {
 	AccessToken string `json:"access_token"`
 }
 
+// ConnectivityConfig defines when a device is reported as degraded or offline
+// and how often an offline device is retried
+type ConnectivityConfig struct {
+	DegradedAfterFailures int `json:"degraded_after_failures"`
+	OfflineAfterFailures  int `json:"offline_after_failures"`
+	RecoverAfterSuccesses int `json:"recover_after_successes"`
+	BackoffInitialSeconds int `json:"backoff_initial_seconds"`
+	BackoffMaxSeconds     int `json:"backoff_max_seconds"`
+}
+
+// InterlockCondition is a single condition of an interlock. It compares a value of a device
+// (latest reading, metadata field or actor state) or checks the device connectivity.
+type InterlockCondition struct {
+	Device string `json:"device"`
+
+	// Field selects the compared value: "" or "value" for the main reading value,
+	// "state" for whether an actor is running (true/false), a reading metadata key
+	// (e.g. "temperature") or a field of the actor state (e.g. "direction")
+	Field string `json:"field,omitempty"`
+
+	// Operator is one of >, >=, <, <=, ==, != and compares the field with Value
+	Operator string      `json:"operator,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+
+	// Connectivity requires a connectivity state ("online", "degraded", "connected" for
+	// online or degraded, ...) instead of comparing a value
+	Connectivity string `json:"connectivity,omitempty"`
+}
+
+// InterlockConfig defines a safety interlock: commands to the listed devices are only
+// executed while all conditions hold. Switching a device off is never blocked.
+type InterlockConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Devices are the protected devices
+	Devices []string `json:"devices"`
+
+	// Commands restricts the interlock to these command types (empty = all)
+	Commands []string `json:"commands,omitempty"`
+
+	Conditions []InterlockCondition `json:"conditions"`
+
+	// MaxAgeSeconds is the maximum age of a reading used in a condition; older readings
+	// fail the condition (0 = default of 300 seconds)
+	MaxAgeSeconds int `json:"max_age_seconds,omitempty"`
+}
+
+// CommandConfig defines how commands to actuators are queued, timed out and audited
+type CommandConfig struct {
+	// TimeoutSeconds is the default time a command may take including the state readback
+	TimeoutSeconds int `json:"timeout_seconds"`
+
+	// QueueSize is the number of commands that may wait per device
+	QueueSize int `json:"queue_size"`
+
+	// AuditLogPath is the JSON Lines file every command is recorded in (empty disables the log)
+	AuditLogPath string `json:"audit_log_path"`
+
+	// AuditLogMaxSizeKB rotates the audit log to <path>.1 when it grows beyond this size
+	AuditLogMaxSizeKB int `json:"audit_log_max_size_kb"`
+}
+
+// FailsafeConfig defines when actuators are put into their safe state besides shutdown
+type FailsafeConfig struct {
+	// ConnectionLossTimeoutSeconds applies the safe state of actors with on_connection_loss
+	// after ThingsBoard has been unreachable this long (0 disables)
+	ConnectionLossTimeoutSeconds int `json:"connection_loss_timeout_seconds"`
+
+	// WatchdogTimeoutSeconds applies all safe states when an acquisition task or a
+	// controller loop is overdue by this long beyond its interval (0 disables)
+	WatchdogTimeoutSeconds int `json:"watchdog_timeout_seconds"`
+}
+
+// PHControlConfig configures the automatic pH neutralization: CO2 dosing (and heating of the
+// CO2 supply) while the pH is above target + tolerance, pumping while it is within the window
+// and switching the plant off when the pH stays out of range for too long. Target, tolerance,
+// stop value and delays can be changed at runtime with the shared attributes of the legacy
+// system (targetPHValue, targetPHtolerrance, minimumPHValStop, ph_high_delay_duration,
+// ph_low_delay_duration).
+type PHControlConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Sensor is the ID of the pH sensor
+	Sensor string `json:"sensor"`
+
+	// CO2Valve, Heating and Pump are the IDs of the switched actors (Heating is optional)
+	CO2Valve string `json:"co2_valve"`
+	Heating  string `json:"heating,omitempty"`
+	Pump     string `json:"pump"`
+
+	TargetPH  float64 `json:"target_ph"`
+	Tolerance float64 `json:"tolerance"`
+
+	// StopPH switches the plant off immediately when the pH falls below it
+	StopPH float64 `json:"stop_ph"`
+
+	// HighDelaySeconds and LowDelaySeconds are the times the pH may stay above or below the
+	// window before the plant is switched off
+	HighDelaySeconds int `json:"high_delay_seconds"`
+	LowDelaySeconds  int `json:"low_delay_seconds"`
+
+	// IntervalSeconds is the cycle time of the control loop
+	IntervalSeconds int `json:"interval_seconds"`
+
+	// MaxReadingAgeSeconds is the maximum age of the pH reading; older readings are a
+	// controller fault and put the actors into their safe state
+	MaxReadingAgeSeconds int `json:"max_reading_age_seconds"`
+
+	// CalibrationHighPH and CalibrationLowPH are the buffer solutions of the two-point
+	// calibration triggered by the shared attribute calibratePH
+	CalibrationHighPH float64 `json:"calibration_high_ph"`
+	CalibrationLowPH  float64 `json:"calibration_low_ph"`
+}
+
+// DefaultPHControlConfig returns the defaults of the pH control used when app.json omits a field
+func DefaultPHControlConfig() PHControlConfig {
+	return PHControlConfig{
+		TargetPH:             7.0,
+		Tolerance:            0.5,
+		StopPH:               5.0,
+		HighDelaySeconds:     600,
+		LowDelaySeconds:      600,
+		IntervalSeconds:      5,
+		MaxReadingAgeSeconds: 60,
+		CalibrationHighPH:    10,
+		CalibrationLowPH:     7,
+	}
+}
+
+// PID output modes
+const (
+	// PIDOutputPosition sends the output as SET_POSITION (e.g. VFD frequency, valve position)
+	PIDOutputPosition = "position"
+	// PIDOutputPWM switches the actor with SET_STATE, on for output percent of each PWM period
+	PIDOutputPWM = "pwm"
+)
+
+// PID modes
+const (
+	PIDModeAuto   = "auto"
+	PIDModeManual = "manual"
+)
+
+// PIDConfig configures a PID control loop between a sensor and an actor. Setpoint, mode,
+// manual output, tuning and limits can be changed at runtime with the shared attributes
+// <id>_setpoint, <id>_mode, <id>_manual_output, <id>_kp, <id>_ki, <id>_kd, <id>_output_min,
+// <id>_output_max and <id>_rate_limit.
+type PIDConfig struct {
+	ID string `json:"id"`
+
+	// Sensor is the ID of the device providing the process value, Field optionally selects a
+	// metadata value of its reading instead of the main value
+	Sensor string `json:"sensor"`
+	Field  string `json:"field,omitempty"`
+
+	// Actor is the ID of the driven actor, Output how it is driven (position or pwm)
+	Actor  string `json:"actor"`
+	Output string `json:"output"`
+
+	// Parameters are passed with every SET_POSITION command (e.g. {"unit": "percent"})
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+
+	Setpoint float64 `json:"setpoint"`
+
+	// Mode is the mode after start (auto or manual), ManualOutput the output in manual mode
+	Mode         string  `json:"mode"`
+	ManualOutput float64 `json:"manual_output"`
+
+	Kp float64 `json:"kp"`
+	Ki float64 `json:"ki"`
+	Kd float64 `json:"kd"`
+
+	// Reverse makes the loop reverse acting: the output rises while the process value is above
+	// the setpoint (e.g. dosing acid to lower the pH)
+	Reverse bool `json:"reverse"`
+
+	// OutputMin and OutputMax limit the output (both 0 = 0-100)
+	OutputMin float64 `json:"output_min"`
+	OutputMax float64 `json:"output_max"`
+
+	// RateLimit is the maximum output change per second (0 = unlimited)
+	RateLimit float64 `json:"rate_limit"`
+
+	// OutputDeadband is the minimum change of a position output before a new command is sent
+	OutputDeadband float64 `json:"output_deadband"`
+
+	// IntervalSeconds is the sample time of the loop
+	IntervalSeconds int `json:"interval_seconds"`
+
+	// PWMPeriodSeconds is the PWM period, PWMMinPulseSeconds the shortest on or off time
+	PWMPeriodSeconds   int `json:"pwm_period_seconds"`
+	PWMMinPulseSeconds int `json:"pwm_min_pulse_seconds"`
+
+	// MaxReadingAgeSeconds is the maximum age of the process value; older readings are a
+	// controller fault and put the actor into its safe state
+	MaxReadingAgeSeconds int `json:"max_reading_age_seconds"`
+}
+
+// SetDefaults fills the fields app.json omitted
+func (c *PIDConfig) SetDefaults() {
+	if c.Output == "" {
+		c.Output = PIDOutputPosition
+	}
+	if c.Mode == "" {
+		c.Mode = PIDModeAuto
+	}
+	if c.OutputMin == 0 && c.OutputMax == 0 {
+		c.OutputMax = 100
+	}
+	if c.OutputDeadband == 0 {
+		c.OutputDeadband = 0.5
+	}
+	if c.IntervalSeconds == 0 {
+		c.IntervalSeconds = 5
+	}
+	if c.PWMPeriodSeconds == 0 {
+		c.PWMPeriodSeconds = 60
+	}
+	if c.PWMMinPulseSeconds == 0 {
+		c.PWMMinPulseSeconds = 2
+	}
+	if c.MaxReadingAgeSeconds == 0 {
+		c.MaxReadingAgeSeconds = 60
+	}
+}
+
+// ControllersConfig holds the configuration of the built-in control loops
+type ControllersConfig struct {
+	PH  PHControlConfig `json:"ph"`
+	PID []PIDConfig     `json:"pid"`
+}
+
+// RuleCondition is a condition of a local rule. Exactly one of All, Any, Device, Attribute
+// or TimeWindow is set; All and Any combine nested conditions with AND and OR.
+type RuleCondition struct {
+	All []RuleCondition `json:"all,omitempty"`
+	Any []RuleCondition `json:"any,omitempty"`
+
+	// Device compares a value of a device like an interlock condition: Field "" or "value" for
+	// the main reading value, "state" for the actor state or a reading metadata key
+	Device string `json:"device,omitempty"`
+	Field  string `json:"field,omitempty"`
+
+	// Attribute compares the last known value of a shared attribute
+	Attribute string `json:"attribute,omitempty"`
+
+	// Operator is one of >, >=, <, <=, ==, != and compares the value with Value
+	Operator string      `json:"operator,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+
+	// Hysteresis keeps a fulfilled threshold condition fulfilled until the value has moved
+	// back past the threshold by this amount (only for >, >=, <, <=)
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+
+	// TimeWindow is fulfilled during the given local time of day
+	TimeWindow *RuleTimeWindow `json:"time_window,omitempty"`
+
+	// ForSeconds requires the condition to be fulfilled without interruption for this long
+	ForSeconds int `json:"for_seconds,omitempty"`
+}
+
+// RuleTimeWindow is a daily time window in local time. From may be later than To for windows
+// spanning midnight (e.g. 22:00-06:00).
+type RuleTimeWindow struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Days restricts the window to weekdays ("mon" ... "sun", empty = every day); for windows
+	// spanning midnight the day the window starts counts
+	Days []string `json:"days,omitempty"`
+}
+
+// Rule action types
+const (
+	RuleActionCommand       = "command"
+	RuleActionAlarm         = "alarm"
+	RuleActionAttribute     = "attribute"
+	RuleActionEnableDevice  = "enable_device"
+	RuleActionDisableDevice = "disable_device"
+)
+
+// RuleAction is executed when a rule becomes active or inactive
+type RuleAction struct {
+	Type string `json:"type"`
+
+	// Device is the target of command, enable_device and disable_device and the device an
+	// alarm is reported for
+	Device string `json:"device,omitempty"`
+
+	// Command, Value and Parameters define the command sent to Device; Value is also the
+	// value of an attribute action
+	Command    string                 `json:"command,omitempty"`
+	Value      interface{}            `json:"value,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+
+	// Name is the alarm name (default: rule name) or the attribute key
+	Name string `json:"name,omitempty"`
+
+	// Severity (INFO, WARNING, CRITICAL; default WARNING) and Message of an alarm
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// RuleConfig defines a local automation rule: Actions are executed when the condition becomes
+// fulfilled, ClearActions when it is no longer fulfilled. Alarms raised by a rule are cleared
+// automatically when it becomes inactive.
+type RuleConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Disabled rules are parsed but not evaluated
+	Disabled bool `json:"disabled,omitempty"`
+
+	Condition    RuleCondition `json:"condition"`
+	Actions      []RuleAction  `json:"actions"`
+	ClearActions []RuleAction  `json:"clear_actions,omitempty"`
+
+	// MaxAgeSeconds is the maximum age of a reading used in a condition; older readings
+	// fail the condition (0 = default of 300 seconds)
+	MaxAgeSeconds int `json:"max_age_seconds,omitempty"`
+}
+
+// Missed run policies of a schedule
+const (
+	// ScheduleMissedSkip drops runs missed during a blackout or while the reader was not running
+	ScheduleMissedSkip = "skip"
+	// ScheduleMissedRunOnce catches up missed runs with a single run as soon as possible
+	ScheduleMissedRunOnce = "run_once"
+)
+
+// ScheduleBlackout is a period in which no schedule run starts: a daily time window (From/To
+// as HH:MM, optional Days like a rule time window) or a fixed period (Start/End in RFC 3339,
+// e.g. a planned maintenance)
+type ScheduleBlackout struct {
+	From string   `json:"from,omitempty"`
+	To   string   `json:"to,omitempty"`
+	Days []string `json:"days,omitempty"`
+
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// ScheduleConfig defines a recurring actuator operation
+type ScheduleConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Disabled schedules are kept but do not run
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Cron is a five-field cron expression (minute hour day-of-month month day-of-week) or
+	// one of @hourly, @daily, @weekly, @monthly, @yearly
+	Cron string `json:"cron"`
+
+	// Timezone is the IANA time zone of Cron and the blackouts (default: scheduler timezone)
+	Timezone string `json:"timezone,omitempty"`
+
+	// Device, Command, Value and Parameters define the command sent at every run
+	Device     string                 `json:"device"`
+	Command    string                 `json:"command"`
+	Value      interface{}            `json:"value,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+
+	// DurationSeconds ends a run after this time with the stop command (0 = no stop command)
+	DurationSeconds int `json:"duration_seconds,omitempty"`
+
+	// StopCommand, StopValue and StopParameters end a run (default: SET_STATE false)
+	StopCommand    string                 `json:"stop_command,omitempty"`
+	StopValue      interface{}            `json:"stop_value,omitempty"`
+	StopParameters map[string]interface{} `json:"stop_parameters,omitempty"`
+
+	// Blackouts are periods in which this schedule does not start
+	Blackouts []ScheduleBlackout `json:"blackouts,omitempty"`
+
+	// MissedRun is the policy for runs missed during a blackout or downtime (skip or run_once)
+	MissedRun string `json:"missed_run,omitempty"`
+}
+
+// SchedulerConfig configures the time-based actuator operations. Schedules from app.json are
+// fixed; schedules added via RPC or the shared attribute "schedules" are stored in StatePath.
+type SchedulerConfig struct {
+	// Timezone is the default IANA time zone of all schedules (empty = local time)
+	Timezone string `json:"timezone"`
+
+	// StatePath stores the managed schedules and the time of the last run of every schedule
+	StatePath string `json:"state_path"`
+
+	// Blackouts apply to all schedules
+	Blackouts []ScheduleBlackout `json:"blackouts"`
+
+	Schedules []ScheduleConfig `json:"schedules"`
+}
+
+// Special step targets of a sequence
+const (
+	// SequenceNextEnd finishes the sequence
+	SequenceNextEnd = "end"
+	// SequenceNextAbort aborts the sequence and runs its abort actions
+	SequenceNextAbort = "abort"
+)
+
+// SequenceStepConfig is one step of a sequence, e.g. "fill until the level reaches 180 cm"
+type SequenceStepConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Actions run when the step is entered and again when a paused sequence resumes
+	Actions []RuleAction `json:"actions,omitempty"`
+
+	// DurationSeconds is the minimum time spent in the step (e.g. mixing or settling time)
+	DurationSeconds int `json:"duration_seconds,omitempty"`
+
+	// Until ends the step once it is fulfilled and DurationSeconds has elapsed. Without Until
+	// and DurationSeconds the step ends right after its actions.
+	Until *RuleCondition `json:"until,omitempty"`
+
+	// Next is the following step (default: the next step in the list, "end" after the last)
+	Next string `json:"next,omitempty"`
+
+	// TimeoutSeconds limits the time in the step (0 = no limit); OnTimeout is the step
+	// entered on timeout (default: "abort")
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+	OnTimeout      string `json:"on_timeout,omitempty"`
+
+	// AbortIf aborts the sequence while this step is active
+	AbortIf *RuleCondition `json:"abort_if,omitempty"`
+}
+
+// SequenceConfig defines a multi-step batch process run as a state machine
+type SequenceConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	Steps []SequenceStepConfig `json:"steps"`
+
+	// AbortIf aborts the sequence in every step (e.g. leak or overflow)
+	AbortIf *RuleCondition `json:"abort_if,omitempty"`
+
+	// AbortActions bring the actuators into a safe state when the sequence is aborted
+	AbortActions []RuleAction `json:"abort_actions,omitempty"`
+
+	// PauseActions run when the sequence is paused (e.g. stop the pumps)
+	PauseActions []RuleAction `json:"pause_actions,omitempty"`
+
+	// MaxAgeSeconds is the maximum age of a reading used in a condition (0 = 300 seconds)
+	MaxAgeSeconds int `json:"max_age_seconds,omitempty"`
+}
+
+// SequencerConfig configures the step sequences
+type SequencerConfig struct {
+	// StatePath stores the current step of every sequence so it resumes after a restart
+	StatePath string `json:"state_path"`
+
+	Sequences []SequenceConfig `json:"sequences"`
+}
+
+// OperatingHoursSystem is the counter of the whole system (running time of the reader)
+const OperatingHoursSystem = "system"
+
+// MaintenanceIntervalConfig raises a maintenance-due alarm after a number of operating hours
+// and/or switching cycles of an actuator (or of the system) since the last maintenance
+type MaintenanceIntervalConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Device is the actuator ID or "system"
+	Device string `json:"device"`
+
+	// Hours and Cycles are the maintenance intervals (0 = not used); for "system" Cycles
+	// counts the starts of the reader
+	Hours  float64 `json:"hours,omitempty"`
+	Cycles int     `json:"cycles,omitempty"`
+}
+
+// OperatingHoursConfig configures the operating-hours and switching-cycle counters
+type OperatingHoursConfig struct {
+	// StatePath stores the counters, the last maintenance of every interval and the resets
+	StatePath string `json:"state_path"`
+
+	// MaxGapSeconds is the longest time without a state reading in which a running actuator
+	// is still counted as running (0 = 300 seconds)
+	MaxGapSeconds int `json:"max_gap_seconds,omitempty"`
+
+	Maintenance []MaintenanceIntervalConfig `json:"maintenance"`
+}
+
+// OverrideTimeoutConfig sets how long a manual command keeps priority over automatic sources
+type OverrideTimeoutConfig struct {
+	// LocalSeconds applies to commands from the local operator panel, RemoteSeconds to
+	// commands from ThingsBoard (RPC and attributes)
+	LocalSeconds  int `json:"local_seconds,omitempty"`
+	RemoteSeconds int `json:"remote_seconds,omitempty"`
+}
+
+// ArbitrationConfig configures the per-actuator arbitration between command sources
+// (safety > local manual > remote manual > automatic control > schedule)
+type ArbitrationConfig struct {
+	// Disabled executes every command regardless of its source
+	Disabled bool `json:"disabled,omitempty"`
+
+	// OverrideTimeout applies to all actuators without an entry in Devices
+	OverrideTimeout OverrideTimeoutConfig `json:"override_timeout"`
+
+	// Devices overrides the timeouts per actuator ID (unset values use OverrideTimeout)
+	Devices map[string]OverrideTimeoutConfig `json:"devices,omitempty"`
+}
+
+// AcquisitionDeviceConfig overrides the acquisition schedule of a single device
+type AcquisitionDeviceConfig struct {
+	// PhaseOffsetMS delays the first read after start (0 = automatic staggering on the bus)
+	PhaseOffsetMS int `json:"phase_offset_ms,omitempty"`
+
+	// Bus groups devices that must not be read at the same time. Defaults to the Modbus
+	// port of the device; devices without a shared bus are only serialized with themselves.
+	Bus string `json:"bus,omitempty"`
+}
+
+// AcquisitionConfig configures the per-device read scheduler
+type AcquisitionConfig struct {
+	// ReadTimeoutSeconds limits a single read, including the state read-back of actuators
+	ReadTimeoutSeconds int `json:"read_timeout_seconds"`
+
+	// JitterMS delays every read by a random amount up to this value (at most a quarter
+	// of the read interval)
+	JitterMS int `json:"jitter_ms"`
+
+	// StaggerMS spreads the first reads of devices on the same bus apart
+	StaggerMS int `json:"stagger_ms"`
+
+	// Devices overrides the schedule per device ID
+	Devices map[string]AcquisitionDeviceConfig `json:"devices,omitempty"`
+}
+
 // AppConfig is the top-level configuration structure
 type AppConfig struct {
 	RS485       RS485Config       `json:"rs485_settings"`
 	ThingsBoard ThingsBoardConfig `json:"thingsboard_settings"`
 	Sensors     []SensorConfig    `json:"sensors"`
 	LogFilePath string            `json:"log_file_path"`
+
+	// DeviceReloadIntervalSeconds controls how often the device config directories
+	// are checked for changes (0 disables hot-reload)
+	DeviceReloadIntervalSeconds int `json:"device_reload_interval_seconds"`
+
+	// Connectivity holds the thresholds of the per-device connectivity state machine
+	Connectivity ConnectivityConfig `json:"connectivity"`
+
+	// Interlocks are checked before every command sent to a device
+	Interlocks []InterlockConfig `json:"interlocks"`
+
+	// Commands configures the actuator command queue and its audit log
+	Commands CommandConfig `json:"commands"`
+
+	// Failsafe configures when actuators are put into their safe state
+	Failsafe FailsafeConfig `json:"failsafe"`
+
+	// Controllers configures the control loops driving the actuators
+	Controllers ControllersConfig `json:"controllers"`
+
+	// Rules are local automation rules evaluated on the device, also without connection
+	Rules []RuleConfig `json:"rules"`
+
+	// RuleStatePath stores the last known shared attributes so attribute conditions of rules
+	// and sequences hold after a restart without connection
+	RuleStatePath string `json:"rule_state_path"`
+
+	// Scheduler configures time-based actuator operations
+	Scheduler SchedulerConfig `json:"scheduler"`
+
+	// Sequencer configures multi-step batch processes
+	Sequencer SequencerConfig `json:"sequencer"`
+
+	// OperatingHours configures the runtime counters and maintenance intervals
+	OperatingHours OperatingHoursConfig `json:"operating_hours"`
+
+	// Arbitration decides between manual and automatic commands to the same actuator
+	Arbitration ArbitrationConfig `json:"arbitration"`
+
+	// Acquisition configures when the sensors and actuator states are read
+	Acquisition AcquisitionConfig `json:"acquisition"`
 }
 
 // LoadAppConfig loads configuration from a JSON file and overrides with .env values
@@ -72,7 +647,45 @@ func LoadAppConfig(configFilePath string) (*AppConfig, error) {
 			Host: "localhost", // Default, will be overridden by env if present
 			Port: 1883,
 		},
-		LogFilePath: "/var/log/owipex/go_reader.log",
+		LogFilePath:                 "/var/log/owipex/go_reader.log",
+		DeviceReloadIntervalSeconds: 5,
+		Connectivity: ConnectivityConfig{
+			DegradedAfterFailures: 1,
+			OfflineAfterFailures:  3,
+			RecoverAfterSuccesses: 1,
+			BackoffInitialSeconds: 10,
+			BackoffMaxSeconds:     300,
+		},
+		Commands: CommandConfig{
+			TimeoutSeconds:    10,
+			QueueSize:         16,
+			AuditLogPath:      "/var/log/owipex/command_audit.jsonl",
+			AuditLogMaxSizeKB: 10240,
+		},
+		Controllers: ControllersConfig{
+			PH: DefaultPHControlConfig(),
+		},
+		RuleStatePath: "/var/lib/owipex/rule_attributes.json",
+		Scheduler: SchedulerConfig{
+			StatePath: "/var/lib/owipex/schedules.json",
+		},
+		Sequencer: SequencerConfig{
+			StatePath: "/var/lib/owipex/sequences.json",
+		},
+		OperatingHours: OperatingHoursConfig{
+			StatePath: "/var/lib/owipex/operating_hours.json",
+		},
+		Arbitration: ArbitrationConfig{
+			OverrideTimeout: OverrideTimeoutConfig{
+				LocalSeconds:  3600,
+				RemoteSeconds: 1800,
+			},
+		},
+		Acquisition: AcquisitionConfig{
+			ReadTimeoutSeconds: 5,
+			JitterMS:           250,
+			StaggerMS:          200,
+		},
 	}
 
 	// Load from JSON config file if provided and exists
@@ -0,0 +1,176 @@
+// Package controller enthält die Regelkreise, die aus Messwerten der Registry Befehle an
+// Aktoren ableiten. Befehle laufen über die Befehlsverwaltung (Auslöser "controller"), bei
+// einer Störung bringt der Regler seine Aktoren über den Failsafe-Manager in den sicheren Zustand.
+package controller
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/failsafe"
+)
+
+// Controller ist ein Regelkreis
+type Controller interface {
+	// ID ist der Name des Reglers, unter dem er Befehle im Audit-Log einträgt
+	ID() string
+
+	// Actors gibt die IDs der angesteuerten Aktoren zurück
+	Actors() []string
+
+	// Start startet den Regelkreis
+	Start() error
+
+	// Stop beendet den Regelkreis, ohne die Aktoren zu schalten
+	Stop()
+
+	// ApplyAttributes übernimmt Shared Attributes aus ThingsBoard (z.B. Sollwerte)
+	ApplyAttributes(attributes map[string]interface{})
+
+	// Status gibt den aktuellen Zustand des Reglers zurück
+	Status() interface{}
+}
+
+// Environment enthält die Dienste, über die ein Regler Messwerte liest und Aktoren schaltet
+type Environment struct {
+	Registry *device.Registry
+	Commands *command.Manager
+	Failsafe *failsafe.Manager
+
+	// Publish sendet Telemetrie und Attribute an ThingsBoard ({"simple": {...}} bzw. {"attributes": {...}})
+	Publish func(data map[string]interface{})
+
+	// StoreCalibration speichert eine neue Kalibrierung eines Sensors in seiner Gerätekonfiguration.
+	// Ist sie nicht gesetzt, wird die Kalibrierung nur im Speicher übernommen.
+	StoreCalibration func(deviceID string, calibration map[string]interface{}) error
+}
+
+// Manager verwaltet die konfigurierten Regler
+type Manager struct {
+	logger *log.Logger
+
+	mutex       sync.RWMutex
+	controllers []Controller
+	started     []Controller
+}
+
+// NewManager erstellt einen Manager ohne Regler
+func NewManager() *Manager {
+	return &Manager{
+		logger: log.New(os.Stdout, "[Controller] ", log.LstdFlags),
+	}
+}
+
+// Add fügt einen Regler hinzu. Regler werden in der Reihenfolge des Hinzufügens gestartet.
+func (m *Manager) Add(c Controller) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, existing := range m.controllers {
+		if existing.ID() == c.ID() {
+			return fmt.Errorf("regler %s ist bereits vorhanden", c.ID())
+		}
+	}
+	m.controllers = append(m.controllers, c)
+	return nil
+}
+
+// Controllers gibt alle Regler zurück
+func (m *Manager) Controllers() []Controller {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return append([]Controller(nil), m.controllers...)
+}
+
+// Start startet alle Regler. Ein Regler, der nicht startet, verhindert den Start der übrigen nicht.
+func (m *Manager) Start() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, c := range m.controllers {
+		if err := c.Start(); err != nil {
+			m.logger.Printf("Regler %s nicht gestartet: %v", c.ID(), err)
+			continue
+		}
+		m.started = append(m.started, c)
+		m.logger.Printf("Regler %s gestartet", c.ID())
+	}
+}
+
+// Stop beendet alle gestarteten Regler in umgekehrter Reihenfolge
+func (m *Manager) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := len(m.started) - 1; i >= 0; i-- {
+		m.started[i].Stop()
+	}
+	m.started = nil
+}
+
+// ApplyAttributes gibt Shared Attributes an alle Regler weiter
+func (m *Manager) ApplyAttributes(attributes map[string]interface{}) {
+	for _, c := range m.Controllers() {
+		c.ApplyAttributes(attributes)
+	}
+}
+
+// Status gibt den Zustand aller Regler nach ID zurück
+func (m *Manager) Status() map[string]interface{} {
+	status := make(map[string]interface{})
+	for _, c := range m.Controllers() {
+		status[c.ID()] = c.Status()
+	}
+	return status
+}
+
+// FloatAttribute liest ein numerisches Attribut. Dashboards senden Zahlen teilweise als String.
+// exists ist false, wenn das Attribut nicht enthalten ist.
+func FloatAttribute(attributes map[string]interface{}, key string) (value float64, exists bool, err error) {
+	raw, exists := attributes[key]
+	if !exists {
+		return 0, false, nil
+	}
+
+	switch v := raw.(type) {
+	case float64:
+		return v, true, nil
+	case int:
+		return float64(v), true, nil
+	case string:
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, true, fmt.Errorf("attribut %s ist keine Zahl: %q", key, v)
+		}
+		return parsed, true, nil
+	default:
+		return 0, true, fmt.Errorf("attribut %s ist keine Zahl: %v", key, raw)
+	}
+}
+
+// BoolAttribute liest ein Schalter-Attribut (Boolean oder "true"/"false")
+func BoolAttribute(attributes map[string]interface{}, key string) (value bool, exists bool, err error) {
+	raw, exists := attributes[key]
+	if !exists {
+		return false, false, nil
+	}
+
+	switch v := raw.(type) {
+	case bool:
+		return v, true, nil
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, true, fmt.Errorf("attribut %s ist kein Boolean: %q", key, v)
+		}
+		return parsed, true, nil
+	default:
+		return false, true, fmt.Errorf("attribut %s ist kein Boolean: %v", key, raw)
+	}
+}
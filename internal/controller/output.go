@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"log"
+	"sync"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/types"
+)
+
+// Output ist ein vom Regler geschalteter Aktor. Ein Befehl wird nur gesendet, wenn sich der
+// Sollzustand ändert oder der letzte Befehl fehlgeschlagen ist; so wiederholt der Regler
+// einen gesperrten oder gestörten Befehl im nächsten Zyklus, ohne den Bus zu fluten.
+type Output struct {
+	DeviceID string
+
+	issuer   string
+	commands *command.Manager
+	logger   *log.Logger
+
+	mutex     sync.Mutex
+	confirmed bool
+	state     bool
+	lastError string
+}
+
+// NewOutput erstellt einen Ausgang, dessen Befehle mit issuer als Auslöser protokolliert werden
+func NewOutput(deviceID, issuer string, commands *command.Manager, logger *log.Logger) *Output {
+	return &Output{
+		DeviceID: deviceID,
+		issuer:   issuer,
+		commands: commands,
+		logger:   logger,
+	}
+}
+
+// Set schaltet den Aktor ein oder aus und gibt zurück, ob der Zustand bestätigt ist
+func (o *Output) Set(on bool) bool {
+	o.mutex.Lock()
+	if o.confirmed && o.state == on {
+		o.mutex.Unlock()
+		return true
+	}
+	o.state = on
+	o.confirmed = false
+	o.mutex.Unlock()
+
+	record := o.commands.Submit(command.Request{
+		DeviceID: o.DeviceID,
+		Command:  types.Command{Type: types.CommandTypeSetState, Value: on},
+		Origin:   command.OriginController,
+		Issuer:   o.issuer,
+	})
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if !record.Succeeded() {
+		// Fehler nur bei Änderung melden, der Befehl wird jeden Zyklus wiederholt
+		if record.Error != o.lastError {
+			o.logger.Printf("%s: %s auf %v nicht geschaltet (%s): %s", o.issuer, o.DeviceID, on, record.Status, record.Error)
+		}
+		o.lastError = record.Error
+		return false
+	}
+
+	if o.lastError != "" {
+		o.logger.Printf("%s: %s wieder geschaltet", o.issuer, o.DeviceID)
+	}
+	o.lastError = ""
+	o.confirmed = o.state == on
+	return o.confirmed
+}
+
+// State gibt den zuletzt gesetzten Sollzustand zurück und ob er bestätigt ist
+func (o *Output) State() (on bool, confirmed bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.state, o.confirmed
+}
+
+// Invalidate verwirft den bestätigten Zustand, z.B. nachdem ein anderer Auslöser (Failsafe,
+// Bedienung) den Aktor geschaltet hat. Der nächste Aufruf von Set sendet den Befehl erneut.
+func (o *Output) Invalidate() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.confirmed = false
+}
@@ -0,0 +1,684 @@
+// Package ph enthält die automatische pH-Neutralisation (Portierung der Regelung aus h2o.py).
+// Liegt der pH-Wert über Sollwert + Toleranz, wird CO2 dosiert (CO2-Ventil und Heizung der
+// CO2-Versorgung ein, Pumpe aus); liegt er im Fenster, läuft die Pumpe. Bleibt der pH-Wert
+// länger als die eingestellte Verzögerung über bzw. unter dem Fenster oder fällt er unter den
+// Abschaltwert, schaltet die Regelung die Anlage ab (powerButton und autoSwitch aus).
+package ph
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/controller"
+	phsensor "owipex_reader/internal/device/sensor/ph"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/types"
+)
+
+// ID ist der Name der pH-Regelung (Auslöser im Audit-Log)
+const ID = "ph_control"
+
+// Shared Attributes des Altsystems, über die das Dashboard die Regelung bedient
+const (
+	AttributePowerButton  = "powerButton"
+	AttributeAutoSwitch   = "autoSwitch"
+	AttributeTargetPH     = "targetPHValue"
+	AttributeTolerance    = "targetPHtolerrance"
+	AttributeStopPH       = "minimumPHValStop"
+	AttributeHighDelay    = "ph_high_delay_duration"
+	AttributeLowDelay     = "ph_low_delay_duration"
+	AttributeCalibrate    = "calibratePH"
+	AttributeMeasuredHigh = "gemessener_high_wert"
+	AttributeMeasuredLow  = "gemessener_low_wert"
+)
+
+// Zustände der Regelung
+const (
+	// StateOff: Anlage ausgeschaltet (powerButton aus oder abgeschaltet)
+	StateOff = "off"
+	// StateStandby: Anlage eingeschaltet, Automatik aus
+	StateStandby = "standby"
+	// StateNeutral: pH-Wert im Fenster, Pumpe läuft
+	StateNeutral = "neutral"
+	// StateDosing: pH-Wert zu hoch, CO2 wird dosiert
+	StateDosing = "dosing"
+	// StateLow: pH-Wert zu niedrig
+	StateLow = "low"
+	// StateFault: kein gültiger pH-Messwert, Aktoren im sicheren Zustand
+	StateFault = "fault"
+)
+
+const (
+	// subscriberID ist die Kennung der Regelung auf dem Event-Bus
+	subscriberID = "controller.ph"
+
+	// telemetryInterval begrenzt das Senden unveränderter Telemetrie (wie DATA_SEND_INTERVAL im Altsystem)
+	telemetryInterval = 15 * time.Second
+
+	// Alarme bei Abschaltung und bei fehlendem Messwert
+	alarmTrip  = "ph_control_trip"
+	alarmFault = "ph_control_fault"
+)
+
+// Status ist der Zustand der pH-Regelung
+type Status struct {
+	State         string   `json:"state"`
+	PowerButton   bool     `json:"power_button"`
+	AutoSwitch    bool     `json:"auto_switch"`
+	PH            *float64 `json:"ph,omitempty"`
+	TargetPH      float64  `json:"target_ph"`
+	Tolerance     float64  `json:"tolerance"`
+	StopPH        float64  `json:"stop_ph"`
+	MinimumPH     float64  `json:"minimum_ph"`
+	MaximumPH     float64  `json:"maximum_ph"`
+	CountdownHigh float64  `json:"countdown_high_seconds"`
+	CountdownLow  float64  `json:"countdown_low_seconds"`
+	CO2Valve      bool     `json:"co2_valve"`
+	Heating       bool     `json:"heating"`
+	Pump          bool     `json:"pump"`
+	TripReason    string   `json:"trip_reason,omitempty"`
+	Fault         string   `json:"fault,omitempty"`
+}
+
+// decision ist das Ergebnis eines Regelzyklus
+type decision struct {
+	// apply ist gesetzt, wenn die Ausgänge auf co2, heating und pump geschaltet werden
+	apply              bool
+	co2, heating, pump bool
+
+	fault     error
+	recovered bool
+	trip      string
+}
+
+// Controller ist die pH-Regelung
+type Controller struct {
+	cfg      config.PHControlConfig
+	env      controller.Environment
+	readings *controller.Readings
+	co2      *controller.Output
+	heating  *controller.Output
+	pump     *controller.Output
+	logger   *log.Logger
+	interval time.Duration
+	maxAge   time.Duration
+
+	mutex     sync.Mutex
+	targetPH  float64
+	tolerance float64
+	stopPH    float64
+	highDelay time.Duration
+	lowDelay  time.Duration
+
+	// power und auto sind die wirksamen Schalter, powerAttribute und autoAttribute die zuletzt
+	// empfangenen Attributwerte. Nach einer Abschaltung schaltet erst ein geänderter Attributwert
+	// die Anlage wieder ein, nicht die Wiederholung nach einem Verbindungsaufbau.
+	power          bool
+	auto           bool
+	powerAttribute *bool
+	autoAttribute  *bool
+
+	highSince time.Time
+	lowSince  time.Time
+	started   time.Time
+
+	state      string
+	tripReason string
+	fault      string
+	ph         float64
+	hasPH      bool
+
+	co2On     bool
+	heatingOn bool
+	pumpOn    bool
+
+	measuredHigh float64
+	measuredLow  float64
+
+	lastPublished      time.Time
+	lastPublishedState string
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewController erstellt die pH-Regelung aus der Anwendungskonfiguration
+func NewController(cfg config.PHControlConfig, env controller.Environment) (*Controller, error) {
+	if cfg.Sensor == "" || cfg.CO2Valve == "" || cfg.Pump == "" {
+		return nil, fmt.Errorf("pH-Regelung benötigt sensor, co2_valve und pump")
+	}
+	if cfg.Tolerance <= 0 {
+		return nil, fmt.Errorf("tolerance der pH-Regelung muss größer als 0 sein")
+	}
+	if cfg.HighDelaySeconds <= 0 || cfg.LowDelaySeconds <= 0 || cfg.IntervalSeconds <= 0 {
+		return nil, fmt.Errorf("high_delay_seconds, low_delay_seconds und interval_seconds der pH-Regelung müssen größer als 0 sein")
+	}
+	if cfg.CalibrationHighPH == cfg.CalibrationLowPH {
+		return nil, fmt.Errorf("calibration_high_ph und calibration_low_ph der pH-Regelung müssen sich unterscheiden")
+	}
+
+	logger := log.New(os.Stdout, "[PHControl] ", log.LstdFlags)
+	c := &Controller{
+		cfg:       cfg,
+		env:       env,
+		readings:  controller.NewReadings(env.Registry.EventBus(), subscriberID, cfg.Sensor),
+		co2:       controller.NewOutput(cfg.CO2Valve, ID, env.Commands, logger),
+		pump:      controller.NewOutput(cfg.Pump, ID, env.Commands, logger),
+		logger:    logger,
+		interval:  time.Duration(cfg.IntervalSeconds) * time.Second,
+		maxAge:    time.Duration(cfg.MaxReadingAgeSeconds) * time.Second,
+		targetPH:  cfg.TargetPH,
+		tolerance: cfg.Tolerance,
+		stopPH:    cfg.StopPH,
+		highDelay: time.Duration(cfg.HighDelaySeconds) * time.Second,
+		lowDelay:  time.Duration(cfg.LowDelaySeconds) * time.Second,
+		state:     StateOff,
+		stopChan:  make(chan struct{}),
+	}
+	if cfg.Heating != "" {
+		c.heating = controller.NewOutput(cfg.Heating, ID, env.Commands, logger)
+	}
+	return c, nil
+}
+
+// ID gibt den Namen der Regelung zurück
+func (c *Controller) ID() string {
+	return ID
+}
+
+// Actors gibt die IDs der geschalteten Aktoren zurück
+func (c *Controller) Actors() []string {
+	ids := make([]string, 0, 3)
+	for _, output := range c.outputs() {
+		ids = append(ids, output.DeviceID)
+	}
+	return ids
+}
+
+// outputs gibt die Ausgänge der Regelung zurück
+func (c *Controller) outputs() []*controller.Output {
+	outputs := []*controller.Output{c.co2, c.pump}
+	if c.heating != nil {
+		outputs = append(outputs, c.heating)
+	}
+	return outputs
+}
+
+// Start startet den Regelzyklus. Bis Attribute aus ThingsBoard eintreffen, bleibt die Anlage aus.
+func (c *Controller) Start() error {
+	for _, id := range append([]string{c.cfg.Sensor}, c.Actors()...) {
+		if _, err := c.env.Registry.GetDevice(id); err != nil {
+			c.logger.Printf("Warnung: Gerät %s ist nicht konfiguriert", id)
+		}
+	}
+
+	if err := c.readings.Start(); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.started = time.Now()
+	c.mutex.Unlock()
+
+	if c.env.Failsafe != nil {
+		c.env.Failsafe.Register(c.watchdogSource(), c.interval)
+	}
+
+	c.wg.Add(1)
+	go c.run()
+	return nil
+}
+
+// Stop beendet den Regelzyklus. Die Aktoren bringt der Failsafe-Manager beim Beenden in den sicheren Zustand.
+func (c *Controller) Stop() {
+	close(c.stopChan)
+	c.wg.Wait()
+	c.readings.Stop()
+	if c.env.Failsafe != nil {
+		c.env.Failsafe.Release(c.watchdogSource())
+	}
+}
+
+// watchdogSource ist der Name des Regelzyklus beim Watchdog des Failsafe-Managers
+func (c *Controller) watchdogSource() string {
+	return "controller:" + c.ID()
+}
+
+// run führt den Regelzyklus aus
+func (c *Controller) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case now := <-ticker.C:
+			c.cycle(now)
+			if c.env.Failsafe != nil {
+				c.env.Failsafe.Kick(c.watchdogSource())
+			}
+		}
+	}
+}
+
+// cycle bewertet den pH-Wert und schaltet die Ausgänge
+func (c *Controller) cycle(now time.Time) {
+	d := c.evaluate(now)
+
+	if d.fault != nil {
+		c.logger.Printf("Störung: %v", d.fault)
+		c.publishAlarm(alarmFault, true, d.fault.Error())
+		c.applyFault(d.fault)
+	}
+	if d.recovered {
+		c.logger.Println("Gültiger pH-Messwert, Regelung läuft wieder")
+		c.publishAlarm(alarmFault, false, "gültiger pH-Messwert, Regelung läuft wieder")
+		if c.env.Failsafe != nil {
+			// Die wegen der Störung gehaltenen sicheren Zustände freigeben
+			c.env.Failsafe.ControllerRecovered(ID, c.Actors()...)
+		}
+	}
+	if d.trip != "" {
+		c.logger.Printf("Anlage abgeschaltet: %s", d.trip)
+		c.publishAlarm(alarmTrip, true, d.trip)
+	}
+
+	if d.apply {
+		// Erst ausschalten, dann einschalten, damit Pumpe und CO2-Dosierung nie gleichzeitig laufen
+		targets := map[*controller.Output]bool{c.co2: d.co2, c.pump: d.pump}
+		if c.heating != nil {
+			targets[c.heating] = d.heating
+		}
+		for _, on := range []bool{false, true} {
+			for _, output := range c.outputs() {
+				if targets[output] == on {
+					output.Set(on)
+				}
+			}
+		}
+	}
+
+	c.publishTelemetry(now, d.fault != nil || d.recovered || d.trip != "")
+}
+
+// evaluate berechnet die Sollzustände der Ausgänge (Regelung aus h2o.py)
+func (c *Controller) evaluate(now time.Time) decision {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	sample, err := c.readings.Latest(c.cfg.Sensor, c.maxAge)
+	if err == nil {
+		value, ok := sample.Reading.Value.(float64)
+		if !ok {
+			err = fmt.Errorf("pH-Messwert von %s ist keine Zahl: %v", c.cfg.Sensor, sample.Reading.Value)
+		} else {
+			c.ph = value
+			c.hasPH = true
+		}
+	}
+
+	d := decision{}
+	if !c.power || !c.auto {
+		// Wie im Altsystem schaltet powerButton aus auch die Automatik ab
+		if !c.power {
+			c.auto = false
+			c.state = StateOff
+		} else {
+			c.state = StateStandby
+		}
+		c.highSince, c.lowSince = time.Time{}, time.Time{}
+		if c.fault != "" {
+			c.fault = ""
+			d.recovered = true
+		}
+		d.apply = true
+		c.setOutputs(&d, false, false, false)
+		return d
+	}
+
+	if err != nil {
+		// Nach dem Start erst auf den ersten Messwert warten
+		if c.fault == "" && now.Sub(c.started) < c.maxAge {
+			return d
+		}
+		if c.fault == "" {
+			d.fault = err
+		}
+		c.fault = err.Error()
+		c.state = StateFault
+		return d
+	}
+
+	if c.fault != "" {
+		c.fault = ""
+		d.recovered = true
+	}
+	d.apply = true
+
+	maximum := c.targetPH + c.tolerance
+	minimum := c.targetPH - c.tolerance
+
+	switch {
+	case c.ph > maximum:
+		c.state = StateDosing
+		c.lowSince = time.Time{}
+		c.setOutputs(&d, true, true, false)
+		if c.highSince.IsZero() {
+			c.highSince = now
+		} else if now.Sub(c.highSince) >= c.highDelay {
+			d.trip = fmt.Sprintf("pH-Wert %.2f seit %s über %.2f", c.ph, c.highDelay, maximum)
+		}
+
+	case c.ph < minimum:
+		// Die Pumpe läuft wie im Altsystem bis zum Ablauf der Verzögerung weiter
+		c.state = StateLow
+		c.highSince = time.Time{}
+		c.setOutputs(&d, false, false, c.pumpOn)
+		if c.ph < c.stopPH {
+			d.trip = fmt.Sprintf("pH-Wert %.2f unter dem Abschaltwert %.2f", c.ph, c.stopPH)
+		} else if c.lowSince.IsZero() {
+			c.lowSince = now
+		} else if now.Sub(c.lowSince) >= c.lowDelay {
+			d.trip = fmt.Sprintf("pH-Wert %.2f seit %s unter %.2f", c.ph, c.lowDelay, minimum)
+		}
+
+	default:
+		c.state = StateNeutral
+		c.highSince, c.lowSince = time.Time{}, time.Time{}
+		c.setOutputs(&d, false, false, true)
+	}
+
+	if d.trip != "" {
+		c.power = false
+		c.auto = false
+		c.tripReason = d.trip
+		c.state = StateOff
+		c.highSince, c.lowSince = time.Time{}, time.Time{}
+		c.setOutputs(&d, false, false, false)
+	}
+	return d
+}
+
+// setOutputs setzt die Sollzustände der Ausgänge
+func (c *Controller) setOutputs(d *decision, co2, heating, pump bool) {
+	d.co2, d.heating, d.pump = co2, heating, pump
+	c.co2On, c.heatingOn, c.pumpOn = co2, heating, pump
+}
+
+// applyFault bringt die Aktoren bei fehlendem Messwert in den sicheren Zustand. Aktoren ohne
+// konfigurierten sicheren Zustand werden ausgeschaltet.
+func (c *Controller) applyFault(err error) {
+	handled := make(map[string]bool)
+	if c.env.Failsafe != nil {
+		for _, record := range c.env.Failsafe.ControllerFault(ID, err, c.Actors()...) {
+			handled[record.DeviceID] = true
+		}
+	}
+
+	for _, output := range c.outputs() {
+		if handled[output.DeviceID] {
+			// Nach der Störung den Sollzustand erneut senden
+			output.Invalidate()
+		} else {
+			output.Set(false)
+		}
+	}
+}
+
+// ApplyAttributes übernimmt Schalter, Sollwerte, Verzögerungen und die Kalibrierung aus den
+// Shared Attributes des Altsystems
+func (c *Controller) ApplyAttributes(attributes map[string]interface{}) {
+	c.mutex.Lock()
+
+	for key, target := range map[string]*float64{
+		AttributeTargetPH:     &c.targetPH,
+		AttributeTolerance:    &c.tolerance,
+		AttributeStopPH:       &c.stopPH,
+		AttributeMeasuredHigh: &c.measuredHigh,
+		AttributeMeasuredLow:  &c.measuredLow,
+	} {
+		value, exists, err := controller.FloatAttribute(attributes, key)
+		if err != nil {
+			c.logger.Println(err)
+			continue
+		}
+		if !exists {
+			continue
+		}
+		if key == AttributeTolerance && value <= 0 {
+			c.logger.Printf("Attribut %s muss größer als 0 sein: %v", key, value)
+			continue
+		}
+		*target = value
+	}
+
+	for key, target := range map[string]*time.Duration{
+		AttributeHighDelay: &c.highDelay,
+		AttributeLowDelay:  &c.lowDelay,
+	} {
+		value, exists, err := controller.FloatAttribute(attributes, key)
+		if err != nil {
+			c.logger.Println(err)
+			continue
+		}
+		if !exists {
+			continue
+		}
+		if value <= 0 {
+			c.logger.Printf("Attribut %s muss größer als 0 sein: %v", key, value)
+			continue
+		}
+		*target = time.Duration(value * float64(time.Second))
+	}
+
+	clearTrip := false
+	if value, exists, err := controller.BoolAttribute(attributes, AttributePowerButton); err != nil {
+		c.logger.Println(err)
+	} else if exists && (c.powerAttribute == nil || *c.powerAttribute != value) {
+		c.powerAttribute = &value
+		c.power = value
+		if value && c.tripReason != "" {
+			clearTrip = true
+			c.tripReason = ""
+		}
+	}
+	if value, exists, err := controller.BoolAttribute(attributes, AttributeAutoSwitch); err != nil {
+		c.logger.Println(err)
+	} else if exists && (c.autoAttribute == nil || *c.autoAttribute != value) {
+		c.autoAttribute = &value
+		c.auto = value
+	}
+
+	calibrate, _, err := controller.BoolAttribute(attributes, AttributeCalibrate)
+	if err != nil {
+		c.logger.Println(err)
+	}
+	measuredHigh, measuredLow := c.measuredHigh, c.measuredLow
+	c.mutex.Unlock()
+
+	if clearTrip {
+		c.logger.Println("Anlage nach Abschaltung wieder eingeschaltet")
+		c.publishAlarm(alarmTrip, false, "anlage wieder eingeschaltet")
+	}
+
+	if calibrate {
+		if err := c.calibrate(measuredHigh, measuredLow); err != nil {
+			c.logger.Printf("Kalibrierung fehlgeschlagen: %v", err)
+		}
+	}
+}
+
+// calibrate führt die Zwei-Punkt-Kalibrierung des pH-Sensors aus. Die Messwerte sind die
+// unkalibrierten Sensorwerte in den Pufferlösungen (Telemetrie measuredPHRaw_telem).
+func (c *Controller) calibrate(measuredHigh, measuredLow float64) error {
+	if measuredHigh == measuredLow {
+		return fmt.Errorf("messwerte für pH %.2f und pH %.2f sind gleich (%v)", c.cfg.CalibrationHighPH, c.cfg.CalibrationLowPH, measuredHigh)
+	}
+
+	sensor, err := c.sensor()
+	if err != nil {
+		return err
+	}
+
+	scale := (c.cfg.CalibrationHighPH - c.cfg.CalibrationLowPH) / (measuredHigh - measuredLow)
+	offset := c.cfg.CalibrationHighPH - scale*measuredHigh
+
+	// Das Attribut wird nach jedem Verbindungsaufbau erneut empfangen, dieselbe Kalibrierung
+	// daher nicht erneut speichern
+	current := sensor.GetCalibration()
+	currentOffset, _ := current[phsensor.CalibrationOffset].(float64)
+	currentScale, _ := current[phsensor.CalibrationScale].(float64)
+	if math.Abs(currentOffset-offset) < 1e-9 && math.Abs(currentScale-scale) < 1e-9 {
+		return nil
+	}
+
+	calibration := map[string]interface{}{
+		phsensor.CalibrationOffset: offset,
+		phsensor.CalibrationScale:  scale,
+	}
+	c.logger.Printf("Kalibriere %s: scale=%.4f, offset=%.4f", c.cfg.Sensor, scale, offset)
+
+	if c.env.StoreCalibration != nil {
+		return c.env.StoreCalibration(c.cfg.Sensor, calibration)
+	}
+	return sensor.SetCalibration(calibration)
+}
+
+// sensor gibt den pH-Sensor aus der Registry zurück
+func (c *Controller) sensor() (types.Sensor, error) {
+	dev, err := c.env.Registry.GetDevice(c.cfg.Sensor)
+	if err != nil {
+		return nil, err
+	}
+	sensor, ok := dev.(types.Sensor)
+	if !ok {
+		return nil, fmt.Errorf("gerät %s ist kein Sensor", c.cfg.Sensor)
+	}
+	return sensor, nil
+}
+
+// Status gibt den aktuellen Zustand der Regelung zurück
+func (c *Controller) Status() interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.statusLocked(time.Now())
+}
+
+// statusLocked erstellt den Status, der Aufrufer hält die Sperre
+func (c *Controller) statusLocked(now time.Time) Status {
+	status := Status{
+		State:         c.state,
+		PowerButton:   c.power,
+		AutoSwitch:    c.auto,
+		TargetPH:      c.targetPH,
+		Tolerance:     c.tolerance,
+		StopPH:        c.stopPH,
+		MinimumPH:     c.targetPH - c.tolerance,
+		MaximumPH:     c.targetPH + c.tolerance,
+		CountdownHigh: countdown(c.highSince, c.highDelay, now),
+		CountdownLow:  countdown(c.lowSince, c.lowDelay, now),
+		CO2Valve:      c.co2On,
+		Heating:       c.heatingOn,
+		Pump:          c.pumpOn,
+		TripReason:    c.tripReason,
+		Fault:         c.fault,
+	}
+	if c.hasPH {
+		ph := c.ph
+		status.PH = &ph
+	}
+	return status
+}
+
+// countdown gibt die verbleibende Zeit einer Verzögerung in Sekunden zurück
+func countdown(since time.Time, delay time.Duration, now time.Time) float64 {
+	if since.IsZero() {
+		return delay.Seconds()
+	}
+	return math.Min(delay.Seconds(), math.Max(0, math.Round((delay-now.Sub(since)).Seconds())))
+}
+
+// publishTelemetry sendet den Zustand unter den Telemetrie-Schlüsseln des Altsystems, damit die
+// bestehenden Dashboards weiter funktionieren. Unveränderte Zustände werden höchstens alle
+// telemetryInterval gesendet.
+func (c *Controller) publishTelemetry(now time.Time, force bool) {
+	if c.env.Publish == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	status := c.statusLocked(now)
+	if !force && status.State == c.lastPublishedState && now.Sub(c.lastPublished) < telemetryInterval {
+		c.mutex.Unlock()
+		return
+	}
+	c.lastPublished = now
+	c.lastPublishedState = status.State
+	c.mutex.Unlock()
+
+	telemetry := map[string]interface{}{
+		"countdownPHHigh":        status.CountdownHigh,
+		"countdownPHLow":         status.CountdownLow,
+		"co2RelaisSwSig":         status.CO2Valve,
+		"co2HeatingRelaySwSig":   status.Heating,
+		"pumpRelaySwSig":         status.Pump,
+		"powerButton":            status.PowerButton,
+		"autoSwitch":             status.AutoSwitch,
+		"targetPHValue":          status.TargetPH,
+		"targetPHtolerrance":     status.Tolerance,
+		"minimumPHValStop":       status.StopPH,
+		"minimumPHVal":           status.MinimumPH,
+		"maximumPHVal":           status.MaximumPH,
+		"ph_control_state":       status.State,
+		"ph_control_trip_reason": status.TripReason,
+		"ph_control_fault":       status.Fault,
+	}
+	if status.PH != nil {
+		telemetry["measuredPHValue_telem"] = *status.PH
+		if raw, ok := c.uncalibrated(*status.PH); ok {
+			telemetry["measuredPHRaw_telem"] = raw
+		}
+	}
+
+	c.env.Publish(map[string]interface{}{"simple": telemetry})
+}
+
+// uncalibrated rechnet einen pH-Wert mit der aktuellen Kalibrierung auf den Sensorwert zurück
+func (c *Controller) uncalibrated(ph float64) (float64, bool) {
+	sensor, err := c.sensor()
+	if err != nil {
+		return 0, false
+	}
+	calibration := sensor.GetCalibration()
+	offset, _ := calibration[phsensor.CalibrationOffset].(float64)
+	scale, ok := calibration[phsensor.CalibrationScale].(float64)
+	if !ok || scale == 0 {
+		scale = 1
+	}
+	return (ph - offset) / scale, true
+}
+
+// publishAlarm meldet eine Abschaltung oder Störung der Regelung
+func (c *Controller) publishAlarm(name string, active bool, message string) {
+	severity := event.SeverityCritical
+	if !active {
+		severity = event.SeverityInfo
+	}
+
+	c.env.Registry.EventBus().Publish(event.NewAlarmEvent(event.Alarm{
+		Name:     name,
+		DeviceID: c.cfg.Sensor,
+		Severity: severity,
+		Active:   active,
+		Message:  message,
+	}))
+}
@@ -0,0 +1,142 @@
+// Package pid enthält einen PID-Regler, der den Messwert eines Sensors über einen Aktor auf
+// einem Sollwert hält, z.B. den Füllstand über einen Frequenzumrichter oder den pH-Wert über
+// eine Dosierpumpe. Die Stellgröße wird als SET_POSITION gesendet oder bei Relais als
+// Pulsweitenmodulation (SET_STATE) ausgegeben.
+package pid
+
+import (
+	"math"
+	"time"
+)
+
+// Tuning sind die Parameter des PID-Algorithmus
+type Tuning struct {
+	Kp float64
+	Ki float64
+	Kd float64
+
+	// Reverse kehrt die Wirkrichtung um: die Stellgröße steigt, solange der Messwert über dem Sollwert liegt
+	Reverse bool
+
+	// OutputMin und OutputMax begrenzen die Stellgröße
+	OutputMin float64
+	OutputMax float64
+
+	// RateLimit ist die größte Änderung der Stellgröße je Sekunde (0 = unbegrenzt)
+	RateLimit float64
+}
+
+// Terms sind die Anteile des letzten Regelschritts
+type Terms struct {
+	Error     float64 `json:"error"`
+	P         float64 `json:"p"`
+	I         float64 `json:"i"`
+	D         float64 `json:"d"`
+	Saturated bool    `json:"saturated"`
+}
+
+// PID ist ein PID-Algorithmus mit D-Anteil auf den Messwert (kein Sprung bei Sollwertänderung),
+// Anti-Windup durch bedingte Integration und Begrenzung von Stellgröße und Änderungsrate.
+// Der I-Anteil wird in Einheiten der Stellgröße geführt, damit Änderungen von Ki stoßfrei sind.
+type PID struct {
+	tuning   Tuning
+	integral float64
+	output   float64
+	lastPV   float64
+	hasLast  bool
+	terms    Terms
+}
+
+// New erstellt einen PID-Algorithmus mit Stellgröße OutputMin
+func New(tuning Tuning) *PID {
+	return &PID{tuning: tuning, output: tuning.OutputMin}
+}
+
+// Tuning gibt die aktuellen Parameter zurück
+func (p *PID) Tuning() Tuning {
+	return p.tuning
+}
+
+// SetTuning übernimmt neue Parameter. Stellgröße und I-Anteil werden auf die neuen Grenzen begrenzt.
+func (p *PID) SetTuning(tuning Tuning) {
+	p.tuning = tuning
+	p.integral = clamp(p.integral, tuning.OutputMin, tuning.OutputMax)
+	p.output = clamp(p.output, tuning.OutputMin, tuning.OutputMax)
+}
+
+// Output gibt die aktuelle Stellgröße zurück
+func (p *PID) Output() float64 {
+	return p.output
+}
+
+// Terms gibt die Anteile des letzten Regelschritts zurück
+func (p *PID) Terms() Terms {
+	return p.terms
+}
+
+// Step berechnet die Stellgröße für einen Messwert nach der Zeit dt seit dem letzten Schritt
+func (p *PID) Step(setpoint, pv float64, dt time.Duration) float64 {
+	seconds := dt.Seconds()
+	e := p.error(setpoint, pv)
+
+	proportional := p.tuning.Kp * e
+
+	derivative := 0.0
+	if p.hasLast && seconds > 0 {
+		slope := (pv - p.lastPV) / seconds
+		if p.tuning.Reverse {
+			slope = -slope
+		}
+		derivative = -p.tuning.Kd * slope
+	}
+
+	increment := p.tuning.Ki * e * seconds
+	p.integral += increment
+
+	unlimited := proportional + p.integral + derivative
+	output := clamp(unlimited, p.tuning.OutputMin, p.tuning.OutputMax)
+	if p.tuning.RateLimit > 0 && seconds > 0 {
+		step := p.tuning.RateLimit * seconds
+		output = clamp(output, p.output-step, p.output+step)
+	}
+	saturated := output != unlimited
+
+	// Anti-Windup: nicht weiter integrieren, solange die Begrenzung in dieselbe Richtung greift
+	if (unlimited > output && increment > 0) || (unlimited < output && increment < 0) {
+		p.integral -= increment
+	}
+	p.integral = clamp(p.integral, p.tuning.OutputMin, p.tuning.OutputMax)
+
+	p.output = output
+	p.lastPV = pv
+	p.hasLast = true
+	p.terms = Terms{Error: e, P: proportional, I: p.integral, D: derivative, Saturated: saturated}
+	return output
+}
+
+// Track führt den Regler einer von außen vorgegebenen Stellgröße nach (Handbetrieb, Störung).
+// Der I-Anteil wird so gesetzt, dass der nächste Step ohne Sprung an output anschließt.
+func (p *PID) Track(output, setpoint, pv float64) {
+	output = clamp(output, p.tuning.OutputMin, p.tuning.OutputMax)
+	e := p.error(setpoint, pv)
+	proportional := p.tuning.Kp * e
+
+	p.integral = clamp(output-proportional, p.tuning.OutputMin, p.tuning.OutputMax)
+	p.output = output
+	p.lastPV = pv
+	p.hasLast = true
+	p.terms = Terms{Error: e, P: proportional, I: p.integral}
+}
+
+// error gibt die Regelabweichung in Wirkrichtung zurück
+func (p *PID) error(setpoint, pv float64) float64 {
+	if p.tuning.Reverse {
+		return pv - setpoint
+	}
+	return setpoint - pv
+}
+
+// clamp begrenzt einen Wert auf [min, max]
+func clamp(value, min, max float64) float64 {
+	return math.Max(min, math.Min(max, value))
+}
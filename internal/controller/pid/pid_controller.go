@@ -0,0 +1,597 @@
+package pid
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/controller"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/types"
+)
+
+// Suffixe der Shared Attributes, über die ein Regler zur Laufzeit bedient wird (<id>_setpoint usw.)
+const (
+	AttributeSetpoint     = "setpoint"
+	AttributeMode         = "mode"
+	AttributeManualOutput = "manual_output"
+	AttributeKp           = "kp"
+	AttributeKi           = "ki"
+	AttributeKd           = "kd"
+	AttributeOutputMin    = "output_min"
+	AttributeOutputMax    = "output_max"
+	AttributeRateLimit    = "rate_limit"
+)
+
+// pwmTick ist die Auflösung der Pulsweitenmodulation
+const pwmTick = time.Second
+
+// Status ist der Zustand eines PID-Reglers
+type Status struct {
+	Mode         string   `json:"mode"`
+	Setpoint     float64  `json:"setpoint"`
+	ProcessValue *float64 `json:"process_value,omitempty"`
+	Output       float64  `json:"output"`
+	ManualOutput float64  `json:"manual_output"`
+	Terms        Terms    `json:"terms"`
+	Kp           float64  `json:"kp"`
+	Ki           float64  `json:"ki"`
+	Kd           float64  `json:"kd"`
+	Reverse      bool     `json:"reverse"`
+	OutputMin    float64  `json:"output_min"`
+	OutputMax    float64  `json:"output_max"`
+	RateLimit    float64  `json:"rate_limit"`
+	Duty         *float64 `json:"duty,omitempty"`
+	Sent         *float64 `json:"sent,omitempty"`
+	Fault        string   `json:"fault,omitempty"`
+}
+
+// Controller regelt den Messwert eines Sensors über einen Aktor
+type Controller struct {
+	cfg      config.PIDConfig
+	env      controller.Environment
+	readings *controller.Readings
+	logger   *log.Logger
+	interval time.Duration
+	maxAge   time.Duration
+
+	// switchOutput ist bei PWM-Ausgabe der geschaltete Aktor
+	switchOutput *controller.Output
+	period       time.Duration
+	minPulse     time.Duration
+
+	mutex        sync.Mutex
+	pid          *PID
+	setpoint     float64
+	mode         string
+	manualOutput float64
+	pv           float64
+	hasPV        bool
+	lastStep     time.Time
+	started      time.Time
+	fault        string
+
+	// sent ist die zuletzt bestätigte Position, sendError der letzte Fehler beim Senden
+	sent      float64
+	hasSent   bool
+	sendError string
+
+	// periodStart und onTime sind Beginn und Einschaltdauer der laufenden PWM-Periode
+	periodStart time.Time
+	onTime      time.Duration
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewController erstellt einen PID-Regler. Die Standardwerte der Konfiguration müssen gesetzt sein.
+func NewController(cfg config.PIDConfig, env controller.Environment) (*Controller, error) {
+	if cfg.ID == "" || cfg.Sensor == "" || cfg.Actor == "" {
+		return nil, fmt.Errorf("PID-Regler benötigt id, sensor und actor")
+	}
+	if cfg.Output != config.PIDOutputPosition && cfg.Output != config.PIDOutputPWM {
+		return nil, fmt.Errorf("PID-Regler %s: unbekannte Ausgabe %q", cfg.ID, cfg.Output)
+	}
+	if cfg.Mode != config.PIDModeAuto && cfg.Mode != config.PIDModeManual {
+		return nil, fmt.Errorf("PID-Regler %s: unbekannter Modus %q", cfg.ID, cfg.Mode)
+	}
+	if cfg.OutputMin >= cfg.OutputMax {
+		return nil, fmt.Errorf("PID-Regler %s: output_min muss kleiner als output_max sein", cfg.ID)
+	}
+	if cfg.IntervalSeconds <= 0 || cfg.MaxReadingAgeSeconds <= 0 {
+		return nil, fmt.Errorf("PID-Regler %s: interval_seconds und max_reading_age_seconds müssen größer als 0 sein", cfg.ID)
+	}
+	if cfg.Output == config.PIDOutputPWM && (cfg.PWMPeriodSeconds <= 0 || cfg.PWMMinPulseSeconds < 0) {
+		return nil, fmt.Errorf("PID-Regler %s: pwm_period_seconds muss größer als 0 sein", cfg.ID)
+	}
+
+	logger := log.New(os.Stdout, fmt.Sprintf("[PID %s] ", cfg.ID), log.LstdFlags)
+	c := &Controller{
+		cfg:      cfg,
+		env:      env,
+		readings: controller.NewReadings(env.Registry.EventBus(), "controller.pid."+cfg.ID, cfg.Sensor),
+		logger:   logger,
+		interval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		maxAge:   time.Duration(cfg.MaxReadingAgeSeconds) * time.Second,
+		period:   time.Duration(cfg.PWMPeriodSeconds) * time.Second,
+		minPulse: time.Duration(cfg.PWMMinPulseSeconds) * time.Second,
+		pid: New(Tuning{
+			Kp:        cfg.Kp,
+			Ki:        cfg.Ki,
+			Kd:        cfg.Kd,
+			Reverse:   cfg.Reverse,
+			OutputMin: cfg.OutputMin,
+			OutputMax: cfg.OutputMax,
+			RateLimit: cfg.RateLimit,
+		}),
+		setpoint:     cfg.Setpoint,
+		mode:         cfg.Mode,
+		manualOutput: cfg.ManualOutput,
+		stopChan:     make(chan struct{}),
+	}
+	if cfg.Output == config.PIDOutputPWM {
+		c.switchOutput = controller.NewOutput(cfg.Actor, cfg.ID, env.Commands, logger)
+	}
+	return c, nil
+}
+
+// ID gibt den Namen des Reglers zurück
+func (c *Controller) ID() string {
+	return c.cfg.ID
+}
+
+// Actors gibt die ID des angesteuerten Aktors zurück
+func (c *Controller) Actors() []string {
+	return []string{c.cfg.Actor}
+}
+
+// Start startet den Regelzyklus
+func (c *Controller) Start() error {
+	for _, id := range []string{c.cfg.Sensor, c.cfg.Actor} {
+		if _, err := c.env.Registry.GetDevice(id); err != nil {
+			c.logger.Printf("Warnung: Gerät %s ist nicht konfiguriert", id)
+		}
+	}
+
+	if err := c.readings.Start(); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.started = time.Now()
+	c.mutex.Unlock()
+
+	if c.env.Failsafe != nil {
+		c.env.Failsafe.Register(c.watchdogSource(), c.interval)
+	}
+
+	c.wg.Add(1)
+	go c.run()
+	return nil
+}
+
+// Stop beendet den Regelzyklus. Den Aktor bringt der Failsafe-Manager beim Beenden in den sicheren Zustand.
+func (c *Controller) Stop() {
+	close(c.stopChan)
+	c.wg.Wait()
+	c.readings.Stop()
+	if c.env.Failsafe != nil {
+		c.env.Failsafe.Release(c.watchdogSource())
+	}
+}
+
+// watchdogSource ist der Name des Regelzyklus beim Watchdog des Failsafe-Managers
+func (c *Controller) watchdogSource() string {
+	return "controller:" + c.ID()
+}
+
+// run führt Regelschritte und bei PWM-Ausgabe das Schalten des Relais aus
+func (c *Controller) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	var pwm <-chan time.Time
+	if c.switchOutput != nil {
+		pwmTicker := time.NewTicker(pwmTick)
+		defer pwmTicker.Stop()
+		pwm = pwmTicker.C
+	}
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case now := <-ticker.C:
+			c.step(now)
+			if c.env.Failsafe != nil {
+				c.env.Failsafe.Kick(c.watchdogSource())
+			}
+		case now := <-pwm:
+			c.modulate(now)
+		}
+	}
+}
+
+// step berechnet die Stellgröße aus dem neuesten Messwert und gibt sie aus
+func (c *Controller) step(now time.Time) {
+	c.mutex.Lock()
+
+	pv, err := c.processValue()
+	if err != nil {
+		// Nach dem Start erst auf den ersten Messwert warten
+		if c.fault == "" && now.Sub(c.started) < c.maxAge {
+			c.mutex.Unlock()
+			return
+		}
+		newFault := c.fault == ""
+		c.fault = err.Error()
+		c.lastStep = time.Time{}
+		c.mutex.Unlock()
+
+		if newFault {
+			c.logger.Printf("Störung: %v", err)
+			c.publishAlarm(true, err.Error())
+			c.applyFault(err)
+		}
+		c.publishTelemetry()
+		return
+	}
+
+	recovered := c.fault != ""
+	c.fault = ""
+	c.pv = pv
+	c.hasPV = true
+
+	dt := c.interval
+	if !c.lastStep.IsZero() {
+		dt = now.Sub(c.lastStep)
+	}
+	c.lastStep = now
+
+	if c.mode == config.PIDModeManual {
+		c.pid.Track(c.manualOutput, c.setpoint, pv)
+	} else {
+		if recovered {
+			// Nach einer Störung stoßfrei an der zuletzt ausgegebenen Stellgröße fortsetzen
+			c.pid.Track(c.pid.Output(), c.setpoint, pv)
+		}
+		c.pid.Step(c.setpoint, pv, dt)
+	}
+	output := c.pid.Output()
+	c.mutex.Unlock()
+
+	if recovered {
+		c.logger.Println("Gültiger Messwert, Regelung läuft wieder")
+		c.publishAlarm(false, "gültiger Messwert, Regelung läuft wieder")
+		if c.env.Failsafe != nil {
+			// Den wegen der Störung gehaltenen sicheren Zustand freigeben
+			c.env.Failsafe.ControllerRecovered(c.cfg.ID, c.cfg.Actor)
+		}
+		if c.switchOutput != nil {
+			c.switchOutput.Invalidate()
+		}
+	}
+
+	if c.switchOutput == nil {
+		c.sendPosition(output)
+	}
+	c.publishTelemetry()
+}
+
+// processValue liest den Istwert aus dem neuesten Messwert des Sensors, der Aufrufer hält die Sperre
+func (c *Controller) processValue() (float64, error) {
+	sample, err := c.readings.Latest(c.cfg.Sensor, c.maxAge)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := sample.Reading.Value
+	if c.cfg.Field != "" {
+		value, exists := sample.Reading.Metadata[c.cfg.Field]
+		if !exists {
+			return 0, fmt.Errorf("messwert von %s enthält kein Feld %s", c.cfg.Sensor, c.cfg.Field)
+		}
+		raw = value
+	}
+
+	value, ok := number(raw)
+	if !ok {
+		return 0, fmt.Errorf("messwert von %s ist keine Zahl: %v", c.cfg.Sensor, raw)
+	}
+	return value, nil
+}
+
+// sendPosition sendet die Stellgröße als SET_POSITION, wenn sie sich um mehr als das Totband
+// geändert hat, eine Grenze erreicht oder der letzte Befehl fehlgeschlagen ist
+func (c *Controller) sendPosition(output float64) {
+	output = math.Round(output*100) / 100
+
+	c.mutex.Lock()
+	tuning := c.pid.Tuning()
+	atLimit := output == tuning.OutputMin || output == tuning.OutputMax
+	if c.hasSent && (output == c.sent || (!atLimit && math.Abs(output-c.sent) < c.cfg.OutputDeadband)) {
+		c.mutex.Unlock()
+		return
+	}
+	c.mutex.Unlock()
+
+	record := c.env.Commands.Submit(command.Request{
+		DeviceID: c.cfg.Actor,
+		Command:  types.Command{Type: types.CommandTypeSetPosition, Value: output, Parameters: c.cfg.Parameters},
+		Origin:   command.OriginController,
+		Issuer:   c.cfg.ID,
+	})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !record.Succeeded() {
+		// Fehler nur bei Änderung melden, der Befehl wird jeden Zyklus wiederholt
+		if record.Error != c.sendError {
+			c.logger.Printf("%s nicht auf %.2f gestellt (%s): %s", c.cfg.Actor, output, record.Status, record.Error)
+		}
+		c.sendError = record.Error
+		c.hasSent = false
+		return
+	}
+
+	if c.sendError != "" {
+		c.logger.Printf("%s wieder gestellt", c.cfg.Actor)
+	}
+	c.sendError = ""
+	c.sent = output
+	c.hasSent = true
+}
+
+// modulate schaltet das Relais bei PWM-Ausgabe. Die Einschaltdauer wird zu Beginn jeder Periode
+// aus der Stellgröße übernommen; Pulse kürzer als pwm_min_pulse_seconds werden unterdrückt.
+func (c *Controller) modulate(now time.Time) {
+	c.mutex.Lock()
+	if c.fault != "" || !c.hasPV {
+		c.mutex.Unlock()
+		return
+	}
+
+	if c.periodStart.IsZero() || now.Sub(c.periodStart) >= c.period {
+		c.periodStart = now
+		c.onTime = c.pulse(c.pid.Output())
+	}
+	on := now.Sub(c.periodStart) < c.onTime
+	c.mutex.Unlock()
+
+	c.switchOutput.Set(on)
+}
+
+// pulse berechnet die Einschaltdauer einer PWM-Periode aus der Stellgröße
+func (c *Controller) pulse(output float64) time.Duration {
+	onTime := time.Duration(c.duty(output) * float64(c.period))
+	switch {
+	case onTime < c.minPulse:
+		return 0
+	case c.period-onTime < c.minPulse:
+		return c.period
+	}
+	return onTime
+}
+
+// duty gibt den Anteil der Stellgröße am Stellbereich zurück (0-1)
+func (c *Controller) duty(output float64) float64 {
+	tuning := c.pid.Tuning()
+	return (output - tuning.OutputMin) / (tuning.OutputMax - tuning.OutputMin)
+}
+
+// applyFault bringt den Aktor bei fehlendem Messwert in den sicheren Zustand. Ein Relais ohne
+// konfigurierten sicheren Zustand wird ausgeschaltet, eine Position bleibt unverändert.
+func (c *Controller) applyFault(err error) {
+	handled := false
+	if c.env.Failsafe != nil {
+		handled = len(c.env.Failsafe.ControllerFault(c.cfg.ID, err, c.cfg.Actor)) > 0
+	}
+
+	c.mutex.Lock()
+	c.periodStart = time.Time{}
+	c.hasSent = false
+	c.mutex.Unlock()
+
+	switch {
+	case c.switchOutput == nil:
+		if !handled {
+			c.logger.Printf("Warnung: %s hat keinen sicheren Zustand, Position bleibt unverändert", c.cfg.Actor)
+		}
+	case handled:
+		// Nach der Störung den Sollzustand erneut senden
+		c.switchOutput.Invalidate()
+	default:
+		c.switchOutput.Set(false)
+	}
+}
+
+// ApplyAttributes übernimmt Sollwert, Modus, Handwert, Parameter und Grenzen aus den Shared
+// Attributes <id>_<name>. Beim Wechsel in den Handbetrieb ohne Handwert bleibt die aktuelle
+// Stellgröße erhalten, beim Wechsel in den Automatikbetrieb setzt der Regler stoßfrei dort an.
+func (c *Controller) ApplyAttributes(attributes map[string]interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := func(name string) string { return c.cfg.ID + "_" + name }
+	float := func(name string) (float64, bool) {
+		value, exists, err := controller.FloatAttribute(attributes, key(name))
+		if err != nil {
+			c.logger.Println(err)
+			return 0, false
+		}
+		return value, exists
+	}
+
+	if value, ok := float(AttributeSetpoint); ok {
+		c.setpoint = value
+	}
+
+	tuning := c.pid.Tuning()
+	changed := false
+	for name, target := range map[string]*float64{
+		AttributeKp:        &tuning.Kp,
+		AttributeKi:        &tuning.Ki,
+		AttributeKd:        &tuning.Kd,
+		AttributeOutputMin: &tuning.OutputMin,
+		AttributeOutputMax: &tuning.OutputMax,
+		AttributeRateLimit: &tuning.RateLimit,
+	} {
+		if value, ok := float(name); ok && value != *target {
+			*target = value
+			changed = true
+		}
+	}
+	if changed {
+		if err := validateTuning(tuning); err != nil {
+			c.logger.Printf("Parameter nicht übernommen: %v", err)
+		} else {
+			c.pid.SetTuning(tuning)
+			c.logger.Printf("Parameter: kp=%v ki=%v kd=%v, Stellgröße %v-%v, Rate %v/s",
+				tuning.Kp, tuning.Ki, tuning.Kd, tuning.OutputMin, tuning.OutputMax, tuning.RateLimit)
+		}
+	}
+
+	manualOutput, hasManualOutput := float(AttributeManualOutput)
+	if raw, exists := attributes[key(AttributeMode)]; exists {
+		mode, _ := raw.(string)
+		switch {
+		case mode != config.PIDModeAuto && mode != config.PIDModeManual:
+			c.logger.Printf("Attribut %s: unbekannter Modus %v", key(AttributeMode), raw)
+		case mode != c.mode:
+			if mode == config.PIDModeManual && !hasManualOutput {
+				// Stoßfrei in den Handbetrieb: die aktuelle Stellgröße bleibt stehen
+				c.manualOutput = c.pid.Output()
+			}
+			c.mode = mode
+			c.logger.Printf("Betriebsart %s", mode)
+		}
+	}
+	if hasManualOutput {
+		c.manualOutput = manualOutput
+	}
+}
+
+// validateTuning prüft zur Laufzeit geänderte Parameter
+func validateTuning(tuning Tuning) error {
+	if tuning.Kp < 0 || tuning.Ki < 0 || tuning.Kd < 0 || tuning.RateLimit < 0 {
+		return fmt.Errorf("kp, ki, kd und rate_limit dürfen nicht negativ sein")
+	}
+	if tuning.OutputMin >= tuning.OutputMax {
+		return fmt.Errorf("output_min %v muss kleiner als output_max %v sein", tuning.OutputMin, tuning.OutputMax)
+	}
+	return nil
+}
+
+// Status gibt den aktuellen Zustand des Reglers zurück
+func (c *Controller) Status() interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.statusLocked()
+}
+
+// statusLocked erstellt den Status, der Aufrufer hält die Sperre
+func (c *Controller) statusLocked() Status {
+	tuning := c.pid.Tuning()
+	status := Status{
+		Mode:         c.mode,
+		Setpoint:     c.setpoint,
+		Output:       c.pid.Output(),
+		ManualOutput: c.manualOutput,
+		Terms:        c.pid.Terms(),
+		Kp:           tuning.Kp,
+		Ki:           tuning.Ki,
+		Kd:           tuning.Kd,
+		Reverse:      tuning.Reverse,
+		OutputMin:    tuning.OutputMin,
+		OutputMax:    tuning.OutputMax,
+		RateLimit:    tuning.RateLimit,
+		Fault:        c.fault,
+	}
+	if c.hasPV {
+		pv := c.pv
+		status.ProcessValue = &pv
+	}
+	if c.switchOutput != nil {
+		duty := c.onTime.Seconds() / c.period.Seconds()
+		status.Duty = &duty
+	} else if c.hasSent {
+		sent := c.sent
+		status.Sent = &sent
+	}
+	return status
+}
+
+// publishTelemetry sendet Sollwert, Istwert, Stellgröße und die Anteile des Reglers als <id>_<name>
+func (c *Controller) publishTelemetry() {
+	if c.env.Publish == nil {
+		return
+	}
+
+	status := c.Status().(Status)
+	prefix := c.cfg.ID + "_"
+	telemetry := map[string]interface{}{
+		prefix + "setpoint":  status.Setpoint,
+		prefix + "output":    status.Output,
+		prefix + "mode":      status.Mode,
+		prefix + "error":     status.Terms.Error,
+		prefix + "p":         status.Terms.P,
+		prefix + "i":         status.Terms.I,
+		prefix + "d":         status.Terms.D,
+		prefix + "saturated": status.Terms.Saturated,
+		prefix + "fault":     status.Fault,
+	}
+	if status.ProcessValue != nil {
+		telemetry[prefix+"process_value"] = *status.ProcessValue
+	}
+	if status.Duty != nil {
+		telemetry[prefix+"duty"] = *status.Duty
+	}
+
+	c.env.Publish(map[string]interface{}{"simple": telemetry})
+}
+
+// publishAlarm meldet eine Störung des Reglers
+func (c *Controller) publishAlarm(active bool, message string) {
+	severity := event.SeverityCritical
+	if !active {
+		severity = event.SeverityInfo
+	}
+
+	c.env.Registry.EventBus().Publish(event.NewAlarmEvent(event.Alarm{
+		Name:     c.cfg.ID + "_fault",
+		DeviceID: c.cfg.Sensor,
+		Severity: severity,
+		Active:   active,
+		Message:  message,
+	}))
+}
+
+// number wandelt einen numerischen Messwert in float64 um
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,235 @@
+package pid
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// step ist ein Regelschritt mit erwarteter Stellgröße
+type step struct {
+	setpoint, pv float64
+	dt           time.Duration
+	want         float64
+}
+
+// TestStep prüft die Stellgröße über mehrere Regelschritte
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name   string
+		tuning Tuning
+		steps  []step
+	}{
+		{
+			// P = 2*10, I = 0,5*10*1, kein D im ersten Schritt; danach D = -1 * (42-40)/1
+			name:   "P, I und D auf den Messwert",
+			tuning: Tuning{Kp: 2, Ki: 0.5, Kd: 1, OutputMin: 0, OutputMax: 100},
+			steps: []step{
+				{setpoint: 50, pv: 40, dt: time.Second, want: 25},
+				{setpoint: 50, pv: 42, dt: time.Second, want: 23},
+			},
+		},
+		{
+			name:   "kein D-Sprung bei Sollwertänderung",
+			tuning: Tuning{Kp: 1, Kd: 10, OutputMin: 0, OutputMax: 100},
+			steps: []step{
+				{setpoint: 50, pv: 40, dt: time.Second, want: 10},
+				{setpoint: 60, pv: 40, dt: time.Second, want: 20},
+			},
+		},
+		{
+			// Messwert über dem Sollwert erhöht die Stellgröße, steigender Messwert ebenso
+			name:   "umgekehrte Wirkrichtung",
+			tuning: Tuning{Kp: 2, Ki: 0.5, Kd: 1, Reverse: true, OutputMin: 0, OutputMax: 100},
+			steps: []step{
+				{setpoint: 7, pv: 8, dt: time.Second, want: 2.5},
+				{setpoint: 7, pv: 8.5, dt: time.Second, want: 4.75},
+				{setpoint: 7, pv: 6, dt: time.Second, want: 0},
+			},
+		},
+		{
+			name:   "Begrenzung der Stellgröße",
+			tuning: Tuning{Kp: 10, OutputMin: 20, OutputMax: 80},
+			steps: []step{
+				{setpoint: 50, pv: 0, dt: time.Second, want: 80},
+				{setpoint: 50, pv: 100, dt: time.Second, want: 20},
+			},
+		},
+		{
+			// Ohne Anti-Windup stünde der I-Anteil nach fünf Schritten bei 500
+			name:   "Anti-Windup oben",
+			tuning: Tuning{Kp: 1, Ki: 1, OutputMin: 0, OutputMax: 10},
+			steps: []step{
+				{setpoint: 100, pv: 0, dt: time.Second, want: 10},
+				{setpoint: 100, pv: 0, dt: time.Second, want: 10},
+				{setpoint: 100, pv: 0, dt: time.Second, want: 10},
+				{setpoint: 100, pv: 0, dt: time.Second, want: 10},
+				{setpoint: 100, pv: 0, dt: time.Second, want: 10},
+				{setpoint: 5, pv: 5, dt: time.Second, want: 0},
+			},
+		},
+		{
+			name:   "Anti-Windup unten",
+			tuning: Tuning{Kp: 1, Ki: 1, OutputMin: 0, OutputMax: 100},
+			steps: []step{
+				{setpoint: 10, pv: 0, dt: time.Second, want: 20},
+				{setpoint: 0, pv: 50, dt: time.Second, want: 0},
+				{setpoint: 0, pv: 50, dt: time.Second, want: 0},
+				{setpoint: 0, pv: 50, dt: time.Second, want: 0},
+				{setpoint: 5, pv: 0, dt: time.Second, want: 20},
+			},
+		},
+		{
+			name:   "Änderungsrate",
+			tuning: Tuning{Kp: 1, OutputMin: 0, OutputMax: 100, RateLimit: 2},
+			steps: []step{
+				{setpoint: 50, pv: 0, dt: time.Second, want: 2},
+				{setpoint: 50, pv: 0, dt: 3 * time.Second, want: 8},
+				{setpoint: 50, pv: 45, dt: time.Second, want: 6},
+				{setpoint: 50, pv: 45, dt: time.Second, want: 5},
+			},
+		},
+		{
+			name:   "Änderungsrate ohne Zeitschritt",
+			tuning: Tuning{Kp: 1, OutputMin: 0, OutputMax: 100, RateLimit: 2},
+			steps: []step{
+				{setpoint: 50, pv: 0, dt: time.Second, want: 2},
+				{setpoint: 50, pv: 0, dt: 0, want: 50},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.tuning)
+			for i, s := range tt.steps {
+				if got := p.Step(s.setpoint, s.pv, s.dt); !approx(got, s.want) {
+					t.Fatalf("Schritt %d: Stellgröße %v, erwartet %v (Anteile %+v)", i+1, got, s.want, p.Terms())
+				}
+			}
+		})
+	}
+}
+
+// TestStepSaturated prüft die Meldung der Begrenzung
+func TestStepSaturated(t *testing.T) {
+	tests := []struct {
+		name   string
+		tuning Tuning
+		step   step
+		want   bool
+	}{
+		{"innerhalb der Grenzen", Tuning{Kp: 1, OutputMax: 100}, step{setpoint: 50, pv: 40, dt: time.Second}, false},
+		{"obere Grenze", Tuning{Kp: 1, OutputMax: 5}, step{setpoint: 50, pv: 40, dt: time.Second}, true},
+		{"untere Grenze", Tuning{Kp: 1, OutputMax: 100}, step{setpoint: 40, pv: 50, dt: time.Second}, true},
+		{"Änderungsrate", Tuning{Kp: 1, OutputMax: 100, RateLimit: 1}, step{setpoint: 50, pv: 40, dt: time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.tuning)
+			p.Step(tt.step.setpoint, tt.step.pv, tt.step.dt)
+			if got := p.Terms().Saturated; got != tt.want {
+				t.Errorf("Saturated = %v, erwartet %v (Anteile %+v)", got, tt.want, p.Terms())
+			}
+		})
+	}
+}
+
+// TestTrack prüft die stoßfreie Übernahme nach dem Nachführen
+func TestTrack(t *testing.T) {
+	tests := []struct {
+		name   string
+		tuning Tuning
+		track  float64
+		// setpoint und pv gelten für Track und den folgenden Step
+		setpoint, pv float64
+		next         step
+	}{
+		{
+			// I-Anteil = 60 - 2*5 = 50, ohne Zeitschritt bleibt die Stellgröße bei 60
+			name:     "ohne Zeitschritt",
+			tuning:   Tuning{Kp: 2, Ki: 0.5, OutputMin: 0, OutputMax: 100},
+			track:    60,
+			setpoint: 50,
+			pv:       45,
+			next:     step{setpoint: 50, pv: 45, dt: 0, want: 60},
+		},
+		{
+			name:     "mit Zeitschritt",
+			tuning:   Tuning{Kp: 2, Ki: 0.5, OutputMin: 0, OutputMax: 100},
+			track:    60,
+			setpoint: 50,
+			pv:       45,
+			next:     step{setpoint: 50, pv: 45, dt: time.Second, want: 62.5},
+		},
+		{
+			// Der zuletzt nachgeführte Messwert verhindert einen D-Sprung
+			name:     "kein D-Sprung",
+			tuning:   Tuning{Kp: 2, Kd: 10, OutputMin: 0, OutputMax: 100},
+			track:    60,
+			setpoint: 50,
+			pv:       45,
+			next:     step{setpoint: 50, pv: 45, dt: time.Second, want: 60},
+		},
+		{
+			name:     "umgekehrte Wirkrichtung",
+			tuning:   Tuning{Kp: 2, Reverse: true, OutputMin: 0, OutputMax: 100},
+			track:    30,
+			setpoint: 7,
+			pv:       8,
+			next:     step{setpoint: 7, pv: 8, dt: time.Second, want: 30},
+		},
+		{
+			name:     "Stellgröße außerhalb der Grenzen",
+			tuning:   Tuning{Kp: 1, OutputMin: 0, OutputMax: 80},
+			track:    150,
+			setpoint: 50,
+			pv:       50,
+			next:     step{setpoint: 50, pv: 50, dt: 0, want: 80},
+		},
+		{
+			name:     "Begrenzung der Änderungsrate gilt ab dem nachgeführten Wert",
+			tuning:   Tuning{Kp: 1, OutputMin: 0, OutputMax: 100, RateLimit: 5},
+			track:    40,
+			setpoint: 50,
+			pv:       0,
+			next:     step{setpoint: 50, pv: 0, dt: time.Second, want: 45},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.tuning)
+			p.Step(tt.setpoint, tt.pv+20, time.Second)
+
+			p.Track(tt.track, tt.setpoint, tt.pv)
+			if want := clamp(tt.track, tt.tuning.OutputMin, tt.tuning.OutputMax); !approx(p.Output(), want) {
+				t.Fatalf("Output nach Track = %v, erwartet %v", p.Output(), want)
+			}
+
+			if got := p.Step(tt.next.setpoint, tt.next.pv, tt.next.dt); !approx(got, tt.next.want) {
+				t.Errorf("Stellgröße nach Track %v, erwartet %v (Anteile %+v)", got, tt.next.want, p.Terms())
+			}
+		})
+	}
+}
+
+// TestSetTuning prüft, dass neue Grenzen Stellgröße und I-Anteil begrenzen
+func TestSetTuning(t *testing.T) {
+	p := New(Tuning{Ki: 1, OutputMin: 0, OutputMax: 100})
+	p.Track(100, 0, 0)
+
+	p.SetTuning(Tuning{Ki: 1, OutputMin: 0, OutputMax: 40})
+	if !approx(p.Output(), 40) {
+		t.Errorf("Output nach SetTuning = %v, erwartet 40", p.Output())
+	}
+	if got := p.Step(0, 0, 0); !approx(got, 40) {
+		t.Errorf("Stellgröße nach SetTuning = %v, erwartet 40 (I-Anteil begrenzt)", got)
+	}
+}
+
+// approx vergleicht Gleitkommazahlen mit Rundungstoleranz
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
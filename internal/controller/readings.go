@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/types"
+)
+
+// Readings hält die neuesten Messwerte der Geräte eines Reglers in einem event.SampleCache.
+// Die Werte kommen vom Event-Bus, gelesen werden die Geräte weiterhin vom SensorAdapter.
+// Zurückgelesene Aktorzustände überschreiben den Messwert eines Hybridgeräts nicht.
+type Readings struct {
+	bus          *event.Bus
+	subscriberID string
+	samples      *event.SampleCache
+}
+
+// NewReadings erstellt einen Zwischenspeicher für die Messwerte der angegebenen Geräte
+func NewReadings(bus *event.Bus, subscriberID string, deviceIDs ...string) *Readings {
+	return &Readings{
+		bus:          bus,
+		subscriberID: subscriberID,
+		samples:      event.NewSampleCache(deviceIDs...),
+	}
+}
+
+// Start abonniert die Messwerte auf dem Event-Bus
+func (r *Readings) Start() error {
+	if err := r.bus.Subscribe(r.subscriberID, r.samples.Handle, event.SubscriberOptions{}, event.TopicReadingProduced); err != nil {
+		return fmt.Errorf("fehler beim Abonnieren der Messwerte: %w", err)
+	}
+	return nil
+}
+
+// Stop beendet das Abonnement
+func (r *Readings) Stop() {
+	r.bus.Unsubscribe(r.subscriberID)
+}
+
+// Latest gibt den neuesten Messwert eines Geräts zurück. Fehlt er oder ist er älter als
+// maxAge (0 = beliebig alt), wird ein Fehler zurückgegeben.
+func (r *Readings) Latest(deviceID string, maxAge time.Duration) (event.Sample, error) {
+	sample, exists := r.samples.Reading(deviceID)
+	if !exists {
+		return event.Sample{}, fmt.Errorf("noch kein Messwert von %s", deviceID)
+	}
+	if age := time.Since(sample.At); maxAge > 0 && age > maxAge {
+		return sample, fmt.Errorf("messwert von %s ist %s alt (maximal %s)", deviceID, age.Round(time.Second), maxAge)
+	}
+	if sample.Reading.Quality == types.QualityBad {
+		return sample, fmt.Errorf("messwert von %s hat die Qualität %s", deviceID, sample.Reading.Quality)
+	}
+	return sample, nil
+}
@@ -0,0 +1,179 @@
+// Package actuator implementiert verschiedene Aktortypen.
+package actuator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"owipex_reader/internal/types"
+)
+
+// BaseActuator ist eine grundlegende Implementierung eines Aktors,
+// die von spezifischen Aktortypen erweitert werden kann.
+type BaseActuator struct {
+	id                string
+	name              string
+	enabled           bool
+	metadata          map[string]interface{}
+	availableCommands []types.CommandType
+	mutex             sync.RWMutex
+	protocol          types.ProtocolHandler
+	failsafe          *Failsafe
+}
+
+// NewBaseActuator erstellt einen neuen BaseActuator
+func NewBaseActuator(id, name string, commands ...types.CommandType) *BaseActuator {
+	return &BaseActuator{
+		id:                id,
+		name:              name,
+		enabled:           true,
+		metadata:          make(map[string]interface{}),
+		availableCommands: commands,
+	}
+}
+
+// ID gibt die eindeutige Kennung des Aktors zurück
+func (a *BaseActuator) ID() string {
+	return a.id
+}
+
+// Name gibt den Anzeigenamen des Aktors zurück
+func (a *BaseActuator) Name() string {
+	return a.name
+}
+
+// Type gibt den Typ des Geräts zurück
+func (a *BaseActuator) Type() types.DeviceType {
+	return types.TypeActor
+}
+
+// Metadata gibt aktorspezifische Metadaten zurück
+func (a *BaseActuator) Metadata() map[string]interface{} {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	// Kopie der Metadaten erstellen
+	metadata := make(map[string]interface{}, len(a.metadata))
+	for k, v := range a.metadata {
+		metadata[k] = v
+	}
+
+	return metadata
+}
+
+// IsEnabled prüft, ob der Aktor aktiviert ist
+func (a *BaseActuator) IsEnabled() bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.enabled
+}
+
+// Enable aktiviert oder deaktiviert den Aktor
+func (a *BaseActuator) Enable(enabled bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.enabled = enabled
+}
+
+// SetMetadata setzt einen Metadaten-Wert
+func (a *BaseActuator) SetMetadata(key string, value interface{}) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.metadata[key] = value
+}
+
+// GetMetadata gibt einen Metadaten-Wert zurück
+func (a *BaseActuator) GetMetadata(key string) (interface{}, bool) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	value, exists := a.metadata[key]
+	return value, exists
+}
+
+// SetProtocol setzt den Protocol-Handler für die Kommunikation
+func (a *BaseActuator) SetProtocol(protocol types.ProtocolHandler) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.protocol = protocol
+}
+
+// GetProtocol gibt den aktuellen Protocol-Handler zurück
+func (a *BaseActuator) GetProtocol() types.ProtocolHandler {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.protocol
+}
+
+// CheckWritable prüft, ob der Aktor aktiviert ist und einen Protokoll-Handler hat
+func (a *BaseActuator) CheckWritable() (types.ProtocolHandler, error) {
+	a.mutex.RLock()
+	protocol := a.protocol
+	enabled := a.enabled
+	a.mutex.RUnlock()
+
+	if !enabled {
+		return nil, fmt.Errorf("aktor %s ist deaktiviert", a.id)
+	}
+	if protocol == nil {
+		return nil, fmt.Errorf("kein Protokoll-Handler für Aktor %s konfiguriert", a.id)
+	}
+
+	return protocol, nil
+}
+
+// Write sendet einen Steuerbefehl an den Aktor
+func (a *BaseActuator) Write(ctx context.Context, command types.Command) error {
+	// Diese Basisimplementierung muss von konkreten Aktoren überschrieben werden
+	return fmt.Errorf("Write muss von spezifischen Aktorimplementierungen überschrieben werden")
+}
+
+// WriteRaw sendet Rohdaten an den Aktor
+func (a *BaseActuator) WriteRaw(ctx context.Context, data []byte) error {
+	// Diese Basisimplementierung muss von konkreten Aktoren überschrieben werden
+	return fmt.Errorf("WriteRaw muss von spezifischen Aktorimplementierungen überschrieben werden")
+}
+
+// GetState gibt den aktuellen Zustand des Aktors zurück
+func (a *BaseActuator) GetState() (interface{}, error) {
+	// Diese Basisimplementierung muss von konkreten Aktoren überschrieben werden
+	return nil, fmt.Errorf("GetState muss von spezifischen Aktorimplementierungen überschrieben werden")
+}
+
+// AvailableCommands gibt die verfügbaren Befehlstypen zurück
+func (a *BaseActuator) AvailableCommands() []types.CommandType {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	// Kopie erstellen, um Änderungen zu verhindern
+	commands := make([]types.CommandType, len(a.availableCommands))
+	copy(commands, a.availableCommands)
+
+	return commands
+}
+
+// Close gibt Ressourcen frei und beendet die Aktorkommunikation
+func (a *BaseActuator) Close() error {
+	a.mutex.Lock()
+	protocol := a.protocol
+	a.mutex.Unlock()
+
+	if protocol != nil {
+		return protocol.Close()
+	}
+	return nil
+}
+
+// StateMismatchError wird zurückgegeben, wenn der zurückgelesene Zustand eines Aktors
+// nicht dem zuletzt befohlenen Zustand entspricht (z.B. Schütz klemmt, Handbetrieb,
+// Sicherung ausgelöst)
+type StateMismatchError struct {
+	DeviceID  string
+	Commanded interface{}
+	Actual    interface{}
+}
+
+// Error implementiert das error-Interface
+func (e *StateMismatchError) Error() string {
+	return fmt.Sprintf("aktor %s: Zustand %v befohlen, aber %v zurückgemeldet", e.DeviceID, e.Commanded, e.Actual)
+}
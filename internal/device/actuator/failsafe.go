@@ -0,0 +1,140 @@
+package actuator
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"owipex_reader/internal/types"
+)
+
+// Failsafe beschreibt den sicheren Zustand eines Aktors. Er wird beim Beenden, bei
+// Reglerfehlern, bei Ablauf des Watchdogs und optional bei Verlust der ThingsBoard-Verbindung
+// hergestellt.
+type Failsafe struct {
+	// Command stellt den sicheren Zustand her (z.B. SET_STATE false)
+	Command types.Command
+
+	// OnConnectionLoss wendet den sicheren Zustand auch bei Verlust der ThingsBoard-Verbindung an
+	OnConnectionLoss bool
+
+	// Hardware sind Register, mit denen die geräteeigene Rückfallebene bei Kommunikationsverlust
+	// beim Start programmiert wird (z.B. Timeout und Ausgangszustand eines Relaismoduls)
+	Hardware []RegisterWrite
+}
+
+// RegisterWrite ist ein Registerwert, der beim Start geschrieben wird
+type RegisterWrite struct {
+	Address uint16
+	Value   uint16
+}
+
+// FailsafeSchema beschreibt den Metadata-Block "failsafe" eines Aktors
+func FailsafeSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": "Sicherer Zustand beim Beenden, bei Reglerfehlern, Watchdog-Ablauf und optional bei Verbindungsverlust",
+		"properties": map[string]interface{}{
+			"command":            map[string]interface{}{"type": "string", "default": string(types.CommandTypeSetState)},
+			"value":              map[string]interface{}{"description": "Wert des Befehls (z.B. false, \"stop\", 0)"},
+			"on_connection_loss": map[string]interface{}{"type": "boolean", "default": false, "description": "Auch bei Verlust der ThingsBoard-Verbindung anwenden"},
+			"hardware": map[string]interface{}{
+				"type":        "array",
+				"description": "Register der geräteeigenen Rückfallebene, die beim Start geschrieben werden",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"address": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+						"value":   map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+					},
+					"required": []interface{}{"address", "value"},
+				},
+			},
+		},
+		"required": []interface{}{"value"},
+	}
+}
+
+// ParseFailsafe liest den Metadata-Block "failsafe". Ohne Block wird nil zurückgegeben.
+func ParseFailsafe(metadata map[string]interface{}) (*Failsafe, error) {
+	raw, exists := metadata["failsafe"]
+	if !exists {
+		return nil, nil
+	}
+	block, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failsafe muss ein Objekt sein")
+	}
+
+	value, exists := block["value"]
+	if !exists {
+		return nil, fmt.Errorf("failsafe.value fehlt")
+	}
+
+	commandType := types.CommandTypeSetState
+	if name, ok := block["command"].(string); ok && name != "" {
+		commandType = types.CommandType(strings.ToUpper(name))
+	}
+
+	failsafe := &Failsafe{Command: types.NewCommand(commandType, value)}
+	failsafe.OnConnectionLoss, _ = block["on_connection_loss"].(bool)
+
+	if raw, exists := block["hardware"]; exists {
+		entries, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("failsafe.hardware muss eine Liste sein")
+		}
+		for i, entry := range entries {
+			register, ok := entry.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("failsafe.hardware[%d] muss ein Objekt sein", i)
+			}
+			address, okAddress := register["address"].(float64)
+			value, okValue := register["value"].(float64)
+			if !okAddress || !okValue || address < 0 || address > 65535 || value < 0 || value > 65535 {
+				return nil, fmt.Errorf("failsafe.hardware[%d]: address und value müssen zwischen 0 und 65535 liegen", i)
+			}
+			failsafe.Hardware = append(failsafe.Hardware, RegisterWrite{Address: uint16(address), Value: uint16(value)})
+		}
+	}
+
+	return failsafe, nil
+}
+
+// SetFailsafe setzt den sicheren Zustand des Aktors (nil = keiner)
+func (a *BaseActuator) SetFailsafe(failsafe *Failsafe) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.failsafe = failsafe
+}
+
+// Failsafe gibt den sicheren Zustand des Aktors zurück (nil, wenn keiner konfiguriert ist)
+func (a *BaseActuator) Failsafe() *Failsafe {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.failsafe
+}
+
+// ProgramFailsafe schreibt die Register der geräteeigenen Rückfallebene, damit die Ausgänge
+// auch dann in den sicheren Zustand gehen, wenn der Prozess abstürzt
+func (a *BaseActuator) ProgramFailsafe(ctx context.Context) error {
+	failsafe := a.Failsafe()
+	if failsafe == nil || len(failsafe.Hardware) == 0 {
+		return nil
+	}
+
+	protocol, err := a.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	for _, register := range failsafe.Hardware {
+		data := make([]byte, 2)
+		binary.BigEndian.PutUint16(data, register.Value)
+		if err := protocol.WriteRegister(ctx, register.Address, data); err != nil {
+			return fmt.Errorf("fehler beim Schreiben der Rückfallebene (Register %d) von %s: %w", register.Address, a.id, err)
+		}
+	}
+	return nil
+}
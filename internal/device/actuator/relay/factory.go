@@ -0,0 +1,167 @@
+package relay
+
+import (
+	"fmt"
+	"time"
+
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/protocol/factory"
+	"owipex_reader/internal/types"
+)
+
+// pointSchema beschreibt einen Ausgang bzw. eine Rückmeldung
+func pointSchema(pointTypes ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"type":    map[string]interface{}{"type": "string", "enum": pointTypes},
+			"address": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+			"bit":     map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 15, "description": "Bit im Holding-Register (nur holding_bit)"},
+		},
+		"required": []interface{}{"type", "address"},
+	}
+}
+
+// Descriptor beschreibt den Gerätetyp "relay" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	return types.TypeDescriptor{
+		Type:        "relay",
+		Name:        "Relais",
+		Description: "Relaisausgang (z.B. Pumpe, CO2-Ventil, Heizung) über Modbus-Coil oder Bit eines Holding-Registers, mit optionaler Rückmeldung",
+		Category:    types.TypeActor,
+		Commands:    []types.CommandType{types.CommandTypeSetState},
+		Protocol:    "modbus",
+		ConfigDir:   "actuators/relay",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus":   factory.ModbusSchema(),
+				"failsafe": actuator.FailsafeSchema(),
+				"relay": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"output":            pointSchema(PointTypeCoil, PointTypeHoldingBit),
+						"feedback":          pointSchema(PointTypeCoil, PointTypeDiscrete, PointTypeHoldingBit),
+						"inverted":          map[string]interface{}{"type": "boolean", "default": false, "description": "Ausgang invertiert (Relais zieht bei 0 an)"},
+						"feedback_inverted": map[string]interface{}{"type": "boolean", "default": false, "description": "Rückmeldung invertiert (Öffnerkontakt)"},
+						"verify_delay_ms":   map[string]interface{}{"type": "integer", "minimum": 0, "default": 200, "description": "Wartezeit zwischen Schalten und Zurücklesen"},
+					},
+					"required": []interface{}{"output"},
+				},
+			},
+			"required": []interface{}{"modbus", "relay"},
+		},
+		Defaults: map[string]interface{}{
+			"relay": map[string]interface{}{
+				"verify_delay_ms": float64(DefaultVerifyDelay / time.Millisecond),
+			},
+		},
+	}
+}
+
+// CreateRelayActuator erstellt einen Relais-Aktor aus einer Konfiguration
+func CreateRelayActuator(config types.DeviceConfig) (types.Actor, error) {
+	relayConfig, err := parseConfig(config.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("ungültige Relais-Konfiguration: %w", err)
+	}
+
+	modbusConfig, ok := config.Metadata["modbus"].(map[string]interface{})
+	if config.Protocol != "modbus" || !ok {
+		return nil, fmt.Errorf("relais benötigt das Protokoll modbus mit einem Metadata-Block \"modbus\"")
+	}
+
+	// Relais am selben Gerät teilen sich die Sperre für Holding-Register
+	port, _ := modbusConfig["port"].(string)
+	if port == "" {
+		port = factory.DefaultModbusPort
+	}
+	lockKey := fmt.Sprintf("%s/%v", port, modbusConfig["slave_id"])
+
+	failsafe, err := actuator.ParseFailsafe(config.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	relay := NewRelayActuator(config.ID, config.Name, relayConfig, lockKey)
+	relay.SetFailsafe(failsafe)
+
+	protocol, err := factory.CreateProtocolHandler("modbus", modbusConfig)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Protokoll-Handlers: %w", err)
+	}
+	relay.SetProtocol(protocol)
+
+	if !config.Enabled {
+		relay.Enable(false)
+	}
+
+	return relay, nil
+}
+
+// parseConfig liest den Metadata-Block "relay"
+func parseConfig(metadata map[string]interface{}) (Config, error) {
+	config := Config{VerifyDelay: DefaultVerifyDelay}
+
+	block, ok := metadata["relay"].(map[string]interface{})
+	if !ok {
+		return config, fmt.Errorf("metadata-Block \"relay\" fehlt")
+	}
+
+	output, ok := block["output"].(map[string]interface{})
+	if !ok {
+		return config, fmt.Errorf("kein Ausgang (relay.output) angegeben")
+	}
+	point, err := ParsePoint(output)
+	if err != nil {
+		return config, fmt.Errorf("relay.output: %w", err)
+	}
+	if point.Type == PointTypeDiscrete {
+		return config, fmt.Errorf("relay.output: diskrete Eingänge können nicht geschaltet werden")
+	}
+	config.Output = point
+
+	if feedback, ok := block["feedback"].(map[string]interface{}); ok {
+		point, err := ParsePoint(feedback)
+		if err != nil {
+			return config, fmt.Errorf("relay.feedback: %w", err)
+		}
+		config.Feedback = &point
+	}
+
+	config.Inverted, _ = block["inverted"].(bool)
+	config.FeedbackInverted, _ = block["feedback_inverted"].(bool)
+
+	if delay, ok := block["verify_delay_ms"].(float64); ok {
+		config.VerifyDelay = time.Duration(delay) * time.Millisecond
+	}
+
+	return config, nil
+}
+
+// ParsePoint liest die Adressierung eines Ausgangs oder einer Rückmeldung
+func ParsePoint(block map[string]interface{}) (Point, error) {
+	var point Point
+
+	point.Type, _ = block["type"].(string)
+	switch point.Type {
+	case PointTypeCoil, PointTypeDiscrete, PointTypeHoldingBit:
+	default:
+		return point, fmt.Errorf("unbekannter Typ %q (erlaubt: coil, discrete, holding_bit)", point.Type)
+	}
+
+	address, ok := block["address"].(float64)
+	if !ok || address < 0 || address > 65535 {
+		return point, fmt.Errorf("ungültige Adresse %v", block["address"])
+	}
+	point.Address = uint16(address)
+
+	if bit, ok := block["bit"].(float64); ok {
+		if bit < 0 || bit > 15 {
+			return point, fmt.Errorf("bit %v liegt außerhalb von 0-15", bit)
+		}
+		point.Bit = uint(bit)
+	}
+
+	return point, nil
+}
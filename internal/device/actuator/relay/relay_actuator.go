@@ -0,0 +1,324 @@
+// Package relay implementiert einen Relais-Aktor (z.B. Pumpe, CO2-Ventil, Heizung), der über
+// Modbus-Coils oder einzelne Bits eines Holding-Registers geschaltet wird.
+package relay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/types"
+)
+
+// Konstanten für Relais
+const (
+	// Adressierungsarten eines Relaisausgangs bzw. einer Rückmeldung
+	PointTypeCoil       = "coil"
+	PointTypeDiscrete   = "discrete"
+	PointTypeHoldingBit = "holding_bit"
+
+	// DefaultVerifyDelay ist die Wartezeit zwischen Schalten und Zurücklesen
+	DefaultVerifyDelay = 200 * time.Millisecond
+
+	// stateTimeout begrenzt das Zurücklesen in GetState
+	stateTimeout = 5 * time.Second
+)
+
+// Point adressiert einen Ausgang oder eine Rückmeldung
+type Point struct {
+	Type    string
+	Address uint16
+	Bit     uint
+}
+
+// String gibt den Punkt lesbar aus
+func (p Point) String() string {
+	if p.Type == PointTypeHoldingBit {
+		return fmt.Sprintf("%s %d.%d", p.Type, p.Address, p.Bit)
+	}
+	return fmt.Sprintf("%s %d", p.Type, p.Address)
+}
+
+// Config enthält die Relais-Konfiguration aus dem Metadata-Block "relay"
+type Config struct {
+	// Output ist der geschaltete Ausgang
+	Output Point
+
+	// Feedback ist die Rückmeldung des tatsächlichen Zustands (z.B. Hilfskontakt eines Schützes).
+	// Ohne Rückmeldung wird der Ausgang zurückgelesen.
+	Feedback *Point
+
+	// Inverted kehrt den Ausgang um (Relais zieht bei 0 an)
+	Inverted bool
+
+	// FeedbackInverted kehrt die Rückmeldung um (z.B. Öffnerkontakt)
+	FeedbackInverted bool
+
+	// VerifyDelay ist die Wartezeit zwischen Schalten und Zurücklesen
+	VerifyDelay time.Duration
+}
+
+// RelayActuator implementiert einen Relais-Aktor mit Zustandsrückmeldung
+type RelayActuator struct {
+	*actuator.BaseActuator
+
+	config    Config
+	lockKey   string
+	commanded *bool
+	actual    *bool
+	stateMu   sync.Mutex
+}
+
+// registerLocks serialisiert Lese-Ändern-Schreiben-Zugriffe auf Holding-Register, deren
+// Bits von mehreren Relais (z.B. Kanälen einer Relaiskarte) geteilt werden
+var registerLocks sync.Map
+
+// NewRelayActuator erstellt einen neuen Relais-Aktor. lockKey identifiziert Gerät und Bus
+// (z.B. "<port>/<slave_id>"), damit Relais mit gemeinsamem Holding-Register sich nicht stören.
+func NewRelayActuator(id, name string, config Config, lockKey string) *RelayActuator {
+	if config.VerifyDelay < 0 {
+		config.VerifyDelay = 0
+	}
+
+	return &RelayActuator{
+		BaseActuator: actuator.NewBaseActuator(id, name, types.CommandTypeSetState),
+		config:       config,
+		lockKey:      lockKey,
+	}
+}
+
+// Write schaltet das Relais (CommandTypeSetState, Wert true/false, "on"/"off" oder 1/0)
+// und liest den Zustand danach zurück. Weicht er ab, wird ein *actuator.StateMismatchError
+// zurückgegeben.
+func (r *RelayActuator) Write(ctx context.Context, command types.Command) error {
+	if command.Type != types.CommandTypeSetState {
+		return fmt.Errorf("befehl %s wird von Relais %s nicht unterstützt", command.Type, r.ID())
+	}
+
+	state, err := ParseState(command.Value)
+	if err != nil {
+		return fmt.Errorf("ungültiger Zustand für Relais %s: %w", r.ID(), err)
+	}
+
+	if err := r.SetState(ctx, state); err != nil {
+		return err
+	}
+
+	// Schaltzeit abwarten, bevor die Rückmeldung gelesen wird
+	if r.config.VerifyDelay > 0 {
+		timer := time.NewTimer(r.config.VerifyDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	_, err = r.ReadState(ctx)
+	return err
+}
+
+// SetState schaltet den Ausgang, ohne die Rückmeldung zu prüfen
+func (r *RelayActuator) SetState(ctx context.Context, state bool) error {
+	protocol, err := r.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	if err := r.writePoint(ctx, protocol, r.config.Output, state != r.config.Inverted); err != nil {
+		return fmt.Errorf("fehler beim Schalten von Relais %s: %w", r.ID(), err)
+	}
+
+	r.stateMu.Lock()
+	r.commanded = &state
+	r.stateMu.Unlock()
+
+	return nil
+}
+
+// WriteRaw schreibt ein Holding-Register des Ausgangs unverändert
+func (r *RelayActuator) WriteRaw(ctx context.Context, data []byte) error {
+	protocol, err := r.CheckWritable()
+	if err != nil {
+		return err
+	}
+	if r.config.Output.Type != PointTypeHoldingBit {
+		return fmt.Errorf("WriteRaw ist nur für Relais an Holding-Registern möglich")
+	}
+
+	unlock := r.lockRegister(r.config.Output.Address)
+	defer unlock()
+
+	return protocol.WriteRegister(ctx, r.config.Output.Address, data)
+}
+
+// GetState liest den tatsächlichen Zustand (bool) zurück. Weicht er vom zuletzt befohlenen
+// Zustand ab, wird zusätzlich ein *actuator.StateMismatchError zurückgegeben.
+func (r *RelayActuator) GetState() (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+
+	return r.GetStateContext(ctx)
+}
+
+// GetStateContext liest den tatsächlichen Zustand mit dem Kontext des Aufrufers zurück (types.StateReader)
+func (r *RelayActuator) GetStateContext(ctx context.Context) (interface{}, error) {
+	return r.ReadState(ctx)
+}
+
+// Running meldet, ob das Relais eingeschaltet ist (types.RunningReporter)
+func (r *RelayActuator) Running(state interface{}) bool {
+	on, _ := state.(bool)
+	return on
+}
+
+// ReadState liest den tatsächlichen Zustand und vergleicht ihn mit dem befohlenen
+func (r *RelayActuator) ReadState(ctx context.Context) (bool, error) {
+	protocol := r.GetProtocol()
+	if protocol == nil {
+		return false, fmt.Errorf("kein Protokoll-Handler für Aktor %s konfiguriert", r.ID())
+	}
+
+	point, inverted := r.config.Output, r.config.Inverted
+	if r.config.Feedback != nil {
+		point, inverted = *r.config.Feedback, r.config.FeedbackInverted
+	}
+
+	raw, err := ReadPoint(ctx, protocol, point)
+	if err != nil {
+		return false, fmt.Errorf("fehler beim Lesen des Zustands von Relais %s: %w", r.ID(), err)
+	}
+	actual := raw != inverted
+
+	r.stateMu.Lock()
+	r.actual = &actual
+	commanded := r.commanded
+	r.stateMu.Unlock()
+
+	if commanded != nil && *commanded != actual {
+		return actual, &actuator.StateMismatchError{DeviceID: r.ID(), Commanded: *commanded, Actual: actual}
+	}
+
+	return actual, nil
+}
+
+// Commanded gibt den zuletzt befohlenen Zustand zurück (ok ist false, solange nicht geschaltet wurde)
+func (r *RelayActuator) Commanded() (state bool, ok bool) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	if r.commanded == nil {
+		return false, false
+	}
+	return *r.commanded, true
+}
+
+// writePoint setzt einen Ausgang
+func (r *RelayActuator) writePoint(ctx context.Context, protocol types.ProtocolHandler, point Point, value bool) error {
+	switch point.Type {
+	case PointTypeCoil:
+		coils, ok := protocol.(types.CoilHandler)
+		if !ok {
+			return fmt.Errorf("protokoll-Handler unterstützt keine Coils")
+		}
+		return coils.WriteCoil(ctx, point.Address, value)
+
+	case PointTypeHoldingBit:
+		// Lese-Ändern-Schreiben, damit die übrigen Bits des Registers erhalten bleiben
+		unlock := r.lockRegister(point.Address)
+		defer unlock()
+
+		current, err := readHoldingRegister(ctx, protocol, point.Address)
+		if err != nil {
+			return err
+		}
+
+		updated := current &^ (1 << point.Bit)
+		if value {
+			updated |= 1 << point.Bit
+		}
+		if updated == current {
+			return nil
+		}
+
+		return protocol.WriteRegister(ctx, point.Address, []byte{byte(updated >> 8), byte(updated)})
+
+	default:
+		return fmt.Errorf("ausgang vom Typ %s kann nicht geschaltet werden", point.Type)
+	}
+}
+
+// ReadPoint liest einen Ausgang, einen Eingang oder ein Registerbit
+func ReadPoint(ctx context.Context, protocol types.ProtocolHandler, point Point) (bool, error) {
+	switch point.Type {
+	case PointTypeCoil, PointTypeDiscrete:
+		coils, ok := protocol.(types.CoilHandler)
+		if !ok {
+			return false, fmt.Errorf("protokoll-Handler unterstützt keine Coils")
+		}
+		if point.Type == PointTypeCoil {
+			return coils.ReadCoil(ctx, point.Address)
+		}
+		return coils.ReadDiscreteInput(ctx, point.Address)
+
+	case PointTypeHoldingBit:
+		value, err := readHoldingRegister(ctx, protocol, point.Address)
+		if err != nil {
+			return false, err
+		}
+		return value&(1<<point.Bit) != 0, nil
+
+	default:
+		return false, fmt.Errorf("unbekannter Punkttyp %s", point.Type)
+	}
+}
+
+// lockRegister sperrt ein Holding-Register des Geräts für Lese-Ändern-Schreiben
+func (r *RelayActuator) lockRegister(address uint16) func() {
+	value, _ := registerLocks.LoadOrStore(fmt.Sprintf("%s/%d", r.lockKey, address), &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// readHoldingRegister liest ein einzelnes Holding-Register als uint16
+func readHoldingRegister(ctx context.Context, protocol types.ProtocolHandler, address uint16) (uint16, error) {
+	data, err := protocol.ReadRegister(ctx, address, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 2 {
+		return 0, fmt.Errorf("unvollständige Antwort beim Lesen von Register %d", address)
+	}
+	return uint16(data[0])<<8 | uint16(data[1]), nil
+}
+
+// ParseState wandelt einen Befehlswert in einen Schaltzustand um.
+// Erlaubt sind true/false, 1/0 und "on"/"off", "ein"/"aus", "true"/"false", "1"/"0".
+func ParseState(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case float64:
+		if v == 0 || v == 1 {
+			return v == 1, nil
+		}
+	case int:
+		if v == 0 || v == 1 {
+			return v == 1, nil
+		}
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "on", "ein", "true", "1":
+			return true, nil
+		case "off", "aus", "false", "0":
+			return false, nil
+		}
+	}
+
+	return false, fmt.Errorf("zustand %v ist weder ein noch aus", value)
+}
@@ -0,0 +1,196 @@
+package valve
+
+import (
+	"fmt"
+	"time"
+
+	"owipex_reader/internal/device/actuator/relay"
+)
+
+// Ansteuerungsarten eines Ventils
+const (
+	// ModeModbus: Stellungsregler mit Sollwert- und Rückmelderegister
+	ModeModbus = "modbus"
+	// ModeRelay: Motor mit je einem Relaisausgang für Auf und Zu, Stellung aus Laufzeit oder Rückmeldung
+	ModeRelay = "relay"
+)
+
+// Standardwerte der Laufzeitüberwachung
+const (
+	DefaultTravelTime          = 60 * time.Second
+	DefaultTravelTimeoutFactor = 1.5
+	DefaultStallTimeout        = 10 * time.Second
+	DefaultTolerance           = 2.0
+	DefaultScale               = 1.0
+)
+
+// Register ist ein Stellungsregister (0-100 % = 0-100/Scale Registereinheiten)
+type Register struct {
+	Address uint16
+	Scale   float64
+}
+
+// Config enthält die Ventil-Konfiguration aus dem Metadata-Block "valve"
+type Config struct {
+	Mode string
+
+	// Setpoint ist das Sollwertregister des Stellungsreglers (nur ModeModbus)
+	Setpoint *Register
+
+	// Feedback ist das Register der Stellungsrückmeldung. Im Relaisbetrieb ohne Rückmeldung
+	// wird die Stellung aus der Laufzeit berechnet.
+	Feedback *Register
+
+	// OpenOutput und CloseOutput fahren den Motor auf bzw. zu (nur ModeRelay)
+	OpenOutput  *relay.Point
+	CloseOutput *relay.Point
+	Inverted    bool
+
+	// OpenLimit und CloseLimit sind die Endschalter. Eine Endlage gilt erst mit Endschalter
+	// als erreicht.
+	OpenLimit      *relay.Point
+	CloseLimit     *relay.Point
+	LimitsInverted bool
+
+	// TravelTime ist die Laufzeit von 0 auf 100 %
+	TravelTime time.Duration
+
+	// TravelTimeoutFactor verlängert die erwartete Laufzeit bis zur Störmeldung
+	TravelTimeoutFactor float64
+
+	// StallTimeout ist die Zeit ohne Stellungsänderung, nach der das Ventil als blockiert gilt
+	StallTimeout time.Duration
+
+	// Tolerance ist die erlaubte Abweichung zwischen Soll- und Iststellung in %
+	Tolerance float64
+}
+
+// parseConfig liest den Metadata-Block "valve"
+func parseConfig(metadata map[string]interface{}) (Config, error) {
+	config := Config{
+		TravelTime:          DefaultTravelTime,
+		TravelTimeoutFactor: DefaultTravelTimeoutFactor,
+		StallTimeout:        DefaultStallTimeout,
+		Tolerance:           DefaultTolerance,
+	}
+
+	block, ok := metadata["valve"].(map[string]interface{})
+	if !ok {
+		return config, fmt.Errorf("metadata-Block \"valve\" fehlt")
+	}
+
+	config.Mode, _ = block["mode"].(string)
+
+	var err error
+	if config.Setpoint, err = parseRegister(block, "setpoint"); err != nil {
+		return config, err
+	}
+	if config.Feedback, err = parseRegister(block, "feedback"); err != nil {
+		return config, err
+	}
+	if config.OpenOutput, err = parsePoint(block, "open_output"); err != nil {
+		return config, err
+	}
+	if config.CloseOutput, err = parsePoint(block, "close_output"); err != nil {
+		return config, err
+	}
+	if config.OpenLimit, err = parsePoint(block, "open_limit"); err != nil {
+		return config, err
+	}
+	if config.CloseLimit, err = parsePoint(block, "close_limit"); err != nil {
+		return config, err
+	}
+	config.Inverted, _ = block["inverted"].(bool)
+	config.LimitsInverted, _ = block["limits_inverted"].(bool)
+
+	if value, ok := block["travel_time_seconds"].(float64); ok {
+		config.TravelTime = time.Duration(value * float64(time.Second))
+	}
+	if value, ok := block["travel_timeout_factor"].(float64); ok {
+		config.TravelTimeoutFactor = value
+	}
+	if value, ok := block["stall_timeout_seconds"].(float64); ok {
+		config.StallTimeout = time.Duration(value * float64(time.Second))
+	}
+	if value, ok := block["tolerance"].(float64); ok {
+		config.Tolerance = value
+	}
+
+	switch config.Mode {
+	case ModeModbus:
+		if config.Setpoint == nil || config.Feedback == nil {
+			return config, fmt.Errorf("modus modbus benötigt valve.setpoint und valve.feedback")
+		}
+	case ModeRelay:
+		if config.OpenOutput == nil || config.CloseOutput == nil {
+			return config, fmt.Errorf("modus relay benötigt valve.open_output und valve.close_output")
+		}
+		if config.OpenOutput.Type == relay.PointTypeDiscrete || config.CloseOutput.Type == relay.PointTypeDiscrete {
+			return config, fmt.Errorf("diskrete Eingänge können nicht als Ausgang geschaltet werden")
+		}
+		if *config.OpenOutput == *config.CloseOutput {
+			return config, fmt.Errorf("valve.open_output und valve.close_output dürfen nicht derselbe Ausgang sein")
+		}
+	default:
+		return config, fmt.Errorf("unbekannter Modus %q (erlaubt: %s, %s)", config.Mode, ModeModbus, ModeRelay)
+	}
+
+	if config.TravelTime <= 0 {
+		return config, fmt.Errorf("travel_time_seconds muss größer als 0 sein")
+	}
+	if config.TravelTimeoutFactor < 1 {
+		return config, fmt.Errorf("travel_timeout_factor muss mindestens 1 sein")
+	}
+	if config.StallTimeout <= 0 {
+		return config, fmt.Errorf("stall_timeout_seconds muss größer als 0 sein")
+	}
+	if config.Tolerance <= 0 || config.Tolerance >= 50 {
+		return config, fmt.Errorf("tolerance muss zwischen 0 und 50 %% liegen")
+	}
+
+	return config, nil
+}
+
+// parseRegister liest ein optionales Stellungsregister
+func parseRegister(block map[string]interface{}, name string) (*Register, error) {
+	raw, exists := block[name]
+	if !exists {
+		return nil, nil
+	}
+	registerBlock, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("valve.%s muss ein Objekt sein", name)
+	}
+
+	address, ok := registerBlock["address"].(float64)
+	if !ok || address < 0 || address > 65535 {
+		return nil, fmt.Errorf("valve.%s: ungültige Adresse %v", name, registerBlock["address"])
+	}
+
+	register := &Register{Address: uint16(address), Scale: DefaultScale}
+	if scale, ok := registerBlock["scale"].(float64); ok {
+		if scale <= 0 {
+			return nil, fmt.Errorf("valve.%s: scale muss größer als 0 sein", name)
+		}
+		register.Scale = scale
+	}
+	return register, nil
+}
+
+// parsePoint liest einen optionalen Ausgang oder Endschalter
+func parsePoint(block map[string]interface{}, name string) (*relay.Point, error) {
+	raw, exists := block[name]
+	if !exists {
+		return nil, nil
+	}
+	pointBlock, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("valve.%s muss ein Objekt sein", name)
+	}
+
+	point, err := relay.ParsePoint(pointBlock)
+	if err != nil {
+		return nil, fmt.Errorf("valve.%s: %w", name, err)
+	}
+	return &point, nil
+}
@@ -0,0 +1,123 @@
+package valve
+
+import (
+	"fmt"
+
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/protocol/factory"
+	"owipex_reader/internal/types"
+)
+
+// pointSchema beschreibt einen Relaisausgang oder Endschalter
+func pointSchema(description string, pointTypes ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": description,
+		"properties": map[string]interface{}{
+			"type":    map[string]interface{}{"type": "string", "enum": pointTypes},
+			"address": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+			"bit":     map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 15, "description": "Bit im Holding-Register (nur holding_bit)"},
+		},
+		"required": []interface{}{"type", "address"},
+	}
+}
+
+// registerSchema beschreibt ein Stellungsregister
+func registerSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": description,
+		"properties": map[string]interface{}{
+			"address": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+			"scale":   map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": DefaultScale, "description": "Prozent je Registereinheit (z.B. 0.1 für 0-1000)"},
+		},
+		"required": []interface{}{"address"},
+	}
+}
+
+// Descriptor beschreibt den Gerätetyp "valve" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	return types.TypeDescriptor{
+		Type:        "valve",
+		Name:        "Motorventil",
+		Description: "Motorisches Stellventil mit Stellungsrückmeldung 0-100 %, Laufzeitüberwachung und optionalen Endschaltern. Ansteuerung über einen Stellungsregler (Modbus-Register) oder zwei Relaisausgänge Auf/Zu",
+		Category:    types.TypeHybrid,
+		Readings:    []types.ReadingType{types.ReadingTypePosition},
+		Commands:    []types.CommandType{types.CommandTypeSetPosition, types.CommandTypeSetState, types.CommandTypeReset},
+		Protocol:    "modbus",
+		ConfigDir:   "actuators/valve",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus":   factory.ModbusSchema(),
+				"failsafe": actuator.FailsafeSchema(),
+				"valve": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"mode":                  map[string]interface{}{"type": "string", "enum": []interface{}{ModeModbus, ModeRelay}},
+						"setpoint":              registerSchema("Sollwertregister des Stellungsreglers (nur mode modbus)"),
+						"feedback":              registerSchema("Register der Stellungsrückmeldung (bei mode relay optional)"),
+						"open_output":           pointSchema("Relaisausgang Auf (nur mode relay)", "coil", "holding_bit"),
+						"close_output":          pointSchema("Relaisausgang Zu (nur mode relay)", "coil", "holding_bit"),
+						"inverted":              map[string]interface{}{"type": "boolean", "default": false, "description": "Relaisausgänge invertiert"},
+						"open_limit":            pointSchema("Endschalter Auf", "coil", "discrete", "holding_bit"),
+						"close_limit":           pointSchema("Endschalter Zu", "coil", "discrete", "holding_bit"),
+						"limits_inverted":       map[string]interface{}{"type": "boolean", "default": false, "description": "Endschalter als Öffner"},
+						"travel_time_seconds":   map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": DefaultTravelTime.Seconds(), "description": "Laufzeit von 0 auf 100 %"},
+						"travel_timeout_factor": map[string]interface{}{"type": "number", "minimum": 1, "default": DefaultTravelTimeoutFactor, "description": "Vielfaches der erwarteten Laufzeit bis zur Störmeldung"},
+						"stall_timeout_seconds": map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": DefaultStallTimeout.Seconds(), "description": "Zeit ohne Stellungsänderung bis zur Meldung \"blockiert\""},
+						"tolerance":             map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "maximum": 50, "default": DefaultTolerance, "description": "Erlaubte Abweichung von der Sollstellung in %"},
+					},
+					"required": []interface{}{"mode"},
+				},
+			},
+			"required": []interface{}{"modbus", "valve"},
+		},
+		Defaults: map[string]interface{}{
+			"valve": map[string]interface{}{
+				"travel_time_seconds": DefaultTravelTime.Seconds(),
+				"tolerance":           DefaultTolerance,
+			},
+		},
+	}
+}
+
+// CreateValveActuator erstellt ein Ventil aus einer Konfiguration
+func CreateValveActuator(config types.DeviceConfig) (types.Actor, error) {
+	valveConfig, err := parseConfig(config.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("ungültige Ventil-Konfiguration: %w", err)
+	}
+
+	modbusConfig, ok := config.Metadata["modbus"].(map[string]interface{})
+	if config.Protocol != "modbus" || !ok {
+		return nil, fmt.Errorf("ventil benötigt das Protokoll modbus mit einem Metadata-Block \"modbus\"")
+	}
+
+	failsafe, err := actuator.ParseFailsafe(config.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Relaisausgänge am selben Gerät teilen sich die Sperre für Holding-Register mit den Relais
+	port, _ := modbusConfig["port"].(string)
+	if port == "" {
+		port = factory.DefaultModbusPort
+	}
+	lockKey := fmt.Sprintf("%s/%v", port, modbusConfig["slave_id"])
+
+	valve := NewValveActuator(config.ID, config.Name, valveConfig, lockKey)
+	valve.SetFailsafe(failsafe)
+
+	protocol, err := factory.CreateProtocolHandler("modbus", modbusConfig)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Protokoll-Handlers: %w", err)
+	}
+	valve.SetProtocol(protocol)
+
+	if !config.Enabled {
+		valve.Enable(false)
+	}
+
+	return valve, nil
+}
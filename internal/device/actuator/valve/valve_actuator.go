@@ -0,0 +1,678 @@
+// Package valve implementiert ein motorisches Stellventil (z.B. Auslaufventil) mit Stellungsrückmeldung,
+// Laufzeitüberwachung und optionalen Endschaltern. Das Ventil wird entweder über die Register eines
+// Stellungsreglers oder über zwei Relaisausgänge (Auf/Zu) mit Laufzeitberechnung angesteuert.
+package valve
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/device/actuator/relay"
+	"owipex_reader/internal/types"
+)
+
+// Fahrtrichtungen
+const (
+	DirectionOpening = "opening"
+	DirectionClosing = "closing"
+)
+
+const (
+	// pollInterval ist der Abstand der Stellungsabfragen während einer Fahrt
+	pollInterval = 500 * time.Millisecond
+
+	// minTravelTimeout ist die Mindestzeit bis zur Störmeldung bei kurzen Fahrten
+	minTravelTimeout = 2 * time.Second
+
+	// overtravel verlängert Fahrten in eine Endlage ohne Rückmeldung und Endschalter (Anteil der Laufzeit)
+	overtravel = 0.1
+
+	// pendingMargin ist die Zeit vor Ablauf des Befehlskontexts, zu der Write bei noch laufender
+	// Fahrt zurückkehrt, damit der Zustand im selben Kontext zurückgelesen werden kann
+	pendingMargin = time.Second
+
+	// stateTimeout begrenzt Zugriffe außerhalb eines Befehls (GetState, Stopp beim Schließen)
+	stateTimeout = 5 * time.Second
+)
+
+// ValveActuator implementiert ein motorisches Stellventil
+type ValveActuator struct {
+	*actuator.BaseActuator
+
+	config     Config
+	openRelay  *relay.RelayActuator
+	closeRelay *relay.RelayActuator
+
+	stateMu   sync.Mutex
+	position  *float64
+	target    *float64
+	direction string
+	fault     string
+
+	// Fahrt im Relaisbetrieb ohne Rückmeldung, aus der die Stellung berechnet wird
+	moveStart float64
+	moveBegin time.Time
+
+	cancelMove context.CancelFunc
+	moveDone   chan struct{}
+}
+
+// NewValveActuator erstellt ein neues Ventil. lockKey identifiziert Gerät und Bus, damit sich
+// Relaisausgänge an einem gemeinsamen Holding-Register nicht stören.
+func NewValveActuator(id, name string, config Config, lockKey string) *ValveActuator {
+	valve := &ValveActuator{
+		BaseActuator: actuator.NewBaseActuator(id, name,
+			types.CommandTypeSetPosition, types.CommandTypeSetState, types.CommandTypeReset),
+		config: config,
+	}
+
+	if config.Mode == ModeRelay {
+		valve.openRelay = relay.NewRelayActuator(id+".open", name+" Auf", relay.Config{Output: *config.OpenOutput, Inverted: config.Inverted}, lockKey)
+		valve.closeRelay = relay.NewRelayActuator(id+".close", name+" Zu", relay.Config{Output: *config.CloseOutput, Inverted: config.Inverted}, lockKey)
+	}
+
+	return valve
+}
+
+// Type gibt den Gerätetyp zurück: Das Ventil wird gesteuert und liefert seine Stellung als Messwert
+func (v *ValveActuator) Type() types.DeviceType {
+	return types.TypeHybrid
+}
+
+// SetProtocol setzt den Protocol-Handler für Ventil und Relaisausgänge
+func (v *ValveActuator) SetProtocol(protocol types.ProtocolHandler) {
+	v.BaseActuator.SetProtocol(protocol)
+	if v.openRelay != nil {
+		v.openRelay.SetProtocol(protocol)
+		v.closeRelay.SetProtocol(protocol)
+	}
+}
+
+// Write verarbeitet die Befehle des Ventils:
+//   - SET_POSITION: Sollstellung in % (0 = zu, 100 = offen)
+//   - SET_STATE: "open"/true, "close"/false oder "stop"
+//   - RESET: hält das Ventil an und quittiert eine Störung
+//
+// Fahrbefehle kehren erst mit dem Ende der Fahrt zurück, siehe MoveTo.
+func (v *ValveActuator) Write(ctx context.Context, command types.Command) error {
+	switch command.Type {
+	case types.CommandTypeSetPosition:
+		target, err := ParsePosition(command.Value)
+		if err != nil {
+			return fmt.Errorf("ungültige Stellung für Ventil %s: %w", v.ID(), err)
+		}
+		return v.MoveTo(ctx, target)
+
+	case types.CommandTypeSetState:
+		if text, ok := command.Value.(string); ok && strings.EqualFold(strings.TrimSpace(text), "stop") {
+			return v.Stop(ctx)
+		}
+		open, err := ParseOpen(command.Value)
+		if err != nil {
+			return fmt.Errorf("ungültiger Zustand für Ventil %s: %w", v.ID(), err)
+		}
+		if open {
+			return v.MoveTo(ctx, 100)
+		}
+		return v.MoveTo(ctx, 0)
+
+	case types.CommandTypeReset:
+		if err := v.Stop(ctx); err != nil {
+			return err
+		}
+		v.stateMu.Lock()
+		v.fault = ""
+		v.stateMu.Unlock()
+		return nil
+
+	default:
+		return fmt.Errorf("befehl %s wird von Ventil %s nicht unterstützt", command.Type, v.ID())
+	}
+}
+
+// MoveTo fährt das Ventil auf eine Stellung in %. Eine laufende Fahrt wird abgebrochen,
+// eine gemeldete Störung mit dem neuen Fahrbefehl zurückgesetzt.
+//
+// MoveTo wartet, bis Endschalter oder Rückmeldung die Sollstellung melden, und gibt eine
+// Störung (Blockade, Überschreitung der Fahrzeit) als Fehler zurück. Reicht der Kontext nicht
+// bis zum Ende der Fahrt, kehrt MoveTo kurz vor dessen Ablauf mit types.ErrPending zurück,
+// die Fahrt wird im Hintergrund weiter überwacht.
+func (v *ValveActuator) MoveTo(ctx context.Context, target float64) error {
+	protocol, err := v.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	v.stopMove()
+
+	start, known, err := v.currentPosition(ctx, protocol)
+	if err != nil {
+		return err
+	}
+	if !known && target != 0 && target != 100 {
+		return fmt.Errorf("stellung von Ventil %s ist unbekannt, zuerst ganz öffnen oder schließen", v.ID())
+	}
+	if !known {
+		// Ungünstigster Fall: Das Ventil steht in der entgegengesetzten Endlage
+		start = 100 - target
+	}
+
+	direction := DirectionOpening
+	if target < start {
+		direction = DirectionClosing
+	}
+
+	switch v.config.Mode {
+	case ModeModbus:
+		raw := math.Round(target / v.config.Setpoint.Scale)
+		if raw < 0 || raw > 65535 {
+			return fmt.Errorf("sollstellung %.1f %% ergibt Registerwert %.0f außerhalb von 0-65535", target, raw)
+		}
+		value := uint16(raw)
+		if err := protocol.WriteRegister(ctx, v.config.Setpoint.Address, []byte{byte(value >> 8), byte(value)}); err != nil {
+			return fmt.Errorf("fehler beim Schreiben der Sollstellung von Ventil %s: %w", v.ID(), err)
+		}
+
+	case ModeRelay:
+		if known && math.Abs(target-start) <= v.config.Tolerance && !v.needsLimit(target) {
+			v.setState(&start, &target, "", "")
+			return nil
+		}
+
+		// Erst den Gegenausgang abschalten, damit nie beide Richtungen gleichzeitig anliegen
+		drive, opposite := v.openRelay, v.closeRelay
+		if direction == DirectionClosing {
+			drive, opposite = v.closeRelay, v.openRelay
+		}
+		if err := opposite.SetState(ctx, false); err != nil {
+			return fmt.Errorf("fehler beim Abschalten von Ventil %s: %w", v.ID(), err)
+		}
+		if err := drive.SetState(ctx, true); err != nil {
+			v.outputsOff()
+			return fmt.Errorf("fehler beim Anfahren von Ventil %s: %w", v.ID(), err)
+		}
+	}
+
+	moveCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	result := make(chan error, 1)
+
+	v.stateMu.Lock()
+	if known {
+		v.position = &start
+	}
+	v.target = &target
+	v.direction = direction
+	v.fault = ""
+	v.moveStart = start
+	v.moveBegin = time.Now()
+	v.cancelMove = cancel
+	v.moveDone = done
+	v.stateMu.Unlock()
+
+	go v.supervise(moveCtx, done, result, protocol, start, target, known)
+
+	var deadline <-chan time.Time
+	if d, ok := ctx.Deadline(); ok {
+		timer := time.NewTimer(time.Until(d) - pendingMargin)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-deadline:
+	case <-ctx.Done():
+	}
+	return fmt.Errorf("%w: ventil %s fährt noch auf %.1f %%", types.ErrPending, v.ID(), target)
+}
+
+// Stop hält das Ventil in der aktuellen Stellung an
+func (v *ValveActuator) Stop(ctx context.Context) error {
+	protocol, err := v.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	v.stopMove()
+
+	switch v.config.Mode {
+	case ModeRelay:
+		if err := v.openRelay.SetState(ctx, false); err != nil {
+			return fmt.Errorf("fehler beim Anhalten von Ventil %s: %w", v.ID(), err)
+		}
+		if err := v.closeRelay.SetState(ctx, false); err != nil {
+			return fmt.Errorf("fehler beim Anhalten von Ventil %s: %w", v.ID(), err)
+		}
+
+	case ModeModbus:
+		// Die aktuelle Stellung als Sollwert schreiben
+		position, err := v.readFeedback(ctx, protocol)
+		if err != nil {
+			return fmt.Errorf("fehler beim Anhalten von Ventil %s: %w", v.ID(), err)
+		}
+		raw := uint16(math.Round(position / v.config.Setpoint.Scale))
+		if err := protocol.WriteRegister(ctx, v.config.Setpoint.Address, []byte{byte(raw >> 8), byte(raw)}); err != nil {
+			return fmt.Errorf("fehler beim Anhalten von Ventil %s: %w", v.ID(), err)
+		}
+	}
+
+	v.stateMu.Lock()
+	v.target = nil
+	v.direction = ""
+	v.stateMu.Unlock()
+	return nil
+}
+
+// supervise überwacht eine Fahrt bis zum Erreichen der Sollstellung, einer Störung oder dem Abbruch
+// und meldet das Ergebnis über result
+func (v *ValveActuator) supervise(ctx context.Context, done chan struct{}, result chan<- error, protocol types.ProtocolHandler, start, target float64, known bool) {
+	defer close(done)
+
+	finish := func(position float64, fault string) {
+		v.finishMove(position, fault)
+		if fault != "" {
+			result <- fmt.Errorf("fahrt von Ventil %s: %s", v.ID(), fault)
+			return
+		}
+		result <- nil
+	}
+
+	distance := math.Abs(target - start)
+	expected := time.Duration(float64(v.config.TravelTime) * distance / 100)
+	timeout := time.Duration(float64(expected) * v.config.TravelTimeoutFactor)
+	if timeout < minTravelTimeout {
+		timeout = minTravelTimeout
+	}
+
+	// Ohne Rückmeldung und Endschalter wird die Laufzeit gefahren, in Endlagen mit Nachlauf
+	runFor := expected
+	if target == 0 || target == 100 || !known {
+		runFor += time.Duration(float64(v.config.TravelTime) * overtravel)
+	}
+
+	begin := time.Now()
+	lastProgress, lastProgressAt := start, begin
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Abbruch durch neuen Befehl, Stopp oder Schließen: berechnete Stellung festhalten
+			if v.config.Feedback == nil {
+				position := v.estimate(start, target, time.Since(begin))
+				v.stateMu.Lock()
+				v.position = &position
+				v.stateMu.Unlock()
+			}
+			result <- fmt.Errorf("fahrt von Ventil %s auf %.1f %% abgebrochen", v.ID(), target)
+			return
+
+		case <-ticker.C:
+		}
+
+		elapsed := time.Since(begin)
+		readCtx, cancel := context.WithTimeout(ctx, stateTimeout)
+
+		position := v.estimate(start, target, elapsed)
+		if v.config.Feedback != nil {
+			if value, err := v.readFeedback(readCtx, protocol); err == nil {
+				position = value
+			} else {
+				// Lesefehler zählen als Stillstand
+				position = lastProgress
+			}
+		}
+		openLimit, closeLimit, limitErr := v.readLimits(readCtx, protocol)
+		cancel()
+
+		if ctx.Err() != nil {
+			continue
+		}
+
+		if limitErr == nil && openLimit && closeLimit {
+			finish(position, "beide Endschalter melden gleichzeitig")
+			return
+		}
+
+		var reached bool
+		switch {
+		case target == 100 && v.config.OpenLimit != nil:
+			reached = limitErr == nil && openLimit
+		case target == 0 && v.config.CloseLimit != nil:
+			reached = limitErr == nil && closeLimit
+		case v.config.Feedback != nil:
+			reached = math.Abs(position-target) <= v.config.Tolerance
+		default:
+			reached = elapsed >= runFor
+		}
+		if reached {
+			if v.needsLimit(target) || v.config.Feedback == nil {
+				position = target
+			}
+			finish(position, "")
+			return
+		}
+
+		if v.config.Feedback != nil {
+			if math.Abs(position-lastProgress) >= v.config.Tolerance/4 {
+				lastProgress, lastProgressAt = position, time.Now()
+			} else if time.Since(lastProgressAt) > v.config.StallTimeout {
+				finish(position, fmt.Sprintf("ventil blockiert bei %.1f %% (Soll %.1f %%)", position, target))
+				return
+			}
+		}
+
+		if elapsed > timeout {
+			finish(position, fmt.Sprintf("sollstellung %.1f %% nicht innerhalb von %s erreicht (Ist %.1f %%)", target, timeout.Round(time.Second), position))
+			return
+		}
+	}
+}
+
+// finishMove beendet eine Fahrt, schaltet im Relaisbetrieb den Motor ab und übernimmt Stellung und Störung
+func (v *ValveActuator) finishMove(position float64, fault string) {
+	if v.config.Mode == ModeRelay {
+		if err := v.outputsOff(); err != nil && fault == "" {
+			fault = err.Error()
+		}
+	}
+
+	v.stateMu.Lock()
+	v.position = &position
+	v.direction = ""
+	v.fault = fault
+	v.cancelMove = nil
+	v.moveDone = nil
+	v.stateMu.Unlock()
+}
+
+// stopMove bricht die Überwachung einer laufenden Fahrt ab und wartet auf deren Ende
+func (v *ValveActuator) stopMove() {
+	v.stateMu.Lock()
+	cancel, done := v.cancelMove, v.moveDone
+	v.cancelMove, v.moveDone = nil, nil
+	v.stateMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// outputsOff schaltet beide Relaisausgänge ab
+func (v *ValveActuator) outputsOff() error {
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+
+	errOpen := v.openRelay.SetState(ctx, false)
+	errClose := v.closeRelay.SetState(ctx, false)
+	if errOpen != nil {
+		return fmt.Errorf("fehler beim Abschalten von Ventil %s: %w", v.ID(), errOpen)
+	}
+	if errClose != nil {
+		return fmt.Errorf("fehler beim Abschalten von Ventil %s: %w", v.ID(), errClose)
+	}
+	return nil
+}
+
+// needsLimit prüft, ob für eine Endlage ein Endschalter konfiguriert ist
+func (v *ValveActuator) needsLimit(target float64) bool {
+	return (target == 100 && v.config.OpenLimit != nil) || (target == 0 && v.config.CloseLimit != nil)
+}
+
+// estimate berechnet die Stellung im Relaisbetrieb aus der Laufzeit
+func (v *ValveActuator) estimate(start, target float64, elapsed time.Duration) float64 {
+	travelled := elapsed.Seconds() / v.config.TravelTime.Seconds() * 100
+	if target >= start {
+		return math.Min(start+travelled, target)
+	}
+	return math.Max(start-travelled, target)
+}
+
+// currentPosition gibt die aktuelle Stellung zurück (known ist false, solange sie unbekannt ist)
+func (v *ValveActuator) currentPosition(ctx context.Context, protocol types.ProtocolHandler) (float64, bool, error) {
+	if v.config.Feedback != nil {
+		position, err := v.readFeedback(ctx, protocol)
+		if err != nil {
+			return 0, false, fmt.Errorf("fehler beim Lesen der Stellung von Ventil %s: %w", v.ID(), err)
+		}
+		return position, true, nil
+	}
+
+	openLimit, closeLimit, err := v.readLimits(ctx, protocol)
+	if err == nil && openLimit != closeLimit {
+		if openLimit {
+			return 100, true, nil
+		}
+		return 0, true, nil
+	}
+
+	v.stateMu.Lock()
+	defer v.stateMu.Unlock()
+	if v.position == nil {
+		return 0, false, nil
+	}
+	return *v.position, true, nil
+}
+
+// readFeedback liest die Stellungsrückmeldung in %
+func (v *ValveActuator) readFeedback(ctx context.Context, protocol types.ProtocolHandler) (float64, error) {
+	data, err := protocol.ReadRegister(ctx, v.config.Feedback.Address, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 2 {
+		return 0, fmt.Errorf("unvollständige Antwort beim Lesen von Register %d", v.config.Feedback.Address)
+	}
+	return float64(uint16(data[0])<<8|uint16(data[1])) * v.config.Feedback.Scale, nil
+}
+
+// readLimits liest die Endschalter. Nicht konfigurierte Endschalter melden false.
+func (v *ValveActuator) readLimits(ctx context.Context, protocol types.ProtocolHandler) (open bool, closed bool, err error) {
+	if v.config.OpenLimit != nil {
+		if open, err = relay.ReadPoint(ctx, protocol, *v.config.OpenLimit); err != nil {
+			return false, false, fmt.Errorf("fehler beim Lesen des Endschalters Auf: %w", err)
+		}
+		open = open != v.config.LimitsInverted
+	}
+	if v.config.CloseLimit != nil {
+		if closed, err = relay.ReadPoint(ctx, protocol, *v.config.CloseLimit); err != nil {
+			return false, false, fmt.Errorf("fehler beim Lesen des Endschalters Zu: %w", err)
+		}
+		closed = closed != v.config.LimitsInverted
+	}
+	return open, closed, nil
+}
+
+// setState übernimmt Stellung und Sollstellung ohne Fahrt
+func (v *ValveActuator) setState(position, target *float64, direction, fault string) {
+	v.stateMu.Lock()
+	defer v.stateMu.Unlock()
+	v.position = position
+	v.target = target
+	v.direction = direction
+	v.fault = fault
+}
+
+// Read liest die Stellung als Messwert (POSITION in %). Sollstellung, Fahrtrichtung, Endschalter
+// und Störungen werden als Metadaten gemeldet.
+func (v *ValveActuator) Read(ctx context.Context) (types.Reading, error) {
+	protocol := v.GetProtocol()
+	if protocol == nil {
+		return types.Reading{}, fmt.Errorf("kein Protokoll-Handler konfiguriert")
+	}
+
+	var position *float64
+	estimated := false
+	if v.config.Feedback != nil {
+		value, err := v.readFeedback(ctx, protocol)
+		if err != nil {
+			return types.Reading{}, fmt.Errorf("fehler beim Lesen der Stellung: %w", err)
+		}
+		position = &value
+	}
+
+	openLimit, closeLimit, limitErr := v.readLimits(ctx, protocol)
+
+	v.stateMu.Lock()
+	if position == nil {
+		estimated = true
+		if v.cancelMove != nil && v.target != nil {
+			value := v.estimate(v.moveStart, *v.target, time.Since(v.moveBegin))
+			position = &value
+		} else if limitErr == nil && openLimit != closeLimit {
+			value := 0.0
+			if openLimit {
+				value = 100
+			}
+			v.position = &value
+			position = &value
+		} else if v.position != nil {
+			value := *v.position
+			position = &value
+		}
+	}
+	target := v.target
+	direction := v.direction
+	fault := v.fault
+	v.stateMu.Unlock()
+
+	reading := types.NewReading(types.ReadingTypePosition, nil, "%", nil)
+	if position != nil {
+		reading.Value = *position
+	} else {
+		// Ohne Rückmeldung ist die Stellung bis zur ersten Fahrt in eine Endlage unbekannt
+		reading.Quality = types.QualityBad
+	}
+
+	reading.Metadata["position_known"] = position != nil
+	reading.Metadata["position_estimated"] = estimated
+	if target != nil {
+		reading.Metadata["target"] = *target
+	}
+	reading.Metadata["moving"] = direction != ""
+	reading.Metadata["direction"] = direction
+	reading.Metadata["fault"] = fault
+	reading.Metadata["faulted"] = fault != ""
+
+	if limitErr != nil {
+		reading.Quality = types.QualityUncertain
+		reading.Metadata["limit_error"] = limitErr.Error()
+	} else {
+		if v.config.OpenLimit != nil {
+			reading.Metadata["open_limit"] = openLimit
+		}
+		if v.config.CloseLimit != nil {
+			reading.Metadata["close_limit"] = closeLimit
+		}
+	}
+
+	return reading, nil
+}
+
+// ReadRaw liest das Rohregister der Stellungsrückmeldung
+func (v *ValveActuator) ReadRaw(ctx context.Context) ([]byte, error) {
+	protocol := v.GetProtocol()
+	if protocol == nil {
+		return nil, fmt.Errorf("kein Protokoll-Handler konfiguriert")
+	}
+	if v.config.Feedback == nil {
+		return nil, fmt.Errorf("ventil %s hat keine Stellungsrückmeldung", v.ID())
+	}
+	return protocol.ReadRegister(ctx, v.config.Feedback.Address, 1)
+}
+
+// AvailableReadings gibt die verfügbaren Messwerttypen zurück
+func (v *ValveActuator) AvailableReadings() []types.ReadingType {
+	return []types.ReadingType{types.ReadingTypePosition}
+}
+
+// GetState gibt Stellung, Sollstellung, Fahrtrichtung und Störung zurück
+func (v *ValveActuator) GetState() (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+
+	return v.GetStateContext(ctx)
+}
+
+// GetStateContext liest Stellung und Status mit dem Kontext des Aufrufers (types.StateReader)
+func (v *ValveActuator) GetStateContext(ctx context.Context) (interface{}, error) {
+	reading, err := v.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state := map[string]interface{}{
+		"position": reading.Value,
+	}
+	for _, key := range []string{"target", "moving", "direction", "fault"} {
+		if value, ok := reading.Metadata[key]; ok {
+			state[key] = value
+		}
+	}
+	return state, nil
+}
+
+// Running meldet, ob das Ventil fährt oder nicht geschlossen ist (types.RunningReporter). Eine
+// unbekannte Stellung gilt als offen.
+func (v *ValveActuator) Running(state interface{}) bool {
+	values, ok := state.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if moving, _ := values["moving"].(bool); moving {
+		return true
+	}
+	position, known := values["position"].(float64)
+	return !known || position > 0
+}
+
+// Close hält eine laufende Fahrt an und gibt die Verbindung frei
+func (v *ValveActuator) Close() error {
+	v.stopMove()
+	if v.config.Mode == ModeRelay && v.GetProtocol() != nil {
+		v.outputsOff()
+	}
+	return v.BaseActuator.Close()
+}
+
+// ParsePosition wandelt einen Befehlswert in eine Stellung zwischen 0 und 100 % um
+func ParsePosition(value interface{}) (float64, error) {
+	var position float64
+	switch v := value.(type) {
+	case float64:
+		position = v
+	case int:
+		position = float64(v)
+	default:
+		return 0, fmt.Errorf("stellung %v ist keine Zahl", value)
+	}
+
+	if position < 0 || position > 100 {
+		return 0, fmt.Errorf("stellung %.1f liegt außerhalb von 0-100 %%", position)
+	}
+	return position, nil
+}
+
+// ParseOpen wandelt einen Befehlswert in Auf (true) oder Zu (false) um. Neben den
+// Schaltzuständen eines Relais sind "open"/"auf" und "close"/"zu" erlaubt.
+func ParseOpen(value interface{}) (bool, error) {
+	if text, ok := value.(string); ok {
+		switch strings.ToLower(strings.TrimSpace(text)) {
+		case "open", "auf":
+			return true, nil
+		case "close", "zu":
+			return false, nil
+		}
+	}
+	return relay.ParseState(value)
+}
@@ -0,0 +1,274 @@
+package vfd
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Namen der Überwachungsregister im Metadata-Block "vfd.monitoring"
+const (
+	MonitorOutputFrequency = "output_frequency"
+	MonitorOutputCurrent   = "output_current"
+	MonitorOutputPower     = "output_power"
+	MonitorDCBusVoltage    = "dc_bus_voltage"
+	MonitorRunHours        = "run_hours"
+	MonitorFaultCode       = "fault_code"
+)
+
+// Einheiten des Sollwerts
+const (
+	UnitHz      = "hz"
+	UnitPercent = "percent"
+	UnitRPM     = "rpm"
+)
+
+// monitorUnits sind die Einheiten der Überwachungswerte nach Anwendung des Skalierungsfaktors
+var monitorUnits = map[string]string{
+	MonitorOutputFrequency: "Hz",
+	MonitorOutputCurrent:   "A",
+	MonitorOutputPower:     "kW",
+	MonitorDCBusVoltage:    "V",
+	MonitorRunHours:        "h",
+	MonitorFaultCode:       "",
+}
+
+// RegisterCommand ist ein Befehl, der als fester Wert in ein Register geschrieben wird
+// (z.B. Steuerwort 0x0012 für "Start vorwärts" bei Delta-Umrichtern)
+type RegisterCommand struct {
+	Address uint16
+	Value   uint16
+}
+
+// Monitor beschreibt ein Überwachungsregister
+type Monitor struct {
+	Address   uint16
+	Length    uint16
+	DataType  string
+	ByteOrder string
+	Scale     float64
+}
+
+// Setpoint beschreibt das Sollwertregister
+type Setpoint struct {
+	Address uint16
+
+	// Unit ist die Einheit, die der Umrichter im Register erwartet (hz oder percent der Maximalfrequenz)
+	Unit string
+
+	// Scale ist der Wert einer Registereinheit (z.B. 0.01 bei 0,01 Hz Auflösung)
+	Scale float64
+}
+
+// Ramp beschreibt die Register der Hoch- und Rücklaufzeit
+type Ramp struct {
+	AccelAddress *uint16
+	DecelAddress *uint16
+
+	// Scale ist der Wert einer Registereinheit in Sekunden (z.B. 0.1)
+	Scale float64
+
+	// AccelTime und DecelTime werden vor jedem Start geschrieben (0 = nicht schreiben)
+	AccelTime float64
+	DecelTime float64
+}
+
+// Config enthält die Umrichter-Konfiguration aus dem Metadata-Block "vfd".
+// Sie ist herstellerneutral; die Registerbelegung einer Umrichterfamilie wird im Geräteprofil hinterlegt.
+type Config struct {
+	Start   RegisterCommand
+	Stop    RegisterCommand
+	Reverse *RegisterCommand
+	Reset   *RegisterCommand
+
+	Setpoint     Setpoint
+	MinFrequency float64
+	MaxFrequency float64
+
+	// RatedSpeed ist die Motordrehzahl bei Maximalfrequenz (für Sollwerte in rpm)
+	RatedSpeed float64
+
+	Ramp       Ramp
+	Monitoring map[string]Monitor
+	FaultCodes map[int]string
+}
+
+// parseConfig liest den Metadata-Block "vfd"
+func parseConfig(metadata map[string]interface{}) (Config, error) {
+	config := Config{
+		Monitoring: make(map[string]Monitor),
+		FaultCodes: make(map[int]string),
+	}
+
+	block, ok := metadata["vfd"].(map[string]interface{})
+	if !ok {
+		return config, fmt.Errorf("metadata-Block \"vfd\" fehlt")
+	}
+
+	// Steuerbefehle
+	control, ok := block["control"].(map[string]interface{})
+	if !ok {
+		return config, fmt.Errorf("keine Steuerbefehle (vfd.control) angegeben")
+	}
+	var err error
+	if config.Start, err = parseCommand(control, "start"); err != nil {
+		return config, err
+	}
+	if config.Stop, err = parseCommand(control, "stop"); err != nil {
+		return config, err
+	}
+	for name, target := range map[string]**RegisterCommand{"reverse": &config.Reverse, "reset": &config.Reset} {
+		if _, exists := control[name]; !exists {
+			continue
+		}
+		command, err := parseCommand(control, name)
+		if err != nil {
+			return config, err
+		}
+		*target = &command
+	}
+
+	// Sollwert
+	setpoint, ok := block["setpoint"].(map[string]interface{})
+	if !ok {
+		return config, fmt.Errorf("kein Sollwertregister (vfd.setpoint) angegeben")
+	}
+	address, err := parseAddress(setpoint, "vfd.setpoint")
+	if err != nil {
+		return config, err
+	}
+	config.Setpoint = Setpoint{Address: address, Unit: UnitHz, Scale: floatValue(setpoint, "scale", 0.01)}
+	if unit, ok := setpoint["unit"].(string); ok {
+		if unit != UnitHz && unit != UnitPercent {
+			return config, fmt.Errorf("vfd.setpoint.unit: unbekannte Einheit %q (erlaubt: hz, percent)", unit)
+		}
+		config.Setpoint.Unit = unit
+	}
+	if config.Setpoint.Scale <= 0 {
+		return config, fmt.Errorf("vfd.setpoint.scale muss größer als 0 sein")
+	}
+
+	config.MinFrequency = floatValue(block, "min_frequency", 0)
+	config.MaxFrequency = floatValue(block, "max_frequency", 0)
+	if config.MaxFrequency <= 0 || config.MinFrequency < 0 || config.MinFrequency >= config.MaxFrequency {
+		return config, fmt.Errorf("ungültiger Frequenzbereich %v-%v Hz", config.MinFrequency, config.MaxFrequency)
+	}
+	config.RatedSpeed = floatValue(block, "rated_speed", 0)
+
+	// Rampen
+	if ramp, ok := block["ramp"].(map[string]interface{}); ok {
+		config.Ramp.Scale = floatValue(ramp, "scale", 0.1)
+		config.Ramp.AccelTime = floatValue(ramp, "accel_time", 0)
+		config.Ramp.DecelTime = floatValue(ramp, "decel_time", 0)
+		if _, exists := ramp["accel_address"]; exists {
+			address, err := parseAddress(ramp, "vfd.ramp", "accel_address")
+			if err != nil {
+				return config, err
+			}
+			config.Ramp.AccelAddress = &address
+		}
+		if _, exists := ramp["decel_address"]; exists {
+			address, err := parseAddress(ramp, "vfd.ramp", "decel_address")
+			if err != nil {
+				return config, err
+			}
+			config.Ramp.DecelAddress = &address
+		}
+		if config.Ramp.Scale <= 0 {
+			return config, fmt.Errorf("vfd.ramp.scale muss größer als 0 sein")
+		}
+	}
+
+	// Überwachungsregister
+	if monitoring, ok := block["monitoring"].(map[string]interface{}); ok {
+		for name, value := range monitoring {
+			if _, known := monitorUnits[name]; !known {
+				return config, fmt.Errorf("vfd.monitoring: unbekannter Wert %q", name)
+			}
+			entry, ok := value.(map[string]interface{})
+			if !ok {
+				return config, fmt.Errorf("vfd.monitoring.%s ist kein Objekt", name)
+			}
+			address, err := parseAddress(entry, "vfd.monitoring."+name)
+			if err != nil {
+				return config, err
+			}
+
+			monitor := Monitor{
+				Address:   address,
+				DataType:  "uint16",
+				ByteOrder: "big_endian",
+				Scale:     floatValue(entry, "scale", 1),
+			}
+			if dataType, ok := entry["data_type"].(string); ok {
+				monitor.DataType = dataType
+			}
+			if byteOrder, ok := entry["byte_order"].(string); ok {
+				monitor.ByteOrder = byteOrder
+			}
+			monitor.Length = 1
+			if monitor.DataType == "int32" || monitor.DataType == "uint32" || monitor.DataType == "float32" {
+				monitor.Length = 2
+			}
+			config.Monitoring[name] = monitor
+		}
+	}
+	if _, ok := config.Monitoring[MonitorOutputFrequency]; !ok {
+		return config, fmt.Errorf("kein Register für die Ausgangsfrequenz (vfd.monitoring.output_frequency) angegeben")
+	}
+
+	// Fehlertexte
+	if faults, ok := block["fault_codes"].(map[string]interface{}); ok {
+		for key, value := range faults {
+			code, err := strconv.Atoi(key)
+			if err != nil {
+				return config, fmt.Errorf("vfd.fault_codes: %q ist kein Fehlercode", key)
+			}
+			text, _ := value.(string)
+			config.FaultCodes[code] = text
+		}
+	}
+
+	return config, nil
+}
+
+// parseCommand liest einen Steuerbefehl aus vfd.control
+func parseCommand(control map[string]interface{}, name string) (RegisterCommand, error) {
+	block, ok := control[name].(map[string]interface{})
+	if !ok {
+		return RegisterCommand{}, fmt.Errorf("steuerbefehl vfd.control.%s fehlt", name)
+	}
+
+	address, err := parseAddress(block, "vfd.control."+name)
+	if err != nil {
+		return RegisterCommand{}, err
+	}
+
+	value, ok := block["value"].(float64)
+	if !ok || value < 0 || value > 65535 {
+		return RegisterCommand{}, fmt.Errorf("vfd.control.%s: ungültiger Wert %v", name, block["value"])
+	}
+
+	return RegisterCommand{Address: address, Value: uint16(value)}, nil
+}
+
+// parseAddress liest eine Registeradresse (Schlüssel "address", sofern nicht anders angegeben)
+func parseAddress(block map[string]interface{}, path string, key ...string) (uint16, error) {
+	name := "address"
+	if len(key) > 0 {
+		name = key[0]
+	}
+
+	address, ok := block[name].(float64)
+	if !ok || address < 0 || address > 65535 {
+		return 0, fmt.Errorf("%s.%s: ungültige Adresse %v", path, name, block[name])
+	}
+	return uint16(address), nil
+}
+
+// floatValue liest einen Zahlenwert mit Standardwert
+func floatValue(block map[string]interface{}, key string, defaultValue float64) float64 {
+	if value, ok := block[key].(float64); ok {
+		return value
+	}
+	return defaultValue
+}
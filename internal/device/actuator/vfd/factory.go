@@ -0,0 +1,156 @@
+package vfd
+
+import (
+	"fmt"
+
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/protocol/factory"
+	"owipex_reader/internal/types"
+)
+
+// commandSchema beschreibt einen Steuerbefehl (fester Wert in einem Register)
+func commandSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": description,
+		"properties": map[string]interface{}{
+			"address": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+			"value":   map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+		},
+		"required": []interface{}{"address", "value"},
+	}
+}
+
+// monitorSchema beschreibt ein Überwachungsregister
+func monitorSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "object",
+		"description": description,
+		"properties": map[string]interface{}{
+			"address":    map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+			"data_type":  map[string]interface{}{"type": "string", "enum": []interface{}{"uint16", "int16", "uint32", "int32", "float32"}, "default": "uint16"},
+			"byte_order": map[string]interface{}{"type": "string", "enum": []interface{}{"big_endian", "little_endian"}, "default": "big_endian"},
+			"scale":      map[string]interface{}{"type": "number", "default": 1.0, "description": "Wert einer Registereinheit"},
+		},
+		"required": []interface{}{"address"},
+	}
+}
+
+// Descriptor beschreibt den Gerätetyp "vfd" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	address := map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535}
+
+	return types.TypeDescriptor{
+		Type:        "vfd",
+		Name:        "Frequenzumrichter",
+		Description: "Frequenzumrichter (z.B. für Pumpen) über Modbus RTU: Start/Stopp/Rückwärts, Frequenz- oder Drehzahlsollwert, Rampen, Fehlerquittierung und Betriebswerte. Die Registerbelegung einer Umrichterfamilie wird als Geräteprofil hinterlegt",
+		Category:    types.TypeHybrid,
+		Readings:    []types.ReadingType{types.ReadingTypeFrequency},
+		Commands:    []types.CommandType{types.CommandTypeSetState, types.CommandTypeSetPosition, types.CommandTypeReset},
+		Protocol:    "modbus",
+		ConfigDir:   "actuators/vfd",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus":   factory.ModbusSchema(),
+				"failsafe": actuator.FailsafeSchema(),
+				"vfd": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"control": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"start":   commandSchema("Start vorwärts"),
+								"stop":    commandSchema("Stopp"),
+								"reverse": commandSchema("Start rückwärts"),
+								"reset":   commandSchema("Fehler quittieren"),
+							},
+							"required":             []interface{}{"start", "stop"},
+							"additionalProperties": false,
+						},
+						"setpoint": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"address": address,
+								"unit":    map[string]interface{}{"type": "string", "enum": []interface{}{UnitHz, UnitPercent}, "default": UnitHz, "description": "Einheit, die der Umrichter im Sollwertregister erwartet"},
+								"scale":   map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": 0.01, "description": "Wert einer Registereinheit"},
+							},
+							"required": []interface{}{"address"},
+						},
+						"min_frequency": map[string]interface{}{"type": "number", "minimum": 0, "default": 0.0},
+						"max_frequency": map[string]interface{}{"type": "number", "exclusiveMinimum": 0},
+						"rated_speed":   map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "description": "Motordrehzahl bei Maximalfrequenz in rpm"},
+						"ramp": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"accel_address": address,
+								"decel_address": address,
+								"scale":         map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": 0.1, "description": "Sekunden je Registereinheit"},
+								"accel_time":    map[string]interface{}{"type": "number", "minimum": 0, "description": "Hochlaufzeit in Sekunden, wird vor jedem Start geschrieben"},
+								"decel_time":    map[string]interface{}{"type": "number", "minimum": 0, "description": "Rücklaufzeit in Sekunden, wird vor jedem Start geschrieben"},
+							},
+						},
+						"monitoring": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								MonitorOutputFrequency: monitorSchema("Ausgangsfrequenz (Hz)"),
+								MonitorOutputCurrent:   monitorSchema("Ausgangsstrom (A)"),
+								MonitorOutputPower:     monitorSchema("Ausgangsleistung (kW)"),
+								MonitorDCBusVoltage:    monitorSchema("Zwischenkreisspannung (V)"),
+								MonitorRunHours:        monitorSchema("Betriebsstunden (h)"),
+								MonitorFaultCode:       monitorSchema("Aktueller Fehlercode (0 = kein Fehler)"),
+							},
+							"required":             []interface{}{MonitorOutputFrequency},
+							"additionalProperties": false,
+						},
+						"fault_codes": map[string]interface{}{
+							"type":                 "object",
+							"description":          "Klartexte der Fehlercodes",
+							"additionalProperties": map[string]interface{}{"type": "string"},
+						},
+					},
+					"required": []interface{}{"control", "setpoint", "max_frequency", "monitoring"},
+				},
+			},
+			"required": []interface{}{"modbus", "vfd"},
+		},
+		Defaults: map[string]interface{}{
+			"vfd": map[string]interface{}{
+				"min_frequency": 0.0,
+			},
+		},
+	}
+}
+
+// CreateVFDActuator erstellt einen Frequenzumrichter aus einer Konfiguration
+func CreateVFDActuator(config types.DeviceConfig) (types.Actor, error) {
+	vfdConfig, err := parseConfig(config.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("ungültige Umrichter-Konfiguration: %w", err)
+	}
+
+	modbusConfig, ok := config.Metadata["modbus"].(map[string]interface{})
+	if config.Protocol != "modbus" || !ok {
+		return nil, fmt.Errorf("umrichter benötigt das Protokoll modbus mit einem Metadata-Block \"modbus\"")
+	}
+
+	failsafe, err := actuator.ParseFailsafe(config.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	drive := NewVFDActuator(config.ID, config.Name, vfdConfig)
+	drive.SetFailsafe(failsafe)
+
+	protocol, err := factory.CreateProtocolHandler("modbus", modbusConfig)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Protokoll-Handlers: %w", err)
+	}
+	drive.SetProtocol(protocol)
+
+	if !config.Enabled {
+		drive.Enable(false)
+	}
+
+	return drive, nil
+}
@@ -0,0 +1,512 @@
+// Package vfd implementiert einen Frequenzumrichter (VFD) als Hybridgerät: Er wird über
+// Modbus gestartet, gestoppt und mit einem Frequenz- bzw. Drehzahlsollwert gesteuert und
+// liefert Ausgangsfrequenz, Strom, Leistung, Zwischenkreisspannung, Betriebsstunden und Fehlercodes.
+package vfd
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/types"
+)
+
+// Laufzustände des Umrichters
+const (
+	DirectionStop    = "stop"
+	DirectionForward = "forward"
+	DirectionReverse = "reverse"
+)
+
+// Parameter von Steuerbefehlen
+const (
+	ParameterUnit      = "unit"
+	ParameterAccelTime = "accel_time"
+	ParameterDecelTime = "decel_time"
+)
+
+// stateTimeout begrenzt das Lesen in GetState
+const stateTimeout = 5 * time.Second
+
+// VFDActuator implementiert einen Frequenzumrichter
+type VFDActuator struct {
+	*actuator.BaseActuator
+
+	config Config
+
+	stateMu   sync.Mutex
+	direction string
+	setpoint  *float64
+}
+
+// NewVFDActuator erstellt einen neuen Frequenzumrichter
+func NewVFDActuator(id, name string, config Config) *VFDActuator {
+	return &VFDActuator{
+		BaseActuator: actuator.NewBaseActuator(id, name,
+			types.CommandTypeSetState, types.CommandTypeSetPosition, types.CommandTypeReset),
+		config:    config,
+		direction: DirectionStop,
+	}
+}
+
+// Type gibt den Typ des Geräts zurück; ein Umrichter misst und steuert
+func (v *VFDActuator) Type() types.DeviceType {
+	return types.TypeHybrid
+}
+
+// Write führt einen Steuerbefehl aus:
+//   - SET_STATE: "start"/"forward", "reverse" oder "stop" (true/false für Start vorwärts/Stopp)
+//   - SET_POSITION: Sollwert in Hz, mit Parameter "unit" auch in "percent" der Maximalfrequenz oder "rpm"
+//   - RESET: Fehler quittieren
+//
+// Die Parameter "accel_time" und "decel_time" (Sekunden) setzen bei jedem Befehl die Rampenzeiten.
+func (v *VFDActuator) Write(ctx context.Context, command types.Command) error {
+	protocol, err := v.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	if err := v.applyRampParameters(ctx, protocol, command.Parameters); err != nil {
+		return err
+	}
+
+	switch command.Type {
+	case types.CommandTypeSetState:
+		direction, err := ParseDirection(command.Value)
+		if err != nil {
+			return fmt.Errorf("ungültiger Zustand für Umrichter %s: %w", v.ID(), err)
+		}
+		return v.SetDirection(ctx, direction)
+
+	case types.CommandTypeSetPosition:
+		value, ok := toFloat(command.Value)
+		if !ok {
+			return fmt.Errorf("sollwert für Umrichter %s ist keine Zahl: %v", v.ID(), command.Value)
+		}
+		unit, _ := command.Parameters[ParameterUnit].(string)
+		if unit == "" {
+			unit = UnitHz
+		}
+		frequency, err := v.ToFrequency(value, unit)
+		if err != nil {
+			return err
+		}
+		return v.SetFrequency(ctx, frequency)
+
+	case types.CommandTypeReset:
+		return v.Reset(ctx)
+
+	default:
+		return fmt.Errorf("befehl %s wird von Umrichter %s nicht unterstützt", command.Type, v.ID())
+	}
+}
+
+// SetDirection startet den Umrichter vorwärts oder rückwärts bzw. stoppt ihn.
+// Vor einem Start werden die konfigurierten Rampenzeiten geschrieben.
+func (v *VFDActuator) SetDirection(ctx context.Context, direction string) error {
+	protocol, err := v.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	var command RegisterCommand
+	switch direction {
+	case DirectionForward:
+		command = v.config.Start
+	case DirectionReverse:
+		if v.config.Reverse == nil {
+			return fmt.Errorf("umrichter %s unterstützt keinen Rückwärtslauf (vfd.control.reverse fehlt)", v.ID())
+		}
+		command = *v.config.Reverse
+	case DirectionStop:
+		command = v.config.Stop
+	default:
+		return fmt.Errorf("unbekannter Laufzustand %q", direction)
+	}
+
+	if direction != DirectionStop {
+		v.stateMu.Lock()
+		accel, decel := v.config.Ramp.AccelTime, v.config.Ramp.DecelTime
+		v.stateMu.Unlock()
+
+		if err := v.writeRamp(ctx, protocol, accel, decel); err != nil {
+			return err
+		}
+	}
+
+	if err := writeUint16(ctx, protocol, command.Address, command.Value); err != nil {
+		return fmt.Errorf("fehler beim Schalten von Umrichter %s (%s): %w", v.ID(), direction, err)
+	}
+
+	v.stateMu.Lock()
+	v.direction = direction
+	v.stateMu.Unlock()
+
+	return nil
+}
+
+// SetFrequency schreibt den Frequenzsollwert in Hz
+func (v *VFDActuator) SetFrequency(ctx context.Context, frequency float64) error {
+	protocol, err := v.CheckWritable()
+	if err != nil {
+		return err
+	}
+
+	if frequency < v.config.MinFrequency || frequency > v.config.MaxFrequency {
+		return fmt.Errorf("sollwert %.2f Hz für Umrichter %s liegt außerhalb von %.2f-%.2f Hz",
+			frequency, v.ID(), v.config.MinFrequency, v.config.MaxFrequency)
+	}
+
+	value := frequency
+	if v.config.Setpoint.Unit == UnitPercent {
+		value = frequency / v.config.MaxFrequency * 100
+	}
+	raw := math.Round(value / v.config.Setpoint.Scale)
+	if raw < 0 || raw > math.MaxUint16 {
+		return fmt.Errorf("sollwert %.2f Hz ist mit Skalierung %v nicht darstellbar", frequency, v.config.Setpoint.Scale)
+	}
+
+	if err := writeUint16(ctx, protocol, v.config.Setpoint.Address, uint16(raw)); err != nil {
+		return fmt.Errorf("fehler beim Schreiben des Sollwerts von Umrichter %s: %w", v.ID(), err)
+	}
+
+	v.stateMu.Lock()
+	v.setpoint = &frequency
+	v.stateMu.Unlock()
+
+	return nil
+}
+
+// ToFrequency rechnet einen Sollwert in Hz um
+func (v *VFDActuator) ToFrequency(value float64, unit string) (float64, error) {
+	switch strings.ToLower(unit) {
+	case UnitHz:
+		return value, nil
+	case UnitPercent:
+		return v.config.MaxFrequency * value / 100, nil
+	case UnitRPM:
+		if v.config.RatedSpeed <= 0 {
+			return 0, fmt.Errorf("umrichter %s: Sollwert in rpm erfordert vfd.rated_speed", v.ID())
+		}
+		return v.config.MaxFrequency * value / v.config.RatedSpeed, nil
+	default:
+		return 0, fmt.Errorf("unbekannte Sollwerteinheit %q (erlaubt: hz, percent, rpm)", unit)
+	}
+}
+
+// Reset quittiert einen Umrichterfehler
+func (v *VFDActuator) Reset(ctx context.Context) error {
+	protocol, err := v.CheckWritable()
+	if err != nil {
+		return err
+	}
+	if v.config.Reset == nil {
+		return fmt.Errorf("umrichter %s unterstützt keine Fehlerquittierung (vfd.control.reset fehlt)", v.ID())
+	}
+
+	if err := writeUint16(ctx, protocol, v.config.Reset.Address, v.config.Reset.Value); err != nil {
+		return fmt.Errorf("fehler beim Quittieren von Umrichter %s: %w", v.ID(), err)
+	}
+	return nil
+}
+
+// applyRampParameters schreibt die Rampenzeiten aus den Befehlsparametern
+func (v *VFDActuator) applyRampParameters(ctx context.Context, protocol types.ProtocolHandler, parameters map[string]interface{}) error {
+	accel, hasAccel := toFloat(parameters[ParameterAccelTime])
+	decel, hasDecel := toFloat(parameters[ParameterDecelTime])
+	if !hasAccel && !hasDecel {
+		return nil
+	}
+	if (hasAccel && accel < 0) || (hasDecel && decel < 0) {
+		return fmt.Errorf("rampenzeiten dürfen nicht negativ sein")
+	}
+
+	if err := v.writeRamp(ctx, protocol, accel, decel); err != nil {
+		return err
+	}
+
+	// Neue Rampenzeiten auch für spätere Starts verwenden
+	v.stateMu.Lock()
+	if hasAccel {
+		v.config.Ramp.AccelTime = accel
+	}
+	if hasDecel {
+		v.config.Ramp.DecelTime = decel
+	}
+	v.stateMu.Unlock()
+
+	return nil
+}
+
+// writeRamp schreibt Hoch- und Rücklaufzeit in Sekunden (0 = nicht schreiben)
+func (v *VFDActuator) writeRamp(ctx context.Context, protocol types.ProtocolHandler, accel, decel float64) error {
+	ramps := []struct {
+		name    string
+		address *uint16
+		seconds float64
+	}{
+		{"Hochlaufzeit", v.config.Ramp.AccelAddress, accel},
+		{"Rücklaufzeit", v.config.Ramp.DecelAddress, decel},
+	}
+
+	for _, ramp := range ramps {
+		if ramp.seconds <= 0 {
+			continue
+		}
+		if ramp.address == nil {
+			return fmt.Errorf("umrichter %s: kein Register für die %s konfiguriert", v.ID(), ramp.name)
+		}
+
+		raw := math.Round(ramp.seconds / v.config.Ramp.Scale)
+		if raw > math.MaxUint16 {
+			return fmt.Errorf("%s %.1f s ist mit Skalierung %v nicht darstellbar", ramp.name, ramp.seconds, v.config.Ramp.Scale)
+		}
+		if err := writeUint16(ctx, protocol, *ramp.address, uint16(raw)); err != nil {
+			return fmt.Errorf("fehler beim Schreiben der %s von Umrichter %s: %w", ramp.name, v.ID(), err)
+		}
+	}
+
+	return nil
+}
+
+// Read liest die Betriebswerte des Umrichters. Hauptwert ist die Ausgangsfrequenz in Hz;
+// Strom, Leistung, Zwischenkreisspannung, Betriebsstunden und der dekodierte Fehler stehen
+// in den Metadaten.
+func (v *VFDActuator) Read(ctx context.Context) (types.Reading, error) {
+	protocol := v.GetProtocol()
+	if protocol == nil {
+		return types.Reading{}, fmt.Errorf("kein Protokoll-Handler konfiguriert")
+	}
+
+	frequency, raw, err := v.readMonitor(ctx, protocol, MonitorOutputFrequency)
+	if err != nil {
+		return types.Reading{}, fmt.Errorf("fehler beim Lesen der Ausgangsfrequenz: %w", err)
+	}
+
+	reading := types.NewReading(types.ReadingTypeFrequency, frequency, "Hz", raw)
+
+	for name := range v.config.Monitoring {
+		if name == MonitorOutputFrequency {
+			continue
+		}
+
+		value, _, err := v.readMonitor(ctx, protocol, name)
+		if err != nil {
+			// Fehlende Nebenwerte machen die Frequenz nicht ungültig
+			reading.Quality = types.QualityUncertain
+			reading.Metadata[name+"_error"] = err.Error()
+			continue
+		}
+
+		if name == MonitorFaultCode {
+			code := int(value)
+			reading.Metadata[MonitorFaultCode] = code
+			reading.Metadata["fault"] = v.DecodeFault(code)
+			reading.Metadata["faulted"] = code != 0
+			continue
+		}
+		reading.Metadata[name] = value
+	}
+
+	if v.config.RatedSpeed > 0 {
+		reading.Metadata["speed_rpm"] = frequency / v.config.MaxFrequency * v.config.RatedSpeed
+	}
+
+	v.stateMu.Lock()
+	reading.Metadata["direction"] = v.direction
+	if v.setpoint != nil {
+		reading.Metadata["setpoint"] = *v.setpoint
+	}
+	v.stateMu.Unlock()
+	reading.Metadata["running"] = frequency > 0
+
+	return reading, nil
+}
+
+// ReadRaw liest das Rohregister der Ausgangsfrequenz
+func (v *VFDActuator) ReadRaw(ctx context.Context) ([]byte, error) {
+	protocol := v.GetProtocol()
+	if protocol == nil {
+		return nil, fmt.Errorf("kein Protokoll-Handler konfiguriert")
+	}
+
+	monitor := v.config.Monitoring[MonitorOutputFrequency]
+	return protocol.ReadRegister(ctx, monitor.Address, monitor.Length)
+}
+
+// WriteRaw schreibt Rohdaten in das Steuerregister
+func (v *VFDActuator) WriteRaw(ctx context.Context, data []byte) error {
+	protocol, err := v.CheckWritable()
+	if err != nil {
+		return err
+	}
+	return protocol.WriteRegister(ctx, v.config.Start.Address, data)
+}
+
+// AvailableReadings gibt die verfügbaren Messwerttypen zurück
+func (v *VFDActuator) AvailableReadings() []types.ReadingType {
+	return []types.ReadingType{types.ReadingTypeFrequency}
+}
+
+// GetState liest die Betriebswerte und gibt Laufzustand, Sollwert, Ausgangsfrequenz und Fehler zurück
+func (v *VFDActuator) GetState() (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+
+	return v.GetStateContext(ctx)
+}
+
+// GetStateContext liest die Betriebswerte mit dem Kontext des Aufrufers (types.StateReader)
+func (v *VFDActuator) GetStateContext(ctx context.Context) (interface{}, error) {
+	reading, err := v.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state := map[string]interface{}{
+		"output_frequency": reading.Value,
+	}
+	for _, key := range []string{"direction", "setpoint", "running", MonitorFaultCode, "fault", "faulted"} {
+		if value, ok := reading.Metadata[key]; ok {
+			state[key] = value
+		}
+	}
+	return state, nil
+}
+
+// Running meldet, ob der Motor dreht, d.h. die Ausgangsfrequenz größer 0 ist (types.RunningReporter)
+func (v *VFDActuator) Running(state interface{}) bool {
+	values, ok := state.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	running, _ := values["running"].(bool)
+	return running
+}
+
+// DecodeFault gibt den Klartext eines Fehlercodes zurück
+func (v *VFDActuator) DecodeFault(code int) string {
+	if code == 0 {
+		return "kein Fehler"
+	}
+	if text, ok := v.config.FaultCodes[code]; ok && text != "" {
+		return text
+	}
+	return fmt.Sprintf("unbekannter Fehler %d", code)
+}
+
+// readMonitor liest ein Überwachungsregister und wendet den Skalierungsfaktor an
+func (v *VFDActuator) readMonitor(ctx context.Context, protocol types.ProtocolHandler, name string) (float64, []byte, error) {
+	monitor, ok := v.config.Monitoring[name]
+	if !ok {
+		return 0, nil, fmt.Errorf("kein Register für %s konfiguriert", name)
+	}
+
+	data, err := protocol.ReadRegister(ctx, monitor.Address, monitor.Length)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	value, err := convertRawToFloat(data, monitor.DataType, monitor.ByteOrder)
+	if err != nil {
+		return 0, data, err
+	}
+	return value * monitor.Scale, data, nil
+}
+
+// ParseDirection wandelt einen Befehlswert in einen Laufzustand um.
+// Erlaubt sind "start"/"forward"/"on", "reverse" und "stop"/"off" sowie true/false und 1/0.
+func ParseDirection(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return DirectionForward, nil
+		}
+		return DirectionStop, nil
+	case float64:
+		if v == 0 || v == 1 {
+			return ParseDirection(v == 1)
+		}
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "start", "forward", "run", "on", "ein", "true", "1":
+			return DirectionForward, nil
+		case "reverse", "rückwärts":
+			return DirectionReverse, nil
+		case "stop", "off", "aus", "false", "0":
+			return DirectionStop, nil
+		}
+	}
+
+	return "", fmt.Errorf("laufzustand %v unbekannt (erlaubt: start, reverse, stop)", value)
+}
+
+// writeUint16 schreibt einen Wert in ein einzelnes Register
+func writeUint16(ctx context.Context, protocol types.ProtocolHandler, address uint16, value uint16) error {
+	return protocol.WriteRegister(ctx, address, []byte{byte(value >> 8), byte(value)})
+}
+
+// toFloat wandelt einen Zahlenwert aus einem Befehl um
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// convertRawToFloat konvertiert Rohdaten in einen Float-Wert
+func convertRawToFloat(rawData []byte, dataType, byteOrder string) (float64, error) {
+	if len(rawData) == 0 {
+		return 0, fmt.Errorf("keine Daten zum Konvertieren")
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if byteOrder == "big_endian" {
+		order = binary.BigEndian
+	}
+
+	switch dataType {
+	case "float32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für float32: %d Bytes", len(rawData))
+		}
+		return float64(math.Float32frombits(order.Uint32(rawData))), nil
+
+	case "int16":
+		if len(rawData) < 2 {
+			return 0, fmt.Errorf("nicht genügend Daten für int16: %d Bytes", len(rawData))
+		}
+		return float64(int16(order.Uint16(rawData))), nil
+
+	case "uint16":
+		if len(rawData) < 2 {
+			return 0, fmt.Errorf("nicht genügend Daten für uint16: %d Bytes", len(rawData))
+		}
+		return float64(order.Uint16(rawData)), nil
+
+	case "int32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für int32: %d Bytes", len(rawData))
+		}
+		return float64(int32(order.Uint32(rawData))), nil
+
+	case "uint32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für uint32: %d Bytes", len(rawData))
+		}
+		return float64(order.Uint32(rawData)), nil
+
+	default:
+		return 0, fmt.Errorf("unbekannter Datentyp: %s", dataType)
+	}
+}
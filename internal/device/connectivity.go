@@ -0,0 +1,339 @@
+package device
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ConnectivityState beschreibt den Verbindungszustand eines Geräts
+type ConnectivityState string
+
+const (
+	// StateInitializing: Gerät wurde hinzugefügt oder neu konfiguriert, es liegt noch kein Ergebnis vor
+	StateInitializing ConnectivityState = "initializing"
+	// StateOnline: Das Gerät antwortet zuverlässig
+	StateOnline ConnectivityState = "online"
+	// StateDegraded: Einzelne Zugriffe sind fehlgeschlagen
+	StateDegraded ConnectivityState = "degraded"
+	// StateOffline: Das Gerät antwortet nicht mehr, Zugriffe erfolgen nur noch mit Backoff
+	StateOffline ConnectivityState = "offline"
+	// StateDisabled: Das Gerät ist deaktiviert
+	StateDisabled ConnectivityState = "disabled"
+	// StateMaintenance: Das Gerät ist in Wartung, Fehler ändern den Zustand nicht
+	StateMaintenance ConnectivityState = "maintenance"
+	// StateIdentityMismatch: Die angeschlossene Hardware passt nicht zur Konfiguration. Das Gerät
+	// wird nicht angesprochen, bis eine erneute Identitätsprüfung erfolgreich ist.
+	StateIdentityMismatch ConnectivityState = "identity_mismatch"
+)
+
+// ConnectivityConfig enthält die Schwellwerte der Zustandsmaschine
+type ConnectivityConfig struct {
+	// DegradedAfter ist die Anzahl aufeinanderfolgender Fehler bis zum Zustand degraded
+	DegradedAfter int
+	// OfflineAfter ist die Anzahl aufeinanderfolgender Fehler bis zum Zustand offline
+	OfflineAfter int
+	// RecoverAfter ist die Anzahl aufeinanderfolgender Erfolge bis zum Zustand online
+	RecoverAfter int
+	// InitialBackoff ist die Wartezeit bis zum ersten Wiederholungsversuch im Zustand offline
+	InitialBackoff time.Duration
+	// MaxBackoff begrenzt die Wartezeit, die sich mit jedem weiteren Fehler verdoppelt
+	MaxBackoff time.Duration
+}
+
+// DefaultConnectivityConfig gibt die Standard-Schwellwerte zurück
+func DefaultConnectivityConfig() ConnectivityConfig {
+	return ConnectivityConfig{
+		DegradedAfter:  1,
+		OfflineAfter:   3,
+		RecoverAfter:   1,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+	}
+}
+
+// normalize ersetzt ungültige Werte durch Standardwerte
+func (c ConnectivityConfig) normalize() ConnectivityConfig {
+	defaults := DefaultConnectivityConfig()
+
+	if c.DegradedAfter <= 0 {
+		c.DegradedAfter = defaults.DegradedAfter
+	}
+	if c.OfflineAfter <= 0 {
+		c.OfflineAfter = defaults.OfflineAfter
+	}
+	if c.OfflineAfter < c.DegradedAfter {
+		c.OfflineAfter = c.DegradedAfter
+	}
+	if c.RecoverAfter <= 0 {
+		c.RecoverAfter = defaults.RecoverAfter
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = defaults.InitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaults.MaxBackoff
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		c.MaxBackoff = c.InitialBackoff
+	}
+
+	return c
+}
+
+// ConnectivityStatus ist eine Momentaufnahme des Verbindungszustands eines Geräts
+type ConnectivityStatus struct {
+	DeviceID             string
+	State                ConnectivityState
+	Since                time.Time
+	LastSuccess          time.Time
+	LastFailure          time.Time
+	ConsecutiveFailures  int
+	ConsecutiveSuccesses int
+	LastError            error
+
+	// NextAttempt ist im Zustand offline der frühestmögliche nächste Zugriff
+	NextAttempt time.Time
+}
+
+// Connected gibt an, ob das Gerät aktuell erreichbar ist
+func (s ConnectivityStatus) Connected() bool {
+	return s.State == StateOnline || s.State == StateDegraded
+}
+
+// Attributes gibt den Zustand als Client-Attribute für ThingsBoard zurück
+func (s ConnectivityStatus) Attributes() map[string]interface{} {
+	prefix := s.DeviceID + "_"
+
+	attributes := map[string]interface{}{
+		prefix + "connectivity_state":   string(s.State),
+		prefix + "consecutive_failures": s.ConsecutiveFailures,
+		prefix + "state_since":          s.Since.UnixNano() / int64(time.Millisecond),
+	}
+
+	if !s.LastSuccess.IsZero() {
+		attributes[prefix+"last_success"] = s.LastSuccess.UnixNano() / int64(time.Millisecond)
+	}
+	if s.LastError != nil {
+		attributes[prefix+"last_error"] = s.LastError.Error()
+	} else {
+		attributes[prefix+"last_error"] = ""
+	}
+
+	return attributes
+}
+
+// String gibt den Zustand lesbar aus
+func (s ConnectivityStatus) String() string {
+	return fmt.Sprintf("%s: %s seit %s (%d Fehler in Folge)", s.DeviceID, s.State, s.Since.Format(time.RFC3339), s.ConsecutiveFailures)
+}
+
+// connectivity ist die Zustandsmaschine eines Geräts
+type connectivity struct {
+	config  ConnectivityConfig
+	status  ConnectivityStatus
+	backoff time.Duration
+	mutex   sync.Mutex
+
+	// identityErr ist der Grund einer fehlgeschlagenen Identitätsprüfung, nil wenn keine vorliegt
+	identityErr error
+}
+
+// newConnectivity erstellt die Zustandsmaschine eines Geräts
+func newConnectivity(deviceID string, config ConnectivityConfig, enabled bool, now time.Time) *connectivity {
+	state := StateInitializing
+	if !enabled {
+		state = StateDisabled
+	}
+
+	return &connectivity{
+		config: config.normalize(),
+		status: ConnectivityStatus{
+			DeviceID: deviceID,
+			State:    state,
+			Since:    now,
+		},
+	}
+}
+
+// setConfig übernimmt neue Schwellwerte, sie gelten ab dem nächsten Zugriff
+func (c *connectivity) setConfig(config ConnectivityConfig) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.config = config.normalize()
+}
+
+// snapshot gibt den aktuellen Zustand zurück
+func (c *connectivity) snapshot() ConnectivityStatus {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.status
+}
+
+// recordSuccess verarbeitet einen erfolgreichen Zugriff und gibt zurück, ob sich der Zustand geändert hat
+func (c *connectivity) recordSuccess(now time.Time) (ConnectivityStatus, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.status.LastSuccess = now
+	c.status.ConsecutiveFailures = 0
+	c.status.ConsecutiveSuccesses++
+	c.status.LastError = c.identityErr
+	c.status.NextAttempt = time.Time{}
+	c.backoff = 0
+
+	changed := false
+	switch c.status.State {
+	case StateInitializing:
+		changed = c.transition(StateOnline, now)
+	case StateDegraded, StateOffline:
+		if c.status.ConsecutiveSuccesses >= c.config.RecoverAfter {
+			changed = c.transition(StateOnline, now)
+		} else {
+			// Erste Antworten nach einem Ausfall: erreichbar, aber noch nicht stabil
+			changed = c.transition(StateDegraded, now)
+		}
+	}
+
+	return c.status, changed
+}
+
+// recordFailure verarbeitet einen fehlgeschlagenen Zugriff und gibt zurück, ob sich der Zustand geändert hat
+func (c *connectivity) recordFailure(err error, now time.Time) (ConnectivityStatus, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.status.LastFailure = now
+	c.status.LastError = err
+	c.status.ConsecutiveFailures++
+	c.status.ConsecutiveSuccesses = 0
+
+	changed := false
+	switch c.status.State {
+	case StateInitializing, StateOnline, StateDegraded:
+		if c.status.ConsecutiveFailures >= c.config.OfflineAfter {
+			changed = c.transition(StateOffline, now)
+		} else if c.status.ConsecutiveFailures >= c.config.DegradedAfter {
+			changed = c.transition(StateDegraded, now)
+		}
+	}
+
+	if c.status.State == StateOffline {
+		// Wartezeit bis zum nächsten Versuch mit jedem Fehler verdoppeln
+		if c.backoff == 0 {
+			c.backoff = c.config.InitialBackoff
+		} else {
+			c.backoff *= 2
+			if c.backoff > c.config.MaxBackoff {
+				c.backoff = c.config.MaxBackoff
+			}
+		}
+		c.status.NextAttempt = now.Add(c.backoff)
+	}
+
+	return c.status, changed
+}
+
+// setEnabled übernimmt den Aktivierungszustand des Geräts
+func (c *connectivity) setEnabled(enabled bool, now time.Time) (ConnectivityStatus, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	changed := false
+	if !enabled && c.status.State != StateDisabled {
+		changed = c.transition(StateDisabled, now)
+	} else if enabled && c.status.State == StateDisabled {
+		c.reset()
+		changed = c.transition(c.restState(), now)
+	}
+
+	return c.status, changed
+}
+
+// setMaintenance versetzt das Gerät in den Wartungszustand oder beendet ihn
+func (c *connectivity) setMaintenance(maintenance bool, now time.Time) (ConnectivityStatus, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	changed := false
+	if maintenance && c.status.State != StateMaintenance && c.status.State != StateDisabled {
+		changed = c.transition(StateMaintenance, now)
+	} else if !maintenance && c.status.State == StateMaintenance {
+		c.reset()
+		changed = c.transition(c.restState(), now)
+	}
+
+	return c.status, changed
+}
+
+// setIdentityMismatch übernimmt das Ergebnis einer Identitätsprüfung. Ein Fehler versetzt das
+// Gerät in den Zustand identity_mismatch, nur err == nil (erfolgreiche Prüfung) beendet ihn.
+// Deaktivierung und Wartung haben Vorrang, danach kehrt das Gerät in identity_mismatch zurück.
+func (c *connectivity) setIdentityMismatch(err error, now time.Time) (ConnectivityStatus, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	changed := false
+	if err != nil {
+		c.identityErr = err
+		c.status.LastError = err
+		if c.status.State != StateDisabled && c.status.State != StateMaintenance {
+			changed = c.transition(StateIdentityMismatch, now)
+		}
+	} else if c.identityErr != nil {
+		c.identityErr = nil
+		c.status.LastError = nil
+		if c.status.State == StateIdentityMismatch {
+			c.reset()
+			changed = c.transition(StateInitializing, now)
+		}
+	}
+
+	return c.status, changed
+}
+
+// shouldAttempt prüft, ob ein Zugriff erfolgen soll. Im Zustand offline wird erst nach
+// Ablauf des Backoffs wieder zugegriffen, deaktivierte Geräte und Geräte mit falscher
+// Identität werden nicht angesprochen.
+func (c *connectivity) shouldAttempt(now time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch c.status.State {
+	case StateDisabled, StateIdentityMismatch:
+		return false
+	case StateOffline:
+		return !now.Before(c.status.NextAttempt)
+	default:
+		return true
+	}
+}
+
+// transition wechselt in einen neuen Zustand (Aufrufer hält die Sperre)
+func (c *connectivity) transition(state ConnectivityState, now time.Time) bool {
+	if c.status.State == state {
+		return false
+	}
+	c.status.State = state
+	c.status.Since = now
+	return true
+}
+
+// restState gibt den Zustand nach Deaktivierung oder Wartung zurück: identity_mismatch, solange
+// die Identitätsprüfung fehlschlägt, sonst initializing (Aufrufer hält die Sperre)
+func (c *connectivity) restState() ConnectivityState {
+	if c.identityErr != nil {
+		return StateIdentityMismatch
+	}
+	return StateInitializing
+}
+
+// reset setzt die Zähler zurück (Aufrufer hält die Sperre)
+func (c *connectivity) reset() {
+	c.status.ConsecutiveFailures = 0
+	c.status.ConsecutiveSuccesses = 0
+	c.status.NextAttempt = time.Time{}
+	c.backoff = 0
+}
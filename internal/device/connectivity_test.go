@@ -0,0 +1,161 @@
+package device
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestConnectivityThresholds prüft die Zustandswechsel bei aufeinanderfolgenden Fehlern und
+// Erfolgen. Jeder Schritt ist ein Zugriff: true erfolgreich, false fehlgeschlagen.
+func TestConnectivityThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ConnectivityConfig
+		results []bool
+		want    []ConnectivityState
+	}{
+		{
+			name:    "Standardwerte",
+			config:  DefaultConnectivityConfig(),
+			results: []bool{true, false, false, false, true},
+			want:    []ConnectivityState{StateOnline, StateDegraded, StateDegraded, StateOffline, StateOnline},
+		},
+		{
+			name:    "erster Zugriff schlägt fehl",
+			config:  DefaultConnectivityConfig(),
+			results: []bool{false},
+			want:    []ConnectivityState{StateDegraded},
+		},
+		{
+			name:    "degraded erst nach zwei Fehlern",
+			config:  ConnectivityConfig{DegradedAfter: 2, OfflineAfter: 4},
+			results: []bool{true, false, false, true, false, false, false, false},
+			want:    []ConnectivityState{StateOnline, StateOnline, StateDegraded, StateOnline, StateOnline, StateDegraded, StateDegraded, StateOffline},
+		},
+		{
+			name:    "Erholung nach zwei Erfolgen",
+			config:  ConnectivityConfig{OfflineAfter: 2, RecoverAfter: 2},
+			results: []bool{true, false, false, true, false, true, true},
+			want:    []ConnectivityState{StateOnline, StateDegraded, StateOffline, StateDegraded, StateDegraded, StateDegraded, StateOnline},
+		},
+		{
+			name:    "offline nach dem ersten Fehler",
+			config:  ConnectivityConfig{DegradedAfter: 1, OfflineAfter: 1},
+			results: []bool{true, false},
+			want:    []ConnectivityState{StateOnline, StateOffline},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			c := newConnectivity("tank", tt.config, true, now)
+			failure := errors.New("zeitüberschreitung")
+
+			for i, success := range tt.results {
+				now = now.Add(time.Minute)
+				var status ConnectivityStatus
+				if success {
+					status, _ = c.recordSuccess(now)
+				} else {
+					status, _ = c.recordFailure(failure, now)
+				}
+				if status.State != tt.want[i] {
+					t.Fatalf("Zugriff %d (Erfolg %v): Zustand %s, erwartet %s", i+1, success, status.State, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestConnectivityConfigNormalize prüft, dass ungültige Schwellwerte durch Standardwerte
+// ersetzt und widersprüchliche begrenzt werden
+func TestConnectivityConfigNormalize(t *testing.T) {
+	defaults := DefaultConnectivityConfig()
+
+	tests := []struct {
+		name   string
+		config ConnectivityConfig
+		want   ConnectivityConfig
+	}{
+		{name: "leer", want: defaults},
+		{
+			name:   "negative Werte",
+			config: ConnectivityConfig{DegradedAfter: -1, OfflineAfter: -1, RecoverAfter: -1, InitialBackoff: -time.Second, MaxBackoff: -time.Second},
+			want:   defaults,
+		},
+		{
+			name:   "offline vor degraded",
+			config: ConnectivityConfig{DegradedAfter: 5, OfflineAfter: 2},
+			want:   ConnectivityConfig{DegradedAfter: 5, OfflineAfter: 5, RecoverAfter: 1, InitialBackoff: defaults.InitialBackoff, MaxBackoff: defaults.MaxBackoff},
+		},
+		{
+			name:   "nur degraded über dem Standard für offline",
+			config: ConnectivityConfig{DegradedAfter: 4},
+			want:   ConnectivityConfig{DegradedAfter: 4, OfflineAfter: 4, RecoverAfter: 1, InitialBackoff: defaults.InitialBackoff, MaxBackoff: defaults.MaxBackoff},
+		},
+		{
+			name:   "maximaler Backoff unter dem ersten",
+			config: ConnectivityConfig{InitialBackoff: time.Minute, MaxBackoff: time.Second},
+			want:   ConnectivityConfig{DegradedAfter: 1, OfflineAfter: 3, RecoverAfter: 1, InitialBackoff: time.Minute, MaxBackoff: time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.normalize(); got != tt.want {
+				t.Errorf("%+v, erwartet %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConnectivityBackoff prüft, dass sich die Wartezeit im Zustand offline mit jedem Fehler
+// bis zum Maximum verdoppelt, vorher nicht zugegriffen wird und ein Erfolg sie zurücksetzt
+func TestConnectivityBackoff(t *testing.T) {
+	config := ConnectivityConfig{
+		DegradedAfter:  1,
+		OfflineAfter:   2,
+		RecoverAfter:   1,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     35 * time.Second,
+	}
+	failure := errors.New("zeitüberschreitung")
+
+	now := time.Now()
+	c := newConnectivity("tank", config, true, now)
+	if status, _ := c.recordFailure(failure, now); !status.NextAttempt.IsZero() {
+		t.Fatalf("Wartezeit im Zustand %s", status.State)
+	}
+
+	// Jeder weitere Fehler erfolgt beim frühestmöglichen Versuch
+	for _, want := range []time.Duration{10 * time.Second, 20 * time.Second, 35 * time.Second, 35 * time.Second} {
+		status, _ := c.recordFailure(failure, now)
+		if status.State != StateOffline {
+			t.Fatalf("Zustand %s, erwartet offline", status.State)
+		}
+		if backoff := status.NextAttempt.Sub(now); backoff != want {
+			t.Fatalf("Wartezeit %s, erwartet %s", backoff, want)
+		}
+		if c.shouldAttempt(status.NextAttempt.Add(-time.Millisecond)) {
+			t.Fatal("Zugriff vor Ablauf der Wartezeit")
+		}
+		if !c.shouldAttempt(status.NextAttempt) {
+			t.Fatal("kein Zugriff nach Ablauf der Wartezeit")
+		}
+		now = status.NextAttempt
+	}
+
+	status, changed := c.recordSuccess(now)
+	if !changed || status.State != StateOnline || !status.NextAttempt.IsZero() || status.LastError != nil {
+		t.Fatalf("nach Erfolg: %s (geändert %v, nächster Versuch %v, Fehler %v)", status.State, changed, status.NextAttempt, status.LastError)
+	}
+
+	// Nach der Erholung beginnt die Wartezeit wieder beim ersten Wert
+	c.recordFailure(failure, now)
+	status, _ = c.recordFailure(failure, now)
+	if backoff := status.NextAttempt.Sub(now); status.State != StateOffline || backoff != config.InitialBackoff {
+		t.Errorf("erneuter Ausfall: %s mit Wartezeit %s, erwartet offline mit %s", status.State, backoff, config.InitialBackoff)
+	}
+}
@@ -0,0 +1,187 @@
+package creator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"owipex_reader/internal/types"
+)
+
+// DeviceCreator ist eine Funktion, die ein Gerät aus einer Konfiguration erstellt
+type DeviceCreator func(config types.DeviceConfig) (types.Device, error)
+
+// catalogEntry verbindet die Beschreibung eines Gerätetyps mit seinem Creator
+type catalogEntry struct {
+	descriptor types.TypeDescriptor
+	create     DeviceCreator
+}
+
+// Catalog ist der zentrale Katalog aller Gerätetypen. Jeder Typ wird mit seinem Creator und
+// einer Beschreibung (Messwerte, Befehle, Protokoll, JSON-Schema des Metadata-Blocks und
+// Standardwerte) registriert, sodass der Katalog Auskunft über verfügbare Typen geben kann.
+type Catalog struct {
+	entries map[string]catalogEntry
+	mutex   sync.RWMutex
+}
+
+// NewCatalog erstellt einen leeren Gerätekatalog
+func NewCatalog() *Catalog {
+	return &Catalog{
+		entries: make(map[string]catalogEntry),
+	}
+}
+
+// Register registriert einen Gerätetyp mit Beschreibung und Creator
+func (c *Catalog) Register(descriptor types.TypeDescriptor, create DeviceCreator) error {
+	if descriptor.Type == "" {
+		return fmt.Errorf("gerätetyp ohne Typbezeichnung kann nicht registriert werden")
+	}
+	if create == nil {
+		return fmt.Errorf("kein Creator für Gerätetyp '%s' angegeben", descriptor.Type)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, exists := c.entries[descriptor.Type]; exists {
+		return fmt.Errorf("gerätetyp '%s' ist bereits registriert", descriptor.Type)
+	}
+
+	c.entries[descriptor.Type] = catalogEntry{descriptor: descriptor, create: create}
+	return nil
+}
+
+// RegisterSensor registriert einen Sensortyp mit Beschreibung und Sensor-Creator
+func (c *Catalog) RegisterSensor(descriptor types.TypeDescriptor, create SensorCreator) error {
+	if descriptor.Category == "" {
+		descriptor.Category = types.TypeSensor
+	}
+	if create == nil {
+		return fmt.Errorf("kein Creator für Sensortyp '%s' angegeben", descriptor.Type)
+	}
+
+	return c.Register(descriptor, func(config types.DeviceConfig) (types.Device, error) {
+		return create(config)
+	})
+}
+
+// RegisterActor registriert einen Aktortyp mit Beschreibung und Aktor-Creator
+func (c *Catalog) RegisterActor(descriptor types.TypeDescriptor, create ActorCreator) error {
+	if descriptor.Category == "" {
+		descriptor.Category = types.TypeActor
+	}
+	if create == nil {
+		return fmt.Errorf("kein Creator für Aktortyp '%s' angegeben", descriptor.Type)
+	}
+
+	return c.Register(descriptor, func(config types.DeviceConfig) (types.Device, error) {
+		return create(config)
+	})
+}
+
+// Describe gibt die Beschreibung eines Gerätetyps zurück
+func (c *Catalog) Describe(deviceType string) (types.TypeDescriptor, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entry, exists := c.entries[deviceType]
+	if !exists {
+		return types.TypeDescriptor{}, fmt.Errorf("gerätetyp '%s' ist nicht registriert", deviceType)
+	}
+	return entry.descriptor, nil
+}
+
+// Types gibt die Beschreibungen aller registrierten Gerätetypen sortiert nach Typ zurück
+func (c *Catalog) Types() []types.TypeDescriptor {
+	c.mutex.RLock()
+	descriptors := make([]types.TypeDescriptor, 0, len(c.entries))
+	for _, entry := range c.entries {
+		descriptors = append(descriptors, entry.descriptor)
+	}
+	c.mutex.RUnlock()
+
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Type < descriptors[j].Type
+	})
+	return descriptors
+}
+
+// ConfigDirs gibt die Konfigurationsverzeichnisse aller Gerätetypen zurück (ohne Duplikate, sortiert)
+func (c *Catalog) ConfigDirs() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, descriptor := range c.Types() {
+		if descriptor.ConfigDir == "" || seen[descriptor.ConfigDir] {
+			continue
+		}
+		seen[descriptor.ConfigDir] = true
+		dirs = append(dirs, descriptor.ConfigDir)
+	}
+
+	sort.Strings(dirs)
+	return dirs
+}
+
+// Create erstellt ein Gerät. Fehlende Werte im Metadata-Block werden vorher mit den
+// Standardwerten des Gerätetyps ergänzt.
+func (c *Catalog) Create(config types.DeviceConfig) (types.Device, error) {
+	c.mutex.RLock()
+	entry, exists := c.entries[config.Type]
+	c.mutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("kein Creator für Gerätetyp '%s' registriert", config.Type)
+	}
+
+	return entry.create(ApplyDefaults(config, entry.descriptor.Defaults))
+}
+
+// ApplyDefaults ergänzt den Metadata-Block einer Konfiguration um fehlende Standardwerte.
+// Verschachtelte Objekte werden zusammengeführt, gesetzte Werte haben Vorrang. Die
+// übergebene Konfiguration wird nicht verändert.
+func ApplyDefaults(config types.DeviceConfig, defaults map[string]interface{}) types.DeviceConfig {
+	config.Metadata = mergeDefaults(config.Metadata, defaults)
+	return config
+}
+
+// mergeDefaults erstellt eine Kopie von values, ergänzt um fehlende Werte aus defaults
+func mergeDefaults(values, defaults map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(values)+len(defaults))
+	for key, value := range values {
+		merged[key] = copyValue(value)
+	}
+
+	for key, defaultValue := range defaults {
+		value, exists := merged[key]
+		if !exists {
+			merged[key] = copyValue(defaultValue)
+			continue
+		}
+
+		valueMap, isMap := value.(map[string]interface{})
+		defaultMap, defaultIsMap := defaultValue.(map[string]interface{})
+		if isMap && defaultIsMap {
+			merged[key] = mergeDefaults(valueMap, defaultMap)
+		}
+	}
+
+	return merged
+}
+
+// copyValue kopiert verschachtelte Maps und Slices, damit Creator die Konfiguration
+// verändern können (z.B. SetCalibration), ohne Standardwerte oder Aufrufer zu beeinflussen
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return mergeDefaults(v, nil)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,110 @@
+// Package creator enthält Factory-Funktionen für die Erstellung von Geräten.
+package creator
+
+import (
+	"fmt"
+
+	"owipex_reader/internal/types"
+)
+
+// SensorCreator ist eine Funktion, die einen Sensor aus einer Konfiguration erstellt
+type SensorCreator func(config types.DeviceConfig) (types.Sensor, error)
+
+// ActorCreator ist eine Funktion, die einen Aktor aus einer Konfiguration erstellt
+type ActorCreator func(config types.DeviceConfig) (types.Actor, error)
+
+// DeviceFactory verwaltet die Registrierung und Erstellung von Sensoren und Aktoren.
+// Die Gerätetypen werden im Gerätekatalog geführt.
+type DeviceFactory struct {
+	catalog *Catalog
+}
+
+// NewDeviceFactory erstellt eine neue Geräte-Factory mit leerem Gerätekatalog
+func NewDeviceFactory() *DeviceFactory {
+	return &DeviceFactory{
+		catalog: NewCatalog(),
+	}
+}
+
+// Catalog gibt den Gerätekatalog mit den Beschreibungen aller registrierten Typen zurück
+func (r *DeviceFactory) Catalog() *Catalog {
+	return r.catalog
+}
+
+// RegisterSensor registriert einen Creator für einen bestimmten Sensortyp ohne weitere Beschreibung
+func (r *DeviceFactory) RegisterSensor(sensorType string, creator SensorCreator) error {
+	return r.RegisterSensorType(types.TypeDescriptor{Type: sensorType, Name: sensorType}, creator)
+}
+
+// RegisterSensorType registriert einen Sensortyp mit Beschreibung im Gerätekatalog
+func (r *DeviceFactory) RegisterSensorType(descriptor types.TypeDescriptor, creator SensorCreator) error {
+	if err := r.catalog.RegisterSensor(descriptor, creator); err != nil {
+		return fmt.Errorf("fehler beim Registrieren des Sensortyps: %w", err)
+	}
+	return nil
+}
+
+// RegisterActorType registriert einen Aktortyp mit Beschreibung im Gerätekatalog
+func (r *DeviceFactory) RegisterActorType(descriptor types.TypeDescriptor, creator ActorCreator) error {
+	if err := r.catalog.RegisterActor(descriptor, creator); err != nil {
+		return fmt.Errorf("fehler beim Registrieren des Aktortyps: %w", err)
+	}
+	return nil
+}
+
+// CreateDevice erstellt ein Gerät (Sensor oder Aktor) basierend auf der Konfiguration
+func (r *DeviceFactory) CreateDevice(config types.DeviceConfig) (types.Device, error) {
+	return r.catalog.Create(config)
+}
+
+// CreateDevices erstellt mehrere Geräte aus einem Array von Konfigurationen
+func (r *DeviceFactory) CreateDevices(configs []types.DeviceConfig) ([]types.Device, []error) {
+	var devices []types.Device
+	var errors []error
+
+	for _, config := range configs {
+		device, err := r.CreateDevice(config)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("fehler beim Erstellen von Gerät '%s': %w", config.ID, err))
+			continue
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices, errors
+}
+
+// CreateSensor erstellt einen Sensor basierend auf der Konfiguration
+func (r *DeviceFactory) CreateSensor(config types.DeviceConfig) (types.Sensor, error) {
+	device, err := r.catalog.Create(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sensor, ok := device.(types.Sensor)
+	if !ok {
+		device.Close()
+		return nil, fmt.Errorf("gerätetyp '%s' ist kein Sensor", config.Type)
+	}
+
+	return sensor, nil
+}
+
+// CreateSensors erstellt mehrere Sensoren aus einem Array von Konfigurationen
+func (r *DeviceFactory) CreateSensors(configs []types.DeviceConfig) ([]types.Sensor, []error) {
+	var sensors []types.Sensor
+	var errors []error
+
+	for _, config := range configs {
+		sensor, err := r.CreateSensor(config)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("fehler beim Erstellen von Sensor '%s': %w", config.ID, err))
+			continue
+		}
+
+		sensors = append(sensors, sensor)
+	}
+
+	return sensors, errors
+}
@@ -0,0 +1,30 @@
+package creator
+
+import (
+	"owipex_reader/internal/device/actuator/relay"
+	"owipex_reader/internal/device/actuator/valve"
+	"owipex_reader/internal/device/actuator/vfd"
+	"owipex_reader/internal/types"
+)
+
+// RegisterAllActuatorTypes registriert alle verfügbaren Aktortypen mit ihrer Beschreibung im Gerätekatalog der Factory
+func RegisterAllActuatorTypes(factory *DeviceFactory) error {
+	actors := []struct {
+		descriptor types.TypeDescriptor
+		create     ActorCreator
+	}{
+		// Relais (Pumpen, CO2-Ventile, Heizungen)
+		{relay.Descriptor(), relay.CreateRelayActuator},
+		// Frequenzumrichter (Hybridgerät, wird zusätzlich wie ein Sensor gelesen)
+		{vfd.Descriptor(), vfd.CreateVFDActuator},
+		// Motorventile (Hybridgerät, Stellung wird wie ein Messwert gelesen)
+		{valve.Descriptor(), valve.CreateValveActuator},
+	}
+
+	for _, actor := range actors {
+		if err := factory.RegisterActorType(actor.descriptor, actor.create); err != nil {
+			return err
+		}
+	}
+	return nil
+}
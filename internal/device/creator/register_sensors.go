@@ -1,23 +1,42 @@
 package creator
 
 import (
+	"owipex_reader/internal/device/sensor/conductivity"
 	"owipex_reader/internal/device/sensor/flow"
+	"owipex_reader/internal/device/sensor/gps"
+	"owipex_reader/internal/device/sensor/oxygen"
 	"owipex_reader/internal/device/sensor/ph"
 	"owipex_reader/internal/device/sensor/radar"
 	"owipex_reader/internal/device/sensor/turbidity"
+	"owipex_reader/internal/types"
 )
 
-// RegisterAllSensorTypes registriert alle verfügbaren Sensortypen in der Registry
-func RegisterAllSensorTypes(registry *SensorRegistry) {
-	// pH-Sensor registrieren
-	registry.RegisterSensor("ph_sensor", ph.CreatePHSensor)
+// RegisterAllSensorTypes registriert alle verfügbaren Sensortypen mit ihrer Beschreibung im Gerätekatalog der Factory
+func RegisterAllSensorTypes(factory *DeviceFactory) error {
+	sensors := []struct {
+		descriptor types.TypeDescriptor
+		create     SensorCreator
+	}{
+		// pH-Sensor
+		{ph.Descriptor(), ph.CreatePHSensor},
+		// Durchflusssensor
+		{flow.Descriptor(), flow.CreateFlowSensor},
+		// Radar-Sensor
+		{radar.Descriptor(), radar.CreateRadarSensor},
+		// Trübungssensor
+		{turbidity.Descriptor(), turbidity.CreateTurbiditySensor},
+		// Leitfähigkeitssensor
+		{conductivity.Descriptor(), conductivity.CreateConductivitySensor},
+		// Sauerstoffsensor
+		{oxygen.Descriptor(), oxygen.CreateDissolvedOxygenSensor},
+		// GPS-Empfänger
+		{gps.Descriptor(), gps.CreateGPSReceiver},
+	}
 
-	// Durchflusssensor registrieren
-	registry.RegisterSensor("flow_sensor", flow.CreateFlowSensor)
-
-	// Radar-Sensor registrieren
-	registry.RegisterSensor("radar_sensor", radar.CreateRadarSensor)
-
-	// Trübungssensor registrieren
-	registry.RegisterSensor("turbidity_sensor", turbidity.CreateTurbiditySensor)
+	for _, sensor := range sensors {
+		if err := factory.RegisterSensorType(sensor.descriptor, sensor.create); err != nil {
+			return err
+		}
+	}
+	return nil
 }
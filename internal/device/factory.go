@@ -12,6 +12,8 @@ import (
 type DeviceCreator func(config types.DeviceConfig) (types.Device, error)
 
 // Factory erstellt Geräte basierend auf ihrer Konfiguration
+//
+// Deprecated: Gerätetypen werden mit Beschreibung im creator.Catalog registriert.
 type Factory struct {
 	creators map[string]DeviceCreator
 	mutex    sync.RWMutex
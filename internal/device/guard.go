@@ -0,0 +1,192 @@
+package device
+
+import (
+	"context"
+	"errors"
+
+	"owipex_reader/internal/types"
+)
+
+// CommandGuard prüft Steuerbefehle, bevor sie ein Gerät erreichen (z.B. Sicherheitsverriegelungen).
+// Ein Fehler verhindert die Ausführung und wird an den Aufrufer von Write zurückgegeben.
+type CommandGuard interface {
+	CheckCommand(deviceID string, command types.Command) error
+}
+
+// CommandObserver wird von CommandGuards implementiert, die über ausgeführte Befehle
+// informiert werden möchten (z.B. um den befohlenen Zustand sofort zu übernehmen)
+type CommandObserver interface {
+	CommandExecuted(deviceID string, command types.Command)
+}
+
+// bypassGuardKey kennzeichnet Kontexte, deren Befehle den Guard nicht durchlaufen
+type bypassGuardKey struct{}
+
+// WithoutGuard gibt einen Kontext zurück, mit dem Write den Guard der Registry umgeht. Nur für
+// die sicheren Zustände des Failsafe-Managers: Sie dürfen nie an einer Verriegelung scheitern.
+func WithoutGuard(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassGuardKey{}, true)
+}
+
+// guardBypassed prüft, ob ein Kontext mit WithoutGuard erstellt wurde
+func guardBypassed(ctx context.Context) bool {
+	bypassed, _ := ctx.Value(bypassGuardKey{}).(bool)
+	return bypassed
+}
+
+// SetCommandGuard setzt den Guard, den jeder Write-Aufruf an Geräten der Registry durchläuft.
+// nil entfernt den Guard.
+func (r *Registry) SetCommandGuard(guard CommandGuard) {
+	r.guardMutex.Lock()
+	defer r.guardMutex.Unlock()
+	r.guard = guard
+}
+
+// commandGuard gibt den aktuellen Guard zurück
+func (r *Registry) commandGuard() CommandGuard {
+	r.guardMutex.RLock()
+	defer r.guardMutex.RUnlock()
+	return r.guard
+}
+
+// Unwrap gibt das ursprüngliche Gerät hinter der Befehlsprüfung der Registry zurück
+func Unwrap(device types.Device) types.Device {
+	if wrapped, ok := device.(interface{ Unwrap() types.Device }); ok {
+		return wrapped.Unwrap()
+	}
+	return device
+}
+
+// guardDevice umhüllt steuerbare Geräte, damit jeder Write-Aufruf den Guard der Registry
+// durchläuft. Die Schnittstellen des Geräts (Actor, HybridDevice) bleiben erhalten.
+func (r *Registry) guardDevice(device types.Device) types.Device {
+	writable, ok := device.(types.WritableDevice)
+	if !ok {
+		return device
+	}
+
+	guarded := guardedWritable{WritableDevice: writable, registry: r}
+
+	actor, isActor := device.(types.Actor)
+	hybrid, isHybrid := device.(types.HybridDevice)
+	switch {
+	case isActor && isHybrid:
+		return &guardedHybridActor{guardedWritable: guarded, readable: hybrid, actor: actor}
+	case isActor:
+		return &guardedActor{guardedWritable: guarded, actor: actor}
+	case isHybrid:
+		return &guardedHybrid{guardedWritable: guarded, readable: hybrid}
+	default:
+		return &guarded
+	}
+}
+
+// guardedWritable prüft Befehle vor der Ausführung
+type guardedWritable struct {
+	types.WritableDevice
+	registry *Registry
+}
+
+// Write prüft den Befehl mit dem Guard der Registry und führt ihn dann aus. Mit WithoutGuard
+// entfällt die Prüfung, der Guard wird aber über den ausgeführten Befehl informiert, ebenso
+// über Befehle, deren Zielzustand noch aussteht (types.ErrPending).
+func (g *guardedWritable) Write(ctx context.Context, command types.Command) error {
+	guard := g.registry.commandGuard()
+	if guard != nil && !guardBypassed(ctx) {
+		if err := guard.CheckCommand(g.ID(), command); err != nil {
+			return err
+		}
+	}
+
+	err := g.WritableDevice.Write(ctx, command)
+	if err != nil && !errors.Is(err, types.ErrPending) {
+		return err
+	}
+
+	if observer, ok := guard.(CommandObserver); ok {
+		observer.CommandExecuted(g.ID(), command)
+	}
+	return err
+}
+
+// Unwrap gibt das umhüllte Gerät zurück
+func (g *guardedWritable) Unwrap() types.Device {
+	return g.WritableDevice
+}
+
+// guardedActor ist ein Aktor mit Befehlsprüfung
+type guardedActor struct {
+	guardedWritable
+	actor types.Actor
+}
+
+// GetState gibt den aktuellen Zustand des Aktors zurück
+func (g *guardedActor) GetState() (interface{}, error) {
+	return g.actor.GetState()
+}
+
+// GetStateContext gibt den aktuellen Zustand des Aktors zurück (types.StateReader)
+func (g *guardedActor) GetStateContext(ctx context.Context) (interface{}, error) {
+	return getStateContext(ctx, g.actor)
+}
+
+// guardedHybrid ist ein Hybridgerät mit Befehlsprüfung
+type guardedHybrid struct {
+	guardedWritable
+	readable types.ReadableDevice
+}
+
+// Read liest einen Messwert
+func (g *guardedHybrid) Read(ctx context.Context) (types.Reading, error) {
+	return g.readable.Read(ctx)
+}
+
+// ReadRaw liest die Rohdaten vom Gerät
+func (g *guardedHybrid) ReadRaw(ctx context.Context) ([]byte, error) {
+	return g.readable.ReadRaw(ctx)
+}
+
+// AvailableReadings gibt die verfügbaren Messwerttypen zurück
+func (g *guardedHybrid) AvailableReadings() []types.ReadingType {
+	return g.readable.AvailableReadings()
+}
+
+// guardedHybridActor ist ein Hybridgerät mit Zustandsabfrage und Befehlsprüfung (z.B. Frequenzumrichter)
+type guardedHybridActor struct {
+	guardedWritable
+	readable types.ReadableDevice
+	actor    types.Actor
+}
+
+// Read liest einen Messwert
+func (g *guardedHybridActor) Read(ctx context.Context) (types.Reading, error) {
+	return g.readable.Read(ctx)
+}
+
+// ReadRaw liest die Rohdaten vom Gerät
+func (g *guardedHybridActor) ReadRaw(ctx context.Context) ([]byte, error) {
+	return g.readable.ReadRaw(ctx)
+}
+
+// AvailableReadings gibt die verfügbaren Messwerttypen zurück
+func (g *guardedHybridActor) AvailableReadings() []types.ReadingType {
+	return g.readable.AvailableReadings()
+}
+
+// GetState gibt den aktuellen Zustand des Geräts zurück
+func (g *guardedHybridActor) GetState() (interface{}, error) {
+	return g.actor.GetState()
+}
+
+// GetStateContext gibt den aktuellen Zustand des Geräts zurück (types.StateReader)
+func (g *guardedHybridActor) GetStateContext(ctx context.Context) (interface{}, error) {
+	return getStateContext(ctx, g.actor)
+}
+
+// getStateContext liest den Zustand mit Kontext, sofern der Aktor das unterstützt
+func getStateContext(ctx context.Context, actor types.Actor) (interface{}, error) {
+	if reader, ok := actor.(types.StateReader); ok {
+		return reader.GetStateContext(ctx)
+	}
+	return actor.GetState()
+}
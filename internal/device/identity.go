@@ -0,0 +1,128 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"owipex_reader/internal/types"
+)
+
+// IdentityCheck enthält das Ergebnis der Identitätsprüfung eines Geräts beim Start
+type IdentityCheck struct {
+	DeviceID string
+	Identity types.DeviceIdentity
+
+	// Verified ist true, wenn die Identität gelesen wurde und zur Konfiguration passt
+	Verified bool
+
+	// Mismatch ist true, wenn die gelesene Identität nicht zur Konfiguration passt
+	Mismatch bool
+
+	// Err enthält den Lese- oder Abweichungsfehler
+	Err error
+}
+
+// CheckIdentity liest die Identität eines Geräts über den Protokoll-Handler und
+// vergleicht sie mit Hersteller, Modell und Firmware aus der Konfiguration
+func CheckIdentity(ctx context.Context, config types.DeviceConfig, handler types.DeviceIdentifier) IdentityCheck {
+	check := IdentityCheck{DeviceID: config.ID}
+
+	identity, err := handler.ReadDeviceIdentity(ctx)
+	if err != nil {
+		check.Err = fmt.Errorf("identität von Gerät %s konnte nicht gelesen werden: %w", config.ID, err)
+		return check
+	}
+	check.Identity = identity
+
+	if err := VerifyIdentity(config, identity); err != nil {
+		check.Mismatch = true
+		check.Err = err
+		return check
+	}
+
+	check.Verified = true
+	return check
+}
+
+// VerifyIdentity prüft, ob die gelesene Identität zu Hersteller, Modell und Firmware
+// aus der Konfiguration passt. Leere Konfigurationswerte werden nicht geprüft.
+// Der konfigurierte Wert muss (ohne Groß-/Kleinschreibung) im gelesenen Wert enthalten sein,
+// bei der Firmware genügt ein Präfix (z.B. "2.1" passt zu "2.1.4").
+func VerifyIdentity(config types.DeviceConfig, identity types.DeviceIdentity) error {
+	var mismatches []string
+
+	if config.Manufacturer != "" && !containsFold(identity.Manufacturer, config.Manufacturer) {
+		mismatches = append(mismatches, fmt.Sprintf("Hersteller %q statt %q", identity.Manufacturer, config.Manufacturer))
+	}
+
+	if config.Model != "" &&
+		!containsFold(identity.Model, config.Model) &&
+		!containsFold(identity.ProductCode, config.Model) &&
+		!containsFold(identity.ProductName, config.Model) {
+		mismatches = append(mismatches, fmt.Sprintf("Modell %q statt %q", identity.Model, config.Model))
+	}
+
+	if config.Firmware != "" && !strings.HasPrefix(normalizeVersion(identity.Firmware), normalizeVersion(config.Firmware)) {
+		mismatches = append(mismatches, fmt.Sprintf("Firmware %q statt %q", identity.Firmware, config.Firmware))
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("angeschlossenes Gerät passt nicht zur Konfiguration von %s: %s",
+			config.ID, strings.Join(mismatches, ", "))
+	}
+
+	return nil
+}
+
+// Attributes gibt das Prüfergebnis als Client-Attribute für ThingsBoard zurück
+func (c IdentityCheck) Attributes() map[string]interface{} {
+	attributes := map[string]interface{}{
+		fmt.Sprintf("%s_identity_verified", c.DeviceID): c.Verified,
+	}
+
+	fields := map[string]string{
+		"manufacturer":  c.Identity.Manufacturer,
+		"model":         c.Identity.Model,
+		"product_code":  c.Identity.ProductCode,
+		"product_name":  c.Identity.ProductName,
+		"firmware":      c.Identity.Firmware,
+		"serial_number": c.Identity.SerialNumber,
+		"vendor_url":    c.Identity.VendorURL,
+	}
+	for key, value := range c.Identity.Extra {
+		fields[key] = value
+	}
+
+	for key, value := range fields {
+		if value != "" {
+			attributes[fmt.Sprintf("%s_%s", c.DeviceID, key)] = value
+		}
+	}
+
+	if c.Err != nil {
+		attributes[fmt.Sprintf("%s_identity_error", c.DeviceID)] = c.Err.Error()
+	} else {
+		attributes[fmt.Sprintf("%s_identity_error", c.DeviceID)] = ""
+	}
+
+	return attributes
+}
+
+// containsFold prüft ohne Berücksichtigung von Groß-/Kleinschreibung und Leerzeichen,
+// ob expected in actual enthalten ist
+func containsFold(actual, expected string) bool {
+	return strings.Contains(normalizeIdentity(actual), normalizeIdentity(expected))
+}
+
+// normalizeIdentity vereinheitlicht Identitätswerte für den Vergleich
+func normalizeIdentity(value string) string {
+	return strings.Join(strings.Fields(strings.ToLower(value)), " ")
+}
+
+// normalizeVersion vereinheitlicht Versionsangaben ("V2.1", "v 2.1", "2.1")
+func normalizeVersion(value string) string {
+	value = normalizeIdentity(value)
+	value = strings.TrimPrefix(value, "v")
+	return strings.TrimSpace(value)
+}
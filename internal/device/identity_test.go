@@ -0,0 +1,225 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"owipex_reader/internal/types"
+)
+
+// testIdentifier liefert eine vorgegebene Identität oder einen Lesefehler
+type testIdentifier struct {
+	identity types.DeviceIdentity
+	err      error
+}
+
+// ReadDeviceIdentity gibt die vorgegebene Identität zurück (types.DeviceIdentifier)
+func (i testIdentifier) ReadDeviceIdentity(ctx context.Context) (types.DeviceIdentity, error) {
+	return i.identity, i.err
+}
+
+// testSensor ist ein Gerät ohne Zugriffe für Tests der Registry
+type testSensor struct {
+	id      string
+	enabled bool
+}
+
+// ID gibt die Kennung des Geräts zurück
+func (s *testSensor) ID() string { return s.id }
+
+// Name gibt die Kennung als Anzeigenamen zurück
+func (s *testSensor) Name() string { return s.id }
+
+// Type gibt den Gerätetyp zurück
+func (s *testSensor) Type() types.DeviceType { return types.TypeSensor }
+
+// Metadata gibt keine Metadaten zurück
+func (s *testSensor) Metadata() map[string]interface{} { return nil }
+
+// IsEnabled gibt den Aktivierungszustand zurück
+func (s *testSensor) IsEnabled() bool { return s.enabled }
+
+// Enable setzt den Aktivierungszustand
+func (s *testSensor) Enable(enabled bool) { s.enabled = enabled }
+
+// Close wird ignoriert
+func (s *testSensor) Close() error { return nil }
+
+// TestCheckIdentity prüft den Vergleich der gelesenen Identität mit der Konfiguration
+func TestCheckIdentity(t *testing.T) {
+	identity := types.DeviceIdentity{
+		Manufacturer: "Hamilton  Bonaduz AG",
+		Model:        "VisiFerm DO Arc 120",
+		ProductName:  "VisiFerm",
+		Firmware:     "V2.1.4",
+	}
+
+	tests := []struct {
+		name         string
+		config       types.DeviceConfig
+		identifier   testIdentifier
+		wantVerified bool
+		wantMismatch bool
+		wantErr      bool
+	}{
+		{
+			name:         "alle Angaben passen",
+			config:       types.DeviceConfig{ID: "do", Manufacturer: "hamilton bonaduz", Model: "VisiFerm", Firmware: "2.1"},
+			identifier:   testIdentifier{identity: identity},
+			wantVerified: true,
+		},
+		{
+			name:         "keine Angaben konfiguriert",
+			config:       types.DeviceConfig{ID: "do"},
+			identifier:   testIdentifier{identity: identity},
+			wantVerified: true,
+		},
+		{
+			name:         "Modell über den Produktnamen",
+			config:       types.DeviceConfig{ID: "do", Model: "visiferm"},
+			identifier:   testIdentifier{identity: types.DeviceIdentity{ProductName: "VisiFerm DO"}},
+			wantVerified: true,
+		},
+		{
+			name:         "falscher Hersteller",
+			config:       types.DeviceConfig{ID: "do", Manufacturer: "Endress+Hauser"},
+			identifier:   testIdentifier{identity: identity},
+			wantMismatch: true,
+			wantErr:      true,
+		},
+		{
+			name:         "falsches Modell",
+			config:       types.DeviceConfig{ID: "do", Model: "Polilyte"},
+			identifier:   testIdentifier{identity: identity},
+			wantMismatch: true,
+			wantErr:      true,
+		},
+		{
+			name:         "Firmware ist kein Präfix",
+			config:       types.DeviceConfig{ID: "do", Firmware: "2.2"},
+			identifier:   testIdentifier{identity: identity},
+			wantMismatch: true,
+			wantErr:      true,
+		},
+		{
+			name:       "Identität nicht lesbar",
+			config:     types.DeviceConfig{ID: "do", Manufacturer: "Hamilton"},
+			identifier: testIdentifier{err: errors.New("keine Antwort")},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := CheckIdentity(context.Background(), tt.config, tt.identifier)
+
+			if check.DeviceID != tt.config.ID {
+				t.Errorf("DeviceID %q, erwartet %q", check.DeviceID, tt.config.ID)
+			}
+			if check.Verified != tt.wantVerified || check.Mismatch != tt.wantMismatch {
+				t.Errorf("Verified %v, Mismatch %v, erwartet %v, %v", check.Verified, check.Mismatch, tt.wantVerified, tt.wantMismatch)
+			}
+			if (check.Err != nil) != tt.wantErr {
+				t.Errorf("Fehler %v, erwartet Fehler: %v", check.Err, tt.wantErr)
+			}
+			if verified := check.Attributes()["do_identity_verified"]; verified != tt.wantVerified {
+				t.Errorf("Attribut identity_verified %v, erwartet %v", verified, tt.wantVerified)
+			}
+		})
+	}
+}
+
+// TestIdentityMismatch prüft, dass nur eine erfolgreiche Prüfung den Zustand identity_mismatch
+// aufhebt und der Grund veröffentlicht wird
+func TestIdentityMismatch(t *testing.T) {
+	reason := errors.New("Modell \"Polilyte\" statt \"VisiFerm\"")
+
+	tests := []struct {
+		name      string
+		actions   func(r *Registry, sensor *testSensor)
+		wantState ConnectivityState
+		wantErr   error
+	}{
+		{
+			name:      "Abweichung",
+			actions:   func(r *Registry, sensor *testSensor) {},
+			wantState: StateIdentityMismatch,
+			wantErr:   reason,
+		},
+		{
+			name: "erfolgreiche Zugriffe heben die Abweichung nicht auf",
+			actions: func(r *Registry, sensor *testSensor) {
+				r.RecordResult(sensor.id, nil)
+				r.RecordResult(sensor.id, nil)
+			},
+			wantState: StateIdentityMismatch,
+			wantErr:   reason,
+		},
+		{
+			name: "Deaktivieren und Aktivieren heben die Abweichung nicht auf",
+			actions: func(r *Registry, sensor *testSensor) {
+				sensor.Enable(false)
+				r.SyncEnabled(sensor.id)
+				sensor.Enable(true)
+				r.SyncEnabled(sensor.id)
+			},
+			wantState: StateIdentityMismatch,
+			wantErr:   reason,
+		},
+		{
+			name: "Wartung hebt die Abweichung nicht auf",
+			actions: func(r *Registry, sensor *testSensor) {
+				r.SetMaintenance(sensor.id, true)
+				r.SetMaintenance(sensor.id, false)
+			},
+			wantState: StateIdentityMismatch,
+			wantErr:   reason,
+		},
+		{
+			name: "erfolgreiche Prüfung hebt die Abweichung auf",
+			actions: func(r *Registry, sensor *testSensor) {
+				r.SetIdentityMismatch(sensor.id, nil)
+			},
+			wantState: StateInitializing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			defer registry.Close()
+			sensor := &testSensor{id: "do", enabled: true}
+			if err := registry.AddDevice(sensor); err != nil {
+				t.Fatal(err)
+			}
+
+			status, changed, err := registry.SetIdentityMismatch(sensor.id, reason)
+			if err != nil || !changed || status.State != StateIdentityMismatch {
+				t.Fatalf("SetIdentityMismatch: %s, geändert %v, Fehler %v", status.State, changed, err)
+			}
+			if status.Connected() || registry.ShouldAttempt(sensor.id) {
+				t.Fatal("Gerät mit abweichender Identität gilt als erreichbar")
+			}
+			if attributes := status.Attributes(); attributes["do_last_error"] != reason.Error() {
+				t.Errorf("last_error %v, erwartet %q", attributes["do_last_error"], reason)
+			}
+
+			tt.actions(registry, sensor)
+
+			status, err = registry.GetConnectivity(sensor.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.State != tt.wantState {
+				t.Errorf("Zustand %s, erwartet %s", status.State, tt.wantState)
+			}
+			if status.LastError != tt.wantErr {
+				t.Errorf("Grund %v, erwartet %v", status.LastError, tt.wantErr)
+			}
+			if attempt := registry.ShouldAttempt(sensor.id); attempt != (tt.wantState != StateIdentityMismatch) {
+				t.Errorf("ShouldAttempt %v im Zustand %s", attempt, status.State)
+			}
+		})
+	}
+}
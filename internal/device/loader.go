@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -52,17 +53,63 @@ func LoadDeviceConfigs(dirPath string) ([]types.DeviceConfig, error) {
 	return configs, nil
 }
 
-// SaveDeviceConfig speichert eine Gerätekonfiguration in einer JSON-Datei
+// BackupDir ist das Verzeichnis der gesicherten Konfigurationsstände relativ zum Gerätekonfigurationspfad
+const BackupDir = "backups"
+
+// SaveDeviceConfig speichert eine Gerätekonfiguration atomar in einer JSON-Datei
 func SaveDeviceConfig(config *types.DeviceConfig, filePath string) error {
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("fehler beim Kodieren der Konfiguration: %w", err)
 	}
 
-	err = ioutil.WriteFile(filePath, data, 0644)
-	if err != nil {
+	if err := WriteFileAtomic(filePath, append(data, '\n'), 0644); err != nil {
 		return fmt.Errorf("fehler beim Schreiben der Konfigurationsdatei: %w", err)
 	}
 
 	return nil
 }
+
+// WriteFileAtomic schreibt eine Datei über eine temporäre Datei im selben Verzeichnis und
+// benennt sie danach um. Leser (z.B. der Hot-Reload) sehen so entweder den alten oder den
+// vollständigen neuen Inhalt, auch bei einem Stromausfall während des Schreibens.
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// Temporäre Datei ohne .json-Endung, damit sie nicht als Konfiguration geladen wird
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(filePath)+".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return err
+	}
+
+	// Verzeichniseintrag auf den Datenträger schreiben
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
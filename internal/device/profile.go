@@ -0,0 +1,263 @@
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"owipex_reader/internal/types"
+)
+
+// ProfileDir ist das Verzeichnis der Geräteprofile relativ zum Gerätekonfigurationspfad.
+// Ein Profil liegt unter <ProfileDir>/<hersteller>/<modell>.json und wird in der
+// Gerätekonfiguration mit "profile": "<hersteller>/<modell>" referenziert.
+const ProfileDir = "profiles"
+
+// Profile beschreibt ein bestimmtes Gerätemodell: Register-Maps, Datentypen, Standardwerte
+// (z.B. Kalibrierung) und Besonderheiten des Modells. Besonderheiten werden als normale
+// Metadata-Werte hinterlegt (z.B. "byte_order" einer Register-Map oder
+// "identification": {"method": "none"} für Geräte ohne Geräteidentifikation).
+type Profile struct {
+	// Ref ist die Referenz des Profils (z.B. "hamilton/polilyte_plus"), ergibt sich aus dem Dateipfad
+	Ref string `json:"-"`
+
+	// Path ist der Dateipfad, aus dem das Profil geladen wurde
+	Path string `json:"-"`
+
+	Type         string `json:"type"`
+	Protocol     string `json:"protocol"`
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	Firmware     string `json:"firmware"`
+	Description  string `json:"description"`
+
+	// Notes sind Hinweise zum Modell für Inbetriebnahme und Wartung
+	Notes []string `json:"notes,omitempty"`
+
+	// Metadata ist die Vorlage für den Metadata-Block der Gerätekonfiguration
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// ProfileError ist ein Fehler beim Laden einer Profildatei
+type ProfileError struct {
+	Path string
+	Err  error
+}
+
+// Error implementiert das error-Interface
+func (e *ProfileError) Error() string {
+	return fmt.Sprintf("profil %s: %v", e.Path, e.Err)
+}
+
+// Unwrap gibt den ursprünglichen Fehler zurück
+func (e *ProfileError) Unwrap() error {
+	return e.Err
+}
+
+// ProfileLibrary enthält die geladenen Geräteprofile
+type ProfileLibrary struct {
+	profiles map[string]Profile
+	mutex    sync.RWMutex
+}
+
+// NewProfileLibrary erstellt eine leere Profilbibliothek
+func NewProfileLibrary() *ProfileLibrary {
+	return &ProfileLibrary{
+		profiles: make(map[string]Profile),
+	}
+}
+
+// Load liest alle Profile unterhalb von dirPath neu ein. Ist eine Profildatei ungültig,
+// bleibt die zuletzt gültige Fassung dieses Profils erhalten, damit ein fehlerhafter
+// Schreibvorgang die Geräte nicht stört. Profile gelöschter Dateien werden entfernt.
+// Ein fehlendes Verzeichnis ergibt eine leere Bibliothek.
+func (l *ProfileLibrary) Load(dirPath string) []error {
+	profiles := make(map[string]Profile)
+	var errs []error
+
+	l.mutex.RLock()
+	previous := l.profiles
+	l.mutex.RUnlock()
+
+	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dirPath {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return err
+		}
+		ref := filepath.ToSlash(strings.TrimSuffix(rel, ".json"))
+
+		profile, err := loadProfile(path)
+		if err != nil {
+			errs = append(errs, &ProfileError{Path: path, Err: err})
+			if old, ok := previous[ref]; ok {
+				profiles[ref] = old
+			}
+			return nil
+		}
+
+		profile.Ref = ref
+		profiles[ref] = *profile
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, &ProfileError{Path: dirPath, Err: err})
+	}
+
+	l.mutex.Lock()
+	l.profiles = profiles
+	l.mutex.Unlock()
+
+	return errs
+}
+
+// loadProfile lädt eine einzelne Profildatei
+func loadProfile(filePath string) (*Profile, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen der Profildatei: %w", err)
+	}
+
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("fehler beim Dekodieren des Profils: %w", err)
+	}
+	if profile.Type == "" {
+		return nil, fmt.Errorf("kein Gerätetyp im Profil angegeben")
+	}
+
+	profile.Path = filePath
+	return &profile, nil
+}
+
+// Get gibt ein Profil anhand seiner Referenz zurück
+func (l *ProfileLibrary) Get(ref string) (Profile, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	profile, exists := l.profiles[ref]
+	if !exists {
+		return Profile{}, fmt.Errorf("profil '%s' nicht gefunden", ref)
+	}
+	return profile, nil
+}
+
+// Refs gibt die Referenzen aller geladenen Profile sortiert zurück
+func (l *ProfileLibrary) Refs() []string {
+	l.mutex.RLock()
+	refs := make([]string, 0, len(l.profiles))
+	for ref := range l.profiles {
+		refs = append(refs, ref)
+	}
+	l.mutex.RUnlock()
+
+	sort.Strings(refs)
+	return refs
+}
+
+// Resolve führt eine Gerätekonfiguration mit dem referenzierten Profil zusammen.
+// Es gilt folgende Rangfolge:
+//  1. Werte der Gerätekonfiguration (z.B. Slave-ID, einzelne Register oder Kalibrierung)
+//  2. Werte des Profils
+//  3. Standardwerte des Gerätetyps (werden beim Erstellen über den Katalog ergänzt)
+//
+// Type, Protocol, Manufacturer, Model und Firmware werden aus dem Profil übernommen,
+// wenn sie in der Gerätekonfiguration leer sind. Der Gerätetyp darf dem Profil nicht
+// widersprechen. Metadata-Objekte werden rekursiv zusammengeführt, Listen und einfache
+// Werte der Gerätekonfiguration ersetzen die des Profils vollständig, und ein
+// explizites null entfernt einen Wert des Profils (z.B. ein nicht benötigtes Register).
+// Konfigurationen ohne Profil werden unverändert zurückgegeben.
+func (l *ProfileLibrary) Resolve(config types.DeviceConfig) (types.DeviceConfig, error) {
+	if config.Profile == "" {
+		return config, nil
+	}
+	if l == nil {
+		return config, fmt.Errorf("profil '%s' nicht gefunden: keine Profile geladen", config.Profile)
+	}
+
+	profile, err := l.Get(config.Profile)
+	if err != nil {
+		return config, err
+	}
+
+	if config.Type != "" && config.Type != profile.Type {
+		return config, fmt.Errorf("gerätetyp '%s' widerspricht dem Typ '%s' des Profils '%s'", config.Type, profile.Type, profile.Ref)
+	}
+
+	config.Type = profile.Type
+	config.Protocol = firstNonEmpty(config.Protocol, profile.Protocol)
+	config.Manufacturer = firstNonEmpty(config.Manufacturer, profile.Manufacturer)
+	config.Model = firstNonEmpty(config.Model, profile.Model)
+	config.Firmware = firstNonEmpty(config.Firmware, profile.Firmware)
+	config.Metadata = MergeOverrides(profile.Metadata, config.Metadata)
+
+	return config, nil
+}
+
+// MergeOverrides erstellt eine Kopie von base, in der die Werte aus overrides gesetzt sind.
+// Objekte werden rekursiv zusammengeführt, andere Werte ersetzt und null entfernt einen
+// Wert (wie bei JSON Merge Patch, RFC 7396). base und overrides werden nicht verändert.
+func MergeOverrides(base, overrides map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = copyProfileValue(value)
+	}
+
+	for key, override := range overrides {
+		if override == nil {
+			delete(merged, key)
+			continue
+		}
+
+		baseMap, baseIsMap := merged[key].(map[string]interface{})
+		overrideMap, overrideIsMap := override.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			merged[key] = MergeOverrides(baseMap, overrideMap)
+			continue
+		}
+
+		merged[key] = copyProfileValue(override)
+	}
+
+	return merged
+}
+
+// copyProfileValue kopiert verschachtelte Maps und Slices, damit Geräte das Profil nicht verändern
+func copyProfileValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return MergeOverrides(v, nil)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyProfileValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// firstNonEmpty gibt den ersten nicht leeren Wert zurück
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
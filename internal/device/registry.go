@@ -3,17 +3,40 @@ package device
 
 import (
 	"fmt"
+	"log"
+	"os"
+	"sort"
 	"sync"
+	"time"
 
+	"owipex_reader/internal/event"
 	"owipex_reader/internal/types"
 )
 
 // Registry ist ein Thread-sicheres Register für alle im System verfügbaren Geräte.
 // Es ermöglicht das Hinzufügen, Entfernen und Abrufen von Geräten.
+// Änderungen werden als TopicDeviceLifecycle-Ereignisse auf dem Event-Bus veröffentlicht.
+// Zu jedem Gerät führt die Registry den Verbindungszustand, dessen Wechsel als
+// TopicConnectionState-Ereignisse veröffentlicht werden.
 type Registry struct {
 	devices  map[string]types.Device
 	mutex    sync.RWMutex
-	handlers map[string]DeviceEventHandler
+	bus      *event.Bus
+	ownsBus  bool
+	handlers map[string]bool
+	logger   *log.Logger
+
+	// lifecycleMutex serialisiert Hinzufügen, Entfernen und Ersetzen samt Ereignis, damit die
+	// Ereignisse in der Reihenfolge der Änderungen erscheinen. Veröffentlicht wird ohne mutex,
+	// damit ein wartender Abonnent die Registry nicht blockiert.
+	lifecycleMutex sync.Mutex
+
+	connectivity       map[string]*connectivity
+	connectivityConfig ConnectivityConfig
+
+	// guard prüft jeden Befehl an steuerbare Geräte (siehe SetCommandGuard)
+	guard      CommandGuard
+	guardMutex sync.RWMutex
 }
 
 // DeviceEventHandler ist ein Callback-Typ für Geräteereignisse
@@ -41,25 +64,48 @@ type DeviceEvent struct {
 	Data     map[string]interface{}
 }
 
-// NewRegistry erstellt eine neue Geräteregistry
+// NewRegistry erstellt eine neue Geräteregistry mit eigenem Event-Bus
 func NewRegistry() *Registry {
+	registry := NewRegistryWithBus(event.NewBus())
+	registry.ownsBus = true
+	return registry
+}
+
+// NewRegistryWithBus erstellt eine neue Geräteregistry, die auf einem gemeinsamen Event-Bus veröffentlicht
+func NewRegistryWithBus(bus *event.Bus) *Registry {
 	return &Registry{
-		devices:  make(map[string]types.Device),
-		handlers: make(map[string]DeviceEventHandler),
+		devices:            make(map[string]types.Device),
+		bus:                bus,
+		handlers:           make(map[string]bool),
+		logger:             log.New(os.Stdout, "[DeviceRegistry] ", log.LstdFlags),
+		connectivity:       make(map[string]*connectivity),
+		connectivityConfig: DefaultConnectivityConfig(),
 	}
 }
 
-// AddDevice fügt ein Gerät zur Registry hinzu
+// EventBus gibt den Event-Bus zurück, auf dem die Registry veröffentlicht
+func (r *Registry) EventBus() *event.Bus {
+	return r.bus
+}
+
+// AddDevice fügt ein Gerät zur Registry hinzu. Steuerbare Geräte werden so umhüllt, dass
+// jeder Write-Aufruf den CommandGuard der Registry durchläuft.
 func (r *Registry) AddDevice(device types.Device) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
+	r.lifecycleMutex.Lock()
+	defer r.lifecycleMutex.Unlock()
 
+	device = r.guardDevice(device)
 	id := device.ID()
+
+	r.mutex.Lock()
 	if _, exists := r.devices[id]; exists {
+		r.mutex.Unlock()
 		return fmt.Errorf("gerät mit ID %s ist bereits registriert", id)
 	}
-
 	r.devices[id] = device
+	r.connectivity[id] = newConnectivity(id, r.connectivityConfig, device.IsEnabled(), time.Now())
+	r.mutex.Unlock()
+
 	r.notifyHandlers(types.DeviceEvent{
 		Type:     types.EventAdded,
 		DeviceID: id,
@@ -71,15 +117,19 @@ func (r *Registry) AddDevice(device types.Device) error {
 
 // RemoveDevice entfernt ein Gerät aus der Registry
 func (r *Registry) RemoveDevice(id string) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
+	r.lifecycleMutex.Lock()
+	defer r.lifecycleMutex.Unlock()
 
+	r.mutex.Lock()
 	device, exists := r.devices[id]
 	if !exists {
+		r.mutex.Unlock()
 		return fmt.Errorf("gerät mit ID %s nicht gefunden", id)
 	}
-
 	delete(r.devices, id)
+	delete(r.connectivity, id)
+	r.mutex.Unlock()
+
 	r.notifyHandlers(types.DeviceEvent{
 		Type:     types.EventRemoved,
 		DeviceID: id,
@@ -89,6 +139,35 @@ func (r *Registry) RemoveDevice(id string) error {
 	return nil
 }
 
+// ReplaceDevice ersetzt ein registriertes Gerät durch eine neue Instanz mit derselben ID
+// und gibt das bisherige Gerät zurück. Das Schließen des alten Geräts ist Aufgabe des Aufrufers.
+func (r *Registry) ReplaceDevice(device types.Device) (types.Device, error) {
+	r.lifecycleMutex.Lock()
+	defer r.lifecycleMutex.Unlock()
+
+	device = r.guardDevice(device)
+	id := device.ID()
+
+	r.mutex.Lock()
+	previous, exists := r.devices[id]
+	if !exists {
+		r.mutex.Unlock()
+		return nil, fmt.Errorf("gerät mit ID %s nicht gefunden", id)
+	}
+	r.devices[id] = device
+	// Neue Instanz beginnt wieder im Zustand initializing
+	r.connectivity[id] = newConnectivity(id, r.connectivityConfig, device.IsEnabled(), time.Now())
+	r.mutex.Unlock()
+
+	r.notifyHandlers(types.DeviceEvent{
+		Type:     types.EventUpdated,
+		DeviceID: id,
+		Device:   device,
+	})
+
+	return previous, nil
+}
+
 // GetDevice gibt ein Gerät aus der Registry zurück
 func (r *Registry) GetDevice(id string) (types.Device, error) {
 	r.mutex.RLock()
@@ -139,6 +218,23 @@ func (r *Registry) GetSensors() []types.Sensor {
 	return sensors
 }
 
+// GetReadableDevices gibt alle Geräte zurück, die Messwerte liefern (Sensoren und Hybridgeräte)
+func (r *Registry) GetReadableDevices() []types.ReadableDevice {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var readables []types.ReadableDevice
+	for _, device := range r.devices {
+		if device.Type() == types.TypeSensor || device.Type() == types.TypeHybrid {
+			if readable, ok := device.(types.ReadableDevice); ok {
+				readables = append(readables, readable)
+			}
+		}
+	}
+
+	return readables
+}
+
 // GetActors gibt alle Aktoren zurück
 func (r *Registry) GetActors() []types.Actor {
 	r.mutex.RLock()
@@ -156,12 +252,167 @@ func (r *Registry) GetActors() []types.Actor {
 	return actors
 }
 
-// RegisterHandler registriert einen Event-Handler mit einer eindeutigen ID
+// SetConnectivityConfig setzt die Schwellwerte der Verbindungszustände für alle Geräte
+func (r *Registry) SetConnectivityConfig(config ConnectivityConfig) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.connectivityConfig = config.normalize()
+	for _, tracker := range r.connectivity {
+		tracker.setConfig(r.connectivityConfig)
+	}
+}
+
+// RecordResult übernimmt das Ergebnis eines Lese- oder Schreibzugriffs (err == nil bei Erfolg)
+// in den Verbindungszustand des Geräts. Zurückgegeben wird der neue Zustand und ob er sich geändert hat.
+func (r *Registry) RecordResult(id string, err error) (ConnectivityStatus, bool, error) {
+	tracker, lookupErr := r.connectivityOf(id)
+	if lookupErr != nil {
+		return ConnectivityStatus{}, false, lookupErr
+	}
+
+	var status ConnectivityStatus
+	var changed bool
+	if err == nil {
+		status, changed = tracker.recordSuccess(time.Now())
+	} else {
+		status, changed = tracker.recordFailure(err, time.Now())
+	}
+
+	if changed {
+		r.publishConnectivity(status)
+	}
+	return status, changed, nil
+}
+
+// SyncEnabled gleicht den Verbindungszustand mit dem Aktivierungszustand des Geräts ab
+func (r *Registry) SyncEnabled(id string) (ConnectivityStatus, bool, error) {
+	device, err := r.GetDevice(id)
+	if err != nil {
+		return ConnectivityStatus{}, false, err
+	}
+	tracker, err := r.connectivityOf(id)
+	if err != nil {
+		return ConnectivityStatus{}, false, err
+	}
+
+	status, changed := tracker.setEnabled(device.IsEnabled(), time.Now())
+	if changed {
+		r.publishConnectivity(status)
+	}
+	return status, changed, nil
+}
+
+// SetMaintenance versetzt ein Gerät in den Wartungszustand oder beendet ihn
+func (r *Registry) SetMaintenance(id string, maintenance bool) (ConnectivityStatus, bool, error) {
+	tracker, err := r.connectivityOf(id)
+	if err != nil {
+		return ConnectivityStatus{}, false, err
+	}
+
+	status, changed := tracker.setMaintenance(maintenance, time.Now())
+	if changed {
+		r.publishConnectivity(status)
+	}
+	return status, changed, nil
+}
+
+// SetIdentityMismatch übernimmt das Ergebnis einer Identitätsprüfung in den Verbindungszustand.
+// Ein Fehler versetzt das Gerät in den Zustand identity_mismatch und wird als Grund
+// veröffentlicht, nil (erfolgreiche Prüfung) hebt den Zustand wieder auf.
+func (r *Registry) SetIdentityMismatch(id string, reason error) (ConnectivityStatus, bool, error) {
+	tracker, err := r.connectivityOf(id)
+	if err != nil {
+		return ConnectivityStatus{}, false, err
+	}
+
+	status, changed := tracker.setIdentityMismatch(reason, time.Now())
+	if changed {
+		r.publishConnectivity(status)
+	}
+	return status, changed, nil
+}
+
+// ShouldAttempt prüft, ob ein Gerät angesprochen werden soll. Offline-Geräte werden erst
+// nach Ablauf des Backoffs erneut versucht, deaktivierte Geräte und Geräte mit falscher
+// Identität gar nicht.
+func (r *Registry) ShouldAttempt(id string) bool {
+	tracker, err := r.connectivityOf(id)
+	if err != nil {
+		return false
+	}
+	return tracker.shouldAttempt(time.Now())
+}
+
+// GetConnectivity gibt den Verbindungszustand eines Geräts zurück
+func (r *Registry) GetConnectivity(id string) (ConnectivityStatus, error) {
+	tracker, err := r.connectivityOf(id)
+	if err != nil {
+		return ConnectivityStatus{}, err
+	}
+	return tracker.snapshot(), nil
+}
+
+// GetAllConnectivity gibt die Verbindungszustände aller Geräte sortiert nach ID zurück
+func (r *Registry) GetAllConnectivity() []ConnectivityStatus {
+	r.mutex.RLock()
+	statuses := make([]ConnectivityStatus, 0, len(r.connectivity))
+	for _, tracker := range r.connectivity {
+		statuses = append(statuses, tracker.snapshot())
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].DeviceID < statuses[j].DeviceID
+	})
+	return statuses
+}
+
+// connectivityOf gibt die Zustandsmaschine eines Geräts zurück
+func (r *Registry) connectivityOf(id string) (*connectivity, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tracker, exists := r.connectivity[id]
+	if !exists {
+		return nil, fmt.Errorf("gerät mit ID %s nicht gefunden", id)
+	}
+	return tracker, nil
+}
+
+// publishConnectivity veröffentlicht einen Zustandswechsel auf dem Event-Bus
+func (r *Registry) publishConnectivity(status ConnectivityStatus) {
+	r.bus.Publish(event.NewConnectionStateEvent(event.ConnectionState{
+		Component: status.DeviceID,
+		Connected: status.Connected(),
+		State:     string(status.State),
+		Err:       status.LastError,
+	}))
+}
+
+// RegisterHandler registriert einen Event-Handler für Geräteereignisse mit einer eindeutigen ID.
+// Der Handler wird als Abonnent von TopicDeviceLifecycle auf dem Event-Bus geführt und erhält
+// die Ereignisse in der Reihenfolge, in der sie aufgetreten sind.
 func (r *Registry) RegisterHandler(id string, handler DeviceEventHandler) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	r.handlers[id] = handler
+	// Vorhandenen Handler mit derselben ID ersetzen
+	if r.handlers[id] {
+		r.bus.Unsubscribe(id)
+	}
+
+	err := r.bus.Subscribe(id, func(e event.Event) {
+		if deviceEvent, ok := e.Payload.(types.DeviceEvent); ok {
+			handler(deviceEvent)
+		}
+	}, event.SubscriberOptions{}, event.TopicDeviceLifecycle)
+	if err != nil {
+		r.logger.Printf("Fehler beim Registrieren des Handlers %s: %v", id, err)
+		return
+	}
+
+	r.handlers[id] = true
 }
 
 // UnregisterHandler entfernt einen Event-Handler
@@ -169,14 +420,16 @@ func (r *Registry) UnregisterHandler(id string) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	delete(r.handlers, id)
+	if r.handlers[id] {
+		r.bus.Unsubscribe(id)
+		delete(r.handlers, id)
+	}
 }
 
-// notifyHandlers informiert alle registrierten Handler über ein Ereignis
-func (r *Registry) notifyHandlers(event types.DeviceEvent) {
-	for _, handler := range r.handlers {
-		go handler(event)
-	}
+// notifyHandlers veröffentlicht ein Geräteereignis auf dem Event-Bus. Der Aufrufer hält
+// lifecycleMutex, aber nicht mutex.
+func (r *Registry) notifyHandlers(deviceEvent types.DeviceEvent) {
+	r.bus.Publish(event.NewDeviceLifecycleEvent(deviceEvent))
 }
 
 // Close schließt alle Geräte und gibt Ressourcen frei
@@ -186,10 +439,20 @@ func (r *Registry) Close() {
 
 	for id, device := range r.devices {
 		if err := device.Close(); err != nil {
-			fmt.Printf("Fehler beim Schließen des Geräts %s: %v\n", id, err)
+			r.logger.Printf("Fehler beim Schließen des Geräts %s: %v", id, err)
 		}
 	}
 
 	r.devices = make(map[string]types.Device)
-	r.handlers = make(map[string]DeviceEventHandler)
+	r.connectivity = make(map[string]*connectivity)
+
+	for id := range r.handlers {
+		r.bus.Unsubscribe(id)
+	}
+	r.handlers = make(map[string]bool)
+
+	// Einen eigenen Bus schließen, ein gemeinsamer Bus gehört dem Aufrufer
+	if r.ownsBus {
+		r.bus.Close()
+	}
 }
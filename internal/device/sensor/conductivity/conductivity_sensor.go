@@ -0,0 +1,388 @@
+// Package conductivity implementiert einen Leitfähigkeitssensor (EC) mit
+// Temperaturkompensation sowie abgeleiteten TDS- und Salinitätswerten.
+package conductivity
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"owipex_reader/internal/device/sensor"
+	"owipex_reader/internal/types"
+)
+
+// Konstanten für Leitfähigkeitssensoren
+const (
+	// Standard-Register-Namen
+	RegisterConductivity = "conductivity"
+	RegisterTemperature  = "temperature"
+
+	// Kalibrierungsparameter
+	CalibrationOffset               = "offset"
+	CalibrationScale                = "scale"
+	CalibrationCellConstant         = "cell_constant"
+	CalibrationReferenceSolution    = "reference_conductivity"
+	CalibrationMeasuredSolution     = "measured_conductivity"
+	CalibrationTemperatureOffset    = "temperature_offset"
+	CalibrationManualTemperature    = "manual_temperature"
+	CalibrationCompensation         = "compensation"
+	CalibrationTempCoefficient      = "temp_coefficient"
+	CalibrationTempCoefficient2     = "temp_coefficient_2"
+	CalibrationReferenceTemperature = "reference_temperature"
+	CalibrationTDSFactor            = "tds_factor"
+	CalibrationSalinityFactor       = "salinity_factor"
+
+	// Kompensationsarten
+	CompensationNone      = "none"
+	CompensationLinear    = "linear"
+	CompensationNonLinear = "nonlinear"
+
+	// Default Register-Adressen
+	DefaultRegisterConductivity = uint16(0x0001)
+	DefaultRegisterTemperature  = uint16(0x0003)
+
+	// Standardwerte für die Kompensation und die abgeleiteten Größen
+	DefaultReferenceTemperature = 25.0
+	DefaultLinearCoefficient    = 2.0    // %/°C
+	DefaultNonLinearCoefficient = 1.91   // %/°C
+	DefaultNonLinearQuadratic   = 0.0085 // %/°C²
+	DefaultTDSFactor            = 0.64
+
+	// Leitfähigkeit von Standard-Meerwasser (S=35) bei 25 °C in µS/cm (PSS-78)
+	seawaterConductivity25 = 53087.0
+)
+
+// Koeffizienten der Practical Salinity Scale 1978 (PSS-78)
+var (
+	pss78A = [6]float64{0.0080, -0.1692, 25.3851, 14.0941, -7.0261, 2.7081}
+	pss78B = [6]float64{0.0005, -0.0056, -0.0066, -0.0375, 0.0636, -0.0144}
+)
+
+const pss78K = 0.0162
+
+// ConductivitySensor implementiert einen Leitfähigkeitssensor
+type ConductivitySensor struct {
+	*sensor.BaseSensor
+}
+
+// NewConductivitySensor erstellt einen neuen Leitfähigkeitssensor
+func NewConductivitySensor(id, name string) *ConductivitySensor {
+	base := sensor.NewBaseSensor(id, name, types.ReadingTypeConductivity, types.ReadingTypeCustom)
+
+	return &ConductivitySensor{
+		BaseSensor: base,
+	}
+}
+
+// Read liest Leitfähigkeit und Temperatur vom Sensor und berechnet
+// die auf die Referenztemperatur kompensierte Leitfähigkeit, TDS und Salinität
+func (s *ConductivitySensor) Read(ctx context.Context) (types.Reading, error) {
+	protocol := s.GetProtocol()
+	if protocol == nil {
+		return types.Reading{}, fmt.Errorf("kein Protokoll-Handler konfiguriert")
+	}
+
+	// Konfiguration für das Leitfähigkeits-Register abrufen
+	ecConfig := protocol.GetRegisterConfig(RegisterConductivity)
+	if ecConfig.Address == 0 {
+		// Fallback auf Standard-Adresse
+		ecConfig.Address = DefaultRegisterConductivity
+		ecConfig.Length = 2
+	}
+	if ecConfig.DataType == "" {
+		ecConfig.DataType = "float32"
+		ecConfig.ByteOrder = "big_endian"
+	}
+
+	// Leitfähigkeit lesen
+	rawData, err := protocol.ReadRegister(ctx, ecConfig.Address, ecConfig.Length)
+	if err != nil {
+		return types.Reading{}, fmt.Errorf("fehler beim Lesen des Leitfähigkeits-Registers: %w", err)
+	}
+
+	rawValue, err := convertRawToFloat(rawData, ecConfig.DataType, ecConfig.ByteOrder)
+	if err != nil {
+		return types.Reading{}, fmt.Errorf("fehler bei der Konvertierung der Leitfähigkeitsdaten: %w", err)
+	}
+
+	// Kalibrierungsdaten abrufen
+	calibration := s.GetCalibration()
+	offset, _ := getFloatFromMap(calibration, CalibrationOffset, 0.0)
+	scale, _ := getFloatFromMap(calibration, CalibrationScale, 1.0)
+	cellConstant, _ := getFloatFromMap(calibration, CalibrationCellConstant, 1.0)
+	tempOffset, _ := getFloatFromMap(calibration, CalibrationTemperatureOffset, 0.0)
+	refTemp, _ := getFloatFromMap(calibration, CalibrationReferenceTemperature, DefaultReferenceTemperature)
+	tdsFactor, _ := getFloatFromMap(calibration, CalibrationTDSFactor, DefaultTDSFactor)
+	salinityFactor, _ := getFloatFromMap(calibration, CalibrationSalinityFactor, 0.0)
+	compensation, _ := calibration[CalibrationCompensation].(string)
+	if compensation == "" {
+		compensation = CompensationLinear
+	}
+
+	// Unkompensierte Leitfähigkeit bei Messtemperatur (µS/cm)
+	ecRaw := rawValue*scale*cellConstant + offset
+	if ecRaw < 0 {
+		ecRaw = 0
+	}
+
+	// Temperatur lesen - ohne Temperatur wird mit der manuellen bzw. Referenztemperatur gerechnet
+	quality := types.QualityGood
+	temperature, tempErr := s.readTemperature(ctx, protocol)
+	if tempErr == nil {
+		temperature += tempOffset
+	} else if manual, ok := getFloatFromMap(calibration, CalibrationManualTemperature, refTemp); ok {
+		temperature = manual
+	} else {
+		temperature = refTemp
+		if compensation != CompensationNone {
+			quality = types.QualityUncertain
+		}
+	}
+
+	// Temperaturkompensation anwenden
+	alpha, beta := compensationCoefficients(calibration, compensation)
+	ec25, err := compensateConductivity(ecRaw, temperature, refTemp, compensation, alpha, beta)
+	if err != nil {
+		return types.Reading{}, err
+	}
+
+	// Reading-Objekt erstellen mit kompensierter Leitfähigkeit als Hauptwert
+	reading := types.NewReading(types.ReadingTypeConductivity, ec25, "µS/cm", rawData)
+	reading.Quality = quality
+
+	// Zusätzliche Metadaten hinzufügen
+	reading.Metadata["conductivity_raw"] = ecRaw
+	reading.Metadata["temperature"] = temperature
+	reading.Metadata["compensation"] = compensation
+	reading.Metadata["reference_temperature"] = refTemp
+	reading.Metadata["tds"] = calculateTDS(ec25, tdsFactor)
+	reading.Metadata["salinity"] = calculateSalinity(ec25, salinityFactor)
+	if tempErr != nil {
+		reading.Metadata["temperature_error"] = tempErr.Error()
+	}
+
+	return reading, nil
+}
+
+// readTemperature liest die Temperatur des Sensors in °C
+func (s *ConductivitySensor) readTemperature(ctx context.Context, protocol types.ProtocolHandler) (float64, error) {
+	tempConfig := protocol.GetRegisterConfig(RegisterTemperature)
+	if tempConfig.Address == 0 {
+		// Fallback auf Standard-Adresse
+		tempConfig.Address = DefaultRegisterTemperature
+		tempConfig.Length = 2
+	}
+	if tempConfig.DataType == "" {
+		tempConfig.DataType = "float32"
+		tempConfig.ByteOrder = "big_endian"
+	}
+
+	tempData, err := protocol.ReadRegister(ctx, tempConfig.Address, tempConfig.Length)
+	if err != nil {
+		return 0, fmt.Errorf("fehler beim Lesen des Temperatur-Registers: %w", err)
+	}
+
+	return convertRawToFloat(tempData, tempConfig.DataType, tempConfig.ByteOrder)
+}
+
+// ReadRaw liest die Rohdaten vom Leitfähigkeitssensor
+func (s *ConductivitySensor) ReadRaw(ctx context.Context) ([]byte, error) {
+	protocol := s.GetProtocol()
+	if protocol == nil {
+		return nil, fmt.Errorf("kein Protokoll-Handler konfiguriert")
+	}
+
+	// Konfiguration für das Leitfähigkeits-Register abrufen
+	registerConfig := protocol.GetRegisterConfig(RegisterConductivity)
+	if registerConfig.Address == 0 {
+		registerConfig.Address = DefaultRegisterConductivity
+		registerConfig.Length = 2
+	}
+
+	// Rohdaten vom Register lesen
+	return protocol.ReadRegister(ctx, registerConfig.Address, registerConfig.Length)
+}
+
+// SetCalibration setzt neue Kalibrierungsparameter für den Leitfähigkeitssensor.
+// Enthält die Kalibrierung eine Referenzlösung (reference_conductivity) und den
+// darin gemessenen Wert (measured_conductivity), wird die Zellkonstante neu berechnet.
+func (s *ConductivitySensor) SetCalibration(calibration map[string]interface{}) error {
+	// Überprüfen, ob erforderliche Kalibrierungsparameter vorhanden sind
+	if _, ok := calibration[CalibrationOffset]; !ok {
+		calibration[CalibrationOffset] = 0.0
+	}
+
+	if _, ok := calibration[CalibrationScale]; !ok {
+		calibration[CalibrationScale] = 1.0
+	}
+
+	if _, ok := calibration[CalibrationCellConstant]; !ok {
+		// Bisherige Zellkonstante übernehmen, damit eine Nachkalibrierung darauf aufbaut
+		current, _ := getFloatFromMap(s.GetCalibration(), CalibrationCellConstant, 1.0)
+		calibration[CalibrationCellConstant] = current
+	}
+
+	if compensation, ok := calibration[CalibrationCompensation]; ok {
+		switch compensation {
+		case CompensationNone, CompensationLinear, CompensationNonLinear:
+		default:
+			return fmt.Errorf("unbekannte Temperaturkompensation: %v", compensation)
+		}
+	}
+
+	// Zellkonstanten-Kalibrierung mit Referenzlösung
+	reference, hasReference := getFloatFromMap(calibration, CalibrationReferenceSolution, 0)
+	measured, hasMeasured := getFloatFromMap(calibration, CalibrationMeasuredSolution, 0)
+	if hasReference || hasMeasured {
+		if !hasReference || !hasMeasured {
+			return fmt.Errorf("für die Zellkonstanten-Kalibrierung werden %s und %s benötigt",
+				CalibrationReferenceSolution, CalibrationMeasuredSolution)
+		}
+		if reference <= 0 || measured <= 0 {
+			return fmt.Errorf("ungültige Werte für die Zellkonstanten-Kalibrierung: referenz=%.3f, gemessen=%.3f", reference, measured)
+		}
+
+		cellConstant, _ := getFloatFromMap(calibration, CalibrationCellConstant, 1.0)
+		calibration[CalibrationCellConstant] = cellConstant * reference / measured
+
+		// Die Kalibrierpunkte nur einmalig anwenden
+		delete(calibration, CalibrationReferenceSolution)
+		delete(calibration, CalibrationMeasuredSolution)
+	}
+
+	if cellConstant, _ := getFloatFromMap(calibration, CalibrationCellConstant, 1.0); cellConstant <= 0 {
+		return fmt.Errorf("ungültige Zellkonstante: %.4f", cellConstant)
+	}
+
+	// Kalibrierung auf den BaseSensor anwenden
+	return s.BaseSensor.SetCalibration(calibration)
+}
+
+// compensationCoefficients liefert die Temperaturkoeffizienten (in 1/°C bzw. 1/°C²)
+// für die gewählte Kompensationsart
+func compensationCoefficients(calibration map[string]interface{}, compensation string) (float64, float64) {
+	switch compensation {
+	case CompensationNonLinear:
+		alpha, _ := getFloatFromMap(calibration, CalibrationTempCoefficient, DefaultNonLinearCoefficient)
+		beta, _ := getFloatFromMap(calibration, CalibrationTempCoefficient2, DefaultNonLinearQuadratic)
+		return alpha / 100, beta / 100
+	case CompensationLinear:
+		alpha, _ := getFloatFromMap(calibration, CalibrationTempCoefficient, DefaultLinearCoefficient)
+		return alpha / 100, 0
+	default:
+		return 0, 0
+	}
+}
+
+// compensateConductivity rechnet eine Leitfähigkeit von der Messtemperatur auf die
+// Referenztemperatur um: EC_ref = EC_T / (1 + α(T-T_ref) + β(T-T_ref)²)
+func compensateConductivity(ec, temperature, refTemp float64, compensation string, alpha, beta float64) (float64, error) {
+	if compensation == CompensationNone {
+		return ec, nil
+	}
+
+	delta := temperature - refTemp
+	factor := 1 + alpha*delta + beta*delta*delta
+	if factor <= 0 {
+		return 0, fmt.Errorf("temperaturkompensation bei %.1f °C nicht möglich (Faktor %.4f)", temperature, factor)
+	}
+
+	return ec / factor, nil
+}
+
+// calculateTDS berechnet die gelösten Feststoffe (mg/L) aus der Leitfähigkeit (µS/cm)
+func calculateTDS(ec25, factor float64) float64 {
+	return ec25 * factor
+}
+
+// calculateSalinity berechnet die Salinität (PSU). Ist ein Salinitätsfaktor konfiguriert,
+// wird linear gerechnet (PSU je mS/cm), sonst nach PSS-78 für 25 °C.
+func calculateSalinity(ec25, factor float64) float64 {
+	if factor > 0 {
+		return ec25 / 1000 * factor
+	}
+
+	rt := ec25 / seawaterConductivity25
+	if rt <= 0 {
+		return 0
+	}
+
+	// Polynome in √Rt; der Temperaturterm ΔS wird für t = 25 °C ausgewertet
+	t := DefaultReferenceTemperature
+	sqrtRt := math.Sqrt(rt)
+	salinity, deltaS := 0.0, 0.0
+	for i := range pss78A {
+		term := math.Pow(sqrtRt, float64(i))
+		salinity += pss78A[i] * term
+		deltaS += pss78B[i] * term
+	}
+	salinity += (t - 15) / (1 + pss78K*(t-15)) * deltaS
+
+	if salinity < 0 {
+		return 0
+	}
+	return salinity
+}
+
+// Hilfsfunktion zum Abrufen eines Float-Werts aus einer Map
+func getFloatFromMap(m map[string]interface{}, key string, defaultValue float64) (float64, bool) {
+	if val, ok := m[key]; ok {
+		switch v := val.(type) {
+		case float64:
+			return v, true
+		case float32:
+			return float64(v), true
+		case int:
+			return float64(v), true
+		}
+	}
+	return defaultValue, false
+}
+
+// convertRawToFloat konvertiert Rohdaten in einen Float-Wert
+func convertRawToFloat(rawData []byte, dataType, byteOrder string) (float64, error) {
+	if len(rawData) == 0 {
+		return 0, fmt.Errorf("keine Daten zum Konvertieren")
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if byteOrder == "big_endian" {
+		order = binary.BigEndian
+	}
+
+	switch dataType {
+	case "float32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für float32: %d Bytes", len(rawData))
+		}
+		return float64(math.Float32frombits(order.Uint32(rawData))), nil
+
+	case "int16":
+		if len(rawData) < 2 {
+			return 0, fmt.Errorf("nicht genügend Daten für int16: %d Bytes", len(rawData))
+		}
+		return float64(int16(order.Uint16(rawData))), nil
+
+	case "uint16":
+		if len(rawData) < 2 {
+			return 0, fmt.Errorf("nicht genügend Daten für uint16: %d Bytes", len(rawData))
+		}
+		return float64(order.Uint16(rawData)), nil
+
+	case "int32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für int32: %d Bytes", len(rawData))
+		}
+		return float64(int32(order.Uint32(rawData))), nil
+
+	case "uint32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für uint32: %d Bytes", len(rawData))
+		}
+		return float64(order.Uint32(rawData)), nil
+
+	default:
+		return 0, fmt.Errorf("unbekannter Datentyp: %s", dataType)
+	}
+}
@@ -0,0 +1,307 @@
+package conductivity
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"owipex_reader/internal/types"
+)
+
+// fakeProtocol liefert feste Registerinhalte je Adresse
+type fakeProtocol struct {
+	registers map[uint16][]byte
+	configs   map[string]types.RegisterConfig
+}
+
+// ReadRegister gibt den Inhalt eines Registers zurück oder einen Fehler, wenn es fehlt
+func (p *fakeProtocol) ReadRegister(ctx context.Context, address uint16, length uint16) ([]byte, error) {
+	data, ok := p.registers[address]
+	if !ok {
+		return nil, errors.New("zeitüberschreitung")
+	}
+	return data, nil
+}
+
+// WriteRegister wird nicht benötigt
+func (p *fakeProtocol) WriteRegister(ctx context.Context, address uint16, data []byte) error {
+	return errors.New("nicht unterstützt")
+}
+
+// GetRegisterConfig gibt die konfigurierte Registerzuordnung zurück
+func (p *fakeProtocol) GetRegisterConfig(name string) types.RegisterConfig {
+	return p.configs[name]
+}
+
+// Close wird ignoriert
+func (p *fakeProtocol) Close() error { return nil }
+
+// float32Bytes kodiert einen Wert als float32 in der angegebenen Byte-Reihenfolge
+func float32Bytes(value float32, order binary.ByteOrder) []byte {
+	data := make([]byte, 4)
+	order.PutUint32(data, math.Float32bits(value))
+	return data
+}
+
+// approx vergleicht Gleitkommazahlen mit Rundungstoleranz
+func approx(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+// TestConvertRawToFloat prüft die Dekodierung der Registerinhalte
+func TestConvertRawToFloat(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		dataType  string
+		byteOrder string
+		want      float64
+		wantErr   bool
+	}{
+		{"float32 big endian", float32Bytes(1413.5, binary.BigEndian), "float32", "big_endian", 1413.5, false},
+		{"float32 little endian", float32Bytes(1413.5, binary.LittleEndian), "float32", "little_endian", 1413.5, false},
+		{"float32 ohne Byte-Reihenfolge ist little endian", float32Bytes(25.25, binary.LittleEndian), "float32", "", 25.25, false},
+		{"int16 negativ", []byte{0xFF, 0x38}, "int16", "big_endian", -200, false},
+		{"uint16", []byte{0xFF, 0x38}, "uint16", "big_endian", 65336, false},
+		{"int32 negativ", []byte{0xFF, 0xFF, 0xFF, 0x9C}, "int32", "big_endian", -100, false},
+		{"uint32", []byte{0x00, 0x01, 0x00, 0x00}, "uint32", "big_endian", 65536, false},
+		{"keine Daten", nil, "float32", "big_endian", 0, true},
+		{"zu kurz für float32", []byte{0x01, 0x02}, "float32", "big_endian", 0, true},
+		{"zu kurz für int16", []byte{0x01}, "int16", "big_endian", 0, true},
+		{"zu kurz für uint32", []byte{0x01, 0x02, 0x03}, "uint32", "big_endian", 0, true},
+		{"unbekannter Datentyp", []byte{0x01, 0x02}, "bcd", "big_endian", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertRawToFloat(tt.data, tt.dataType, tt.byteOrder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertRawToFloat = %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !approx(got, tt.want, 1e-9) {
+				t.Errorf("convertRawToFloat = %v, erwartet %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompensateConductivity prüft die Umrechnung auf die Referenztemperatur
+func TestCompensateConductivity(t *testing.T) {
+	tests := []struct {
+		name         string
+		ec           float64
+		temperature  float64
+		compensation string
+		calibration  map[string]interface{}
+		want         float64
+		wantErr      bool
+	}{
+		{"linear wärmer", 1200, 35, CompensationLinear, nil, 1000, false},
+		{"linear kälter", 800, 15, CompensationLinear, nil, 1000, false},
+		{"linear bei Referenztemperatur", 1000, 25, CompensationLinear, nil, 1000, false},
+		{"linear mit Koeffizient", 1150, 35, CompensationLinear, map[string]interface{}{CalibrationTempCoefficient: 1.5}, 1000, false},
+		{"nichtlinear", 1199.5, 35, CompensationNonLinear, nil, 1000, false},
+		{"nichtlinear kälter", 817.5, 15, CompensationNonLinear, nil, 1000, false},
+		{"ohne Kompensation", 1200, 35, CompensationNone, nil, 1200, false},
+		{"Faktor nicht positiv", 1000, -30, CompensationLinear, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alpha, beta := compensationCoefficients(tt.calibration, tt.compensation)
+			got, err := compensateConductivity(tt.ec, tt.temperature, DefaultReferenceTemperature, tt.compensation, alpha, beta)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compensateConductivity = %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !approx(got, tt.want, 1e-6) {
+				t.Errorf("compensateConductivity = %v, erwartet %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCalculateSalinity prüft die Salinität nach PSS-78 und mit linearem Faktor
+func TestCalculateSalinity(t *testing.T) {
+	tests := []struct {
+		name   string
+		ec25   float64
+		factor float64
+		want   float64
+	}{
+		{"Standard-Meerwasser", seawaterConductivity25, 0, 35},
+		{"Süßwasser", 0, 0, 0},
+		{"linearer Faktor", 10000, 0.7, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateSalinity(tt.ec25, tt.factor); !approx(got, tt.want, 0.01) {
+				t.Errorf("calculateSalinity = %v, erwartet %v", got, tt.want)
+			}
+		})
+	}
+
+	// Die Salinität steigt mit der Leitfähigkeit
+	if low, high := calculateSalinity(1000, 0), calculateSalinity(5000, 0); low <= 0 || high <= low {
+		t.Errorf("Salinität bei 1000/5000 µS/cm = %v/%v", low, high)
+	}
+}
+
+// TestRead prüft Messwert, Qualität und Metadaten eines vollständigen Lesevorgangs
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name            string
+		temperature     *float32
+		calibration     map[string]interface{}
+		configs         map[string]types.RegisterConfig
+		order           binary.ByteOrder
+		want            float64
+		wantTemperature float64
+		wantQuality     types.ReadingQuality
+	}{
+		{
+			name:            "mit Temperatur",
+			temperature:     float32Ptr(35),
+			want:            1000,
+			wantTemperature: 35,
+			wantQuality:     types.QualityGood,
+		},
+		{
+			name:            "Temperatur-Offset",
+			temperature:     float32Ptr(36),
+			calibration:     map[string]interface{}{CalibrationTemperatureOffset: -1.0},
+			want:            1000,
+			wantTemperature: 35,
+			wantQuality:     types.QualityGood,
+		},
+		{
+			name:            "ohne Temperatur",
+			want:            1200,
+			wantTemperature: DefaultReferenceTemperature,
+			wantQuality:     types.QualityUncertain,
+		},
+		{
+			name:            "manuelle Temperatur",
+			calibration:     map[string]interface{}{CalibrationManualTemperature: 35.0},
+			want:            1000,
+			wantTemperature: 35,
+			wantQuality:     types.QualityGood,
+		},
+		{
+			name:            "ohne Temperatur und ohne Kompensation",
+			calibration:     map[string]interface{}{CalibrationCompensation: CompensationNone},
+			want:            1200,
+			wantTemperature: DefaultReferenceTemperature,
+			wantQuality:     types.QualityGood,
+		},
+		{
+			name:        "konfigurierte Register in little endian",
+			temperature: float32Ptr(35),
+			configs: map[string]types.RegisterConfig{
+				RegisterConductivity: {Name: RegisterConductivity, Address: DefaultRegisterConductivity, Length: 2, DataType: "float32", ByteOrder: "little_endian"},
+				RegisterTemperature:  {Name: RegisterTemperature, Address: DefaultRegisterTemperature, Length: 2, DataType: "float32", ByteOrder: "little_endian"},
+			},
+			order:           binary.LittleEndian,
+			want:            1000,
+			wantTemperature: 35,
+			wantQuality:     types.QualityGood,
+		},
+		{
+			name:            "Skalierung und Zellkonstante",
+			temperature:     float32Ptr(25),
+			calibration:     map[string]interface{}{CalibrationScale: 0.5, CalibrationCellConstant: 2.0, CalibrationOffset: -200.0},
+			want:            1000,
+			wantTemperature: 25,
+			wantQuality:     types.QualityGood,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			if order == nil {
+				order = binary.BigEndian
+			}
+			protocol := &fakeProtocol{
+				registers: map[uint16][]byte{DefaultRegisterConductivity: float32Bytes(1200, order)},
+				configs:   tt.configs,
+			}
+			if tt.temperature != nil {
+				protocol.registers[DefaultRegisterTemperature] = float32Bytes(*tt.temperature, order)
+			}
+
+			s := NewConductivitySensor("ec", "Leitfähigkeit")
+			s.SetProtocol(protocol)
+			calibration := tt.calibration
+			if calibration == nil {
+				calibration = map[string]interface{}{}
+			}
+			if err := s.SetCalibration(calibration); err != nil {
+				t.Fatalf("SetCalibration: %v", err)
+			}
+
+			reading, err := s.Read(context.Background())
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if value, _ := reading.Value.(float64); !approx(value, tt.want, 1e-3) {
+				t.Errorf("Leitfähigkeit %v, erwartet %v", reading.Value, tt.want)
+			}
+			if reading.Quality != tt.wantQuality {
+				t.Errorf("Qualität %v, erwartet %v", reading.Quality, tt.wantQuality)
+			}
+			if temperature, _ := reading.Metadata["temperature"].(float64); !approx(temperature, tt.wantTemperature, 1e-3) {
+				t.Errorf("Temperatur %v, erwartet %v", temperature, tt.wantTemperature)
+			}
+			if tds, _ := reading.Metadata["tds"].(float64); !approx(tds, tt.want*DefaultTDSFactor, 1e-3) {
+				t.Errorf("TDS %v, erwartet %v", tds, tt.want*DefaultTDSFactor)
+			}
+		})
+	}
+}
+
+// TestSetCalibrationCellConstant prüft die Zellkonstante aus einer Referenzlösung und die
+// Ablehnung ungültiger Kalibrierungen
+func TestSetCalibrationCellConstant(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration map[string]interface{}
+		want        float64
+		wantErr     bool
+	}{
+		{"Referenzlösung", map[string]interface{}{CalibrationReferenceSolution: 1413.0, CalibrationMeasuredSolution: 1285.0}, 1413.0 / 1285.0, false},
+		{"Nachkalibrierung auf Zellkonstante", map[string]interface{}{CalibrationCellConstant: 2.0, CalibrationReferenceSolution: 1000.0, CalibrationMeasuredSolution: 500.0}, 4, false},
+		{"nur Referenzwert", map[string]interface{}{CalibrationReferenceSolution: 1413.0}, 0, true},
+		{"Messwert null", map[string]interface{}{CalibrationReferenceSolution: 1413.0, CalibrationMeasuredSolution: 0.0}, 0, true},
+		{"negative Zellkonstante", map[string]interface{}{CalibrationCellConstant: -1.0}, 0, true},
+		{"unbekannte Kompensation", map[string]interface{}{CalibrationCompensation: "quadratic"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewConductivitySensor("ec", "Leitfähigkeit")
+			err := s.SetCalibration(tt.calibration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetCalibration = %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			calibration := s.GetCalibration()
+			if cellConstant, _ := getFloatFromMap(calibration, CalibrationCellConstant, 0); !approx(cellConstant, tt.want, 1e-9) {
+				t.Errorf("Zellkonstante %v, erwartet %v", cellConstant, tt.want)
+			}
+			if _, ok := calibration[CalibrationReferenceSolution]; ok {
+				t.Errorf("Kalibrierpunkt gespeichert")
+			}
+		})
+	}
+}
+
+// float32Ptr gibt einen Zeiger auf eine Temperatur zurück
+func float32Ptr(v float32) *float32 {
+	return &v
+}
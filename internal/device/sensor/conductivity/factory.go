@@ -0,0 +1,78 @@
+package conductivity
+
+import (
+	"fmt"
+
+	"owipex_reader/internal/protocol/factory"
+	"owipex_reader/internal/types"
+)
+
+// Descriptor beschreibt den Gerätetyp "conductivity_sensor" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	return types.TypeDescriptor{
+		Type:        "conductivity_sensor",
+		Name:        "Leitfähigkeitssensor",
+		Description: "Leitfähigkeitssonde mit Temperaturkompensation, TDS und Salinität über Modbus RTU",
+		Category:    types.TypeSensor,
+		Readings:    []types.ReadingType{types.ReadingTypeConductivity, types.ReadingTypeCustom},
+		Commands:    []types.CommandType{types.CommandTypeCalibrate},
+		Protocol:    "modbus",
+		ConfigDir:   "sensors/conductivity",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus": factory.ModbusSchema(),
+				"calibration": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						CalibrationOffset:               map[string]interface{}{"type": "number", "default": 0.0},
+						CalibrationScale:                map[string]interface{}{"type": "number", "default": 1.0},
+						CalibrationCellConstant:         map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": 1.0},
+						CalibrationReferenceSolution:    map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "description": "Leitfähigkeit der Referenzlösung (einmalige Zellkonstanten-Kalibrierung)"},
+						CalibrationMeasuredSolution:     map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "description": "Gemessene Leitfähigkeit in der Referenzlösung"},
+						CalibrationTemperatureOffset:    map[string]interface{}{"type": "number", "default": 0.0},
+						CalibrationManualTemperature:    map[string]interface{}{"type": "number", "description": "Feste Temperatur, falls kein Temperaturregister vorhanden ist"},
+						CalibrationCompensation:         map[string]interface{}{"type": "string", "enum": []interface{}{CompensationNone, CompensationLinear, CompensationNonLinear}, "default": CompensationLinear},
+						CalibrationTempCoefficient:      map[string]interface{}{"type": "number", "description": "Temperaturkoeffizient in %/°C (Standard je nach Kompensationsart)"},
+						CalibrationReferenceTemperature: map[string]interface{}{"type": "number", "default": DefaultReferenceTemperature},
+						CalibrationTDSFactor:            map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": DefaultTDSFactor},
+						CalibrationSalinityFactor:       map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "description": "Fester Umrechnungsfaktor, ohne Angabe wird die Salinität nach PSS-78 berechnet"},
+					},
+				},
+			},
+			"required": []interface{}{"modbus"},
+		},
+		Defaults: map[string]interface{}{
+			"calibration": map[string]interface{}{
+				CalibrationOffset: 0.0,
+				CalibrationScale:  1.0,
+			},
+		},
+	}
+}
+
+// CreateConductivitySensor erstellt einen Leitfähigkeitssensor aus einer Konfiguration
+func CreateConductivitySensor(config types.DeviceConfig) (types.Sensor, error) {
+	// Neuen Leitfähigkeitssensor erstellen
+	sensor := NewConductivitySensor(config.ID, config.Name)
+
+	// Protokoll-Handler konfigurieren
+	if config.Protocol == "modbus" {
+		if modbusConfig, ok := config.Metadata["modbus"].(map[string]interface{}); ok {
+			protocol, err := factory.CreateProtocolHandler("modbus", modbusConfig)
+			if err != nil {
+				return nil, fmt.Errorf("fehler beim Erstellen des Protokoll-Handlers: %w", err)
+			}
+			sensor.BaseSensor.SetProtocol(protocol)
+		}
+	}
+
+	// Kalibrierung setzen, falls vorhanden
+	if calibration, ok := config.Metadata["calibration"].(map[string]interface{}); ok {
+		if err := sensor.SetCalibration(calibration); err != nil {
+			return nil, fmt.Errorf("fehler beim Setzen der Kalibrierung: %w", err)
+		}
+	}
+
+	return sensor, nil
+}
@@ -3,10 +3,36 @@ package flow
 import (
 	"fmt"
 
+	"owipex_reader/internal/device/sensor"
 	"owipex_reader/internal/protocol/factory"
 	"owipex_reader/internal/types"
 )
 
+// Descriptor beschreibt den Gerätetyp "flow_sensor" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	return types.TypeDescriptor{
+		Type:        "flow_sensor",
+		Name:        "Durchflusssensor",
+		Description: "Durchflussmesser mit Momentanwert und Summenzähler über Modbus RTU",
+		Category:    types.TypeSensor,
+		Readings:    []types.ReadingType{types.ReadingTypeFlow, types.ReadingTypeCustom},
+		Commands:    []types.CommandType{types.CommandTypeCalibrate},
+		Protocol:    "modbus",
+		ConfigDir:   "sensors/flow",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus":      factory.ModbusSchema(),
+				"calibration": sensor.OffsetScaleCalibrationSchema(),
+			},
+			"required": []interface{}{"modbus"},
+		},
+		Defaults: map[string]interface{}{
+			"calibration": map[string]interface{}{"offset": 0.0, "scale": 1.0},
+		},
+	}
+}
+
 // CreateFlowSensor erstellt einen Durchflusssensor aus einer Konfiguration
 func CreateFlowSensor(config types.DeviceConfig) (types.Sensor, error) {
 	// Neuen Durchflusssensor erstellen
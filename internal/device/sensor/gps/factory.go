@@ -0,0 +1,103 @@
+package gps
+
+import (
+	"fmt"
+	"time"
+
+	"owipex_reader/internal/types"
+)
+
+// Descriptor beschreibt den Gerätetyp "gps_receiver" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	return types.TypeDescriptor{
+		Type:        "gps_receiver",
+		Name:        "GPS-Empfänger",
+		Description: "NMEA-GPS-Empfänger an serieller Schnittstelle, TCP oder gpsd",
+		Category:    types.TypeSensor,
+		Readings:    []types.ReadingType{types.ReadingTypePosition},
+		ConfigDir:   "sensors/gps",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"gps": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"source":                  map[string]interface{}{"type": "string", "enum": []interface{}{SourceSerial, SourceTCP, SourceGpsd}, "default": SourceSerial},
+						"port":                    map[string]interface{}{"type": "string", "description": "Serielle Schnittstelle (source=serial)"},
+						"baud_rate":               map[string]interface{}{"type": "integer", "default": DefaultBaudRate},
+						"address":                 map[string]interface{}{"type": "string", "description": "host:port (source=tcp oder gpsd)"},
+						"update_interval_seconds": map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": DefaultUpdateInterval.Seconds()},
+						"max_fix_age_seconds":     map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": DefaultMaxFixAge.Seconds()},
+						"reconnect_delay_seconds": map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "default": DefaultReconnectDelay.Seconds()},
+						"enable_attribute":        map[string]interface{}{"type": "string", "default": DefaultEnableAttribute},
+					},
+				},
+			},
+			"required": []interface{}{"gps"},
+		},
+		Defaults: map[string]interface{}{
+			"gps": map[string]interface{}{
+				"source": SourceSerial,
+			},
+		},
+	}
+}
+
+// CreateGPSReceiver erstellt einen GPS-Empfänger aus einer Konfiguration
+func CreateGPSReceiver(config types.DeviceConfig) (types.Sensor, error) {
+	receiverConfig := ReceiverConfig{
+		Source: SourceSerial,
+	}
+
+	// Verbindungseinstellungen aus den Metadaten lesen
+	if gpsConfig, ok := config.Metadata["gps"].(map[string]interface{}); ok {
+		if source, ok := gpsConfig["source"].(string); ok {
+			receiverConfig.Source = source
+		}
+		if port, ok := gpsConfig["port"].(string); ok {
+			receiverConfig.Port = port
+		}
+		if baudRate, ok := gpsConfig["baud_rate"].(float64); ok {
+			receiverConfig.BaudRate = int(baudRate)
+		}
+		if address, ok := gpsConfig["address"].(string); ok {
+			receiverConfig.Address = address
+		}
+		if interval, ok := gpsConfig["update_interval_seconds"].(float64); ok {
+			receiverConfig.UpdateInterval = time.Duration(interval * float64(time.Second))
+		}
+		if maxAge, ok := gpsConfig["max_fix_age_seconds"].(float64); ok {
+			receiverConfig.MaxFixAge = time.Duration(maxAge * float64(time.Second))
+		}
+		if delay, ok := gpsConfig["reconnect_delay_seconds"].(float64); ok {
+			receiverConfig.ReconnectDelay = time.Duration(delay * float64(time.Second))
+		}
+		if attribute, ok := gpsConfig["enable_attribute"].(string); ok {
+			receiverConfig.EnableAttribute = attribute
+		}
+	}
+
+	switch receiverConfig.Source {
+	case SourceSerial:
+		if receiverConfig.Port == "" {
+			return nil, fmt.Errorf("keine serielle Schnittstelle für GPS-Empfänger %s konfiguriert", config.ID)
+		}
+	case SourceTCP, SourceGpsd:
+		if receiverConfig.Address == "" {
+			if receiverConfig.Source != SourceGpsd {
+				return nil, fmt.Errorf("keine Adresse für GPS-Empfänger %s konfiguriert", config.ID)
+			}
+			// Standard-Port von gpsd
+			receiverConfig.Address = "localhost:2947"
+		}
+	default:
+		return nil, fmt.Errorf("unbekannte GPS-Quelle: %s", receiverConfig.Source)
+	}
+
+	receiver := NewGPSReceiver(config.ID, config.Name, receiverConfig)
+
+	// Nur den Zustand übernehmen; das Einlesen startet der Adapter mit Start
+	receiver.BaseSensor.Enable(config.Enabled)
+
+	return receiver, nil
+}
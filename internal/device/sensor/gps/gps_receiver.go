@@ -0,0 +1,398 @@
+// Package gps implementiert einen GPS-Empfänger, der NMEA-0183-Sätze von einer
+// seriellen Schnittstelle oder einem TCP-Stream (z.B. gpsd) liest und Positionsmesswerte liefert.
+package gps
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/device/sensor"
+	"owipex_reader/internal/types"
+
+	"github.com/goburrow/serial"
+)
+
+// Verbindungsarten des Empfängers
+const (
+	SourceSerial = "serial"
+	SourceTCP    = "tcp"
+	SourceGpsd   = "gpsd"
+
+	// DefaultEnableAttribute ist das Shared Attribute, mit dem GPS ein- und ausgeschaltet wird
+	DefaultEnableAttribute = "gpsEnabled"
+
+	// Standardwerte
+	DefaultUpdateInterval = 60 * time.Second
+	DefaultMaxFixAge      = 10 * time.Second
+	DefaultReconnectDelay = 5 * time.Second
+	DefaultBaudRate       = 9600
+
+	// gpsdWatchCommand aktiviert bei gpsd die Ausgabe der rohen NMEA-Sätze
+	gpsdWatchCommand = "?WATCH={\"enable\":true,\"nmea\":true};\n"
+)
+
+// ReceiverConfig enthält die Verbindungs- und Zeitparameter des Empfängers
+type ReceiverConfig struct {
+	Source          string
+	Port            string
+	BaudRate        int
+	Address         string
+	UpdateInterval  time.Duration
+	MaxFixAge       time.Duration
+	ReconnectDelay  time.Duration
+	EnableAttribute string
+}
+
+// GPSReceiver implementiert einen NMEA-GPS-Empfänger als Sensor
+type GPSReceiver struct {
+	*sensor.BaseSensor
+	config ReceiverConfig
+	logger *log.Logger
+
+	fix       Fix
+	lastError error
+	fixMutex  sync.RWMutex
+
+	conn     io.ReadCloser
+	running  bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	runMutex sync.Mutex
+
+	// started ist gesetzt, sobald der Besitzer das Einlesen freigegeben hat (Start); erst dann
+	// startet Enable das Einlesen. Nach Close bleibt der Empfänger gestoppt.
+	started bool
+	closed  bool
+}
+
+// NewGPSReceiver erstellt einen neuen GPS-Empfänger
+func NewGPSReceiver(id, name string, config ReceiverConfig) *GPSReceiver {
+	if config.Source == "" {
+		config.Source = SourceSerial
+	}
+	if config.BaudRate <= 0 {
+		config.BaudRate = DefaultBaudRate
+	}
+	if config.UpdateInterval <= 0 {
+		config.UpdateInterval = DefaultUpdateInterval
+	}
+	if config.MaxFixAge <= 0 {
+		config.MaxFixAge = DefaultMaxFixAge
+	}
+	if config.ReconnectDelay <= 0 {
+		config.ReconnectDelay = DefaultReconnectDelay
+	}
+	if config.EnableAttribute == "" {
+		config.EnableAttribute = DefaultEnableAttribute
+	}
+
+	return &GPSReceiver{
+		BaseSensor: sensor.NewBaseSensor(id, name, types.ReadingTypePosition),
+		config:     config,
+		logger:     log.New(os.Stdout, fmt.Sprintf("[GPS %s] ", id), log.LstdFlags),
+	}
+}
+
+// Start gibt das Einlesen der NMEA-Sätze frei und startet es im Hintergrund, sofern der
+// Empfänger aktiviert ist. Die Factory startet keine I/O, das übernimmt der Besitzer des
+// Geräts nach dem Erstellen.
+func (r *GPSReceiver) Start() {
+	r.runMutex.Lock()
+	defer r.runMutex.Unlock()
+
+	if r.closed {
+		return
+	}
+	r.started = true
+	if r.IsEnabled() {
+		r.startLocked()
+	}
+}
+
+// Stop beendet das Einlesen und schließt die Verbindung zum Empfänger. Danach startet erst ein
+// erneutes Start das Einlesen wieder.
+func (r *GPSReceiver) Stop() {
+	r.runMutex.Lock()
+	r.started = false
+	r.runMutex.Unlock()
+
+	r.stopReading()
+}
+
+// Enable aktiviert oder deaktiviert den Empfänger. Nach Start wird das Einlesen damit
+// gestartet bzw. gestoppt.
+func (r *GPSReceiver) Enable(enabled bool) {
+	r.BaseSensor.Enable(enabled)
+
+	if !enabled {
+		r.stopReading()
+		return
+	}
+
+	r.runMutex.Lock()
+	defer r.runMutex.Unlock()
+	if r.started && !r.closed {
+		r.startLocked()
+	}
+}
+
+// startLocked startet das Einlesen, der Aufrufer hält runMutex
+func (r *GPSReceiver) startLocked() {
+	if r.running {
+		return
+	}
+
+	r.running = true
+	r.stopChan = make(chan struct{})
+	r.wg.Add(1)
+	go r.run(r.stopChan)
+}
+
+// stopReading beendet das Einlesen und wartet auf dessen Ende
+func (r *GPSReceiver) stopReading() {
+	r.runMutex.Lock()
+	if !r.running {
+		r.runMutex.Unlock()
+		return
+	}
+	r.running = false
+	close(r.stopChan)
+	if r.conn != nil {
+		// Blockierendes Lesen abbrechen
+		r.conn.Close()
+	}
+	r.runMutex.Unlock()
+
+	r.wg.Wait()
+}
+
+// EnableAttribute gibt das Shared Attribute zurück, über das der Empfänger geschaltet wird
+func (r *GPSReceiver) EnableAttribute() string {
+	return r.config.EnableAttribute
+}
+
+// ReadInterval gibt das Intervall zurück, in dem Positionsmesswerte erzeugt werden sollen
+func (r *GPSReceiver) ReadInterval() time.Duration {
+	return r.config.UpdateInterval
+}
+
+// CurrentFix gibt den zuletzt empfangenen Positionsstand zurück
+func (r *GPSReceiver) CurrentFix() Fix {
+	r.fixMutex.RLock()
+	defer r.fixMutex.RUnlock()
+	return r.fix
+}
+
+// Read liefert die aktuelle Position als Messwert
+func (r *GPSReceiver) Read(ctx context.Context) (types.Reading, error) {
+	if !r.IsEnabled() {
+		return types.Reading{}, fmt.Errorf("GPS-Empfänger %s ist deaktiviert", r.ID())
+	}
+
+	r.fixMutex.RLock()
+	fix := r.fix
+	lastError := r.lastError
+	r.fixMutex.RUnlock()
+
+	if !fix.HasFix() {
+		if lastError != nil {
+			return types.Reading{}, fmt.Errorf("kein GPS-Fix: %w", lastError)
+		}
+		return types.Reading{}, fmt.Errorf("kein GPS-Fix")
+	}
+
+	age := time.Since(fix.UpdatedAt)
+	if age > r.config.MaxFixAge {
+		return types.Reading{}, fmt.Errorf("GPS-Position veraltet (%s alt)", age.Round(time.Second))
+	}
+
+	position := map[string]interface{}{
+		"latitude":  fix.Latitude,
+		"longitude": fix.Longitude,
+		"altitude":  fix.Altitude,
+	}
+
+	reading := types.NewReading(types.ReadingTypePosition, position, "deg", nil)
+	if fix.FixType == 2 || fix.HDOP > 5 {
+		// 2D-Fix oder schlechte Satellitengeometrie
+		reading.Quality = types.QualityUncertain
+	}
+
+	// Zusätzliche Metadaten hinzufügen
+	reading.Metadata["latitude"] = fix.Latitude
+	reading.Metadata["longitude"] = fix.Longitude
+	reading.Metadata["altitude"] = fix.Altitude
+	reading.Metadata["fix_quality"] = fix.Quality
+	reading.Metadata["fix_type"] = fix.FixType
+	reading.Metadata["satellites"] = fix.Satellites
+	reading.Metadata["hdop"] = fix.HDOP
+	reading.Metadata["pdop"] = fix.PDOP
+	reading.Metadata["vdop"] = fix.VDOP
+	reading.Metadata["speed_knots"] = fix.SpeedKnots
+	reading.Metadata["course"] = fix.Course
+	if !fix.Time.IsZero() {
+		reading.Metadata["gps_time"] = fix.Time.UnixNano() / int64(time.Millisecond)
+	}
+
+	return reading, nil
+}
+
+// ReadRaw liest den nächsten NMEA-Satz direkt vom Empfänger
+func (r *GPSReceiver) ReadRaw(ctx context.Context) ([]byte, error) {
+	return nil, fmt.Errorf("ReadRaw wird vom GPS-Empfänger nicht unterstützt, die Sätze werden kontinuierlich gelesen")
+}
+
+// Close beendet das Einlesen und gibt Ressourcen frei. Ein späteres Start bleibt wirkungslos.
+func (r *GPSReceiver) Close() error {
+	r.runMutex.Lock()
+	r.closed = true
+	r.started = false
+	r.runMutex.Unlock()
+
+	r.stopReading()
+	return nil
+}
+
+// run verbindet sich mit dem Empfänger und liest Sätze, bis Stop aufgerufen wird.
+// Verbindungsfehler führen nach ReconnectDelay zu einem neuen Verbindungsversuch.
+func (r *GPSReceiver) run(stopChan chan struct{}) {
+	defer r.wg.Done()
+
+	for {
+		err := r.readStream(stopChan)
+
+		select {
+		case <-stopChan:
+			return
+		default:
+		}
+
+		if err != nil {
+			r.setError(err)
+			r.logger.Printf("Fehler beim Lesen vom GPS-Empfänger: %v, neuer Versuch in %s", err, r.config.ReconnectDelay)
+		}
+
+		select {
+		case <-stopChan:
+			return
+		case <-time.After(r.config.ReconnectDelay):
+		}
+	}
+}
+
+// readStream öffnet die Verbindung und verarbeitet Zeilen bis zu einem Fehler
+func (r *GPSReceiver) readStream(stopChan chan struct{}) error {
+	conn, err := r.open()
+	if err != nil {
+		return err
+	}
+
+	r.runMutex.Lock()
+	select {
+	case <-stopChan:
+		r.runMutex.Unlock()
+		conn.Close()
+		return nil
+	default:
+	}
+	r.conn = conn
+	r.runMutex.Unlock()
+
+	defer func() {
+		r.runMutex.Lock()
+		r.conn = nil
+		r.runMutex.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	var partial strings.Builder
+	for {
+		chunk, err := reader.ReadString('\n')
+		partial.WriteString(chunk)
+
+		if err != nil {
+			// Timeouts der seriellen Schnittstelle sind kein Fehler, die Zeile wird fortgesetzt
+			if errors.Is(err, serial.ErrTimeout) {
+				continue
+			}
+			return fmt.Errorf("verbindung zum GPS-Empfänger unterbrochen: %w", err)
+		}
+
+		line := partial.String()
+		partial.Reset()
+		r.processLine(line)
+	}
+}
+
+// processLine wendet einen NMEA-Satz auf den aktuellen Fix an
+func (r *GPSReceiver) processLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] != '$' {
+		// gpsd-JSON-Statusmeldungen und Leerzeilen ignorieren
+		return
+	}
+
+	r.fixMutex.Lock()
+	defer r.fixMutex.Unlock()
+
+	if err := applySentence(&r.fix, line, time.Now()); err != nil {
+		r.lastError = err
+		return
+	}
+	r.lastError = nil
+}
+
+// setError merkt sich den letzten Verbindungsfehler und verwirft den Fix
+func (r *GPSReceiver) setError(err error) {
+	r.fixMutex.Lock()
+	defer r.fixMutex.Unlock()
+
+	r.lastError = err
+	r.fix.Valid = false
+}
+
+// open öffnet die konfigurierte Verbindung zum Empfänger
+func (r *GPSReceiver) open() (io.ReadCloser, error) {
+	switch r.config.Source {
+	case SourceSerial:
+		port, err := serial.Open(&serial.Config{
+			Address:  r.config.Port,
+			BaudRate: r.config.BaudRate,
+			DataBits: 8,
+			StopBits: 1,
+			Parity:   "N",
+			Timeout:  r.config.MaxFixAge,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Öffnen der seriellen Schnittstelle %s: %w", r.config.Port, err)
+		}
+		return port, nil
+
+	case SourceTCP, SourceGpsd:
+		conn, err := net.DialTimeout("tcp", r.config.Address, r.config.ReconnectDelay)
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Verbinden mit %s: %w", r.config.Address, err)
+		}
+
+		if r.config.Source == SourceGpsd {
+			if _, err := conn.Write([]byte(gpsdWatchCommand)); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("fehler beim Aktivieren des gpsd-NMEA-Streams: %w", err)
+			}
+		}
+		return conn, nil
+
+	default:
+		return nil, fmt.Errorf("unbekannte GPS-Quelle: %s", r.config.Source)
+	}
+}
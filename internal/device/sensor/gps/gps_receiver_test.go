@@ -0,0 +1,84 @@
+package gps
+
+import (
+	"testing"
+	"time"
+
+	"owipex_reader/internal/types"
+)
+
+// isRunning meldet, ob der Empfänger gerade einliest
+func isRunning(r *GPSReceiver) bool {
+	r.runMutex.Lock()
+	defer r.runMutex.Unlock()
+	return r.running
+}
+
+// TestReceiverLifecycle prüft, dass die Factory keine I/O startet und das Einlesen erst nach
+// Start dem Aktivierungszustand folgt
+func TestReceiverLifecycle(t *testing.T) {
+	sensor, err := CreateGPSReceiver(types.DeviceConfig{
+		ID:      "gps",
+		Name:    "GPS",
+		Enabled: true,
+		Metadata: map[string]interface{}{
+			"gps": map[string]interface{}{
+				// Nicht erreichbare Adresse: das Einlesen versucht es nur erneut
+				"source":                  SourceTCP,
+				"address":                 "127.0.0.1:1",
+				"reconnect_delay_seconds": 3600.0,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateGPSReceiver: %v", err)
+	}
+	receiver := sensor.(*GPSReceiver)
+	defer receiver.Close()
+
+	steps := []struct {
+		name        string
+		action      func()
+		wantRunning bool
+	}{
+		{"nach dem Erstellen", func() {}, false},
+		{"Aktivieren vor Start", func() { receiver.Enable(true) }, false},
+		{"Start", receiver.Start, true},
+		{"Deaktivieren", func() { receiver.Enable(false) }, false},
+		{"Start im deaktivierten Zustand", receiver.Start, false},
+		{"Aktivieren", func() { receiver.Enable(true) }, true},
+		{"Stop", receiver.Stop, false},
+		{"Aktivieren nach Stop", func() { receiver.Enable(true) }, false},
+		{"Start", receiver.Start, true},
+		{"Close", func() { receiver.Close() }, false},
+		{"Start nach Close", receiver.Start, false},
+		{"Aktivieren nach Close", func() { receiver.Enable(true) }, false},
+	}
+
+	for _, step := range steps {
+		step.action()
+		if running := isRunning(receiver); running != step.wantRunning {
+			t.Fatalf("%s: Einlesen aktiv = %v, erwartet %v", step.name, running, step.wantRunning)
+		}
+	}
+}
+
+// TestCreateGPSReceiverDisabled prüft, dass ein deaktivierter Empfänger auch nach Start nicht
+// einliest
+func TestCreateGPSReceiverDisabled(t *testing.T) {
+	sensor, err := CreateGPSReceiver(types.DeviceConfig{
+		ID:       "gps",
+		Metadata: map[string]interface{}{"gps": map[string]interface{}{"source": SourceGpsd, "reconnect_delay_seconds": 3600.0}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGPSReceiver: %v", err)
+	}
+	receiver := sensor.(*GPSReceiver)
+	defer receiver.Close()
+
+	receiver.Start()
+	time.Sleep(10 * time.Millisecond)
+	if receiver.IsEnabled() || isRunning(receiver) {
+		t.Errorf("deaktivierter Empfänger liest ein")
+	}
+}
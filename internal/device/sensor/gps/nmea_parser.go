@@ -0,0 +1,241 @@
+package gps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fix enthält den zuletzt aus den NMEA-Sätzen zusammengesetzten Positionsstand
+type Fix struct {
+	// Zeitpunkt der Position laut Empfänger (UTC)
+	Time time.Time
+
+	// Position in Dezimalgrad bzw. Höhe über NN in Metern
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+
+	// Fix-Qualität aus GGA (0 = kein Fix, 1 = GPS, 2 = DGPS, 4/5 = RTK, ...)
+	Quality int
+
+	// Fix-Typ aus GSA (1 = kein Fix, 2 = 2D, 3 = 3D)
+	FixType int
+
+	// Anzahl der verwendeten Satelliten
+	Satellites int
+
+	// Genauigkeitsmaße (Dilution of Precision)
+	HDOP float64
+	PDOP float64
+	VDOP float64
+
+	// Geschwindigkeit über Grund (Knoten) und Kurs (Grad) aus RMC
+	SpeedKnots float64
+	Course     float64
+
+	// Valid gibt an, ob der Empfänger die Position als gültig meldet
+	Valid bool
+
+	// UpdatedAt ist der lokale Zeitpunkt der letzten gültigen Positionsaktualisierung
+	UpdatedAt time.Time
+}
+
+// HasFix prüft, ob eine verwertbare Position vorliegt
+func (f Fix) HasFix() bool {
+	return f.Valid && f.Quality > 0
+}
+
+// parseSentence prüft einen NMEA-0183-Satz und gibt Satztyp (z.B. "GGA") und Felder zurück
+func parseSentence(line string) (string, []string, error) {
+	line = strings.TrimSpace(line)
+	if len(line) < 7 || (line[0] != '$' && line[0] != '!') {
+		return "", nil, fmt.Errorf("kein NMEA-Satz: %q", line)
+	}
+
+	body := line[1:]
+	if idx := strings.LastIndex(body, "*"); idx >= 0 {
+		checksum := body[idx+1:]
+		body = body[:idx]
+
+		expected, err := strconv.ParseUint(checksum, 16, 8)
+		if err != nil {
+			return "", nil, fmt.Errorf("ungültige Prüfsumme %q", checksum)
+		}
+
+		var sum byte
+		for i := 0; i < len(body); i++ {
+			sum ^= body[i]
+		}
+		if sum != byte(expected) {
+			return "", nil, fmt.Errorf("prüfsummenfehler: erwartet %02X, berechnet %02X", expected, sum)
+		}
+	}
+
+	fields := strings.Split(body, ",")
+	address := fields[0]
+	if len(address) < 5 {
+		return "", nil, fmt.Errorf("ungültige Satzadresse %q", address)
+	}
+
+	// Talker-ID (GP, GN, GL, GA, BD, ...) ignorieren
+	return address[len(address)-3:], fields[1:], nil
+}
+
+// applySentence aktualisiert den Fix anhand eines NMEA-Satzes.
+// Unbekannte Satztypen werden ignoriert.
+func applySentence(fix *Fix, line string, now time.Time) error {
+	sentenceType, fields, err := parseSentence(line)
+	if err != nil {
+		return err
+	}
+
+	switch sentenceType {
+	case "GGA":
+		return applyGGA(fix, fields, now)
+	case "RMC":
+		return applyRMC(fix, fields, now)
+	case "GSA":
+		return applyGSA(fix, fields)
+	}
+
+	return nil
+}
+
+// applyGGA verarbeitet einen GGA-Satz (Position, Fix-Qualität, Satelliten, HDOP, Höhe)
+func applyGGA(fix *Fix, fields []string, now time.Time) error {
+	if len(fields) < 9 {
+		return fmt.Errorf("GGA-Satz unvollständig: %d Felder", len(fields))
+	}
+
+	quality, _ := strconv.Atoi(fields[5])
+	fix.Quality = quality
+	fix.Satellites, _ = strconv.Atoi(fields[6])
+	if hdop, err := strconv.ParseFloat(fields[7], 64); err == nil {
+		fix.HDOP = hdop
+	}
+
+	if quality == 0 {
+		fix.Valid = false
+		return nil
+	}
+
+	lat, err := parseCoordinate(fields[1], fields[2])
+	if err != nil {
+		return fmt.Errorf("GGA-Breitengrad: %w", err)
+	}
+	lon, err := parseCoordinate(fields[3], fields[4])
+	if err != nil {
+		return fmt.Errorf("GGA-Längengrad: %w", err)
+	}
+
+	fix.Latitude = lat
+	fix.Longitude = lon
+	if alt, err := strconv.ParseFloat(fields[8], 64); err == nil {
+		fix.Altitude = alt
+	}
+	if t, err := parseTimeOfDay(fields[0], fix.Time, now); err == nil {
+		fix.Time = t
+	}
+	fix.Valid = true
+	fix.UpdatedAt = now
+
+	return nil
+}
+
+// applyRMC verarbeitet einen RMC-Satz (Gültigkeit, Position, Datum, Geschwindigkeit, Kurs)
+func applyRMC(fix *Fix, fields []string, now time.Time) error {
+	if len(fields) < 9 {
+		return fmt.Errorf("RMC-Satz unvollständig: %d Felder", len(fields))
+	}
+
+	if fields[1] != "A" {
+		fix.Valid = false
+		return nil
+	}
+
+	lat, err := parseCoordinate(fields[2], fields[3])
+	if err != nil {
+		return fmt.Errorf("RMC-Breitengrad: %w", err)
+	}
+	lon, err := parseCoordinate(fields[4], fields[5])
+	if err != nil {
+		return fmt.Errorf("RMC-Längengrad: %w", err)
+	}
+
+	fix.Latitude = lat
+	fix.Longitude = lon
+	fix.SpeedKnots, _ = strconv.ParseFloat(fields[6], 64)
+	fix.Course, _ = strconv.ParseFloat(fields[7], 64)
+	if t, err := time.Parse("020106 150405", fields[8]+" "+fields[0]); err == nil {
+		fix.Time = t
+	}
+	fix.Valid = true
+	fix.UpdatedAt = now
+
+	return nil
+}
+
+// applyGSA verarbeitet einen GSA-Satz (Fix-Typ und DOP-Werte)
+func applyGSA(fix *Fix, fields []string) error {
+	if len(fields) < 17 {
+		return fmt.Errorf("GSA-Satz unvollständig: %d Felder", len(fields))
+	}
+
+	fix.FixType, _ = strconv.Atoi(fields[1])
+	if pdop, err := strconv.ParseFloat(fields[14], 64); err == nil {
+		fix.PDOP = pdop
+	}
+	if hdop, err := strconv.ParseFloat(fields[15], 64); err == nil {
+		fix.HDOP = hdop
+	}
+	if vdop, err := strconv.ParseFloat(fields[16], 64); err == nil {
+		fix.VDOP = vdop
+	}
+
+	return nil
+}
+
+// parseCoordinate wandelt eine NMEA-Koordinate (d)ddmm.mmmm mit Himmelsrichtung in Dezimalgrad um
+func parseCoordinate(value, hemisphere string) (float64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("leere Koordinate")
+	}
+
+	raw, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ungültige Koordinate %q", value)
+	}
+
+	degrees := float64(int(raw / 100))
+	minutes := raw - degrees*100
+	coordinate := degrees + minutes/60
+
+	switch hemisphere {
+	case "N", "E":
+	case "S", "W":
+		coordinate = -coordinate
+	default:
+		return 0, fmt.Errorf("ungültige Himmelsrichtung %q", hemisphere)
+	}
+
+	return coordinate, nil
+}
+
+// parseTimeOfDay kombiniert eine NMEA-Uhrzeit hhmmss.ss mit dem zuletzt bekannten
+// Datum (aus RMC) bzw. dem aktuellen Datum
+func parseTimeOfDay(value string, last time.Time, now time.Time) (time.Time, error) {
+	tod, err := time.Parse("150405", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date := last
+	if date.IsZero() {
+		date = now.UTC()
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(),
+		tod.Hour(), tod.Minute(), tod.Second(), tod.Nanosecond(), time.UTC), nil
+}
@@ -0,0 +1,253 @@
+package gps
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestParseSentence prüft Prüfsumme, Satzadresse und Felder
+func TestParseSentence(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantType   string
+		wantFields int
+		wantErr    bool
+	}{
+		{"GGA mit Prüfsumme", "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", "GGA", 14, false},
+		{"Prüfsumme in Kleinbuchstaben", "$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6a", "RMC", 11, false},
+		{"Zeilenende und Leerzeichen", "  $GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39\r\n", "GSA", 17, false},
+		{"ohne Prüfsumme", "$GNRMC,123519,V,,,,,,,230394,,", "RMC", 11, false},
+		{"AIS-Startzeichen", "!AIVDM,1,1,,A,13aEOK?P00PD2wVMdLDRhgvL289?,0*26", "VDM", 6, false},
+		{"falsche Prüfsumme", "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48", "", 0, true},
+		{"verfälschtes Feld", "$GPGGA,123519,4807.039,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", "", 0, true},
+		{"ungültige Prüfsumme", "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*XY", "", 0, true},
+		{"kein Startzeichen", "GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", "", 0, true},
+		{"zu kurz", "$GPGG", "", 0, true},
+		{"leer", "", "", 0, true},
+		{"zu kurze Satzadresse", "$GGA,1,2,3", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentenceType, fields, err := parseSentence(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSentence(%q) erwartet Fehler, erhalten %q", tt.line, sentenceType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSentence(%q): %v", tt.line, err)
+			}
+			if sentenceType != tt.wantType {
+				t.Errorf("Satztyp = %q, erwartet %q", sentenceType, tt.wantType)
+			}
+			if len(fields) != tt.wantFields {
+				t.Errorf("%d Felder, erwartet %d: %q", len(fields), tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+// TestParseCoordinate prüft die Umrechnung von (d)ddmm.mmmm in Dezimalgrad
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		hemisphere string
+		want       float64
+		wantErr    bool
+	}{
+		{"Nord", "4807.038", "N", 48.1173, false},
+		{"Ost mit führender Null", "01131.000", "E", 11 + 31.0/60, false},
+		{"Süd", "3355.2018", "S", -(33 + 55.2018/60), false},
+		{"West dreistellig", "15110.5765", "W", -(151 + 10.5765/60), false},
+		{"Äquator", "0000.000", "N", 0, false},
+		{"weniger als ein Grad", "0030.000", "W", -0.5, false},
+		{"leer", "", "N", 0, true},
+		{"keine Zahl", "48o7.038", "N", 0, true},
+		{"ohne Himmelsrichtung", "4807.038", "", 0, true},
+		{"ungültige Himmelsrichtung", "4807.038", "X", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCoordinate(tt.value, tt.hemisphere)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCoordinate(%q, %q) erwartet Fehler, erhalten %v", tt.value, tt.hemisphere, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCoordinate(%q, %q): %v", tt.value, tt.hemisphere, err)
+			}
+			if !approx(got, tt.want) {
+				t.Errorf("parseCoordinate(%q, %q) = %v, erwartet %v", tt.value, tt.hemisphere, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApplySentence prüft den aus aufeinanderfolgenden Sätzen zusammengesetzten Fix
+func TestApplySentence(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		lines   []string
+		want    Fix
+		wantFix bool
+		wantErr bool
+	}{
+		{
+			name:  "GGA",
+			lines: []string{"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47"},
+			want: Fix{
+				Time:     time.Date(2026, 10, 18, 12, 35, 19, 0, time.UTC),
+				Latitude: 48.1173, Longitude: 11 + 31.0/60, Altitude: 545.4,
+				Quality: 1, Satellites: 8, HDOP: 0.9, Valid: true, UpdatedAt: now,
+			},
+			wantFix: true,
+		},
+		{
+			// Das Datum aus RMC gilt für die Uhrzeit des folgenden GGA-Satzes
+			name: "RMC, GGA und GSA",
+			lines: []string{
+				"$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A",
+				"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+				"$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39",
+			},
+			want: Fix{
+				Time:     time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC),
+				Latitude: 48.1173, Longitude: 11 + 31.0/60, Altitude: 545.4,
+				Quality: 1, FixType: 3, Satellites: 8,
+				HDOP: 1.3, PDOP: 2.5, VDOP: 2.1,
+				SpeedKnots: 22.4, Course: 84.4, Valid: true, UpdatedAt: now,
+			},
+			wantFix: true,
+		},
+		{
+			name:  "Süd/West mit Nachkommasekunden",
+			lines: []string{"$GNGGA,001043.00,3355.2018,S,15110.5765,W,2,12,0.8,12.3,M,,M,,*53"},
+			want: Fix{
+				Time:     time.Date(2026, 10, 18, 0, 10, 43, 0, time.UTC),
+				Latitude: -(33 + 55.2018/60), Longitude: -(151 + 10.5765/60), Altitude: 12.3,
+				Quality: 2, Satellites: 12, HDOP: 0.8, Valid: true, UpdatedAt: now,
+			},
+			wantFix: true,
+		},
+		{
+			// Position und Zeitpunkt des letzten gültigen Fixes bleiben erhalten
+			name: "GGA ohne Fix nach gültigem Fix",
+			lines: []string{
+				"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+				"$GPGGA,123519,,,,,0,00,99.9,,M,,M,,*7C",
+			},
+			want: Fix{
+				Time:     time.Date(2026, 10, 18, 12, 35, 19, 0, time.UTC),
+				Latitude: 48.1173, Longitude: 11 + 31.0/60, Altitude: 545.4,
+				Quality: 0, Satellites: 0, HDOP: 99.9, Valid: false, UpdatedAt: now,
+			},
+		},
+		{
+			name: "RMC ungültig nach gültigem GGA",
+			lines: []string{
+				"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+				"$GNRMC,123519,V,,,,,,,230394,,*2D",
+			},
+			want: Fix{
+				Time:     time.Date(2026, 10, 18, 12, 35, 19, 0, time.UTC),
+				Latitude: 48.1173, Longitude: 11 + 31.0/60, Altitude: 545.4,
+				Quality: 1, Satellites: 8, HDOP: 0.9, Valid: false, UpdatedAt: now,
+			},
+		},
+		{
+			name:  "unbekannter Satztyp wird ignoriert",
+			lines: []string{"$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48"},
+			want:  Fix{},
+		},
+		{
+			name:    "Prüfsummenfehler",
+			lines:   []string{"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*00"},
+			want:    Fix{},
+			wantErr: true,
+		},
+		{
+			name:    "GGA unvollständig",
+			lines:   []string{"$GPGGA,123519,4807.038,N"},
+			want:    Fix{},
+			wantErr: true,
+		},
+		{
+			name:    "GGA mit ungültiger Koordinate",
+			lines:   []string{"$GPGGA,123519,4807.038,X,01131.000,E,1,08,0.9,545.4,M,46.9,M,,"},
+			want:    Fix{Quality: 1, Satellites: 8, HDOP: 0.9},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fix Fix
+			var err error
+			for _, line := range tt.lines {
+				if err = applySentence(&fix, line, now); err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Fehler = %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+			assertFix(t, fix, tt.want)
+			if fix.HasFix() != tt.wantFix {
+				t.Errorf("HasFix() = %v, erwartet %v", fix.HasFix(), tt.wantFix)
+			}
+		})
+	}
+}
+
+// assertFix vergleicht zwei Fixes mit Rundungstoleranz für Gleitkommawerte
+func assertFix(t *testing.T, got, want Fix) {
+	t.Helper()
+
+	if !got.Time.Equal(want.Time) {
+		t.Errorf("Time = %v, erwartet %v", got.Time, want.Time)
+	}
+	if !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, erwartet %v", got.UpdatedAt, want.UpdatedAt)
+	}
+
+	floats := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Latitude", got.Latitude, want.Latitude},
+		{"Longitude", got.Longitude, want.Longitude},
+		{"Altitude", got.Altitude, want.Altitude},
+		{"HDOP", got.HDOP, want.HDOP},
+		{"PDOP", got.PDOP, want.PDOP},
+		{"VDOP", got.VDOP, want.VDOP},
+		{"SpeedKnots", got.SpeedKnots, want.SpeedKnots},
+		{"Course", got.Course, want.Course},
+	}
+	for _, f := range floats {
+		if !approx(f.got, f.want) {
+			t.Errorf("%s = %v, erwartet %v", f.name, f.got, f.want)
+		}
+	}
+
+	if got.Quality != want.Quality || got.FixType != want.FixType || got.Satellites != want.Satellites || got.Valid != want.Valid {
+		t.Errorf("Quality/FixType/Satellites/Valid = %d/%d/%d/%v, erwartet %d/%d/%d/%v",
+			got.Quality, got.FixType, got.Satellites, got.Valid,
+			want.Quality, want.FixType, want.Satellites, want.Valid)
+	}
+}
+
+// approx vergleicht Gleitkommazahlen mit Rundungstoleranz
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,391 @@
+// Package oxygen implementiert einen optischen Sensor für gelösten Sauerstoff (DO)
+// mit Salinitäts- und Luftdruckkompensation.
+package oxygen
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/device/sensor"
+	"owipex_reader/internal/types"
+)
+
+// Konstanten für Sauerstoffsensoren
+const (
+	// Standard-Register-Namen
+	RegisterConcentration = "do_concentration"
+	RegisterSaturation    = "do_saturation"
+	RegisterTemperature   = "temperature"
+
+	// Kalibrierungsparameter (Nullpunkt und Steilheit auf der Sättigungsskala in %)
+	CalibrationZero = "zero"
+	CalibrationSpan = "span"
+
+	// Kompensationsparameter im Metadaten-Block "compensation"
+	CompensationSalinity       = "salinity"
+	CompensationPressure       = "pressure_hpa"
+	CompensationSalinitySource = "salinity_source"
+	CompensationPressureSource = "pressure_source"
+	CompensationMaxSourceAge   = "max_source_age_seconds"
+
+	// Default Register-Adressen
+	DefaultRegisterConcentration = uint16(0x0001)
+	DefaultRegisterTemperature   = uint16(0x0003)
+
+	// Standardwerte für die Kompensation
+	DefaultSalinity      = 0.0     // PSU
+	DefaultPressure      = 1013.25 // hPa
+	DefaultMaxSourceAge  = 10 * time.Minute
+	standardAtmosphere   = 1013.25 // hPa
+	kelvinOffset         = 273.15
+	metadataCompensation = "compensation"
+)
+
+// compensationValue ist ein von einem anderen Sensor übernommener Kompensationswert
+type compensationValue struct {
+	value     float64
+	updatedAt time.Time
+}
+
+// DissolvedOxygenSensor implementiert einen Sensor für gelösten Sauerstoff
+type DissolvedOxygenSensor struct {
+	*sensor.BaseSensor
+	liveValues map[string]compensationValue
+	liveMutex  sync.RWMutex
+}
+
+// NewDissolvedOxygenSensor erstellt einen neuen Sauerstoffsensor
+func NewDissolvedOxygenSensor(id, name string) *DissolvedOxygenSensor {
+	base := sensor.NewBaseSensor(id, name, types.ReadingTypeDissolvedOxygen, types.ReadingTypeCustom)
+
+	return &DissolvedOxygenSensor{
+		BaseSensor: base,
+		liveValues: make(map[string]compensationValue),
+	}
+}
+
+// Read liest Sauerstoff und Temperatur vom Sensor und liefert die auf Salinität und
+// Luftdruck kompensierte Sauerstoffkonzentration in mg/L. Sind Register für Sättigung und
+// Konzentration konfiguriert, werden beide gelesen und veröffentlicht; die Sättigung ist dann
+// die Grundlage der kompensierten Konzentration.
+func (s *DissolvedOxygenSensor) Read(ctx context.Context) (types.Reading, error) {
+	protocol := s.GetProtocol()
+	if protocol == nil {
+		return types.Reading{}, fmt.Errorf("kein Protokoll-Handler konfiguriert")
+	}
+
+	// Temperatur wird für jede Umrechnung benötigt
+	temperature, err := s.readFloatRegister(ctx, protocol, RegisterTemperature, DefaultRegisterTemperature)
+	if err != nil {
+		return types.Reading{}, fmt.Errorf("fehler beim Lesen der Temperatur: %w", err)
+	}
+
+	salinity, pressure := s.compensationParameters()
+	factor, err := compensationFactor(temperature, salinity, pressure)
+	if err != nil {
+		return types.Reading{}, err
+	}
+	quality := types.QualityGood
+
+	satConfig := protocol.GetRegisterConfig(RegisterSaturation)
+	concConfig := protocol.GetRegisterConfig(RegisterConcentration)
+	if satConfig.Address == 0 && concConfig.Address == 0 {
+		// Fallback auf Standard-Adresse
+		concConfig.Address = DefaultRegisterConcentration
+		concConfig.Length = 2
+	}
+
+	// Die Konzentration des Sensors gilt für Süßwasser bei Normaldruck
+	var rawData []byte
+	var saturationRaw, concentrationRaw float64
+	if concConfig.Address != 0 {
+		rawData, concentrationRaw, err = s.readRegister(ctx, protocol, concConfig)
+		if err != nil {
+			return types.Reading{}, fmt.Errorf("fehler beim Lesen der Sauerstoffkonzentration: %w", err)
+		}
+		saturationRaw = concentrationRaw / oxygenSolubility(temperature, 0, standardAtmosphere) * 100
+	}
+
+	// Die Sättigung hängt nicht von Salinität und Luftdruck ab und hat daher Vorrang
+	if satConfig.Address != 0 {
+		rawData, saturationRaw, err = s.readRegister(ctx, protocol, satConfig)
+		if err != nil {
+			return types.Reading{}, fmt.Errorf("fehler beim Lesen der Sauerstoffsättigung: %w", err)
+		}
+	}
+
+	// Nullpunkt- und Steilheitskalibrierung anwenden
+	calibration := s.GetCalibration()
+	zero, _ := getFloatFromMap(calibration, CalibrationZero, 0.0)
+	span, _ := getFloatFromMap(calibration, CalibrationSpan, 100.0)
+	saturation := (saturationRaw - zero) / (span - zero) * 100
+	if saturation < 0 {
+		saturation = 0
+		quality = types.QualityUncertain
+	}
+
+	// Konzentration bei aktueller Salinität und aktuellem Luftdruck
+	solubility := oxygenSolubility(temperature, salinity, pressure)
+	concentration := saturation / 100 * solubility
+
+	// Reading-Objekt erstellen mit Konzentration als Hauptwert
+	reading := types.NewReading(types.ReadingTypeDissolvedOxygen, concentration, "mg/L", rawData)
+	reading.Quality = quality
+
+	// Zusätzliche Metadaten hinzufügen
+	reading.Metadata["saturation_percent"] = saturation
+	reading.Metadata["temperature"] = temperature
+	reading.Metadata["salinity"] = salinity
+	reading.Metadata["pressure_hpa"] = pressure
+	reading.Metadata["solubility"] = solubility
+	reading.Metadata["compensation_factor"] = factor
+	if satConfig.Address != 0 {
+		reading.Metadata["saturation_raw"] = saturationRaw
+	}
+	if concConfig.Address != 0 {
+		reading.Metadata["concentration_raw"] = concentrationRaw
+		reading.Metadata["concentration_sensor_compensated"] = compensateConcentration(concentrationRaw, factor)
+	}
+
+	return reading, nil
+}
+
+// ReadRaw liest die Rohdaten vom Sauerstoffsensor
+func (s *DissolvedOxygenSensor) ReadRaw(ctx context.Context) ([]byte, error) {
+	protocol := s.GetProtocol()
+	if protocol == nil {
+		return nil, fmt.Errorf("kein Protokoll-Handler konfiguriert")
+	}
+
+	registerConfig := protocol.GetRegisterConfig(RegisterSaturation)
+	if registerConfig.Address == 0 {
+		registerConfig = protocol.GetRegisterConfig(RegisterConcentration)
+	}
+	if registerConfig.Address == 0 {
+		registerConfig.Address = DefaultRegisterConcentration
+		registerConfig.Length = 2
+	}
+
+	// Rohdaten vom Register lesen
+	return protocol.ReadRegister(ctx, registerConfig.Address, registerConfig.Length)
+}
+
+// SetCalibration setzt neue Kalibrierungsparameter für den Sauerstoffsensor
+func (s *DissolvedOxygenSensor) SetCalibration(calibration map[string]interface{}) error {
+	// Überprüfen, ob erforderliche Kalibrierungsparameter vorhanden sind
+	if _, ok := calibration[CalibrationZero]; !ok {
+		calibration[CalibrationZero] = 0.0
+	}
+
+	if _, ok := calibration[CalibrationSpan]; !ok {
+		calibration[CalibrationSpan] = 100.0
+	}
+
+	zero, _ := getFloatFromMap(calibration, CalibrationZero, 0.0)
+	span, _ := getFloatFromMap(calibration, CalibrationSpan, 100.0)
+	if span <= zero {
+		return fmt.Errorf("ungültige Kalibrierung: span (%.2f) muss größer als zero (%.2f) sein", span, zero)
+	}
+
+	// Kalibrierung auf den BaseSensor anwenden
+	return s.BaseSensor.SetCalibration(calibration)
+}
+
+// CompensationSources gibt die Sensoren zurück, deren Messwerte für die Kompensation
+// verwendet werden. Der Wert hat die Form "<sensor_id>" (Hauptwert) oder
+// "<sensor_id>.<metadaten_schlüssel>".
+func (s *DissolvedOxygenSensor) CompensationSources() map[string]string {
+	sources := make(map[string]string)
+
+	compensation, _ := s.Metadata()[metadataCompensation].(map[string]interface{})
+	if source, ok := compensation[CompensationSalinitySource].(string); ok && source != "" {
+		sources[CompensationSalinity] = source
+	}
+	if source, ok := compensation[CompensationPressureSource].(string); ok && source != "" {
+		sources[CompensationPressure] = source
+	}
+
+	return sources
+}
+
+// UpdateCompensation übernimmt einen Kompensationswert eines anderen Sensors
+func (s *DissolvedOxygenSensor) UpdateCompensation(parameter string, value float64) {
+	s.liveMutex.Lock()
+	defer s.liveMutex.Unlock()
+
+	s.liveValues[parameter] = compensationValue{value: value, updatedAt: time.Now()}
+}
+
+// compensationParameters ermittelt Salinität (PSU) und Luftdruck (hPa). Aktuelle Werte
+// anderer Sensoren haben Vorrang vor den konfigurierten Festwerten.
+func (s *DissolvedOxygenSensor) compensationParameters() (float64, float64) {
+	compensation, _ := s.Metadata()[metadataCompensation].(map[string]interface{})
+
+	salinity, _ := getFloatFromMap(compensation, CompensationSalinity, DefaultSalinity)
+	pressure, _ := getFloatFromMap(compensation, CompensationPressure, DefaultPressure)
+
+	maxAge := DefaultMaxSourceAge
+	if seconds, ok := getFloatFromMap(compensation, CompensationMaxSourceAge, 0); ok && seconds > 0 {
+		maxAge = time.Duration(seconds) * time.Second
+	}
+
+	s.liveMutex.RLock()
+	defer s.liveMutex.RUnlock()
+
+	if live, ok := s.liveValues[CompensationSalinity]; ok && time.Since(live.updatedAt) <= maxAge {
+		salinity = live.value
+	}
+	if live, ok := s.liveValues[CompensationPressure]; ok && time.Since(live.updatedAt) <= maxAge && live.value > 0 {
+		pressure = live.value
+	}
+
+	return salinity, pressure
+}
+
+// readFloatRegister liest ein benanntes Register als Float-Wert
+func (s *DissolvedOxygenSensor) readFloatRegister(ctx context.Context, protocol types.ProtocolHandler, name string, defaultAddress uint16) (float64, error) {
+	registerConfig := protocol.GetRegisterConfig(name)
+	if registerConfig.Address == 0 {
+		// Fallback auf Standard-Adresse
+		registerConfig.Address = defaultAddress
+		registerConfig.Length = 2
+	}
+
+	_, value, err := s.readRegister(ctx, protocol, registerConfig)
+	return value, err
+}
+
+// readRegister liest ein Register und konvertiert es anhand seiner Konfiguration
+func (s *DissolvedOxygenSensor) readRegister(ctx context.Context, protocol types.ProtocolHandler, registerConfig types.RegisterConfig) ([]byte, float64, error) {
+	if registerConfig.DataType == "" {
+		registerConfig.DataType = "float32"
+		registerConfig.ByteOrder = "big_endian"
+	}
+
+	rawData, err := protocol.ReadRegister(ctx, registerConfig.Address, registerConfig.Length)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, err := convertRawToFloat(rawData, registerConfig.DataType, registerConfig.ByteOrder)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return rawData, value, nil
+}
+
+// compensationFactor gibt das Verhältnis der Sauerstofflöslichkeit bei Salinität (PSU) und
+// Luftdruck (hPa) zur Löslichkeit in Süßwasser bei Normaldruck zurück. Damit wird eine für
+// Süßwasser und Normaldruck gemessene Konzentration kompensiert.
+func compensationFactor(temperature, salinity, pressureHPa float64) (float64, error) {
+	reference := oxygenSolubility(temperature, 0, standardAtmosphere)
+	if reference <= 0 {
+		return 0, fmt.Errorf("sauerstofflöslichkeit bei %.1f °C nicht bestimmbar", temperature)
+	}
+	return oxygenSolubility(temperature, salinity, pressureHPa) / reference, nil
+}
+
+// compensateConcentration rechnet eine für Süßwasser bei Normaldruck gemessene Konzentration
+// (mg/L) mit dem Faktor aus compensationFactor auf die aktuelle Salinität und den aktuellen
+// Luftdruck um
+func compensateConcentration(concentration, factor float64) float64 {
+	return concentration * factor
+}
+
+// oxygenSolubility berechnet die Sauerstofflöslichkeit in mg/L bei Temperatur (°C),
+// Salinität (PSU) und Luftdruck (hPa) nach Benson & Krause (APHA 4500-O)
+func oxygenSolubility(temperature, salinity, pressureHPa float64) float64 {
+	t := temperature + kelvinOffset
+	if t <= 0 {
+		return 0
+	}
+
+	// Löslichkeit in Süßwasser bei 1 atm
+	lnC := -139.34411 +
+		1.575701e5/t -
+		6.642308e7/(t*t) +
+		1.243800e10/(t*t*t) -
+		8.621949e11/(t*t*t*t)
+
+	// Salinitätskorrektur
+	lnC -= salinity * (1.7674e-2 - 10.754/t + 2140.7/(t*t))
+
+	c := math.Exp(lnC)
+
+	// Druckkorrektur mit Wasserdampfpartialdruck
+	p := pressureHPa / standardAtmosphere
+	pwv := math.Exp(11.8571 - 3840.70/t - 216961/(t*t))
+	theta := 0.000975 - 1.426e-5*temperature + 6.436e-8*temperature*temperature
+	if p <= pwv {
+		return 0
+	}
+
+	return c * p * ((1 - pwv/p) * (1 - theta*p)) / ((1 - pwv) * (1 - theta))
+}
+
+// Hilfsfunktion zum Abrufen eines Float-Werts aus einer Map
+func getFloatFromMap(m map[string]interface{}, key string, defaultValue float64) (float64, bool) {
+	if val, ok := m[key]; ok {
+		switch v := val.(type) {
+		case float64:
+			return v, true
+		case float32:
+			return float64(v), true
+		case int:
+			return float64(v), true
+		}
+	}
+	return defaultValue, false
+}
+
+// convertRawToFloat konvertiert Rohdaten in einen Float-Wert
+func convertRawToFloat(rawData []byte, dataType, byteOrder string) (float64, error) {
+	if len(rawData) == 0 {
+		return 0, fmt.Errorf("keine Daten zum Konvertieren")
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if byteOrder == "big_endian" {
+		order = binary.BigEndian
+	}
+
+	switch dataType {
+	case "float32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für float32: %d Bytes", len(rawData))
+		}
+		return float64(math.Float32frombits(order.Uint32(rawData))), nil
+
+	case "int16":
+		if len(rawData) < 2 {
+			return 0, fmt.Errorf("nicht genügend Daten für int16: %d Bytes", len(rawData))
+		}
+		return float64(int16(order.Uint16(rawData))), nil
+
+	case "uint16":
+		if len(rawData) < 2 {
+			return 0, fmt.Errorf("nicht genügend Daten für uint16: %d Bytes", len(rawData))
+		}
+		return float64(order.Uint16(rawData)), nil
+
+	case "int32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für int32: %d Bytes", len(rawData))
+		}
+		return float64(int32(order.Uint32(rawData))), nil
+
+	case "uint32":
+		if len(rawData) < 4 {
+			return 0, fmt.Errorf("nicht genügend Daten für uint32: %d Bytes", len(rawData))
+		}
+		return float64(order.Uint32(rawData)), nil
+
+	default:
+		return 0, fmt.Errorf("unbekannter Datentyp: %s", dataType)
+	}
+}
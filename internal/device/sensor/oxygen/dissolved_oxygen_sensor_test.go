@@ -0,0 +1,317 @@
+package oxygen
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/types"
+)
+
+// fakeProtocol liefert feste Registerinhalte je Adresse
+type fakeProtocol struct {
+	registers map[uint16][]byte
+	configs   map[string]types.RegisterConfig
+}
+
+// ReadRegister gibt den Inhalt eines Registers zurück oder einen Fehler, wenn es fehlt
+func (p *fakeProtocol) ReadRegister(ctx context.Context, address uint16, length uint16) ([]byte, error) {
+	data, ok := p.registers[address]
+	if !ok {
+		return nil, errors.New("zeitüberschreitung")
+	}
+	return data, nil
+}
+
+// WriteRegister wird nicht benötigt
+func (p *fakeProtocol) WriteRegister(ctx context.Context, address uint16, data []byte) error {
+	return errors.New("nicht unterstützt")
+}
+
+// GetRegisterConfig gibt die konfigurierte Registerzuordnung zurück
+func (p *fakeProtocol) GetRegisterConfig(name string) types.RegisterConfig {
+	return p.configs[name]
+}
+
+// Close wird ignoriert
+func (p *fakeProtocol) Close() error { return nil }
+
+// float32Bytes kodiert einen Wert als float32 in Big-Endian
+func float32Bytes(value float64) []byte {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, math.Float32bits(float32(value)))
+	return data
+}
+
+// approx vergleicht Gleitkommazahlen mit Toleranz
+func approx(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+// Register der Tests (Sättigung an einer eigenen Adresse)
+const testRegisterSaturation = uint16(0x0005)
+
+// TestOxygenSolubility prüft die Löslichkeit gegen Tabellenwerte (USGS DOTABLES, APHA 4500-O)
+func TestOxygenSolubility(t *testing.T) {
+	tests := []struct {
+		name        string
+		temperature float64
+		salinity    float64
+		pressure    float64
+		want        float64
+	}{
+		{"Süßwasser 0 °C", 0, 0, standardAtmosphere, 14.62},
+		{"Süßwasser 20 °C", 20, 0, standardAtmosphere, 9.09},
+		{"Süßwasser 25 °C", 25, 0, standardAtmosphere, 8.26},
+		{"Meerwasser 20 °C", 20, 35, standardAtmosphere, 7.38},
+		{"Brackwasser 25 °C", 25, 10, standardAtmosphere, 7.79},
+		{"900 hPa 20 °C", 20, 0, 900, 8.05},
+		{"1050 hPa 10 °C", 10, 0, 1050, 11.70},
+		{"Luftdruck unter Dampfdruck", 20, 0, 20, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := oxygenSolubility(tt.temperature, tt.salinity, tt.pressure); !approx(got, tt.want, 0.03) {
+				t.Errorf("oxygenSolubility(%v, %v, %v) = %.3f, erwartet %.2f", tt.temperature, tt.salinity, tt.pressure, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompensateConcentration prüft die Kompensation einer Konzentration, die der Sensor für
+// Süßwasser bei Normaldruck liefert
+func TestCompensateConcentration(t *testing.T) {
+	tests := []struct {
+		name        string
+		measured    float64
+		temperature float64
+		salinity    float64
+		pressure    float64
+		want        float64
+	}{
+		{"ohne Kompensation", 9.09, 20, 0, standardAtmosphere, 9.09},
+		{"Salinität", 9.09, 20, 35, standardAtmosphere, 7.38},
+		{"halbe Sättigung mit Salinität", 4.545, 20, 35, standardAtmosphere, 3.69},
+		{"Luftdruck", 9.09, 20, 0, 900, 8.05},
+		{"Salinität und Luftdruck", 8.26, 25, 10, 900, 6.91},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factor, err := compensationFactor(tt.temperature, tt.salinity, tt.pressure)
+			if err != nil {
+				t.Fatalf("compensationFactor: %v", err)
+			}
+			if got := compensateConcentration(tt.measured, factor); !approx(got, tt.want, 0.03) {
+				t.Errorf("compensateConcentration = %.3f, erwartet %.2f", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := compensationFactor(-300, 0, standardAtmosphere); err == nil {
+		t.Errorf("Kompensation unter dem absoluten Nullpunkt erwartet Fehler")
+	}
+}
+
+// TestRead prüft, welche Register gelesen und welche Werte veröffentlicht werden
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name              string
+		saturation        *float64
+		concentration     *float64
+		configs           map[string]types.RegisterConfig
+		compensation      map[string]interface{}
+		calibration       map[string]interface{}
+		want              float64
+		wantSaturation    float64
+		wantMetadata      []string
+		wantNoMetadata    []string
+		wantErr           bool
+		wantQualityUncert bool
+	}{
+		{
+			name:           "nur Konzentration an Standardadresse",
+			concentration:  floatPtr(9.09),
+			want:           9.09,
+			wantSaturation: 100,
+			wantMetadata:   []string{"concentration_raw", "concentration_sensor_compensated"},
+			wantNoMetadata: []string{"saturation_raw"},
+		},
+		{
+			name:           "Konzentration mit Salinität",
+			concentration:  floatPtr(9.09),
+			compensation:   map[string]interface{}{CompensationSalinity: 35.0},
+			want:           7.38,
+			wantSaturation: 100,
+		},
+		{
+			name:       "nur Sättigung",
+			saturation: floatPtr(50),
+			configs: map[string]types.RegisterConfig{
+				RegisterSaturation: {Name: RegisterSaturation, Address: testRegisterSaturation, Length: 2},
+			},
+			compensation:   map[string]interface{}{CompensationPressure: 900.0},
+			want:           4.025,
+			wantSaturation: 50,
+			wantMetadata:   []string{"saturation_raw"},
+			wantNoMetadata: []string{"concentration_raw"},
+		},
+		{
+			name:          "Sättigung und Konzentration",
+			saturation:    floatPtr(80),
+			concentration: floatPtr(7.27),
+			configs: map[string]types.RegisterConfig{
+				RegisterSaturation:    {Name: RegisterSaturation, Address: testRegisterSaturation, Length: 2},
+				RegisterConcentration: {Name: RegisterConcentration, Address: DefaultRegisterConcentration, Length: 2},
+			},
+			compensation:   map[string]interface{}{CompensationSalinity: 35.0},
+			want:           5.90,
+			wantSaturation: 80,
+			wantMetadata:   []string{"saturation_raw", "concentration_raw", "concentration_sensor_compensated"},
+		},
+		{
+			name:          "Sättigungsregister fehlt",
+			concentration: floatPtr(9.09),
+			configs: map[string]types.RegisterConfig{
+				RegisterSaturation:    {Name: RegisterSaturation, Address: testRegisterSaturation, Length: 2},
+				RegisterConcentration: {Name: RegisterConcentration, Address: DefaultRegisterConcentration, Length: 2},
+			},
+			wantErr: true,
+		},
+		{
+			name:           "Kalibrierung auf der Sättigungsskala",
+			concentration:  floatPtr(9.09),
+			calibration:    map[string]interface{}{CalibrationZero: 0.0, CalibrationSpan: 125.0},
+			want:           7.27,
+			wantSaturation: 80,
+		},
+		{
+			name:              "negative Sättigung nach Nullpunkt",
+			concentration:     floatPtr(0.0),
+			calibration:       map[string]interface{}{CalibrationZero: 2.0, CalibrationSpan: 100.0},
+			want:              0,
+			wantSaturation:    0,
+			wantQualityUncert: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protocol := &fakeProtocol{
+				registers: map[uint16][]byte{DefaultRegisterTemperature: float32Bytes(20)},
+				configs:   tt.configs,
+			}
+			if tt.saturation != nil {
+				protocol.registers[testRegisterSaturation] = float32Bytes(*tt.saturation)
+			}
+			if tt.concentration != nil {
+				protocol.registers[DefaultRegisterConcentration] = float32Bytes(*tt.concentration)
+			}
+
+			s := NewDissolvedOxygenSensor("oxygen_1", "Sauerstoff")
+			s.SetProtocol(protocol)
+			if tt.compensation != nil {
+				s.SetMetadata(metadataCompensation, tt.compensation)
+			}
+			if tt.calibration != nil {
+				if err := s.SetCalibration(tt.calibration); err != nil {
+					t.Fatalf("SetCalibration: %v", err)
+				}
+			}
+
+			reading, err := s.Read(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read = %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if value, _ := reading.Value.(float64); !approx(value, tt.want, 0.03) {
+				t.Errorf("Konzentration %.3f mg/L, erwartet %.2f", value, tt.want)
+			}
+			if saturation, _ := reading.Metadata["saturation_percent"].(float64); !approx(saturation, tt.wantSaturation, 0.1) {
+				t.Errorf("Sättigung %.2f %%, erwartet %.2f", saturation, tt.wantSaturation)
+			}
+			if uncertain := reading.Quality == types.QualityUncertain; uncertain != tt.wantQualityUncert {
+				t.Errorf("Qualität %v", reading.Quality)
+			}
+			for _, key := range tt.wantMetadata {
+				if _, ok := reading.Metadata[key]; !ok {
+					t.Errorf("Metadaten ohne %s", key)
+				}
+			}
+			for _, key := range tt.wantNoMetadata {
+				if _, ok := reading.Metadata[key]; ok {
+					t.Errorf("Metadaten mit %s", key)
+				}
+			}
+		})
+	}
+}
+
+// TestCompensationParameters prüft den Vorrang aktueller Werte anderer Sensoren vor den
+// Festwerten und deren Ablauf
+func TestCompensationParameters(t *testing.T) {
+	tests := []struct {
+		name         string
+		compensation map[string]interface{}
+		live         map[string]compensationValue
+		wantSalinity float64
+		wantPressure float64
+	}{
+		{"Standardwerte", nil, nil, DefaultSalinity, DefaultPressure},
+		{"Festwerte", map[string]interface{}{CompensationSalinity: 5.0, CompensationPressure: 950.0}, nil, 5, 950},
+		{
+			name:         "aktuelle Werte",
+			compensation: map[string]interface{}{CompensationSalinity: 5.0},
+			live: map[string]compensationValue{
+				CompensationSalinity: {value: 12, updatedAt: time.Now()},
+				CompensationPressure: {value: 980, updatedAt: time.Now()},
+			},
+			wantSalinity: 12,
+			wantPressure: 980,
+		},
+		{
+			name:         "veraltete Werte",
+			compensation: map[string]interface{}{CompensationSalinity: 5.0, CompensationMaxSourceAge: 60.0},
+			live: map[string]compensationValue{
+				CompensationSalinity: {value: 12, updatedAt: time.Now().Add(-2 * time.Minute)},
+			},
+			wantSalinity: 5,
+			wantPressure: DefaultPressure,
+		},
+		{
+			name:         "Luftdruck nicht positiv",
+			live:         map[string]compensationValue{CompensationPressure: {value: 0, updatedAt: time.Now()}},
+			wantSalinity: DefaultSalinity,
+			wantPressure: DefaultPressure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewDissolvedOxygenSensor("oxygen_1", "Sauerstoff")
+			if tt.compensation != nil {
+				s.SetMetadata(metadataCompensation, tt.compensation)
+			}
+			for parameter, value := range tt.live {
+				s.liveValues[parameter] = value
+			}
+
+			salinity, pressure := s.compensationParameters()
+			if salinity != tt.wantSalinity || pressure != tt.wantPressure {
+				t.Errorf("Salinität/Luftdruck = %v/%v, erwartet %v/%v", salinity, pressure, tt.wantSalinity, tt.wantPressure)
+			}
+		})
+	}
+}
+
+// floatPtr gibt einen Zeiger auf einen Registerwert zurück
+func floatPtr(v float64) *float64 {
+	return &v
+}
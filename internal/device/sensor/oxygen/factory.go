@@ -0,0 +1,83 @@
+package oxygen
+
+import (
+	"fmt"
+
+	"owipex_reader/internal/protocol/factory"
+	"owipex_reader/internal/types"
+)
+
+// Descriptor beschreibt den Gerätetyp "dissolved_oxygen_sensor" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	return types.TypeDescriptor{
+		Type:        "dissolved_oxygen_sensor",
+		Name:        "Sauerstoffsensor",
+		Description: "Gelöstsauerstoff-Sonde mit Salinitäts- und Luftdruckkompensation über Modbus RTU",
+		Category:    types.TypeSensor,
+		Readings:    []types.ReadingType{types.ReadingTypeDissolvedOxygen, types.ReadingTypeCustom},
+		Commands:    []types.CommandType{types.CommandTypeCalibrate},
+		Protocol:    "modbus",
+		ConfigDir:   "sensors/oxygen",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus": factory.ModbusSchema(),
+				metadataCompensation: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						CompensationSalinity:       map[string]interface{}{"type": "number", "minimum": 0, "description": "Salinität in PSU", "default": DefaultSalinity},
+						CompensationPressure:       map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "description": "Luftdruck in hPa", "default": DefaultPressure},
+						CompensationSalinitySource: map[string]interface{}{"type": "string", "description": "Quellsensor der Salinität (\"<sensor_id>\" oder \"<sensor_id>.<metadaten_schlüssel>\")"},
+						CompensationPressureSource: map[string]interface{}{"type": "string", "description": "Quellsensor des Luftdrucks"},
+						CompensationMaxSourceAge:   map[string]interface{}{"type": "number", "exclusiveMinimum": 0},
+					},
+				},
+				"calibration": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						CalibrationZero: map[string]interface{}{"type": "number", "default": 0.0},
+						CalibrationSpan: map[string]interface{}{"type": "number", "default": 100.0},
+					},
+				},
+			},
+			"required": []interface{}{"modbus"},
+		},
+		Defaults: map[string]interface{}{
+			"calibration": map[string]interface{}{
+				CalibrationZero: 0.0,
+				CalibrationSpan: 100.0,
+			},
+		},
+	}
+}
+
+// CreateDissolvedOxygenSensor erstellt einen Sauerstoffsensor aus einer Konfiguration
+func CreateDissolvedOxygenSensor(config types.DeviceConfig) (types.Sensor, error) {
+	// Neuen Sauerstoffsensor erstellen
+	sensor := NewDissolvedOxygenSensor(config.ID, config.Name)
+
+	// Protokoll-Handler konfigurieren
+	if config.Protocol == "modbus" {
+		if modbusConfig, ok := config.Metadata["modbus"].(map[string]interface{}); ok {
+			protocol, err := factory.CreateProtocolHandler("modbus", modbusConfig)
+			if err != nil {
+				return nil, fmt.Errorf("fehler beim Erstellen des Protokoll-Handlers: %w", err)
+			}
+			sensor.BaseSensor.SetProtocol(protocol)
+		}
+	}
+
+	// Kompensationseinstellungen (Salinität, Luftdruck, Quellsensoren) übernehmen
+	if compensation, ok := config.Metadata[metadataCompensation].(map[string]interface{}); ok {
+		sensor.SetMetadata(metadataCompensation, compensation)
+	}
+
+	// Kalibrierung setzen, falls vorhanden
+	if calibration, ok := config.Metadata["calibration"].(map[string]interface{}); ok {
+		if err := sensor.SetCalibration(calibration); err != nil {
+			return nil, fmt.Errorf("fehler beim Setzen der Kalibrierung: %w", err)
+		}
+	}
+
+	return sensor, nil
+}
@@ -3,10 +3,36 @@ package ph
 import (
 	"fmt"
 
+	"owipex_reader/internal/device/sensor"
 	"owipex_reader/internal/protocol/factory"
 	"owipex_reader/internal/types"
 )
 
+// Descriptor beschreibt den Gerätetyp "ph_sensor" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	return types.TypeDescriptor{
+		Type:        "ph_sensor",
+		Name:        "pH-Sensor",
+		Description: "pH-Sonde mit Temperaturmessung über Modbus RTU",
+		Category:    types.TypeSensor,
+		Readings:    []types.ReadingType{types.ReadingTypePH, types.ReadingTypeCustom},
+		Commands:    []types.CommandType{types.CommandTypeCalibrate},
+		Protocol:    "modbus",
+		ConfigDir:   "sensors/ph",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus":      factory.ModbusSchema(),
+				"calibration": sensor.OffsetScaleCalibrationSchema(),
+			},
+			"required": []interface{}{"modbus"},
+		},
+		Defaults: map[string]interface{}{
+			"calibration": map[string]interface{}{"offset": 0.0, "scale": 1.0},
+		},
+	}
+}
+
 // CreatePHSensor erstellt einen pH-Sensor aus einer Konfiguration
 func CreatePHSensor(config types.DeviceConfig) (types.Sensor, error) {
 	// Neuen pH-Sensor erstellen
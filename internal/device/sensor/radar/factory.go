@@ -7,6 +7,43 @@ import (
 	"owipex_reader/internal/types"
 )
 
+// Descriptor beschreibt den Gerätetyp "radar_sensor" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	number := func(description string, defaultValue float64) map[string]interface{} {
+		return map[string]interface{}{"type": "number", "minimum": 0, "description": description, "default": defaultValue}
+	}
+
+	return types.TypeDescriptor{
+		Type:        "radar_sensor",
+		Name:        "Radar-Füllstandsensor",
+		Description: "Radar-Abstandsmessung mit Berechnung von Füllstand und Volumen eines rechteckigen Behälters",
+		Category:    types.TypeSensor,
+		Readings:    []types.ReadingType{types.ReadingTypeLevel, types.ReadingTypeCustom},
+		Commands:    []types.CommandType{types.CommandTypeCalibrate},
+		Protocol:    "modbus",
+		ConfigDir:   "sensors/radar",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus": factory.ModbusSchema(),
+				"container_config": map[string]interface{}{
+					"type":        "object",
+					"description": "Abmessungen des Behälters",
+					"properties": map[string]interface{}{
+						ConfigWidthMM:             number("Breite in mm", 2500),
+						ConfigLengthMM:            number("Länge in mm", 4000),
+						ConfigMaxVolumeM3:         number("Maximales Volumen in m³", 15),
+						ConfigAirDistanceMaxLevel: number("Luftabstand bei maximalem Füllstand in mm", 5500),
+						ConfigMaxWaterLevel:       number("Maximaler Wasserstand in mm", 1500),
+						ConfigNormalWaterLevel:    number("Normaler Wasserstand in mm", 800),
+					},
+				},
+			},
+			"required": []interface{}{"modbus"},
+		},
+	}
+}
+
 // CreateRadarSensor erstellt einen Radar-Sensor aus einer Konfiguration
 func CreateRadarSensor(config types.DeviceConfig) (types.Sensor, error) {
 	// Neuen Radar-Sensor erstellen
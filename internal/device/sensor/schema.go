@@ -0,0 +1,16 @@
+package sensor
+
+import "owipex_reader/internal/types"
+
+// OffsetScaleCalibrationSchema gibt das JSON-Schema einer linearen Kalibrierung
+// (Wert * scale + offset) zurück, wie sie die meisten Sensoren verwenden
+func OffsetScaleCalibrationSchema() types.Schema {
+	return types.Schema{
+		"type":        "object",
+		"description": "Lineare Kalibrierung: Wert * scale + offset",
+		"properties": map[string]interface{}{
+			"offset": map[string]interface{}{"type": "number", "default": 0.0},
+			"scale":  map[string]interface{}{"type": "number", "default": 1.0},
+		},
+	}
+}
@@ -3,10 +3,36 @@ package turbidity
 import (
 	"fmt"
 
+	"owipex_reader/internal/device/sensor"
 	"owipex_reader/internal/protocol/factory"
 	"owipex_reader/internal/types"
 )
 
+// Descriptor beschreibt den Gerätetyp "turbidity_sensor" für den Gerätekatalog
+func Descriptor() types.TypeDescriptor {
+	return types.TypeDescriptor{
+		Type:        "turbidity_sensor",
+		Name:        "Trübungssensor",
+		Description: "Trübungssonde (NTU) mit Temperaturmessung über Modbus RTU",
+		Category:    types.TypeSensor,
+		Readings:    []types.ReadingType{types.ReadingTypeTurbidity, types.ReadingTypeCustom},
+		Commands:    []types.CommandType{types.CommandTypeCalibrate},
+		Protocol:    "modbus",
+		ConfigDir:   "sensors/turbidity",
+		MetadataSchema: types.Schema{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"modbus":      factory.ModbusSchema(),
+				"calibration": sensor.OffsetScaleCalibrationSchema(),
+			},
+			"required": []interface{}{"modbus"},
+		},
+		Defaults: map[string]interface{}{
+			"calibration": map[string]interface{}{"offset": 0.0, "scale": 1.0},
+		},
+	}
+}
+
 // CreateTurbiditySensor erstellt einen Trübungssensor aus einer Konfiguration
 func CreateTurbiditySensor(config types.DeviceConfig) (types.Sensor, error) {
 	// Neuen Trübungssensor erstellen
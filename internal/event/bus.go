@@ -0,0 +1,343 @@
+// Package event implementiert einen internen Event-Bus mit typisierten Topics,
+// Warteschlangen je Abonnent und einer für alle Abonnenten gleichen Reihenfolge.
+package event
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy legt fest, was bei voller Warteschlange eines Abonnenten passiert
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest verwirft das älteste wartende Ereignis (Standard)
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDropNewest verwirft das neue Ereignis
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowBlock lässt den Publisher warten, bis wieder Platz ist. Das Ereignis ist dann
+	// bereits eingereiht, andere Publisher werden nicht aufgehalten.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowUnbounded verwirft nie und lässt den Publisher nie warten; die Warteschlange
+	// wächst über QueueSize hinaus (für Sicherheitsfunktionen wie Failsafe und Verriegelungen)
+	OverflowUnbounded OverflowPolicy = "unbounded"
+
+	// DefaultQueueSize ist die Standardgröße der Warteschlange eines Abonnenten
+	DefaultQueueSize = 256
+)
+
+// Handler verarbeitet ein Ereignis
+type Handler func(Event)
+
+// SubscriberOptions enthält die Einstellungen eines Abonnenten
+type SubscriberOptions struct {
+	// QueueSize begrenzt die Anzahl wartender Ereignisse (Standard: DefaultQueueSize). Bei
+	// OverflowUnbounded wird ab dieser Größe gewarnt.
+	QueueSize int
+	// Overflow legt das Verhalten bei voller Warteschlange fest (Standard: OverflowDropOldest)
+	Overflow OverflowPolicy
+}
+
+// SubscriberStats enthält Zähler eines Abonnenten
+type SubscriberStats struct {
+	Queued    int
+	Delivered uint64
+	Dropped   uint64
+	Panics    uint64
+}
+
+// Bus verteilt Ereignisse an Abonnenten. Jeder Abonnent hat eine eigene Warteschlange und
+// genau eine Worker-Goroutine. Sequenznummer und Einreihen erfolgen unter einer Sperre,
+// daher erhält jeder Abonnent die Ereignisse in der Reihenfolge ihrer Sequenznummern, und
+// ein langsamer oder abstürzender Abonnent beeinträchtigt die anderen nicht.
+type Bus struct {
+	subscribers map[string]*subscriber
+	mutex       sync.RWMutex
+	sequence    uint64
+	closed      bool
+	wg          sync.WaitGroup
+	logger      *log.Logger
+}
+
+// NewBus erstellt einen neuen Event-Bus
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[string]*subscriber),
+		logger:      log.New(os.Stdout, "[EventBus] ", log.LstdFlags),
+	}
+}
+
+// Subscribe registriert einen Abonnenten für die angegebenen Topics.
+// Ohne Topics erhält der Abonnent alle Ereignisse.
+func (b *Bus) Subscribe(id string, handler Handler, options SubscriberOptions, topics ...Topic) error {
+	if handler == nil {
+		return fmt.Errorf("kein Handler für Abonnent %s angegeben", id)
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = DefaultQueueSize
+	}
+	switch options.Overflow {
+	case "":
+		options.Overflow = OverflowDropOldest
+	case OverflowDropOldest, OverflowDropNewest, OverflowBlock, OverflowUnbounded:
+	default:
+		return fmt.Errorf("unbekannte Overflow-Strategie: %s", options.Overflow)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return fmt.Errorf("event-Bus ist geschlossen")
+	}
+	if _, exists := b.subscribers[id]; exists {
+		return fmt.Errorf("abonnent %s ist bereits registriert", id)
+	}
+
+	sub := newSubscriber(id, handler, options, topics, b.logger)
+	b.subscribers[id] = sub
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		sub.run()
+	}()
+
+	return nil
+}
+
+// Unsubscribe entfernt einen Abonnenten. Bereits wartende Ereignisse werden noch zugestellt.
+func (b *Bus) Unsubscribe(id string) {
+	b.mutex.Lock()
+	sub, exists := b.subscribers[id]
+	delete(b.subscribers, id)
+	b.mutex.Unlock()
+
+	if exists {
+		sub.close()
+	}
+}
+
+// Publish veröffentlicht ein Ereignis an alle passenden Abonnenten.
+// Zeitstempel und Sequenznummer werden gesetzt, falls nicht vorhanden.
+func (b *Bus) Publish(e Event) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+
+	// Sequenznummer und Einreihen unter derselben Sperre, damit kein Abonnent ein späteres
+	// Ereignis vor einem früheren erhält. Einreihen wartet nie.
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return
+	}
+	b.sequence++
+	e.Sequence = b.sequence
+
+	var full []*subscriber
+	for _, sub := range b.subscribers {
+		if sub.accepts(e.Topic) && sub.enqueue(e) {
+			full = append(full, sub)
+		}
+	}
+	b.mutex.Unlock()
+
+	// Volle Abonnenten mit OverflowBlock bremsen den Publisher erst nach dem Freigeben der
+	// Sperre, damit sie weder andere Publisher noch Subscribe aufhalten
+	for _, sub := range full {
+		sub.waitForRoom()
+	}
+}
+
+// Stats gibt die Zähler eines Abonnenten zurück
+func (b *Bus) Stats(id string) (SubscriberStats, bool) {
+	b.mutex.RLock()
+	sub, exists := b.subscribers[id]
+	b.mutex.RUnlock()
+
+	if !exists {
+		return SubscriberStats{}, false
+	}
+	return sub.stats(), true
+}
+
+// Close beendet den Bus. Wartende Ereignisse werden noch zugestellt.
+func (b *Bus) Close() {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return
+	}
+	b.closed = true
+	subscribers := b.subscribers
+	b.subscribers = make(map[string]*subscriber)
+	b.mutex.Unlock()
+
+	for _, sub := range subscribers {
+		sub.close()
+	}
+	b.wg.Wait()
+}
+
+// subscriber verwaltet die Warteschlange und den Worker eines Abonnenten
+type subscriber struct {
+	id      string
+	handler Handler
+	topics  map[Topic]bool
+	options SubscriberOptions
+	logger  *log.Logger
+
+	queue  []Event
+	closed bool
+	mutex  sync.Mutex
+	cond   *sync.Cond
+
+	delivered uint64
+	dropped   uint64
+	panics    uint64
+}
+
+// newSubscriber erstellt einen neuen Abonnenten
+func newSubscriber(id string, handler Handler, options SubscriberOptions, topics []Topic, logger *log.Logger) *subscriber {
+	sub := &subscriber{
+		id:      id,
+		handler: handler,
+		options: options,
+		logger:  logger,
+		queue:   make([]Event, 0, options.QueueSize),
+	}
+	sub.cond = sync.NewCond(&sub.mutex)
+
+	if len(topics) > 0 {
+		sub.topics = make(map[Topic]bool, len(topics))
+		for _, topic := range topics {
+			sub.topics[topic] = true
+		}
+	}
+
+	return sub
+}
+
+// accepts prüft, ob der Abonnent ein Topic abonniert hat
+func (s *subscriber) accepts(topic Topic) bool {
+	return s.topics == nil || s.topics[topic]
+}
+
+// enqueue stellt ein Ereignis in die Warteschlange und wendet bei Bedarf die Overflow-Strategie
+// an. Es wartet nie; bei OverflowBlock meldet es eine übervolle Warteschlange, auf die der
+// Publisher anschließend mit waitForRoom wartet.
+func (s *subscriber) enqueue(e Event) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	if len(s.queue) >= s.options.QueueSize {
+		switch s.options.Overflow {
+		case OverflowBlock:
+		case OverflowUnbounded:
+			if len(s.queue)%s.options.QueueSize == 0 {
+				s.logger.Printf("Warteschlange von %s wächst auf %d Ereignisse", s.id, len(s.queue)+1)
+			}
+		case OverflowDropNewest:
+			s.drop(e)
+			return false
+		default:
+			s.drop(s.queue[0])
+			s.queue[0] = Event{}
+			s.queue = s.queue[1:]
+		}
+	}
+
+	s.queue = append(s.queue, e)
+	s.cond.Broadcast()
+	return s.options.Overflow == OverflowBlock && len(s.queue) > s.options.QueueSize
+}
+
+// waitForRoom wartet, bis die Warteschlange wieder in QueueSize passt oder der Abonnent
+// geschlossen ist
+func (s *subscriber) waitForRoom() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for !s.closed && len(s.queue) > s.options.QueueSize {
+		s.cond.Wait()
+	}
+}
+
+// drop zählt ein verworfenes Ereignis und meldet den ersten Verlust sowie jeden weiteren 100er-Schritt
+func (s *subscriber) drop(e Event) {
+	s.dropped++
+	if s.dropped == 1 || s.dropped%100 == 0 {
+		s.logger.Printf("Warteschlange von %s voll, Ereignis %s von %s verworfen (%d insgesamt)",
+			s.id, e.Topic, e.Source, s.dropped)
+	}
+}
+
+// run stellt Ereignisse nacheinander zu, bis der Abonnent geschlossen und die Warteschlange leer ist
+func (s *subscriber) run() {
+	for {
+		s.mutex.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 && s.closed {
+			s.mutex.Unlock()
+			return
+		}
+
+		e := s.queue[0]
+		s.queue[0] = Event{}
+		s.queue = s.queue[1:]
+		s.cond.Broadcast()
+		s.mutex.Unlock()
+
+		s.deliver(e)
+	}
+}
+
+// deliver ruft den Handler auf und fängt Panics ab, damit der Prozess weiterläuft
+func (s *subscriber) deliver(e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.mutex.Lock()
+			s.panics++
+			s.mutex.Unlock()
+			s.logger.Printf("Panic im Handler %s bei Ereignis %s von %s: %v\n%s", s.id, e.Topic, e.Source, r, debug.Stack())
+		}
+	}()
+
+	s.handler(e)
+
+	s.mutex.Lock()
+	s.delivered++
+	s.mutex.Unlock()
+}
+
+// close beendet die Annahme neuer Ereignisse, der Worker arbeitet die Warteschlange noch ab
+func (s *subscriber) close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+// stats gibt die Zähler des Abonnenten zurück
+func (s *subscriber) stats() SubscriberStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return SubscriberStats{
+		Queued:    len(s.queue),
+		Delivered: s.delivered,
+		Dropped:   s.dropped,
+		Panics:    s.panics,
+	}
+}
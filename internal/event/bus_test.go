@@ -0,0 +1,278 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder sammelt die zugestellten Ereignisse eines Abonnenten
+type recorder struct {
+	mutex  sync.Mutex
+	events []Event
+}
+
+// handle speichert ein zugestelltes Ereignis
+func (r *recorder) handle(e Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, e)
+}
+
+// sources gibt die Quellen der zugestellten Ereignisse in Zustellreihenfolge zurück
+func (r *recorder) sources() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sources := make([]string, len(r.events))
+	for i, e := range r.events {
+		sources[i] = e.Source
+	}
+	return sources
+}
+
+// count gibt die Anzahl zugestellter Ereignisse zurück
+func (r *recorder) count() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.events)
+}
+
+// waitFor wartet höchstens eine Sekunde, bis die Bedingung erfüllt ist
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Zeitüberschreitung beim Warten auf %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// testEvent erstellt ein Ereignis mit der Quelle als Kennung
+func testEvent(topic Topic, source string) Event {
+	return Event{Topic: topic, Source: source}
+}
+
+// TestPublishOrder prüft, dass alle Abonnenten die Ereignisse gleichzeitiger Publisher in
+// derselben, nach Sequenznummern aufsteigenden Reihenfolge erhalten
+func TestPublishOrder(t *testing.T) {
+	const publishers, perPublisher = 8, 200
+
+	bus := NewBus()
+	first, second := &recorder{}, &recorder{}
+	options := SubscriberOptions{QueueSize: 4, Overflow: OverflowUnbounded}
+	if err := bus.Subscribe("first", first.handle, options); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe("second", second.handle, SubscriberOptions{QueueSize: 4, Overflow: OverflowBlock}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perPublisher; i++ {
+				bus.Publish(testEvent(TopicAlarm, fmt.Sprintf("%d-%d", p, i)))
+			}
+		}(p)
+	}
+	wg.Wait()
+	bus.Close()
+
+	for name, rec := range map[string]*recorder{"first": first, "second": second} {
+		if len(rec.events) != publishers*perPublisher {
+			t.Fatalf("%s erhielt %d Ereignisse, erwartet %d", name, len(rec.events), publishers*perPublisher)
+		}
+		for i := 1; i < len(rec.events); i++ {
+			if rec.events[i].Sequence <= rec.events[i-1].Sequence {
+				t.Fatalf("%s: Sequenz %d nach %d", name, rec.events[i].Sequence, rec.events[i-1].Sequence)
+			}
+		}
+	}
+	for i := range first.events {
+		if first.events[i].Sequence != second.events[i].Sequence {
+			t.Fatalf("Abonnenten unterscheiden sich an Position %d", i)
+		}
+	}
+}
+
+// TestOverflowPolicies prüft die Overflow-Strategien an einer vollen Warteschlange, während
+// der Handler noch das erste Ereignis bearbeitet
+func TestOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		overflow    OverflowPolicy
+		want        []string
+		wantDropped uint64
+	}{
+		{"drop_oldest", OverflowDropOldest, []string{"1", "4", "5"}, 2},
+		{"drop_newest", OverflowDropNewest, []string{"1", "2", "3"}, 2},
+		{"unbounded", OverflowUnbounded, []string{"1", "2", "3", "4", "5"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewBus()
+			rec := &recorder{}
+			started := make(chan struct{}, 1)
+			release := make(chan struct{})
+			handler := func(e Event) {
+				if e.Source == "1" {
+					started <- struct{}{}
+					<-release
+				}
+				rec.handle(e)
+			}
+			if err := bus.Subscribe("sub", handler, SubscriberOptions{QueueSize: 2, Overflow: tt.overflow}); err != nil {
+				t.Fatal(err)
+			}
+
+			bus.Publish(testEvent(TopicAlarm, "1"))
+			<-started
+			for _, source := range []string{"2", "3", "4", "5"} {
+				bus.Publish(testEvent(TopicAlarm, source))
+			}
+			stats, _ := bus.Stats("sub")
+			close(release)
+			bus.Close()
+
+			if got := rec.sources(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("zugestellt %v, erwartet %v", got, tt.want)
+			}
+			if stats.Dropped != tt.wantDropped {
+				t.Errorf("%d verworfen, erwartet %d", stats.Dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+// TestOverflowBlock prüft, dass ein voller Abonnent mit OverflowBlock nur den eigenen Publisher
+// bremst, das Ereignis aber bereits für alle Abonnenten eingereiht ist
+func TestOverflowBlock(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	blocked, other := &recorder{}, &recorder{}
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handler := func(e Event) {
+		if e.Source == "1" {
+			started <- struct{}{}
+			<-release
+		}
+		blocked.handle(e)
+	}
+	if err := bus.Subscribe("blocked", handler, SubscriberOptions{QueueSize: 1, Overflow: OverflowBlock}, TopicAlarm); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe("other", other.handle, SubscriberOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	bus.Publish(testEvent(TopicAlarm, "1"))
+	<-started
+	bus.Publish(testEvent(TopicAlarm, "2"))
+
+	done := make(chan struct{})
+	go func() {
+		bus.Publish(testEvent(TopicAlarm, "3"))
+		close(done)
+	}()
+
+	// Der andere Abonnent erhält das Ereignis, obwohl dessen Publisher noch wartet
+	waitFor(t, "Zustellung an den anderen Abonnenten", func() bool { return other.count() == 3 })
+	select {
+	case <-done:
+		t.Fatal("Publish kehrte trotz voller Warteschlange zurück")
+	default:
+	}
+
+	// Andere Publisher werden nicht aufgehalten
+	finished := make(chan struct{})
+	go func() {
+		bus.Publish(testEvent(TopicReadingProduced, "4"))
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Publish eines anderen Topics wartet auf den vollen Abonnenten")
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish kehrte nach Freigabe nicht zurück")
+	}
+	waitFor(t, "Zustellung an den blockierten Abonnenten", func() bool { return blocked.count() == 3 })
+	if got := blocked.sources(); fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("zugestellt %v, erwartet [1 2 3]", got)
+	}
+}
+
+// TestClose prüft, dass Close wartende Ereignisse noch zustellt und danach weder Ereignisse
+// noch Abonnenten annimmt
+func TestClose(t *testing.T) {
+	bus := NewBus()
+	rec := &recorder{}
+	handler := func(e Event) {
+		time.Sleep(5 * time.Millisecond)
+		rec.handle(e)
+	}
+	if err := bus.Subscribe("sub", handler, SubscriberOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{"1", "2", "3"} {
+		bus.Publish(testEvent(TopicAlarm, source))
+	}
+	bus.Close()
+
+	if got := rec.sources(); fmt.Sprint(got) != "[1 2 3]" {
+		t.Fatalf("zugestellt %v, erwartet [1 2 3]", got)
+	}
+
+	bus.Publish(testEvent(TopicAlarm, "4"))
+	if err := bus.Subscribe("late", rec.handle, SubscriberOptions{}); err == nil {
+		t.Error("Subscribe nach Close erwartet Fehler")
+	}
+	bus.Close()
+	if n := rec.count(); n != 3 {
+		t.Errorf("%d Ereignisse nach Close, erwartet 3", n)
+	}
+}
+
+// TestHandlerPanic prüft, dass eine Panic im Handler gezählt wird und die Zustellung weiterläuft
+func TestHandlerPanic(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	rec := &recorder{}
+	handler := func(e Event) {
+		if e.Source == "1" {
+			panic("Testfehler")
+		}
+		rec.handle(e)
+	}
+	if err := bus.Subscribe("sub", handler, SubscriberOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	bus.Publish(testEvent(TopicAlarm, "1"))
+	bus.Publish(testEvent(TopicAlarm, "2"))
+	waitFor(t, "Zustellung nach Panic", func() bool { return rec.count() == 1 })
+
+	stats, ok := bus.Stats("sub")
+	if !ok {
+		t.Fatal("Abonnent nicht gefunden")
+	}
+	if stats.Panics != 1 || stats.Delivered != 1 {
+		t.Errorf("Zähler %+v, erwartet 1 Panic und 1 Zustellung", stats)
+	}
+}
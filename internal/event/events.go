@@ -0,0 +1,152 @@
+package event
+
+import (
+	"time"
+
+	"owipex_reader/internal/types"
+)
+
+// Topic bezeichnet die Art eines Ereignisses
+type Topic string
+
+const (
+	// TopicDeviceLifecycle: Gerät hinzugefügt, entfernt, aktualisiert oder (de)aktiviert (Payload: types.DeviceEvent)
+	TopicDeviceLifecycle Topic = "device.lifecycle"
+	// TopicReadingProduced: Ein Sensor hat einen Messwert geliefert (Payload: ReadingProduced)
+	TopicReadingProduced Topic = "reading.produced"
+	// TopicReadFailed: Das Lesen eines Sensors ist fehlgeschlagen (Payload: ReadFailed)
+	TopicReadFailed Topic = "reading.failed"
+	// TopicCommandIssued: Ein Steuerbefehl wurde ausgelöst (Payload: CommandIssued)
+	TopicCommandIssued Topic = "command.issued"
+	// TopicCommandAcknowledged: Ein Steuerbefehl wurde ausgeführt oder abgelehnt (Payload: CommandAcknowledged)
+	TopicCommandAcknowledged Topic = "command.acknowledged"
+	// TopicConnectionState: Verbindungszustand eines Geräts oder einer Integration hat sich geändert (Payload: ConnectionState)
+	TopicConnectionState Topic = "connection.state"
+	// TopicAlarm: Ein Alarm wurde ausgelöst oder aufgehoben (Payload: Alarm)
+	TopicAlarm Topic = "alarm"
+)
+
+// Event ist ein Ereignis auf dem Bus
+type Event struct {
+	Topic Topic
+
+	// Source identifiziert die Quelle (z.B. Geräte-ID). Ereignisse derselben Quelle
+	// werden jedem Abonnenten in Veröffentlichungsreihenfolge zugestellt.
+	Source string
+
+	// Sequence ist die fortlaufende Nummer des Ereignisses auf dem Bus
+	Sequence uint64
+
+	Timestamp time.Time
+	Payload   interface{}
+}
+
+// ReadingProduced ist die Payload von TopicReadingProduced
+type ReadingProduced struct {
+	DeviceID string
+	Reading  types.Reading
+}
+
+// ReadFailed ist die Payload von TopicReadFailed
+type ReadFailed struct {
+	DeviceID string
+	Err      error
+}
+
+// CommandIssued ist die Payload von TopicCommandIssued
+type CommandIssued struct {
+	CommandID string
+	DeviceID  string
+	Method    string
+	Params    map[string]interface{}
+	Origin    string
+}
+
+// CommandAcknowledged ist die Payload von TopicCommandAcknowledged
+type CommandAcknowledged struct {
+	CommandID string
+	DeviceID  string
+	Method    string
+	Success   bool
+	Result    interface{}
+	Err       error
+
+	// State ist der nach dem Befehl zurückgelesene Zustand des Geräts (nur Befehle der
+	// Befehlsverwaltung, nil wenn nicht gelesen)
+	State interface{}
+}
+
+// ConnectionState ist die Payload von TopicConnectionState
+type ConnectionState struct {
+	Component string
+	Connected bool
+	State     string
+	Err       error
+}
+
+// AlarmSeverity gibt die Schwere eines Alarms an
+type AlarmSeverity string
+
+const (
+	SeverityInfo     AlarmSeverity = "INFO"
+	SeverityWarning  AlarmSeverity = "WARNING"
+	SeverityCritical AlarmSeverity = "CRITICAL"
+)
+
+// Alarm ist die Payload von TopicAlarm
+type Alarm struct {
+	Name     string
+	DeviceID string
+	Severity AlarmSeverity
+	Active   bool
+	Message  string
+	Value    interface{}
+}
+
+// NewDeviceLifecycleEvent erstellt ein Lebenszyklus-Ereignis eines Geräts
+func NewDeviceLifecycleEvent(deviceEvent types.DeviceEvent) Event {
+	return Event{Topic: TopicDeviceLifecycle, Source: deviceEvent.DeviceID, Payload: deviceEvent}
+}
+
+// NewReadingProducedEvent erstellt ein Ereignis für einen neuen Messwert
+func NewReadingProducedEvent(deviceID string, reading types.Reading) Event {
+	return Event{Topic: TopicReadingProduced, Source: deviceID, Payload: ReadingProduced{DeviceID: deviceID, Reading: reading}}
+}
+
+// NewReadFailedEvent erstellt ein Ereignis für einen fehlgeschlagenen Lesevorgang
+func NewReadFailedEvent(deviceID string, err error) Event {
+	return Event{Topic: TopicReadFailed, Source: deviceID, Payload: ReadFailed{DeviceID: deviceID, Err: err}}
+}
+
+// NewCommandIssuedEvent erstellt ein Ereignis für einen ausgelösten Befehl
+func NewCommandIssuedEvent(command CommandIssued) Event {
+	return Event{Topic: TopicCommandIssued, Source: commandSource(command.DeviceID, command.Origin), Payload: command}
+}
+
+// NewCommandAcknowledgedEvent erstellt ein Ereignis für einen quittierten Befehl
+func NewCommandAcknowledgedEvent(ack CommandAcknowledged, origin string) Event {
+	return Event{Topic: TopicCommandAcknowledged, Source: commandSource(ack.DeviceID, origin), Payload: ack}
+}
+
+// NewConnectionStateEvent erstellt ein Ereignis für einen geänderten Verbindungszustand
+func NewConnectionStateEvent(state ConnectionState) Event {
+	return Event{Topic: TopicConnectionState, Source: state.Component, Payload: state}
+}
+
+// NewAlarmEvent erstellt ein Alarm-Ereignis
+func NewAlarmEvent(alarm Alarm) Event {
+	source := alarm.DeviceID
+	if source == "" {
+		source = alarm.Name
+	}
+	return Event{Topic: TopicAlarm, Source: source, Payload: alarm}
+}
+
+// commandSource bestimmt die Quelle eines Befehlsereignisses, damit Auslösung und Quittung
+// derselben Quelle zugeordnet werden
+func commandSource(deviceID, origin string) string {
+	if deviceID != "" {
+		return deviceID
+	}
+	return origin
+}
@@ -0,0 +1,89 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"owipex_reader/internal/types"
+)
+
+// Sample ist ein Messwert mit dem Zeitpunkt seines Eingangs
+type Sample struct {
+	Reading types.Reading
+	At      time.Time
+}
+
+// SampleCache hält je Gerät den neuesten Messwert und den neuesten zurückgelesenen Aktorzustand
+// (types.ReadingTypeState) aus den ReadingProduced-Events. Beide werden getrennt gehalten, damit
+// der Zustand eines Hybridgeräts dessen Messwert nicht überschreibt. Regeln, Verriegelungen und
+// Regler verwenden denselben Zwischenspeicher.
+type SampleCache struct {
+	devices map[string]bool
+
+	mutex    sync.RWMutex
+	readings map[string]Sample
+	states   map[string]Sample
+}
+
+// NewSampleCache erstellt einen Zwischenspeicher für die angegebenen Geräte, ohne Angabe für
+// alle Geräte
+func NewSampleCache(deviceIDs ...string) *SampleCache {
+	var devices map[string]bool
+	if len(deviceIDs) > 0 {
+		devices = make(map[string]bool, len(deviceIDs))
+		for _, id := range deviceIDs {
+			devices[id] = true
+		}
+	}
+
+	return &SampleCache{
+		devices:  devices,
+		readings: make(map[string]Sample),
+		states:   make(map[string]Sample),
+	}
+}
+
+// Handle übernimmt den Messwert eines ReadingProduced-Events mit dem Zeitpunkt des Eingangs.
+// Handle kann direkt als Handler für TopicReadingProduced abonniert werden.
+func (c *SampleCache) Handle(ev Event) {
+	produced, ok := ev.Payload.(ReadingProduced)
+	if !ok {
+		return
+	}
+	c.Put(produced.DeviceID, Sample{Reading: produced.Reading, At: time.Now()})
+}
+
+// Put übernimmt einen Messwert oder, bei types.ReadingTypeState, einen Aktorzustand. Werte
+// nicht beobachteter Geräte werden ignoriert.
+func (c *SampleCache) Put(deviceID string, sample Sample) {
+	if c.devices != nil && !c.devices[deviceID] {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if sample.Reading.Type == types.ReadingTypeState {
+		c.states[deviceID] = sample
+	} else {
+		c.readings[deviceID] = sample
+	}
+}
+
+// Reading gibt den neuesten Messwert eines Geräts zurück
+func (c *SampleCache) Reading(deviceID string) (Sample, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	sample, exists := c.readings[deviceID]
+	return sample, exists
+}
+
+// State gibt den neuesten Zustand eines Aktors zurück
+func (c *SampleCache) State(deviceID string) (Sample, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	sample, exists := c.states[deviceID]
+	return sample, exists
+}
@@ -0,0 +1,65 @@
+package event
+
+import (
+	"testing"
+
+	"owipex_reader/internal/types"
+)
+
+// TestSampleCache prüft, dass Zustände getrennt von Messwerten gehalten und nur die
+// beobachteten Geräte übernommen werden
+func TestSampleCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		deviceIDs   []string
+		events      []Event
+		wantReading interface{}
+		wantState   interface{}
+	}{
+		{
+			name: "Zustand überschreibt den Messwert eines Hybridgeräts nicht",
+			events: []Event{
+				NewReadingProducedEvent("valve", types.NewReading(types.ReadingTypeFlow, 12.5, "m3/h", nil)),
+				NewReadingProducedEvent("valve", types.NewReading(types.ReadingTypeState, 40.0, "%", nil)),
+			},
+			wantReading: 12.5,
+			wantState:   40.0,
+		},
+		{
+			name:      "neuester Messwert gilt",
+			deviceIDs: []string{"valve"},
+			events: []Event{
+				NewReadingProducedEvent("valve", types.NewReading(types.ReadingTypeFlow, 12.5, "m3/h", nil)),
+				NewReadingProducedEvent("valve", types.NewReading(types.ReadingTypeFlow, 13.0, "m3/h", nil)),
+			},
+			wantReading: 13.0,
+		},
+		{
+			name:      "nicht beobachtetes Gerät",
+			deviceIDs: []string{"ph"},
+			events: []Event{
+				NewReadingProducedEvent("valve", types.NewReading(types.ReadingTypeFlow, 12.5, "m3/h", nil)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewSampleCache(tt.deviceIDs...)
+			for _, ev := range tt.events {
+				cache.Handle(ev)
+			}
+
+			var reading, state interface{}
+			if sample, ok := cache.Reading("valve"); ok {
+				reading = sample.Reading.Value
+			}
+			if sample, ok := cache.State("valve"); ok {
+				state = sample.Reading.Value
+			}
+			if reading != tt.wantReading || state != tt.wantState {
+				t.Errorf("Messwert %v, Zustand %v, erwartet %v, %v", reading, state, tt.wantReading, tt.wantState)
+			}
+		})
+	}
+}
@@ -0,0 +1,449 @@
+// Package failsafe bringt Aktoren in ihren sicheren Zustand: beim Beenden der Anwendung, bei
+// Reglerfehlern, bei Ablauf des Watchdogs und optional bei Verlust der ThingsBoard-Verbindung.
+// Beim Start wird zusätzlich die geräteeigene Rückfallebene programmiert, damit die Ausgänge
+// auch nach einem Absturz des Prozesses sicher schalten.
+package failsafe
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/types"
+)
+
+// Anlässe für den sicheren Zustand
+const (
+	ReasonShutdown        = "shutdown"
+	ReasonConnectionLoss  = "connection_loss"
+	ReasonControllerFault = "controller_fault"
+	ReasonWatchdog        = "watchdog"
+	ReasonManual          = "manual"
+)
+
+// thingsboardComponent ist die Komponente des ThingsBoard-Clients auf dem Event-Bus
+const thingsboardComponent = "thingsboard"
+
+// subscriberID ist die ID des Managers als Abonnent auf dem Event-Bus
+const subscriberID = "failsafe"
+
+// programTimeout begrenzt das Programmieren der Rückfallebene eines Geräts
+const programTimeout = 5 * time.Second
+
+// watchdogTick ist der Abstand, in dem der Watchdog die überwachten Loops prüft
+const watchdogTick = time.Second
+
+// provider wird von Aktoren mit sicherem Zustand implementiert (actuator.BaseActuator)
+type provider interface {
+	Failsafe() *actuator.Failsafe
+}
+
+// programmer wird von Aktoren mit geräteeigener Rückfallebene implementiert
+type programmer interface {
+	ProgramFailsafe(ctx context.Context) error
+}
+
+// Holder hält die sicheren Zustände gegen Befehle niedrigerer Priorität, bis ihr Anlass
+// behoben ist (arbitration.Arbiter)
+type Holder interface {
+	ReleaseSafety(reason string, deviceIDs ...string) []string
+}
+
+// Manager stellt die sicheren Zustände der Aktoren her
+type Manager struct {
+	registry *device.Registry
+	commands *command.Manager
+	bus      *event.Bus
+	logger   *log.Logger
+
+	holder      Holder
+	holderMutex sync.RWMutex
+
+	connectionLossTimeout time.Duration
+	watchdogTimeout       time.Duration
+	watchdogTick          time.Duration
+
+	mutex           sync.Mutex
+	lossTimer       *time.Timer
+	connectionLost  bool
+	sources         map[string]*watchSource
+	watchdogExpired bool
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewManager erstellt einen Manager. Befehle laufen über den Befehlsmanager und werden
+// dort mit dem Auslöser "failsafe" protokolliert.
+func NewManager(cfg config.FailsafeConfig, registry *device.Registry, commands *command.Manager) *Manager {
+	return &Manager{
+		registry:              registry,
+		commands:              commands,
+		bus:                   registry.EventBus(),
+		logger:                log.New(os.Stdout, "[Failsafe] ", log.LstdFlags),
+		connectionLossTimeout: time.Duration(cfg.ConnectionLossTimeoutSeconds) * time.Second,
+		watchdogTimeout:       time.Duration(cfg.WatchdogTimeoutSeconds) * time.Second,
+		watchdogTick:          watchdogTick,
+		sources:               make(map[string]*watchSource),
+		stopChan:              make(chan struct{}),
+	}
+}
+
+// SetHolder legt fest, wer die sicheren Zustände hält. Behobene Anlässe (Verbindung
+// wiederhergestellt, Watchdog bedient, Regler ohne Störung) werden ihm gemeldet.
+func (m *Manager) SetHolder(holder Holder) {
+	m.holderMutex.Lock()
+	defer m.holderMutex.Unlock()
+
+	m.holder = holder
+}
+
+// Start programmiert die Rückfallebenen der Geräte, überwacht die ThingsBoard-Verbindung
+// und startet den Watchdog
+func (m *Manager) Start() error {
+	for _, dev := range m.registry.GetAllDevices() {
+		m.program(dev)
+	}
+
+	// Ohne Begrenzung, damit kein Verbindungsverlust verworfen wird
+	options := event.SubscriberOptions{Overflow: event.OverflowUnbounded}
+	if err := m.bus.Subscribe(subscriberID, m.handleEvent, options, event.TopicDeviceLifecycle, event.TopicConnectionState); err != nil {
+		return fmt.Errorf("fehler beim Abonnieren des Event-Bus: %w", err)
+	}
+
+	if m.watchdogTimeout > 0 {
+		m.wg.Add(1)
+		go m.watchdog()
+	}
+
+	return nil
+}
+
+// Stop beendet Watchdog und Verbindungsüberwachung. Die sicheren Zustände werden dabei
+// nicht hergestellt, dafür ist Apply(ReasonShutdown) aufzurufen.
+func (m *Manager) Stop() {
+	close(m.stopChan)
+	m.wg.Wait()
+	m.bus.Unsubscribe(subscriberID)
+
+	m.mutex.Lock()
+	if m.lossTimer != nil {
+		m.lossTimer.Stop()
+		m.lossTimer = nil
+	}
+	m.mutex.Unlock()
+}
+
+// Apply stellt den sicheren Zustand der angegebenen Aktoren her, ohne Angabe den aller Aktoren
+// mit sicherem Zustand. Bei Verbindungsverlust werden nur Aktoren mit on_connection_loss
+// berücksichtigt. Die Befehle laufen je Gerät parallel.
+func (m *Manager) Apply(reason string, deviceIDs ...string) []command.Record {
+	targets := m.targets(reason, deviceIDs)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(targets))
+	for id := range targets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	m.logger.Printf("Sicherer Zustand (%s) für %s", reason, strings.Join(ids, ", "))
+
+	records := make([]command.Record, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			records[i] = m.commands.Submit(command.Request{
+				DeviceID: id,
+				Command:  targets[id].Command,
+				Origin:   command.OriginFailsafe,
+				Issuer:   reason,
+			})
+		}(i, id)
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, record := range records {
+		if !record.Succeeded() {
+			failed = append(failed, fmt.Sprintf("%s (%s)", record.DeviceID, record.Error))
+		}
+	}
+	if len(failed) > 0 {
+		m.logger.Printf("Sicherer Zustand (%s) nicht hergestellt für %s", reason, strings.Join(failed, ", "))
+	}
+
+	if reason != ReasonShutdown && reason != ReasonManual {
+		message := fmt.Sprintf("sicherer Zustand hergestellt für %s", strings.Join(ids, ", "))
+		if len(failed) > 0 {
+			message += fmt.Sprintf(", fehlgeschlagen für %s", strings.Join(failed, ", "))
+		}
+		m.publishAlarm(reason, true, message)
+	}
+
+	return records
+}
+
+// ControllerFault stellt den sicheren Zustand der Aktoren eines gestörten Reglers her
+func (m *Manager) ControllerFault(controller string, err error, deviceIDs ...string) []command.Record {
+	m.logger.Printf("Störung des Reglers %s: %v", controller, err)
+	return m.Apply(ReasonControllerFault, deviceIDs...)
+}
+
+// ControllerRecovered meldet, dass die Störung eines Reglers behoben ist. Die wegen der Störung
+// gehaltenen sicheren Zustände seiner Aktoren werden freigegeben.
+func (m *Manager) ControllerRecovered(controller string, deviceIDs ...string) {
+	if len(deviceIDs) == 0 {
+		return
+	}
+	if released := m.releaseHold(ReasonControllerFault, deviceIDs...); len(released) > 0 {
+		m.logger.Printf("Regler %s läuft wieder, %s freigegeben", controller, strings.Join(released, ", "))
+	}
+}
+
+// releaseHold gibt die aus dem Anlass reason gehaltenen sicheren Zustände frei
+func (m *Manager) releaseHold(reason string, deviceIDs ...string) []string {
+	m.holderMutex.RLock()
+	holder := m.holder
+	m.holderMutex.RUnlock()
+
+	if holder == nil {
+		return nil
+	}
+	return holder.ReleaseSafety(reason, deviceIDs...)
+}
+
+// watchSource ist ein vom Watchdog überwachter Loop
+type watchSource struct {
+	timeout  time.Duration
+	lastKick time.Time
+}
+
+// Register meldet einen Loop beim Watchdog an, z.B. eine Leseaufgabe oder einen Regler. Jeder
+// Loop hat einen eigenen Namen und ruft Kick mindestens einmal je period auf. Bleibt der Aufruf
+// länger als period plus watchdog_timeout_seconds aus, werden alle Aktoren in den sicheren
+// Zustand gebracht. Ohne Watchdog (Timeout 0) wird nichts überwacht.
+func (m *Manager) Register(source string, period time.Duration) {
+	if m.watchdogTimeout <= 0 {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.sources[source] = &watchSource{timeout: period + m.watchdogTimeout, lastKick: time.Now()}
+}
+
+// Kick bedient den Watchdog für einen angemeldeten Loop. Bedienen alle Loops den Watchdog
+// wieder, werden die wegen des Watchdogs gehaltenen sicheren Zustände freigegeben.
+func (m *Manager) Kick(source string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	watched, exists := m.sources[source]
+	if !exists {
+		return
+	}
+	watched.lastKick = time.Now()
+	m.recoverLocked(watched.lastKick)
+}
+
+// Release meldet einen Loop ab, der planmäßig beendet wird (z.B. beim Stoppen eines Reglers)
+func (m *Manager) Release(source string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.sources, source)
+	m.recoverLocked(time.Now())
+}
+
+// staleLocked gibt die Loops zurück, die den Watchdog nicht rechtzeitig bedient haben.
+// Muss mit gesperrtem Mutex aufgerufen werden.
+func (m *Manager) staleLocked(now time.Time) []string {
+	var stale []string
+	for source, watched := range m.sources {
+		if now.Sub(watched.lastKick) > watched.timeout {
+			stale = append(stale, source)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// recoverLocked gibt die sicheren Zustände des Watchdogs frei, sobald kein Loop mehr überfällig
+// ist. Muss mit gesperrtem Mutex aufgerufen werden.
+func (m *Manager) recoverLocked(now time.Time) {
+	if !m.watchdogExpired || len(m.staleLocked(now)) > 0 {
+		return
+	}
+
+	m.watchdogExpired = false
+	m.logger.Println("Watchdog wird wieder bedient, sichere Zustände werden freigegeben")
+	go func() {
+		m.releaseHold(ReasonWatchdog)
+		m.publishAlarm(ReasonWatchdog, false, "watchdog wird wieder bedient")
+	}()
+}
+
+// watchdog prüft, ob alle angemeldeten Loops den Watchdog rechtzeitig bedienen
+func (m *Manager) watchdog() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.watchdogTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case now := <-ticker.C:
+			m.mutex.Lock()
+			var stale []string
+			if !m.watchdogExpired {
+				stale = m.staleLocked(now)
+				m.watchdogExpired = len(stale) > 0
+			}
+			m.mutex.Unlock()
+
+			if len(stale) > 0 {
+				m.logger.Printf("Watchdog nicht rechtzeitig bedient von %s", strings.Join(stale, ", "))
+				m.Apply(ReasonWatchdog)
+			}
+		}
+	}
+}
+
+// handleEvent programmiert neue Geräte und überwacht die ThingsBoard-Verbindung
+func (m *Manager) handleEvent(ev event.Event) {
+	switch payload := ev.Payload.(type) {
+	case types.DeviceEvent:
+		if (payload.Type == types.EventAdded || payload.Type == types.EventUpdated) && payload.Device != nil {
+			m.program(payload.Device)
+		}
+	case event.ConnectionState:
+		if payload.Component == thingsboardComponent {
+			m.handleConnection(payload.Connected)
+		}
+	}
+}
+
+// handleConnection startet bei Verbindungsverlust den Timer für den sicheren Zustand
+func (m *Manager) handleConnection(connected bool) {
+	if m.connectionLossTimeout <= 0 {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if connected {
+		if m.lossTimer != nil {
+			m.lossTimer.Stop()
+			m.lossTimer = nil
+		}
+		if m.connectionLost {
+			m.connectionLost = false
+			m.logger.Println("ThingsBoard-Verbindung wiederhergestellt, sichere Zustände werden freigegeben")
+			go func() {
+				m.releaseHold(ReasonConnectionLoss)
+				m.publishAlarm(ReasonConnectionLoss, false, "ThingsBoard-Verbindung wiederhergestellt")
+			}()
+		}
+		return
+	}
+
+	if m.lossTimer != nil || m.connectionLost {
+		return
+	}
+	m.lossTimer = time.AfterFunc(m.connectionLossTimeout, func() {
+		m.mutex.Lock()
+		if m.lossTimer == nil {
+			m.mutex.Unlock()
+			return
+		}
+		m.lossTimer = nil
+		m.connectionLost = true
+		m.mutex.Unlock()
+
+		m.logger.Printf("ThingsBoard seit %s nicht erreichbar", m.connectionLossTimeout)
+		m.Apply(ReasonConnectionLoss)
+	})
+}
+
+// targets gibt die sicheren Zustände der betroffenen Aktoren zurück
+func (m *Manager) targets(reason string, deviceIDs []string) map[string]*actuator.Failsafe {
+	wanted := make(map[string]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		wanted[id] = true
+	}
+
+	targets := make(map[string]*actuator.Failsafe)
+	for _, dev := range m.registry.GetAllDevices() {
+		if len(wanted) > 0 && !wanted[dev.ID()] {
+			continue
+		}
+
+		p, ok := device.Unwrap(dev).(provider)
+		if !ok {
+			continue
+		}
+		failsafe := p.Failsafe()
+		if failsafe == nil {
+			continue
+		}
+		if reason == ReasonConnectionLoss && !failsafe.OnConnectionLoss {
+			continue
+		}
+		targets[dev.ID()] = failsafe
+	}
+
+	for _, id := range deviceIDs {
+		if _, ok := targets[id]; !ok {
+			m.logger.Printf("Gerät %s hat keinen sicheren Zustand konfiguriert", id)
+		}
+	}
+	return targets
+}
+
+// program schreibt die geräteeigene Rückfallebene eines Aktors
+func (m *Manager) program(dev types.Device) {
+	p, ok := device.Unwrap(dev).(programmer)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), programTimeout)
+	defer cancel()
+
+	if err := p.ProgramFailsafe(ctx); err != nil {
+		m.logger.Printf("Rückfallebene von %s nicht programmiert: %v", dev.ID(), err)
+	}
+}
+
+// publishAlarm meldet einen hergestellten sicheren Zustand
+func (m *Manager) publishAlarm(reason string, active bool, message string) {
+	severity := event.SeverityCritical
+	if !active {
+		severity = event.SeverityInfo
+	}
+
+	m.bus.Publish(event.NewAlarmEvent(event.Alarm{
+		Name:     "failsafe_" + reason,
+		Severity: severity,
+		Active:   active,
+		Message:  message,
+	}))
+}
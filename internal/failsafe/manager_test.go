@@ -0,0 +1,244 @@
+package failsafe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/types"
+)
+
+// testActor ist ein Aktor mit sicherem Zustand, der seine Befehle aufzeichnet
+type testActor struct {
+	id       string
+	failsafe *actuator.Failsafe
+
+	mutex    sync.Mutex
+	commands []types.Command
+}
+
+// ID gibt die Kennung des Aktors zurück
+func (a *testActor) ID() string { return a.id }
+
+// Name gibt die Kennung als Anzeigenamen zurück
+func (a *testActor) Name() string { return a.id }
+
+// Type gibt den Gerätetyp zurück
+func (a *testActor) Type() types.DeviceType { return types.TypeActor }
+
+// Metadata gibt keine Metadaten zurück
+func (a *testActor) Metadata() map[string]interface{} { return nil }
+
+// IsEnabled meldet den Aktor als aktiviert
+func (a *testActor) IsEnabled() bool { return true }
+
+// Enable wird ignoriert
+func (a *testActor) Enable(bool) {}
+
+// Close wird ignoriert
+func (a *testActor) Close() error { return nil }
+
+// Write zeichnet den Befehl auf
+func (a *testActor) Write(ctx context.Context, cmd types.Command) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.commands = append(a.commands, cmd)
+	return nil
+}
+
+// WriteRaw wird nicht unterstützt
+func (a *testActor) WriteRaw(ctx context.Context, data []byte) error {
+	return errors.New("nicht unterstützt")
+}
+
+// AvailableCommands gibt SET_STATE zurück
+func (a *testActor) AvailableCommands() []types.CommandType {
+	return []types.CommandType{types.CommandTypeSetState}
+}
+
+// GetState gibt den zuletzt befohlenen Wert zurück
+func (a *testActor) GetState() (interface{}, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if len(a.commands) == 0 {
+		return nil, nil
+	}
+	return a.commands[len(a.commands)-1].Value, nil
+}
+
+// Failsafe gibt den sicheren Zustand zurück (provider)
+func (a *testActor) Failsafe() *actuator.Failsafe { return a.failsafe }
+
+// written gibt die Anzahl ausgeführter Befehle zurück
+func (a *testActor) written() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return len(a.commands)
+}
+
+// blockingGuard sperrt jeden Befehl wie eine aktive Verriegelung
+type blockingGuard struct{}
+
+// CheckCommand lehnt jeden Befehl ab
+func (blockingGuard) CheckCommand(deviceID string, cmd types.Command) error {
+	return errors.New("verriegelt")
+}
+
+// testHolder zeichnet freigegebene Anlässe auf
+type testHolder struct {
+	released chan string
+}
+
+// ReleaseSafety meldet den freigegebenen Anlass
+func (h *testHolder) ReleaseSafety(reason string, deviceIDs ...string) []string {
+	h.released <- reason
+	return nil
+}
+
+// newTestManager erstellt einen Manager mit einem Aktor, kurzem Watchdog und schnellem Takt
+func newTestManager(t *testing.T) (*Manager, *testActor) {
+	t.Helper()
+
+	actor := &testActor{id: "pump", failsafe: &actuator.Failsafe{Command: types.NewCommand(types.CommandTypeSetState, false)}}
+	registry := device.NewRegistry()
+	if err := registry.AddDevice(actor); err != nil {
+		t.Fatal(err)
+	}
+	commands := command.NewManager(registry, nil, time.Second, 0)
+
+	m := NewManager(config.FailsafeConfig{WatchdogTimeoutSeconds: 1}, registry, commands)
+	m.watchdogTimeout = 50 * time.Millisecond
+	m.watchdogTick = 5 * time.Millisecond
+
+	t.Cleanup(func() {
+		commands.Stop()
+		registry.Close()
+	})
+	return m, actor
+}
+
+// TestWatchdog prüft, dass nur ein überfälliger angemeldeter Loop den sicheren Zustand auslöst
+func TestWatchdog(t *testing.T) {
+	tests := []struct {
+		name     string
+		register bool
+		kick     bool
+		release  bool
+		wantSafe bool
+	}{
+		{name: "kein Loop angemeldet"},
+		{name: "Loop bedient den Watchdog", register: true, kick: true},
+		{name: "Loop bleibt aus", register: true, wantSafe: true},
+		{name: "Loop abgemeldet", register: true, release: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, actor := newTestManager(t)
+			if tt.register {
+				m.Register("acquisition:pump", 0)
+			}
+			if tt.release {
+				m.Release("acquisition:pump")
+			}
+			if err := m.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer m.Stop()
+
+			deadline := time.Now().Add(200 * time.Millisecond)
+			for time.Now().Before(deadline) {
+				if tt.kick {
+					m.Kick("acquisition:pump")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if safe := actor.written() > 0; safe != tt.wantSafe {
+				t.Errorf("sicherer Zustand hergestellt: %v, erwartet %v", safe, tt.wantSafe)
+			}
+		})
+	}
+}
+
+// TestWatchdogRecovery prüft, dass der sichere Zustand einmal hergestellt und erst freigegeben
+// wird, wenn alle Loops den Watchdog wieder bedienen
+func TestWatchdogRecovery(t *testing.T) {
+	m, actor := newTestManager(t)
+	holder := &testHolder{released: make(chan string, 1)}
+	m.SetHolder(holder)
+
+	m.Register("acquisition:pump", 0)
+	m.Register("controller:ph_control", 0)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	time.Sleep(150 * time.Millisecond)
+	if n := actor.written(); n != 1 {
+		t.Fatalf("%d Befehle, erwartet genau einen sicheren Zustand", n)
+	}
+
+	// Solange ein Loop überfällig bleibt, wird nicht freigegeben
+	m.Kick("acquisition:pump")
+	select {
+	case reason := <-holder.released:
+		t.Fatalf("freigegeben (%s), obwohl der Regler überfällig ist", reason)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	m.Kick("controller:ph_control")
+	select {
+	case reason := <-holder.released:
+		if reason != ReasonWatchdog {
+			t.Errorf("freigegebener Anlass %s, erwartet %s", reason, ReasonWatchdog)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("sicherer Zustand nicht freigegeben")
+	}
+}
+
+// TestShutdown prüft die Reihenfolge beim Beenden: Die Loops melden sich ab, der Watchdog wird
+// gestoppt, danach wird der sichere Zustand auch gegen eine aktive Verriegelung hergestellt
+func TestShutdown(t *testing.T) {
+	m, actor := newTestManager(t)
+	m.registry.SetCommandGuard(blockingGuard{})
+
+	m.Register("acquisition:pump", 0)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loops werden vor dem Failsafe-Manager gestoppt und lösen den Watchdog nicht aus
+	m.Release("acquisition:pump")
+	m.Stop()
+	time.Sleep(100 * time.Millisecond)
+	if n := actor.written(); n != 0 {
+		t.Fatalf("%d Befehle nach dem Stoppen der Loops, erwartet keine", n)
+	}
+
+	// Ein normaler Befehl scheitert an der Verriegelung, der sichere Zustand nicht
+	blocked := m.commands.Submit(command.Request{
+		DeviceID: "pump",
+		Command:  types.NewCommand(types.CommandTypeSetState, true),
+		Origin:   command.OriginController,
+	})
+	if blocked.Succeeded() {
+		t.Fatalf("Befehl trotz Verriegelung ausgeführt")
+	}
+
+	records := m.Apply(ReasonShutdown)
+	if len(records) != 1 || !records[0].Succeeded() {
+		t.Fatalf("sicherer Zustand beim Beenden %+v, erwartet erfolgreich", records)
+	}
+	if state, _ := actor.GetState(); state != false {
+		t.Errorf("Zustand %v, erwartet false", state)
+	}
+}
@@ -84,6 +84,7 @@ func (c *Client) Connect() error {
 	// OnConnect Handler
 	opts.OnConnect = func(client mqtt.Client) {
 		c.Logger.Printf("Verbunden mit ThingsBoard MQTT (Client-ID: %s)", clientID)
+		c.publishConnectionState(true, nil)
 
 		// Mit Verzögerung abonnieren
 		go func() {
@@ -95,6 +96,7 @@ func (c *Client) Connect() error {
 	// OnConnectionLost Handler
 	opts.OnConnectionLost = func(client mqtt.Client, err error) {
 		c.Logger.Printf("Verbindung zu ThingsBoard verloren: %v", err)
+		c.publishConnectionState(false, err)
 	}
 
 	// OnReconnecting Handler
@@ -121,6 +123,7 @@ func (c *Client) IsConnected() bool {
 // Start beginnt das Senden von Daten aus dem Datenkanal.
 func (c *Client) Start() {
 	c.Logger.Println("ThingsBoard-Client-Datenverarbeitung wird gestartet...")
+	c.subscribeEvents()
 	go c.dataProcessingLoop()
 }
 
@@ -147,6 +150,15 @@ func (c *Client) processIncomingData(data map[string]interface{}) {
 		return
 	}
 
+	// Client-Attribute (z.B. Geräteidentitäten) veröffentlichen
+	if attributes, ok := data["attributes"].(map[string]interface{}); ok && len(attributes) > 0 {
+		if err := c.PublishAttributes(attributes); err != nil {
+			c.Logger.Printf("Fehler beim Veröffentlichen der Client-Attribute: %v", err)
+		} else {
+			c.Logger.Printf("Client-Attribute erfolgreich veröffentlicht: %v", attributes)
+		}
+	}
+
 	// Verarbeite unterschiedliche Datenformate
 	if simpleData, ok := data["simple"].(map[string]interface{}); ok && len(simpleData) > 0 {
 		if err := c.SendTelemetry(simpleData); err != nil {
@@ -206,6 +218,7 @@ func (c *Client) setupSubscriptions() {
 func (c *Client) Stop() {
 	c.Logger.Println("ThingsBoard-Client wird beendet...")
 	close(c.stopChan)
+	c.unsubscribeEvents()
 	if c.mqttClient != nil && c.getMQTTClient().IsConnected() {
 		c.getMQTTClient().Disconnect(250)
 	}
@@ -0,0 +1,116 @@
+package thingsboardMQTT
+
+import (
+	"fmt"
+
+	"owipex_reader/internal/event"
+)
+
+//
+// Event-Bus-Anbindung
+//
+
+// eventComponent ist die Quelle der Ereignisse dieses Clients auf dem Event-Bus
+const eventComponent = "thingsboard"
+
+// eventSubscriberID ist die ID des Clients als Abonnent auf dem Event-Bus
+const eventSubscriberID = "thingsboard_client"
+
+// WithEventBus verbindet den Client mit dem internen Event-Bus. Der Client veröffentlicht
+// Verbindungszustände und RPC-Befehle und sendet Lesefehler, Alarme und Verbindungszustände
+// anderer Komponenten als Telemetrie an ThingsBoard.
+func WithEventBus(bus *event.Bus) ClientOption {
+	return func(c *Client) {
+		c.eventBus = bus
+	}
+}
+
+// publishEvent veröffentlicht ein Ereignis, sofern ein Event-Bus gesetzt ist
+func (c *Client) publishEvent(e event.Event) {
+	if c.eventBus != nil {
+		c.eventBus.Publish(e)
+	}
+}
+
+// publishConnectionState veröffentlicht den Verbindungszustand zu ThingsBoard
+func (c *Client) publishConnectionState(connected bool, err error) {
+	state := "disconnected"
+	if connected {
+		state = "connected"
+	}
+
+	c.publishEvent(event.NewConnectionStateEvent(event.ConnectionState{
+		Component: eventComponent,
+		Connected: connected,
+		State:     state,
+		Err:       err,
+	}))
+}
+
+// subscribeEvents abonniert die Ereignisse, die an ThingsBoard weitergeleitet werden
+func (c *Client) subscribeEvents() {
+	if c.eventBus == nil {
+		return
+	}
+
+	err := c.eventBus.Subscribe(eventSubscriberID, c.handleBusEvent, event.SubscriberOptions{},
+		event.TopicReadFailed, event.TopicAlarm, event.TopicConnectionState)
+	if err != nil {
+		c.Logger.Printf("Fehler beim Abonnieren des Event-Bus: %v", err)
+	}
+}
+
+// unsubscribeEvents beendet das Abonnement auf dem Event-Bus
+func (c *Client) unsubscribeEvents() {
+	if c.eventBus != nil {
+		c.eventBus.Unsubscribe(eventSubscriberID)
+	}
+}
+
+// handleBusEvent wandelt Ereignisse vom Event-Bus in Telemetrie um
+func (c *Client) handleBusEvent(e event.Event) {
+	var telemetry map[string]interface{}
+
+	switch payload := e.Payload.(type) {
+	case event.ReadFailed:
+		message := "unbekannter Fehler"
+		if payload.Err != nil {
+			message = payload.Err.Error()
+		}
+		telemetry = map[string]interface{}{
+			fmt.Sprintf("%s_error", payload.DeviceID): message,
+		}
+
+	case event.Alarm:
+		prefix := payload.Name
+		if payload.DeviceID != "" {
+			prefix = fmt.Sprintf("%s_alarm_%s", payload.DeviceID, payload.Name)
+		}
+		telemetry = map[string]interface{}{
+			prefix:                             payload.Active,
+			fmt.Sprintf("%s_severity", prefix): string(payload.Severity),
+			fmt.Sprintf("%s_message", prefix):  payload.Message,
+		}
+
+	case event.ConnectionState:
+		// Eigene Verbindungszustände nicht zurückmelden
+		if payload.Component == eventComponent {
+			return
+		}
+		telemetry = map[string]interface{}{
+			fmt.Sprintf("%s_connection_state", payload.Component): payload.State,
+		}
+
+	default:
+		return
+	}
+
+	if !c.IsConnected() {
+		c.Logger.Printf("Kann Ereignis %s von %s nicht senden - Client nicht verbunden", e.Topic, e.Source)
+		return
+	}
+
+	if err := c.SendTelemetry(telemetry); err != nil {
+		c.Logger.Printf("Fehler beim Senden des Ereignisses %s von %s: %v", e.Topic, e.Source, err)
+	}
+}
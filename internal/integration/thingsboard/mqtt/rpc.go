@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"owipex_reader/internal/event"
+
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
@@ -47,12 +49,34 @@ func (c *Client) handleRPCRequest(client mqtt.Client, msg mqtt.Message) {
 		return
 	}
 
+	// Befehl auf dem Event-Bus melden
+	commandID := fmt.Sprintf("rpc-%s", requestID)
+	deviceID, _ := params["device_id"].(string)
+	c.publishEvent(event.NewCommandIssuedEvent(event.CommandIssued{
+		CommandID: commandID,
+		DeviceID:  deviceID,
+		Method:    method,
+		Params:    params,
+		Origin:    eventComponent,
+	}))
+
 	// RPC-Callback aufrufen, wenn gesetzt
 	var response interface{}
 	var err error
 
 	if c.rpcCallback != nil {
 		response, err = c.rpcCallback(method, params)
+
+		// Ergebnis des Befehls auf dem Event-Bus melden
+		c.publishEvent(event.NewCommandAcknowledgedEvent(event.CommandAcknowledged{
+			CommandID: commandID,
+			DeviceID:  deviceID,
+			Method:    method,
+			Success:   err == nil,
+			Result:    response,
+			Err:       err,
+		}, eventComponent))
+
 		if err != nil {
 			response = map[string]interface{}{
 				"success": false,
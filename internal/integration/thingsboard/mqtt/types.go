@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"owipex_reader/internal/config"
+	"owipex_reader/internal/event"
 )
 
 // Client ist die Hauptschnittstelle für die ThingsBoard-Kommunikation.
@@ -19,6 +20,7 @@ type Client struct {
 	mqttClient interface{} // Tatsächlicher Typ: mqtt.Client
 	stopChan   chan struct{}
 	dataChan   <-chan map[string]interface{}
+	eventBus   *event.Bus
 
 	// Lokale Caches
 	sharedAttributes map[string]interface{}
@@ -0,0 +1,490 @@
+package interlock
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/types"
+)
+
+const (
+	// subscriberID ist die Kennung der Engine auf dem Event-Bus
+	subscriberID = "interlock.engine"
+
+	// evaluateInterval ist das Intervall, in dem die Verriegelungen neu bewertet werden,
+	// damit veraltete Messwerte und Verbindungswechsel auch ohne neue Messwerte wirken
+	evaluateInterval = 5 * time.Second
+
+	// alarmPrefix ist das Präfix der Alarme aktiver Verriegelungen
+	alarmPrefix = "interlock_"
+)
+
+// BlockedError wird zurückgegeben, wenn eine Verriegelung einen Befehl verhindert
+type BlockedError struct {
+	DeviceID  string
+	Command   types.CommandType
+	Interlock string
+	Reason    string
+}
+
+// Error implementiert das error-Interface
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("befehl %s an %s durch Verriegelung '%s' gesperrt: %s", e.Command, e.DeviceID, e.Interlock, e.Reason)
+}
+
+// Status ist der Zustand einer Verriegelung. Active ist gesetzt, solange eine Bedingung nicht
+// erfüllt ist und die Verriegelung Befehle an die geschützten Geräte sperrt.
+type Status struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Devices     []string `json:"devices"`
+	Active      bool     `json:"active"`
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// Enforcer setzt aktive Verriegelungen an bereits laufenden Geräten durch. Die Anwendung
+// verbindet ihn mit dem Befehlsmanager, damit Abschaltungen wie alle Befehle protokolliert
+// und arbitriert werden.
+type Enforcer interface {
+	// Shutdown schaltet ein geschütztes Gerät aus, reason ist der Name des Alarms der Verriegelung
+	Shutdown(deviceID string, command types.Command, reason string) error
+
+	// Release gibt die Geräte nach dem Aufheben der Verriegelung wieder frei
+	Release(reason string, deviceIDs ...string)
+}
+
+// Engine prüft Steuerbefehle gegen die konfigurierten Verriegelungen. Sie implementiert
+// device.CommandGuard und wird mit SetCommandGuard an der Registry angemeldet.
+type Engine struct {
+	rules    []Rule
+	registry *device.Registry
+	bus      *event.Bus
+	logger   *log.Logger
+
+	samples *event.SampleCache
+
+	mutex    sync.RWMutex
+	active   map[string]string
+	enforcer Enforcer
+
+	// evaluateMutex serialisiert Bewertungen, damit Wechsel genau einmal gemeldet werden
+	evaluateMutex sync.Mutex
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewEngine erstellt eine Engine aus den Verriegelungen der Anwendungskonfiguration.
+// Ungültige Verriegelungen führen zu einem Fehler, damit keine Sicherung unbemerkt fehlt.
+func NewEngine(configs []config.InterlockConfig, registry *device.Registry) (*Engine, error) {
+	rules, errs := ParseRules(configs)
+	if len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return nil, fmt.Errorf("ungültige Verriegelungen: %s", strings.Join(messages, "; "))
+	}
+
+	return &Engine{
+		rules:    rules,
+		registry: registry,
+		bus:      registry.EventBus(),
+		logger:   log.New(os.Stdout, "[Interlock] ", log.LstdFlags),
+		samples:  event.NewSampleCache(),
+		active:   make(map[string]string),
+		stopChan: make(chan struct{}),
+	}, nil
+}
+
+// Rules gibt die geprüften Verriegelungen zurück
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// SetEnforcer legt fest, wer laufende Geräte bei Aktivierung einer Verriegelung ausschaltet.
+// Ohne Enforcer werden nur neue Befehle gesperrt.
+func (e *Engine) SetEnforcer(enforcer Enforcer) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.enforcer = enforcer
+}
+
+// Start abonniert die Messwerte auf dem Event-Bus und bewertet die Verriegelungen zyklisch
+func (e *Engine) Start() error {
+	// Ohne Begrenzung, damit kein Messwert einer Verriegelung verworfen wird
+	options := event.SubscriberOptions{Overflow: event.OverflowUnbounded}
+	if err := e.bus.Subscribe(subscriberID, e.handleReading, options, event.TopicReadingProduced); err != nil {
+		return fmt.Errorf("fehler beim Abonnieren der Messwerte: %w", err)
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(evaluateInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.stopChan:
+				return
+			case <-ticker.C:
+				e.Evaluate()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop beendet die zyklische Bewertung und das Abonnement
+func (e *Engine) Stop() {
+	close(e.stopChan)
+	e.wg.Wait()
+	e.bus.Unsubscribe(subscriberID)
+}
+
+// handleReading übernimmt neue Messwerte und Aktorzustände und bewertet die Verriegelungen
+func (e *Engine) handleReading(ev event.Event) {
+	e.samples.Handle(ev)
+	e.Evaluate()
+}
+
+// CheckCommand prüft einen Befehl gegen alle Verriegelungen des Geräts (device.CommandGuard).
+// Befehle, die ein Gerät ausschalten, werden nie gesperrt.
+func (e *Engine) CheckCommand(deviceID string, command types.Command) error {
+	if IsShutdown(command) {
+		return nil
+	}
+
+	for _, rule := range e.rules {
+		if !rule.appliesTo(deviceID, command.Type) {
+			continue
+		}
+
+		if ok, reason := e.evaluateRule(rule); !ok {
+			e.logger.Printf("Befehl %s an %s gesperrt durch %s: %s", command.Type, deviceID, rule.Name, reason)
+			return &BlockedError{DeviceID: deviceID, Command: command.Type, Interlock: rule.Name, Reason: reason}
+		}
+	}
+
+	return nil
+}
+
+// CommandExecuted übernimmt den befohlenen Zustand eines Geräts, damit nachfolgende Befehle
+// nicht bis zum nächsten Zurücklesen auf einem veralteten Zustand geprüft werden (device.CommandObserver)
+func (e *Engine) CommandExecuted(deviceID string, command types.Command) {
+	if command.Type != types.CommandTypeSetState && command.Type != types.CommandTypeSetPosition {
+		return
+	}
+	running := !IsShutdown(command)
+
+	// Positionsbefehle ändern nur, ob der Aktor läuft; die Stellung folgt beim Zurücklesen
+	var value interface{} = running
+	if command.Type == types.CommandTypeSetPosition {
+		value = nil
+		if previous, exists := e.samples.State(deviceID); exists {
+			value = previous.Reading.Value
+		}
+	}
+
+	reading := types.NewReading(types.ReadingTypeState, value, "", nil)
+	reading.Metadata[types.MetadataRunning] = running
+	e.samples.Put(deviceID, event.Sample{Reading: reading, At: time.Now()})
+}
+
+// Evaluate bewertet alle Verriegelungen und meldet Wechsel als Alarm auf dem Event-Bus. Wird
+// eine Verriegelung aktiv, werden laufende geschützte Geräte ausgeschaltet, wird sie
+// aufgehoben, werden sie wieder freigegeben.
+func (e *Engine) Evaluate() []Status {
+	e.evaluateMutex.Lock()
+	defer e.evaluateMutex.Unlock()
+
+	statuses := make([]Status, 0, len(e.rules))
+	var changed []Status
+	var changedRules []Rule
+
+	for _, rule := range e.rules {
+		ok, reason := e.evaluateRule(rule)
+		status := Status{
+			Name:        rule.Name,
+			Description: rule.Description,
+			Devices:     rule.Devices,
+			Active:      !ok,
+			Reason:      reason,
+		}
+		statuses = append(statuses, status)
+
+		e.mutex.Lock()
+		_, wasActive := e.active[rule.Name]
+		if status.Active {
+			e.active[rule.Name] = reason
+		} else {
+			delete(e.active, rule.Name)
+		}
+		e.mutex.Unlock()
+
+		// Nur Wechsel melden, der Grund enthält aktuelle Werte und ändert sich laufend
+		if status.Active != wasActive {
+			changed = append(changed, status)
+			changedRules = append(changedRules, rule)
+		}
+	}
+
+	for i, status := range changed {
+		alarm := event.Alarm{
+			Name:     alarmPrefix + status.Name,
+			Severity: event.SeverityWarning,
+			Active:   status.Active,
+			Message:  status.Reason,
+			Value:    status.Devices,
+		}
+		if !status.Active {
+			alarm.Message = "alle Bedingungen erfüllt"
+		}
+		e.bus.Publish(event.NewAlarmEvent(alarm))
+
+		if status.Active {
+			e.enforce(changedRules[i])
+		} else {
+			e.release(changedRules[i])
+		}
+	}
+
+	return statuses
+}
+
+// enforce schaltet die laufenden Geräte einer aktiv gewordenen Verriegelung aus. Die Befehle
+// laufen im Hintergrund, da der Befehlsmanager auf die Bestätigung der Geräte wartet.
+func (e *Engine) enforce(rule Rule) {
+	e.mutex.RLock()
+	enforcer := e.enforcer
+	e.mutex.RUnlock()
+	if enforcer == nil {
+		return
+	}
+
+	reason := alarmPrefix + rule.Name
+	command := rule.shutdownCommand()
+	for _, deviceID := range rule.Devices {
+		if !e.running(deviceID) {
+			continue
+		}
+
+		e.logger.Printf("Verriegelung %s aktiv, schalte laufendes Gerät %s aus", rule.Name, deviceID)
+		go func(deviceID string) {
+			if err := enforcer.Shutdown(deviceID, command, reason); err != nil {
+				e.logger.Printf("Fehler beim Ausschalten von %s durch Verriegelung %s: %v", deviceID, rule.Name, err)
+			}
+
+			// Wurde die Verriegelung während der Abschaltung aufgehoben, gibt es keine
+			// spätere Freigabe mehr
+			e.mutex.RLock()
+			_, active := e.active[rule.Name]
+			e.mutex.RUnlock()
+			if !active {
+				enforcer.Release(reason, deviceID)
+			}
+		}(deviceID)
+	}
+}
+
+// release gibt die Geräte einer aufgehobenen Verriegelung wieder frei
+func (e *Engine) release(rule Rule) {
+	e.mutex.RLock()
+	enforcer := e.enforcer
+	e.mutex.RUnlock()
+	if enforcer == nil {
+		return
+	}
+
+	enforcer.Release(alarmPrefix+rule.Name, rule.Devices...)
+}
+
+// running prüft, ob ein Gerät nach dem zuletzt bekannten Zustand läuft (types.MetadataRunning).
+// Geräte ohne bekannten Zustand gelten nicht als laufend.
+func (e *Engine) running(deviceID string) bool {
+	state, exists := e.samples.State(deviceID)
+	if !exists {
+		return false
+	}
+	running, _ := state.Reading.Metadata[types.MetadataRunning].(bool)
+	return running
+}
+
+// Status gibt den zuletzt bewerteten Zustand aller Verriegelungen zurück, sortiert nach Name
+func (e *Engine) Status() []Status {
+	statuses := e.Evaluate()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// evaluateRule prüft alle Bedingungen einer Verriegelung. reason beschreibt die erste nicht
+// erfüllte Bedingung.
+func (e *Engine) evaluateRule(rule Rule) (ok bool, reason string) {
+	for _, condition := range rule.Conditions {
+		if ok, reason := e.evaluateCondition(condition, rule.MaxAge); !ok {
+			return false, reason
+		}
+	}
+	return true, ""
+}
+
+// evaluateCondition prüft eine Bedingung. Fehlende oder veraltete Werte gelten als nicht erfüllt.
+func (e *Engine) evaluateCondition(condition Condition, maxAge time.Duration) (bool, string) {
+	if condition.Connectivity != "" {
+		status, err := e.registry.GetConnectivity(condition.Device)
+		if err != nil {
+			return false, fmt.Sprintf("gerät %s nicht vorhanden", condition.Device)
+		}
+
+		state := string(status.State)
+		if state == condition.Connectivity || (condition.Connectivity == connectivityConnected && status.Connected()) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s nicht erfüllt (Zustand %s)", condition, state)
+	}
+
+	value, age, found := e.lookup(condition)
+	if !found {
+		return false, fmt.Sprintf("%s nicht erfüllt (kein Wert von %s)", condition, condition.Device)
+	}
+	if age > maxAge {
+		return false, fmt.Sprintf("%s nicht erfüllt (Wert von %s ist %s alt)", condition, condition.Device, age.Round(time.Second))
+	}
+
+	if !Compare(value, condition.Operator, condition.Value) {
+		return false, fmt.Sprintf("%s nicht erfüllt (aktuell %v)", condition, value)
+	}
+	return true, ""
+}
+
+// lookup sucht den Wert einer Bedingung. Ohne Feldangabe wird der letzte Messwert verwendet,
+// bei Aktoren ohne Messwerte der Zustand. Das Feld state ist bei Aktoren, die melden, ob sie
+// laufen, dieser Wert (true/false), andere Felder werden in den Metadaten des Messwerts und
+// im Zustand des Aktors (z.B. direction eines Umrichters) gesucht.
+func (e *Engine) lookup(condition Condition) (interface{}, time.Duration, bool) {
+	reading, readingExists := e.samples.Reading(condition.Device)
+	state, stateExists := e.samples.State(condition.Device)
+
+	switch condition.Field {
+	case "", FieldValue:
+		if readingExists {
+			return reading.Reading.Value, time.Since(reading.At), true
+		}
+		if stateExists {
+			return state.Reading.Value, time.Since(state.At), true
+		}
+	case FieldState:
+		if stateExists {
+			if running, ok := state.Reading.Metadata[types.MetadataRunning].(bool); ok {
+				return running, time.Since(state.At), true
+			}
+			return state.Reading.Value, time.Since(state.At), true
+		}
+	default:
+		if readingExists {
+			if value, ok := reading.Reading.Metadata[condition.Field]; ok {
+				return value, time.Since(reading.At), true
+			}
+		}
+		if stateExists {
+			if values, ok := state.Reading.Value.(map[string]interface{}); ok {
+				if value, ok := values[condition.Field]; ok {
+					return value, time.Since(state.At), true
+				}
+			}
+		}
+	}
+
+	return nil, 0, false
+}
+
+// Compare vergleicht einen Wert mit dem Vergleichswert einer Bedingung. Zahlen werden numerisch
+// verglichen, andere Werte (z.B. Zustände wie true oder "forward") nur auf Gleichheit.
+func Compare(actual interface{}, operator string, expected interface{}) bool {
+	actualNumber, actualIsNumber := toFloat(actual)
+	expectedNumber, expectedIsNumber := toFloat(expected)
+
+	if actualIsNumber && expectedIsNumber {
+		switch operator {
+		case ">":
+			return actualNumber > expectedNumber
+		case ">=":
+			return actualNumber >= expectedNumber
+		case "<":
+			return actualNumber < expectedNumber
+		case "<=":
+			return actualNumber <= expectedNumber
+		case "==":
+			return actualNumber == expectedNumber
+		case "!=":
+			return actualNumber != expectedNumber
+		}
+		return false
+	}
+
+	// Zustände wie true/false oder "forward" werden auf Gleichheit geprüft
+	equal := fmt.Sprint(actual) == fmt.Sprint(expected)
+	switch operator {
+	case "==":
+		return equal
+	case "!=":
+		return !equal
+	default:
+		return false
+	}
+}
+
+// IsShutdown prüft, ob ein Befehl ein Gerät ausschaltet bzw. in den sicheren Zustand bringt
+// (SET_STATE mit false/0/"off"/"stop"/"aus", SET_POSITION 0). Solche Befehle werden nie gesperrt.
+func IsShutdown(command types.Command) bool {
+	switch command.Type {
+	case types.CommandTypeSetState:
+		switch v := command.Value.(type) {
+		case bool:
+			return !v
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "off", "stop", "aus", "false", "0", "close":
+				return true
+			}
+		default:
+			if number, ok := toFloat(v); ok {
+				return number == 0
+			}
+		}
+	case types.CommandTypeSetPosition:
+		if number, ok := toFloat(command.Value); ok {
+			return number == 0
+		}
+	}
+	return false
+}
+
+// toFloat wandelt Zahlenwerte um
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
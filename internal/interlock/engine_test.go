@@ -0,0 +1,288 @@
+package interlock
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/device/actuator/relay"
+	"owipex_reader/internal/device/actuator/valve"
+	"owipex_reader/internal/device/actuator/vfd"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/types"
+)
+
+// testDevice ist ein Gerät ohne Kommunikation, dessen Verbindungszustand der Test vorgibt
+type testDevice struct {
+	id string
+}
+
+// ID gibt die Kennung des Testgeräts zurück
+func (d *testDevice) ID() string { return d.id }
+
+// Name gibt die Kennung als Anzeigenamen zurück
+func (d *testDevice) Name() string { return d.id }
+
+// Type gibt den Gerätetyp zurück
+func (d *testDevice) Type() types.DeviceType { return types.TypeSensor }
+
+// Metadata gibt keine Metadaten zurück
+func (d *testDevice) Metadata() map[string]interface{} { return nil }
+
+// IsEnabled meldet das Gerät als aktiviert
+func (d *testDevice) IsEnabled() bool { return true }
+
+// Enable wird ignoriert
+func (d *testDevice) Enable(bool) {}
+
+// Close wird ignoriert
+func (d *testDevice) Close() error { return nil }
+
+// testEnforcer zeichnet die Abschaltungen auf
+type testEnforcer struct {
+	mutex    sync.Mutex
+	shutdown []string
+	done     chan struct{}
+}
+
+// Shutdown merkt das ausgeschaltete Gerät
+func (e *testEnforcer) Shutdown(deviceID string, command types.Command, reason string) error {
+	e.mutex.Lock()
+	e.shutdown = append(e.shutdown, deviceID)
+	e.mutex.Unlock()
+	e.done <- struct{}{}
+	return nil
+}
+
+// Release wird ignoriert
+func (e *testEnforcer) Release(reason string, deviceIDs ...string) {}
+
+// newTestEngine erstellt eine Engine mit dem Sensor tank in der Registry
+func newTestEngine(t *testing.T, configs []config.InterlockConfig) (*Engine, *device.Registry) {
+	t.Helper()
+
+	registry := device.NewRegistry()
+	if err := registry.AddDevice(&testDevice{id: "tank"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(registry.Close)
+
+	e, err := NewEngine(configs, registry)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	return e, registry
+}
+
+// measured erstellt einen Messwert mit Metadaten, der age alt ist
+func measured(value interface{}, metadata map[string]interface{}, age time.Duration) event.Sample {
+	reading := types.NewReading(types.ReadingTypeLevel, value, "", nil)
+	for key, v := range metadata {
+		reading.Metadata[key] = v
+	}
+	return event.Sample{Reading: reading, At: time.Now().Add(-age)}
+}
+
+// actorState erstellt einen Zustandsmesswert, wie ihn der SensorAdapter für einen Aktor meldet
+func actorState(reporter types.RunningReporter, state interface{}) event.Sample {
+	reading := types.NewReading(types.ReadingTypeState, state, "", nil)
+	reading.Metadata[types.MetadataRunning] = reporter.Running(state)
+	return event.Sample{Reading: reading, At: time.Now()}
+}
+
+// TestEvaluateCondition prüft jede Art von Bedingung mit erfüllten, nicht erfüllten, fehlenden
+// und veralteten Werten
+func TestEvaluateCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition Condition
+		reading   *event.Sample
+		state     *event.Sample
+		results   []error
+		want      bool
+	}{
+		{name: "größer erfüllt", condition: Condition{Device: "tank", Operator: ">", Value: 200.0}, reading: samplePtr(measured(250.0, nil, 0)), want: true},
+		{name: "größer nicht erfüllt", condition: Condition{Device: "tank", Operator: ">", Value: 200.0}, reading: samplePtr(measured(150.0, nil, 0))},
+		{name: "größer gleich", condition: Condition{Device: "tank", Operator: ">=", Value: 200.0}, reading: samplePtr(measured(200.0, nil, 0)), want: true},
+		{name: "kleiner", condition: Condition{Device: "tank", Operator: "<", Value: 200.0}, reading: samplePtr(measured(200.0, nil, 0))},
+		{name: "kleiner gleich", condition: Condition{Device: "tank", Operator: "<=", Value: 200}, reading: samplePtr(measured(200.0, nil, 0)), want: true},
+		{name: "gleich", condition: Condition{Device: "tank", Operator: "==", Value: 7.0}, reading: samplePtr(measured(7.0, nil, 0)), want: true},
+		{name: "ungleich", condition: Condition{Device: "tank", Operator: "!=", Value: 7.0}, reading: samplePtr(measured(7.0, nil, 0))},
+		{
+			name:      "Feld aus Metadaten",
+			condition: Condition{Device: "tank", Field: "temperature", Operator: "<", Value: 40.0},
+			reading:   samplePtr(measured(250.0, map[string]interface{}{"temperature": 30.0}, 0)),
+			want:      true,
+		},
+		{name: "kein Wert", condition: Condition{Device: "tank", Operator: ">", Value: 200.0}},
+		{
+			name:      "veralteter Wert",
+			condition: Condition{Device: "tank", Operator: ">", Value: 200.0},
+			reading:   samplePtr(measured(250.0, nil, 10*time.Minute)),
+		},
+		{
+			name:      "Zustand eines Relais",
+			condition: Condition{Device: "tank", Field: FieldState, Operator: "==", Value: true},
+			state:     samplePtr(actorState(&relay.RelayActuator{}, true)),
+			want:      true,
+		},
+		{
+			name:      "Umrichter läuft",
+			condition: Condition{Device: "tank", Field: FieldState, Operator: "==", Value: true},
+			state:     samplePtr(actorState(&vfd.VFDActuator{}, map[string]interface{}{"output_frequency": 30.0, "running": true, "direction": "forward"})),
+			want:      true,
+		},
+		{
+			name:      "Umrichter steht",
+			condition: Condition{Device: "tank", Field: FieldState, Operator: "==", Value: true},
+			state:     samplePtr(actorState(&vfd.VFDActuator{}, map[string]interface{}{"output_frequency": 0.0, "running": false})),
+		},
+		{
+			name:      "Drehrichtung des Umrichters",
+			condition: Condition{Device: "tank", Field: "direction", Operator: "==", Value: "forward"},
+			state:     samplePtr(actorState(&vfd.VFDActuator{}, map[string]interface{}{"output_frequency": 30.0, "running": true, "direction": "forward"})),
+			want:      true,
+		},
+		{
+			name:      "Stellung eines Ventils",
+			condition: Condition{Device: "tank", Field: "position", Operator: ">", Value: 10.0},
+			state:     samplePtr(actorState(&valve.ValveActuator{}, map[string]interface{}{"position": 0.0, "moving": false})),
+		},
+		{name: "Verbindung online", condition: Condition{Device: "tank", Connectivity: string(device.StateOnline)}, results: []error{nil}, want: true},
+		{name: "Verbindung vor dem ersten Lesen", condition: Condition{Device: "tank", Connectivity: connectivityConnected}},
+		{
+			name:      "gestört gilt als verbunden",
+			condition: Condition{Device: "tank", Connectivity: connectivityConnected},
+			results:   []error{nil, errors.New("zeitüberschreitung")},
+			want:      true,
+		},
+		{
+			name:      "offline",
+			condition: Condition{Device: "tank", Connectivity: connectivityConnected},
+			results:   []error{errors.New("zeitüberschreitung"), errors.New("zeitüberschreitung"), errors.New("zeitüberschreitung")},
+		},
+		{
+			name:      "offline erwartet",
+			condition: Condition{Device: "tank", Connectivity: string(device.StateOffline)},
+			results:   []error{errors.New("zeitüberschreitung"), errors.New("zeitüberschreitung"), errors.New("zeitüberschreitung")},
+			want:      true,
+		},
+		{name: "unbekanntes Gerät", condition: Condition{Device: "missing", Connectivity: connectivityConnected}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, registry := newTestEngine(t, nil)
+			if tt.reading != nil {
+				e.samples.Put("tank", *tt.reading)
+			}
+			if tt.state != nil {
+				e.samples.Put("tank", *tt.state)
+			}
+			for _, result := range tt.results {
+				if _, _, err := registry.RecordResult("tank", result); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ok, reason := e.evaluateCondition(tt.condition, 5*time.Minute)
+			if ok != tt.want {
+				t.Errorf("%s = %v (%s), erwartet %v", tt.condition, ok, reason, tt.want)
+			}
+			if !ok && reason == "" {
+				t.Errorf("nicht erfüllte Bedingung ohne Grund")
+			}
+		})
+	}
+}
+
+// TestRunning prüft, welche Aktorzustände als laufend gelten
+func TestRunning(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   *event.Sample
+		command *types.Command
+		want    bool
+	}{
+		{name: "kein Zustand"},
+		{name: "Relais ein", state: samplePtr(actorState(&relay.RelayActuator{}, true)), want: true},
+		{name: "Relais aus", state: samplePtr(actorState(&relay.RelayActuator{}, false))},
+		{name: "Ventil geschlossen", state: samplePtr(actorState(&valve.ValveActuator{}, map[string]interface{}{"position": 0.0, "moving": false}))},
+		{name: "Ventil offen", state: samplePtr(actorState(&valve.ValveActuator{}, map[string]interface{}{"position": 40.0, "moving": false})), want: true},
+		{name: "Ventil fährt auf", state: samplePtr(actorState(&valve.ValveActuator{}, map[string]interface{}{"position": 0.0, "moving": true})), want: true},
+		{name: "Ventilstellung unbekannt", state: samplePtr(actorState(&valve.ValveActuator{}, map[string]interface{}{"position": nil, "moving": false})), want: true},
+		{name: "Umrichter dreht", state: samplePtr(actorState(&vfd.VFDActuator{}, map[string]interface{}{"output_frequency": 30.0, "running": true})), want: true},
+		{name: "Umrichter steht", state: samplePtr(actorState(&vfd.VFDActuator{}, map[string]interface{}{"output_frequency": 0.0, "running": false}))},
+		{
+			// Zustand ohne Angabe, ob der Aktor läuft
+			name:  "Zustand ohne Laufmeldung",
+			state: &event.Sample{Reading: types.NewReading(types.ReadingTypeState, map[string]interface{}{"output_frequency": 0.0}, "", nil), At: time.Now()},
+		},
+		{name: "eingeschaltet", command: &types.Command{Type: types.CommandTypeSetState, Value: "forward"}, want: true},
+		{name: "ausgeschaltet", command: &types.Command{Type: types.CommandTypeSetState, Value: "stop"}},
+		{name: "Ventil auf Stellung gefahren", command: &types.Command{Type: types.CommandTypeSetPosition, Value: 50.0}, want: true},
+		{name: "Ventil geschlossen gefahren", command: &types.Command{Type: types.CommandTypeSetPosition, Value: 0.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := newTestEngine(t, nil)
+			if tt.state != nil {
+				e.samples.Put("pump", *tt.state)
+			}
+			if tt.command != nil {
+				e.CommandExecuted("pump", *tt.command)
+			}
+
+			if got := e.running("pump"); got != tt.want {
+				t.Errorf("running = %v, erwartet %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEnforce prüft, dass eine aktiv werdende Verriegelung nur laufende Geräte ausschaltet
+func TestEnforce(t *testing.T) {
+	e, _ := newTestEngine(t, []config.InterlockConfig{{
+		Name:       "dry_run",
+		Devices:    []string{"pump_vfd", "inlet_valve", "dosing_relay"},
+		Conditions: []config.InterlockCondition{{Device: "tank", Operator: ">", Value: 200.0}},
+	}})
+	enforcer := &testEnforcer{done: make(chan struct{}, 3)}
+	e.SetEnforcer(enforcer)
+
+	e.samples.Put("tank", measured(100.0, nil, 0))
+	e.samples.Put("pump_vfd", actorState(&vfd.VFDActuator{}, map[string]interface{}{"output_frequency": 30.0, "running": true}))
+	e.samples.Put("inlet_valve", actorState(&valve.ValveActuator{}, map[string]interface{}{"position": 0.0, "moving": false}))
+	e.samples.Put("dosing_relay", actorState(&relay.RelayActuator{}, false))
+
+	statuses := e.Evaluate()
+	if len(statuses) != 1 || !statuses[0].Active {
+		t.Fatalf("Status %+v, erwartet aktive Verriegelung", statuses)
+	}
+
+	select {
+	case <-enforcer.done:
+	case <-time.After(time.Second):
+		t.Fatal("laufendes Gerät nicht ausgeschaltet")
+	}
+	select {
+	case <-enforcer.done:
+		t.Fatal("stehendes Gerät ausgeschaltet")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	enforcer.mutex.Lock()
+	defer enforcer.mutex.Unlock()
+	if len(enforcer.shutdown) != 1 || enforcer.shutdown[0] != "pump_vfd" {
+		t.Errorf("ausgeschaltet %v, erwartet [pump_vfd]", enforcer.shutdown)
+	}
+}
+
+// samplePtr gibt einen Zeiger auf einen Wert zurück
+func samplePtr(s event.Sample) *event.Sample {
+	return &s
+}
@@ -0,0 +1,235 @@
+// Package interlock implementiert Sicherheitsverriegelungen für Steuerbefehle. Verriegelungen
+// werden in der Anwendungskonfiguration über Messwerte, Aktorzustände und den Verbindungszustand
+// von Geräten beschrieben (z.B. "Pumpe darf nur laufen, wenn der Füllstand über 200 mm liegt")
+// und vor jedem Befehl an ein Gerät der Registry geprüft.
+package interlock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/types"
+)
+
+// Feldnamen einer Bedingung
+const (
+	FieldValue = "value"
+	FieldState = "state"
+)
+
+// DefaultMaxAge ist das Höchstalter eines Messwerts, der in einer Bedingung verwendet wird
+const DefaultMaxAge = 300 * time.Second
+
+// connectivityConnected fasst die erreichbaren Verbindungszustände online und degraded zusammen
+const connectivityConnected = "connected"
+
+// Operators sind die erlaubten Vergleichsoperatoren (auch für lokale Regeln)
+var Operators = []string{">", ">=", "<", "<=", "==", "!="}
+
+// connectivityStates sind die erlaubten Werte für Verbindungsbedingungen
+var connectivityStates = []string{
+	connectivityConnected,
+	string(device.StateOnline),
+	string(device.StateDegraded),
+	string(device.StateOffline),
+	string(device.StateMaintenance),
+	string(device.StateDisabled),
+	string(device.StateIdentityMismatch),
+	string(device.StateInitializing),
+}
+
+// Rule ist eine geprüfte Verriegelung
+type Rule struct {
+	Name        string
+	Description string
+	Devices     []string
+	Commands    []types.CommandType
+	Conditions  []Condition
+	MaxAge      time.Duration
+}
+
+// Condition ist eine geprüfte Bedingung einer Verriegelung
+type Condition struct {
+	Device       string
+	Field        string
+	Operator     string
+	Value        interface{}
+	Connectivity string
+}
+
+// String gibt die Bedingung lesbar aus (z.B. "tank_level > 200")
+func (c Condition) String() string {
+	if c.Connectivity != "" {
+		return fmt.Sprintf("%s ist %s", c.Device, c.Connectivity)
+	}
+
+	subject := c.Device
+	if c.Field != "" && c.Field != FieldValue {
+		subject += "." + c.Field
+	}
+	return fmt.Sprintf("%s %s %v", subject, c.Operator, c.Value)
+}
+
+// appliesTo prüft, ob die Verriegelung einen Befehl an ein Gerät betrifft
+func (r Rule) appliesTo(deviceID string, commandType types.CommandType) bool {
+	protected := false
+	for _, id := range r.Devices {
+		if id == deviceID {
+			protected = true
+			break
+		}
+	}
+	if !protected {
+		return false
+	}
+
+	if len(r.Commands) == 0 {
+		return true
+	}
+	for _, command := range r.Commands {
+		if command == commandType {
+			return true
+		}
+	}
+	return false
+}
+
+// shutdownCommand gibt den Befehl zurück, mit dem ein geschütztes Gerät bei Aktivierung der
+// Verriegelung ausgeschaltet wird: SET_POSITION 0, wenn nur Positionsbefehle gesperrt werden,
+// sonst SET_STATE false
+func (r Rule) shutdownCommand() types.Command {
+	setState, setPosition := len(r.Commands) == 0, false
+	for _, command := range r.Commands {
+		switch command {
+		case types.CommandTypeSetState:
+			setState = true
+		case types.CommandTypeSetPosition:
+			setPosition = true
+		}
+	}
+
+	if setPosition && !setState {
+		return types.Command{Type: types.CommandTypeSetPosition, Value: 0.0}
+	}
+	return types.Command{Type: types.CommandTypeSetState, Value: false}
+}
+
+// ParseRules prüft die Verriegelungen der Anwendungskonfiguration und wandelt sie um.
+// Alle Fehler werden gesammelt zurückgegeben, ungültige Verriegelungen werden ausgelassen.
+func ParseRules(configs []config.InterlockConfig) ([]Rule, []error) {
+	var rules []Rule
+	var errs []error
+	names := make(map[string]bool)
+
+	for i, cfg := range configs {
+		rule, err := parseRule(cfg)
+		if err == nil && names[rule.Name] {
+			err = fmt.Errorf("name ist bereits vergeben")
+		}
+		if err != nil {
+			label := cfg.Name
+			if label == "" {
+				label = fmt.Sprintf("#%d", i)
+			}
+			errs = append(errs, fmt.Errorf("verriegelung %s: %w", label, err))
+			continue
+		}
+
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+
+	return rules, errs
+}
+
+// parseRule prüft eine Verriegelung
+func parseRule(cfg config.InterlockConfig) (Rule, error) {
+	if cfg.Name == "" {
+		return Rule{}, fmt.Errorf("kein Name angegeben")
+	}
+	if len(cfg.Devices) == 0 {
+		return Rule{}, fmt.Errorf("keine geschützten Geräte (devices) angegeben")
+	}
+	if len(cfg.Conditions) == 0 {
+		return Rule{}, fmt.Errorf("keine Bedingungen (conditions) angegeben")
+	}
+	if cfg.MaxAgeSeconds < 0 {
+		return Rule{}, fmt.Errorf("max_age_seconds darf nicht negativ sein")
+	}
+
+	rule := Rule{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		Devices:     cfg.Devices,
+		MaxAge:      DefaultMaxAge,
+	}
+	if cfg.MaxAgeSeconds > 0 {
+		rule.MaxAge = time.Duration(cfg.MaxAgeSeconds) * time.Second
+	}
+
+	for _, command := range cfg.Commands {
+		rule.Commands = append(rule.Commands, types.CommandType(strings.ToUpper(command)))
+	}
+
+	for i, condition := range cfg.Conditions {
+		parsed, err := parseCondition(condition)
+		if err != nil {
+			return Rule{}, fmt.Errorf("bedingung %d: %w", i, err)
+		}
+		rule.Conditions = append(rule.Conditions, parsed)
+	}
+
+	return rule, nil
+}
+
+// parseCondition prüft eine Bedingung
+func parseCondition(cfg config.InterlockCondition) (Condition, error) {
+	condition := Condition{
+		Device:       cfg.Device,
+		Field:        cfg.Field,
+		Operator:     cfg.Operator,
+		Value:        cfg.Value,
+		Connectivity: strings.ToLower(cfg.Connectivity),
+	}
+
+	if condition.Device == "" {
+		return condition, fmt.Errorf("kein Gerät (device) angegeben")
+	}
+
+	if condition.Connectivity != "" {
+		if condition.Operator != "" || condition.Value != nil {
+			return condition, fmt.Errorf("connectivity kann nicht mit operator/value kombiniert werden")
+		}
+		if !contains(connectivityStates, condition.Connectivity) {
+			return condition, fmt.Errorf("unbekannter Verbindungszustand %q (erlaubt: %s)", cfg.Connectivity, strings.Join(connectivityStates, ", "))
+		}
+		return condition, nil
+	}
+
+	if !contains(Operators, condition.Operator) {
+		return condition, fmt.Errorf("unbekannter Operator %q (erlaubt: %s)", cfg.Operator, strings.Join(Operators, " "))
+	}
+	if condition.Value == nil {
+		return condition, fmt.Errorf("kein Vergleichswert (value) angegeben")
+	}
+
+	_, isNumber := toFloat(condition.Value)
+	if !isNumber && condition.Operator != "==" && condition.Operator != "!=" {
+		return condition, fmt.Errorf("operator %s erfordert einen Zahlenwert", condition.Operator)
+	}
+
+	return condition, nil
+}
+
+// contains prüft, ob ein Wert in einer Liste enthalten ist
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+// Package maintenance zählt Betriebsstunden und Schaltspiele der Aktoren und die Laufzeit des
+// Systems (Nachfolger des RuntimeTracker aus dem Altsystem). Die Zähler werden aus den
+// Zustandsrückmeldungen der Aktoren abgeleitet, über Neustarts hinweg gespeichert und lösen
+// nach konfigurierbaren Wartungsintervallen einen Alarm aus.
+package maintenance
+
+import (
+	"fmt"
+	"time"
+
+	"owipex_reader/internal/config"
+)
+
+// Interval ist ein geprüftes Wartungsintervall
+type Interval struct {
+	Name        string
+	Description string
+	Device      string
+	Hours       float64
+	Cycles      int
+}
+
+// AlarmName gibt den Namen des Alarms "Wartung fällig" zurück
+func (i *Interval) AlarmName() string {
+	return "maintenance_" + i.Name
+}
+
+// due prüft, ob die Wartung nach den Stunden bzw. Schaltspielen seit der letzten Wartung
+// fällig ist
+func (i *Interval) due(runtime time.Duration, cycles int) bool {
+	if i.Hours > 0 && runtime.Hours() >= i.Hours {
+		return true
+	}
+	return i.Cycles > 0 && cycles >= i.Cycles
+}
+
+// ParseIntervals prüft die Wartungsintervalle der Anwendungskonfiguration. Alle Fehler werden
+// gesammelt zurückgegeben, ungültige Intervalle werden ausgelassen.
+func ParseIntervals(configs []config.MaintenanceIntervalConfig) ([]Interval, []error) {
+	var intervals []Interval
+	var errs []error
+	names := make(map[string]bool)
+
+	for i, cfg := range configs {
+		interval, err := parseInterval(cfg)
+		if err == nil && names[interval.Name] {
+			err = fmt.Errorf("name ist bereits vergeben")
+		}
+		if err != nil {
+			label := cfg.Name
+			if label == "" {
+				label = fmt.Sprintf("#%d", i)
+			}
+			errs = append(errs, fmt.Errorf("wartungsintervall %s: %w", label, err))
+			continue
+		}
+
+		names[interval.Name] = true
+		intervals = append(intervals, interval)
+	}
+
+	return intervals, errs
+}
+
+// parseInterval prüft ein Wartungsintervall
+func parseInterval(cfg config.MaintenanceIntervalConfig) (Interval, error) {
+	switch {
+	case cfg.Name == "":
+		return Interval{}, fmt.Errorf("kein Name angegeben")
+	case cfg.Device == "":
+		return Interval{}, fmt.Errorf("kein Gerät (device) angegeben, für die Systemlaufzeit %q verwenden", config.OperatingHoursSystem)
+	case cfg.Hours < 0 || cfg.Cycles < 0:
+		return Interval{}, fmt.Errorf("hours und cycles dürfen nicht negativ sein")
+	case cfg.Hours == 0 && cfg.Cycles == 0:
+		return Interval{}, fmt.Errorf("hours oder cycles muss angegeben sein")
+	}
+
+	return Interval{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		Device:      cfg.Device,
+		Hours:       cfg.Hours,
+		Cycles:      cfg.Cycles,
+	}, nil
+}
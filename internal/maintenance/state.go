@@ -0,0 +1,77 @@
+package maintenance
+
+import (
+	"time"
+
+	"owipex_reader/internal/statefile"
+)
+
+// maxResets ist die Anzahl der gespeicherten Rücksetzungen
+const maxResets = 100
+
+// counterRecord ist der gespeicherte Zähler eines Aktors oder des Systems
+type counterRecord struct {
+	RuntimeSeconds float64   `json:"runtime_seconds"`
+	Cycles         int       `json:"cycles"`
+	Running        bool      `json:"running"`
+	LastChange     time.Time `json:"last_change"`
+}
+
+// serviceRecord ist die letzte Wartung eines Intervalls mit den Zählerständen zu diesem Zeitpunkt
+type serviceRecord struct {
+	ServicedAt     time.Time `json:"serviced_at"`
+	RuntimeSeconds float64   `json:"runtime_seconds"`
+	Cycles         int       `json:"cycles"`
+	Due            bool      `json:"due"`
+}
+
+// Reset ist eine protokollierte Rücksetzung eines Wartungsintervalls oder eines Zählers
+type Reset struct {
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Name   string    `json:"name,omitempty"`
+	Device string    `json:"device"`
+	Issuer string    `json:"issuer,omitempty"`
+	Note   string    `json:"note,omitempty"`
+
+	// Hours und Cycles sind die Stände vor der Rücksetzung
+	Hours  float64 `json:"hours"`
+	Cycles int     `json:"cycles"`
+}
+
+// state ist der Inhalt der Zustandsdatei
+type state struct {
+	Counters    map[string]counterRecord `json:"counters"`
+	Maintenance map[string]serviceRecord `json:"maintenance"`
+	Resets      []Reset                  `json:"resets"`
+}
+
+// newState erstellt einen leeren Zustand
+func newState() state {
+	return state{
+		Counters:    make(map[string]counterRecord),
+		Maintenance: make(map[string]serviceRecord),
+	}
+}
+
+// loadState liest die Zustandsdatei. Fehlt sie, ist der Zustand leer. Eine Datei, die sich
+// nicht parsen lässt, wird beiseitegelegt (statefile.ErrCorrupt); lässt sie
+// sich nicht lesen oder verschieben, wird ein Fehler zurückgegeben.
+func loadState(path string) (state, error) {
+	st := newState()
+	if err := statefile.Load(path, &st); err != nil {
+		return newState(), err
+	}
+	if st.Counters == nil {
+		st.Counters = make(map[string]counterRecord)
+	}
+	if st.Maintenance == nil {
+		st.Maintenance = make(map[string]serviceRecord)
+	}
+	return st, nil
+}
+
+// saveState schreibt die Zustandsdatei
+func saveState(path string, st state) error {
+	return statefile.Save(path, st)
+}
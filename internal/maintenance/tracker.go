@@ -0,0 +1,686 @@
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/statefile"
+	"owipex_reader/internal/types"
+)
+
+// Arten einer Rücksetzung
+const (
+	ResetMaintenance = "maintenance"
+	ResetCounter     = "counter"
+)
+
+const (
+	// subscriberID ist die Kennung des Trackers auf dem Event-Bus
+	subscriberID = "maintenance.tracker"
+
+	// tickInterval ist das Intervall, in dem Laufzeiten fortgeschrieben, Wartungsintervalle
+	// geprüft, die Zähler gesendet und gespeichert werden
+	tickInterval = time.Minute
+
+	// defaultMaxGap ist die längste Zeit ohne Zustandsrückmeldung, in der ein laufender Aktor
+	// weiter als laufend gezählt wird
+	defaultMaxGap = 300 * time.Second
+)
+
+// CounterStatus ist der Stand der Zähler eines Aktors oder des Systems
+type CounterStatus struct {
+	Device         string     `json:"device"`
+	OperatingHours float64    `json:"operating_hours"`
+	Cycles         int        `json:"cycles"`
+	Running        bool       `json:"running"`
+	LastChange     *time.Time `json:"last_change,omitempty"`
+}
+
+// IntervalStatus ist der Stand eines Wartungsintervalls
+type IntervalStatus struct {
+	Name               string     `json:"name"`
+	Description        string     `json:"description,omitempty"`
+	Device             string     `json:"device"`
+	IntervalHours      float64    `json:"interval_hours,omitempty"`
+	IntervalCycles     int        `json:"interval_cycles,omitempty"`
+	HoursSinceService  float64    `json:"hours_since_service"`
+	CyclesSinceService int        `json:"cycles_since_service"`
+	RemainingHours     *float64   `json:"remaining_hours,omitempty"`
+	RemainingCycles    *int       `json:"remaining_cycles,omitempty"`
+	Due                bool       `json:"due"`
+	ServicedAt         *time.Time `json:"serviced_at,omitempty"`
+}
+
+// Status ist der Stand aller Zähler und Wartungsintervalle
+type Status struct {
+	Counters    []CounterStatus  `json:"counters"`
+	Maintenance []IntervalStatus `json:"maintenance"`
+	Resets      []Reset          `json:"resets"`
+}
+
+// counter ist der Laufzeitzustand eines Zählers
+type counter struct {
+	counterRecord
+
+	// lastSample ist der Zeitpunkt der letzten Zustandsrückmeldung, accountedUntil der
+	// Zeitpunkt, bis zu dem die Laufzeit gezählt ist
+	lastSample     time.Time
+	accountedUntil time.Time
+}
+
+// runtime gibt die gezählte Laufzeit zurück
+func (c *counter) runtime() time.Duration {
+	return time.Duration(c.RuntimeSeconds * float64(time.Second))
+}
+
+// accumulate schreibt die Laufzeit bis now fort. Ohne Rückmeldung wird höchstens bis maxGap
+// nach der letzten Rückmeldung gezählt, da der Zustand danach unbekannt ist.
+func (c *counter) accumulate(now time.Time, maxGap time.Duration) {
+	if !c.Running || c.lastSample.IsZero() {
+		return
+	}
+
+	until := now
+	if limit := c.lastSample.Add(maxGap); until.After(limit) {
+		until = limit
+	}
+	if until.After(c.accountedUntil) {
+		c.RuntimeSeconds += until.Sub(c.accountedUntil).Seconds()
+		c.accountedUntil = until
+	}
+}
+
+// observe übernimmt eine Zustandsrückmeldung. Ein Wechsel von aus nach ein ist ein Schaltspiel.
+// Rückmeldungen, die älter als die letzte übernommene sind (z.B. ein Zurücklesen, das vor einem
+// Befehl begann), werden verworfen.
+func (c *counter) observe(running bool, now time.Time, maxGap time.Duration) {
+	if now.Before(c.lastSample) {
+		return
+	}
+	c.accumulate(now, maxGap)
+
+	if running != c.Running {
+		if running {
+			c.Cycles++
+		}
+		c.LastChange = now
+	}
+	c.Running = running
+	c.lastSample = now
+	c.accountedUntil = now
+}
+
+// alarm ist ein zu meldender Wechsel eines Wartungsintervalls
+type alarm struct {
+	interval *Interval
+	due      bool
+	message  string
+}
+
+// Tracker zählt Betriebsstunden und Schaltspiele der Aktoren und die Laufzeit und Starts des
+// Systems. Schaltvorgänge übernimmt er aus den Ergebnissen der Befehlsverwaltung auf dem
+// Event-Bus, sodass auch kurze Einschaltungen zwischen zwei Abfragen zählen. Die zyklischen
+// Zustandsrückmeldungen (alle 15 Sekunden bzw. im Leseintervall) erfassen zusätzlich
+// Schaltvorgänge, die nicht über die Befehlsverwaltung laufen (z.B. Handbetrieb vor Ort).
+type Tracker struct {
+	intervals []Interval
+	bus       *event.Bus
+	logger    *log.Logger
+	statePath string
+	maxGap    time.Duration
+
+	// publish sendet die Zähler als Telemetrie an ThingsBoard
+	publish func(data map[string]interface{})
+
+	mutex    sync.Mutex
+	counters map[string]*counter
+	services map[string]serviceRecord
+	resets   []Reset
+
+	// saveMutex hält die Reihenfolge der Schreibvorgänge in die Zustandsdatei ein
+	saveMutex sync.Mutex
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewTracker erstellt den Tracker und lädt die gespeicherten Zähler. Ungültige
+// Wartungsintervalle und eine nicht lesbare Zustandsdatei führen zu einem Fehler.
+func NewTracker(cfg config.OperatingHoursConfig, bus *event.Bus, publish func(map[string]interface{})) (*Tracker, error) {
+	intervals, errs := ParseIntervals(cfg.Maintenance)
+	if len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return nil, fmt.Errorf("ungültige Wartungsintervalle: %s", strings.Join(messages, "; "))
+	}
+	if cfg.MaxGapSeconds < 0 {
+		return nil, fmt.Errorf("operating_hours.max_gap_seconds darf nicht negativ sein")
+	}
+
+	t := &Tracker{
+		intervals: intervals,
+		bus:       bus,
+		logger:    log.New(os.Stdout, "[Maintenance] ", log.LstdFlags),
+		statePath: cfg.StatePath,
+		maxGap:    defaultMaxGap,
+		publish:   publish,
+		counters:  make(map[string]*counter),
+		stopChan:  make(chan struct{}),
+	}
+	if cfg.MaxGapSeconds > 0 {
+		t.maxGap = time.Duration(cfg.MaxGapSeconds) * time.Second
+	}
+
+	st, err := loadState(cfg.StatePath)
+	if errors.Is(err, statefile.ErrCorrupt) {
+		t.logger.Printf("Betriebsstunden beginnen bei null: %v", err)
+	} else if err != nil {
+		// Ohne gelesenen Zustand würde der nächste Schreibvorgang die Zähler überschreiben
+		return nil, fmt.Errorf("fehler beim Laden der Betriebsstunden: %w", err)
+	}
+	for id, saved := range st.Counters {
+		t.counters[id] = &counter{counterRecord: saved}
+	}
+	t.services = st.Maintenance
+	t.resets = st.Resets
+
+	return t, nil
+}
+
+// Intervals gibt die geprüften Wartungsintervalle zurück
+func (t *Tracker) Intervals() []Interval {
+	return t.intervals
+}
+
+// Start zählt einen Systemstart, abonniert Aktorzustände und Befehlsergebnisse auf dem Event-Bus
+// und schreibt die Zähler zyklisch fort. Bereits fällige Wartungen werden erneut gemeldet.
+func (t *Tracker) Start() error {
+	if err := t.bus.Subscribe(subscriberID, t.handleEvent, event.SubscriberOptions{}, event.TopicReadingProduced, event.TopicCommandAcknowledged); err != nil {
+		return fmt.Errorf("fehler beim Abonnieren der Aktorzustände: %w", err)
+	}
+
+	now := time.Now()
+	t.mutex.Lock()
+	system := t.counterLocked(config.OperatingHoursSystem)
+	system.Cycles++
+	system.Running = true
+	system.LastChange = now
+	system.lastSample = now
+	system.accountedUntil = now
+
+	var alarms []alarm
+	for i := range t.intervals {
+		interval := &t.intervals[i]
+		if t.services[interval.Name].Due {
+			alarms = append(alarms, alarm{interval: interval, due: true, message: t.dueMessageLocked(interval)})
+		}
+	}
+	t.mutex.Unlock()
+
+	t.raise(alarms)
+	t.Tick(now)
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stopChan:
+				return
+			case now := <-ticker.C:
+				t.Tick(now)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop schreibt die Zähler fort und speichert sie
+func (t *Tracker) Stop() {
+	close(t.stopChan)
+	t.wg.Wait()
+	t.bus.Unsubscribe(subscriberID)
+
+	now := time.Now()
+	t.mutex.Lock()
+	for id, c := range t.counters {
+		if id == config.OperatingHoursSystem {
+			c.lastSample = now
+		}
+		c.accumulate(now, t.maxGap)
+	}
+	t.counterLocked(config.OperatingHoursSystem).Running = false
+	t.mutex.Unlock()
+
+	t.persist()
+}
+
+// counterLocked gibt den Zähler eines Geräts zurück und legt ihn bei Bedarf an, der Aufrufer
+// hält die Sperre
+func (t *Tracker) counterLocked(deviceID string) *counter {
+	c, exists := t.counters[deviceID]
+	if !exists {
+		c = &counter{}
+		t.counters[deviceID] = c
+	}
+	return c
+}
+
+// handleEvent übernimmt Zustandsrückmeldungen und Befehlsergebnisse der Aktoren
+func (t *Tracker) handleEvent(ev event.Event) {
+	switch payload := ev.Payload.(type) {
+	case event.ReadingProduced:
+		if payload.Reading.Type != types.ReadingTypeState {
+			return
+		}
+		// Der vom Aktor gemeldete Laufzustand hat Vorrang vor der Auswertung des Werts
+		running, known := payload.Reading.Metadata[types.MetadataRunning].(bool)
+		if !known {
+			running, known = IsRunning(payload.Reading.Value)
+		}
+		if !known {
+			return
+		}
+		at := ev.Timestamp
+		if payload.Reading.Timestamp > 0 {
+			at = time.Unix(0, payload.Reading.Timestamp*int64(time.Millisecond))
+		}
+		t.observe(payload.DeviceID, running, at)
+
+	case event.CommandAcknowledged:
+		// Nach jedem Befehl der Befehlsverwaltung liegt der zurückgelesene Zustand vor, auch
+		// wenn er vom befohlenen abweicht
+		if payload.State == nil {
+			return
+		}
+		if running, known := IsRunning(payload.State); known {
+			t.observe(payload.DeviceID, running, ev.Timestamp)
+		}
+	}
+}
+
+// observe übernimmt den Laufzustand eines Aktors zum Zeitpunkt at
+func (t *Tracker) observe(deviceID string, running bool, at time.Time) {
+	if deviceID == "" || deviceID == config.OperatingHoursSystem {
+		return
+	}
+
+	t.mutex.Lock()
+	t.counterLocked(deviceID).observe(running, at, t.maxGap)
+	t.mutex.Unlock()
+}
+
+// Tick schreibt die Laufzeiten fort, prüft die Wartungsintervalle, sendet die Zähler und
+// speichert sie
+func (t *Tracker) Tick(now time.Time) {
+	t.mutex.Lock()
+	for id, c := range t.counters {
+		if id == config.OperatingHoursSystem {
+			c.lastSample = now
+		}
+		c.accumulate(now, t.maxGap)
+	}
+
+	var alarms []alarm
+	for i := range t.intervals {
+		interval := &t.intervals[i]
+		service := t.services[interval.Name]
+		runtime, cycles := t.sinceServiceLocked(interval)
+		due := interval.due(runtime, cycles)
+		if due == service.Due {
+			continue
+		}
+
+		service.Due = due
+		t.services[interval.Name] = service
+		if due {
+			message := t.dueMessageLocked(interval)
+			t.logger.Printf("Wartung %s fällig: %s", interval.Name, message)
+			alarms = append(alarms, alarm{interval: interval, due: true, message: message})
+		}
+	}
+	telemetry := t.telemetryLocked()
+	t.mutex.Unlock()
+
+	t.raise(alarms)
+	if t.publish != nil {
+		t.publish(map[string]interface{}{"simple": telemetry})
+	}
+	t.persist()
+}
+
+// sinceServiceLocked gibt Laufzeit und Schaltspiele seit der letzten Wartung zurück, der
+// Aufrufer hält die Sperre
+func (t *Tracker) sinceServiceLocked(interval *Interval) (time.Duration, int) {
+	c, exists := t.counters[interval.Device]
+	if !exists {
+		return 0, 0
+	}
+	service := t.services[interval.Name]
+	runtime := time.Duration((c.RuntimeSeconds - service.RuntimeSeconds) * float64(time.Second))
+	return runtime, c.Cycles - service.Cycles
+}
+
+// dueMessageLocked beschreibt eine fällige Wartung, der Aufrufer hält die Sperre
+func (t *Tracker) dueMessageLocked(interval *Interval) string {
+	runtime, cycles := t.sinceServiceLocked(interval)
+	message := fmt.Sprintf("%s: %.1f h und %d Schaltspiele seit der letzten Wartung", interval.Device, runtime.Hours(), cycles)
+	if interval.Description != "" {
+		message = interval.Description + " (" + message + ")"
+	}
+	return message
+}
+
+// raise meldet fällige bzw. erledigte Wartungen als Alarm
+func (t *Tracker) raise(alarms []alarm) {
+	for _, a := range alarms {
+		severity := event.SeverityWarning
+		if !a.due {
+			severity = event.SeverityInfo
+		}
+		deviceID := a.interval.Device
+		if deviceID == config.OperatingHoursSystem {
+			deviceID = ""
+		}
+
+		t.bus.Publish(event.NewAlarmEvent(event.Alarm{
+			Name:     a.interval.AlarmName(),
+			DeviceID: deviceID,
+			Severity: severity,
+			Active:   a.due,
+			Message:  a.message,
+		}))
+	}
+}
+
+// telemetryLocked gibt die Zähler als Telemetrie zurück, der Aufrufer hält die Sperre
+func (t *Tracker) telemetryLocked() map[string]interface{} {
+	telemetry := make(map[string]interface{})
+	for id, c := range t.counters {
+		telemetry[id+"_operating_hours"] = roundHours(c.runtime())
+		if id == config.OperatingHoursSystem {
+			telemetry[id+"_starts"] = c.Cycles
+		} else {
+			telemetry[id+"_switch_cycles"] = c.Cycles
+		}
+	}
+	for i := range t.intervals {
+		status := t.intervalStatusLocked(&t.intervals[i])
+		prefix := t.intervals[i].AlarmName() + "_"
+		telemetry[prefix+"due"] = status.Due
+		if status.RemainingHours != nil {
+			telemetry[prefix+"remaining_hours"] = *status.RemainingHours
+		}
+		if status.RemainingCycles != nil {
+			telemetry[prefix+"remaining_cycles"] = *status.RemainingCycles
+		}
+	}
+	return telemetry
+}
+
+// persist schreibt Zähler, Wartungen und Rücksetzungen in die Zustandsdatei
+func (t *Tracker) persist() {
+	t.saveMutex.Lock()
+	defer t.saveMutex.Unlock()
+
+	t.mutex.Lock()
+	st := newState()
+	for id, c := range t.counters {
+		st.Counters[id] = c.counterRecord
+	}
+	for name, service := range t.services {
+		st.Maintenance[name] = service
+	}
+	st.Resets = append([]Reset(nil), t.resets...)
+	t.mutex.Unlock()
+
+	if err := saveState(t.statePath, st); err != nil {
+		t.logger.Printf("Betriebsstunden nicht gespeichert: %v", err)
+	}
+}
+
+// ResetMaintenance setzt ein Wartungsintervall nach erledigter Wartung zurück. Die
+// Gesamtzähler bleiben erhalten, die Rücksetzung wird protokolliert.
+func (t *Tracker) ResetMaintenance(name, issuer, note string) (IntervalStatus, error) {
+	var interval *Interval
+	for i := range t.intervals {
+		if t.intervals[i].Name == name {
+			interval = &t.intervals[i]
+		}
+	}
+	if interval == nil {
+		return IntervalStatus{}, fmt.Errorf("unbekanntes Wartungsintervall: %s", name)
+	}
+
+	now := time.Now()
+	t.mutex.Lock()
+	c := t.counterLocked(interval.Device)
+	c.accumulate(now, t.maxGap)
+	runtime, cycles := t.sinceServiceLocked(interval)
+	wasDue := t.services[name].Due
+
+	t.services[name] = serviceRecord{ServicedAt: now, RuntimeSeconds: c.RuntimeSeconds, Cycles: c.Cycles}
+	t.appendResetLocked(Reset{
+		Time:   now,
+		Kind:   ResetMaintenance,
+		Name:   name,
+		Device: interval.Device,
+		Issuer: issuer,
+		Note:   note,
+		Hours:  roundHours(runtime),
+		Cycles: cycles,
+	})
+	status := t.intervalStatusLocked(interval)
+	t.mutex.Unlock()
+
+	t.logger.Printf("Wartung %s (%s) durch %q zurückgesetzt nach %.1f h und %d Schaltspielen: %s", name, interval.Device, issuer, runtime.Hours(), cycles, note)
+	if wasDue {
+		t.raise([]alarm{{interval: interval, due: false, message: fmt.Sprintf("wartung %s erledigt", name)}})
+	}
+	t.persist()
+	return status, nil
+}
+
+// ResetCounter setzt Betriebsstunden und Schaltspiele eines Geräts zurück (z.B. nach dem
+// Austausch einer Pumpe). Die Stände seit der letzten Wartung bleiben erhalten, die
+// Rücksetzung wird protokolliert.
+func (t *Tracker) ResetCounter(deviceID, issuer, note string) (CounterStatus, error) {
+	now := time.Now()
+
+	t.mutex.Lock()
+	c, exists := t.counters[deviceID]
+	if !exists {
+		t.mutex.Unlock()
+		return CounterStatus{}, fmt.Errorf("keine Betriebsstunden für %s erfasst", deviceID)
+	}
+	if deviceID == config.OperatingHoursSystem {
+		c.lastSample = now
+	}
+	c.accumulate(now, t.maxGap)
+	hours, cycles := roundHours(c.runtime()), c.Cycles
+
+	// Die Stände der letzten Wartung verschieben, damit die Zählung seit der Wartung weiterläuft
+	for i := range t.intervals {
+		if t.intervals[i].Device != deviceID {
+			continue
+		}
+		service := t.services[t.intervals[i].Name]
+		service.RuntimeSeconds -= c.RuntimeSeconds
+		service.Cycles -= c.Cycles
+		t.services[t.intervals[i].Name] = service
+	}
+	c.RuntimeSeconds = 0
+	c.Cycles = 0
+
+	t.appendResetLocked(Reset{
+		Time:   now,
+		Kind:   ResetCounter,
+		Device: deviceID,
+		Issuer: issuer,
+		Note:   note,
+		Hours:  hours,
+		Cycles: cycles,
+	})
+	status := counterStatus(deviceID, c)
+	t.mutex.Unlock()
+
+	t.logger.Printf("Betriebsstunden von %s durch %q zurückgesetzt (bisher %.2f h, %d Schaltspiele): %s", deviceID, issuer, hours, cycles, note)
+	t.persist()
+	return status, nil
+}
+
+// appendResetLocked protokolliert eine Rücksetzung, der Aufrufer hält die Sperre
+func (t *Tracker) appendResetLocked(reset Reset) {
+	t.resets = append(t.resets, reset)
+	if len(t.resets) > maxResets {
+		t.resets = t.resets[len(t.resets)-maxResets:]
+	}
+}
+
+// Status gibt alle Zähler sortiert nach Gerät, die Wartungsintervalle und die protokollierten
+// Rücksetzungen zurück
+func (t *Tracker) Status() Status {
+	now := time.Now()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	status := Status{
+		Counters:    make([]CounterStatus, 0, len(t.counters)),
+		Maintenance: make([]IntervalStatus, 0, len(t.intervals)),
+		Resets:      append([]Reset(nil), t.resets...),
+	}
+	for id, c := range t.counters {
+		if id == config.OperatingHoursSystem {
+			c.lastSample = now
+		}
+		c.accumulate(now, t.maxGap)
+		status.Counters = append(status.Counters, counterStatus(id, c))
+	}
+	sort.Slice(status.Counters, func(i, j int) bool {
+		return status.Counters[i].Device < status.Counters[j].Device
+	})
+	for i := range t.intervals {
+		status.Maintenance = append(status.Maintenance, t.intervalStatusLocked(&t.intervals[i]))
+	}
+	return status
+}
+
+// counterStatus gibt den Stand eines Zählers zurück
+func counterStatus(deviceID string, c *counter) CounterStatus {
+	status := CounterStatus{
+		Device:         deviceID,
+		OperatingHours: roundHours(c.runtime()),
+		Cycles:         c.Cycles,
+		Running:        c.Running,
+	}
+	if !c.LastChange.IsZero() {
+		lastChange := c.LastChange
+		status.LastChange = &lastChange
+	}
+	return status
+}
+
+// intervalStatusLocked gibt den Stand eines Wartungsintervalls zurück, der Aufrufer hält die
+// Sperre
+func (t *Tracker) intervalStatusLocked(interval *Interval) IntervalStatus {
+	service := t.services[interval.Name]
+	runtime, cycles := t.sinceServiceLocked(interval)
+
+	status := IntervalStatus{
+		Name:               interval.Name,
+		Description:        interval.Description,
+		Device:             interval.Device,
+		IntervalHours:      interval.Hours,
+		IntervalCycles:     interval.Cycles,
+		HoursSinceService:  roundHours(runtime),
+		CyclesSinceService: cycles,
+		Due:                service.Due,
+	}
+	if interval.Hours > 0 {
+		remaining := math.Max(roundHours(time.Duration(interval.Hours*float64(time.Hour))-runtime), 0)
+		status.RemainingHours = &remaining
+	}
+	if interval.Cycles > 0 {
+		remaining := interval.Cycles - cycles
+		if remaining < 0 {
+			remaining = 0
+		}
+		status.RemainingCycles = &remaining
+	}
+	if !service.ServicedAt.IsZero() {
+		servicedAt := service.ServicedAt
+		status.ServicedAt = &servicedAt
+	}
+	return status
+}
+
+// roundHours gibt eine Dauer in Stunden mit zwei Nachkommastellen zurück
+func roundHours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+// IsRunning leitet aus dem Zustand eines Aktors ab, ob er läuft bzw. offen ist: Relais melden
+// einen Boolean, Frequenzumrichter "running" bzw. die Ausgangsfrequenz, Ventile die Stellung.
+// known ist false, wenn der Zustand nicht ausgewertet werden kann.
+func IsRunning(state interface{}) (running bool, known bool) {
+	switch v := state.(type) {
+	case bool:
+		return v, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "on", "true", "1", "run", "running", "open", "ein", "an":
+			return true, true
+		case "off", "false", "0", "stop", "stopped", "close", "closed", "aus":
+			return false, true
+		}
+		return false, false
+	case map[string]interface{}:
+		for _, key := range []string{"running", "output_frequency", "position", "state"} {
+			if value, exists := v[key]; exists {
+				return IsRunning(value)
+			}
+		}
+		return false, false
+	}
+
+	if number, ok := toFloat(state); ok {
+		return number > 0, true
+	}
+	return false, false
+}
+
+// toFloat wandelt Zahlenwerte um
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	}
+	return 0, false
+}
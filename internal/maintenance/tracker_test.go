@@ -0,0 +1,169 @@
+package maintenance
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/types"
+)
+
+// stateEvent erstellt eine Zustandsrückmeldung, deren Messwert zum Zeitpunkt at gelesen wurde
+func stateEvent(deviceID string, value interface{}, at time.Time) event.Event {
+	reading := types.NewReading(types.ReadingTypeState, value, "", nil)
+	reading.Timestamp = at.UnixNano() / int64(time.Millisecond)
+	ev := event.NewReadingProducedEvent(deviceID, reading)
+	ev.Timestamp = at
+	return ev
+}
+
+// commandEvent erstellt das Ergebnis eines Befehls der Befehlsverwaltung zum Zeitpunkt at
+func commandEvent(deviceID string, success bool, state interface{}, at time.Time) event.Event {
+	ev := event.NewCommandAcknowledgedEvent(event.CommandAcknowledged{
+		DeviceID: deviceID,
+		Method:   string(types.CommandTypeSetState),
+		Success:  success,
+		State:    state,
+	}, "rule")
+	ev.Timestamp = at
+	return ev
+}
+
+// TestCycles prüft die Zählung der Schaltspiele aus Befehlsergebnissen und Zustandsrückmeldungen
+func TestCycles(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	at := func(seconds int) time.Time { return base.Add(time.Duration(seconds) * time.Second) }
+
+	tests := []struct {
+		name       string
+		events     []event.Event
+		wantCycles int
+		wantOn     bool
+	}{
+		{
+			name: "kurze Einschaltung zwischen zwei Abfragen",
+			events: []event.Event{
+				stateEvent("pump", false, at(0)),
+				commandEvent("pump", true, true, at(3)),
+				commandEvent("pump", true, false, at(8)),
+				stateEvent("pump", false, at(15)),
+			},
+			wantCycles: 1,
+		},
+		{
+			name: "Befehl und folgende Abfrage zählen einmal",
+			events: []event.Event{
+				stateEvent("pump", false, at(0)),
+				commandEvent("pump", true, true, at(3)),
+				stateEvent("pump", true, at(15)),
+			},
+			wantCycles: 1,
+			wantOn:     true,
+		},
+		{
+			name: "Abfrage vor dem Befehl wird nach dem Befehl zugestellt",
+			events: []event.Event{
+				stateEvent("pump", false, at(0)),
+				commandEvent("pump", true, true, at(3)),
+				stateEvent("pump", false, at(2)),
+				stateEvent("pump", true, at(15)),
+			},
+			wantCycles: 1,
+			wantOn:     true,
+		},
+		{
+			name: "fehlgeschlagener Befehl mit zurückgelesenem Zustand",
+			events: []event.Event{
+				commandEvent("pump", false, false, at(3)),
+				commandEvent("pump", false, true, at(5)),
+			},
+			wantCycles: 1,
+			wantOn:     true,
+		},
+		{
+			name: "Befehl ohne zurückgelesenen Zustand",
+			events: []event.Event{
+				stateEvent("pump", false, at(0)),
+				commandEvent("pump", false, nil, at(3)),
+			},
+		},
+		{
+			name: "Einschaltung im Handbetrieb vor Ort",
+			events: []event.Event{
+				stateEvent("pump", false, at(0)),
+				stateEvent("pump", true, at(15)),
+				stateEvent("pump", false, at(30)),
+				stateEvent("pump", map[string]interface{}{"running": true}, at(45)),
+			},
+			wantCycles: 2,
+			wantOn:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := event.NewBus()
+			defer bus.Close()
+			tracker, err := NewTracker(config.OperatingHoursConfig{StatePath: filepath.Join(t.TempDir(), "operating_hours.json")}, bus, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, ev := range tt.events {
+				tracker.handleEvent(ev)
+			}
+
+			var pump *CounterStatus
+			for _, c := range tracker.Status().Counters {
+				if c.Device == "pump" {
+					c := c
+					pump = &c
+				}
+			}
+			if pump == nil {
+				if tt.wantCycles != 0 || tt.wantOn {
+					t.Fatal("kein Zähler für pump")
+				}
+				return
+			}
+			if pump.Cycles != tt.wantCycles || pump.Running != tt.wantOn {
+				t.Errorf("%d Schaltspiele, läuft %v, erwartet %d, %v", pump.Cycles, pump.Running, tt.wantCycles, tt.wantOn)
+			}
+		})
+	}
+}
+
+// TestCyclesFromBus prüft, dass der gestartete Tracker die Befehlsergebnisse auf dem Event-Bus
+// übernimmt
+func TestCyclesFromBus(t *testing.T) {
+	bus := event.NewBus()
+	defer bus.Close()
+	tracker, err := NewTracker(config.OperatingHoursConfig{StatePath: filepath.Join(t.TempDir(), "operating_hours.json")}, bus, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.Stop()
+
+	now := time.Now()
+	bus.Publish(commandEvent("pump", true, true, now))
+	bus.Publish(commandEvent("pump", true, false, now.Add(time.Second)))
+	bus.Publish(commandEvent("pump", true, true, now.Add(2*time.Second)))
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		for _, c := range tracker.Status().Counters {
+			if c.Device == "pump" && c.Cycles == 2 {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Schaltspiele nicht übernommen: %+v", tracker.Status().Counters)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
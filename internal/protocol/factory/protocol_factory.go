@@ -9,6 +9,9 @@ import (
 	"owipex_reader/internal/types"
 )
 
+// DefaultModbusPort ist die serielle Schnittstelle, wenn in der Konfiguration keine angegeben ist
+const DefaultModbusPort = "/dev/ttyUSB0"
+
 // CreateProtocolHandler erstellt einen Protokoll-Handler basierend auf der Konfiguration
 func CreateProtocolHandler(protocolType string, config map[string]interface{}) (types.ProtocolHandler, error) {
 	switch protocolType {
@@ -23,12 +26,12 @@ func CreateProtocolHandler(protocolType string, config map[string]interface{}) (
 func createModbusHandler(config map[string]interface{}) (types.ProtocolHandler, error) {
 	// Standardwerte setzen
 	modbusConfig := modbus.ModbusConfig{
-		Port:         "/dev/ttyUSB0",  // Standard-Port
-		BaudRate:     9600,            // Standard-Baudrate
-		DataBits:     8,               // Standard-Datenbits
-		StopBits:     1,               // Standard-Stopbits
-		Parity:       "N",             // Standard-Parität (None)
-		Timeout:      5 * time.Second, // Standard-Timeout
+		Port:         DefaultModbusPort, // Standard-Port
+		BaudRate:     9600,              // Standard-Baudrate
+		DataBits:     8,                 // Standard-Datenbits
+		StopBits:     1,                 // Standard-Stopbits
+		Parity:       "N",               // Standard-Parität (None)
+		Timeout:      5 * time.Second,   // Standard-Timeout
 		RegisterMaps: make(map[string]types.RegisterMap),
 	}
 
@@ -71,64 +74,84 @@ func createModbusHandler(config map[string]interface{}) (types.ProtocolHandler,
 	if registerMaps, ok := config["register_maps"].(map[string]interface{}); ok {
 		for name, regMapInterface := range registerMaps {
 			if regMap, ok := regMapInterface.(map[string]interface{}); ok {
-				registerMap := types.RegisterMap{
-					Name: name,
-				}
+				// Register-Map zur Konfiguration hinzufügen
+				modbusConfig.RegisterMaps[name] = parseRegisterMap(name, regMap)
+			}
+		}
+	}
 
-				// Register-Typ extrahieren
-				if regType, ok := regMap["type"].(string); ok {
-					// Umwandlung in ModbusRegisterType
-					switch regType {
-					case "HOLDING", "holding":
-						registerMap.Type = types.RegisterTypeHolding
-					case "INPUT", "input":
-						registerMap.Type = types.RegisterTypeInput
-					case "COIL", "coil":
-						registerMap.Type = types.RegisterTypeCoil
-					case "DISCRETE", "discrete":
-						registerMap.Type = types.RegisterTypeDiscrete
-					default:
-						// Standardmäßig Holding-Register verwenden
-						registerMap.Type = types.RegisterTypeHolding
-					}
+	// Geräteidentifikation (FC 43/14 bzw. herstellerspezifische ID-Register) extrahieren
+	if identification, ok := config["identification"].(map[string]interface{}); ok {
+		if method, ok := identification["method"].(string); ok {
+			modbusConfig.Identification.Method = method
+		}
+		if registers, ok := identification["registers"].(map[string]interface{}); ok {
+			modbusConfig.Identification.Registers = make(map[string]types.RegisterMap)
+			for field, regMapInterface := range registers {
+				if regMap, ok := regMapInterface.(map[string]interface{}); ok {
+					modbusConfig.Identification.Registers[field] = parseRegisterMap(field, regMap)
 				}
+			}
+		}
+	}
 
-				// Register-Adresse extrahieren
-				if address, ok := regMap["address"].(float64); ok {
-					registerMap.Address = uint16(address)
-				}
+	// Modbus-Client erstellen
+	return modbus.NewModbusClient(modbusConfig)
+}
 
-				// Register-Länge extrahieren
-				if length, ok := regMap["length"].(float64); ok {
-					registerMap.Length = uint16(length)
-				}
+// parseRegisterMap erstellt eine Register-Map aus einer Konfiguration
+func parseRegisterMap(name string, regMap map[string]interface{}) types.RegisterMap {
+	registerMap := types.RegisterMap{
+		Name: name,
+	}
 
-				// Datentyp extrahieren
-				if dataType, ok := regMap["data_type"].(string); ok {
-					registerMap.DataType = dataType
-				}
+	// Register-Typ extrahieren
+	if regType, ok := regMap["type"].(string); ok {
+		// Umwandlung in ModbusRegisterType
+		switch regType {
+		case "HOLDING", "holding":
+			registerMap.Type = types.RegisterTypeHolding
+		case "INPUT", "input":
+			registerMap.Type = types.RegisterTypeInput
+		case "COIL", "coil":
+			registerMap.Type = types.RegisterTypeCoil
+		case "DISCRETE", "discrete":
+			registerMap.Type = types.RegisterTypeDiscrete
+		default:
+			// Standardmäßig Holding-Register verwenden
+			registerMap.Type = types.RegisterTypeHolding
+		}
+	}
 
-				// Byte-Order extrahieren
-				if byteOrder, ok := regMap["byte_order"].(string); ok {
-					registerMap.ByteOrder = byteOrder
-				}
+	// Register-Adresse extrahieren
+	if address, ok := regMap["address"].(float64); ok {
+		registerMap.Address = uint16(address)
+	}
 
-				// Multiplikator extrahieren
-				if multiplier, ok := regMap["multiplier"].(float64); ok {
-					registerMap.Multiplier = multiplier
-				}
+	// Register-Länge extrahieren
+	if length, ok := regMap["length"].(float64); ok {
+		registerMap.Length = uint16(length)
+	}
 
-				// Offset extrahieren
-				if offset, ok := regMap["offset"].(float64); ok {
-					registerMap.Offset = offset
-				}
+	// Datentyp extrahieren
+	if dataType, ok := regMap["data_type"].(string); ok {
+		registerMap.DataType = dataType
+	}
 
-				// Register-Map zur Konfiguration hinzufügen
-				modbusConfig.RegisterMaps[name] = registerMap
-			}
-		}
+	// Byte-Order extrahieren
+	if byteOrder, ok := regMap["byte_order"].(string); ok {
+		registerMap.ByteOrder = byteOrder
 	}
 
-	// Modbus-Client erstellen
-	return modbus.NewModbusClient(modbusConfig)
+	// Multiplikator extrahieren
+	if multiplier, ok := regMap["multiplier"].(float64); ok {
+		registerMap.Multiplier = multiplier
+	}
+
+	// Offset extrahieren
+	if offset, ok := regMap["offset"].(float64); ok {
+		registerMap.Offset = offset
+	}
+
+	return registerMap
 }
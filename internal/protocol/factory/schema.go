@@ -0,0 +1,57 @@
+package factory
+
+import "owipex_reader/internal/types"
+
+// registerMapSchema beschreibt eine Register-Map (siehe parseRegisterMap)
+func registerMapSchema() types.Schema {
+	return types.Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":    "string",
+				"enum":    []interface{}{"HOLDING", "INPUT", "COIL", "DISCRETE", "holding", "input", "coil", "discrete"},
+				"default": "HOLDING",
+			},
+			"address":    map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+			"length":     map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 125},
+			"data_type":  map[string]interface{}{"type": "string", "enum": []interface{}{"uint16", "int16", "uint32", "int32", "float32", "float64", "string", "version"}},
+			"byte_order": map[string]interface{}{"type": "string", "enum": []interface{}{"big_endian", "little_endian"}},
+			"multiplier": map[string]interface{}{"type": "number"},
+			"offset":     map[string]interface{}{"type": "number"},
+		},
+		"required": []interface{}{"address"},
+	}
+}
+
+// ModbusSchema gibt das JSON-Schema des Metadata-Blocks "modbus" zurück, wie ihn
+// CreateProtocolHandler("modbus", ...) auswertet
+func ModbusSchema() types.Schema {
+	return types.Schema{
+		"type":        "object",
+		"description": "Modbus-RTU-Verbindung und Register des Geräts",
+		"properties": map[string]interface{}{
+			"slave_id":  map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 247},
+			"port":      map[string]interface{}{"type": "string", "default": DefaultModbusPort},
+			"baud_rate": map[string]interface{}{"type": "integer", "enum": []interface{}{1200, 2400, 4800, 9600, 19200, 38400, 57600, 115200}, "default": 9600},
+			"data_bits": map[string]interface{}{"type": "integer", "enum": []interface{}{7, 8}, "default": 8},
+			"stop_bits": map[string]interface{}{"type": "integer", "enum": []interface{}{1, 2}, "default": 1},
+			"parity":    map[string]interface{}{"type": "string", "enum": []interface{}{"N", "E", "O", "n", "e", "o", "none", "even", "odd", "NONE", "EVEN", "ODD"}, "default": "N"},
+			"timeout":   map[string]interface{}{"type": "integer", "minimum": 1, "description": "Timeout in Millisekunden", "default": 5000},
+			"register_maps": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": registerMapSchema(),
+			},
+			"identification": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"method": map[string]interface{}{"type": "string", "enum": []interface{}{"auto", "fc43", "registers", "none"}, "default": "auto"},
+					"registers": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": registerMapSchema(),
+					},
+				},
+			},
+		},
+		"required": []interface{}{"slave_id"},
+	}
+}
@@ -0,0 +1,72 @@
+package modbus
+
+import (
+	"context"
+	"sync"
+)
+
+// portLocks enthält je serieller Schnittstelle die Sperre, unter der alle Transaktionen an
+// dieser Schnittstelle laufen. Geräte an derselben RS485-Leitung haben eigene Clients, dürfen
+// aber nie gleichzeitig senden, egal ob sie von der Erfassung, einem Befehl, der Rückfallebene
+// oder der Geräteidentifikation angesprochen werden.
+var portLocks = struct {
+	sync.Mutex
+	locks map[string]chan struct{}
+}{locks: make(map[string]chan struct{})}
+
+// portLock gibt die Sperre einer Schnittstelle zurück und legt sie bei Bedarf an
+func portLock(port string) chan struct{} {
+	portLocks.Lock()
+	defer portLocks.Unlock()
+
+	lock, exists := portLocks.locks[port]
+	if !exists {
+		lock = make(chan struct{}, 1)
+		portLocks.locks[port] = lock
+	}
+	return lock
+}
+
+// transact führt eine Transaktion unter der Sperre der Schnittstelle aus. Endet der Kontext
+// vorher, kehrt transact mit dem Fehler des Kontexts zurück. Eine bereits begonnene
+// Transaktion lässt sich nicht unterbrechen; sie hält die Schnittstelle bis zu ihrem Ende
+// (höchstens bis zum Zeitlimit des Handlers), danach wird die Verbindung zurückgesetzt, damit
+// eine verspätete Antwort nicht der nächsten Anfrage zugeordnet wird.
+func (c *ModbusClient) transact(ctx context.Context, transaction func() error) error {
+	lock := portLock(c.config.Port)
+	select {
+	case lock <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		<-lock
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- transaction()
+	}()
+
+	select {
+	case err := <-done:
+		<-lock
+		return err
+	case <-ctx.Done():
+		go func() {
+			<-done
+			c.resetConnection()
+			<-lock
+		}()
+		return ctx.Err()
+	}
+}
+
+// resetConnection schließt die Verbindung des Handlers, sie wird bei der nächsten Transaktion
+// neu geöffnet
+func (c *ModbusClient) resetConnection() {
+	if c.handler != nil {
+		c.handler.Close()
+	}
+}
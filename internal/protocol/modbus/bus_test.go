@@ -0,0 +1,109 @@
+package modbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestTransactSerializesPort prüft, dass Clients an derselben Schnittstelle nie gleichzeitig
+// senden, Clients an verschiedenen Schnittstellen aber parallel
+func TestTransactSerializesPort(t *testing.T) {
+	tests := []struct {
+		name        string
+		ports       []string
+		wantMaxBusy int32
+	}{
+		{"gleiche Schnittstelle", []string{"/dev/test-serial-a", "/dev/test-serial-a", "/dev/test-serial-a"}, 1},
+		{"verschiedene Schnittstellen", []string{"/dev/test-serial-b", "/dev/test-serial-c"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var busy, maxBusy int32
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+
+			for _, port := range tt.ports {
+				client := &ModbusClient{config: ModbusConfig{Port: port}}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					err := client.transact(context.Background(), func() error {
+						n := atomic.AddInt32(&busy, 1)
+						for {
+							highest := atomic.LoadInt32(&maxBusy)
+							if n <= highest || atomic.CompareAndSwapInt32(&maxBusy, highest, n) {
+								break
+							}
+						}
+						time.Sleep(20 * time.Millisecond)
+						atomic.AddInt32(&busy, -1)
+						return nil
+					})
+					if err != nil {
+						t.Errorf("transact: %v", err)
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if maxBusy != tt.wantMaxBusy {
+				t.Errorf("%d gleichzeitige Transaktionen, erwartet %d", maxBusy, tt.wantMaxBusy)
+			}
+		})
+	}
+}
+
+// TestTransactContext prüft, dass ein beendeter Kontext die Wartezeit und die Transaktion
+// abbricht, die Schnittstelle aber bis zum Ende der laufenden Transaktion belegt bleibt
+func TestTransactContext(t *testing.T) {
+	client := &ModbusClient{config: ModbusConfig{Port: "/dev/test-serial-ctx"}}
+	other := &ModbusClient{config: ModbusConfig{Port: "/dev/test-serial-ctx"}}
+
+	// Bereits beendeter Kontext: keine Transaktion
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	ran := false
+	if err := client.transact(cancelled, func() error { ran = true; return nil }); !errors.Is(err, context.Canceled) || ran {
+		t.Fatalf("transact = %v, Transaktion ausgeführt: %v", err, ran)
+	}
+
+	// Laufende Transaktion überschreitet das Zeitlimit des Aufrufers
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	err := client.transact(ctx, func() error {
+		<-release
+		close(finished)
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("transact = %v, erwartet DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Fatalf("transact kehrte erst nach %v zurück", elapsed)
+	}
+
+	// Ein anderer Client wartet, solange die abgebrochene Transaktion noch läuft
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer waitCancel()
+	if err := other.transact(waitCtx, func() error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Schnittstelle während laufender Transaktion freigegeben: %v", err)
+	}
+
+	close(release)
+	<-finished
+	nextCtx, nextCancel := context.WithTimeout(context.Background(), time.Second)
+	defer nextCancel()
+	if err := other.transact(nextCtx, func() error { return nil }); err != nil {
+		t.Fatalf("Schnittstelle nach Ende der Transaktion nicht freigegeben: %v", err)
+	}
+}
@@ -33,6 +33,9 @@ type ModbusConfig struct {
 	Parity       string                       `json:"parity"`
 	Timeout      time.Duration                `json:"timeout"`
 	RegisterMaps map[string]types.RegisterMap `json:"register_maps"`
+
+	// Identification legt fest, wie die Geräteidentität gelesen wird
+	Identification IdentificationConfig `json:"identification"`
 }
 
 // RegisterMap definiert die Zuordnung von Namen zu Registern
@@ -53,7 +56,10 @@ type ModbusClient struct {
 	client       modbus.Client
 	handler      *modbus.RTUClientHandler
 	registerMaps map[string]types.RegisterMap
-	mutex        sync.RWMutex
+
+	// mutex schützt die Registerzuordnung, die Transaktionen serialisiert die Sperre der
+	// Schnittstelle (siehe transact)
+	mutex sync.RWMutex
 }
 
 // NewModbusClient erstellt einen neuen Modbus-Client
@@ -97,13 +103,15 @@ func NewModbusClient(config ModbusConfig) (*ModbusClient, error) {
 	}, nil
 }
 
-// ReadRegister liest Daten aus einem Register
+// ReadRegister liest Daten aus einem Register. Die Transaktion läuft unter der Sperre der
+// Schnittstelle und endet spätestens mit ctx.
 func (c *ModbusClient) ReadRegister(ctx context.Context, address uint16, length uint16) ([]byte, error) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	// Standard-Lesefunktion für Holding-Register verwenden
-	result, err := c.client.ReadHoldingRegisters(address, length)
+	var result []byte
+	err := c.transact(ctx, func() (err error) {
+		// Standard-Lesefunktion für Holding-Register verwenden
+		result, err = c.client.ReadHoldingRegisters(address, length)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("fehler beim Lesen des Registers %d: %w", address, err)
 	}
@@ -113,25 +121,22 @@ func (c *ModbusClient) ReadRegister(ctx context.Context, address uint16, length
 
 // WriteRegister schreibt Daten in ein Register
 func (c *ModbusClient) WriteRegister(ctx context.Context, address uint16, data []byte) error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
 	if len(data) == 0 {
 		return fmt.Errorf("keine Daten zum Schreiben")
 	}
 
-	// Für einzelnes Register
-	if len(data) == 2 {
-		value := uint16(data[0])<<8 | uint16(data[1])
-		_, err := c.client.WriteSingleRegister(address, value)
-		if err != nil {
-			return fmt.Errorf("fehler beim Schreiben in Register %d: %w", address, err)
+	err := c.transact(ctx, func() error {
+		// Für einzelnes Register
+		if len(data) == 2 {
+			value := uint16(data[0])<<8 | uint16(data[1])
+			_, err := c.client.WriteSingleRegister(address, value)
+			return err
 		}
-		return nil
-	}
 
-	// Für mehrere Register
-	_, err := c.client.WriteMultipleRegisters(address, uint16(len(data)/2), data)
+		// Für mehrere Register
+		_, err := c.client.WriteMultipleRegisters(address, uint16(len(data)/2), data)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("fehler beim Schreiben in Register %d: %w", address, err)
 	}
@@ -139,6 +144,58 @@ func (c *ModbusClient) WriteRegister(ctx context.Context, address uint16, data [
 	return nil
 }
 
+// ReadCoil liest den Zustand einer Coil
+func (c *ModbusClient) ReadCoil(ctx context.Context, address uint16) (bool, error) {
+	var result []byte
+	err := c.transact(ctx, func() (err error) {
+		result, err = c.client.ReadCoils(address, 1)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("fehler beim Lesen der Coil %d: %w", address, err)
+	}
+	if len(result) == 0 {
+		return false, fmt.Errorf("leere Antwort beim Lesen der Coil %d", address)
+	}
+
+	return result[0]&0x01 != 0, nil
+}
+
+// WriteCoil setzt eine Coil (Funktionscode 05)
+func (c *ModbusClient) WriteCoil(ctx context.Context, address uint16, value bool) error {
+	var data uint16
+	if value {
+		data = 0xFF00
+	}
+
+	err := c.transact(ctx, func() error {
+		_, err := c.client.WriteSingleCoil(address, data)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("fehler beim Schreiben der Coil %d: %w", address, err)
+	}
+
+	return nil
+}
+
+// ReadDiscreteInput liest den Zustand eines diskreten Eingangs
+func (c *ModbusClient) ReadDiscreteInput(ctx context.Context, address uint16) (bool, error) {
+	var result []byte
+	err := c.transact(ctx, func() (err error) {
+		result, err = c.client.ReadDiscreteInputs(address, 1)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("fehler beim Lesen des diskreten Eingangs %d: %w", address, err)
+	}
+	if len(result) == 0 {
+		return false, fmt.Errorf("leere Antwort beim Lesen des diskreten Eingangs %d", address)
+	}
+
+	return result[0]&0x01 != 0, nil
+}
+
 // ReadRegisterByName liest ein Register anhand seines Namens
 func (c *ModbusClient) ReadRegisterByName(ctx context.Context, name string) ([]byte, error) {
 	c.mutex.RLock()
@@ -149,30 +206,25 @@ func (c *ModbusClient) ReadRegisterByName(ctx context.Context, name string) ([]b
 		return nil, fmt.Errorf("register-Name %s nicht gefunden", name)
 	}
 
-	var result []byte
-	var err error
-
+	var read func(address, quantity uint16) ([]byte, error)
 	switch registerMap.Type {
 	case types.RegisterTypeHolding:
-		c.mutex.Lock()
-		result, err = c.client.ReadHoldingRegisters(registerMap.Address, registerMap.Length)
-		c.mutex.Unlock()
+		read = c.client.ReadHoldingRegisters
 	case types.RegisterTypeInput:
-		c.mutex.Lock()
-		result, err = c.client.ReadInputRegisters(registerMap.Address, registerMap.Length)
-		c.mutex.Unlock()
+		read = c.client.ReadInputRegisters
 	case types.RegisterTypeCoil:
-		c.mutex.Lock()
-		result, err = c.client.ReadCoils(registerMap.Address, registerMap.Length)
-		c.mutex.Unlock()
+		read = c.client.ReadCoils
 	case types.RegisterTypeDiscrete:
-		c.mutex.Lock()
-		result, err = c.client.ReadDiscreteInputs(registerMap.Address, registerMap.Length)
-		c.mutex.Unlock()
+		read = c.client.ReadDiscreteInputs
 	default:
 		return nil, fmt.Errorf("unbekannter Register-Typ: %s", registerMap.Type)
 	}
 
+	var result []byte
+	err := c.transact(ctx, func() (err error) {
+		result, err = read(registerMap.Address, registerMap.Length)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("fehler beim Lesen des Registers %s: %w", name, err)
 	}
@@ -199,11 +251,8 @@ func (c *ModbusClient) GetRegisterConfig(name string) types.RegisterConfig {
 	}
 }
 
-// Close schließt die Modbus-Verbindung
+// Close schließt die Modbus-Verbindung. Eine laufende Transaktion wird vorher abgeschlossen.
 func (c *ModbusClient) Close() error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
 	if c.handler != nil {
 		return c.handler.Close()
 	}
@@ -0,0 +1,401 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"owipex_reader/internal/types"
+
+	"github.com/goburrow/modbus"
+	"github.com/goburrow/serial"
+)
+
+// Konstanten für Read Device Identification (Funktionscode 43, MEI-Typ 14)
+const (
+	FuncCodeEncapsulatedInterface = 0x2B
+	MEITypeReadDeviceID           = 0x0E
+
+	// Lesecodes
+	ReadDeviceIDBasic    = 0x01
+	ReadDeviceIDRegular  = 0x02
+	ReadDeviceIDExtended = 0x03
+
+	// Standard-Objekt-IDs
+	ObjectVendorName          = 0x00
+	ObjectProductCode         = 0x01
+	ObjectMajorMinorRevision  = 0x02
+	ObjectVendorURL           = 0x03
+	ObjectProductName         = 0x04
+	ObjectModelName           = 0x05
+	ObjectUserApplicationName = 0x06
+
+	// Identifikationsmethoden
+	IdentificationAuto      = "auto"
+	IdentificationFC43      = "fc43"
+	IdentificationRegisters = "registers"
+	IdentificationNone      = "none"
+
+	// maxIdentificationFrames begrenzt die Anzahl der Folgeanfragen bei "more follows"
+	maxIdentificationFrames = 16
+
+	// Maximale Größe eines RTU-Frames
+	rtuMaxFrameSize = 256
+)
+
+// IdentificationConfig beschreibt, wie die Identität eines Modbus-Geräts gelesen wird
+type IdentificationConfig struct {
+	// Method ist "auto" (FC 43/14, danach ID-Register), "fc43", "registers" oder "none"
+	Method string `json:"method"`
+
+	// Registers ordnet Identitätsfeldern (manufacturer, model, product_code, firmware,
+	// serial_number, ...) herstellerspezifische ID-Register zu
+	Registers map[string]types.RegisterMap `json:"registers"`
+}
+
+// ReadDeviceIdentity liest die Identität des Geräts über Read Device Identification
+// (FC 43/14) und/oder über die konfigurierten herstellerspezifischen ID-Register
+func (c *ModbusClient) ReadDeviceIdentity(ctx context.Context) (types.DeviceIdentity, error) {
+	method := c.config.Identification.Method
+	if method == "" {
+		method = IdentificationAuto
+	}
+
+	var identity types.DeviceIdentity
+	var fc43Err error
+
+	switch method {
+	case IdentificationNone:
+		return identity, fmt.Errorf("geräteidentifikation ist deaktiviert")
+	case IdentificationAuto, IdentificationFC43:
+		objects, err := c.readDeviceIdentification(ctx, ReadDeviceIDRegular)
+		if err != nil {
+			fc43Err = err
+			if method == IdentificationFC43 {
+				return identity, err
+			}
+		} else {
+			identity = identityFromObjects(objects)
+		}
+	case IdentificationRegisters:
+	default:
+		return identity, fmt.Errorf("unbekannte Identifikationsmethode: %s", method)
+	}
+
+	// Herstellerspezifische ID-Register ergänzen bzw. überschreiben die Objekte aus FC 43
+	if len(c.config.Identification.Registers) > 0 {
+		if err := c.readIdentityRegisters(ctx, &identity); err != nil {
+			return identity, err
+		}
+	} else if method == IdentificationRegisters {
+		return identity, fmt.Errorf("keine ID-Register konfiguriert")
+	}
+
+	if identity.IsEmpty() {
+		if fc43Err != nil {
+			return identity, fc43Err
+		}
+		return identity, fmt.Errorf("gerät hat keine Identifikationsdaten geliefert")
+	}
+
+	return identity, nil
+}
+
+// readDeviceIdentification führt Read Device Identification (FC 43/14) aus und gibt
+// die gelesenen Objekte zurück. Die Bibliothek unterstützt diesen Funktionscode nicht,
+// daher wird die serielle Schnittstelle für die Dauer der Abfrage selbst geöffnet.
+func (c *ModbusClient) readDeviceIdentification(ctx context.Context, readCode byte) (map[byte]string, error) {
+	var objects map[byte]string
+	err := c.transact(ctx, func() (err error) {
+		objects, err = c.exchangeDeviceIdentification(ctx, readCode)
+		return err
+	})
+	return objects, err
+}
+
+// exchangeDeviceIdentification tauscht die Frames von Read Device Identification aus. Der
+// Aufrufer hält die Sperre der Schnittstelle.
+func (c *ModbusClient) exchangeDeviceIdentification(ctx context.Context, readCode byte) (map[byte]string, error) {
+	// Verbindung des Handlers freigeben, sie wird beim nächsten Zugriff automatisch neu geöffnet
+	if err := c.handler.Close(); err != nil {
+		return nil, fmt.Errorf("fehler beim Freigeben der Modbus-Verbindung: %w", err)
+	}
+
+	timeout := c.config.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	port, err := serial.Open(&serial.Config{
+		Address:  c.config.Port,
+		BaudRate: c.handler.BaudRate,
+		DataBits: c.handler.DataBits,
+		StopBits: c.handler.StopBits,
+		Parity:   c.handler.Parity,
+		Timeout:  timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Öffnen von %s für die Geräteidentifikation: %w", c.config.Port, err)
+	}
+	defer port.Close()
+
+	objects := make(map[byte]string)
+	objectID := byte(ObjectVendorName)
+
+	for frame := 0; frame < maxIdentificationFrames; frame++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		request := &modbus.ProtocolDataUnit{
+			FunctionCode: FuncCodeEncapsulatedInterface,
+			Data:         []byte{MEITypeReadDeviceID, readCode, objectID},
+		}
+		aduRequest, err := c.handler.Encode(request)
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Kodieren der Identifikationsanfrage: %w", err)
+		}
+
+		if _, err := port.Write(aduRequest); err != nil {
+			return nil, fmt.Errorf("fehler beim Senden der Identifikationsanfrage: %w", err)
+		}
+
+		aduResponse, err := readIdentificationFrame(port)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.handler.Verify(aduRequest, aduResponse); err != nil {
+			return nil, fmt.Errorf("ungültige Identifikationsantwort: %w", err)
+		}
+		response, err := c.handler.Decode(aduResponse)
+		if err != nil {
+			return nil, fmt.Errorf("ungültige Identifikationsantwort: %w", err)
+		}
+
+		if response.FunctionCode != FuncCodeEncapsulatedInterface {
+			if response.FunctionCode == FuncCodeEncapsulatedInterface|0x80 && len(response.Data) > 0 {
+				return nil, fmt.Errorf("gerät unterstützt Read Device Identification nicht (Exception-Code %d)", response.Data[0])
+			}
+			return nil, fmt.Errorf("unerwarteter Funktionscode in Identifikationsantwort: 0x%02X", response.FunctionCode)
+		}
+
+		moreFollows, nextObjectID, err := parseIdentificationObjects(response.Data, objects)
+		if err != nil {
+			return nil, err
+		}
+		if !moreFollows {
+			return objects, nil
+		}
+		objectID = nextObjectID
+	}
+
+	return objects, fmt.Errorf("identifikationsantwort nach %d Frames nicht vollständig", maxIdentificationFrames)
+}
+
+// readIdentificationFrame liest einen vollständigen RTU-Frame einer FC-43-Antwort.
+// Die Länge ergibt sich erst aus den Objektlängen, daher wird schrittweise gelesen.
+func readIdentificationFrame(port serial.Port) ([]byte, error) {
+	buffer := make([]byte, 0, rtuMaxFrameSize)
+	chunk := make([]byte, rtuMaxFrameSize)
+
+	for {
+		if length, complete := identificationFrameLength(buffer); complete {
+			return buffer[:length], nil
+		}
+		if len(buffer) >= rtuMaxFrameSize {
+			return nil, fmt.Errorf("identifikationsantwort überschreitet die maximale Frame-Größe")
+		}
+
+		n, err := port.Read(chunk)
+		if err != nil {
+			if errors.Is(err, serial.ErrTimeout) {
+				return nil, fmt.Errorf("zeitüberschreitung beim Lesen der Identifikationsantwort (%d Bytes empfangen)", len(buffer))
+			}
+			return nil, fmt.Errorf("fehler beim Lesen der Identifikationsantwort: %w", err)
+		}
+		buffer = append(buffer, chunk[:n]...)
+	}
+}
+
+// identificationFrameLength berechnet die Länge eines FC-43/14-RTU-Frames
+// (Adresse, Funktionscode, Daten, CRC), sobald genug Bytes vorliegen
+func identificationFrameLength(frame []byte) (int, bool) {
+	if len(frame) < 2 {
+		return 0, false
+	}
+
+	// Exception-Antwort: Adresse, Funktionscode, Exception-Code, CRC
+	if frame[1]&0x80 != 0 {
+		return 5, len(frame) >= 5
+	}
+
+	// Kopf: Adresse, FC, MEI-Typ, Lesecode, Konformität, More Follows, Next Object ID, Objektanzahl
+	const headerLength = 8
+	if len(frame) < headerLength {
+		return 0, false
+	}
+
+	length := headerLength
+	for i := 0; i < int(frame[7]); i++ {
+		// Objekt-ID und Objektlänge
+		if len(frame) < length+2 {
+			return 0, false
+		}
+		length += 2 + int(frame[length+1])
+	}
+	length += 2 // CRC
+
+	return length, len(frame) >= length
+}
+
+// parseIdentificationObjects liest die Objekte aus den Daten einer FC-43/14-Antwort
+func parseIdentificationObjects(data []byte, objects map[byte]string) (bool, byte, error) {
+	// MEI-Typ, Lesecode, Konformität, More Follows, Next Object ID, Objektanzahl
+	if len(data) < 6 {
+		return false, 0, fmt.Errorf("identifikationsantwort zu kurz: %d Bytes", len(data))
+	}
+	if data[0] != MEITypeReadDeviceID {
+		return false, 0, fmt.Errorf("unerwarteter MEI-Typ in Identifikationsantwort: 0x%02X", data[0])
+	}
+
+	moreFollows := data[3] == 0xFF
+	nextObjectID := data[4]
+	count := int(data[5])
+
+	offset := 6
+	for i := 0; i < count; i++ {
+		if len(data) < offset+2 {
+			return false, 0, fmt.Errorf("identifikationsobjekt %d unvollständig", i)
+		}
+		id := data[offset]
+		length := int(data[offset+1])
+		offset += 2
+		if len(data) < offset+length {
+			return false, 0, fmt.Errorf("identifikationsobjekt 0x%02X unvollständig", id)
+		}
+		objects[id] = strings.TrimSpace(string(data[offset : offset+length]))
+		offset += length
+	}
+
+	return moreFollows, nextObjectID, nil
+}
+
+// identityFromObjects ordnet die Standard-Objekte den Feldern der Geräteidentität zu
+func identityFromObjects(objects map[byte]string) types.DeviceIdentity {
+	identity := types.DeviceIdentity{
+		Manufacturer: objects[ObjectVendorName],
+		ProductCode:  objects[ObjectProductCode],
+		Firmware:     objects[ObjectMajorMinorRevision],
+		VendorURL:    objects[ObjectVendorURL],
+		ProductName:  objects[ObjectProductName],
+		Model:        objects[ObjectModelName],
+	}
+
+	// Ohne ModelName wird der Produktcode als Modell verwendet
+	if identity.Model == "" {
+		identity.Model = identity.ProductCode
+	}
+
+	for id, value := range objects {
+		if id <= ObjectModelName {
+			continue
+		}
+		if identity.Extra == nil {
+			identity.Extra = make(map[string]string)
+		}
+		if id == ObjectUserApplicationName {
+			identity.Extra["user_application_name"] = value
+		} else {
+			identity.Extra[fmt.Sprintf("object_0x%02X", id)] = value
+		}
+	}
+
+	return identity
+}
+
+// readIdentityRegisters liest die herstellerspezifischen ID-Register und trägt sie in die Identität ein
+func (c *ModbusClient) readIdentityRegisters(ctx context.Context, identity *types.DeviceIdentity) error {
+	for field, registerMap := range c.config.Identification.Registers {
+		length := registerMap.Length
+		if length == 0 {
+			length = 1
+		}
+
+		var data []byte
+		err := c.transact(ctx, func() (err error) {
+			if registerMap.Type == types.RegisterTypeInput {
+				data, err = c.client.ReadInputRegisters(registerMap.Address, length)
+			} else {
+				data, err = c.client.ReadHoldingRegisters(registerMap.Address, length)
+			}
+			return err
+		})
+
+		if err != nil {
+			return fmt.Errorf("fehler beim Lesen des ID-Registers %s: %w", field, err)
+		}
+
+		value := decodeIdentityValue(data, registerMap.DataType, registerMap.ByteOrder)
+
+		switch field {
+		case "manufacturer":
+			identity.Manufacturer = value
+		case "product_code":
+			identity.ProductCode = value
+		case "model":
+			identity.Model = value
+		case "product_name":
+			identity.ProductName = value
+		case "firmware":
+			identity.Firmware = value
+		case "serial_number":
+			identity.SerialNumber = value
+		default:
+			if identity.Extra == nil {
+				identity.Extra = make(map[string]string)
+			}
+			identity.Extra[field] = value
+		}
+	}
+
+	return nil
+}
+
+// decodeIdentityValue wandelt den Inhalt eines ID-Registers in eine Zeichenkette um.
+// Unterstützt werden "string" (ASCII), "uint16", "uint32" und "version" (High-Byte.Low-Byte).
+func decodeIdentityValue(data []byte, dataType, byteOrder string) string {
+	switch dataType {
+	case "uint16":
+		if len(data) >= 2 {
+			return strconv.FormatUint(uint64(binary.BigEndian.Uint16(data)), 10)
+		}
+	case "uint32":
+		if len(data) >= 4 {
+			if byteOrder == "little_endian" {
+				// Wort-Reihenfolge vertauscht
+				return strconv.FormatUint(uint64(binary.BigEndian.Uint16(data[2:]))<<16|uint64(binary.BigEndian.Uint16(data)), 10)
+			}
+			return strconv.FormatUint(uint64(binary.BigEndian.Uint32(data)), 10)
+		}
+	case "version":
+		if len(data) >= 2 {
+			return fmt.Sprintf("%d.%d", data[0], data[1])
+		}
+	default:
+		// ASCII-Zeichenkette, ggf. mit vertauschten Bytes je Register
+		text := make([]byte, len(data))
+		copy(text, data)
+		if byteOrder == "little_endian" {
+			for i := 0; i+1 < len(text); i += 2 {
+				text[i], text[i+1] = text[i+1], text[i]
+			}
+		}
+		return strings.TrimSpace(strings.ReplaceAll(string(text), "\x00", ""))
+	}
+
+	return ""
+}
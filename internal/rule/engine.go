@@ -0,0 +1,492 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/interlock"
+	"owipex_reader/internal/statefile"
+)
+
+const (
+	// subscriberID ist die Kennung der Engine auf dem Event-Bus
+	subscriberID = "rule.engine"
+
+	// evaluateInterval ist das Intervall, in dem die Regeln bewertet werden. Mindestdauern und
+	// Zeitfenster werden damit auf eine Sekunde genau eingehalten.
+	evaluateInterval = time.Second
+
+	// queueSize ist die Anzahl der Regelwechsel, deren Aktionen auf die Ausführung warten können
+	queueSize = 64
+)
+
+// Status ist der Zustand einer Regel
+type Status struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Condition   string     `json:"condition"`
+	Disabled    bool       `json:"disabled,omitempty"`
+	Active      bool       `json:"active"`
+	Since       *time.Time `json:"since,omitempty"`
+	Triggers    int        `json:"triggers"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// ruleState ist der Laufzeitzustand einer Regel
+type ruleState struct {
+	active    bool
+	since     time.Time
+	triggers  int
+	lastError string
+
+	// alarms sind die von der Regel ausgelösten Alarme, die beim Zurücksetzen aufgehoben werden
+	alarms []event.Alarm
+}
+
+// transition ist ein Wechsel einer Regel, dessen Aktionen ausgeführt werden
+type transition struct {
+	index  int
+	active bool
+}
+
+// Engine bewertet die Regeln zyklisch und führt beim Wechsel einer Regel deren Aktionen aus.
+// Die Aktionen laufen nacheinander in einem eigenen Worker, damit ein langsamer Befehl die
+// Bewertung nicht aufhält.
+type Engine struct {
+	rules    []Rule
+	registry *device.Registry
+	bus      *event.Bus
+	commands *command.Manager
+	logger   *log.Logger
+
+	// setAttributes übernimmt die Attribute einer attribute-Aktion (lokal und in ThingsBoard)
+	setAttributes func(attributes map[string]interface{})
+
+	samples *event.SampleCache
+
+	mutex      sync.Mutex
+	attributes map[string]interface{}
+	ruleStates []ruleState
+
+	// statePath speichert die zuletzt bekannten Attribute (leer = nicht gespeichert)
+	statePath string
+
+	transitions chan transition
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+}
+
+// NewEngine erstellt eine Engine aus den Regeln der Anwendungskonfiguration. Ungültige Regeln
+// führen zu einem Fehler, damit keine Automatisierung unbemerkt fehlt.
+func NewEngine(configs []config.RuleConfig, registry *device.Registry, commands *command.Manager, setAttributes func(map[string]interface{})) (*Engine, error) {
+	rules, errs := ParseRules(configs)
+	if len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return nil, fmt.Errorf("ungültige Regeln: %s", strings.Join(messages, "; "))
+	}
+
+	return &Engine{
+		rules:         rules,
+		registry:      registry,
+		bus:           registry.EventBus(),
+		commands:      commands,
+		logger:        log.New(os.Stdout, "[Rule] ", log.LstdFlags),
+		setAttributes: setAttributes,
+		samples:       event.NewSampleCache(),
+		attributes:    make(map[string]interface{}),
+		ruleStates:    make([]ruleState, len(rules)),
+		transitions:   make(chan transition, queueSize),
+		stopChan:      make(chan struct{}),
+	}, nil
+}
+
+// Rules gibt die geprüften Regeln zurück
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Start abonniert die Messwerte auf dem Event-Bus, bewertet die Regeln zyklisch und startet
+// den Worker für die Aktionen
+func (e *Engine) Start() error {
+	if err := e.bus.Subscribe(subscriberID, e.samples.Handle, event.SubscriberOptions{}, event.TopicReadingProduced); err != nil {
+		return fmt.Errorf("fehler beim Abonnieren der Messwerte: %w", err)
+	}
+
+	e.wg.Add(2)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(evaluateInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.stopChan:
+				return
+			case now := <-ticker.C:
+				e.Evaluate(now)
+			}
+		}
+	}()
+	go func() {
+		defer e.wg.Done()
+
+		for {
+			select {
+			case <-e.stopChan:
+				return
+			case t := <-e.transitions:
+				e.execute(t)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop beendet Bewertung und Worker. Noch nicht ausgeführte Aktionen werden verworfen.
+func (e *Engine) Stop() {
+	close(e.stopChan)
+	e.wg.Wait()
+	e.bus.Unsubscribe(subscriberID)
+}
+
+// SetStatePath legt die Zustandsdatei der Attribute fest und übernimmt die dort gespeicherten
+// Werte, damit Attributbedingungen nach einem Neustart ohne Verbindung zu ThingsBoard gelten.
+// Bereits übernommene Attribute haben Vorrang. Eine beschädigte Datei wird beiseitegelegt.
+func (e *Engine) SetStatePath(path string) error {
+	st, err := loadState(path)
+	if errors.Is(err, statefile.ErrCorrupt) {
+		e.logger.Printf("Gespeicherte Attribute verworfen: %v", err)
+	} else if err != nil {
+		// Ohne gelesenen Zustand würde der nächste Schreibvorgang die Attribute überschreiben
+		return fmt.Errorf("fehler beim Laden der Attribute: %w", err)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.statePath = path
+	for key, value := range st.Attributes {
+		if _, exists := e.attributes[key]; !exists {
+			e.attributes[key] = value
+		}
+	}
+	if len(st.Attributes) > 0 {
+		e.logger.Printf("%d gespeicherte Attribute vom %s übernommen", len(st.Attributes), st.Updated.Local().Format("02.01.2006 15:04:05"))
+	}
+	return nil
+}
+
+// ApplyAttributes übernimmt Shared Attributes für Attributbedingungen. Die zuletzt bekannten
+// Werte bleiben bei einem Verbindungsabbruch erhalten und werden in der Zustandsdatei
+// gespeichert (SetStatePath).
+func (e *Engine) ApplyAttributes(attributes map[string]interface{}) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	changed := false
+	for key, value := range attributes {
+		if previous, exists := e.attributes[key]; !exists || !reflect.DeepEqual(previous, value) {
+			changed = true
+		}
+		e.attributes[key] = value
+	}
+
+	if changed && e.statePath != "" {
+		if err := saveState(e.statePath, state{Attributes: e.attributes, Updated: time.Now()}); err != nil {
+			e.logger.Printf("Fehler beim Speichern der Attribute: %v", err)
+		}
+	}
+}
+
+// Evaluate bewertet alle Regeln und reiht die Aktionen gewechselter Regeln zur Ausführung ein
+func (e *Engine) Evaluate(now time.Time) {
+	var changed []transition
+
+	e.mutex.Lock()
+	for i, r := range e.rules {
+		if r.Disabled {
+			continue
+		}
+
+		active := e.evaluate(r.Condition, r.MaxAge, now)
+		state := &e.ruleStates[i]
+		if active == state.active {
+			continue
+		}
+
+		state.active = active
+		state.since = now
+		if active {
+			state.triggers++
+		}
+		changed = append(changed, transition{index: i, active: active})
+	}
+	e.mutex.Unlock()
+
+	for _, t := range changed {
+		select {
+		case e.transitions <- t:
+		case <-e.stopChan:
+			return
+		}
+	}
+}
+
+// evaluate bewertet eine Bedingung, der Aufrufer hält die Sperre. Verschachtelte Bedingungen
+// werden immer alle bewertet, damit ihre Mindestdauern weiterlaufen.
+func (e *Engine) evaluate(condition *Condition, maxAge time.Duration, now time.Time) bool {
+	fulfilled := false
+
+	switch {
+	case condition.All != nil:
+		fulfilled = true
+		for _, child := range condition.All {
+			if !e.evaluate(child, maxAge, now) {
+				fulfilled = false
+			}
+		}
+	case condition.Any != nil:
+		for _, child := range condition.Any {
+			if e.evaluate(child, maxAge, now) {
+				fulfilled = true
+			}
+		}
+	case condition.Window != nil:
+		fulfilled = condition.Window.Contains(now)
+	default:
+		if value, found := e.lookup(condition, maxAge, now); found {
+			fulfilled = compare(condition, value)
+		}
+	}
+	condition.fulfilled = fulfilled
+
+	if condition.For == 0 {
+		return fulfilled
+	}
+	if !fulfilled {
+		condition.since = time.Time{}
+		return false
+	}
+	if condition.since.IsZero() {
+		condition.since = now
+	}
+	return now.Sub(condition.since) >= condition.For
+}
+
+// lookup sucht den Wert einer Bedingung, der Aufrufer hält die Sperre. Fehlende und veraltete
+// Messwerte gelten als nicht vorhanden.
+func (e *Engine) lookup(condition *Condition, maxAge time.Duration, now time.Time) (interface{}, bool) {
+	if condition.Attribute != "" {
+		value, exists := e.attributes[condition.Attribute]
+		return value, exists
+	}
+
+	reading, readingExists := e.samples.Reading(condition.Device)
+	state, stateExists := e.samples.State(condition.Device)
+
+	var found event.Sample
+	var value interface{}
+	switch condition.Field {
+	case "", interlock.FieldValue:
+		if readingExists {
+			found, value = reading, reading.Reading.Value
+		} else if stateExists {
+			found, value = state, state.Reading.Value
+		}
+	case interlock.FieldState:
+		if stateExists {
+			found, value = state, state.Reading.Value
+		}
+	default:
+		if readingExists {
+			if metadata, ok := reading.Reading.Metadata[condition.Field]; ok {
+				found, value = reading, metadata
+			}
+		}
+	}
+
+	if found.At.IsZero() || now.Sub(found.At) > maxAge {
+		return nil, false
+	}
+	return value, true
+}
+
+// compare vergleicht einen Wert mit einer Bedingung. Mit Hysterese bleibt eine erfüllte
+// Schwellwertbedingung erfüllt, bis der Wert die Schwelle um die Hysterese unterschritten
+// (bzw. bei < und <= überschritten) hat.
+func compare(condition *Condition, value interface{}) bool {
+	threshold, thresholdIsNumber := toFloat(condition.Value)
+	actual, actualIsNumber := toFloat(value)
+
+	if condition.Hysteresis > 0 && condition.fulfilled && thresholdIsNumber && actualIsNumber {
+		switch condition.Operator {
+		case ">", ">=":
+			return actual > threshold-condition.Hysteresis
+		case "<", "<=":
+			return actual < threshold+condition.Hysteresis
+		}
+	}
+
+	return interlock.Compare(value, condition.Operator, condition.Value)
+}
+
+// execute führt die Aktionen eines Regelwechsels aus. Beim Zurücksetzen werden die von der
+// Regel ausgelösten Alarme aufgehoben.
+func (e *Engine) execute(t transition) {
+	r := e.rules[t.index]
+
+	actions := r.Actions
+	if t.active {
+		e.logger.Printf("Regel %s aktiv: %s", r.Name, r.Condition)
+	} else {
+		actions = r.ClearActions
+		e.logger.Printf("Regel %s zurückgesetzt", r.Name)
+	}
+
+	message := r.Description
+	if message == "" {
+		message = r.Condition.String()
+	}
+
+	var errs []string
+	var raised []event.Alarm
+	for _, action := range actions {
+		alarm, err := e.executeAction(action, command.OriginRule, r.Name, message)
+		if err != nil {
+			e.logger.Printf("Regel %s: %s fehlgeschlagen: %v", r.Name, action, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", action, err))
+		}
+		if alarm != nil {
+			raised = append(raised, *alarm)
+		}
+	}
+
+	e.mutex.Lock()
+	state := &e.ruleStates[t.index]
+	state.lastError = strings.Join(errs, "; ")
+	cleared := state.alarms
+	if t.active {
+		state.alarms = append(state.alarms, raised...)
+		cleared = nil
+	} else {
+		state.alarms = nil
+	}
+	e.mutex.Unlock()
+
+	for _, alarm := range cleared {
+		alarm.Active = false
+		alarm.Severity = event.SeverityInfo
+		alarm.Message = fmt.Sprintf("regel %s zurückgesetzt", r.Name)
+		e.bus.Publish(event.NewAlarmEvent(alarm))
+	}
+}
+
+// Check bewertet eine Bedingung außerhalb einer Regel (z.B. für Ablaufsteuerungen) mit den
+// Messwerten und Attributen der Engine. Hysterese und Mindestdauer beziehen sich auf die
+// vorherigen Aufrufe mit derselben Bedingung.
+func (e *Engine) Check(condition *Condition, maxAge time.Duration, now time.Time) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.evaluate(condition, maxAge, now)
+}
+
+// Perform führt eine Aktion außerhalb einer Regel aus. Befehle tragen den Auslöser origin und
+// issuer, Alarme ohne eigene Meldung erhalten message.
+func (e *Engine) Perform(action Action, origin, issuer, message string) error {
+	_, err := e.executeAction(action, origin, issuer, message)
+	return err
+}
+
+// executeAction führt eine Aktion aus und gibt einen ausgelösten Alarm zurück
+func (e *Engine) executeAction(action Action, origin, issuer, defaultMessage string) (*event.Alarm, error) {
+	switch action.Type {
+	case config.RuleActionCommand:
+		record := e.commands.Submit(command.Request{
+			DeviceID: action.Device,
+			Command:  action.Command,
+			Origin:   origin,
+			Issuer:   issuer,
+		})
+		if !record.Succeeded() {
+			return nil, fmt.Errorf("%s: %s", record.Status, record.Error)
+		}
+
+	case config.RuleActionAlarm:
+		message := action.Message
+		if message == "" {
+			message = defaultMessage
+		}
+		alarm := event.Alarm{
+			Name:     action.Name,
+			DeviceID: action.Device,
+			Severity: action.Severity,
+			Active:   true,
+			Message:  message,
+		}
+		e.bus.Publish(event.NewAlarmEvent(alarm))
+		return &alarm, nil
+
+	case config.RuleActionAttribute:
+		attributes := map[string]interface{}{action.Name: action.Value}
+		e.ApplyAttributes(attributes)
+		if e.setAttributes != nil {
+			e.setAttributes(attributes)
+		}
+
+	case config.RuleActionEnableDevice, config.RuleActionDisableDevice:
+		dev, err := e.registry.GetDevice(action.Device)
+		if err != nil {
+			return nil, err
+		}
+		dev.Enable(action.Type == config.RuleActionEnableDevice)
+	}
+
+	return nil, nil
+}
+
+// Status gibt den Zustand aller Regeln zurück, sortiert nach Name
+func (e *Engine) Status() []Status {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	statuses := make([]Status, 0, len(e.rules))
+	for i, r := range e.rules {
+		state := e.ruleStates[i]
+		status := Status{
+			Name:        r.Name,
+			Description: r.Description,
+			Condition:   r.Condition.String(),
+			Disabled:    r.Disabled,
+			Active:      state.active,
+			Triggers:    state.triggers,
+			LastError:   state.lastError,
+		}
+		if !state.since.IsZero() {
+			since := state.since
+			status.Since = &since
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
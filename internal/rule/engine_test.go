@@ -0,0 +1,173 @@
+package rule
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/device"
+)
+
+// TestCompareHysteresis prüft die Hysterese über eine Folge von Messwerten. Wie in evaluate
+// wird das Ergebnis jedes Vergleichs als vorheriges Ergebnis des nächsten festgehalten.
+func TestCompareHysteresis(t *testing.T) {
+	tests := []struct {
+		name       string
+		operator   string
+		threshold  interface{}
+		hysteresis float64
+		values     []interface{}
+		want       []bool
+	}{
+		{
+			// Erfüllt ab 200, zurückgesetzt erst bei 190 oder darunter
+			name:       "größer",
+			operator:   ">",
+			threshold:  200.0,
+			hysteresis: 10,
+			values:     []interface{}{195.0, 200.0, 200.5, 199.0, 190.5, 190.0, 195.0, 201.0},
+			want:       []bool{false, false, true, true, true, false, false, true},
+		},
+		{
+			name:       "größer gleich",
+			operator:   ">=",
+			threshold:  200.0,
+			hysteresis: 10,
+			values:     []interface{}{199.9, 200.0, 190.1, 190.0, 199.0},
+			want:       []bool{false, true, true, false, false},
+		},
+		{
+			// Erfüllt unter 5, zurückgesetzt erst bei 5,5 oder darüber
+			name:       "kleiner",
+			operator:   "<",
+			threshold:  5.0,
+			hysteresis: 0.5,
+			values:     []interface{}{6.0, 5.0, 4.9, 5.2, 5.49, 5.5, 5.2, 4.0},
+			want:       []bool{false, false, true, true, true, false, false, true},
+		},
+		{
+			name:       "kleiner gleich",
+			operator:   "<=",
+			threshold:  5.0,
+			hysteresis: 0.5,
+			values:     []interface{}{5.1, 5.0, 5.4, 5.5, 5.1},
+			want:       []bool{false, true, true, false, false},
+		},
+		{
+			name:       "ohne Hysterese",
+			operator:   ">",
+			threshold:  200.0,
+			hysteresis: 0,
+			values:     []interface{}{201.0, 200.0, 199.0, 201.0},
+			want:       []bool{true, false, false, true},
+		},
+		{
+			name:       "ganzzahlige Werte",
+			operator:   ">",
+			threshold:  100,
+			hysteresis: 5,
+			values:     []interface{}{int64(101), 96, float32(95), int64(100)},
+			want:       []bool{true, true, false, false},
+		},
+		{
+			// Zustände werden ohne Hysterese auf Gleichheit geprüft
+			name:       "nicht numerischer Wert",
+			operator:   ">",
+			threshold:  200.0,
+			hysteresis: 10,
+			values:     []interface{}{201.0, "195", true, 195.0},
+			want:       []bool{true, false, false, false},
+		},
+		{
+			name:       "Gleichheit",
+			operator:   "==",
+			threshold:  true,
+			hysteresis: 0,
+			values:     []interface{}{true, false, true},
+			want:       []bool{true, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := &Condition{
+				Device:     "tank_level",
+				Operator:   tt.operator,
+				Value:      tt.threshold,
+				Hysteresis: tt.hysteresis,
+			}
+
+			for i, value := range tt.values {
+				got := compare(condition, value)
+				if got != tt.want[i] {
+					t.Fatalf("Wert %d (%v): %v, erwartet %v", i+1, value, got, tt.want[i])
+				}
+				condition.fulfilled = got
+			}
+		})
+	}
+}
+
+// TestCompareHysteresisReset prüft, dass Reset die Hysterese verwirft
+func TestCompareHysteresisReset(t *testing.T) {
+	condition := &Condition{Device: "tank_level", Operator: ">", Value: 200.0, Hysteresis: 10}
+
+	condition.fulfilled = compare(condition, 205.0)
+	if !compare(condition, 195.0) {
+		t.Fatalf("195 innerhalb der Hysterese erwartet erfüllt")
+	}
+
+	condition.Reset()
+	if compare(condition, 195.0) {
+		t.Errorf("195 nach Reset erwartet nicht erfüllt")
+	}
+}
+
+// newTestEngine erstellt eine Engine ohne Regeln und Befehlsverwaltung
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	registry := device.NewRegistry()
+	t.Cleanup(registry.Close)
+	e, err := NewEngine(nil, registry, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// TestAttributesRestart prüft, dass die zuletzt bekannten Attribute einen Neustart ohne
+// Verbindung überdauern und neuere Attribute Vorrang haben
+func TestAttributesRestart(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied map[string]interface{}
+		want    interface{}
+	}{
+		{name: "gespeicherter Wert", want: true},
+		{name: "neuerer Wert vor dem Laden", applied: map[string]interface{}{"dosing_enabled": false}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rule_attributes.json")
+
+			before := newTestEngine(t)
+			if err := before.SetStatePath(path); err != nil {
+				t.Fatal(err)
+			}
+			before.ApplyAttributes(map[string]interface{}{"dosing_enabled": true})
+
+			after := newTestEngine(t)
+			after.ApplyAttributes(tt.applied)
+			if err := after.SetStatePath(path); err != nil {
+				t.Fatal(err)
+			}
+
+			value, found := after.lookup(&Condition{Attribute: "dosing_enabled"}, time.Minute, time.Now())
+			if !found || value != tt.want {
+				t.Errorf("Attribut %v (vorhanden %v), erwartet %v", value, found, tt.want)
+			}
+		})
+	}
+}
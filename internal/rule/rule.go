@@ -0,0 +1,441 @@
+// Package rule implementiert lokale Automatisierungsregeln. Regeln werden in der
+// Anwendungskonfiguration über Messwerte, Aktorzustände, Shared Attributes und Zeitfenster
+// beschrieben (z.B. "Belüftung ein, wenn der Sauerstoff 10 Minuten unter 2 mg/l liegt") und
+// auf dem Gerät ausgewertet, so dass sie auch ohne Verbindung zu ThingsBoard weiterlaufen.
+package rule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/interlock"
+	"owipex_reader/internal/types"
+)
+
+// DefaultMaxAge ist das Höchstalter eines Messwerts, der in einer Bedingung verwendet wird
+const DefaultMaxAge = interlock.DefaultMaxAge
+
+// weekdays ordnet die Kurznamen der Wochentage zu
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Rule ist eine geprüfte Regel
+type Rule struct {
+	Name         string
+	Description  string
+	Disabled     bool
+	Condition    *Condition
+	Actions      []Action
+	ClearActions []Action
+	MaxAge       time.Duration
+}
+
+// Condition ist eine geprüfte Bedingung. Hysterese und Mindestdauer benötigen das Ergebnis der
+// vorherigen Bewertung, das die Engine in fulfilled und since festhält.
+type Condition struct {
+	All []*Condition
+	Any []*Condition
+
+	Device    string
+	Field     string
+	Attribute string
+	Operator  string
+	Value     interface{}
+
+	Hysteresis float64
+	Window     *TimeWindow
+	For        time.Duration
+
+	// fulfilled ist das letzte Ergebnis ohne Mindestdauer, since der Beginn der Erfüllung
+	fulfilled bool
+	since     time.Time
+}
+
+// String gibt die Bedingung lesbar aus (z.B. "tank_level > 200 für 30s")
+func (c *Condition) String() string {
+	var text string
+	switch {
+	case c.All != nil:
+		text = "(" + joinConditions(c.All, " und ") + ")"
+	case c.Any != nil:
+		text = "(" + joinConditions(c.Any, " oder ") + ")"
+	case c.Window != nil:
+		text = "zeit " + c.Window.String()
+	case c.Attribute != "":
+		text = fmt.Sprintf("attribut %s %s %v", c.Attribute, c.Operator, c.Value)
+	default:
+		subject := c.Device
+		if c.Field != "" && c.Field != interlock.FieldValue {
+			subject += "." + c.Field
+		}
+		text = fmt.Sprintf("%s %s %v", subject, c.Operator, c.Value)
+	}
+
+	if c.For > 0 {
+		text += fmt.Sprintf(" für %s", c.For)
+	}
+	return text
+}
+
+// Reset verwirft das Ergebnis der vorherigen Bewertung, Hysterese und Mindestdauer beginnen
+// damit neu
+func (c *Condition) Reset() {
+	c.fulfilled = false
+	c.since = time.Time{}
+	for _, child := range c.All {
+		child.Reset()
+	}
+	for _, child := range c.Any {
+		child.Reset()
+	}
+}
+
+// joinConditions verbindet die Texte mehrerer Bedingungen
+func joinConditions(conditions []*Condition, separator string) string {
+	texts := make([]string, len(conditions))
+	for i, condition := range conditions {
+		texts[i] = condition.String()
+	}
+	return strings.Join(texts, separator)
+}
+
+// TimeWindow ist ein tägliches Zeitfenster in Minuten seit Mitternacht (Ortszeit)
+type TimeWindow struct {
+	From int
+	To   int
+
+	// Days sind die erlaubten Wochentage (leer = jeden Tag)
+	Days map[time.Weekday]bool
+}
+
+// Contains prüft, ob ein Zeitpunkt im Zeitfenster liegt. Bei Fenstern über Mitternacht zählt
+// der Wochentag, an dem das Fenster beginnt.
+func (w *TimeWindow) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	if w.From < w.To {
+		return minute >= w.From && minute < w.To && w.onDay(day)
+	}
+	if minute >= w.From {
+		return w.onDay(day)
+	}
+	if minute < w.To {
+		return w.onDay((day + 6) % 7)
+	}
+	return false
+}
+
+// onDay prüft, ob das Fenster an einem Wochentag gilt
+func (w *TimeWindow) onDay(day time.Weekday) bool {
+	return len(w.Days) == 0 || w.Days[day]
+}
+
+// String gibt das Zeitfenster im Format der Konfiguration aus
+func (w *TimeWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.From/60, w.From%60, w.To/60, w.To%60)
+}
+
+// Action ist eine geprüfte Aktion einer Regel
+type Action struct {
+	Type     string
+	Device   string
+	Command  types.Command
+	Name     string
+	Value    interface{}
+	Severity event.AlarmSeverity
+	Message  string
+}
+
+// String gibt die Aktion lesbar aus
+func (a Action) String() string {
+	switch a.Type {
+	case config.RuleActionCommand:
+		return fmt.Sprintf("%s %v an %s", a.Command.Type, a.Command.Value, a.Device)
+	case config.RuleActionAlarm:
+		return "alarm " + a.Name
+	case config.RuleActionAttribute:
+		return fmt.Sprintf("attribut %s = %v", a.Name, a.Value)
+	default:
+		return a.Type + " " + a.Device
+	}
+}
+
+// ParseRules prüft die Regeln der Anwendungskonfiguration und wandelt sie um.
+// Alle Fehler werden gesammelt zurückgegeben, ungültige Regeln werden ausgelassen.
+func ParseRules(configs []config.RuleConfig) ([]Rule, []error) {
+	var rules []Rule
+	var errs []error
+	names := make(map[string]bool)
+
+	for i, cfg := range configs {
+		parsed, err := parseRule(cfg)
+		if err == nil && names[parsed.Name] {
+			err = fmt.Errorf("name ist bereits vergeben")
+		}
+		if err != nil {
+			label := cfg.Name
+			if label == "" {
+				label = fmt.Sprintf("#%d", i)
+			}
+			errs = append(errs, fmt.Errorf("regel %s: %w", label, err))
+			continue
+		}
+
+		names[parsed.Name] = true
+		rules = append(rules, parsed)
+	}
+
+	return rules, errs
+}
+
+// parseRule prüft eine Regel
+func parseRule(cfg config.RuleConfig) (Rule, error) {
+	if cfg.Name == "" {
+		return Rule{}, fmt.Errorf("kein Name angegeben")
+	}
+	if len(cfg.Actions) == 0 && len(cfg.ClearActions) == 0 {
+		return Rule{}, fmt.Errorf("keine Aktionen (actions) angegeben")
+	}
+	if cfg.MaxAgeSeconds < 0 {
+		return Rule{}, fmt.Errorf("max_age_seconds darf nicht negativ sein")
+	}
+
+	condition, err := ParseCondition(cfg.Condition)
+	if err != nil {
+		return Rule{}, fmt.Errorf("bedingung: %w", err)
+	}
+
+	parsed := Rule{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		Disabled:    cfg.Disabled,
+		Condition:   condition,
+		MaxAge:      DefaultMaxAge,
+	}
+	if cfg.MaxAgeSeconds > 0 {
+		parsed.MaxAge = time.Duration(cfg.MaxAgeSeconds) * time.Second
+	}
+
+	for i, actionCfg := range cfg.Actions {
+		action, err := ParseAction(actionCfg, cfg.Name)
+		if err != nil {
+			return Rule{}, fmt.Errorf("aktion %d: %w", i, err)
+		}
+		parsed.Actions = append(parsed.Actions, action)
+	}
+	for i, actionCfg := range cfg.ClearActions {
+		action, err := ParseAction(actionCfg, cfg.Name)
+		if err != nil {
+			return Rule{}, fmt.Errorf("clear_actions %d: %w", i, err)
+		}
+		parsed.ClearActions = append(parsed.ClearActions, action)
+	}
+
+	return parsed, nil
+}
+
+// ParseCondition prüft eine Bedingung und ihre verschachtelten Bedingungen
+func ParseCondition(cfg config.RuleCondition) (*Condition, error) {
+	kinds := 0
+	for _, set := range []bool{cfg.All != nil, cfg.Any != nil, cfg.Device != "", cfg.Attribute != "", cfg.TimeWindow != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, fmt.Errorf("genau eines von all, any, device, attribute oder time_window angeben")
+	}
+	if cfg.ForSeconds < 0 {
+		return nil, fmt.Errorf("for_seconds darf nicht negativ sein")
+	}
+
+	condition := &Condition{
+		Device:     cfg.Device,
+		Field:      cfg.Field,
+		Attribute:  cfg.Attribute,
+		Operator:   cfg.Operator,
+		Value:      cfg.Value,
+		Hysteresis: cfg.Hysteresis,
+		For:        time.Duration(cfg.ForSeconds) * time.Second,
+	}
+
+	switch {
+	case cfg.All != nil || cfg.Any != nil:
+		children, label := cfg.All, "all"
+		if cfg.Any != nil {
+			children, label = cfg.Any, "any"
+		}
+		if len(children) == 0 {
+			return nil, fmt.Errorf("%s enthält keine Bedingungen", label)
+		}
+		for i, childCfg := range children {
+			child, err := ParseCondition(childCfg)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", label, i, err)
+			}
+			if label == "all" {
+				condition.All = append(condition.All, child)
+			} else {
+				condition.Any = append(condition.Any, child)
+			}
+		}
+
+	case cfg.TimeWindow != nil:
+		window, err := ParseTimeWindow(*cfg.TimeWindow)
+		if err != nil {
+			return nil, fmt.Errorf("time_window: %w", err)
+		}
+		condition.Window = window
+
+	default:
+		if !contains(interlock.Operators, cfg.Operator) {
+			return nil, fmt.Errorf("unbekannter Operator %q (erlaubt: %s)", cfg.Operator, strings.Join(interlock.Operators, " "))
+		}
+		if cfg.Value == nil {
+			return nil, fmt.Errorf("kein Vergleichswert (value) angegeben")
+		}
+		_, isNumber := toFloat(cfg.Value)
+		if !isNumber && cfg.Operator != "==" && cfg.Operator != "!=" {
+			return nil, fmt.Errorf("operator %s erfordert einen Zahlenwert", cfg.Operator)
+		}
+		if cfg.Hysteresis < 0 {
+			return nil, fmt.Errorf("hysteresis darf nicht negativ sein")
+		}
+		if cfg.Hysteresis > 0 && (cfg.Operator == "==" || cfg.Operator == "!=") {
+			return nil, fmt.Errorf("hysteresis ist nur mit >, >=, < und <= möglich")
+		}
+		return condition, nil
+	}
+
+	if cfg.Operator != "" || cfg.Value != nil || cfg.Hysteresis != 0 || cfg.Field != "" {
+		return nil, fmt.Errorf("operator, value, hysteresis und field sind nur mit device oder attribute möglich")
+	}
+	return condition, nil
+}
+
+// ParseTimeWindow prüft ein tägliches Zeitfenster (auch für Sperrzeiten der Zeitpläne)
+func ParseTimeWindow(cfg config.RuleTimeWindow) (*TimeWindow, error) {
+	from, err := parseTimeOfDay(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	to, err := parseTimeOfDay(cfg.To)
+	if err != nil {
+		return nil, fmt.Errorf("to: %w", err)
+	}
+	if from == to {
+		return nil, fmt.Errorf("from und to sind gleich")
+	}
+
+	window := &TimeWindow{From: from, To: to}
+	for _, name := range cfg.Days {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unbekannter Wochentag %q (erlaubt: mon, tue, wed, thu, fri, sat, sun)", name)
+		}
+		if window.Days == nil {
+			window.Days = make(map[time.Weekday]bool)
+		}
+		window.Days[day] = true
+	}
+	return window, nil
+}
+
+// parseTimeOfDay wandelt eine Uhrzeit "HH:MM" in Minuten seit Mitternacht um
+func parseTimeOfDay(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("ungültige Uhrzeit %q (Format HH:MM)", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// ParseAction prüft eine Aktion. Alarme ohne Namen erhalten defaultName.
+func ParseAction(cfg config.RuleAction, defaultName string) (Action, error) {
+	action := Action{
+		Type:    strings.ToLower(cfg.Type),
+		Device:  cfg.Device,
+		Name:    cfg.Name,
+		Value:   cfg.Value,
+		Message: cfg.Message,
+	}
+
+	switch action.Type {
+	case config.RuleActionCommand:
+		if cfg.Device == "" || cfg.Command == "" {
+			return action, fmt.Errorf("device und command sind erforderlich")
+		}
+		action.Command = types.Command{
+			Type:       types.CommandType(strings.ToUpper(cfg.Command)),
+			Value:      cfg.Value,
+			Parameters: cfg.Parameters,
+		}
+
+	case config.RuleActionAlarm:
+		if action.Name == "" {
+			action.Name = defaultName
+		}
+		action.Severity = event.SeverityWarning
+		if cfg.Severity != "" {
+			action.Severity = event.AlarmSeverity(strings.ToUpper(cfg.Severity))
+		}
+		switch action.Severity {
+		case event.SeverityInfo, event.SeverityWarning, event.SeverityCritical:
+		default:
+			return action, fmt.Errorf("unbekannte Schwere %q (erlaubt: INFO, WARNING, CRITICAL)", cfg.Severity)
+		}
+
+	case config.RuleActionAttribute:
+		if cfg.Name == "" || cfg.Value == nil {
+			return action, fmt.Errorf("name und value sind erforderlich")
+		}
+
+	case config.RuleActionEnableDevice, config.RuleActionDisableDevice:
+		if cfg.Device == "" {
+			return action, fmt.Errorf("device ist erforderlich")
+		}
+
+	default:
+		return action, fmt.Errorf("unbekannter Aktionstyp %q (erlaubt: %s, %s, %s, %s, %s)", cfg.Type,
+			config.RuleActionCommand, config.RuleActionAlarm, config.RuleActionAttribute,
+			config.RuleActionEnableDevice, config.RuleActionDisableDevice)
+	}
+
+	return action, nil
+}
+
+// contains prüft, ob ein Wert in einer Liste enthalten ist
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat wandelt Zahlenwerte um
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,31 @@
+package rule
+
+import (
+	"time"
+
+	"owipex_reader/internal/statefile"
+)
+
+// state ist der Inhalt der Zustandsdatei: die zuletzt bekannten Shared Attributes
+type state struct {
+	Attributes map[string]interface{} `json:"attributes"`
+	Updated    time.Time              `json:"updated"`
+}
+
+// loadState liest die Zustandsdatei. Fehlt sie, ist der Zustand leer. Eine beschädigte Datei
+// wird beiseitegelegt (statefile.ErrCorrupt).
+func loadState(path string) (state, error) {
+	var st state
+	if err := statefile.Load(path, &st); err != nil {
+		return state{Attributes: make(map[string]interface{})}, err
+	}
+	if st.Attributes == nil {
+		st.Attributes = make(map[string]interface{})
+	}
+	return st, nil
+}
+
+// saveState schreibt die Zustandsdatei
+func saveState(path string, st state) error {
+	return statefile.Save(path, st)
+}
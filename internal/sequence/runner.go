@@ -0,0 +1,659 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/rule"
+	"owipex_reader/internal/statefile"
+)
+
+// Zustände einer Schrittkette
+const (
+	StateIdle      = "idle"
+	StateRunning   = "running"
+	StatePaused    = "paused"
+	StateCompleted = "completed"
+	StateAborted   = "aborted"
+)
+
+const (
+	// tickInterval ist das Intervall, in dem die aktiven Schritte bewertet werden
+	tickInterval = time.Second
+
+	// telemetryInterval ist das Intervall, in dem laufende Schrittketten ihren Fortschritt
+	// auch ohne Schrittwechsel senden
+	telemetryInterval = 10 * time.Second
+
+	// persistInterval ist das Intervall, in dem die Zeit im aktuellen Schritt gespeichert wird.
+	// Schrittwechsel werden sofort gespeichert.
+	persistInterval = time.Minute
+)
+
+// Status ist der Zustand einer Schrittkette
+type Status struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	State       string     `json:"state"`
+	Step        string     `json:"step,omitempty"`
+	StepIndex   int        `json:"step_index"`
+	Steps       []string   `json:"steps"`
+	StepElapsed float64    `json:"step_elapsed_seconds"`
+	Progress    float64    `json:"progress"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+}
+
+// run ist der Laufzeitzustand einer Schrittkette
+type run struct {
+	record
+
+	// step ist der Index des aktuellen Schritts, -1 ohne aktiven Schritt
+	step      int
+	stepStart time.Time
+
+	// elapsed ist die Zeit im Schritt beim Pausieren bzw. beim Neustart
+	elapsed time.Duration
+
+	// resume führt die Aktionen des Schritts beim Start des Runners erneut aus (Neustart
+	// während eines Laufs)
+	resume bool
+
+	lastPublish time.Time
+	lastPersist time.Time
+}
+
+// stepElapsed gibt die Zeit im aktuellen Schritt zurück
+func (r *run) stepElapsed(now time.Time) time.Duration {
+	if r.State == StateRunning && !r.stepStart.IsZero() {
+		return now.Sub(r.stepStart)
+	}
+	return r.elapsed
+}
+
+// Runner führt die Schrittketten aus. Bedingungen werden mit den Messwerten der Regel-Engine
+// bewertet, Aktionen laufen wie bei Regeln über die Befehlsverwaltung.
+type Runner struct {
+	sequences []Sequence
+	rules     *rule.Engine
+	bus       *event.Bus
+	logger    *log.Logger
+	statePath string
+
+	// publish sendet Schritt und Fortschritt als Telemetrie an ThingsBoard
+	publish func(data map[string]interface{})
+
+	// opMutex hält Bewertung und RPC-Methoden nacheinander, auch während Aktionen laufen
+	opMutex sync.Mutex
+
+	// mutex schützt runs für Status und Zustandsdatei
+	mutex sync.Mutex
+	runs  []*run
+
+	// saveMutex hält die Reihenfolge der Schreibvorgänge in die Zustandsdatei ein
+	saveMutex sync.Mutex
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewRunner erstellt den Runner aus der Konfiguration und stellt den gespeicherten Zustand
+// wieder her. Ungültige Schrittketten und eine nicht lesbare Zustandsdatei führen zu einem
+// Fehler; eine beschädigte Zustandsdatei wird beiseitegelegt und alle Schrittketten beginnen
+// im Ruhezustand.
+func NewRunner(cfg config.SequencerConfig, rules *rule.Engine, bus *event.Bus, publish func(map[string]interface{})) (*Runner, error) {
+	sequences, errs := ParseSequences(cfg.Sequences)
+	if len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return nil, fmt.Errorf("ungültige Schrittketten: %s", strings.Join(messages, "; "))
+	}
+
+	r := &Runner{
+		sequences: sequences,
+		rules:     rules,
+		bus:       bus,
+		logger:    log.New(os.Stdout, "[Sequence] ", log.LstdFlags),
+		statePath: cfg.StatePath,
+		publish:   publish,
+		runs:      make([]*run, len(sequences)),
+		stopChan:  make(chan struct{}),
+	}
+
+	st, err := loadState(cfg.StatePath)
+	if errors.Is(err, statefile.ErrCorrupt) {
+		r.logger.Printf("Schrittketten beginnen im Ruhezustand: %v", err)
+	} else if err != nil {
+		// Ohne gelesenen Zustand würde der nächste Schreibvorgang die Position laufender
+		// Schrittketten überschreiben
+		return nil, fmt.Errorf("fehler beim Laden des Ablaufzustands: %w", err)
+	}
+	for i := range sequences {
+		r.runs[i] = r.restore(&sequences[i], st.Sequences[sequences[i].Name])
+	}
+
+	return r, nil
+}
+
+// restore erstellt den Laufzeitzustand einer Schrittkette aus dem gespeicherten Zustand
+func (r *Runner) restore(seq *Sequence, saved record) *run {
+	restored := &run{record: saved, step: -1}
+	if restored.State == "" {
+		restored.State = StateIdle
+	}
+	if restored.State != StateRunning && restored.State != StatePaused {
+		return restored
+	}
+
+	index := seq.StepIndex(saved.Step)
+	if index < 0 {
+		r.logger.Printf("Schrittkette %s: gespeicherter Schritt %q existiert nicht mehr, Lauf gilt als abgebrochen", seq.Name, saved.Step)
+		restored.State = StateAborted
+		restored.Reason = fmt.Sprintf("schritt %q existiert nach Neustart nicht mehr", saved.Step)
+		return restored
+	}
+
+	restored.step = index
+	restored.elapsed = time.Duration(saved.StepElapsedSeconds * float64(time.Second))
+	restored.resume = restored.State == StateRunning
+	return restored
+}
+
+// Sequences gibt die geprüften Schrittketten zurück
+func (r *Runner) Sequences() []Sequence {
+	return r.sequences
+}
+
+// Start setzt die beim Neustart laufenden Schrittketten in ihrem Schritt fort und bewertet die
+// Schritte zyklisch. Die Aktionen des Schritts werden dabei erneut ausgeführt, da die Aktoren
+// beim Herunterfahren in ihren sicheren Zustand gebracht wurden. Die Regel-Engine muss bereits
+// laufen.
+func (r *Runner) Start() error {
+	now := time.Now()
+
+	r.opMutex.Lock()
+	for i := range r.sequences {
+		seq, current := &r.sequences[i], r.runs[i]
+
+		r.mutex.Lock()
+		resume := current.resume
+		current.resume = false
+		if resume {
+			current.stepStart = now.Add(-current.elapsed)
+		}
+		r.mutex.Unlock()
+
+		if resume {
+			step := &seq.Steps[current.step]
+			r.logger.Printf("Schrittkette %s wird nach Neustart in Schritt %s fortgesetzt", seq.Name, step.Name)
+			r.resetConditions(seq, step)
+			r.runActions(i, step.Actions, now)
+		}
+		r.publishProgress(i, now)
+	}
+	r.opMutex.Unlock()
+	r.persist()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stopChan:
+				return
+			case now := <-ticker.C:
+				r.Tick(now)
+			}
+		}
+	}()
+
+	r.logger.Printf("%d Schrittketten geladen", len(r.sequences))
+	return nil
+}
+
+// Stop beendet die Bewertung und speichert die Zeit im aktuellen Schritt. Laufende
+// Schrittketten bleiben laufend und werden beim nächsten Start fortgesetzt.
+func (r *Runner) Stop() {
+	close(r.stopChan)
+	r.wg.Wait()
+
+	r.opMutex.Lock()
+	defer r.opMutex.Unlock()
+	r.persist()
+}
+
+// Tick bewertet die aktiven Schritte aller laufenden Schrittketten
+func (r *Runner) Tick(now time.Time) {
+	r.opMutex.Lock()
+	defer r.opMutex.Unlock()
+
+	for i := range r.sequences {
+		r.tickSequence(i, now)
+	}
+}
+
+// tickSequence bewertet den aktiven Schritt einer Schrittkette, der Aufrufer hält opMutex.
+// Alle Bedingungen werden in jedem Durchlauf bewertet, damit ihre Mindestdauern weiterlaufen.
+func (r *Runner) tickSequence(i int, now time.Time) {
+	seq, current := &r.sequences[i], r.runs[i]
+
+	r.mutex.Lock()
+	running := current.State == StateRunning
+	index := current.step
+	elapsed := current.stepElapsed(now)
+	r.mutex.Unlock()
+	if !running {
+		return
+	}
+	step := &seq.Steps[index]
+
+	sequenceAbort := seq.AbortIf != nil && r.rules.Check(seq.AbortIf, seq.MaxAge, now)
+	stepAbort := step.AbortIf != nil && r.rules.Check(step.AbortIf, seq.MaxAge, now)
+	until := step.Until == nil || r.rules.Check(step.Until, seq.MaxAge, now)
+
+	switch {
+	case sequenceAbort:
+		r.abort(i, "abbruchbedingung: "+seq.AbortIf.String(), now)
+	case stepAbort:
+		r.abort(i, fmt.Sprintf("abbruchbedingung in Schritt %s: %s", step.Name, step.AbortIf), now)
+	case elapsed >= step.Duration && until:
+		r.logger.Printf("Schrittkette %s: Schritt %s abgeschlossen", seq.Name, step.Name)
+		r.enter(i, step.Next, now, "")
+	case step.Timeout > 0 && elapsed >= step.Timeout:
+		r.logger.Printf("Schrittkette %s: Zeitüberschreitung in Schritt %s nach %s", seq.Name, step.Name, step.Timeout)
+		r.enter(i, step.OnTimeout, now, fmt.Sprintf("zeitüberschreitung in Schritt %s", step.Name))
+	default:
+		r.mutex.Lock()
+		publish := now.Sub(current.lastPublish) >= telemetryInterval
+		persist := now.Sub(current.lastPersist) >= persistInterval
+		r.mutex.Unlock()
+
+		if publish {
+			r.publishProgress(i, now)
+		}
+		if persist {
+			r.persist()
+		}
+	}
+}
+
+// enter wechselt in einen Schritt oder beendet die Schrittkette (stepEnd, stepAbort) und führt
+// die Aktionen des Schritts aus. reason begründet einen Abbruch. Der Aufrufer hält opMutex.
+func (r *Runner) enter(i, index int, now time.Time, reason string) {
+	seq, current := &r.sequences[i], r.runs[i]
+
+	switch index {
+	case stepAbort:
+		if reason == "" {
+			reason = "abbruch durch Schrittfolge"
+		}
+		r.abort(i, reason, now)
+		return
+	case stepEnd:
+		r.mutex.Lock()
+		current.State = StateCompleted
+		current.Step = ""
+		current.step = -1
+		current.FinishedAt = now
+		current.Reason = ""
+		r.mutex.Unlock()
+
+		r.logger.Printf("Schrittkette %s abgeschlossen", seq.Name)
+		r.publishProgress(i, now)
+		r.persist()
+		return
+	}
+
+	step := &seq.Steps[index]
+	r.mutex.Lock()
+	current.State = StateRunning
+	current.Step = step.Name
+	current.step = index
+	current.stepStart = now
+	current.elapsed = 0
+	r.mutex.Unlock()
+
+	r.logger.Printf("Schrittkette %s: Schritt %s", seq.Name, step.Name)
+	r.resetConditions(seq, step)
+	r.publishProgress(i, now)
+	r.persist()
+
+	r.runActions(i, step.Actions, now)
+}
+
+// runActions führt die Aktionen eines Schritts aus. Schlägt eine Aktion fehl, wird die
+// Schrittkette abgebrochen, statt im Schritt auf eine Bedingung zu warten, die nie eintritt.
+// Der Aufrufer hält opMutex.
+func (r *Runner) runActions(i int, actions []rule.Action, now time.Time) {
+	seq := &r.sequences[i]
+	for _, action := range actions {
+		if err := r.perform(seq, action); err != nil {
+			r.abort(i, fmt.Sprintf("%s fehlgeschlagen: %v", action, err), now)
+			return
+		}
+	}
+}
+
+// perform führt eine Aktion im Namen einer Schrittkette aus
+func (r *Runner) perform(seq *Sequence, action rule.Action) error {
+	err := r.rules.Perform(action, command.OriginSequence, seq.Name, "Schrittkette "+seq.Name)
+	if err != nil {
+		r.logger.Printf("Schrittkette %s: %s fehlgeschlagen: %v", seq.Name, action, err)
+	}
+	return err
+}
+
+// abort bricht eine Schrittkette ab, führt die Abbruchaktionen aus und meldet einen Alarm.
+// Fehlgeschlagene Abbruchaktionen werden protokolliert, die übrigen trotzdem ausgeführt. Der
+// Aufrufer hält opMutex.
+func (r *Runner) abort(i int, reason string, now time.Time) {
+	seq, current := &r.sequences[i], r.runs[i]
+
+	r.mutex.Lock()
+	step := current.Step
+	current.elapsed = current.stepElapsed(now)
+	current.State = StateAborted
+	current.FinishedAt = now
+	current.Reason = reason
+	r.mutex.Unlock()
+
+	r.logger.Printf("Schrittkette %s in Schritt %s abgebrochen: %s", seq.Name, step, reason)
+	r.publishProgress(i, now)
+	r.persist()
+
+	for _, action := range seq.AbortActions {
+		r.perform(seq, action)
+	}
+
+	r.bus.Publish(event.NewAlarmEvent(event.Alarm{
+		Name:     seq.Name + "_aborted",
+		Severity: event.SeverityWarning,
+		Active:   true,
+		Message:  fmt.Sprintf("Schrittkette %s in Schritt %s abgebrochen: %s", seq.Name, step, reason),
+	}))
+}
+
+// resetConditions setzt die Bedingungen eines Schritts zurück, damit Mindestdauern im neuen
+// Schritt neu beginnen. Die Abbruchbedingung der Schrittkette läuft über alle Schritte weiter.
+func (r *Runner) resetConditions(seq *Sequence, step *Step) {
+	for _, condition := range seq.conditions(step) {
+		if condition != seq.AbortIf {
+			condition.Reset()
+		}
+	}
+}
+
+// find sucht eine Schrittkette
+func (r *Runner) find(name string) (int, error) {
+	for i := range r.sequences {
+		if r.sequences[i].Name == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unbekannte Schrittkette: %s", name)
+}
+
+// StartSequence startet eine Schrittkette im ersten Schritt oder im Schritt step
+func (r *Runner) StartSequence(name, step string) error {
+	r.opMutex.Lock()
+	defer r.opMutex.Unlock()
+
+	i, err := r.find(name)
+	if err != nil {
+		return err
+	}
+	seq, current := &r.sequences[i], r.runs[i]
+
+	index := 0
+	if step != "" {
+		if index = seq.StepIndex(step); index < 0 {
+			return fmt.Errorf("schrittkette %s hat keinen Schritt %s", name, step)
+		}
+	}
+
+	r.mutex.Lock()
+	state := current.State
+	wasAborted := state == StateAborted
+	if state != StateRunning && state != StatePaused {
+		current.StartedAt = time.Now()
+		current.FinishedAt = time.Time{}
+		current.Reason = ""
+	}
+	r.mutex.Unlock()
+	if state == StateRunning || state == StatePaused {
+		return fmt.Errorf("schrittkette %s läuft bereits (%s)", name, state)
+	}
+
+	if wasAborted {
+		r.bus.Publish(event.NewAlarmEvent(event.Alarm{
+			Name:     seq.Name + "_aborted",
+			Severity: event.SeverityInfo,
+			Active:   false,
+			Message:  fmt.Sprintf("Schrittkette %s neu gestartet", seq.Name),
+		}))
+	}
+	if seq.AbortIf != nil {
+		seq.AbortIf.Reset()
+	}
+
+	r.logger.Printf("Schrittkette %s gestartet", seq.Name)
+	r.enter(i, index, time.Now(), "")
+	return nil
+}
+
+// PauseSequence hält eine laufende Schrittkette an und führt ihre Pausenaktionen aus. Die Zeit
+// im Schritt läuft während der Pause nicht weiter.
+func (r *Runner) PauseSequence(name string) error {
+	r.opMutex.Lock()
+	defer r.opMutex.Unlock()
+
+	i, err := r.find(name)
+	if err != nil {
+		return err
+	}
+	seq, current := &r.sequences[i], r.runs[i]
+	now := time.Now()
+
+	r.mutex.Lock()
+	state := current.State
+	if state == StateRunning {
+		current.elapsed = current.stepElapsed(now)
+		current.State = StatePaused
+	}
+	r.mutex.Unlock()
+	if state != StateRunning {
+		return fmt.Errorf("schrittkette %s läuft nicht (%s)", name, state)
+	}
+
+	r.logger.Printf("Schrittkette %s in Schritt %s angehalten", seq.Name, current.Step)
+	r.publishProgress(i, now)
+	r.persist()
+
+	var errs []string
+	for _, action := range seq.PauseActions {
+		if err := r.perform(seq, action); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", action, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("schrittkette %s angehalten, Pausenaktionen fehlgeschlagen: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ResumeSequence setzt eine angehaltene Schrittkette im aktuellen Schritt fort und führt dessen
+// Aktionen erneut aus
+func (r *Runner) ResumeSequence(name string) error {
+	r.opMutex.Lock()
+	defer r.opMutex.Unlock()
+
+	i, err := r.find(name)
+	if err != nil {
+		return err
+	}
+	seq, current := &r.sequences[i], r.runs[i]
+	now := time.Now()
+
+	r.mutex.Lock()
+	state := current.State
+	if state == StatePaused {
+		current.State = StateRunning
+		current.stepStart = now.Add(-current.elapsed)
+	}
+	r.mutex.Unlock()
+	if state != StatePaused {
+		return fmt.Errorf("schrittkette %s ist nicht angehalten (%s)", name, state)
+	}
+
+	step := &seq.Steps[current.step]
+	r.logger.Printf("Schrittkette %s in Schritt %s fortgesetzt", seq.Name, step.Name)
+	r.resetConditions(seq, step)
+	r.publishProgress(i, now)
+	r.persist()
+
+	r.runActions(i, step.Actions, now)
+	return nil
+}
+
+// AbortSequence bricht eine laufende oder angehaltene Schrittkette ab
+func (r *Runner) AbortSequence(name, reason string) error {
+	r.opMutex.Lock()
+	defer r.opMutex.Unlock()
+
+	i, err := r.find(name)
+	if err != nil {
+		return err
+	}
+	current := r.runs[i]
+
+	r.mutex.Lock()
+	state := current.State
+	r.mutex.Unlock()
+	if state != StateRunning && state != StatePaused {
+		return fmt.Errorf("schrittkette %s läuft nicht (%s)", name, state)
+	}
+
+	if reason == "" {
+		reason = "abbruch per RPC"
+	}
+	r.abort(i, reason, time.Now())
+	return nil
+}
+
+// Status gibt den Zustand aller Schrittketten zurück
+func (r *Runner) Status() []Status {
+	now := time.Now()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	statuses := make([]Status, 0, len(r.sequences))
+	for i := range r.sequences {
+		statuses = append(statuses, r.statusLocked(i, now))
+	}
+	return statuses
+}
+
+// statusLocked gibt den Zustand einer Schrittkette zurück, der Aufrufer hält mutex
+func (r *Runner) statusLocked(i int, now time.Time) Status {
+	seq, current := &r.sequences[i], r.runs[i]
+
+	status := Status{
+		Name:        seq.Name,
+		Description: seq.Description,
+		State:       current.State,
+		Step:        current.Step,
+		StepIndex:   current.step,
+		Steps:       make([]string, len(seq.Steps)),
+		Reason:      current.Reason,
+	}
+	for j, step := range seq.Steps {
+		status.Steps[j] = step.Name
+	}
+	if !current.StartedAt.IsZero() {
+		startedAt := current.StartedAt
+		status.StartedAt = &startedAt
+	}
+	if !current.FinishedAt.IsZero() {
+		finishedAt := current.FinishedAt
+		status.FinishedAt = &finishedAt
+	}
+
+	switch {
+	case current.State == StateCompleted:
+		status.Progress = 100
+	case current.step >= 0:
+		elapsed := current.stepElapsed(now)
+		status.StepElapsed = math.Round(elapsed.Seconds())
+
+		// Schritte mit fester Dauer tragen anteilig zum Fortschritt bei, Schritte mit
+		// Bedingung erst, wenn sie abgeschlossen sind
+		step := &seq.Steps[current.step]
+		fraction := 0.0
+		if step.Until == nil && step.Duration > 0 {
+			fraction = math.Min(elapsed.Seconds()/step.Duration.Seconds(), 1)
+		}
+		status.Progress = math.Round((float64(current.step)+fraction)/float64(len(seq.Steps))*1000) / 10
+	}
+	return status
+}
+
+// publishProgress sendet Zustand, Schritt und Fortschritt einer Schrittkette als <name>_<wert>
+func (r *Runner) publishProgress(i int, now time.Time) {
+	r.mutex.Lock()
+	status := r.statusLocked(i, now)
+	r.runs[i].lastPublish = now
+	r.mutex.Unlock()
+
+	if r.publish == nil {
+		return
+	}
+
+	prefix := status.Name + "_"
+	r.publish(map[string]interface{}{"simple": map[string]interface{}{
+		prefix + "state":        status.State,
+		prefix + "step":         status.Step,
+		prefix + "step_index":   status.StepIndex,
+		prefix + "step_elapsed": status.StepElapsed,
+		prefix + "progress":     status.Progress,
+	}})
+}
+
+// persist schreibt den Zustand aller Schrittketten in die Zustandsdatei
+func (r *Runner) persist() {
+	r.saveMutex.Lock()
+	defer r.saveMutex.Unlock()
+
+	now := time.Now()
+	r.mutex.Lock()
+	st := state{Sequences: make(map[string]record, len(r.runs))}
+	for i, current := range r.runs {
+		saved := current.record
+		if current.step >= 0 {
+			saved.StepElapsedSeconds = current.stepElapsed(now).Seconds()
+		}
+		st.Sequences[r.sequences[i].Name] = saved
+		current.lastPersist = now
+	}
+	r.mutex.Unlock()
+
+	if err := saveState(r.statePath, st); err != nil {
+		r.logger.Printf("Ablaufzustand nicht gespeichert: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package sequence führt mehrstufige Chargenprozesse wie Füllen, Dosieren, Mischen, Absetzen
+// und Ablassen als Schrittketten aus. Jeder Schritt führt beim Eintritt seine Aktionen aus und
+// endet nach einer Mindestdauer und/oder einer Bedingung auf Messwerten. Zeitüberschreitungen
+// und Abbruchbedingungen führen in einen anderen Schritt oder brechen die Kette ab.
+package sequence
+
+import (
+	"fmt"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/rule"
+)
+
+// Besondere Ziele eines Schritts
+const (
+	stepEnd   = -1
+	stepAbort = -2
+)
+
+// Step ist ein geprüfter Schritt
+type Step struct {
+	Name        string
+	Description string
+	Actions     []rule.Action
+	Duration    time.Duration
+	Until       *rule.Condition
+	Next        int
+	Timeout     time.Duration
+	OnTimeout   int
+	AbortIf     *rule.Condition
+}
+
+// Sequence ist eine geprüfte Schrittkette
+type Sequence struct {
+	Name         string
+	Description  string
+	Steps        []Step
+	AbortIf      *rule.Condition
+	AbortActions []rule.Action
+	PauseActions []rule.Action
+	MaxAge       time.Duration
+}
+
+// StepIndex gibt den Index eines Schritts zurück, -1 wenn es ihn nicht gibt
+func (s *Sequence) StepIndex(name string) int {
+	for i, step := range s.Steps {
+		if step.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// conditions gibt die Bedingungen eines Schritts einschließlich der Abbruchbedingung der
+// Schrittkette zurück
+func (s *Sequence) conditions(step *Step) []*rule.Condition {
+	var conditions []*rule.Condition
+	for _, condition := range []*rule.Condition{s.AbortIf, step.AbortIf, step.Until} {
+		if condition != nil {
+			conditions = append(conditions, condition)
+		}
+	}
+	return conditions
+}
+
+// ParseSequences prüft die Schrittketten der Anwendungskonfiguration und wandelt sie um.
+// Alle Fehler werden gesammelt zurückgegeben, ungültige Schrittketten werden ausgelassen.
+func ParseSequences(configs []config.SequenceConfig) ([]Sequence, []error) {
+	var sequences []Sequence
+	var errs []error
+	names := make(map[string]bool)
+
+	for i, cfg := range configs {
+		parsed, err := parseSequence(cfg)
+		if err == nil && names[parsed.Name] {
+			err = fmt.Errorf("name ist bereits vergeben")
+		}
+		if err != nil {
+			label := cfg.Name
+			if label == "" {
+				label = fmt.Sprintf("#%d", i)
+			}
+			errs = append(errs, fmt.Errorf("schrittkette %s: %w", label, err))
+			continue
+		}
+
+		names[parsed.Name] = true
+		sequences = append(sequences, parsed)
+	}
+
+	return sequences, errs
+}
+
+// parseSequence prüft eine Schrittkette
+func parseSequence(cfg config.SequenceConfig) (Sequence, error) {
+	if cfg.Name == "" {
+		return Sequence{}, fmt.Errorf("kein Name angegeben")
+	}
+	if len(cfg.Steps) == 0 {
+		return Sequence{}, fmt.Errorf("keine Schritte (steps) angegeben")
+	}
+	if cfg.MaxAgeSeconds < 0 {
+		return Sequence{}, fmt.Errorf("max_age_seconds darf nicht negativ sein")
+	}
+
+	parsed := Sequence{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		MaxAge:      rule.DefaultMaxAge,
+	}
+	if cfg.MaxAgeSeconds > 0 {
+		parsed.MaxAge = time.Duration(cfg.MaxAgeSeconds) * time.Second
+	}
+
+	var err error
+	if cfg.AbortIf != nil {
+		if parsed.AbortIf, err = rule.ParseCondition(*cfg.AbortIf); err != nil {
+			return Sequence{}, fmt.Errorf("abort_if: %w", err)
+		}
+	}
+	if parsed.AbortActions, err = parseActions(cfg.AbortActions, cfg.Name); err != nil {
+		return Sequence{}, fmt.Errorf("abort_actions %w", err)
+	}
+	if parsed.PauseActions, err = parseActions(cfg.PauseActions, cfg.Name); err != nil {
+		return Sequence{}, fmt.Errorf("pause_actions %w", err)
+	}
+
+	// Schrittnamen zuerst sammeln, damit next und on_timeout auch spätere Schritte erreichen
+	indexes := make(map[string]int)
+	for i, stepCfg := range cfg.Steps {
+		switch stepCfg.Name {
+		case "":
+			return Sequence{}, fmt.Errorf("schritt %d: kein Name angegeben", i)
+		case config.SequenceNextEnd, config.SequenceNextAbort:
+			return Sequence{}, fmt.Errorf("schritt %d: Name %q ist reserviert", i, stepCfg.Name)
+		}
+		if _, exists := indexes[stepCfg.Name]; exists {
+			return Sequence{}, fmt.Errorf("schritt %s ist mehrfach definiert", stepCfg.Name)
+		}
+		indexes[stepCfg.Name] = i
+	}
+
+	target := func(name string, fallback int) (int, error) {
+		switch name {
+		case "":
+			return fallback, nil
+		case config.SequenceNextEnd:
+			return stepEnd, nil
+		case config.SequenceNextAbort:
+			return stepAbort, nil
+		}
+		index, exists := indexes[name]
+		if !exists {
+			return 0, fmt.Errorf("unbekannter Schritt %q", name)
+		}
+		return index, nil
+	}
+
+	for i, stepCfg := range cfg.Steps {
+		step, err := parseStep(stepCfg, cfg.Name)
+		if err != nil {
+			return Sequence{}, fmt.Errorf("schritt %s: %w", stepCfg.Name, err)
+		}
+
+		fallback := i + 1
+		if fallback == len(cfg.Steps) {
+			fallback = stepEnd
+		}
+		if step.Next, err = target(stepCfg.Next, fallback); err != nil {
+			return Sequence{}, fmt.Errorf("schritt %s: next: %w", stepCfg.Name, err)
+		}
+		if step.OnTimeout, err = target(stepCfg.OnTimeout, stepAbort); err != nil {
+			return Sequence{}, fmt.Errorf("schritt %s: on_timeout: %w", stepCfg.Name, err)
+		}
+		parsed.Steps = append(parsed.Steps, step)
+	}
+
+	return parsed, nil
+}
+
+// parseStep prüft einen Schritt ohne seine Sprungziele
+func parseStep(cfg config.SequenceStepConfig, sequenceName string) (Step, error) {
+	if cfg.DurationSeconds < 0 || cfg.TimeoutSeconds < 0 {
+		return Step{}, fmt.Errorf("duration_seconds und timeout_seconds dürfen nicht negativ sein")
+	}
+	if cfg.OnTimeout != "" && cfg.TimeoutSeconds == 0 {
+		return Step{}, fmt.Errorf("on_timeout erfordert timeout_seconds")
+	}
+	if cfg.TimeoutSeconds > 0 && cfg.TimeoutSeconds <= cfg.DurationSeconds {
+		return Step{}, fmt.Errorf("timeout_seconds muss größer als duration_seconds sein")
+	}
+
+	step := Step{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		Duration:    time.Duration(cfg.DurationSeconds) * time.Second,
+		Timeout:     time.Duration(cfg.TimeoutSeconds) * time.Second,
+	}
+
+	var err error
+	if step.Actions, err = parseActions(cfg.Actions, sequenceName); err != nil {
+		return Step{}, fmt.Errorf("actions %w", err)
+	}
+	if cfg.Until != nil {
+		if step.Until, err = rule.ParseCondition(*cfg.Until); err != nil {
+			return Step{}, fmt.Errorf("until: %w", err)
+		}
+	}
+	if cfg.AbortIf != nil {
+		if step.AbortIf, err = rule.ParseCondition(*cfg.AbortIf); err != nil {
+			return Step{}, fmt.Errorf("abort_if: %w", err)
+		}
+	}
+	return step, nil
+}
+
+// parseActions prüft eine Liste von Aktionen
+func parseActions(configs []config.RuleAction, sequenceName string) ([]rule.Action, error) {
+	var actions []rule.Action
+	for i, cfg := range configs {
+		action, err := rule.ParseAction(cfg, sequenceName)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", i, err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
@@ -0,0 +1,45 @@
+package sequence
+
+import (
+	"time"
+
+	"owipex_reader/internal/statefile"
+)
+
+// record ist der gespeicherte Zustand einer Schrittkette
+type record struct {
+	State              string    `json:"state"`
+	Step               string    `json:"step,omitempty"`
+	StepElapsedSeconds float64   `json:"step_elapsed_seconds"`
+	StartedAt          time.Time `json:"started_at"`
+	FinishedAt         time.Time `json:"finished_at"`
+	Reason             string    `json:"reason,omitempty"`
+}
+
+// state ist der Inhalt der Zustandsdatei
+type state struct {
+	Sequences map[string]record `json:"sequences"`
+}
+
+// newState erstellt einen leeren Zustand
+func newState() state {
+	return state{Sequences: make(map[string]record)}
+}
+
+// loadState liest die Zustandsdatei. Fehlt sie, ist der Zustand leer. Eine beschädigte Datei
+// wird beiseitegelegt (statefile.ErrCorrupt).
+func loadState(path string) (state, error) {
+	st := newState()
+	if err := statefile.Load(path, &st); err != nil {
+		return newState(), err
+	}
+	if st.Sequences == nil {
+		st.Sequences = make(map[string]record)
+	}
+	return st, nil
+}
+
+// saveState schreibt die Zustandsdatei
+func saveState(path string, st state) error {
+	return statefile.Save(path, st)
+}
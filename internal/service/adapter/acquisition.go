@@ -0,0 +1,146 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"owipex_reader/internal/acquisition"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/protocol/factory"
+	"owipex_reader/internal/types"
+)
+
+// defaultReadInterval ist das Leseintervall von Sensoren ohne konfiguriertes oder vom Sensor
+// vorgegebenes Intervall
+const defaultReadInterval = 15 * time.Second
+
+// acquisitionTasks erstellt die Leseaufgaben aller Sensoren und Hybridgeräte sowie das
+// Zurücklesen des Zustands aller Aktoren
+func (a *SensorAdapter) acquisitionTasks() []acquisition.Task {
+	registry := a.deviceService.Registry()
+	var tasks []acquisition.Task
+
+	for _, sensor := range registry.GetReadableDevices() {
+		id := sensor.ID()
+		interval := a.readIntervals[id]
+		if interval <= 0 {
+			if provider, ok := sensor.(intervalProvider); ok && provider.ReadInterval() > 0 {
+				// Vom Sensor vorgegebenes Intervall verwenden
+				interval = provider.ReadInterval()
+			} else {
+				interval = defaultReadInterval
+			}
+		}
+
+		tasks = append(tasks, a.acquisitionTask(id, id, interval, func(ctx context.Context) error {
+			return a.readSensor(ctx, id)
+		}))
+	}
+
+	for _, actor := range registry.GetActors() {
+		id := actor.ID()
+		interval := a.readIntervals[id]
+		if interval <= 0 {
+			interval = actorStateInterval
+		}
+
+		tasks = append(tasks, a.acquisitionTask(id+"_state", id, interval, func(ctx context.Context) error {
+			return a.readActorState(ctx, id)
+		}))
+	}
+
+	return tasks
+}
+
+// acquisitionTask erstellt eine Leseaufgabe mit Bus und Phasenversatz des Geräts. Gelesen
+// wird nur, solange das Gerät aktiviert ist und nicht im Backoff wartet.
+func (a *SensorAdapter) acquisitionTask(taskID, deviceID string, interval time.Duration, read func(ctx context.Context) error) acquisition.Task {
+	deviceCfg := a.appConfig.Acquisition.Devices[deviceID]
+	registry := a.deviceService.Registry()
+
+	return acquisition.Task{
+		ID:       taskID,
+		DeviceID: deviceID,
+		Bus:      a.acquisitionBus(deviceID),
+		Interval: interval,
+		Offset:   time.Duration(deviceCfg.PhaseOffsetMS) * time.Millisecond,
+		Ready: func() bool {
+			dev, err := registry.GetDevice(deviceID)
+			return err == nil && dev.IsEnabled() && registry.ShouldAttempt(deviceID)
+		},
+		Read: read,
+	}
+}
+
+// acquisitionBus gibt den Bus eines Geräts zurück: den konfigurierten oder bei Modbus-Geräten
+// die serielle Schnittstelle. Andere Geräte werden nur mit sich selbst serialisiert.
+func (a *SensorAdapter) acquisitionBus(deviceID string) string {
+	if bus := a.appConfig.Acquisition.Devices[deviceID].Bus; bus != "" {
+		return bus
+	}
+
+	deviceCfg, exists := a.deviceService.ActiveConfig(deviceID)
+	if !exists || deviceCfg.Protocol != "modbus" {
+		return ""
+	}
+	modbusConfig, _ := deviceCfg.Metadata["modbus"].(map[string]interface{})
+	port, _ := modbusConfig["port"].(string)
+	if port == "" {
+		port = factory.DefaultModbusPort
+	}
+	return "modbus:" + port
+}
+
+// syncAcquisition plant die Leseaufgaben nach Änderungen der Geräte neu ein
+func (a *SensorAdapter) syncAcquisition() {
+	a.acquisition.Sync(a.acquisitionTasks())
+}
+
+// readSensor liest einen Messwert und verteilt ihn über den Event-Bus und an ThingsBoard
+func (a *SensorAdapter) readSensor(ctx context.Context, id string) error {
+	dev, err := a.deviceService.Registry().GetDevice(id)
+	if err != nil {
+		// Gerät wurde inzwischen entfernt
+		return err
+	}
+	sensor, ok := dev.(types.ReadableDevice)
+	if !ok {
+		return fmt.Errorf("gerät %s liefert keine Messwerte", id)
+	}
+
+	a.logger.Printf("Lese Sensor: %s", id)
+	reading, err := sensor.Read(ctx)
+	if errors.Is(err, context.Canceled) {
+		// Beim Stoppen abgebrochen, kein Verbindungsfehler
+		return err
+	}
+	a.recordResult(id, err)
+
+	if err != nil {
+		a.logger.Printf("Fehler beim Lesen des Sensors %s: %v", id, err)
+		a.deviceService.EventBus().Publish(event.NewReadFailedEvent(id, err))
+		return err
+	}
+
+	a.logger.Printf("Sensor %s erfolgreich gelesen: %v", id, reading.Value)
+	a.deviceService.EventBus().Publish(event.NewReadingProducedEvent(id, reading))
+
+	// Daten für ThingsBoard formatieren
+	if err := a.sendTelemetry(ctx, a.formatReadingForThingsboard(sensor, reading)); err != nil {
+		return fmt.Errorf("messwert von %s nicht an ThingsBoard übergeben: %w", id, err)
+	}
+	return nil
+}
+
+// sendTelemetry übergibt Daten an den ThingsBoard-Kanal. Ist der Kanal voll, wird höchstens
+// bis zum Ende des Lesevorgangs gewartet, damit Stop nicht blockiert.
+func (a *SensorAdapter) sendTelemetry(ctx context.Context, data map[string]interface{}) error {
+	select {
+	case a.thingsboardChan <- data:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,103 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/device/actuator"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/types"
+)
+
+// actorStateInterval ist das Intervall, in dem der tatsächliche Zustand der Aktoren zurückgelesen wird
+const actorStateInterval = 15 * time.Second
+
+// stateMismatchAlarm ist der Name des Alarms bei abweichender Zustandsrückmeldung
+const stateMismatchAlarm = "state_mismatch"
+
+// readActorState liest den Zustand eines Aktors, sendet ihn als Telemetrie ("<id>_state",
+// "<id>_state_mismatch") und löst bei Abweichung vom befohlenen Zustand einen Alarm aus.
+// Eine Abweichung ist kein Fehler des Lesevorgangs.
+func (a *SensorAdapter) readActorState(ctx context.Context, id string) error {
+	dev, err := a.deviceService.Registry().GetDevice(id)
+	if err != nil {
+		// Gerät wurde inzwischen entfernt
+		return err
+	}
+	actor, ok := dev.(types.Actor)
+	if !ok {
+		return fmt.Errorf("gerät %s ist kein Aktor", id)
+	}
+	var state interface{}
+	if reader, ok := actor.(types.StateReader); ok {
+		state, err = reader.GetStateContext(ctx)
+	} else {
+		state, err = actor.GetState()
+	}
+	if errors.Is(err, context.Canceled) {
+		// Beim Stoppen abgebrochen, kein Verbindungsfehler
+		return err
+	}
+
+	var mismatch *actuator.StateMismatchError
+	isMismatch := errors.As(err, &mismatch)
+
+	// Eine abweichende Rückmeldung ist ein gültiger Lesevorgang, kein Verbindungsfehler
+	if isMismatch {
+		a.recordResult(id, nil)
+	} else {
+		a.recordResult(id, err)
+	}
+
+	if err != nil && !isMismatch {
+		a.logger.Printf("Fehler beim Lesen des Zustands von Aktor %s: %v", id, err)
+		a.deviceService.EventBus().Publish(event.NewReadFailedEvent(id, err))
+		return err
+	}
+
+	// Zustand als Messwert melden (z.B. für Verriegelungen über Aktorzustände)
+	reading := types.NewReading(types.ReadingTypeState, state, "", nil)
+	reading.Metadata["state_mismatch"] = isMismatch
+	if reporter, ok := device.Unwrap(dev).(types.RunningReporter); ok {
+		reading.Metadata[types.MetadataRunning] = reporter.Running(state)
+	}
+	a.deviceService.EventBus().Publish(event.NewReadingProducedEvent(id, reading))
+
+	if err := a.sendTelemetry(ctx, map[string]interface{}{
+		"simple": map[string]interface{}{
+			fmt.Sprintf("%s_state", id):          state,
+			fmt.Sprintf("%s_state_mismatch", id): isMismatch,
+		},
+	}); err != nil {
+		return fmt.Errorf("zustand von %s nicht an ThingsBoard übergeben: %w", id, err)
+	}
+
+	// Alarm nur bei Wechsel auslösen bzw. aufheben
+	a.actorMismatchMutex.Lock()
+	previous := a.actorMismatch[id]
+	a.actorMismatch[id] = isMismatch
+	a.actorMismatchMutex.Unlock()
+
+	if isMismatch == previous {
+		return nil
+	}
+
+	alarm := event.Alarm{
+		Name:     stateMismatchAlarm,
+		DeviceID: id,
+		Severity: event.SeverityCritical,
+		Active:   isMismatch,
+		Value:    state,
+	}
+	if isMismatch {
+		alarm.Message = mismatch.Error()
+		a.logger.Printf("Zustandsabweichung: %v", mismatch)
+	} else {
+		alarm.Message = fmt.Sprintf("aktor %s: Zustand stimmt wieder mit dem befohlenen überein", id)
+	}
+	a.deviceService.EventBus().Publish(event.NewAlarmEvent(alarm))
+	return nil
+}
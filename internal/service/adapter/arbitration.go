@@ -0,0 +1,80 @@
+package adapter
+
+import (
+	"fmt"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/types"
+)
+
+// commandAttributeKeys sind die Shared Attributes, mit denen Aktoren aus ThingsBoard geschaltet
+// werden, als Endung der Geräte-ID
+var commandAttributeKeys = []struct {
+	suffix      string
+	commandType types.CommandType
+}{
+	{"_set_state", types.CommandTypeSetState},
+	{"_set_position", types.CommandTypeSetPosition},
+}
+
+// releaseOverride hebt die Handbedienung oder den gehaltenen sicheren Zustand eines Aktors auf,
+// damit Regler und Zeitpläne ihn wieder steuern können
+func (a *SensorAdapter) releaseOverride(params map[string]interface{}) (interface{}, error) {
+	deviceID, err := stringParam(params, "device_id")
+	if err != nil {
+		return nil, err
+	}
+	issuer, _ := params["issuer"].(string)
+	if issuer == "" {
+		issuer = "thingsboard"
+	}
+
+	released, err := a.arbiter.Release(deviceID, issuer)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"device_id": deviceID, "released": released}, nil
+}
+
+// publishAttributes meldet den steuernden Auslöser der Aktoren als Client-Attribute
+func (a *SensorAdapter) publishAttributes(attributes map[string]interface{}) {
+	a.thingsboardChan <- map[string]interface{}{"attributes": attributes}
+}
+
+// applyCommandAttributes schaltet Aktoren über die Shared Attributes "<id>_set_state" und
+// "<id>_set_position" (Auslöser attribute, Vorrang wie eine Handbedienung per RPC). Antworten
+// auf Attributanfragen (z.B. nach einem Neuverbinden) werden nur gemerkt, damit ein alter Wert
+// keine neue Übersteuerung auslöst. Geschaltet wird, wenn sich ein Wert ändert.
+func (a *SensorAdapter) applyCommandAttributes(attributes map[string]interface{}, response bool) {
+	for _, actor := range a.deviceService.Registry().GetActors() {
+		for _, attribute := range commandAttributeKeys {
+			key := actor.ID() + attribute.suffix
+			value, exists := attributes[key]
+			if !exists {
+				continue
+			}
+
+			a.commandAttributesMutex.Lock()
+			previous, seen := a.commandAttributes[key]
+			a.commandAttributes[key] = value
+			a.commandAttributesMutex.Unlock()
+
+			if response || (seen && fmt.Sprint(previous) == fmt.Sprint(value)) {
+				continue
+			}
+
+			a.logger.Printf("Attribut %s: %s an %s mit %v", key, attribute.commandType, actor.ID(), value)
+			go func(deviceID, key string, cmd types.Command) {
+				record := a.commands.Submit(command.Request{
+					DeviceID: deviceID,
+					Command:  cmd,
+					Origin:   command.OriginAttribute,
+					Issuer:   key,
+				})
+				if !record.Succeeded() {
+					a.logger.Printf("Befehl aus Attribut %s nicht ausgeführt (%s): %s", key, record.Status, record.Error)
+				}
+			}(actor.ID(), key, types.NewCommand(attribute.commandType, value))
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package adapter
+
+import (
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/controller"
+	"owipex_reader/internal/controller/ph"
+	"owipex_reader/internal/controller/pid"
+)
+
+// newControllers erstellt die in der Anwendungskonfiguration aktivierten Regler
+func newControllers(cfg config.ControllersConfig, env controller.Environment) (*controller.Manager, error) {
+	manager := controller.NewManager()
+
+	if cfg.PH.Enabled {
+		phController, err := ph.NewController(cfg.PH, env)
+		if err != nil {
+			return nil, err
+		}
+		if err := manager.Add(phController); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pidConfig := range cfg.PID {
+		pidConfig.SetDefaults()
+		pidController, err := pid.NewController(pidConfig, env)
+		if err != nil {
+			return nil, err
+		}
+		if err := manager.Add(pidController); err != nil {
+			return nil, err
+		}
+	}
+
+	return manager, nil
+}
+
+// storeCalibration speichert die Kalibrierung eines Sensors in seiner Gerätekonfiguration.
+// Die Änderung wird wie bei update_device validiert, gesichert und sofort neu geladen.
+func (a *SensorAdapter) storeCalibration(deviceID string, calibration map[string]interface{}) error {
+	result, err := a.deviceService.UpdateDeviceConfig(deviceID, map[string]interface{}{
+		"metadata": map[string]interface{}{"calibration": calibration},
+	})
+	if err != nil {
+		return err
+	}
+
+	a.logger.Printf("Kalibrierung von %s gespeichert, vorheriger Stand gesichert als %s", deviceID, result.Backup.Version)
+	return nil
+}
+
+// publish sendet Daten eines Reglers an ThingsBoard
+func (a *SensorAdapter) publish(data map[string]interface{}) {
+	a.thingsboardChan <- data
+}
@@ -0,0 +1,40 @@
+package adapter
+
+import (
+	"fmt"
+	"strings"
+
+	"owipex_reader/internal/arbitration"
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/types"
+)
+
+// interlockEnforcer schaltet laufende Geräte aktiver Verriegelungen über den Befehlsmanager
+// aus (interlock.Enforcer). Die Abschaltung hat Vorrang wie der sichere Zustand und wird vom
+// Arbiter gehalten, bis die Verriegelung aufgehoben ist.
+type interlockEnforcer struct {
+	commands *command.Manager
+	arbiter  *arbitration.Arbiter
+	adapter  *SensorAdapter
+}
+
+// Shutdown sendet den Abschaltbefehl mit Auslöser failsafe
+func (e *interlockEnforcer) Shutdown(deviceID string, cmd types.Command, reason string) error {
+	record := e.commands.Submit(command.Request{
+		DeviceID: deviceID,
+		Command:  cmd,
+		Origin:   command.OriginFailsafe,
+		Issuer:   reason,
+	})
+	if !record.Succeeded() {
+		return fmt.Errorf("befehl %s: %s", record.Status, record.Error)
+	}
+	return nil
+}
+
+// Release hebt das Halten des sicheren Zustands aus dem Anlass reason auf
+func (e *interlockEnforcer) Release(reason string, deviceIDs ...string) {
+	if released := e.arbiter.ReleaseSafety(reason, deviceIDs...); len(released) > 0 {
+		e.adapter.logger.Printf("Verriegelung aufgehoben (%s), Aktoren freigegeben: %s", reason, strings.Join(released, ", "))
+	}
+}
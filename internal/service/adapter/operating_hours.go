@@ -0,0 +1,26 @@
+package adapter
+
+// resetOperatingHours setzt ein Wartungsintervall oder die Zähler eines Geräts zurück. Der
+// Auslöser ("issuer", Standard "rpc") und die Notiz werden mit den bisherigen Ständen
+// protokolliert.
+func (a *SensorAdapter) resetOperatingHours(method string, params map[string]interface{}) (interface{}, error) {
+	issuer, _ := params["issuer"].(string)
+	if issuer == "" {
+		issuer = "rpc"
+	}
+	note, _ := params["note"].(string)
+
+	if method == "reset_maintenance" {
+		name, err := stringParam(params, "name")
+		if err != nil {
+			return nil, err
+		}
+		return a.operatingHours.ResetMaintenance(name, issuer, note)
+	}
+
+	deviceID, err := stringParam(params, "device_id")
+	if err != nil {
+		return nil, err
+	}
+	return a.operatingHours.ResetCounter(deviceID, issuer, note)
+}
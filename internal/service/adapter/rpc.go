@@ -0,0 +1,347 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/failsafe"
+	"owipex_reader/internal/service"
+	"owipex_reader/internal/types"
+)
+
+// HandleRPC verarbeitet RPC-Methoden, die der SensorAdapter bereitstellt. handled ist false,
+// wenn die Methode nicht vom Adapter behandelt wird.
+//
+// Unterstützte Methoden:
+//   - describe: {"type": "<gerätetyp>"} gibt die Beschreibung eines Gerätetyps zurück,
+//     ohne Parameter die Beschreibungen aller Gerätetypen
+//   - get_device_config: {"id": "<geräte-id>"} gibt die gespeicherte Konfiguration zurück
+//   - add_device: {"config": {...}} legt ein Gerät an
+//   - update_device: {"id": "<geräte-id>", "config": {...}} ändert ein Gerät (JSON Merge Patch)
+//   - set_device_enabled: {"id": "<geräte-id>", "enabled": true|false}
+//   - delete_device: {"id": "<geräte-id>"} löscht ein Gerät
+//   - rollback_config: stellt den neuesten fehlerfrei geladenen Konfigurationsstand wieder her
+//   - list_config_backups: listet die gesicherten Konfigurationsstände
+//   - get_interlocks: gibt den Zustand aller Sicherheitsverriegelungen zurück
+//   - send_command: {"device_id": "<geräte-id>", "command": "SET_STATE", "value": ..., "parameters": {...},
+//     "timeout_ms": 5000, "issuer": "<auslöser>"} führt einen Befehl aus und gibt das Ergebnis zurück
+//   - get_command_log: {"device_id": "<geräte-id>", "limit": 50} gibt die neuesten Befehle aus dem Audit-Log zurück
+//   - apply_failsafe: {"device_ids": ["<geräte-id>", ...]} bringt Aktoren (ohne Angabe alle) in den sicheren Zustand
+//   - get_controllers: gibt den Zustand aller Regler zurück
+//   - get_rules: gibt den Zustand aller lokalen Regeln zurück
+//   - get_schedules: gibt den Zustand aller Zeitpläne zurück
+//   - set_schedule: {"schedule": {...}} legt einen Zeitplan an oder ersetzt ihn
+//   - delete_schedule: {"name": "<zeitplan>"} löscht einen Zeitplan
+//   - run_schedule: {"name": "<zeitplan>"} startet einen Zeitplan sofort
+//   - get_sequences: gibt den Zustand aller Schrittketten zurück
+//   - start_sequence: {"name": "<schrittkette>", "step": "<schritt>"} startet eine Schrittkette (optional ab einem Schritt)
+//   - pause_sequence, resume_sequence: {"name": "<schrittkette>"} hält eine Schrittkette an bzw. setzt sie fort
+//   - abort_sequence: {"name": "<schrittkette>", "reason": "<grund>"} bricht eine Schrittkette ab
+//   - get_operating_hours: gibt Betriebsstunden, Schaltspiele, Wartungsintervalle und Rücksetzungen zurück
+//   - reset_maintenance: {"name": "<wartungsintervall>", "issuer": "<auslöser>", "note": "<notiz>"} setzt
+//     ein Wartungsintervall nach erledigter Wartung zurück
+//   - reset_operating_hours: {"device_id": "<geräte-id>", "issuer": "<auslöser>", "note": "<notiz>"} setzt
+//     die Zähler eines Geräts (oder "system") zurück
+//   - get_control_sources: gibt je Aktor den aktuell steuernden Auslöser zurück
+//   - release_override: {"device_id": "<geräte-id>", "issuer": "<auslöser>"} hebt eine Handbedienung
+//     oder einen gehaltenen sicheren Zustand auf
+//   - get_acquisition_stats: gibt je Leseaufgabe Zeitplan, Bus, Zähler, Überläufe und Lesedauer zurück
+//
+// Änderungen werden vor dem Schreiben validiert, der vorherige Stand wird gesichert und
+// die Geräte werden sofort neu geladen.
+func (a *SensorAdapter) HandleRPC(method string, params map[string]interface{}) (result interface{}, handled bool, err error) {
+	switch method {
+	case "describe":
+		result, err = a.describe(params)
+		return result, true, err
+	case "get_device_config":
+		result, err = a.getDeviceConfig(params)
+		return result, true, err
+	case "add_device", "update_device", "set_device_enabled", "delete_device":
+		result, err = a.changeDeviceConfig(method, params)
+		return result, true, err
+	case "rollback_config":
+		result, err = a.rollbackConfig()
+		return result, true, err
+	case "list_config_backups":
+		backups, err := a.deviceService.ConfigBackups()
+		return map[string]interface{}{"backups": backups}, true, err
+	case "get_interlocks":
+		return map[string]interface{}{"interlocks": a.interlocks.Status()}, true, nil
+	case "send_command":
+		result, err = a.sendCommand(params)
+		return result, true, err
+	case "get_command_log":
+		result, err = a.getCommandLog(params)
+		return result, true, err
+	case "apply_failsafe":
+		result, err = a.applyFailsafe(params)
+		return result, true, err
+	case "get_controllers":
+		return map[string]interface{}{"controllers": a.controllers.Status()}, true, nil
+	case "get_rules":
+		return map[string]interface{}{"rules": a.rules.Status()}, true, nil
+	case "get_schedules":
+		return map[string]interface{}{"schedules": a.scheduler.Schedules()}, true, nil
+	case "set_schedule", "delete_schedule", "run_schedule":
+		result, err = a.changeSchedule(method, params)
+		return result, true, err
+	case "get_sequences":
+		return map[string]interface{}{"sequences": a.sequences.Status()}, true, nil
+	case "start_sequence", "pause_sequence", "resume_sequence", "abort_sequence":
+		result, err = a.changeSequence(method, params)
+		return result, true, err
+	case "get_operating_hours":
+		return a.operatingHours.Status(), true, nil
+	case "reset_maintenance", "reset_operating_hours":
+		result, err = a.resetOperatingHours(method, params)
+		return result, true, err
+	case "get_control_sources":
+		return map[string]interface{}{"actors": a.arbiter.Status()}, true, nil
+	case "release_override":
+		result, err = a.releaseOverride(params)
+		return result, true, err
+	case "get_acquisition_stats":
+		return map[string]interface{}{"tasks": a.acquisition.Stats()}, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// describe gibt Beschreibungen aus dem Gerätekatalog zurück
+func (a *SensorAdapter) describe(params map[string]interface{}) (interface{}, error) {
+	catalog := a.deviceService.Catalog()
+
+	if value, exists := params["type"]; exists {
+		deviceType, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("parameter 'type' muss ein String sein")
+		}
+		return catalog.Describe(deviceType)
+	}
+
+	return map[string]interface{}{
+		"types": catalog.Types(),
+	}, nil
+}
+
+// getDeviceConfig gibt die gespeicherte Konfiguration eines Geräts zurück
+func (a *SensorAdapter) getDeviceConfig(params map[string]interface{}) (interface{}, error) {
+	id, err := stringParam(params, "id")
+	if err != nil {
+		return nil, err
+	}
+	return a.deviceService.GetDeviceConfig(id)
+}
+
+// changeDeviceConfig führt eine Konfigurationsänderung aus und gibt deren Ergebnis zurück
+func (a *SensorAdapter) changeDeviceConfig(method string, params map[string]interface{}) (interface{}, error) {
+	var id string
+	if method != "add_device" {
+		var err error
+		if id, err = stringParam(params, "id"); err != nil {
+			return nil, err
+		}
+	}
+
+	var result service.ChangeResult
+	var err error
+
+	switch method {
+	case "add_device":
+		config, paramErr := configParam(params)
+		if paramErr != nil {
+			return nil, paramErr
+		}
+		result, err = a.deviceService.AddDeviceConfig(config)
+
+	case "update_device":
+		patch, ok := params["config"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("parameter 'config' muss ein Objekt sein")
+		}
+		result, err = a.deviceService.UpdateDeviceConfig(id, patch)
+
+	case "set_device_enabled":
+		enabled, ok := params["enabled"].(bool)
+		if !ok {
+			return nil, fmt.Errorf("parameter 'enabled' muss true oder false sein")
+		}
+		result, err = a.deviceService.SetDeviceEnabled(id, enabled)
+
+	case "delete_device":
+		result, err = a.deviceService.DeleteDeviceConfig(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Printf("Konfiguration geändert (%s), vorheriger Stand gesichert als %s", method, result.Backup.Version)
+
+	response := reloadResponse(result.Reload)
+	response["backup"] = result.Backup.Version
+	if len(result.Warnings) > 0 {
+		warnings := make([]string, len(result.Warnings))
+		for i, warning := range result.Warnings {
+			warnings[i] = warning.String()
+		}
+		response["warnings"] = warnings
+	}
+	return response, nil
+}
+
+// rollbackConfig stellt den zuletzt gesicherten Konfigurationsstand wieder her
+func (a *SensorAdapter) rollbackConfig() (interface{}, error) {
+	backup, reload, err := a.deviceService.RollbackConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Printf("Konfigurationsstand %s wiederhergestellt (%s)", backup.Version, backup.Reason)
+
+	response := reloadResponse(reload)
+	response["restored"] = backup.Version
+	response["reason"] = backup.Reason
+	return response, nil
+}
+
+// sendCommand führt einen Befehl über die Befehlswarteschlange aus. Die Antwort enthält das
+// endgültige Ergebnis (ausgeführt, fehlgeschlagen, Zeitüberschreitung, abgelehnt).
+func (a *SensorAdapter) sendCommand(params map[string]interface{}) (interface{}, error) {
+	deviceID, err := stringParam(params, "device_id")
+	if err != nil {
+		return nil, err
+	}
+	commandType, err := stringParam(params, "command")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := types.NewCommand(types.CommandType(strings.ToUpper(commandType)), params["value"])
+	if value, exists := params["parameters"]; exists {
+		parameters, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("parameter 'parameters' muss ein Objekt sein")
+		}
+		cmd.Parameters = parameters
+	}
+
+	request := command.Request{
+		DeviceID: deviceID,
+		Command:  cmd,
+		Origin:   command.OriginRPC,
+		Issuer:   "thingsboard",
+	}
+	if issuer, ok := params["issuer"].(string); ok && issuer != "" {
+		request.Issuer = issuer
+	}
+	if timeout, ok := params["timeout_ms"].(float64); ok && timeout > 0 {
+		request.Timeout = time.Duration(timeout) * time.Millisecond
+	}
+
+	record := a.commands.Submit(request)
+	return map[string]interface{}{
+		"success": record.Succeeded(),
+		"command": record,
+	}, nil
+}
+
+// getCommandLog gibt die neuesten Einträge des Audit-Logs zurück
+func (a *SensorAdapter) getCommandLog(params map[string]interface{}) (interface{}, error) {
+	audit := a.commands.AuditLog()
+	if audit == nil {
+		return nil, fmt.Errorf("audit-Log ist deaktiviert (commands.audit_log_path)")
+	}
+
+	limit := 50
+	if value, ok := params["limit"].(float64); ok && value > 0 {
+		limit = int(value)
+	}
+	deviceID, _ := params["device_id"].(string)
+
+	records, err := audit.Recent(limit, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"commands": records}, nil
+}
+
+// applyFailsafe bringt Aktoren auf Anforderung in den sicheren Zustand
+func (a *SensorAdapter) applyFailsafe(params map[string]interface{}) (interface{}, error) {
+	var deviceIDs []string
+	if value, exists := params["device_ids"]; exists {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("parameter 'device_ids' muss eine Liste sein")
+		}
+		for _, entry := range list {
+			id, ok := entry.(string)
+			if !ok {
+				return nil, fmt.Errorf("parameter 'device_ids' darf nur Strings enthalten")
+			}
+			deviceIDs = append(deviceIDs, id)
+		}
+	}
+
+	records := a.failsafe.Apply(failsafe.ReasonManual, deviceIDs...)
+	success := true
+	for _, record := range records {
+		success = success && record.Succeeded()
+	}
+	return map[string]interface{}{"success": success, "commands": records}, nil
+}
+
+// reloadResponse wandelt das Ergebnis eines Reloads in eine RPC-Antwort um
+func reloadResponse(reload service.ReloadResult) map[string]interface{} {
+	errs := make([]string, len(reload.Errors))
+	for i, err := range reload.Errors {
+		errs[i] = err.Error()
+	}
+
+	return map[string]interface{}{
+		"success": len(errs) == 0,
+		"added":   emptyIfNil(reload.Added),
+		"updated": emptyIfNil(reload.Updated),
+		"removed": emptyIfNil(reload.Removed),
+		"errors":  errs,
+	}
+}
+
+// emptyIfNil gibt eine leere Liste statt nil zurück, damit die Antwort [] statt null enthält
+func emptyIfNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// stringParam liest einen nicht leeren String-Parameter
+func stringParam(params map[string]interface{}, name string) (string, error) {
+	value, ok := params[name].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("parameter '%s' fehlt oder ist kein String", name)
+	}
+	return value, nil
+}
+
+// configParam liest den Parameter "config" als Gerätekonfiguration
+func configParam(params map[string]interface{}) (types.DeviceConfig, error) {
+	var config types.DeviceConfig
+
+	raw, ok := params["config"].(map[string]interface{})
+	if !ok {
+		return config, fmt.Errorf("parameter 'config' muss ein Objekt sein")
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parameter 'config' ist keine gültige Gerätekonfiguration: %w", err)
+	}
+	return config, nil
+}
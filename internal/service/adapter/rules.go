@@ -0,0 +1,9 @@
+package adapter
+
+// applyRuleAttributes übernimmt die Attribute einer Regelaktion wie ein Shared Attribute aus
+// ThingsBoard (Regler, Wartung, Sensorschalter) und meldet sie als Client-Attribute. Eine
+// spätere Änderung des Shared Attributes in ThingsBoard hat wieder Vorrang.
+func (a *SensorAdapter) applyRuleAttributes(attributes map[string]interface{}) {
+	a.ApplySharedAttributes(attributes)
+	a.thingsboardChan <- map[string]interface{}{"attributes": attributes}
+}
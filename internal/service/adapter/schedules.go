@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"owipex_reader/internal/config"
+)
+
+// changeSchedule führt die RPC-Methoden zur Verwaltung der Zeitpläne aus
+func (a *SensorAdapter) changeSchedule(method string, params map[string]interface{}) (interface{}, error) {
+	if method == "set_schedule" {
+		schedule, err := scheduleParam(params)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.scheduler.SetSchedule(schedule); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"name": schedule.Name, "saved": true}, nil
+	}
+
+	name, err := stringParam(params, "name")
+	if err != nil {
+		return nil, err
+	}
+	switch method {
+	case "delete_schedule":
+		if err := a.scheduler.DeleteSchedule(name); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"name": name, "deleted": true}, nil
+	default:
+		if err := a.scheduler.RunSchedule(name); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"name": name, "started": true}, nil
+	}
+}
+
+// scheduleParam liest den Parameter "schedule" als Zeitplan
+func scheduleParam(params map[string]interface{}) (config.ScheduleConfig, error) {
+	var schedule config.ScheduleConfig
+
+	raw, ok := params["schedule"].(map[string]interface{})
+	if !ok {
+		return schedule, fmt.Errorf("parameter 'schedule' muss ein Objekt sein")
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return schedule, err
+	}
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return schedule, fmt.Errorf("parameter 'schedule' ist kein gültiger Zeitplan: %w", err)
+	}
+	return schedule, nil
+}
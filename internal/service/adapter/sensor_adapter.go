@@ -2,29 +2,97 @@
 package adapter
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
+	"owipex_reader/internal/acquisition"
+	"owipex_reader/internal/arbitration"
+	"owipex_reader/internal/command"
 	"owipex_reader/internal/config"
+	"owipex_reader/internal/controller"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/event"
+	"owipex_reader/internal/failsafe"
+	"owipex_reader/internal/interlock"
+	"owipex_reader/internal/maintenance"
+	"owipex_reader/internal/rule"
+	"owipex_reader/internal/sequence"
 	"owipex_reader/internal/service"
+	"owipex_reader/internal/service/scheduler"
 	"owipex_reader/internal/types"
 )
 
 // SensorAdapter verbindet die neue Sensorarchitektur mit der ThingsBoard-Integration.
 type SensorAdapter struct {
 	deviceService   *service.DeviceService
-	sensors         []types.Sensor
 	logger          *log.Logger
 	stopChan        chan struct{}
 	wg              sync.WaitGroup
 	thingsboardChan chan map[string]interface{}
 	readIntervals   map[string]time.Duration
-	lastReadTimes   map[string]time.Time
+	acquisition     *acquisition.Scheduler
 	appConfig       *config.AppConfig
+	interlocks      *interlock.Engine
+	commands        *command.Manager
+	failsafe        *failsafe.Manager
+	controllers     *controller.Manager
+	rules           *rule.Engine
+	scheduler       *scheduler.Scheduler
+	sequences       *sequence.Runner
+	operatingHours  *maintenance.Tracker
+	arbiter         *arbitration.Arbiter
+
+	// Aktoren, deren Rückmeldung zuletzt vom befohlenen Zustand abwich
+	actorMismatch      map[string]bool
+	actorMismatchMutex sync.Mutex
+
+	// Zuletzt empfangene Werte der Schalt-Attribute "<id>_set_state" und "<id>_set_position"
+	commandAttributes      map[string]interface{}
+	commandAttributesMutex sync.Mutex
+
+	// Zeitpunkt der zuletzt gesendeten Verbindungsattribute je Gerät
+	connectivityPublished map[string]time.Time
+	connectivityMutex     sync.Mutex
+}
+
+// connectivityRefreshInterval begrenzt, wie oft Verbindungsattribute (z.B. last_success)
+// ohne Zustandswechsel aktualisiert werden
+const connectivityRefreshInterval = time.Minute
+
+// identityRecheckInterval ist der Abstand, in dem die Identität von Geräten mit abweichender
+// Hardware erneut geprüft wird
+const identityRecheckInterval = 5 * time.Minute
+
+// compensationConsumer wird von Sensoren implementiert, die Messwerte anderer
+// Sensoren zur Kompensation verwenden (z.B. Salinität und Luftdruck beim Sauerstoffsensor).
+type compensationConsumer interface {
+	CompensationSources() map[string]string
+	UpdateCompensation(parameter string, value float64)
+}
+
+// intervalProvider wird von Sensoren implementiert, die ein eigenes Leseintervall vorgeben
+// (z.B. der GPS-Empfänger). Ein in der Anwendungskonfiguration gesetztes Intervall hat Vorrang.
+type intervalProvider interface {
+	ReadInterval() time.Duration
+}
+
+// attributeSwitchable wird von Sensoren implementiert, die über ein Shared Attribute
+// aus ThingsBoard ein- und ausgeschaltet werden (z.B. "gpsEnabled").
+type attributeSwitchable interface {
+	EnableAttribute() string
+	Enable(enabled bool)
+	IsEnabled() bool
+}
+
+// backgroundReader wird von Geräten implementiert, die im Hintergrund einlesen (z.B. der
+// GPS-Empfänger). Die Factory startet keine I/O; der Adapter gibt das Einlesen mit Start frei,
+// beendet wird es mit Close beim Entfernen des Geräts.
+type backgroundReader interface {
+	Start()
 }
 
 // NewSensorAdapter erstellt einen neuen SensorAdapter.
@@ -51,6 +119,31 @@ func NewSensorAdapter(configPath string, deviceConfigPath string, tbChan chan ma
 
 	logger.Printf("Erfolgreich %d Sensoren geladen", len(sensors))
 
+	// Schwellwerte der Verbindungszustände übernehmen
+	deviceService.Registry().SetConnectivityConfig(device.ConnectivityConfig{
+		DegradedAfter:  appCfg.Connectivity.DegradedAfterFailures,
+		OfflineAfter:   appCfg.Connectivity.OfflineAfterFailures,
+		RecoverAfter:   appCfg.Connectivity.RecoverAfterSuccesses,
+		InitialBackoff: time.Duration(appCfg.Connectivity.BackoffInitialSeconds) * time.Second,
+		MaxBackoff:     time.Duration(appCfg.Connectivity.BackoffMaxSeconds) * time.Second,
+	})
+
+	// Sicherheitsverriegelungen vor jedem Befehl an ein Gerät prüfen
+	interlocks, err := interlock.NewEngine(appCfg.Interlocks, deviceService.Registry())
+	if err != nil {
+		deviceService.Close()
+		return nil, err
+	}
+	deviceService.Registry().SetCommandGuard(interlocks)
+
+	// Befehle an Aktoren je Gerät nacheinander ausführen und protokollieren
+	var audit *command.AuditLog
+	if appCfg.Commands.AuditLogPath != "" {
+		audit = command.NewAuditLog(appCfg.Commands.AuditLogPath, int64(appCfg.Commands.AuditLogMaxSizeKB)*1024)
+	}
+	commands := command.NewManager(deviceService.Registry(), audit, time.Duration(appCfg.Commands.TimeoutSeconds)*time.Second, appCfg.Commands.QueueSize)
+	failsafeManager := failsafe.NewManager(appCfg.Failsafe, deviceService.Registry(), commands)
+
 	// Read-Intervalle aus der Konfiguration extrahieren
 	readIntervals := make(map[string]time.Duration)
 	for _, sensorCfg := range appCfg.Sensors {
@@ -59,21 +152,161 @@ func NewSensorAdapter(configPath string, deviceConfigPath string, tbChan chan ma
 		}
 	}
 
-	return &SensorAdapter{
+	adapter := &SensorAdapter{
 		deviceService:   deviceService,
-		sensors:         sensors,
 		logger:          logger,
 		stopChan:        make(chan struct{}),
 		thingsboardChan: tbChan,
 		readIntervals:   readIntervals,
-		lastReadTimes:   make(map[string]time.Time),
+		acquisition:     acquisition.NewScheduler(appCfg.Acquisition),
 		appConfig:       appCfg,
-	}, nil
+		interlocks:      interlocks,
+		commands:        commands,
+		failsafe:        failsafeManager,
+		actorMismatch:   make(map[string]bool),
+
+		commandAttributes: make(map[string]interface{}),
+
+		connectivityPublished: make(map[string]time.Time),
+	}
+
+	// Vorrang zwischen Handbedienung, Reglern und Zeitplänen je Aktor
+	adapter.arbiter, err = arbitration.NewArbiter(appCfg.Arbitration, deviceService.Registry(), adapter.publishAttributes)
+	if err != nil {
+		commands.Stop()
+		deviceService.Close()
+		return nil, err
+	}
+	commands.SetArbiter(adapter.arbiter)
+	failsafeManager.SetHolder(adapter.arbiter)
+	adapter.acquisition.SetWatchdog(failsafeManager)
+	interlocks.SetEnforcer(&interlockEnforcer{commands: commands, arbiter: adapter.arbiter, adapter: adapter})
+
+	// Regelkreise schalten die Aktoren über die Befehlsverwaltung
+	adapter.controllers, err = newControllers(appCfg.Controllers, controller.Environment{
+		Registry:         deviceService.Registry(),
+		Commands:         commands,
+		Failsafe:         failsafeManager,
+		Publish:          adapter.publish,
+		StoreCalibration: adapter.storeCalibration,
+	})
+	if err != nil {
+		commands.Stop()
+		deviceService.Close()
+		return nil, fmt.Errorf("Fehler beim Erstellen der Regler: %w", err)
+	}
+
+	// Lokale Regeln laufen auch ohne Verbindung zu ThingsBoard
+	adapter.rules, err = rule.NewEngine(appCfg.Rules, deviceService.Registry(), commands, adapter.applyRuleAttributes)
+	if err != nil {
+		commands.Stop()
+		deviceService.Close()
+		return nil, err
+	}
+	if err := adapter.rules.SetStatePath(appCfg.RuleStatePath); err != nil {
+		commands.Stop()
+		deviceService.Close()
+		return nil, err
+	}
+
+	// Zeitpläne senden ihre Befehle ebenfalls über die Befehlsverwaltung
+	adapter.scheduler, err = scheduler.NewScheduler(appCfg.Scheduler, deviceService.Registry(), commands)
+	if err != nil {
+		commands.Stop()
+		deviceService.Close()
+		return nil, fmt.Errorf("Fehler beim Erstellen der Zeitpläne: %w", err)
+	}
+
+	// Schrittketten bewerten ihre Bedingungen mit den Messwerten der lokalen Regeln
+	adapter.sequences, err = sequence.NewRunner(appCfg.Sequencer, adapter.rules, deviceService.EventBus(), adapter.publish)
+	if err != nil {
+		commands.Stop()
+		deviceService.Close()
+		return nil, fmt.Errorf("Fehler beim Erstellen der Schrittketten: %w", err)
+	}
+
+	// Betriebsstunden und Schaltspiele aus den Zustandsrückmeldungen der Aktoren zählen
+	adapter.operatingHours, err = maintenance.NewTracker(appCfg.OperatingHours, deviceService.EventBus(), adapter.publish)
+	if err != nil {
+		commands.Stop()
+		deviceService.Close()
+		return nil, fmt.Errorf("Fehler beim Erstellen der Betriebsstundenzähler: %w", err)
+	}
+
+	return adapter, nil
 }
 
 // Start startet den SensorAdapter.
 func (a *SensorAdapter) Start() {
 	a.logger.Println("Starte SensorAdapter...")
+
+	// Geräteidentitäten als Client-Attribute veröffentlichen
+	a.publishIdentities("")
+
+	// Anfangszustand der Verbindungen veröffentlichen
+	for _, status := range a.deviceService.Registry().GetAllConnectivity() {
+		a.publishConnectivity(status, true)
+	}
+
+	// Ereignisse über den Event-Bus verarbeiten
+	bus := a.deviceService.EventBus()
+	if err := bus.Subscribe("sensor_adapter.lifecycle", a.handleDeviceEvent, event.SubscriberOptions{}, event.TopicDeviceLifecycle); err != nil {
+		a.logger.Printf("Fehler beim Abonnieren der Geräteereignisse: %v", err)
+	}
+	if err := bus.Subscribe("sensor_adapter.compensation", a.handleReading, event.SubscriberOptions{}, event.TopicReadingProduced); err != nil {
+		a.logger.Printf("Fehler beim Abonnieren der Messwerte: %v", err)
+	}
+
+	// Betriebsstunden zählen, bevor die ersten Aktorzustände gemeldet werden
+	if err := a.operatingHours.Start(); err != nil {
+		a.logger.Printf("Fehler beim Starten der Betriebsstundenzähler: %v", err)
+	}
+
+	// Verriegelungen bewerten und aktive Verriegelungen als Alarm melden
+	if err := a.interlocks.Start(); err != nil {
+		a.logger.Printf("Fehler beim Starten der Verriegelungen: %v", err)
+	}
+	a.logger.Printf("%d Verriegelungen aktiv", len(a.interlocks.Rules()))
+
+	// Besitzer der Aktoren melden und abgelaufene Handbedienungen aufheben
+	a.arbiter.Start()
+
+	// Rückfallebenen der Aktoren programmieren, Verbindung und Watchdog überwachen
+	if err := a.failsafe.Start(); err != nil {
+		a.logger.Printf("Fehler beim Starten der Failsafe-Überwachung: %v", err)
+	}
+
+	// Regelkreise starten
+	a.controllers.Start()
+
+	// Lokale Regeln bewerten
+	if err := a.rules.Start(); err != nil {
+		a.logger.Printf("Fehler beim Starten der Regeln: %v", err)
+	}
+	a.logger.Printf("%d Regeln aktiv", len(a.rules.Rules()))
+
+	// Zeitpläne einplanen
+	if err := a.scheduler.Start(); err != nil {
+		a.logger.Printf("Fehler beim Starten der Zeitpläne: %v", err)
+	}
+
+	// Schrittketten fortsetzen, die vor dem Neustart liefen
+	if err := a.sequences.Start(); err != nil {
+		a.logger.Printf("Fehler beim Starten der Schrittketten: %v", err)
+	}
+
+	// Einlesen im Hintergrund (z.B. GPS) freigeben
+	for _, dev := range a.deviceService.Registry().GetAllDevices() {
+		a.startBackgroundReader(dev.ID())
+	}
+
+	// Sensoren und Aktorzustände nach eigenem Zeitplan je Gerät lesen
+	a.syncAcquisition()
+	a.acquisition.Start()
+
+	// Änderungen der Gerätekonfiguration zur Laufzeit übernehmen
+	a.deviceService.StartWatching(time.Duration(a.appConfig.DeviceReloadIntervalSeconds) * time.Second)
+
 	a.wg.Add(1)
 	go a.run()
 }
@@ -83,15 +316,36 @@ func (a *SensorAdapter) Stop() {
 	a.logger.Println("Stoppe SensorAdapter...")
 	close(a.stopChan)
 	a.wg.Wait()
+	a.acquisition.Stop()
+	a.deviceService.EventBus().Unsubscribe("sensor_adapter.lifecycle")
+	a.deviceService.EventBus().Unsubscribe("sensor_adapter.compensation")
+	a.operatingHours.Stop()
+
+	// Schrittketten, Zeitpläne, Regeln und Regler anhalten und Aktoren vor dem Schließen der Verbindungen in den sicheren Zustand bringen
+	a.sequences.Stop()
+	a.scheduler.Stop()
+	a.rules.Stop()
+	a.controllers.Stop()
+	a.failsafe.Stop()
+	a.failsafe.Apply(failsafe.ReasonShutdown)
+	a.arbiter.Stop()
+	a.commands.Stop()
+	a.interlocks.Stop()
+	a.deviceService.Close()
 	a.logger.Println("SensorAdapter gestoppt.")
 }
 
-// run führt den Hauptloop des SensorAdapters aus.
+// run führt den Hauptloop des SensorAdapters aus. Er gleicht den Verbindungszustand mit der
+// (De-)Aktivierung der Geräte ab und prüft die Identität von Geräten mit abweichender Hardware
+// erneut; gelesen wird im Scheduler der Messwerterfassung. Den
+// Watchdog der Failsafe-Überwachung bedienen die Leseaufgaben und Regler selbst.
 func (a *SensorAdapter) run() {
 	defer a.wg.Done()
 
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
+	identityTicker := time.NewTicker(identityRecheckInterval)
+	defer identityTicker.Stop()
 
 	for {
 		select {
@@ -99,56 +353,249 @@ func (a *SensorAdapter) run() {
 			a.logger.Println("SensorAdapter-Loop wird gestoppt.")
 			return
 		case <-ticker.C:
-			// Über alle Sensoren iterieren
-			for _, sensor := range a.sensors {
-				sensorID := sensor.ID()
+			registry := a.deviceService.Registry()
 
-				// Prüfen, ob es Zeit ist, den Sensor zu lesen
-				lastRead, exists := a.lastReadTimes[sensorID]
-				readInterval, intervalExists := a.readIntervals[sensorID]
-
-				if !intervalExists {
-					// Standardintervall verwenden, wenn keines konfiguriert ist
-					readInterval = 15 * time.Second
+			for _, dev := range registry.GetAllDevices() {
+				if status, changed, err := registry.SyncEnabled(dev.ID()); err == nil && changed {
+					a.publishConnectivity(status, true)
 				}
+			}
+		case <-identityTicker.C:
+			a.recheckIdentities()
+		}
+	}
+}
 
-				if !exists || time.Since(lastRead) >= readInterval {
-					a.logger.Printf("Lese Sensor: %s", sensorID)
-					a.wg.Add(1)
+// recheckIdentities prüft die Identität der Geräte im Zustand identity_mismatch erneut und
+// veröffentlicht Prüfergebnis und Verbindungszustand
+func (a *SensorAdapter) recheckIdentities() {
+	for _, check := range a.deviceService.RecheckIdentities() {
+		if check.Verified {
+			a.logger.Printf("Identität von %s bestätigt, Gerät wird wieder angesprochen", check.DeviceID)
+		}
+		a.publishIdentities(check.DeviceID)
+		if status, err := a.deviceService.Registry().GetConnectivity(check.DeviceID); err == nil {
+			a.publishConnectivity(status, true)
+		}
+	}
+}
 
-					go func(s types.Sensor) {
-						defer a.wg.Done()
+// ApplySharedAttributes schaltet Sensoren anhand von Shared Attributes aus ThingsBoard ein oder aus,
+// schaltet Aktoren über "<id>_set_state" und "<id>_set_position" und gibt die Attribute an die
+// Regler (z.B. Sollwerte der pH-Regelung), die lokalen Regeln und den Zeitplaner (Attribut
+// "schedules") weiter.
+// Sowohl Attribut-Updates als auch Antworten auf Attributanfragen ({"shared": {...}}) werden unterstützt.
+func (a *SensorAdapter) ApplySharedAttributes(attributes map[string]interface{}) {
+	response := false
+	if shared, ok := attributes["shared"].(map[string]interface{}); ok {
+		attributes = shared
+		response = true
+	}
 
-						// Sensor lesen
-						ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-						defer cancel()
+	a.applyMaintenanceAttributes(attributes)
+	a.controllers.ApplyAttributes(attributes)
+	a.rules.ApplyAttributes(attributes)
+	a.scheduler.ApplyAttributes(attributes)
+	a.applyCommandAttributes(attributes, response)
 
-						reading, err := s.Read(ctx)
-						a.lastReadTimes[s.ID()] = time.Now()
+	for _, sensor := range a.deviceService.Registry().GetSensors() {
+		switchable, ok := sensor.(attributeSwitchable)
+		if !ok {
+			continue
+		}
 
-						if err != nil {
-							a.logger.Printf("Fehler beim Lesen des Sensors %s: %v", s.ID(), err)
-							a.thingsboardChan <- map[string]interface{}{
-								fmt.Sprintf("%s_error", s.ID()): err.Error(),
-							}
-							return
-						}
+		value, exists := attributes[switchable.EnableAttribute()]
+		if !exists {
+			continue
+		}
 
-						a.logger.Printf("Sensor %s erfolgreich gelesen: %v", s.ID(), reading.Value)
+		enabled, ok := value.(bool)
+		if !ok {
+			a.logger.Printf("Attribut %s für Sensor %s ist kein Boolean: %v", switchable.EnableAttribute(), sensor.ID(), value)
+			continue
+		}
 
-						// Daten für ThingsBoard formatieren
-						formattedData := a.formatReadingForThingsboard(s, reading)
-						a.thingsboardChan <- formattedData
+		if switchable.IsEnabled() != enabled {
+			a.logger.Printf("Setze Sensor %s über Attribut %s auf enabled=%v", sensor.ID(), switchable.EnableAttribute(), enabled)
+			switchable.Enable(enabled)
+		}
+	}
+}
 
-					}(sensor)
-				}
+// applyMaintenanceAttributes versetzt Geräte über das Shared Attribute "<id>_maintenance"
+// in den Wartungszustand oder beendet ihn.
+func (a *SensorAdapter) applyMaintenanceAttributes(attributes map[string]interface{}) {
+	registry := a.deviceService.Registry()
+
+	for _, status := range registry.GetAllConnectivity() {
+		key := status.DeviceID + "_maintenance"
+		value, exists := attributes[key]
+		if !exists {
+			continue
+		}
+
+		maintenance, ok := value.(bool)
+		if !ok {
+			a.logger.Printf("Attribut %s ist kein Boolean: %v", key, value)
+			continue
+		}
+
+		newStatus, changed, err := registry.SetMaintenance(status.DeviceID, maintenance)
+		if err != nil {
+			a.logger.Printf("Fehler beim Setzen des Wartungszustands von %s: %v", status.DeviceID, err)
+			continue
+		}
+		if changed {
+			a.logger.Printf("Gerät %s: Wartung=%v", status.DeviceID, maintenance)
+			a.publishConnectivity(newStatus, true)
+		}
+	}
+}
+
+// recordResult übernimmt das Ergebnis eines Lesevorgangs in den Verbindungszustand und
+// veröffentlicht die Verbindungsattribute bei Zustandswechseln und Fehlern sofort, sonst
+// höchstens einmal je connectivityRefreshInterval.
+func (a *SensorAdapter) recordResult(deviceID string, err error) {
+	status, changed, recordErr := a.deviceService.Registry().RecordResult(deviceID, err)
+	if recordErr != nil {
+		// Gerät wurde inzwischen entfernt
+		return
+	}
+
+	if changed {
+		a.logger.Printf("Verbindungszustand %s", status)
+	}
+	a.publishConnectivity(status, changed || err != nil)
+}
+
+// publishConnectivity sendet den Verbindungszustand eines Geräts als Client-Attribute an ThingsBoard.
+func (a *SensorAdapter) publishConnectivity(status device.ConnectivityStatus, force bool) {
+	a.connectivityMutex.Lock()
+	last, exists := a.connectivityPublished[status.DeviceID]
+	if !force && exists && time.Since(last) < connectivityRefreshInterval {
+		a.connectivityMutex.Unlock()
+		return
+	}
+	a.connectivityPublished[status.DeviceID] = time.Now()
+	a.connectivityMutex.Unlock()
+
+	a.thingsboardChan <- map[string]interface{}{
+		"attributes": status.Attributes(),
+	}
+}
+
+// EventBus gibt den Event-Bus zurück, über den Geräte-, Mess- und Verbindungsereignisse laufen.
+func (a *SensorAdapter) EventBus() *event.Bus {
+	return a.deviceService.EventBus()
+}
+
+// handleDeviceEvent verarbeitet Ereignisse der Geräteregistry (z.B. beim Konfigurations-Reload).
+func (a *SensorAdapter) handleDeviceEvent(e event.Event) {
+	deviceEvent, ok := e.Payload.(types.DeviceEvent)
+	if !ok {
+		return
+	}
+
+	a.logger.Printf("Geräteereignis %s für %s", deviceEvent.Type, deviceEvent.DeviceID)
+
+	switch deviceEvent.Type {
+	case types.EventAdded, types.EventUpdated:
+		// Die neue Instanz einlesen lassen und ihre Identität und ihren Verbindungszustand
+		// veröffentlichen
+		a.startBackgroundReader(deviceEvent.DeviceID)
+		a.publishIdentities(deviceEvent.DeviceID)
+		if status, err := a.deviceService.Registry().GetConnectivity(deviceEvent.DeviceID); err == nil {
+			a.publishConnectivity(status, true)
+		}
+		a.syncAcquisition()
+	case types.EventRemoved:
+		a.connectivityMutex.Lock()
+		delete(a.connectivityPublished, deviceEvent.DeviceID)
+		a.connectivityMutex.Unlock()
+
+		a.actorMismatchMutex.Lock()
+		delete(a.actorMismatch, deviceEvent.DeviceID)
+		a.actorMismatchMutex.Unlock()
+		a.syncAcquisition()
+	}
+}
+
+// startBackgroundReader gibt das Einlesen eines Geräts im Hintergrund frei, sofern es das
+// unterstützt
+func (a *SensorAdapter) startBackgroundReader(deviceID string) {
+	dev, err := a.deviceService.Registry().GetDevice(deviceID)
+	if err != nil {
+		return
+	}
+	if reader, ok := dev.(backgroundReader); ok {
+		reader.Start()
+	}
+}
+
+// handleReading gibt neue Messwerte an Sensoren weiter, die sie zur Kompensation nutzen.
+func (a *SensorAdapter) handleReading(e event.Event) {
+	if produced, ok := e.Payload.(event.ReadingProduced); ok {
+		a.distributeCompensation(produced.DeviceID, produced.Reading)
+	}
+}
+
+// publishIdentities sendet die gelesenen Geräteidentitäten als Client-Attribute an ThingsBoard.
+// Ist deviceID gesetzt, wird nur die Identität dieses Geräts gesendet.
+func (a *SensorAdapter) publishIdentities(deviceID string) {
+	attributes := make(map[string]interface{})
+	for _, check := range a.deviceService.IdentityChecks() {
+		if deviceID != "" && check.DeviceID != deviceID {
+			continue
+		}
+		if check.Err != nil {
+			a.logger.Printf("Identitätsprüfung für %s: %v", check.DeviceID, check.Err)
+		}
+		for key, value := range check.Attributes() {
+			attributes[key] = value
+		}
+	}
+
+	if len(attributes) > 0 {
+		a.thingsboardChan <- map[string]interface{}{
+			"attributes": attributes,
+		}
+	}
+}
+
+// distributeCompensation übergibt einen Messwert an alle Sensoren, die diesen Sensor
+// als Kompensationsquelle ("<sensor_id>" oder "<sensor_id>.<metadaten_schlüssel>") konfiguriert haben.
+func (a *SensorAdapter) distributeCompensation(sourceID string, reading types.Reading) {
+	for _, sensor := range a.deviceService.Registry().GetSensors() {
+		consumer, ok := sensor.(compensationConsumer)
+		if !ok {
+			continue
+		}
+
+		for parameter, source := range consumer.CompensationSources() {
+			id, key := source, ""
+			if idx := strings.Index(source, "."); idx >= 0 {
+				id, key = source[:idx], source[idx+1:]
+			}
+			if id != sourceID {
+				continue
+			}
+
+			value := reading.Value
+			if key != "" {
+				value = reading.Metadata[key]
+			}
+
+			if floatValue, ok := value.(float64); ok {
+				consumer.UpdateCompensation(parameter, floatValue)
+			} else {
+				a.logger.Printf("Kompensationswert %s von %s für Sensor %s ist keine Zahl: %v", parameter, source, sensor.ID(), value)
 			}
 		}
 	}
 }
 
 // formatReadingForThingsboard formatiert die Sensordaten für ThingsBoard.
-func (a *SensorAdapter) formatReadingForThingsboard(s types.Sensor, reading types.Reading) map[string]interface{} {
+func (a *SensorAdapter) formatReadingForThingsboard(s types.ReadableDevice, reading types.Reading) map[string]interface{} {
 	// Konfiguration für diesen Sensor finden
 	var sensorCfg config.SensorConfig
 	for _, cfg := range a.appConfig.Sensors {
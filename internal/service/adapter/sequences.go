@@ -0,0 +1,33 @@
+package adapter
+
+// changeSequence führt die RPC-Methoden zur Steuerung der Schrittketten aus und gibt den
+// neuen Zustand der Schrittkette zurück
+func (a *SensorAdapter) changeSequence(method string, params map[string]interface{}) (interface{}, error) {
+	name, err := stringParam(params, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "start_sequence":
+		step, _ := params["step"].(string)
+		err = a.sequences.StartSequence(name, step)
+	case "pause_sequence":
+		err = a.sequences.PauseSequence(name)
+	case "resume_sequence":
+		err = a.sequences.ResumeSequence(name)
+	default:
+		reason, _ := params["reason"].(string)
+		err = a.sequences.AbortSequence(name, reason)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range a.sequences.Status() {
+		if status.Name == name {
+			return status, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"owipex_reader/internal/device"
+)
+
+const (
+	// maxConfigBackups begrenzt die Anzahl der aufbewahrten Konfigurationsstände
+	maxConfigBackups = 20
+
+	// backupManifest ist die Beschreibungsdatei eines Konfigurationsstands
+	backupManifest = "backup.json"
+
+	// backupVersionFormat ergibt sortierbare Versionsbezeichnungen
+	backupVersionFormat = "20060102T150405.000Z"
+
+	// rollbackReason ist der Anlass des Stands, den RollbackConfig vor dem Wiederherstellen
+	// sichert. Solche Stände sind kein Ziel eines weiteren Rollbacks.
+	rollbackReason = "rollback_config"
+)
+
+// ConfigBackup beschreibt einen gesicherten Stand aller Gerätekonfigurationen
+type ConfigBackup struct {
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+	Reason  string    `json:"reason"`
+	Files   []string  `json:"files"`
+
+	// Good ist gesetzt, wenn der gesicherte Stand ohne Fehler geladen war. Nur solche Stände
+	// stellt RollbackConfig wieder her.
+	Good bool `json:"good"`
+}
+
+// backupRoot gibt das Verzeichnis der Konfigurationsstände zurück
+func (s *DeviceService) backupRoot() string {
+	return filepath.Join(s.configPath, device.BackupDir)
+}
+
+// ConfigBackups gibt die gesicherten Konfigurationsstände zurück, der neueste zuerst
+func (s *DeviceService) ConfigBackups() ([]ConfigBackup, error) {
+	entries, err := ioutil.ReadDir(s.backupRoot())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fehler beim Lesen der Konfigurationsstände: %w", err)
+	}
+
+	var backups []ConfigBackup
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(s.backupRoot(), entry.Name(), backupManifest))
+		if err != nil {
+			// Unvollständige Sicherung (z.B. Abbruch beim Schreiben)
+			continue
+		}
+
+		var backup ConfigBackup
+		if err := json.Unmarshal(data, &backup); err != nil {
+			continue
+		}
+		backup.Version = entry.Name()
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Version > backups[j].Version
+	})
+	return backups, nil
+}
+
+// snapshotConfigs sichert alle Gerätekonfigurationen als neuen Konfigurationsstand.
+// Das Manifest wird zuletzt geschrieben, ein abgebrochener Stand wird daher ignoriert.
+// reloadMutex muss gehalten werden.
+func (s *DeviceService) snapshotConfigs(reason string) (ConfigBackup, error) {
+	now := time.Now().UTC()
+	backup := ConfigBackup{
+		Version: now.Format(backupVersionFormat),
+		Created: now,
+		Reason:  reason,
+		Good:    s.cleanState,
+	}
+
+	dir := filepath.Join(s.backupRoot(), backup.Version)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		backup.Version = fmt.Sprintf("%s-%d", now.Format(backupVersionFormat), i)
+		dir = filepath.Join(s.backupRoot(), backup.Version)
+	}
+
+	for _, sensorDir := range s.sensorDirs() {
+		paths, err := listConfigFiles(sensorDir)
+		if err != nil {
+			return ConfigBackup{}, err
+		}
+
+		for _, path := range paths {
+			rel, err := filepath.Rel(s.configPath, path)
+			if err != nil {
+				return ConfigBackup{}, err
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return ConfigBackup{}, fmt.Errorf("fehler beim Sichern von %s: %w", path, err)
+			}
+			if err := device.WriteFileAtomic(filepath.Join(dir, rel), data, 0644); err != nil {
+				return ConfigBackup{}, fmt.Errorf("fehler beim Sichern von %s: %w", path, err)
+			}
+			backup.Files = append(backup.Files, filepath.ToSlash(rel))
+		}
+	}
+
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return ConfigBackup{}, err
+	}
+	if err := device.WriteFileAtomic(filepath.Join(dir, backupManifest), data, 0644); err != nil {
+		return ConfigBackup{}, fmt.Errorf("fehler beim Schreiben des Manifests: %w", err)
+	}
+
+	s.pruneBackups()
+	return backup, nil
+}
+
+// rollbackTarget gibt den neuesten fehlerfrei geladenen Stand zurück, den RollbackConfig
+// wiederherstellt. backups muss wie von ConfigBackups sortiert sein.
+func rollbackTarget(backups []ConfigBackup) (ConfigBackup, bool) {
+	for _, backup := range backups {
+		if backup.Good && backup.Reason != rollbackReason {
+			return backup, true
+		}
+	}
+	return ConfigBackup{}, false
+}
+
+// removeBackup löscht einen Konfigurationsstand
+func (s *DeviceService) removeBackup(backup ConfigBackup) error {
+	return os.RemoveAll(filepath.Join(s.backupRoot(), backup.Version))
+}
+
+// pruneBackups löscht die ältesten Konfigurationsstände über maxConfigBackups hinaus. Der
+// Stand, den RollbackConfig wiederherstellen würde, bleibt immer erhalten, auch wenn ihm mehr
+// als maxConfigBackups fehlerhafte Stände folgen.
+func (s *DeviceService) pruneBackups() {
+	backups, err := s.ConfigBackups()
+	if err != nil || len(backups) <= maxConfigBackups {
+		return
+	}
+
+	target, found := rollbackTarget(backups)
+	keep := maxConfigBackups
+	if found && target.Version < backups[maxConfigBackups-1].Version {
+		// Platz für den fehlerfreien Stand schaffen
+		keep--
+	}
+
+	for _, backup := range backups[keep:] {
+		if found && backup.Version == target.Version {
+			continue
+		}
+		os.RemoveAll(filepath.Join(s.backupRoot(), backup.Version))
+	}
+}
+
+// restoreBackup ersetzt alle Gerätekonfigurationen durch einen gesicherten Stand.
+// Dateien, die im Stand nicht enthalten sind, werden gelöscht.
+func (s *DeviceService) restoreBackup(backup ConfigBackup) error {
+	dir := filepath.Join(s.backupRoot(), backup.Version)
+
+	// Erst alle Dateien lesen, damit ein unvollständiger Stand nichts verändert
+	contents := make(map[string][]byte, len(backup.Files))
+	for _, rel := range backup.Files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return fmt.Errorf("konfigurationsstand %s unvollständig: %w", backup.Version, err)
+		}
+		contents[filepath.Join(s.configPath, filepath.FromSlash(rel))] = data
+	}
+
+	for path, data := range contents {
+		if err := device.WriteFileAtomic(path, data, 0644); err != nil {
+			return fmt.Errorf("fehler beim Wiederherstellen von %s: %w", path, err)
+		}
+	}
+
+	for _, sensorDir := range s.sensorDirs() {
+		paths, err := listConfigFiles(sensorDir)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if _, restored := contents[path]; restored {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("fehler beim Entfernen von %s: %w", path, err)
+			}
+		}
+	}
+
+	return nil
+}
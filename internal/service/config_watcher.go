@@ -0,0 +1,263 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"owipex_reader/internal/types"
+)
+
+const (
+	// createRetryInitial ist die Wartezeit, bevor ein Gerät nach fehlgeschlagenem Erstellen
+	// erneut erstellt wird; sie verdoppelt sich bis createRetryMax
+	createRetryInitial = 30 * time.Second
+	createRetryMax     = 10 * time.Minute
+)
+
+// createFailure ist eine Konfiguration, deren Gerät nicht erstellt werden konnte
+type createFailure struct {
+	config types.DeviceConfig
+	delay  time.Duration
+	next   time.Time
+}
+
+// ReloadResult fasst die Änderungen eines Konfigurations-Reloads zusammen
+type ReloadResult struct {
+	Added   []string
+	Updated []string
+	Removed []string
+	Errors  []error
+
+	// Failed sind die Geräte, deren neue Konfiguration nicht in Betrieb genommen werden konnte
+	Failed []string
+}
+
+// failed prüft, ob eines der Geräte beim Reload nicht in Betrieb genommen werden konnte
+func (r ReloadResult) failed(ids []string) []string {
+	var failed []string
+	for _, id := range ids {
+		for _, failedID := range r.Failed {
+			if id == failedID {
+				failed = append(failed, id)
+				break
+			}
+		}
+	}
+	return failed
+}
+
+// HasChanges prüft, ob beim Reload Geräte geändert wurden
+func (r ReloadResult) HasChanges() bool {
+	return len(r.Added) > 0 || len(r.Updated) > 0 || len(r.Removed) > 0
+}
+
+// StartWatching prüft die Konfigurationsverzeichnisse im angegebenen Intervall auf Änderungen
+// und wendet diese über Reload an
+func (s *DeviceService) StartWatching(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s.reloadMutex.Lock()
+	if s.watchStop != nil {
+		s.reloadMutex.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.watchStop = stop
+	s.reloadMutex.Unlock()
+
+	s.watchWg.Add(1)
+	go func() {
+		defer s.watchWg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				result := s.Reload()
+				for _, err := range result.Errors {
+					s.logger.Printf("Konfigurations-Reload: %v", err)
+				}
+				if result.HasChanges() {
+					s.logger.Printf("Konfigurations-Reload: %d hinzugefügt, %d aktualisiert, %d entfernt",
+						len(result.Added), len(result.Updated), len(result.Removed))
+				}
+			}
+		}
+	}()
+}
+
+// StopWatching beendet die Überwachung der Konfigurationsverzeichnisse
+func (s *DeviceService) StopWatching() {
+	s.reloadMutex.Lock()
+	stop := s.watchStop
+	s.watchStop = nil
+	s.reloadMutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		s.watchWg.Wait()
+	}
+}
+
+// Close beendet die Überwachung, schließt alle Geräte und danach den Event-Bus
+func (s *DeviceService) Close() {
+	s.StopWatching()
+	s.deviceRegistry.Close()
+	s.eventBus.Close()
+}
+
+// Reload liest die Konfigurationsverzeichnisse neu ein und gleicht die Geräte mit dem
+// geladenen Stand ab. Neue Geräte werden erstellt, geänderte durch eine neue Instanz ersetzt
+// und entfernte geschlossen. Die Registry löst dabei EventAdded, EventUpdated bzw.
+// EventRemoved aus. Unveränderte Geräte laufen ungestört weiter. Ein geändertes Gerät läuft
+// weiter, bis die neue Instanz erstellt und in der Registry eingesetzt ist, und wird erst danach
+// geschlossen; schlägt das Erstellen fehl, läuft es mit der bisherigen Konfiguration weiter und
+// die neue wird mit wachsendem Abstand erneut versucht.
+func (s *DeviceService) Reload() ReloadResult {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	return s.reloadLocked()
+}
+
+// reloadLocked führt den Reload aus, reloadMutex muss gehalten werden
+func (s *DeviceService) reloadLocked() ReloadResult {
+	var result ReloadResult
+
+	files, errs := s.scanConfigFiles()
+	result.Errors = append(result.Errors, errs...)
+
+	configs, errs := collectConfigs(files)
+	result.Errors = append(result.Errors, errs...)
+
+	current := make(map[string]types.DeviceConfig, len(configs))
+	for _, config := range configs {
+		current[config.ID] = config
+	}
+
+	// Entfernte Geräte schließen
+	for id := range s.configs {
+		if _, exists := current[id]; exists {
+			continue
+		}
+
+		delete(s.configs, id)
+		if s.removeDevice(id, &result) {
+			result.Removed = append(result.Removed, id)
+		}
+	}
+	for id := range s.createFailures {
+		if _, exists := current[id]; !exists {
+			delete(s.createFailures, id)
+		}
+	}
+
+	// Neue und geänderte Geräte erstellen
+	now := time.Now()
+	var created []types.Device
+	var createdConfigs []types.DeviceConfig
+	for _, config := range configs {
+		previous, existed := s.configs[config.ID]
+		if existed && reflect.DeepEqual(previous, config) {
+			// Eine fehlgeschlagene Änderung wurde zurückgenommen
+			delete(s.createFailures, config.ID)
+			continue
+		}
+
+		// Fehlgeschlagene Konfigurationen erst nach Ablauf der Wartezeit erneut versuchen
+		failure, failedBefore := s.createFailures[config.ID]
+		if failedBefore && reflect.DeepEqual(failure.config, config) && now.Before(failure.next) {
+			result.Failed = append(result.Failed, config.ID)
+			continue
+		}
+
+		dev, err := s.deviceFactory.CreateDevice(config)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("fehler beim Erstellen von Gerät '%s': %w", config.ID, err))
+			result.Failed = append(result.Failed, config.ID)
+			s.recordCreateFailure(config, failure, failedBefore && reflect.DeepEqual(failure.config, config), now)
+			continue
+		}
+		delete(s.createFailures, config.ID)
+
+		created = append(created, dev)
+		createdConfigs = append(createdConfigs, config)
+	}
+
+	// Identität der neuen Geräte prüfen, bevor sie in Betrieb gehen
+	result.Errors = append(result.Errors, s.verifySensorIdentities(created, createdConfigs)...)
+
+	for i, dev := range created {
+		id := dev.ID()
+
+		if _, err := s.deviceRegistry.GetDevice(id); err != nil {
+			if err := s.deviceRegistry.AddDevice(dev); err != nil {
+				result.Errors = append(result.Errors, err)
+				result.Failed = append(result.Failed, id)
+				dev.Close()
+				continue
+			}
+			s.applyIdentityCheck(id)
+			s.configs[id] = createdConfigs[i]
+			result.Added = append(result.Added, id)
+			continue
+		}
+
+		// Neue Instanz zuerst einsetzen, das bisherige Gerät erst danach schließen
+		previous, err := s.deviceRegistry.ReplaceDevice(dev)
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			result.Failed = append(result.Failed, id)
+			dev.Close()
+			continue
+		}
+		s.applyIdentityCheck(id)
+		s.configs[id] = createdConfigs[i]
+		if err := previous.Close(); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("fehler beim Schließen des alten Geräts %s: %w", id, err))
+		}
+		result.Updated = append(result.Updated, id)
+	}
+
+	s.cleanState = len(result.Errors) == 0 && len(s.fileErrors) == 0 && len(s.profileErrors) == 0 && len(s.createFailures) == 0
+	return result
+}
+
+// recordCreateFailure merkt eine Konfiguration, deren Gerät nicht erstellt werden konnte, und
+// verdoppelt bei wiederholtem Fehlschlag die Wartezeit bis zum nächsten Versuch
+func (s *DeviceService) recordCreateFailure(config types.DeviceConfig, previous createFailure, repeated bool, now time.Time) {
+	delay := createRetryInitial
+	if repeated {
+		delay = previous.delay * 2
+		if delay > createRetryMax {
+			delay = createRetryMax
+		}
+	}
+	s.createFailures[config.ID] = createFailure{config: config, delay: delay, next: now.Add(delay)}
+}
+
+// removeDevice entfernt ein Gerät aus der Registry und schließt es
+func (s *DeviceService) removeDevice(id string, result *ReloadResult) bool {
+	existing, err := s.deviceRegistry.GetDevice(id)
+	if err != nil {
+		// Gerät wurde nie erfolgreich erstellt
+		return false
+	}
+
+	if err := s.deviceRegistry.RemoveDevice(id); err != nil {
+		result.Errors = append(result.Errors, err)
+		return false
+	}
+	if err := existing.Close(); err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("fehler beim Schließen des Geräts %s: %w", id, err))
+	}
+
+	return true
+}
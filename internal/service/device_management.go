@@ -0,0 +1,377 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/types"
+	"owipex_reader/internal/validation"
+)
+
+// deviceIDPattern beschränkt Geräte-IDs auf Zeichen, die als Dateiname unkritisch sind
+var deviceIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ChangeResult ist das Ergebnis einer Konfigurationsänderung
+type ChangeResult struct {
+	// Backup ist der vor der Änderung gesicherte Konfigurationsstand
+	Backup ConfigBackup
+
+	// Reload enthält die dabei hinzugefügten, aktualisierten und entfernten Geräte
+	Reload ReloadResult
+
+	// Warnings sind Warnungen der Validierung, die die Änderung nicht verhindert haben
+	Warnings []validation.Issue
+}
+
+// ValidationError wird zurückgegeben, wenn eine Änderung die Validierung nicht besteht
+type ValidationError struct {
+	Issues []validation.Issue
+}
+
+// Error implementiert das error-Interface
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = issue.String()
+	}
+	return "konfiguration ungültig: " + strings.Join(messages, "; ")
+}
+
+// configChange beschreibt die zu schreibenden (Wert) und zu löschenden (nil) Dateien einer Änderung
+type configChange map[string]*types.DeviceConfig
+
+// GetDeviceConfig gibt die gespeicherte Konfiguration eines Geräts zurück (ohne Profil)
+func (s *DeviceService) GetDeviceConfig(id string) (types.DeviceConfig, error) {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	_, config, err := s.findConfigFile(id)
+	if err != nil {
+		return types.DeviceConfig{}, err
+	}
+	return config, nil
+}
+
+// AddDeviceConfig legt eine neue Gerätekonfiguration im Verzeichnis ihres Gerätetyps an
+// und nimmt das Gerät in Betrieb
+func (s *DeviceService) AddDeviceConfig(config types.DeviceConfig) (ChangeResult, error) {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	if !deviceIDPattern.MatchString(config.ID) {
+		return ChangeResult{}, fmt.Errorf("ungültige Geräte-ID '%s': erlaubt sind Buchstaben, Ziffern, '_', '-' und '.'", config.ID)
+	}
+	if _, _, err := s.findConfigFile(config.ID); err == nil {
+		return ChangeResult{}, fmt.Errorf("gerät '%s' existiert bereits", config.ID)
+	}
+
+	path, err := s.configFilePath(config)
+	if err != nil {
+		return ChangeResult{}, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return ChangeResult{}, fmt.Errorf("konfigurationsdatei %s existiert bereits", path)
+	}
+
+	return s.applyChange("add_device "+config.ID, configChange{path: &config})
+}
+
+// UpdateDeviceConfig ändert die Konfiguration eines Geräts. patch wird wie ein JSON Merge
+// Patch angewendet: Objekte werden zusammengeführt, andere Werte ersetzt und null entfernt
+// einen Wert. Die ID kann nicht geändert werden.
+func (s *DeviceService) UpdateDeviceConfig(id string, patch map[string]interface{}) (ChangeResult, error) {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	return s.updateDeviceConfig(id, patch)
+}
+
+// SetDeviceEnabled aktiviert oder deaktiviert ein Gerät dauerhaft in seiner Konfiguration
+func (s *DeviceService) SetDeviceEnabled(id string, enabled bool) (ChangeResult, error) {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	return s.updateDeviceConfig(id, map[string]interface{}{"enabled": enabled})
+}
+
+// DeleteDeviceConfig löscht die Konfiguration eines Geräts und nimmt es außer Betrieb
+func (s *DeviceService) DeleteDeviceConfig(id string) (ChangeResult, error) {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	path, _, err := s.findConfigFile(id)
+	if err != nil {
+		return ChangeResult{}, err
+	}
+
+	return s.applyChange("delete_device "+id, configChange{path: nil})
+}
+
+// RollbackConfig stellt den neuesten gesicherten Konfigurationsstand wieder her, der ohne
+// Fehler geladen war, und wendet ihn an. Der Stand wird danach verworfen, ein weiterer Aufruf
+// geht also einen Schritt weiter zurück. Der aktuelle Stand wird vorher gesichert (Anlass
+// rollback_config), damit ein versehentlicher Rollback rückgängig gemacht werden kann.
+func (s *DeviceService) RollbackConfig() (ConfigBackup, ReloadResult, error) {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	backups, err := s.ConfigBackups()
+	if err != nil {
+		return ConfigBackup{}, ReloadResult{}, err
+	}
+
+	backup, found := rollbackTarget(backups)
+	if !found {
+		return ConfigBackup{}, ReloadResult{}, fmt.Errorf("kein fehlerfrei geladener Konfigurationsstand vorhanden")
+	}
+
+	if _, err := s.snapshotConfigs(rollbackReason); err != nil {
+		return ConfigBackup{}, ReloadResult{}, fmt.Errorf("fehler beim Sichern der Konfiguration, Rollback nicht ausgeführt: %w", err)
+	}
+
+	if err := s.restoreBackup(backup); err != nil {
+		// Teilweise wiederhergestellte Dateien trotzdem anwenden, damit Registry und Dateien übereinstimmen
+		return backup, s.reloadLocked(), err
+	}
+	if err := s.removeBackup(backup); err != nil {
+		return backup, s.reloadLocked(), fmt.Errorf("konfigurationsstand %s wiederhergestellt, aber nicht entfernt: %w", backup.Version, err)
+	}
+
+	return backup, s.reloadLocked(), nil
+}
+
+// updateDeviceConfig wendet einen Patch auf die Konfiguration eines Geräts an, reloadMutex muss gehalten werden
+func (s *DeviceService) updateDeviceConfig(id string, patch map[string]interface{}) (ChangeResult, error) {
+	path, current, err := s.findConfigFile(id)
+	if err != nil {
+		return ChangeResult{}, err
+	}
+
+	if value, exists := patch["id"]; exists && value != id {
+		return ChangeResult{}, fmt.Errorf("die ID von Gerät '%s' kann nicht geändert werden", id)
+	}
+
+	updated, err := patchConfig(current, patch)
+	if err != nil {
+		return ChangeResult{}, err
+	}
+
+	// Bei einem Wechsel des Gerätetyps in das Verzeichnis des neuen Typs verschieben
+	newPath, err := s.configFilePath(updated)
+	if err != nil {
+		return ChangeResult{}, err
+	}
+
+	change := configChange{newPath: &updated}
+	if newPath != path {
+		change[path] = nil
+	}
+
+	return s.applyChange("update_device "+id, change)
+}
+
+// applyChange prüft eine Änderung, sichert den aktuellen Stand, schreibt die Dateien atomar
+// und wendet die Änderung über einen Reload an. Abgelehnt wird eine Änderung nur wegen Fehlern,
+// die sie selbst verursacht; bereits vorhandene Fehler anderer Dateien blockieren sie nicht.
+// Lassen sich die Dateien nicht schreiben oder die geänderten Geräte nicht in Betrieb nehmen,
+// wird der gesicherte Stand wiederhergestellt. reloadMutex muss gehalten werden.
+func (s *DeviceService) applyChange(reason string, change configChange) (ChangeResult, error) {
+	current, err := s.readStoredConfigs()
+	if err != nil {
+		return ChangeResult{}, err
+	}
+
+	candidate := make(map[string]types.DeviceConfig, len(current)+len(change))
+	for path, config := range current {
+		candidate[path] = config
+	}
+	for path, config := range change {
+		if config == nil {
+			delete(candidate, path)
+			continue
+		}
+		candidate[path] = *config
+	}
+
+	issues := s.newIssues(current, candidate)
+	var errs, warnings []validation.Issue
+	for _, issue := range issues {
+		if issue.Severity == validation.SeverityError {
+			errs = append(errs, issue)
+		} else {
+			warnings = append(warnings, issue)
+		}
+	}
+	if len(errs) > 0 {
+		return ChangeResult{}, &ValidationError{Issues: errs}
+	}
+
+	backup, err := s.snapshotConfigs(reason)
+	if err != nil {
+		return ChangeResult{}, fmt.Errorf("fehler beim Sichern der Konfiguration, Änderung nicht ausgeführt: %w", err)
+	}
+
+	result := ChangeResult{Backup: backup, Warnings: warnings}
+
+	paths := make([]string, 0, len(change))
+	for path := range change {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if config := change[path]; config != nil {
+			err = device.SaveDeviceConfig(config, path)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			return s.revertChange(result, fmt.Errorf("fehler beim Schreiben von %s: %w", path, err))
+		}
+	}
+
+	result.Reload = s.reloadLocked()
+
+	var ids []string
+	for _, config := range change {
+		if config != nil {
+			ids = append(ids, config.ID)
+		}
+	}
+	if failed := result.Reload.failed(ids); len(failed) > 0 {
+		return s.revertChange(result, fmt.Errorf("geräte %s konnten nicht in Betrieb genommen werden: %v", strings.Join(failed, ", "), result.Reload.Errors))
+	}
+
+	return result, nil
+}
+
+// revertChange stellt den vor einer fehlgeschlagenen Änderung gesicherten Stand wieder her
+// und wendet ihn an. Der Stand wird danach verworfen, da er wieder der aktuelle ist.
+// reloadMutex muss gehalten werden.
+func (s *DeviceService) revertChange(result ChangeResult, cause error) (ChangeResult, error) {
+	if err := s.restoreBackup(result.Backup); err != nil {
+		// Teilweise wiederhergestellte Dateien trotzdem anwenden, damit Registry und Dateien übereinstimmen
+		result.Reload = s.reloadLocked()
+		return result, fmt.Errorf("%v; Wiederherstellen von %s fehlgeschlagen: %w", cause, result.Backup.Version, err)
+	}
+
+	result.Reload = s.reloadLocked()
+	if err := s.removeBackup(result.Backup); err != nil {
+		return result, fmt.Errorf("%v; Stand %s wiederhergestellt, aber nicht entfernt: %w", cause, result.Backup.Version, err)
+	}
+	return result, fmt.Errorf("%v; Änderung zurückgenommen", cause)
+}
+
+// newIssues gibt die Befunde der geänderten Konfiguration zurück, die im aktuellen Stand noch nicht bestehen
+func (s *DeviceService) newIssues(current, candidate map[string]types.DeviceConfig) []validation.Issue {
+	validator := validation.NewValidator(s.Catalog())
+
+	existing := make(map[string]bool)
+	for _, issue := range validator.ValidateDevices(current, s.profiles).Issues {
+		existing[issue.String()] = true
+	}
+
+	var issues []validation.Issue
+	for _, issue := range validator.ValidateDevices(candidate, s.profiles).Issues {
+		if !existing[issue.String()] {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// readStoredConfigs liest alle Konfigurationsdateien so, wie sie gespeichert sind.
+// Nicht lesbare Dateien werden übersprungen, sie werden beim Reload gemeldet.
+func (s *DeviceService) readStoredConfigs() (map[string]types.DeviceConfig, error) {
+	configs := make(map[string]types.DeviceConfig)
+
+	for _, dir := range s.sensorDirs() {
+		paths, err := listConfigFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			config, err := readConfigFile(path)
+			if err != nil {
+				continue
+			}
+			configs[path] = *config
+		}
+	}
+
+	return configs, nil
+}
+
+// findConfigFile sucht die Konfigurationsdatei eines Geräts
+func (s *DeviceService) findConfigFile(id string) (string, types.DeviceConfig, error) {
+	configs, err := s.readStoredConfigs()
+	if err != nil {
+		return "", types.DeviceConfig{}, err
+	}
+
+	paths := make([]string, 0, len(configs))
+	for path := range configs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Bei doppelten IDs gilt wie beim Laden die Datei mit dem alphabetisch ersten Pfad
+	for _, path := range paths {
+		if configs[path].ID == id {
+			return path, configs[path], nil
+		}
+	}
+
+	return "", types.DeviceConfig{}, fmt.Errorf("keine Konfiguration für Gerät '%s' gefunden", id)
+}
+
+// configFilePath gibt den Dateipfad einer Konfiguration im Verzeichnis ihres Gerätetyps zurück
+func (s *DeviceService) configFilePath(config types.DeviceConfig) (string, error) {
+	resolved, err := s.profiles.Resolve(config)
+	if err != nil {
+		return "", err
+	}
+	if resolved.Type == "" {
+		return "", fmt.Errorf("kein Typ in der Konfiguration angegeben")
+	}
+
+	descriptor, err := s.Catalog().Describe(resolved.Type)
+	if err != nil {
+		return "", err
+	}
+	if descriptor.ConfigDir == "" {
+		return "", fmt.Errorf("gerätetyp '%s' hat kein Konfigurationsverzeichnis", resolved.Type)
+	}
+
+	return filepath.Join(s.configPath, filepath.FromSlash(descriptor.ConfigDir), config.ID+".json"), nil
+}
+
+// patchConfig wendet einen JSON Merge Patch auf eine Gerätekonfiguration an
+func patchConfig(config types.DeviceConfig, patch map[string]interface{}) (types.DeviceConfig, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return config, err
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return config, err
+	}
+
+	data, err = json.Marshal(device.MergeOverrides(document, patch))
+	if err != nil {
+		return config, fmt.Errorf("ungültige Änderung: %w", err)
+	}
+
+	var updated types.DeviceConfig
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return config, fmt.Errorf("ungültige Änderung: %w", err)
+	}
+	return updated, nil
+}
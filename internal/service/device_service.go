@@ -2,114 +2,498 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
+	"owipex_reader/internal/device"
 	"owipex_reader/internal/device/creator"
+	"owipex_reader/internal/event"
 	"owipex_reader/internal/types"
 )
 
+// identityTimeout begrenzt die Dauer der Identitätsprüfung je Gerät
+const identityTimeout = 10 * time.Second
+
+// protocolProvider wird von Geräten implementiert, die ihren Protokoll-Handler bereitstellen
+type protocolProvider interface {
+	GetProtocol() types.ProtocolHandler
+}
+
 // DeviceService verwaltet die Erstellung und Verwaltung von Geräten
 type DeviceService struct {
-	sensorRegistry *creator.SensorRegistry
+	deviceFactory  *creator.DeviceFactory
+	deviceRegistry *device.Registry
+	eventBus       *event.Bus
 	configPath     string
+	profiles       *device.ProfileLibrary
+	identityChecks []device.IdentityCheck
+	identityMutex  sync.RWMutex
+	logger         *log.Logger
+
+	// Zustand für den Hot-Reload der Konfiguration
+	configs        map[string]types.DeviceConfig // Geräte-ID -> aktive Konfiguration
+	createFailures map[string]createFailure      // Geräte-ID -> nicht erstellbare Konfiguration
+	fileConfigs    map[string]types.DeviceConfig // Dateipfad -> zuletzt gültige Konfiguration
+	fileErrors     map[string]string             // Dateipfad -> zuletzt gemeldeter Fehler
+	profileErrors  map[string]string             // Profildatei -> zuletzt gemeldeter Fehler
+	reloadMutex    sync.Mutex
+	watchStop      chan struct{}
+	watchWg        sync.WaitGroup
+
+	// cleanState ist gesetzt, wenn der aktuelle Stand ohne Fehler geladen wurde. Nur solche
+	// Stände sind Ziel eines Rollbacks.
+	cleanState bool
 }
 
 // NewDeviceService erstellt einen neuen DeviceService
 func NewDeviceService(configPath string) *DeviceService {
-	registry := creator.NewSensorRegistry()
+	bus := event.NewBus()
 
 	return &DeviceService{
-		sensorRegistry: registry,
+		deviceFactory:  creator.NewDeviceFactory(),
+		deviceRegistry: device.NewRegistryWithBus(bus),
+		eventBus:       bus,
 		configPath:     configPath,
+		profiles:       device.NewProfileLibrary(),
+		logger:         log.New(os.Stdout, "[DeviceService] ", log.LstdFlags),
+		configs:        make(map[string]types.DeviceConfig),
+		createFailures: make(map[string]createFailure),
+		fileConfigs:    make(map[string]types.DeviceConfig),
+		fileErrors:     make(map[string]string),
+		profileErrors:  make(map[string]string),
 	}
 }
 
-// Initialize initialisiert den Service und registriert Sensor-Typen
+// Initialize initialisiert den Service und registriert Sensor- und Aktortypen
 func (s *DeviceService) Initialize() error {
 	// Sensor-Typen registrieren
-	creator.RegisterAllSensorTypes(s.sensorRegistry)
+	if err := creator.RegisterAllSensorTypes(s.deviceFactory); err != nil {
+		return err
+	}
 
-	return nil
+	// Aktortypen registrieren
+	return creator.RegisterAllActuatorTypes(s.deviceFactory)
 }
 
-// LoadSensorsFromConfig lädt Sensoren aus Konfigurationsdateien
+// LoadSensorsFromConfig lädt Sensoren und Aktoren aus Konfigurationsdateien und registriert sie
+// in der Geräteregistry. Zurückgegeben werden die registrierten Sensoren.
 func (s *DeviceService) LoadSensorsFromConfig() ([]types.Sensor, error) {
-	var sensorConfigs []types.DeviceConfig
-	var loadingErrors []error
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	// Konfigurationsdateien aus allen Verzeichnissen laden
+	files, loadingErrors := s.scanConfigFiles()
+	sensorConfigs, errs := collectConfigs(files)
+	loadingErrors = append(loadingErrors, errs...)
 
-	// Verzeichnisse für verschiedene Sensortypen
-	sensorDirs := []string{
-		filepath.Join(s.configPath, "sensors", "ph"),
-		filepath.Join(s.configPath, "sensors", "flow"),
-		filepath.Join(s.configPath, "sensors", "radar"),
-		filepath.Join(s.configPath, "sensors", "turbidity"),
+	// Geräte aus Konfigurationen erstellen
+	devices, errs := s.deviceFactory.CreateDevices(sensorConfigs)
+	for _, err := range errs {
+		loadingErrors = append(loadingErrors, err)
 	}
 
-	// Konfigurationsdateien aus allen Verzeichnissen laden
-	for _, dir := range sensorDirs {
-		configs, err := loadConfigFilesFromDir(dir)
-		if err != nil {
-			loadingErrors = append(loadingErrors, fmt.Errorf("fehler beim Laden aus %s: %w", dir, err))
+	// Identität der angeschlossenen Geräte prüfen
+	loadingErrors = append(loadingErrors, s.verifySensorIdentities(devices, sensorConfigs)...)
+
+	// Geräte in der Geräteregistry registrieren
+	var registered []types.Sensor
+	for _, dev := range devices {
+		if err := s.deviceRegistry.AddDevice(dev); err != nil {
+			loadingErrors = append(loadingErrors, err)
+			dev.Close()
 			continue
 		}
-		sensorConfigs = append(sensorConfigs, configs...)
+		s.applyIdentityCheck(dev.ID())
+		if sensor, ok := dev.(types.Sensor); ok {
+			registered = append(registered, sensor)
+		}
 	}
 
-	// Sensoren aus Konfigurationen erstellen
-	sensors, errs := s.sensorRegistry.CreateSensors(sensorConfigs)
-	for _, err := range errs {
-		loadingErrors = append(loadingErrors, err)
+	// Geladenen Stand für den Hot-Reload merken. Nicht erstellte Geräte versucht der Reload erneut.
+	now := time.Now()
+	for _, config := range sensorConfigs {
+		if _, err := s.deviceRegistry.GetDevice(config.ID); err != nil {
+			s.recordCreateFailure(config, createFailure{}, false, now)
+			continue
+		}
+		s.configs[config.ID] = config
 	}
+	s.cleanState = len(loadingErrors) == 0 && len(s.fileErrors) == 0 && len(s.profileErrors) == 0 && len(s.createFailures) == 0
 
 	// Wenn Fehler aufgetreten sind, diese zusammenfassen
 	if len(loadingErrors) > 0 {
-		fmt.Println("Fehler beim Laden der Sensor-Konfigurationen:")
+		s.logger.Println("Fehler beim Laden der Sensor-Konfigurationen:")
 		for _, err := range loadingErrors {
-			fmt.Printf("  - %v\n", err)
+			s.logger.Printf("  - %v", err)
 		}
 	}
 
-	return sensors, nil
+	return registered, nil
+}
+
+// Registry gibt die Geräteregistry mit allen aktiven Geräten zurück
+func (s *DeviceService) Registry() *device.Registry {
+	return s.deviceRegistry
+}
+
+// EventBus gibt den gemeinsamen Event-Bus für Geräte-, Mess- und Verbindungsereignisse zurück
+func (s *DeviceService) EventBus() *event.Bus {
+	return s.eventBus
+}
+
+// ActiveConfig gibt die Konfiguration zurück, mit der ein Gerät zuletzt geladen wurde
+func (s *DeviceService) ActiveConfig(id string) (types.DeviceConfig, bool) {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	config, exists := s.configs[id]
+	return config, exists
+}
+
+// Catalog gibt den Gerätekatalog mit den Beschreibungen aller registrierten Gerätetypen zurück
+func (s *DeviceService) Catalog() *creator.Catalog {
+	return s.deviceFactory.Catalog()
+}
+
+// Profiles gibt die Bibliothek der Geräteprofile zurück
+func (s *DeviceService) Profiles() *device.ProfileLibrary {
+	return s.profiles
+}
+
+// sensorDirs gibt die Konfigurationsverzeichnisse der registrierten Gerätetypen zurück
+func (s *DeviceService) sensorDirs() []string {
+	var dirs []string
+	for _, dir := range s.deviceFactory.Catalog().ConfigDirs() {
+		dirs = append(dirs, filepath.Join(s.configPath, filepath.FromSlash(dir)))
+	}
+	return dirs
+}
+
+// IdentityChecks gibt die Ergebnisse der Identitätsprüfung beim Laden zurück
+func (s *DeviceService) IdentityChecks() []device.IdentityCheck {
+	s.identityMutex.RLock()
+	defer s.identityMutex.RUnlock()
+
+	checks := make([]device.IdentityCheck, len(s.identityChecks))
+	copy(checks, s.identityChecks)
+	return checks
 }
 
-// loadConfigFilesFromDir lädt alle JSON-Konfigurationsdateien aus einem Verzeichnis
-func loadConfigFilesFromDir(dirPath string) ([]types.DeviceConfig, error) {
+// verifySensorIdentities liest die Identität aller Sensoren, für die Hersteller, Modell,
+// Firmware oder eine Geräteidentifikation konfiguriert ist. Das Ergebnis übernimmt
+// applyIdentityCheck nach dem Registrieren in den Verbindungszustand. Kann die Identität eines
+// Geräts mit abweichender Hardware nicht gelesen werden, bleibt die Abweichung bestehen.
+func (s *DeviceService) verifySensorIdentities(devices []types.Device, configs []types.DeviceConfig) []error {
+	configByID := make(map[string]types.DeviceConfig, len(configs))
+	for _, config := range configs {
+		configByID[config.ID] = config
+	}
+
+	var checks []device.IdentityCheck
+	var errs []error
+
+	for _, dev := range devices {
+		config, ok := configByID[dev.ID()]
+		if !ok || !identificationRequested(config) {
+			continue
+		}
+
+		provider, ok := dev.(protocolProvider)
+		if !ok {
+			continue
+		}
+		identifier, ok := provider.GetProtocol().(types.DeviceIdentifier)
+		if !ok {
+			continue
+		}
+
+		check := s.checkIdentity(config, identifier)
+		if check.Mismatch {
+			// Falsche Hardware liefert unbrauchbare Messwerte, Gerät wird nicht angesprochen
+			errs = append(errs, fmt.Errorf("gerät %s wird nicht angesprochen: %w", dev.ID(), check.Err))
+		} else if check.Err != nil {
+			errs = append(errs, check.Err)
+		}
+
+		checks = append(checks, check)
+	}
+
+	s.storeIdentityChecks(checks)
+
+	return errs
+}
+
+// checkIdentity liest die Identität eines Geräts. Schlägt das Lesen bei einem Gerät fehl, dessen
+// letzte Prüfung eine Abweichung ergab, bleibt die Abweichung bestehen: Nur eine erfolgreiche
+// Prüfung hebt sie auf.
+func (s *DeviceService) checkIdentity(config types.DeviceConfig, identifier types.DeviceIdentifier) device.IdentityCheck {
+	ctx, cancel := context.WithTimeout(context.Background(), identityTimeout)
+	check := device.CheckIdentity(ctx, config, identifier)
+	cancel()
+
+	if !check.Verified && !check.Mismatch {
+		if previous, ok := s.identityCheck(config.ID); ok && previous.Mismatch {
+			previous.Err = fmt.Errorf("%w (erneute Prüfung fehlgeschlagen: %v)", previous.Err, check.Err)
+			return previous
+		}
+	}
+	return check
+}
+
+// applyIdentityCheck übernimmt das letzte Prüfergebnis eines registrierten Geräts in dessen
+// Verbindungszustand: Eine Abweichung versetzt es in den Zustand identity_mismatch, eine
+// bestätigte Identität hebt ihn auf. Lesefehler ändern den Zustand nicht.
+func (s *DeviceService) applyIdentityCheck(id string) {
+	check, ok := s.identityCheck(id)
+	if !ok {
+		return
+	}
+
+	if check.Mismatch {
+		s.deviceRegistry.SetIdentityMismatch(id, check.Err)
+	} else if check.Verified {
+		s.deviceRegistry.SetIdentityMismatch(id, nil)
+	}
+}
+
+// RecheckIdentities prüft die Identität aller Geräte im Zustand identity_mismatch erneut und
+// gibt die neuen Prüfergebnisse zurück. Passt die Hardware inzwischen, wird das Gerät wieder
+// angesprochen.
+func (s *DeviceService) RecheckIdentities() []device.IdentityCheck {
+	s.reloadMutex.Lock()
+	defer s.reloadMutex.Unlock()
+
+	var checks []device.IdentityCheck
+	for _, status := range s.deviceRegistry.GetAllConnectivity() {
+		if status.State != device.StateIdentityMismatch {
+			continue
+		}
+		config, ok := s.configs[status.DeviceID]
+		if !ok {
+			continue
+		}
+		dev, err := s.deviceRegistry.GetDevice(status.DeviceID)
+		if err != nil {
+			continue
+		}
+		provider, ok := device.Unwrap(dev).(protocolProvider)
+		if !ok {
+			continue
+		}
+		identifier, ok := provider.GetProtocol().(types.DeviceIdentifier)
+		if !ok {
+			continue
+		}
+
+		check := s.checkIdentity(config, identifier)
+		s.storeIdentityChecks([]device.IdentityCheck{check})
+		s.applyIdentityCheck(check.DeviceID)
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// identityCheck gibt das letzte Prüfergebnis eines Geräts zurück
+func (s *DeviceService) identityCheck(id string) (device.IdentityCheck, bool) {
+	s.identityMutex.RLock()
+	defer s.identityMutex.RUnlock()
+
+	for _, check := range s.identityChecks {
+		if check.DeviceID == id {
+			return check, true
+		}
+	}
+	return device.IdentityCheck{}, false
+}
+
+// storeIdentityChecks übernimmt neue Prüfergebnisse und ersetzt ältere Ergebnisse desselben Geräts
+func (s *DeviceService) storeIdentityChecks(checks []device.IdentityCheck) {
+	s.identityMutex.Lock()
+	defer s.identityMutex.Unlock()
+
+	for _, check := range checks {
+		replaced := false
+		for i := range s.identityChecks {
+			if s.identityChecks[i].DeviceID == check.DeviceID {
+				s.identityChecks[i] = check
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.identityChecks = append(s.identityChecks, check)
+		}
+	}
+}
+
+// identificationRequested prüft, ob für ein Gerät eine Identitätsprüfung konfiguriert ist.
+// Die Methode "none" schaltet sie ab, auch wenn Hersteller oder Modell (z.B. aus einem
+// Geräteprofil) angegeben sind.
+func identificationRequested(config types.DeviceConfig) bool {
+	if modbusConfig, ok := config.Metadata["modbus"].(map[string]interface{}); ok {
+		if identification, ok := modbusConfig["identification"].(map[string]interface{}); ok {
+			if method, _ := identification["method"].(string); method == "none" {
+				return false
+			}
+			return true
+		}
+	}
+
+	return config.Manufacturer != "" || config.Model != "" || config.Firmware != ""
+}
+
+// scanConfigFiles lädt die Geräteprofile und alle JSON-Konfigurationsdateien aus den
+// Sensorverzeichnissen und führt Konfigurationen mit ihrem Profil zusammen.
+// Ist eine Datei ungültig, wird die zuletzt gültige Konfiguration dieser Datei weiterverwendet,
+// damit ein fehlerhafter Schreibvorgang das laufende Gerät nicht stört.
+func (s *DeviceService) scanConfigFiles() (map[string]types.DeviceConfig, []error) {
+	files := make(map[string]types.DeviceConfig)
+	errs := s.loadProfiles()
+
+	for _, dir := range s.sensorDirs() {
+		paths, err := listConfigFiles(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fehler beim Laden aus %s: %w", dir, err))
+			continue
+		}
+
+		for _, path := range paths {
+			config, err := loadConfigFile(path, s.profiles)
+			if err != nil {
+				if previous, ok := s.fileConfigs[path]; ok {
+					files[path] = previous
+				}
+				if s.fileErrors[path] != err.Error() {
+					s.fileErrors[path] = err.Error()
+					errs = append(errs, fmt.Errorf("konfiguration %s abgelehnt: %w", path, err))
+				}
+				continue
+			}
+
+			delete(s.fileErrors, path)
+			files[path] = *config
+		}
+	}
+
+	s.fileConfigs = files
+	return files, errs
+}
+
+// loadProfiles lädt die Geräteprofile neu. Fehler werden wie bei Konfigurationsdateien
+// nur gemeldet, wenn sie sich seit dem letzten Durchlauf geändert haben.
+func (s *DeviceService) loadProfiles() []error {
+	var errs []error
+	failed := make(map[string]bool)
+
+	for _, err := range s.profiles.Load(filepath.Join(s.configPath, device.ProfileDir)) {
+		var profileErr *device.ProfileError
+		if !errors.As(err, &profileErr) {
+			errs = append(errs, err)
+			continue
+		}
+
+		failed[profileErr.Path] = true
+		if s.profileErrors[profileErr.Path] != err.Error() {
+			s.profileErrors[profileErr.Path] = err.Error()
+			errs = append(errs, err)
+		}
+	}
+
+	for path := range s.profileErrors {
+		if !failed[path] {
+			delete(s.profileErrors, path)
+		}
+	}
+
+	return errs
+}
+
+// collectConfigs fasst die Konfigurationen aller Dateien zusammen. Doppelte IDs werden
+// abgelehnt, es gilt die Datei mit dem alphabetisch ersten Pfad.
+func collectConfigs(files map[string]types.DeviceConfig) ([]types.DeviceConfig, []error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
 	var configs []types.DeviceConfig
+	var errs []error
+	seen := make(map[string]string)
+
+	for _, path := range paths {
+		config := files[path]
+		if other, exists := seen[config.ID]; exists {
+			errs = append(errs, fmt.Errorf("konfiguration %s abgelehnt: ID %s bereits in %s verwendet", path, config.ID, other))
+			continue
+		}
+		seen[config.ID] = path
+		configs = append(configs, config)
+	}
+
+	return configs, errs
+}
+
+// listConfigFiles gibt die Pfade aller JSON-Konfigurationsdateien eines Verzeichnisses zurück
+func listConfigFiles(dirPath string) ([]string, error) {
+	var paths []string
 
 	// Verzeichnis lesen
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		// Wenn das Verzeichnis nicht existiert, ist das kein kritischer Fehler
 		if os.IsNotExist(err) {
-			return configs, nil
+			return paths, nil
 		}
 		return nil, fmt.Errorf("fehler beim Lesen des Verzeichnisses %s: %w", dirPath, err)
 	}
 
-	// Alle JSON-Dateien verarbeiten
+	// Alle JSON-Dateien sammeln
 	for _, entry := range entries {
 		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
 			continue
 		}
+		paths = append(paths, filepath.Join(dirPath, entry.Name()))
+	}
 
-		filePath := filepath.Join(dirPath, entry.Name())
-		config, err := loadConfigFile(filePath)
-		if err != nil {
-			fmt.Printf("Warnung: Fehler beim Laden von %s: %v\n", filePath, err)
-			continue
-		}
+	return paths, nil
+}
+
+// loadConfigFile lädt eine einzelne Konfigurationsdatei und führt sie mit ihrem Profil zusammen
+func loadConfigFile(filePath string, profiles *device.ProfileLibrary) (*types.DeviceConfig, error) {
+	config, err := readConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-		configs = append(configs, *config)
+	// Profil einbeziehen
+	resolved, err := profiles.Resolve(*config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Konfiguration validieren
+	if resolved.ID == "" {
+		return nil, fmt.Errorf("keine ID in der Konfiguration angegeben")
+	}
+	if resolved.Type == "" {
+		return nil, fmt.Errorf("kein Typ in der Konfiguration angegeben")
 	}
 
-	return configs, nil
+	return &resolved, nil
 }
 
-// loadConfigFile lädt eine einzelne Konfigurationsdatei
-func loadConfigFile(filePath string) (*types.DeviceConfig, error) {
+// readConfigFile liest eine Konfigurationsdatei so, wie sie gespeichert ist (ohne Profil)
+func readConfigFile(filePath string) (*types.DeviceConfig, error) {
 	// Datei öffnen
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -124,13 +508,5 @@ func loadConfigFile(filePath string) (*types.DeviceConfig, error) {
 		return nil, fmt.Errorf("fehler beim Dekodieren der Konfiguration: %w", err)
 	}
 
-	// Konfiguration validieren
-	if config.ID == "" {
-		return nil, fmt.Errorf("keine ID in der Konfiguration angegeben")
-	}
-	if config.Type == "" {
-		return nil, fmt.Errorf("kein Typ in der Konfiguration angegeben")
-	}
-
 	return &config, nil
 }
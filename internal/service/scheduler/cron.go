@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// macros sind die Kurzformen häufiger Cron-Ausdrücke
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field beschreibt ein Feld eines Cron-Ausdrucks
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "Minute", min: 0, max: 59}
+	hourField   = field{name: "Stunde", min: 0, max: 23}
+	domField    = field{name: "Tag", min: 1, max: 31}
+	monthField  = field{name: "Monat", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "Wochentag", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Cron ist ein geprüfter Cron-Ausdruck mit fünf Feldern (Minute, Stunde, Tag, Monat, Wochentag).
+// Sind Tag und Wochentag eingeschränkt, genügt wie bei cron(8) einer der beiden. Anders als bei
+// cron(8) gilt auch ein Schritt über alle Werte ("*/2") als Einschränkung: "0 0 */2 * mon"
+// trifft an ungeraden Tagen und an jedem Montag zu.
+type Cron struct {
+	expression string
+
+	minute, hour, dom, month, dow uint64
+
+	domRestricted, dowRestricted bool
+}
+
+// ParseCron prüft einen Cron-Ausdruck. Unterstützt werden Listen (1,15), Bereiche (1-5),
+// Schritte (*/10, 8-18/2), Monats- und Wochentagsnamen (jan, mon) sowie @hourly, @daily,
+// @weekly, @monthly und @yearly.
+func ParseCron(expression string) (*Cron, error) {
+	spec := strings.TrimSpace(expression)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron-Ausdruck %q benötigt 5 Felder (Minute Stunde Tag Monat Wochentag)", expression)
+	}
+
+	c := &Cron{expression: expression}
+	var err error
+	if c.minute, _, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if c.hour, _, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if c.dom, c.domRestricted, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if c.month, _, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if c.dow, c.dowRestricted, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+
+	// 7 ist wie 0 der Sonntag
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// parseField wandelt ein Feld in eine Bitmaske um. restricted ist false für "*".
+func parseField(text string, f field) (bits uint64, restricted bool, err error) {
+	for _, part := range strings.Split(text, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, false, fmt.Errorf("ungültige Schrittweite %q im Feld %s", part[i+1:], f.name)
+			}
+			part = part[:i]
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case part == "*":
+			if step == 1 {
+				// "*" schränkt nicht ein, "*/n" schon
+				bits |= rangeBits(low, high, 1)
+				continue
+			}
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			if low, err = parseValue(bounds[0], f); err != nil {
+				return 0, false, err
+			}
+			if high, err = parseValue(bounds[1], f); err != nil {
+				return 0, false, err
+			}
+			if low > high {
+				return 0, false, fmt.Errorf("ungültiger Bereich %q im Feld %s", part, f.name)
+			}
+		default:
+			if low, err = parseValue(part, f); err != nil {
+				return 0, false, err
+			}
+			high = low
+			if step > 1 {
+				high = f.max
+			}
+		}
+
+		bits |= rangeBits(low, high, step)
+		restricted = true
+	}
+	return bits, restricted, nil
+}
+
+// parseValue wandelt eine Zahl oder einen Namen eines Felds um
+func parseValue(text string, f field) (int, error) {
+	if value, ok := f.names[strings.ToLower(text)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("ungültiger Wert %q im Feld %s (erlaubt: %d-%d)", text, f.name, f.min, f.max)
+	}
+	return value, nil
+}
+
+// rangeBits setzt die Bits von low bis high in der angegebenen Schrittweite
+func rangeBits(low, high, step int) uint64 {
+	var bits uint64
+	for i := low; i <= high; i += step {
+		bits |= 1 << uint(i)
+	}
+	return bits
+}
+
+// String gibt den ursprünglichen Ausdruck zurück
+func (c *Cron) String() string {
+	return c.expression
+}
+
+// Next gibt den ersten Zeitpunkt nach after zurück, auf den der Ausdruck in der Zeitzone loc
+// zutrifft. Gibt es in den nächsten fünf Jahren keinen (z.B. 30. Februar), ist er null.
+// Geprüft werden Uhrzeiten, nicht vergangene Minuten: Beim Ende der Sommerzeit trifft eine
+// Uhrzeit in der doppelten Stunde nur einmal zu. Uhrzeiten, die beim Beginn der Sommerzeit
+// übersprungen werden, treffen nach der Umstellung zu (02:30 um 03:30).
+func (c *Cron) Next(after time.Time, loc *time.Location) time.Time {
+	start := after.In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	limit := day.Year() + 5
+
+	for ; day.Year() <= limit; day = day.AddDate(0, 0, 1) {
+		if c.month&(1<<uint(day.Month())) == 0 {
+			// Zum letzten Tag des Monats springen, die Schleife geht zum nächsten
+			day = time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.dayMatches(day) {
+			continue
+		}
+
+		// Frühesten Zeitpunkt des Tages suchen; bei der Umstellung auf Sommerzeit ist die
+		// Reihenfolge der Uhrzeiten nicht die der Zeitpunkte
+		var next time.Time
+		for hour := 0; hour < 24; hour++ {
+			if c.hour&(1<<uint(hour)) == 0 {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if c.minute&(1<<uint(minute)) == 0 {
+					continue
+				}
+				t := localTime(day, hour, minute, loc)
+				if t.After(after) && (next.IsZero() || t.Before(next)) {
+					next = t
+				}
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// localTime gibt den Zeitpunkt einer Uhrzeit an einem Datum in loc zurück. Gibt es die
+// Uhrzeit beim Ende der Sommerzeit zweimal, gilt die erste.
+func localTime(day time.Time, hour, minute int, loc *time.Location) time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+	if earlier := t.Add(-time.Hour); earlier.Day() == t.Day() && earlier.Hour() == hour && earlier.Minute() == minute {
+		return earlier
+	}
+	return t
+}
+
+// dayMatches prüft Tag und Wochentag eines Datums
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,310 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseCronErrors prüft, dass ungültige Ausdrücke abgelehnt werden
+func TestParseCronErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"* * * foo *",
+		"@reboot",
+	} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("ParseCron(%q): Fehler erwartet", expression)
+		}
+	}
+}
+
+// TestCronNext prüft die nächsten Zeitpunkte typischer Ausdrücke in UTC
+func TestCronNext(t *testing.T) {
+	// Donnerstag, 15. Januar 2026
+	after := time.Date(2026, 1, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expression string
+		want       []time.Time
+	}{
+		{
+			name:       "jede Minute",
+			expression: "* * * * *",
+			want: []time.Time{
+				time.Date(2026, 1, 15, 10, 8, 0, 0, time.UTC),
+				time.Date(2026, 1, 15, 10, 9, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "Schritt und Bereich",
+			expression: "*/20 8-18/4 * * *",
+			want: []time.Time{
+				time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 15, 12, 20, 0, 0, time.UTC),
+				time.Date(2026, 1, 15, 12, 40, 0, 0, time.UTC),
+				time.Date(2026, 1, 15, 16, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "Liste und Namen",
+			expression: "30 6 * jan,mar mon-wed",
+			want: []time.Time{
+				time.Date(2026, 1, 19, 6, 30, 0, 0, time.UTC),
+				time.Date(2026, 1, 20, 6, 30, 0, 0, time.UTC),
+				time.Date(2026, 1, 21, 6, 30, 0, 0, time.UTC),
+				time.Date(2026, 1, 26, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "Sonntag als 7",
+			expression: "0 12 * * 7",
+			want: []time.Time{
+				time.Date(2026, 1, 18, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 25, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "Monatsende überspringt kürzere Monate",
+			expression: "0 0 31 * *",
+			want: []time.Time{
+				time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "Schaltjahr",
+			expression: "0 0 29 2 *",
+			want: []time.Time{
+				time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2032, 2, 29, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertNext(t, tt.expression, after, time.UTC, tt.want)
+		})
+	}
+}
+
+// TestCronMacros prüft die Kurzformen gegen die ausgeschriebenen Ausdrücke
+func TestCronMacros(t *testing.T) {
+	after := time.Date(2026, 1, 15, 10, 7, 0, 0, time.UTC)
+
+	tests := []struct {
+		macro string
+		want  time.Time
+	}{
+		{"@hourly", time.Date(2026, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@midnight", time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@annually", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@DAILY", time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.macro, func(t *testing.T) {
+			assertNext(t, tt.macro, after, time.UTC, []time.Time{tt.want})
+		})
+	}
+}
+
+// TestCronDayOfMonthOrWeekday prüft die Verknüpfung von Tag und Wochentag: Sind beide
+// eingeschränkt, genügt einer; sonst entscheidet das eingeschränkte Feld
+func TestCronDayOfMonthOrWeekday(t *testing.T) {
+	// Donnerstag, 1. Januar 2026
+	after := time.Date(2025, 12, 31, 23, 59, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expression string
+		want       []time.Time
+	}{
+		{
+			name:       "Tag oder Wochentag",
+			expression: "0 0 13 * fri",
+			want: []time.Time{
+				time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "nur Tag",
+			expression: "0 0 13 * *",
+			want: []time.Time{
+				time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 2, 13, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "nur Wochentag",
+			expression: "0 0 * * fri",
+			want: []time.Time{
+				time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// Anders als bei cron(8) schränkt "*/n" ein und wird mit dem Wochentag verknüpft
+			name:       "Schritt im Tag gilt als Einschränkung",
+			expression: "0 0 */10 * mon",
+			want: []time.Time{
+				time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 21, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "Schritt im Wochentag gilt als Einschränkung",
+			expression: "0 0 15 * */3",
+			want: []time.Time{
+				time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 14, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertNext(t, tt.expression, after, time.UTC, tt.want)
+		})
+	}
+}
+
+// TestCronDaylightSaving prüft die Umstellung zwischen Sommer- und Winterzeit
+func TestCronDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Zeitzonendaten nicht verfügbar: %v", err)
+	}
+	cet := time.FixedZone("CET", 3600)
+	cest := time.FixedZone("CEST", 2*3600)
+
+	tests := []struct {
+		name       string
+		expression string
+		after      time.Time
+		want       []time.Time
+	}{
+		{
+			// 29.03.2026: 02:00 CET wird 03:00 CEST, 02:30 gibt es nicht
+			name:       "übersprungene Uhrzeit läuft nach der Umstellung",
+			expression: "30 2 * * *",
+			after:      time.Date(2026, 3, 29, 0, 0, 0, 0, cet),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 3, 30, 0, 0, cest),
+				time.Date(2026, 3, 30, 2, 30, 0, 0, cest),
+			},
+		},
+		{
+			name:       "Uhrzeit nach der Lücke läuft einmal",
+			expression: "30 2,3 * * *",
+			after:      time.Date(2026, 3, 29, 0, 0, 0, 0, cet),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 3, 30, 0, 0, cest),
+				time.Date(2026, 3, 30, 2, 30, 0, 0, cest),
+			},
+		},
+		{
+			name:       "stündlich in der Lücke",
+			expression: "0 * * * *",
+			after:      time.Date(2026, 3, 29, 1, 0, 0, 0, cet),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 3, 0, 0, 0, cest),
+				time.Date(2026, 3, 29, 4, 0, 0, 0, cest),
+			},
+		},
+		{
+			// 25.10.2026: 03:00 CEST wird 02:00 CET, 02:30 gibt es zweimal
+			name:       "doppelte Uhrzeit läuft einmal",
+			expression: "30 2 * * *",
+			after:      time.Date(2026, 10, 25, 0, 0, 0, 0, cest),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 2, 30, 0, 0, cest),
+				time.Date(2026, 10, 26, 2, 30, 0, 0, cet),
+			},
+		},
+		{
+			name:       "stündlich in der doppelten Stunde",
+			expression: "0 * * * *",
+			after:      time.Date(2026, 10, 25, 1, 0, 0, 0, cest),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 2, 0, 0, 0, cest),
+				time.Date(2026, 10, 25, 3, 0, 0, 0, cet),
+			},
+		},
+		{
+			// Nach einem Neustart in der zweiten 02:xx-Stunde nicht erneut ausführen
+			name:       "Start in der wiederholten Stunde",
+			expression: "30 2 * * *",
+			after:      time.Date(2026, 10, 25, 2, 10, 0, 0, cet),
+			want: []time.Time{
+				time.Date(2026, 10, 26, 2, 30, 0, 0, cet),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertNext(t, tt.expression, tt.after, berlin, tt.want)
+		})
+	}
+}
+
+// TestCronNextNever prüft, dass ein nie zutreffender Ausdruck den Nullzeitpunkt ergibt
+func TestCronNextNever(t *testing.T) {
+	cron, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	if next := cron.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.UTC); !next.IsZero() {
+		t.Errorf("Next = %v, Nullzeitpunkt erwartet", next)
+	}
+}
+
+// assertNext prüft die aufeinanderfolgenden Zeitpunkte eines Ausdrucks ab after
+func assertNext(t *testing.T, expression string, after time.Time, loc *time.Location, want []time.Time) {
+	t.Helper()
+
+	cron, err := ParseCron(expression)
+	if err != nil {
+		t.Fatalf("ParseCron(%q): %v", expression, err)
+	}
+
+	current := after
+	for i, expected := range want {
+		next := cron.Next(current, loc)
+		if !next.Equal(expected) {
+			t.Fatalf("%q: Zeitpunkt %d nach %v = %v, erwartet %v", expression, i+1, current, next, expected)
+		}
+		current = next
+	}
+}
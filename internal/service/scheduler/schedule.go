@@ -0,0 +1,180 @@
+// Package scheduler führt zeitgesteuerte Aktorbefehle aus, z.B. "Schlammpumpe alle 2 Stunden
+// für 5 Minuten" oder "pH-Sonde um 03:00 spülen". Die Befehle laufen über die Befehlsverwaltung
+// (Auslöser "schedule"), Sicherheitsverriegelungen gelten also auch für Zeitpläne.
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/rule"
+	"owipex_reader/internal/types"
+)
+
+// Schedule ist ein geprüfter Zeitplan
+type Schedule struct {
+	Config config.ScheduleConfig
+
+	Cron        *Cron
+	Location    *time.Location
+	Command     types.Command
+	StopCommand types.Command
+	Duration    time.Duration
+	Blackouts   []Blackout
+	MissedRun   string
+}
+
+// Blackout ist eine Sperrzeit: ein tägliches Zeitfenster in der Zeitzone Location oder ein
+// fester Zeitraum
+type Blackout struct {
+	Window   *rule.TimeWindow
+	Location *time.Location
+
+	Start time.Time
+	End   time.Time
+}
+
+// Contains prüft, ob ein Zeitpunkt in der Sperrzeit liegt
+func (b Blackout) Contains(t time.Time) bool {
+	if b.Window != nil {
+		return b.Window.Contains(t.In(b.Location))
+	}
+	return !t.Before(b.Start) && t.Before(b.End)
+}
+
+// String gibt die Sperrzeit lesbar aus
+func (b Blackout) String() string {
+	if b.Window != nil {
+		return b.Window.String()
+	}
+	return b.Start.Format(time.RFC3339) + " - " + b.End.Format(time.RFC3339)
+}
+
+// LoadLocation lädt eine IANA-Zeitzone, ohne Angabe die lokale Zeitzone
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unbekannte Zeitzone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// ParseBlackouts prüft Sperrzeiten. Tägliche Zeitfenster gelten in der Zeitzone loc.
+func ParseBlackouts(configs []config.ScheduleBlackout, loc *time.Location) ([]Blackout, error) {
+	var blackouts []Blackout
+	for i, cfg := range configs {
+		blackout, err := ParseBlackout(cfg, loc)
+		if err != nil {
+			return nil, fmt.Errorf("sperrzeit %d: %w", i, err)
+		}
+		blackouts = append(blackouts, blackout)
+	}
+	return blackouts, nil
+}
+
+// ParseBlackout prüft eine Sperrzeit. Ein tägliches Zeitfenster gilt in der Zeitzone loc.
+func ParseBlackout(cfg config.ScheduleBlackout, loc *time.Location) (Blackout, error) {
+	daily := cfg.From != "" || cfg.To != ""
+	fixed := cfg.Start != "" || cfg.End != ""
+
+	switch {
+	case daily && fixed:
+		return Blackout{}, fmt.Errorf("from/to und start/end können nicht kombiniert werden")
+	case daily:
+		window, err := rule.ParseTimeWindow(config.RuleTimeWindow{From: cfg.From, To: cfg.To, Days: cfg.Days})
+		if err != nil {
+			return Blackout{}, err
+		}
+		return Blackout{Window: window, Location: loc}, nil
+	case fixed:
+		start, err := time.Parse(time.RFC3339, cfg.Start)
+		if err != nil {
+			return Blackout{}, fmt.Errorf("start: ungültiger Zeitpunkt %q (Format RFC 3339, z.B. 2026-05-01T08:00:00+02:00)", cfg.Start)
+		}
+		end, err := time.Parse(time.RFC3339, cfg.End)
+		if err != nil {
+			return Blackout{}, fmt.Errorf("end: ungültiger Zeitpunkt %q (Format RFC 3339, z.B. 2026-05-01T18:00:00+02:00)", cfg.End)
+		}
+		if !end.After(start) {
+			return Blackout{}, fmt.Errorf("end muss nach start liegen")
+		}
+		return Blackout{Start: start, End: end}, nil
+	default:
+		return Blackout{}, fmt.Errorf("from/to oder start/end angeben")
+	}
+}
+
+// ParseSchedule prüft einen Zeitplan. Ohne eigene Zeitzone gilt defaultLocation.
+func ParseSchedule(cfg config.ScheduleConfig, defaultLocation *time.Location) (*Schedule, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("kein Name angegeben")
+	}
+	if cfg.Device == "" || cfg.Command == "" {
+		return nil, fmt.Errorf("zeitplan %s: device und command sind erforderlich", cfg.Name)
+	}
+	if cfg.DurationSeconds < 0 {
+		return nil, fmt.Errorf("zeitplan %s: duration_seconds darf nicht negativ sein", cfg.Name)
+	}
+
+	cron, err := ParseCron(cfg.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("zeitplan %s: %w", cfg.Name, err)
+	}
+
+	loc := defaultLocation
+	if cfg.Timezone != "" {
+		if loc, err = LoadLocation(cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("zeitplan %s: %w", cfg.Name, err)
+		}
+	}
+
+	blackouts, err := ParseBlackouts(cfg.Blackouts, loc)
+	if err != nil {
+		return nil, fmt.Errorf("zeitplan %s: %w", cfg.Name, err)
+	}
+
+	missedRun := strings.ToLower(cfg.MissedRun)
+	switch missedRun {
+	case "":
+		missedRun = config.ScheduleMissedSkip
+	case config.ScheduleMissedSkip, config.ScheduleMissedRunOnce:
+	default:
+		return nil, fmt.Errorf("zeitplan %s: unbekannte missed_run-Strategie %q (erlaubt: %s, %s)",
+			cfg.Name, cfg.MissedRun, config.ScheduleMissedSkip, config.ScheduleMissedRunOnce)
+	}
+
+	s := &Schedule{
+		Config:   cfg,
+		Cron:     cron,
+		Location: loc,
+		Command: types.Command{
+			Type:       types.CommandType(strings.ToUpper(cfg.Command)),
+			Value:      cfg.Value,
+			Parameters: cfg.Parameters,
+		},
+		StopCommand: types.Command{Type: types.CommandTypeSetState, Value: false},
+		Duration:    time.Duration(cfg.DurationSeconds) * time.Second,
+		Blackouts:   blackouts,
+		MissedRun:   missedRun,
+	}
+	if cfg.StopCommand != "" {
+		s.StopCommand = types.Command{
+			Type:       types.CommandType(strings.ToUpper(cfg.StopCommand)),
+			Value:      cfg.StopValue,
+			Parameters: cfg.StopParameters,
+		}
+	} else if cfg.StopValue != nil {
+		s.StopCommand.Value = cfg.StopValue
+	}
+	return s, nil
+}
+
+// Name gibt den Namen des Zeitplans zurück
+func (s *Schedule) Name() string {
+	return s.Config.Name
+}
@@ -0,0 +1,705 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"owipex_reader/internal/command"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/statefile"
+	"owipex_reader/internal/types"
+)
+
+const (
+	// AttributeKey ist das Shared Attribute mit den verwalteten Zeitplänen
+	AttributeKey = "schedules"
+
+	// SourceConfig kennzeichnet Zeitpläne aus app.json, SourceManaged die per RPC oder Shared
+	// Attribute angelegten
+	SourceConfig  = "config"
+	SourceManaged = "managed"
+
+	// tickInterval ist das Intervall, in dem fällige Zeitpläne gesucht werden
+	tickInterval = time.Second
+)
+
+// Status ist der Zustand eines Zeitplans
+type Status struct {
+	config.ScheduleConfig
+
+	Source     string     `json:"source"`
+	MissedRun  string     `json:"missed_run"`
+	Timezone   string     `json:"timezone"`
+	NextRun    *time.Time `json:"next_run,omitempty"`
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	LastResult string     `json:"last_result,omitempty"`
+	Running    bool       `json:"running"`
+	Pending    bool       `json:"pending"`
+	Blackout   string     `json:"blackout,omitempty"`
+
+	// Inactive ist der Grund, aus dem ein gespeicherter Zeitplan nicht eingeplant ist
+	Inactive string `json:"inactive,omitempty"`
+}
+
+// job ist der Laufzeitzustand eines Zeitplans
+type job struct {
+	schedule *Schedule
+	managed  bool
+
+	runState
+	next    time.Time
+	pending bool
+	running bool
+
+	// cancel beendet einen laufenden Lauf vorzeitig (Zeitplan gelöscht oder geändert)
+	cancel chan struct{}
+}
+
+// inactiveSchedule ist ein gespeicherter verwalteter Zeitplan, der nicht eingeplant werden kann,
+// z.B. weil sein Gerät beim Start nicht erstellt wurde. Er bleibt in der Zustandsdatei erhalten
+// und wird eingeplant, sobald er gültig ist.
+type inactiveSchedule struct {
+	config config.ScheduleConfig
+	runState
+	reason string
+}
+
+// Scheduler führt die Zeitpläne aus. Ein Lauf sendet den Befehl des Zeitplans über die
+// Befehlsverwaltung und nach duration_seconds den Stoppbefehl. Läuft ein Zeitplan noch, wenn
+// er erneut fällig wird, entfällt der neue Lauf.
+type Scheduler struct {
+	registry  *device.Registry
+	commands  *command.Manager
+	logger    *log.Logger
+	location  *time.Location
+	blackouts []Blackout
+	statePath string
+
+	mutex     sync.Mutex
+	jobs      []*job
+	inactive  []inactiveSchedule
+	attribute json.RawMessage
+	stopped   bool
+
+	// saveMutex hält die Reihenfolge der Schreibvorgänge in die Zustandsdatei ein
+	saveMutex sync.Mutex
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewScheduler erstellt den Zeitplaner aus der Konfiguration und lädt die verwalteten
+// Zeitpläne aus der Zustandsdatei. Ungültige Zeitpläne in app.json und eine nicht lesbare
+// Zustandsdatei führen zu einem Fehler. Verwaltete Zeitpläne, die sich nicht einplanen lassen
+// (z.B. Gerät noch nicht erstellt), bleiben inaktiv gespeichert und werden eingeplant, sobald
+// sie gültig sind.
+func NewScheduler(cfg config.SchedulerConfig, registry *device.Registry, commands *command.Manager) (*Scheduler, error) {
+	location, err := LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	blackouts, err := ParseBlackouts(cfg.Blackouts, location)
+	if err != nil {
+		return nil, fmt.Errorf("blackouts: %w", err)
+	}
+
+	s := &Scheduler{
+		registry:  registry,
+		commands:  commands,
+		logger:    log.New(os.Stdout, "[Scheduler] ", log.LstdFlags),
+		location:  location,
+		blackouts: blackouts,
+		statePath: cfg.StatePath,
+		stopChan:  make(chan struct{}),
+	}
+
+	var errs []string
+	names := make(map[string]bool)
+	for _, scheduleCfg := range cfg.Schedules {
+		schedule, err := ParseSchedule(scheduleCfg, location)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if names[schedule.Name()] {
+			errs = append(errs, fmt.Sprintf("zeitplan %s ist mehrfach definiert", schedule.Name()))
+			continue
+		}
+		names[schedule.Name()] = true
+		s.jobs = append(s.jobs, &job{schedule: schedule})
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("ungültige Zeitpläne: %s", strings.Join(errs, "; "))
+	}
+
+	st, err := loadState(cfg.StatePath)
+	if errors.Is(err, statefile.ErrCorrupt) {
+		s.logger.Printf("Verwaltete Zeitpläne und letzte Läufe verworfen: %v", err)
+	} else if err != nil {
+		// Ohne gelesenen Zustand würde der nächste Schreibvorgang die verwalteten Zeitpläne löschen
+		return nil, fmt.Errorf("fehler beim Laden des Zeitplanzustands: %w", err)
+	}
+	for _, managedCfg := range st.Managed {
+		schedule, err := s.parseManaged(managedCfg)
+		if err == nil && names[schedule.Name()] {
+			err = fmt.Errorf("zeitplan %s ist mehrfach definiert", schedule.Name())
+		}
+		if err != nil {
+			s.logger.Printf("Gespeicherter Zeitplan bleibt inaktiv: %v", err)
+			s.inactive = append(s.inactive, inactiveSchedule{config: managedCfg, runState: st.Runs[managedCfg.Name], reason: err.Error()})
+			continue
+		}
+		names[schedule.Name()] = true
+		s.jobs = append(s.jobs, &job{schedule: schedule, managed: true})
+	}
+	for _, j := range s.jobs {
+		j.runState = st.Runs[j.schedule.Name()]
+	}
+	s.attribute = st.Attribute
+
+	return s, nil
+}
+
+// parseManaged prüft einen verwalteten Zeitplan einschließlich des Geräts
+func (s *Scheduler) parseManaged(cfg config.ScheduleConfig) (*Schedule, error) {
+	schedule, err := ParseSchedule(cfg, s.location)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.registry.GetDevice(cfg.Device); err != nil {
+		return nil, fmt.Errorf("zeitplan %s: unbekanntes Gerät %s", cfg.Name, cfg.Device)
+	}
+	return schedule, nil
+}
+
+// Start plant die Zeitpläne ein und prüft sie zyklisch. Mit missed_run "run_once" wird ein
+// während des Stillstands verpasster Lauf nachgeholt.
+func (s *Scheduler) Start() error {
+	now := time.Now()
+
+	s.mutex.Lock()
+	for _, j := range s.jobs {
+		s.planLocked(j, now)
+	}
+	s.mutex.Unlock()
+	s.persist()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopChan:
+				return
+			case now := <-ticker.C:
+				s.Tick(now)
+			}
+		}
+	}()
+
+	s.logger.Printf("%d Zeitpläne eingeplant", len(s.jobs))
+	return nil
+}
+
+// Stop beendet den Zeitplaner. Laufende Läufe werden mit ihrem Stoppbefehl beendet, der
+// Zeitplaner muss daher vor der Befehlsverwaltung gestoppt werden.
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	s.stopped = true
+	s.mutex.Unlock()
+
+	close(s.stopChan)
+	s.wg.Wait()
+}
+
+// planLocked bestimmt den nächsten Lauf eines Zeitplans, der Aufrufer hält die Sperre
+func (s *Scheduler) planLocked(j *job, now time.Time) {
+	j.next = j.schedule.Cron.Next(now, j.schedule.Location)
+	if j.LastDue.IsZero() || j.schedule.Config.Disabled {
+		return
+	}
+
+	missed := j.schedule.Cron.Next(j.LastDue, j.schedule.Location)
+	if missed.IsZero() || !missed.Before(now) {
+		return
+	}
+	// Die verpassten Läufe sind damit behandelt, auch bei einem erneuten Neustart
+	j.LastDue = now
+	if j.schedule.MissedRun == config.ScheduleMissedRunOnce {
+		s.logger.Printf("Zeitplan %s: verpasster Lauf von %s wird nachgeholt", j.schedule.Name(), missed.Format(time.RFC3339))
+		j.pending = true
+	} else {
+		s.logger.Printf("Zeitplan %s: verpasster Lauf von %s entfällt", j.schedule.Name(), missed.Format(time.RFC3339))
+	}
+}
+
+// Tick plant gültig gewordene inaktive Zeitpläne ein und startet die fälligen Zeitpläne
+func (s *Scheduler) Tick(now time.Time) {
+	s.mutex.Lock()
+	changed := s.activateLocked(now)
+	for _, j := range s.jobs {
+		if j.schedule.Config.Disabled {
+			continue
+		}
+
+		due := !j.next.IsZero() && !now.Before(j.next)
+		if due {
+			j.LastDue = j.next
+			j.next = j.schedule.Cron.Next(now, j.schedule.Location)
+			changed = true
+		}
+		if !due && !j.pending {
+			continue
+		}
+
+		if blackout := s.blackoutLocked(j, now); blackout != nil {
+			if !due {
+				continue
+			}
+			if j.schedule.MissedRun == config.ScheduleMissedRunOnce {
+				if !j.pending {
+					s.logger.Printf("Zeitplan %s: Sperrzeit %s, Lauf wird danach nachgeholt", j.schedule.Name(), blackout)
+				}
+				j.pending = true
+			} else {
+				s.logger.Printf("Zeitplan %s: Sperrzeit %s, Lauf entfällt", j.schedule.Name(), blackout)
+				j.LastResult = "skipped: blackout " + blackout.String()
+			}
+			continue
+		}
+
+		j.pending = false
+		if j.running {
+			s.logger.Printf("Zeitplan %s läuft noch, Lauf entfällt", j.schedule.Name())
+			j.LastResult = "skipped: still running"
+			continue
+		}
+		s.startLocked(j, now)
+	}
+	s.mutex.Unlock()
+
+	if changed {
+		s.persist()
+	}
+}
+
+// activateLocked plant inaktive Zeitpläne ein, die inzwischen gültig sind (z.B. weil ihr Gerät
+// nachträglich erstellt wurde). Der Aufrufer hält die Sperre.
+func (s *Scheduler) activateLocked(now time.Time) bool {
+	changed := false
+	remaining := s.inactive[:0]
+	for _, in := range s.inactive {
+		schedule, err := s.parseManaged(in.config)
+		if err == nil {
+			if _, existing := s.findLocked(schedule.Name()); existing != nil {
+				err = fmt.Errorf("zeitplan %s ist mehrfach definiert", schedule.Name())
+			}
+		}
+		if err != nil {
+			in.reason = err.Error()
+			remaining = append(remaining, in)
+			continue
+		}
+
+		j := &job{schedule: schedule, managed: true, runState: in.runState}
+		s.planLocked(j, now)
+		s.jobs = append(s.jobs, j)
+		s.logger.Printf("Zeitplan %s ist jetzt eingeplant", schedule.Name())
+		changed = true
+	}
+	s.inactive = remaining
+	return changed
+}
+
+// removeInactiveLocked entfernt einen inaktiven Zeitplan, der Aufrufer hält die Sperre
+func (s *Scheduler) removeInactiveLocked(name string) bool {
+	for i, in := range s.inactive {
+		if in.config.Name == name {
+			s.inactive = append(s.inactive[:i], s.inactive[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// blackoutLocked gibt die aktive Sperrzeit eines Zeitplans zurück, der Aufrufer hält die Sperre
+func (s *Scheduler) blackoutLocked(j *job, now time.Time) *Blackout {
+	for i := range s.blackouts {
+		if s.blackouts[i].Contains(now) {
+			return &s.blackouts[i]
+		}
+	}
+	for i := range j.schedule.Blackouts {
+		if j.schedule.Blackouts[i].Contains(now) {
+			return &j.schedule.Blackouts[i]
+		}
+	}
+	return nil
+}
+
+// startLocked startet einen Lauf, der Aufrufer hält die Sperre
+func (s *Scheduler) startLocked(j *job, now time.Time) {
+	cancel := make(chan struct{})
+	j.running = true
+	j.LastRun = now
+	j.cancel = cancel
+
+	s.wg.Add(1)
+	go s.run(j, cancel)
+}
+
+// run führt einen Lauf aus: Befehl senden, Dauer abwarten und Stoppbefehl senden
+func (s *Scheduler) run(j *job, cancel chan struct{}) {
+	defer s.wg.Done()
+
+	schedule := j.schedule
+	s.logger.Printf("Zeitplan %s: %s an %s", schedule.Name(), schedule.Command.Type, schedule.Config.Device)
+	result := s.submit(schedule, schedule.Command)
+
+	if result == string(command.StatusSucceeded) && schedule.Duration > 0 {
+		timer := time.NewTimer(schedule.Duration)
+		select {
+		case <-timer.C:
+		case <-cancel:
+		case <-s.stopChan:
+		}
+		timer.Stop()
+
+		s.logger.Printf("Zeitplan %s: %s an %s (Ende)", schedule.Name(), schedule.StopCommand.Type, schedule.Config.Device)
+		if stopResult := s.submit(schedule, schedule.StopCommand); stopResult != string(command.StatusSucceeded) {
+			result = "stop " + stopResult
+		}
+	}
+
+	s.mutex.Lock()
+	j.running = false
+	j.cancel = nil
+	j.LastResult = result
+	s.mutex.Unlock()
+
+	s.persist()
+}
+
+// submit sendet einen Befehl über die Befehlsverwaltung und gibt das Ergebnis zurück
+func (s *Scheduler) submit(schedule *Schedule, cmd types.Command) string {
+	record := s.commands.Submit(command.Request{
+		DeviceID: schedule.Config.Device,
+		Command:  cmd,
+		Origin:   command.OriginSchedule,
+		Issuer:   schedule.Name(),
+	})
+	if !record.Succeeded() {
+		s.logger.Printf("Zeitplan %s: %s an %s fehlgeschlagen: %s: %s", schedule.Name(), cmd.Type, schedule.Config.Device, record.Status, record.Error)
+		return fmt.Sprintf("%s: %s", record.Status, record.Error)
+	}
+	return string(record.Status)
+}
+
+// cancelLocked beendet einen laufenden Lauf vorzeitig, der Aufrufer hält die Sperre
+func cancelLocked(j *job) {
+	if j.cancel != nil {
+		close(j.cancel)
+		j.cancel = nil
+	}
+}
+
+// persist schreibt die verwalteten Zeitpläne einschließlich der inaktiven und die Laufzeiten in
+// die Zustandsdatei
+func (s *Scheduler) persist() {
+	s.saveMutex.Lock()
+	defer s.saveMutex.Unlock()
+
+	s.mutex.Lock()
+	st := state{Attribute: s.attribute, Runs: make(map[string]runState, len(s.jobs)+len(s.inactive))}
+	for _, j := range s.jobs {
+		if j.managed {
+			st.Managed = append(st.Managed, j.schedule.Config)
+		}
+		st.Runs[j.schedule.Name()] = j.runState
+	}
+	for _, in := range s.inactive {
+		st.Managed = append(st.Managed, in.config)
+		if _, exists := st.Runs[in.config.Name]; !exists {
+			st.Runs[in.config.Name] = in.runState
+		}
+	}
+	s.mutex.Unlock()
+
+	if err := saveState(s.statePath, st); err != nil {
+		s.logger.Printf("Zeitplanzustand nicht gespeichert: %v", err)
+	}
+}
+
+// findLocked sucht einen Zeitplan, der Aufrufer hält die Sperre
+func (s *Scheduler) findLocked(name string) (int, *job) {
+	for i, j := range s.jobs {
+		if j.schedule.Name() == name {
+			return i, j
+		}
+	}
+	return -1, nil
+}
+
+// SetSchedule legt einen verwalteten Zeitplan an oder ersetzt ihn. Ein laufender Lauf des
+// alten Zeitplans wird mit dessen Stoppbefehl beendet.
+func (s *Scheduler) SetSchedule(cfg config.ScheduleConfig) error {
+	schedule, err := s.parseManaged(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	i, old := s.findLocked(schedule.Name())
+	if old != nil && !old.managed {
+		s.mutex.Unlock()
+		return fmt.Errorf("zeitplan %s ist in app.json festgelegt und kann nur dort geändert werden", schedule.Name())
+	}
+	j := s.replaceLocked(old, schedule, time.Now())
+	if old != nil {
+		s.jobs[i] = j
+	} else {
+		s.jobs = append(s.jobs, j)
+	}
+	s.removeInactiveLocked(schedule.Name())
+	s.mutex.Unlock()
+
+	s.logger.Printf("Zeitplan %s gespeichert (%s, %s an %s)", schedule.Name(), schedule.Cron, schedule.Command.Type, cfg.Device)
+	s.persist()
+	return nil
+}
+
+// replaceLocked erstellt den Laufzeitzustand eines verwalteten Zeitplans. Ein unveränderter
+// Zeitplan behält seinen Zustand, ein geänderter übernimmt die letzten Läufe. Der Aufrufer
+// hält die Sperre.
+func (s *Scheduler) replaceLocked(old *job, schedule *Schedule, now time.Time) *job {
+	if old != nil && reflect.DeepEqual(old.schedule.Config, schedule.Config) {
+		return old
+	}
+
+	j := &job{schedule: schedule, managed: true}
+	if old != nil {
+		cancelLocked(old)
+		j.runState = old.runState
+	}
+	j.next = schedule.Cron.Next(now, schedule.Location)
+	return j
+}
+
+// DeleteSchedule löscht einen verwalteten Zeitplan. Ein laufender Lauf wird mit dem
+// Stoppbefehl beendet.
+func (s *Scheduler) DeleteSchedule(name string) error {
+	s.mutex.Lock()
+	i, j := s.findLocked(name)
+	switch {
+	case j == nil && s.removeInactiveLocked(name):
+		s.mutex.Unlock()
+		s.logger.Printf("Inaktiver Zeitplan %s gelöscht", name)
+		s.persist()
+		return nil
+	case j == nil:
+		s.mutex.Unlock()
+		return fmt.Errorf("unbekannter Zeitplan: %s", name)
+	case !j.managed:
+		s.mutex.Unlock()
+		return fmt.Errorf("zeitplan %s ist in app.json festgelegt und kann nur dort gelöscht werden", name)
+	}
+	cancelLocked(j)
+	s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+	s.mutex.Unlock()
+
+	s.logger.Printf("Zeitplan %s gelöscht", name)
+	s.persist()
+	return nil
+}
+
+// RunSchedule startet einen Zeitplan sofort. Sperrzeiten gelten auch hier.
+func (s *Scheduler) RunSchedule(name string) error {
+	now := time.Now()
+
+	s.mutex.Lock()
+	_, j := s.findLocked(name)
+	var err error
+	switch {
+	case s.stopped:
+		err = fmt.Errorf("zeitplaner ist gestoppt")
+	case j == nil:
+		err = fmt.Errorf("unbekannter Zeitplan: %s", name)
+	case j.schedule.Config.Disabled:
+		err = fmt.Errorf("zeitplan %s ist deaktiviert", name)
+	case j.running:
+		err = fmt.Errorf("zeitplan %s läuft bereits", name)
+	default:
+		if blackout := s.blackoutLocked(j, now); blackout != nil {
+			err = fmt.Errorf("zeitplan %s: Sperrzeit %s aktiv", name, blackout)
+		} else {
+			j.pending = false
+			s.startLocked(j, now)
+		}
+	}
+	s.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+	s.persist()
+	return nil
+}
+
+// ApplyAttributes übernimmt das Shared Attribute "schedules" (Liste von Zeitplänen oder
+// JSON-Text). Es ersetzt alle verwalteten Zeitpläne, aber nur, wenn sich sein Wert seit der
+// letzten Übernahme geändert hat. So heben die beim Neuverbinden erneut gemeldeten Attribute
+// keine per RPC vorgenommenen Änderungen auf.
+func (s *Scheduler) ApplyAttributes(attributes map[string]interface{}) {
+	value, exists := attributes[AttributeKey]
+	if !exists {
+		return
+	}
+
+	var raw []byte
+	if text, ok := value.(string); ok {
+		raw = []byte(text)
+	} else {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			s.logger.Printf("Shared Attribute %s ungültig: %v", AttributeKey, err)
+			return
+		}
+	}
+
+	s.mutex.Lock()
+	unchanged := bytes.Equal(raw, s.attribute)
+	s.mutex.Unlock()
+	if unchanged {
+		return
+	}
+
+	var configs []config.ScheduleConfig
+	if err := json.Unmarshal(raw, &configs); err != nil {
+		s.logger.Printf("Shared Attribute %s ungültig: %v", AttributeKey, err)
+		return
+	}
+	if err := s.ReplaceManaged(configs); err != nil {
+		s.logger.Printf("Shared Attribute %s abgelehnt: %v", AttributeKey, err)
+		return
+	}
+
+	s.mutex.Lock()
+	s.attribute = raw
+	s.mutex.Unlock()
+	s.persist()
+}
+
+// ReplaceManaged ersetzt alle verwalteten Zeitpläne. Ist einer ungültig, bleibt der bisherige
+// Stand erhalten.
+func (s *Scheduler) ReplaceManaged(configs []config.ScheduleConfig) error {
+	schedules := make([]*Schedule, 0, len(configs))
+	var errs []string
+	names := make(map[string]bool)
+	for _, cfg := range configs {
+		schedule, err := s.parseManaged(cfg)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if names[schedule.Name()] {
+			errs = append(errs, fmt.Sprintf("zeitplan %s ist mehrfach definiert", schedule.Name()))
+			continue
+		}
+		names[schedule.Name()] = true
+		schedules = append(schedules, schedule)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("ungültige Zeitpläne: %s", strings.Join(errs, "; "))
+	}
+
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, j := range s.jobs {
+		if !j.managed && names[j.schedule.Name()] {
+			return fmt.Errorf("zeitplan %s ist in app.json festgelegt und kann nur dort geändert werden", j.schedule.Name())
+		}
+	}
+
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		if !j.managed {
+			jobs = append(jobs, j)
+		} else if !names[j.schedule.Name()] {
+			cancelLocked(j)
+		}
+	}
+	for _, schedule := range schedules {
+		_, old := s.findLocked(schedule.Name())
+		jobs = append(jobs, s.replaceLocked(old, schedule, now))
+	}
+	s.jobs = jobs
+	s.inactive = nil
+
+	s.logger.Printf("%d verwaltete Zeitpläne übernommen", len(schedules))
+	return nil
+}
+
+// Schedules gibt den Zustand aller Zeitpläne zurück
+func (s *Scheduler) Schedules() []Status {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := Status{
+			ScheduleConfig: j.schedule.Config,
+			Source:         SourceConfig,
+			MissedRun:      j.schedule.MissedRun,
+			Timezone:       j.schedule.Location.String(),
+			LastResult:     j.LastResult,
+			Running:        j.running,
+			Pending:        j.pending,
+		}
+		if j.managed {
+			status.Source = SourceManaged
+		}
+		if !j.next.IsZero() && !j.schedule.Config.Disabled {
+			next := j.next
+			status.NextRun = &next
+		}
+		if !j.LastRun.IsZero() {
+			lastRun := j.LastRun
+			status.LastRun = &lastRun
+		}
+		if blackout := s.blackoutLocked(j, now); blackout != nil {
+			status.Blackout = blackout.String()
+		}
+		statuses = append(statuses, status)
+	}
+	for _, in := range s.inactive {
+		status := Status{
+			ScheduleConfig: in.config,
+			Source:         SourceManaged,
+			LastResult:     in.LastResult,
+			Inactive:       in.reason,
+		}
+		if !in.LastRun.IsZero() {
+			lastRun := in.LastRun
+			status.LastRun = &lastRun
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
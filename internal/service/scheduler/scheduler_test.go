@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/types"
+)
+
+// testDevice ist ein Gerät ohne Kommunikation
+type testDevice struct {
+	id string
+}
+
+// ID gibt die Kennung des Testgeräts zurück
+func (d *testDevice) ID() string { return d.id }
+
+// Name gibt die Kennung als Anzeigenamen zurück
+func (d *testDevice) Name() string { return d.id }
+
+// Type gibt den Gerätetyp zurück
+func (d *testDevice) Type() types.DeviceType { return types.TypeActor }
+
+// Metadata gibt keine Metadaten zurück
+func (d *testDevice) Metadata() map[string]interface{} { return nil }
+
+// IsEnabled meldet das Gerät als aktiviert
+func (d *testDevice) IsEnabled() bool { return true }
+
+// Enable wird ignoriert
+func (d *testDevice) Enable(bool) {}
+
+// Close wird ignoriert
+func (d *testDevice) Close() error { return nil }
+
+// managedSchedule ist ein verwalteter Zeitplan für das Gerät deviceID
+func managedSchedule(name, deviceID string) config.ScheduleConfig {
+	return config.ScheduleConfig{Name: name, Cron: "0 6 * * *", Device: deviceID, Command: "SET_STATE", Value: true}
+}
+
+// writeState schreibt eine Zustandsdatei mit den verwalteten Zeitplänen
+func writeState(t *testing.T, path string, st state) {
+	t.Helper()
+	data, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readState liest die Zustandsdatei
+func readState(t *testing.T, path string) state {
+	t.Helper()
+	st, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	return st
+}
+
+// TestInactiveManagedSchedule prüft, dass ein verwalteter Zeitplan ohne Gerät gespeichert bleibt
+// und eingeplant wird, sobald das Gerät erstellt ist
+func TestInactiveManagedSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	lastRun := time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC)
+	writeState(t, path, state{
+		Managed: []config.ScheduleConfig{managedSchedule("pump_morning", "pump"), managedSchedule("valve_morning", "valve")},
+		Runs:    map[string]runState{"valve_morning": {LastRun: lastRun, LastResult: "ok"}},
+	})
+
+	registry := device.NewRegistry()
+	if err := registry.AddDevice(&testDevice{id: "pump"}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewScheduler(config.SchedulerConfig{Timezone: "UTC", StatePath: path}, registry, nil)
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+
+	statuses := s.Schedules()
+	if len(statuses) != 2 || statuses[0].Inactive != "" || statuses[1].Name != "valve_morning" || statuses[1].Inactive == "" {
+		t.Fatalf("Zeitpläne %+v, erwartet pump_morning aktiv und valve_morning inaktiv", statuses)
+	}
+	if statuses[1].LastRun == nil || !statuses[1].LastRun.Equal(lastRun) {
+		t.Errorf("letzter Lauf des inaktiven Zeitplans %v, erwartet %v", statuses[1].LastRun, lastRun)
+	}
+
+	// Das Speichern beim Start darf den inaktiven Zeitplan nicht verwerfen
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Stop()
+	if st := readState(t, path); len(st.Managed) != 2 || !st.Runs["valve_morning"].LastRun.Equal(lastRun) {
+		t.Fatalf("gespeicherter Zustand %+v, erwartet beide Zeitpläne", st)
+	}
+
+	now := time.Date(2026, 10, 18, 5, 0, 0, 0, time.UTC)
+	s.Tick(now)
+	if statuses := s.Schedules(); statuses[1].Inactive == "" {
+		t.Fatalf("valve_morning ohne Gerät eingeplant")
+	}
+
+	if err := registry.AddDevice(&testDevice{id: "valve"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Tick(now)
+	statuses = s.Schedules()
+	if len(statuses) != 2 {
+		t.Fatalf("%d Zeitpläne, erwartet 2", len(statuses))
+	}
+	for _, status := range statuses {
+		if status.Inactive != "" || status.NextRun == nil {
+			t.Errorf("Zeitplan %s nicht eingeplant: %+v", status.Name, status)
+		}
+	}
+	if st := readState(t, path); len(st.Managed) != 2 || !st.Runs["valve_morning"].LastRun.Equal(lastRun) {
+		t.Errorf("gespeicherter Zustand %+v, erwartet beide Zeitpläne mit letztem Lauf", st)
+	}
+}
+
+// TestDeleteInactiveSchedule prüft das Löschen eines inaktiven Zeitplans
+func TestDeleteInactiveSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	writeState(t, path, state{Managed: []config.ScheduleConfig{managedSchedule("valve_morning", "valve")}})
+
+	s, err := NewScheduler(config.SchedulerConfig{Timezone: "UTC", StatePath: path}, device.NewRegistry(), nil)
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	if err := s.DeleteSchedule("valve_morning"); err != nil {
+		t.Fatalf("DeleteSchedule: %v", err)
+	}
+	if statuses := s.Schedules(); len(statuses) != 0 {
+		t.Errorf("Zeitpläne %+v, erwartet keine", statuses)
+	}
+	if st := readState(t, path); len(st.Managed) != 0 {
+		t.Errorf("gespeicherte Zeitpläne %+v, erwartet keine", st.Managed)
+	}
+}
+
+// TestNewSchedulerState prüft den Start mit fehlender, beschädigter und nicht lesbarer
+// Zustandsdatei
+func TestNewSchedulerState(t *testing.T) {
+	tests := []struct {
+		name        string
+		prepare     func(t *testing.T, path string)
+		wantErr     bool
+		wantCorrupt bool
+	}{
+		{name: "fehlende Datei", prepare: func(*testing.T, string) {}},
+		{
+			name: "beschädigte Datei",
+			prepare: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte(`{"managed": [`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantCorrupt: true,
+		},
+		{
+			// Ein Verzeichnis lässt sich nicht lesen, der Zustand darf nicht überschrieben werden
+			name: "nicht lesbar",
+			prepare: func(t *testing.T, path string) {
+				if err := os.Mkdir(path, 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "schedules.json")
+			tt.prepare(t, path)
+
+			_, err := NewScheduler(config.SchedulerConfig{Timezone: "UTC", StatePath: path}, device.NewRegistry(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewScheduler = %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+
+			copies, err := filepath.Glob(filepath.Join(dir, "schedules.json.corrupt-*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (len(copies) == 1) != tt.wantCorrupt {
+				t.Errorf("beiseitegelegte Dateien %v, erwartet beiseitegelegt: %v", copies, tt.wantCorrupt)
+			}
+		})
+	}
+}
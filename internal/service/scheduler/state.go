@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"time"
+
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/statefile"
+)
+
+// runState ist der gespeicherte Zustand eines Zeitplans
+type runState struct {
+	// LastDue ist der letzte Fälligkeitszeitpunkt, der behandelt (ausgeführt oder ausgelassen)
+	// wurde. Daran werden nach einem Neustart verpasste Läufe erkannt.
+	LastDue    time.Time `json:"last_due"`
+	LastRun    time.Time `json:"last_run,omitempty"`
+	LastResult string    `json:"last_result,omitempty"`
+}
+
+// state ist der Inhalt der Zustandsdatei
+type state struct {
+	// Managed sind die per RPC oder Shared Attribute angelegten Zeitpläne
+	Managed []config.ScheduleConfig `json:"managed"`
+
+	// Attribute ist der zuletzt übernommene Wert des Shared Attributes "schedules". Ein
+	// unveränderter Wert (z.B. nach einem Neuverbinden) überschreibt keine RPC-Änderungen.
+	Attribute json.RawMessage `json:"attribute,omitempty"`
+
+	Runs map[string]runState `json:"runs"`
+}
+
+// newState erstellt einen leeren Zustand
+func newState() state {
+	return state{Runs: make(map[string]runState)}
+}
+
+// loadState liest die Zustandsdatei. Fehlt sie, ist der Zustand leer. Eine beschädigte Datei
+// wird beiseitegelegt (statefile.ErrCorrupt).
+func loadState(path string) (state, error) {
+	st := newState()
+	if err := statefile.Load(path, &st); err != nil {
+		return newState(), err
+	}
+	if st.Runs == nil {
+		st.Runs = make(map[string]runState)
+	}
+	return st, nil
+}
+
+// saveState schreibt die Zustandsdatei
+func saveState(path string, st state) error {
+	return statefile.Save(path, st)
+}
@@ -0,0 +1,79 @@
+// Package statefile liest und schreibt die JSON-Zustandsdateien der Dienste (Zeitpläne,
+// Schrittketten, Betriebsstunden). Geschrieben wird atomar, eine beschädigte Datei wird
+// beiseitegelegt statt überschrieben.
+package statefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"owipex_reader/internal/device"
+)
+
+// corruptSuffixFormat ergibt sortierbare Zeitstempel für beiseitegelegte Dateien
+const corruptSuffixFormat = "20060102T150405.000Z"
+
+// ErrCorrupt kennzeichnet eine Zustandsdatei, die sich nicht parsen ließ und nach
+// <path>.corrupt-<Zeitstempel> verschoben wurde, damit sie nicht mit leerem Zustand
+// überschrieben wird
+var ErrCorrupt = errors.New("zustandsdatei beschädigt")
+
+// Load liest eine Zustandsdatei nach v. Bei leerem Pfad oder fehlender Datei bleibt v
+// unverändert. Eine Datei, die sich nicht parsen lässt, wird beiseitegelegt (ErrCorrupt); lässt
+// sie sich nicht lesen oder verschieben, wird ein anderer Fehler zurückgegeben und der Aufrufer
+// darf die Datei nicht überschreiben. Nach einem Fehler kann v teilweise gefüllt sein.
+func Load(path string, v interface{}) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fehler beim Lesen von %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		corruptPath, renameErr := setAside(path, time.Now())
+		if renameErr != nil {
+			return fmt.Errorf("fehler beim Parsen von %s (%v), Verschieben fehlgeschlagen: %w", path, err, renameErr)
+		}
+		return fmt.Errorf("%w: fehler beim Parsen von %s (%v), gesichert als %s", ErrCorrupt, path, err, corruptPath)
+	}
+	return nil
+}
+
+// setAside verschiebt eine beschädigte Datei unter einen Namen mit Zeitstempel. Frühere
+// beiseitegelegte Dateien bleiben erhalten.
+func setAside(path string, now time.Time) (string, error) {
+	base := path + ".corrupt-" + now.UTC().Format(corruptSuffixFormat)
+	corruptPath := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(corruptPath); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		corruptPath = fmt.Sprintf("%s-%d", base, i)
+	}
+	return corruptPath, os.Rename(path, corruptPath)
+}
+
+// Save schreibt v als JSON-Zustandsdatei über device.WriteFileAtomic. Ein leerer Pfad wird
+// ignoriert.
+func Save(path string, v interface{}) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fehler beim Serialisieren von %s: %w", path, err)
+	}
+	if err := device.WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("fehler beim Schreiben von %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package statefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testState ist ein Beispielzustand
+type testState struct {
+	Counters map[string]int `json:"counters"`
+}
+
+// TestLoad prüft fehlende, gültige, beschädigte und nicht lesbare Dateien
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     *string
+		path        func(dir string) string
+		want        map[string]int
+		wantCorrupt bool
+		wantErr     bool
+	}{
+		{name: "leerer Pfad", path: func(string) string { return "" }},
+		{name: "fehlende Datei"},
+		{name: "gültige Datei", content: ptr(`{"counters": {"pump": 3}}`), want: map[string]int{"pump": 3}},
+		{name: "beschädigte Datei", content: ptr(`{"counters": {"pump": 3`), wantCorrupt: true, wantErr: true},
+		{name: "falscher Typ", content: ptr(`{"counters": []}`), wantCorrupt: true, wantErr: true},
+		{name: "Verzeichnis statt Datei", path: func(dir string) string { return dir }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "state.json")
+			if tt.path != nil {
+				path = tt.path(dir)
+			}
+			if tt.content != nil {
+				writeFile(t, path, *tt.content)
+			}
+
+			var st testState
+			err := Load(path, &st)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load = %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrCorrupt) != tt.wantCorrupt {
+				t.Fatalf("Load = %v, erwartet ErrCorrupt: %v", err, tt.wantCorrupt)
+			}
+			if !tt.wantErr && len(st.Counters) != len(tt.want) {
+				t.Errorf("Zustand %v, erwartet %v", st.Counters, tt.want)
+			}
+			for key, value := range tt.want {
+				if st.Counters[key] != value {
+					t.Errorf("%s = %d, erwartet %d", key, st.Counters[key], value)
+				}
+			}
+
+			if tt.wantCorrupt {
+				if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("beschädigte Datei nicht verschoben")
+				}
+				if copies := corruptCopies(t, dir); len(copies) != 1 || readFile(t, copies[0]) != *tt.content {
+					t.Errorf("beiseitegelegte Dateien %v, erwartet eine mit dem alten Inhalt", copies)
+				}
+			}
+		})
+	}
+}
+
+// TestLoadKeepsEarlierCorruptCopies prüft, dass eine erneut beschädigte Datei frühere Kopien
+// nicht überschreibt, auch innerhalb derselben Millisekunde
+func TestLoadKeepsEarlierCorruptCopies(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	for i, content := range []string{"{erste", "{zweite", "{dritte"} {
+		writeFile(t, path, content)
+		corruptPath, err := setAside(path, now)
+		if err != nil {
+			t.Fatalf("setAside %d: %v", i+1, err)
+		}
+		if !strings.HasPrefix(filepath.Base(corruptPath), "state.json.corrupt-20261018T120000.000Z") {
+			t.Errorf("Name %s ohne Zeitstempel", corruptPath)
+		}
+		if readFile(t, corruptPath) != content {
+			t.Errorf("%s enthält nicht %q", corruptPath, content)
+		}
+	}
+
+	if copies := corruptCopies(t, dir); len(copies) != 3 {
+		t.Errorf("beiseitegelegte Dateien %v, erwartet 3", copies)
+	}
+}
+
+// TestSave prüft das Schreiben und erneute Lesen einer Zustandsdatei
+func TestSave(t *testing.T) {
+	if err := Save("", testState{}); err != nil {
+		t.Fatalf("Save ohne Pfad: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "sub", "state.json")
+	if err := Save(path, testState{Counters: map[string]int{"pump": 7}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var st testState
+	if err := Load(path, &st); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if st.Counters["pump"] != 7 {
+		t.Errorf("pump = %d, erwartet 7", st.Counters["pump"])
+	}
+}
+
+// ptr gibt einen Zeiger auf einen Dateiinhalt zurück
+func ptr(s string) *string {
+	return &s
+}
+
+// writeFile schreibt eine Testdatei
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFile liest eine Testdatei
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// corruptCopies gibt die beiseitegelegten Dateien in dir zurück
+func corruptCopies(t *testing.T, dir string) []string {
+	t.Helper()
+	copies, err := filepath.Glob(filepath.Join(dir, "*.corrupt-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return copies
+}
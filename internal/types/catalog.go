@@ -0,0 +1,39 @@
+package types
+
+// Schema ist ein JSON-Schema (Draft 7) als verschachtelte Map, wie es von encoding/json erzeugt wird
+type Schema map[string]interface{}
+
+// TypeDescriptor beschreibt einen Gerätetyp: welche Messwerte er liefert, welche Befehle er
+// annimmt, welches Protokoll er benötigt und welche Konfiguration er im Metadata-Block erwartet.
+// Konfigurationsoberflächen können daraus erzeugt werden.
+type TypeDescriptor struct {
+	// Type ist der Wert des Feldes "type" in der Gerätekonfiguration (z.B. "ph_sensor")
+	Type string `json:"type"`
+
+	// Name ist ein lesbarer Name des Gerätetyps
+	Name string `json:"name"`
+
+	// Description beschreibt den Gerätetyp
+	Description string `json:"description,omitempty"`
+
+	// Category gibt an, ob es sich um einen Sensor, Aktor oder ein Hybridgerät handelt
+	Category DeviceType `json:"category"`
+
+	// Readings sind die Messwerttypen, die das Gerät liefert
+	Readings []ReadingType `json:"readings,omitempty"`
+
+	// Commands sind die Befehlstypen, die das Gerät annimmt
+	Commands []CommandType `json:"commands,omitempty"`
+
+	// Protocol ist das benötigte Kommunikationsprotokoll (z.B. "modbus"), leer falls keines
+	Protocol string `json:"protocol,omitempty"`
+
+	// ConfigDir ist das Konfigurationsverzeichnis relativ zum Gerätekonfigurationspfad (z.B. "sensors/ph")
+	ConfigDir string `json:"config_dir,omitempty"`
+
+	// MetadataSchema ist das JSON-Schema des Metadata-Blocks der Gerätekonfiguration
+	MetadataSchema Schema `json:"metadata_schema,omitempty"`
+
+	// Defaults sind Standardwerte für den Metadata-Block, die beim Erstellen ergänzt werden
+	Defaults map[string]interface{} `json:"defaults,omitempty"`
+}
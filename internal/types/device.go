@@ -4,6 +4,7 @@ package types
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -23,13 +24,16 @@ const (
 type ReadingType string
 
 const (
-	ReadingTypePH        ReadingType = "PH"
-	ReadingTypeFlow      ReadingType = "FLOW"
-	ReadingTypeTurbidity ReadingType = "TURBIDITY"
-	ReadingTypeLevel     ReadingType = "LEVEL"
-	ReadingTypePosition  ReadingType = "POSITION"
-	ReadingTypeState     ReadingType = "STATE"
-	ReadingTypeCustom    ReadingType = "CUSTOM"
+	ReadingTypePH              ReadingType = "PH"
+	ReadingTypeFlow            ReadingType = "FLOW"
+	ReadingTypeTurbidity       ReadingType = "TURBIDITY"
+	ReadingTypeConductivity    ReadingType = "CONDUCTIVITY"
+	ReadingTypeDissolvedOxygen ReadingType = "DISSOLVED_OXYGEN"
+	ReadingTypeLevel           ReadingType = "LEVEL"
+	ReadingTypePosition        ReadingType = "POSITION"
+	ReadingTypeState           ReadingType = "STATE"
+	ReadingTypeFrequency       ReadingType = "FREQUENCY"
+	ReadingTypeCustom          ReadingType = "CUSTOM"
 )
 
 // ReadingQuality gibt die Qualität eines Messwerts an
@@ -181,6 +185,30 @@ type Actor interface {
 	GetState() (interface{}, error)
 }
 
+// ErrPending meldet Write, wenn der Befehl ausgeführt wurde, das Gerät den Zielzustand aber vor
+// Ablauf des Kontexts noch nicht erreicht hat (z.B. Fahrt eines Ventils). Das Gerät überwacht
+// den Befehl weiter und meldet eine spätere Störung über seinen Zustand.
+var ErrPending = errors.New("befehl ausgeführt, Zielzustand noch nicht erreicht")
+
+// StateReader wird von Aktoren implementiert, deren Zustand mit einem Kontext zurückgelesen
+// werden kann, damit das Zurücklesen beim Stoppen oder nach Ablauf des Zeitlimits abbricht
+type StateReader interface {
+	// GetStateContext gibt den aktuellen Zustand des Aktors zurück
+	GetStateContext(ctx context.Context) (interface{}, error)
+}
+
+// MetadataRunning ist der Metadaten-Schlüssel der Zustandsmesswerte (ReadingTypeState) von
+// Aktoren: true, solange der Aktor läuft, z.B. Relais eingeschaltet, Ventil nicht geschlossen,
+// Umrichter dreht. Der Wert ist immer bool.
+const MetadataRunning = "running"
+
+// RunningReporter wird von Aktoren implementiert, die aus ihrem zurückgelesenen Zustand
+// (GetState) ableiten, ob sie laufen
+type RunningReporter interface {
+	// Running meldet, ob der Aktor im Zustand state läuft
+	Running(state interface{}) bool
+}
+
 // HybridDevice ist ein Gerät, das sowohl messen als auch steuern kann
 type HybridDevice interface {
 	ReadableDevice
@@ -192,8 +220,10 @@ type DeviceConfig struct {
 	ID           string                 `json:"id"`
 	Name         string                 `json:"name"`
 	Type         string                 `json:"type"`
+	Profile      string                 `json:"profile,omitempty"` // Geräteprofil "<hersteller>/<modell>"
 	Manufacturer string                 `json:"manufacturer"`
 	Model        string                 `json:"model"`
+	Firmware     string                 `json:"firmware"`
 	Protocol     string                 `json:"protocol"`
 	Enabled      bool                   `json:"enabled"`
 	Metadata     map[string]interface{} `json:"metadata"`
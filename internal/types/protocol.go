@@ -27,6 +27,45 @@ type ProtocolHandler interface {
 	Close() error
 }
 
+// DeviceIdentity enthält die Identifikationsdaten eines angeschlossenen Geräts
+// (z.B. aus Modbus Read Device Identification oder herstellerspezifischen ID-Registern)
+type DeviceIdentity struct {
+	Manufacturer string            `json:"manufacturer"`
+	ProductCode  string            `json:"product_code"`
+	Model        string            `json:"model"`
+	ProductName  string            `json:"product_name"`
+	Firmware     string            `json:"firmware"`
+	SerialNumber string            `json:"serial_number"`
+	VendorURL    string            `json:"vendor_url"`
+	Extra        map[string]string `json:"extra,omitempty"`
+}
+
+// IsEmpty prüft, ob keine Identifikationsdaten vorliegen
+func (i DeviceIdentity) IsEmpty() bool {
+	return i.Manufacturer == "" && i.ProductCode == "" && i.Model == "" &&
+		i.ProductName == "" && i.Firmware == "" && i.SerialNumber == ""
+}
+
+// DeviceIdentifier wird von Protokoll-Handlern implementiert, die die Identität
+// des angeschlossenen Geräts auslesen können
+type DeviceIdentifier interface {
+	// ReadDeviceIdentity liest die Identifikationsdaten des Geräts
+	ReadDeviceIdentity(ctx context.Context) (DeviceIdentity, error)
+}
+
+// CoilHandler wird von Protokoll-Handlern implementiert, die einzelne Bits (Modbus-Coils und
+// diskrete Eingänge) lesen und schreiben können, z.B. für Relais und Rückmeldekontakte
+type CoilHandler interface {
+	// ReadCoil liest den Zustand einer Coil
+	ReadCoil(ctx context.Context, address uint16) (bool, error)
+
+	// WriteCoil setzt eine Coil
+	WriteCoil(ctx context.Context, address uint16, value bool) error
+
+	// ReadDiscreteInput liest den Zustand eines diskreten Eingangs
+	ReadDiscreteInput(ctx context.Context, address uint16) (bool, error)
+}
+
 // ModbusRegisterType definiert den Typ des Modbus-Registers
 type ModbusRegisterType string
 
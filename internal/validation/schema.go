@@ -0,0 +1,410 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"owipex_reader/internal/types"
+)
+
+// ValidateSchema prüft einen aus JSON dekodierten Wert gegen ein JSON-Schema.
+// Unterstützt wird die Teilmenge, die in den Gerätebeschreibungen verwendet wird:
+// type, properties, required, additionalProperties, items, enum, minimum, maximum
+// und exclusiveMinimum. path ist der JSON-Pfad des Werts (z.B. "$.metadata").
+func ValidateSchema(value interface{}, schema types.Schema, path string) []Issue {
+	return validateValue(value, map[string]interface{}(schema), path)
+}
+
+// validateValue prüft einen Wert gegen ein (Teil-)Schema
+func validateValue(value interface{}, schema map[string]interface{}, path string) []Issue {
+	if schema == nil {
+		return nil
+	}
+
+	if expected, ok := schema["type"]; ok {
+		if !matchesType(value, expected) {
+			return []Issue{{
+				Severity:   SeverityError,
+				Path:       path,
+				Message:    fmt.Sprintf("erwartet %s, gefunden %s", describeType(expected), jsonTypeOf(value)),
+				Suggestion: exampleFor(schema),
+			}}
+		}
+	}
+
+	var issues []Issue
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(value, enum) {
+		issues = append(issues, Issue{
+			Severity:   SeverityError,
+			Path:       path,
+			Message:    fmt.Sprintf("ungültiger Wert %v", formatValue(value)),
+			Suggestion: fmt.Sprintf("erlaubt sind: %s", formatEnum(enum)),
+		})
+	}
+
+	if number, ok := value.(float64); ok {
+		issues = append(issues, validateNumber(number, schema, path)...)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		issues = append(issues, validateObject(v, schema, path)...)
+	case []interface{}:
+		if items, ok := asSchema(schema["items"]); ok {
+			for i, item := range v {
+				issues = append(issues, validateValue(item, items, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	return issues
+}
+
+// validateNumber prüft die Wertebereiche einer Zahl
+func validateNumber(number float64, schema map[string]interface{}, path string) []Issue {
+	var issues []Issue
+
+	if minimum, ok := toFloat(schema["minimum"]); ok && number < minimum {
+		issues = append(issues, Issue{
+			Severity:   SeverityError,
+			Path:       path,
+			Message:    fmt.Sprintf("Wert %v ist kleiner als das Minimum %v", number, minimum),
+			Suggestion: fmt.Sprintf("einen Wert >= %v verwenden", minimum),
+		})
+	}
+	if minimum, ok := toFloat(schema["exclusiveMinimum"]); ok && number <= minimum {
+		issues = append(issues, Issue{
+			Severity:   SeverityError,
+			Path:       path,
+			Message:    fmt.Sprintf("Wert %v muss größer als %v sein", number, minimum),
+			Suggestion: fmt.Sprintf("einen Wert > %v verwenden", minimum),
+		})
+	}
+	if maximum, ok := toFloat(schema["maximum"]); ok && number > maximum {
+		issues = append(issues, Issue{
+			Severity:   SeverityError,
+			Path:       path,
+			Message:    fmt.Sprintf("Wert %v ist größer als das Maximum %v", number, maximum),
+			Suggestion: fmt.Sprintf("einen Wert <= %v verwenden", maximum),
+		})
+	}
+
+	return issues
+}
+
+// validateObject prüft Pflichtfelder und Eigenschaften eines Objekts
+func validateObject(object map[string]interface{}, schema map[string]interface{}, path string) []Issue {
+	var issues []Issue
+
+	properties, _ := asSchema(schema["properties"])
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, exists := object[key]; exists {
+				continue
+			}
+			issue := Issue{
+				Severity: SeverityError,
+				Path:     joinPath(path, key),
+				Message:  "Pflichtfeld fehlt",
+			}
+			if propertySchema, ok := asSchema(properties[key]); ok {
+				issue.Suggestion = exampleFor(propertySchema)
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	// Schlüssel sortiert prüfen, damit die Ausgabe stabil ist
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := object[key]
+		childPath := joinPath(path, key)
+
+		if propertySchema, ok := asSchema(properties[key]); ok {
+			issues = append(issues, validateValue(value, propertySchema, childPath)...)
+			continue
+		}
+		if additional, ok := asSchema(schema["additionalProperties"]); ok {
+			issues = append(issues, validateValue(value, additional, childPath)...)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				issues = append(issues, Issue{
+					Severity:   SeverityError,
+					Path:       childPath,
+					Message:    "unbekanntes Feld",
+					Suggestion: suggestKey(key, properties),
+				})
+			}
+		default:
+			// Unbekannte Felder sind erlaubt, Tippfehler bekannter Felder werden aber gemeldet
+			if suggestion := suggestKey(key, properties); suggestion != "" {
+				issues = append(issues, Issue{
+					Severity:   SeverityWarning,
+					Path:       childPath,
+					Message:    "unbekanntes Feld wird ignoriert",
+					Suggestion: suggestion,
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// asSchema wandelt ein Teilschema (map oder types.Schema) in eine Map um
+func asSchema(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case types.Schema:
+		return map[string]interface{}(v), true
+	default:
+		return nil, false
+	}
+}
+
+// matchesType prüft, ob ein Wert zu einem oder mehreren JSON-Schema-Typen passt
+func matchesType(value interface{}, expected interface{}) bool {
+	switch t := expected.(type) {
+	case string:
+		return matchesSingleType(value, t)
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && matchesSingleType(value, name) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// matchesSingleType prüft, ob ein Wert zu einem JSON-Schema-Typ passt
+func matchesSingleType(value interface{}, expected string) bool {
+	switch expected {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+// jsonTypeOf gibt den JSON-Typ eines dekodierten Werts zurück
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// describeType gibt den erwarteten Typ lesbar aus
+func describeType(expected interface{}) string {
+	if list, ok := expected.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, item := range list {
+			names = append(names, fmt.Sprint(item))
+		}
+		return strings.Join(names, " oder ")
+	}
+	return fmt.Sprint(expected)
+}
+
+// exampleFor erzeugt einen Vorschlag aus Standardwert, Aufzählung oder Beschreibung eines Schemas
+func exampleFor(schema map[string]interface{}) string {
+	if defaultValue, ok := schema["default"]; ok {
+		return fmt.Sprintf("z.B. %s", formatValue(defaultValue))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		return fmt.Sprintf("erlaubt sind: %s", formatEnum(enum))
+	}
+	if description, ok := schema["description"].(string); ok {
+		return description
+	}
+	if expected, ok := schema["type"]; ok {
+		return fmt.Sprintf("einen Wert vom Typ %s angeben", describeType(expected))
+	}
+	return ""
+}
+
+// inEnum prüft, ob ein Wert in einer Aufzählung enthalten ist (Zahlen werden numerisch verglichen)
+func inEnum(value interface{}, enum []interface{}) bool {
+	number, isNumber := toFloat(value)
+	for _, allowed := range enum {
+		if allowedNumber, ok := toFloat(allowed); ok && isNumber {
+			if allowedNumber == number {
+				return true
+			}
+			continue
+		}
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatEnum gibt die Werte einer Aufzählung lesbar aus
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, value := range enum {
+		values[i] = formatValue(value)
+	}
+	return strings.Join(values, ", ")
+}
+
+// formatValue gibt einen Wert in JSON-Schreibweise aus
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(value)
+}
+
+// toFloat wandelt numerische Werte (aus JSON oder Go-Literalen im Schema) in float64 um
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// joinPath hängt einen Schlüssel an einen JSON-Pfad an
+func joinPath(path, key string) string {
+	if path == "" {
+		path = "$"
+	}
+	return path + "." + key
+}
+
+// suggestKey schlägt einen bekannten Schlüssel vor, der einem unbekannten ähnelt
+func suggestKey(key string, properties map[string]interface{}) string {
+	candidates := make([]string, 0, len(properties))
+	for name := range properties {
+		candidates = append(candidates, name)
+	}
+
+	if match := closestMatch(key, candidates); match != "" {
+		return fmt.Sprintf("meinten Sie %q?", match)
+	}
+	return ""
+}
+
+// closestMatch gibt den ähnlichsten Kandidaten zurück, sofern er höchstens ein Drittel
+// der Zeichen (mindestens 1, höchstens 3) abweicht
+func closestMatch(value string, candidates []string) string {
+	sort.Strings(candidates)
+
+	best := ""
+	bestDistance := -1
+	for _, candidate := range candidates {
+		distance := levenshtein(strings.ToLower(value), strings.ToLower(candidate))
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	limit := len(value) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > 3 {
+		limit = 3
+	}
+	if bestDistance < 0 || bestDistance > limit || best == value {
+		return ""
+	}
+	return best
+}
+
+// levenshtein berechnet die Editierdistanz zweier Zeichenketten
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// minInt gibt das Minimum mehrerer Ganzzahlen zurück
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,1375 @@
+// Package validation prüft Anwendungs- und Gerätekonfigurationen offline, bevor sie
+// ausgerollt werden. Fehler werden mit Datei, JSON-Pfad und Vorschlag gemeldet.
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"owipex_reader/internal/arbitration"
+	"owipex_reader/internal/config"
+	"owipex_reader/internal/device"
+	"owipex_reader/internal/device/creator"
+	"owipex_reader/internal/interlock"
+	"owipex_reader/internal/maintenance"
+	"owipex_reader/internal/protocol/factory"
+	"owipex_reader/internal/rule"
+	"owipex_reader/internal/sequence"
+	"owipex_reader/internal/service/scheduler"
+	"owipex_reader/internal/types"
+)
+
+// Severity gibt die Schwere eines Befunds an
+type Severity string
+
+const (
+	// SeverityError: Die Konfiguration ist ungültig oder führt zu Fehlverhalten
+	SeverityError Severity = "error"
+	// SeverityWarning: Die Konfiguration ist gültig, aber vermutlich nicht beabsichtigt
+	SeverityWarning Severity = "warning"
+)
+
+// Issue ist ein Befund der Validierung
+type Issue struct {
+	Severity   Severity `json:"severity"`
+	File       string   `json:"file"`
+	Path       string   `json:"path"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// String gibt den Befund im Format "datei: pfad: schwere: meldung (Vorschlag: ...)" aus
+func (i Issue) String() string {
+	var b strings.Builder
+	if i.File != "" {
+		b.WriteString(i.File)
+		b.WriteString(": ")
+	}
+	if i.Path != "" {
+		b.WriteString(i.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(string(i.Severity))
+	b.WriteString(": ")
+	b.WriteString(i.Message)
+	if i.Suggestion != "" {
+		b.WriteString(" (Vorschlag: ")
+		b.WriteString(i.Suggestion)
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// Report fasst die Befunde einer Validierung zusammen
+type Report struct {
+	Files  []string `json:"files"`
+	Issues []Issue  `json:"issues"`
+}
+
+// Errors gibt die Anzahl der Fehler zurück
+func (r Report) Errors() int {
+	return r.count(SeverityError)
+}
+
+// Warnings gibt die Anzahl der Warnungen zurück
+func (r Report) Warnings() int {
+	return r.count(SeverityWarning)
+}
+
+// Failed prüft, ob die Validierung fehlgeschlagen ist: bei Fehlern, mit strict auch bei Warnungen
+func (r Report) Failed(strict bool) bool {
+	return r.Errors() > 0 || (strict && r.Warnings() > 0)
+}
+
+// count zählt die Befunde einer Schwere
+func (r Report) count(severity Severity) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// addFile merkt eine geprüfte Datei
+func (r *Report) addFile(file string) {
+	r.Files = append(r.Files, file)
+}
+
+// add übernimmt Befunde und setzt die Datei, falls sie fehlt
+func (r *Report) add(file string, issues ...Issue) {
+	for _, issue := range issues {
+		if issue.File == "" {
+			issue.File = file
+		}
+		r.Issues = append(r.Issues, issue)
+	}
+}
+
+// Validator prüft Konfigurationen gegen die Gerätebeschreibungen des Katalogs
+type Validator struct {
+	catalog *creator.Catalog
+}
+
+// NewValidator erstellt einen Validator für die Gerätetypen des Katalogs
+func NewValidator(catalog *creator.Catalog) *Validator {
+	return &Validator{catalog: catalog}
+}
+
+// deviceFile ist eine erfolgreich gelesene Gerätekonfiguration
+type deviceFile struct {
+	path   string
+	config types.DeviceConfig
+}
+
+// Validate prüft die Anwendungskonfiguration (leer = nicht prüfen) und alle
+// Gerätekonfigurationen unterhalb von devicePath
+func (v *Validator) Validate(appConfigPath, devicePath string) Report {
+	var report Report
+
+	profiles := v.loadProfiles(devicePath, &report)
+	devices := v.validateDeviceFiles(devicePath, profiles, &report)
+	v.validateDuplicateIDs(devices, &report)
+	v.validateBuses(devices, &report)
+
+	if appConfigPath != "" {
+		v.validateAppConfig(appConfigPath, devices, &report)
+	}
+
+	return report
+}
+
+// ValidateDevices prüft Gerätekonfigurationen (Dateipfad -> Konfiguration), die noch nicht
+// geschrieben wurden, z.B. vor einer Änderung per RPC. Es werden dieselben Prüfungen wie bei
+// Validate ausgeführt, mit Ausnahme der JSON-Syntax und unbekannter Felder.
+func (v *Validator) ValidateDevices(configs map[string]types.DeviceConfig, profiles *device.ProfileLibrary) Report {
+	var report Report
+
+	paths := make([]string, 0, len(configs))
+	for path := range configs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var devices []deviceFile
+	for _, path := range paths {
+		report.addFile(path)
+		devices = append(devices, v.validateDeviceConfig(path, configs[path], profiles, &report))
+	}
+	v.validateDuplicateIDs(devices, &report)
+	v.validateBuses(devices, &report)
+
+	return report
+}
+
+// loadProfiles lädt die Geräteprofile und meldet ungültige Profildateien
+func (v *Validator) loadProfiles(devicePath string, report *Report) *device.ProfileLibrary {
+	profiles := device.NewProfileLibrary()
+
+	for _, err := range profiles.Load(filepath.Join(devicePath, device.ProfileDir)) {
+		var profileErr *device.ProfileError
+		if errors.As(err, &profileErr) {
+			report.addFile(profileErr.Path)
+			report.add(profileErr.Path, Issue{Severity: SeverityError, Message: profileErr.Err.Error()})
+			continue
+		}
+		report.add(devicePath, Issue{Severity: SeverityError, Message: err.Error()})
+	}
+
+	for _, ref := range profiles.Refs() {
+		profile, _ := profiles.Get(ref)
+		report.addFile(profile.Path)
+		if _, err := v.catalog.Describe(profile.Type); err != nil {
+			issue := Issue{
+				Severity: SeverityError,
+				Path:     "$.type",
+				Message:  fmt.Sprintf("unbekannter Gerätetyp %q", profile.Type),
+			}
+			if match := closestMatch(profile.Type, v.typeNames()); match != "" {
+				issue.Suggestion = fmt.Sprintf("meinten Sie %q?", match)
+			}
+			report.add(profile.Path, issue)
+		}
+	}
+
+	return profiles
+}
+
+// validateDeviceFiles liest und prüft alle JSON-Dateien der Konfigurationsverzeichnisse
+func (v *Validator) validateDeviceFiles(devicePath string, profiles *device.ProfileLibrary, report *Report) []deviceFile {
+	var devices []deviceFile
+
+	if info, err := os.Stat(devicePath); err != nil || !info.IsDir() {
+		report.add(devicePath, Issue{
+			Severity:   SeverityError,
+			Message:    "Gerätekonfigurationsverzeichnis nicht gefunden",
+			Suggestion: "Pfad mit -devices angeben",
+		})
+		return nil
+	}
+
+	known := make(map[string]bool)
+	for _, dir := range v.catalog.ConfigDirs() {
+		known[filepath.FromSlash(dir)] = true
+
+		paths, err := filepath.Glob(filepath.Join(devicePath, filepath.FromSlash(dir), "*.json"))
+		if err != nil {
+			report.add(dir, Issue{Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			report.addFile(path)
+			if entry, ok := v.validateDeviceFile(path, profiles, report); ok {
+				devices = append(devices, entry)
+			}
+		}
+	}
+
+	v.reportUnknownDirs(devicePath, known, report)
+
+	return devices
+}
+
+// reportUnknownDirs warnt vor Konfigurationsdateien in Verzeichnissen, die nicht geladen werden
+func (v *Validator) reportUnknownDirs(devicePath string, known map[string]bool, report *Report) {
+	_ = filepath.Walk(devicePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(devicePath, filepath.Dir(path))
+		if err != nil || known[rel] || inDir(rel, device.ProfileDir) || inDir(rel, device.BackupDir) {
+			return nil
+		}
+
+		knownDirs := make([]string, 0, len(known))
+		for dir := range known {
+			knownDirs = append(knownDirs, dir)
+		}
+		suggestion := "Datei in eines der Verzeichnisse " + strings.Join(sortedStrings(knownDirs), ", ") + " verschieben"
+		if match := closestMatch(rel, knownDirs); match != "" {
+			suggestion = fmt.Sprintf("meinten Sie das Verzeichnis %q?", match)
+		}
+
+		report.add(path, Issue{
+			Severity:   SeverityWarning,
+			Message:    "Datei liegt in keinem Konfigurationsverzeichnis und wird nicht geladen",
+			Suggestion: suggestion,
+		})
+		return nil
+	})
+}
+
+// validateDeviceFile prüft eine einzelne Gerätekonfiguration
+func (v *Validator) validateDeviceFile(path string, profiles *device.ProfileLibrary, report *Report) (deviceFile, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		report.add(path, Issue{Severity: SeverityError, Message: fmt.Sprintf("Datei kann nicht gelesen werden: %v", err)})
+		return deviceFile{}, false
+	}
+
+	var raw map[string]interface{}
+	if issue, ok := decodeJSON(data, &raw); !ok {
+		report.add(path, issue)
+		return deviceFile{}, false
+	}
+
+	var deviceConfig types.DeviceConfig
+	if err := json.Unmarshal(data, &deviceConfig); err != nil {
+		report.add(path, typeErrorIssue(err))
+		return deviceFile{}, false
+	}
+
+	report.add(path, unknownFields(raw, reflect.TypeOf(types.DeviceConfig{}), "$")...)
+
+	return v.validateDeviceConfig(path, deviceConfig, profiles, report), true
+}
+
+// validateDeviceConfig prüft eine dekodierte Gerätekonfiguration. Auch Konfigurationen mit
+// Fehlern werden für die dateiübergreifenden Prüfungen zurückgegeben.
+func (v *Validator) validateDeviceConfig(path string, deviceConfig types.DeviceConfig, profiles *device.ProfileLibrary, report *Report) deviceFile {
+	// Profil einbeziehen, alle weiteren Prüfungen gelten der zusammengeführten Konfiguration
+	if deviceConfig.Profile != "" {
+		resolved, err := profiles.Resolve(deviceConfig)
+		if err != nil {
+			issue := Issue{
+				Severity:   SeverityError,
+				Path:       "$.profile",
+				Message:    err.Error(),
+				Suggestion: fmt.Sprintf("Profile liegen unter %s/<hersteller>/<modell>.json", device.ProfileDir),
+			}
+			if match := closestMatch(deviceConfig.Profile, profiles.Refs()); match != "" {
+				issue.Suggestion = fmt.Sprintf("meinten Sie %q?", match)
+			}
+			report.add(path, issue)
+			return deviceFile{path: path, config: deviceConfig}
+		}
+		deviceConfig = resolved
+	}
+
+	file := deviceFile{path: path, config: deviceConfig}
+
+	if deviceConfig.ID == "" {
+		report.add(path, Issue{
+			Severity:   SeverityError,
+			Path:       "$.id",
+			Message:    "keine ID angegeben",
+			Suggestion: fmt.Sprintf("z.B. %q", strings.TrimSuffix(filepath.Base(path), ".json")),
+		})
+	}
+	if deviceConfig.Name == "" {
+		report.add(path, Issue{
+			Severity:   SeverityWarning,
+			Path:       "$.name",
+			Message:    "kein Anzeigename angegeben",
+			Suggestion: "Namen für Dashboard und Logs vergeben",
+		})
+	}
+
+	if deviceConfig.Type == "" {
+		report.add(path, Issue{
+			Severity:   SeverityError,
+			Path:       "$.type",
+			Message:    "kein Gerätetyp angegeben",
+			Suggestion: "verfügbare Typen mit \"reader list-types\" anzeigen",
+		})
+		return file
+	}
+
+	descriptor, err := v.catalog.Describe(deviceConfig.Type)
+	if err != nil {
+		issue := Issue{
+			Severity:   SeverityError,
+			Path:       "$.type",
+			Message:    fmt.Sprintf("unbekannter Gerätetyp %q", deviceConfig.Type),
+			Suggestion: "verfügbare Typen mit \"reader list-types\" anzeigen",
+		}
+		if match := closestMatch(deviceConfig.Type, v.typeNames()); match != "" {
+			issue.Suggestion = fmt.Sprintf("meinten Sie %q?", match)
+		}
+		report.add(path, issue)
+		return file
+	}
+
+	if descriptor.Protocol != "" && deviceConfig.Protocol != descriptor.Protocol {
+		report.add(path, Issue{
+			Severity:   SeverityError,
+			Path:       "$.protocol",
+			Message:    fmt.Sprintf("Gerätetyp %s benötigt das Protokoll %q, angegeben ist %q", descriptor.Type, descriptor.Protocol, deviceConfig.Protocol),
+			Suggestion: fmt.Sprintf("\"protocol\": %q setzen", descriptor.Protocol),
+		})
+	}
+
+	if descriptor.MetadataSchema != nil {
+		metadata := deviceConfig.Metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		report.add(path, ValidateSchema(metadata, descriptor.MetadataSchema, "$.metadata")...)
+	}
+
+	report.add(path, validateCalibration(deviceConfig, descriptor)...)
+	report.add(path, validateRegisterOverlaps(deviceConfig)...)
+
+	return file
+}
+
+// validateCalibration warnt vor Sensoren ohne Kalibrierung, wenn der Typ eine vorsieht
+func validateCalibration(deviceConfig types.DeviceConfig, descriptor types.TypeDescriptor) []Issue {
+	properties, _ := asSchema(descriptor.MetadataSchema["properties"])
+	if _, calibratable := properties["calibration"]; !calibratable {
+		return nil
+	}
+	if _, exists := deviceConfig.Metadata["calibration"]; exists {
+		return nil
+	}
+
+	suggestion := "Kalibrierung nach der Inbetriebnahme unter metadata.calibration eintragen"
+	if defaults, ok := descriptor.Defaults["calibration"]; ok {
+		if data, err := json.Marshal(defaults); err == nil {
+			suggestion = fmt.Sprintf("Kalibrierung eintragen, ohne Angabe gilt %s", data)
+		}
+	}
+
+	return []Issue{{
+		Severity:   SeverityWarning,
+		Path:       "$.metadata.calibration",
+		Message:    "keine Kalibrierung hinterlegt",
+		Suggestion: suggestion,
+	}}
+}
+
+// registerRange ist der belegte Adressbereich einer Register-Map
+type registerRange struct {
+	name  string
+	table string
+	start int
+	end   int // exklusiv
+}
+
+// validateRegisterOverlaps prüft, ob sich Register-Maps eines Geräts überlappen
+func validateRegisterOverlaps(deviceConfig types.DeviceConfig) []Issue {
+	modbusConfig, ok := deviceConfig.Metadata["modbus"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	registerMaps, ok := modbusConfig["register_maps"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var ranges []registerRange
+	for name, value := range registerMaps {
+		regMap, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		address, ok := regMap["address"].(float64)
+		if !ok {
+			continue
+		}
+		ranges = append(ranges, registerRange{
+			name:  name,
+			table: registerTable(regMap),
+			start: int(address),
+			end:   int(address) + registerLength(regMap),
+		})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].table != ranges[j].table {
+			return ranges[i].table < ranges[j].table
+		}
+		if ranges[i].start != ranges[j].start {
+			return ranges[i].start < ranges[j].start
+		}
+		return ranges[i].name < ranges[j].name
+	})
+
+	var issues []Issue
+	for i := 0; i < len(ranges); i++ {
+		for j := i + 1; j < len(ranges) && ranges[j].table == ranges[i].table && ranges[j].start < ranges[i].end; j++ {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Path:     "$.metadata.modbus.register_maps." + ranges[j].name,
+				Message: fmt.Sprintf("%s-Register %d-%d überlappen mit %s (%d-%d)",
+					ranges[j].table, ranges[j].start, ranges[j].end-1, ranges[i].name, ranges[i].start, ranges[i].end-1),
+				Suggestion: fmt.Sprintf("Adresse >= %d verwenden oder length von %s prüfen", ranges[i].end, ranges[i].name),
+			})
+		}
+	}
+
+	return issues
+}
+
+// registerTable gibt die Registertabelle einer Register-Map zurück (Standard: Holding)
+func registerTable(regMap map[string]interface{}) string {
+	regType, _ := regMap["type"].(string)
+	switch strings.ToUpper(regType) {
+	case "INPUT", "COIL", "DISCRETE":
+		return strings.ToUpper(regType)
+	default:
+		return "HOLDING"
+	}
+}
+
+// registerLength gibt die Anzahl belegter Register zurück. Ohne Angabe wird sie aus dem Datentyp abgeleitet.
+func registerLength(regMap map[string]interface{}) int {
+	if length, ok := regMap["length"].(float64); ok && length > 0 {
+		return int(length)
+	}
+
+	dataType, _ := regMap["data_type"].(string)
+	switch dataType {
+	case "float32", "int32", "uint32":
+		return 2
+	case "float64":
+		return 4
+	default:
+		return 1
+	}
+}
+
+// validateDuplicateIDs meldet Geräte-IDs, die in mehreren Dateien verwendet werden
+func (v *Validator) validateDuplicateIDs(devices []deviceFile, report *Report) {
+	seen := make(map[string]string)
+	for _, entry := range devices {
+		id := entry.config.ID
+		if id == "" {
+			continue
+		}
+		if other, exists := seen[id]; exists {
+			report.add(entry.path, Issue{
+				Severity:   SeverityError,
+				Path:       "$.id",
+				Message:    fmt.Sprintf("ID %q wird bereits in %s verwendet", id, other),
+				Suggestion: "eindeutige ID vergeben",
+			})
+			continue
+		}
+		seen[id] = entry.path
+	}
+}
+
+// busDevice ist ein Modbus-Gerät an einer seriellen Schnittstelle
+type busDevice struct {
+	path     string
+	id       string
+	slaveID  int
+	baudRate int
+
+	// shared ist gesetzt, wenn sich mehrere Aktoren eine Slave-ID teilen dürfen
+	// (z.B. die Kanäle einer Relaiskarte oder die Auf/Zu-Ausgänge eines Ventils)
+	shared  bool
+	outputs []busOutput
+}
+
+// busOutput ist ein von einem Aktor geschalteter Ausgang
+type busOutput struct {
+	// path ist der JSON-Pfad der Ausgangsdefinition in der Gerätedatei
+	path string
+	// name beschreibt den Ausgang, z.B. "coil 3" oder "holding_bit 10.2"
+	name string
+}
+
+// validateBuses prüft doppelte Slave-IDs und abweichende Baudraten an derselben Schnittstelle
+func (v *Validator) validateBuses(devices []deviceFile, report *Report) {
+	buses := make(map[string][]busDevice)
+	var ports []string
+
+	for _, entry := range devices {
+		if entry.config.Protocol != "modbus" || !entry.config.Enabled {
+			continue
+		}
+		modbusConfig, ok := entry.config.Metadata["modbus"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		slaveID, ok := modbusConfig["slave_id"].(float64)
+		if !ok {
+			continue
+		}
+
+		port, _ := modbusConfig["port"].(string)
+		if port == "" {
+			port = factory.DefaultModbusPort
+		}
+		baudRate := 9600
+		if value, ok := modbusConfig["baud_rate"].(float64); ok {
+			baudRate = int(value)
+		}
+
+		if _, exists := buses[port]; !exists {
+			ports = append(ports, port)
+		}
+		bus := busDevice{
+			path:     entry.path,
+			id:       entry.config.ID,
+			slaveID:  int(slaveID),
+			baudRate: baudRate,
+			outputs:  actorOutputs(entry.config.Metadata),
+		}
+		if descriptor, err := v.catalog.Describe(entry.config.Type); err == nil && descriptor.Category == types.TypeActor {
+			bus.shared = true
+		}
+		if len(bus.outputs) > 0 {
+			bus.shared = true
+		}
+		buses[port] = append(buses[port], bus)
+	}
+
+	sort.Strings(ports)
+	for _, port := range ports {
+		slaves := make(map[int]busDevice)
+		outputs := make(map[string]busDevice)
+		first := buses[port][0]
+
+		for _, entry := range buses[port] {
+			other, exists := slaves[entry.slaveID]
+			if exists && entry.shared && other.shared {
+				// Geteilte Slave-ID, aber jeder Ausgang darf nur von einem Aktor geschaltet werden
+				for _, output := range entry.outputs {
+					key := fmt.Sprintf("%d/%s", entry.slaveID, output.name)
+					if previous, used := outputs[key]; used {
+						report.add(entry.path, Issue{
+							Severity:   SeverityError,
+							Path:       output.path,
+							Message:    fmt.Sprintf("Ausgang %s von Slave %d an %s wird bereits von %s (%s) geschaltet", output.name, entry.slaveID, port, previous.id, previous.path),
+							Suggestion: "anderen Ausgang bzw. anderes Bit wählen",
+						})
+					} else {
+						outputs[key] = entry
+					}
+				}
+			} else if exists {
+				report.add(entry.path, Issue{
+					Severity:   SeverityError,
+					Path:       "$.metadata.modbus.slave_id",
+					Message:    fmt.Sprintf("Slave-ID %d an %s wird bereits von %s (%s) verwendet", entry.slaveID, port, other.id, other.path),
+					Suggestion: fmt.Sprintf("freie Slave-ID verwenden, z.B. %d", freeSlaveID(slaves)),
+				})
+			} else {
+				slaves[entry.slaveID] = entry
+				for _, output := range entry.outputs {
+					outputs[fmt.Sprintf("%d/%s", entry.slaveID, output.name)] = entry
+				}
+			}
+
+			if entry.baudRate != first.baudRate {
+				report.add(entry.path, Issue{
+					Severity:   SeverityError,
+					Path:       "$.metadata.modbus.baud_rate",
+					Message:    fmt.Sprintf("Baudrate %d an %s weicht von %s (%d) ab", entry.baudRate, port, first.id, first.baudRate),
+					Suggestion: fmt.Sprintf("alle Geräte an %s mit %d Baud betreiben", port, first.baudRate),
+				})
+			}
+		}
+	}
+}
+
+// actorOutputs gibt die geschalteten Ausgänge eines Aktors zurück: den Ausgang eines Relais
+// bzw. die Auf/Zu-Ausgänge eines Ventils im Relaisbetrieb
+func actorOutputs(metadata map[string]interface{}) []busOutput {
+	var outputs []busOutput
+	add := func(block, key string) {
+		parent, _ := metadata[block].(map[string]interface{})
+		if name := outputName(parent[key]); name != "" {
+			outputs = append(outputs, busOutput{path: fmt.Sprintf("$.metadata.%s.%s", block, key), name: name})
+		}
+	}
+
+	add("relay", "output")
+	add("valve", "open_output")
+	add("valve", "close_output")
+	return outputs
+}
+
+// outputName beschreibt einen Ausgang (z.B. "coil 3" oder "holding_bit 10.2")
+func outputName(raw interface{}) string {
+	output, ok := raw.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	pointType, _ := output["type"].(string)
+	address, _ := output["address"].(float64)
+	if pointType == "holding_bit" {
+		bit, _ := output["bit"].(float64)
+		return fmt.Sprintf("%s %d.%d", pointType, int(address), int(bit))
+	}
+	return fmt.Sprintf("%s %d", pointType, int(address))
+}
+
+// freeSlaveID gibt die kleinste unbenutzte Slave-ID zurück
+func freeSlaveID(used map[int]busDevice) int {
+	for id := 1; id <= 247; id++ {
+		if _, exists := used[id]; !exists {
+			return id
+		}
+	}
+	return 247
+}
+
+// validateAppConfig prüft die Anwendungskonfiguration und gleicht die Sensoreinträge mit den Geräten ab
+func (v *Validator) validateAppConfig(path string, devices []deviceFile, report *Report) {
+	report.addFile(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		report.add(path, Issue{Severity: SeverityError, Message: fmt.Sprintf("Datei kann nicht gelesen werden: %v", err)})
+		return
+	}
+
+	var raw map[string]interface{}
+	if issue, ok := decodeJSON(data, &raw); !ok {
+		report.add(path, issue)
+		return
+	}
+
+	// Standardwerte wie beim Laden, damit weggelassene Felder nicht als fehlerhaft gelten
+	appConfig := config.AppConfig{Controllers: config.ControllersConfig{PH: config.DefaultPHControlConfig()}}
+	if err := json.Unmarshal(data, &appConfig); err != nil {
+		report.add(path, typeErrorIssue(err))
+		return
+	}
+
+	report.add(path, unknownFields(raw, reflect.TypeOf(config.AppConfig{}), "$")...)
+
+	if appConfig.DeviceReloadIntervalSeconds < 0 {
+		report.add(path, Issue{
+			Severity:   SeverityError,
+			Path:       "$.device_reload_interval_seconds",
+			Message:    "Intervall darf nicht negativ sein",
+			Suggestion: "0 deaktiviert den Hot-Reload",
+		})
+	}
+
+	connectivity := appConfig.Connectivity
+	if connectivity.OfflineAfterFailures > 0 && connectivity.OfflineAfterFailures < connectivity.DegradedAfterFailures {
+		report.add(path, Issue{
+			Severity:   SeverityWarning,
+			Path:       "$.connectivity.offline_after_failures",
+			Message:    "offline_after_failures ist kleiner als degraded_after_failures",
+			Suggestion: fmt.Sprintf("mindestens %d verwenden", connectivity.DegradedAfterFailures),
+		})
+	}
+	if connectivity.BackoffMaxSeconds > 0 && connectivity.BackoffMaxSeconds < connectivity.BackoffInitialSeconds {
+		report.add(path, Issue{
+			Severity:   SeverityWarning,
+			Path:       "$.connectivity.backoff_max_seconds",
+			Message:    "backoff_max_seconds ist kleiner als backoff_initial_seconds",
+			Suggestion: fmt.Sprintf("mindestens %d verwenden", connectivity.BackoffInitialSeconds),
+		})
+	}
+
+	deviceIDs := make(map[string]bool, len(devices))
+	var idList []string
+	for _, entry := range devices {
+		deviceIDs[entry.config.ID] = true
+		idList = append(idList, entry.config.ID)
+	}
+
+	seen := make(map[string]int)
+	for i, sensor := range appConfig.Sensors {
+		sensorPath := fmt.Sprintf("$.sensors[%d]", i)
+
+		if sensor.ID == "" {
+			report.add(path, Issue{Severity: SeverityError, Path: sensorPath + ".id", Message: "keine ID angegeben"})
+			continue
+		}
+		if other, exists := seen[sensor.ID]; exists {
+			report.add(path, Issue{
+				Severity:   SeverityError,
+				Path:       sensorPath + ".id",
+				Message:    fmt.Sprintf("ID %q ist bereits in $.sensors[%d] eingetragen", sensor.ID, other),
+				Suggestion: "doppelten Eintrag entfernen",
+			})
+			continue
+		}
+		seen[sensor.ID] = i
+
+		if sensor.ReadIntervalSeconds < 0 {
+			report.add(path, Issue{
+				Severity:   SeverityError,
+				Path:       sensorPath + ".read_interval_seconds",
+				Message:    "Leseintervall darf nicht negativ sein",
+				Suggestion: "Intervall in Sekunden angeben oder weglassen (Standard 15)",
+			})
+		}
+
+		if len(devices) > 0 && !deviceIDs[sensor.ID] {
+			issue := Issue{
+				Severity:   SeverityWarning,
+				Path:       sensorPath + ".id",
+				Message:    fmt.Sprintf("kein Gerät mit ID %q konfiguriert, Leseintervall und Anzeigename werden nicht verwendet", sensor.ID),
+				Suggestion: "ID einer Gerätekonfiguration verwenden",
+			}
+			if match := closestMatch(sensor.ID, idList); match != "" {
+				issue.Suggestion = fmt.Sprintf("meinten Sie %q?", match)
+			}
+			report.add(path, issue)
+		}
+	}
+
+	report.add(path, validateInterlocks(appConfig.Interlocks, deviceIDs, idList)...)
+	report.add(path, validatePHControl(appConfig.Controllers.PH, deviceIDs, idList)...)
+	report.add(path, validatePID(appConfig.Controllers.PID, deviceIDs, idList)...)
+	report.add(path, validateRules(appConfig.Rules, deviceIDs, idList)...)
+	report.add(path, validateScheduler(appConfig.Scheduler, deviceIDs, idList)...)
+	report.add(path, validateSequences(appConfig.Sequencer.Sequences, deviceIDs, idList)...)
+	report.add(path, validateOperatingHours(appConfig.OperatingHours, deviceIDs, idList)...)
+	report.add(path, validateArbitration(appConfig.Arbitration, deviceIDs, idList)...)
+	report.add(path, validateAcquisition(appConfig.Acquisition, deviceIDs, idList)...)
+}
+
+// validateInterlocks prüft die Sicherheitsverriegelungen und ihre Gerätereferenzen
+func validateInterlocks(interlocks []config.InterlockConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	var issues []Issue
+	names := make(map[string]int)
+
+	unknownDevice := func(path, id string) {
+		if issue, unknown := unknownDeviceIssue(path, id, deviceIDs, idList); unknown {
+			issues = append(issues, issue)
+		}
+	}
+
+	for i, cfg := range interlocks {
+		interlockPath := fmt.Sprintf("$.interlocks[%d]", i)
+
+		if _, errs := interlock.ParseRules([]config.InterlockConfig{cfg}); len(errs) > 0 {
+			for _, err := range errs {
+				issues = append(issues, Issue{Severity: SeverityError, Path: interlockPath, Message: err.Error()})
+			}
+			continue
+		}
+		if other, exists := names[cfg.Name]; exists {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Path:       interlockPath + ".name",
+				Message:    fmt.Sprintf("Name %q ist bereits in $.interlocks[%d] vergeben", cfg.Name, other),
+				Suggestion: "eindeutigen Namen verwenden",
+			})
+		}
+		names[cfg.Name] = i
+
+		for j, id := range cfg.Devices {
+			unknownDevice(fmt.Sprintf("%s.devices[%d]", interlockPath, j), id)
+		}
+		for j, condition := range cfg.Conditions {
+			unknownDevice(fmt.Sprintf("%s.conditions[%d].device", interlockPath, j), condition.Device)
+		}
+	}
+
+	return issues
+}
+
+// validateRules prüft die lokalen Regeln und ihre Gerätereferenzen
+func validateRules(rules []config.RuleConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	var issues []Issue
+	names := make(map[string]int)
+
+	unknownDevice := func(path, id string) {
+		if issue, unknown := unknownDeviceIssue(path, id, deviceIDs, idList); unknown {
+			issues = append(issues, issue)
+		}
+	}
+
+	var walk func(path string, condition config.RuleCondition)
+	walk = func(path string, condition config.RuleCondition) {
+		if condition.Device != "" {
+			unknownDevice(path+".device", condition.Device)
+		}
+		for i, child := range condition.All {
+			walk(fmt.Sprintf("%s.all[%d]", path, i), child)
+		}
+		for i, child := range condition.Any {
+			walk(fmt.Sprintf("%s.any[%d]", path, i), child)
+		}
+	}
+
+	for i, cfg := range rules {
+		rulePath := fmt.Sprintf("$.rules[%d]", i)
+
+		if _, errs := rule.ParseRules([]config.RuleConfig{cfg}); len(errs) > 0 {
+			for _, err := range errs {
+				issues = append(issues, Issue{Severity: SeverityError, Path: rulePath, Message: err.Error()})
+			}
+			continue
+		}
+		if other, exists := names[cfg.Name]; exists {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Path:       rulePath + ".name",
+				Message:    fmt.Sprintf("Name %q ist bereits in $.rules[%d] vergeben", cfg.Name, other),
+				Suggestion: "eindeutigen Namen verwenden",
+			})
+		}
+		names[cfg.Name] = i
+
+		walk(rulePath+".condition", cfg.Condition)
+		for key, actions := range map[string][]config.RuleAction{"actions": cfg.Actions, "clear_actions": cfg.ClearActions} {
+			for j, action := range actions {
+				if action.Device != "" {
+					unknownDevice(fmt.Sprintf("%s.%s[%d].device", rulePath, key, j), action.Device)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// validateScheduler prüft Zeitzone, Sperrzeiten und Zeitpläne des Zeitplaners
+func validateScheduler(cfg config.SchedulerConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	var issues []Issue
+
+	location, err := scheduler.LoadLocation(cfg.Timezone)
+	if err != nil {
+		issues = append(issues, Issue{
+			Severity:   SeverityError,
+			Path:       "$.scheduler.timezone",
+			Message:    err.Error(),
+			Suggestion: "IANA-Zeitzone wie \"Europe/Berlin\" verwenden oder leer lassen (Ortszeit)",
+		})
+		location = time.Local
+	}
+	for i, blackout := range cfg.Blackouts {
+		if _, err := scheduler.ParseBlackout(blackout, location); err != nil {
+			issues = append(issues, Issue{Severity: SeverityError, Path: fmt.Sprintf("$.scheduler.blackouts[%d]", i), Message: err.Error()})
+		}
+	}
+
+	names := make(map[string]int)
+	for i, schedule := range cfg.Schedules {
+		schedulePath := fmt.Sprintf("$.scheduler.schedules[%d]", i)
+
+		if _, err := scheduler.ParseSchedule(schedule, location); err != nil {
+			issues = append(issues, Issue{Severity: SeverityError, Path: schedulePath, Message: err.Error()})
+			continue
+		}
+		if other, exists := names[schedule.Name]; exists {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Path:       schedulePath + ".name",
+				Message:    fmt.Sprintf("Name %q ist bereits in $.scheduler.schedules[%d] vergeben", schedule.Name, other),
+				Suggestion: "eindeutigen Namen verwenden",
+			})
+		}
+		names[schedule.Name] = i
+
+		if issue, unknown := unknownDeviceIssue(schedulePath+".device", schedule.Device, deviceIDs, idList); unknown {
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// validateSequences prüft die Schrittketten und ihre Gerätereferenzen
+func validateSequences(sequences []config.SequenceConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	var issues []Issue
+	names := make(map[string]int)
+
+	unknownDevice := func(path, id string) {
+		if issue, unknown := unknownDeviceIssue(path, id, deviceIDs, idList); unknown {
+			issues = append(issues, issue)
+		}
+	}
+
+	var walk func(path string, condition *config.RuleCondition)
+	walk = func(path string, condition *config.RuleCondition) {
+		if condition == nil {
+			return
+		}
+		if condition.Device != "" {
+			unknownDevice(path+".device", condition.Device)
+		}
+		for i := range condition.All {
+			walk(fmt.Sprintf("%s.all[%d]", path, i), &condition.All[i])
+		}
+		for i := range condition.Any {
+			walk(fmt.Sprintf("%s.any[%d]", path, i), &condition.Any[i])
+		}
+	}
+	actions := func(path string, actions []config.RuleAction) {
+		for i, action := range actions {
+			if action.Device != "" {
+				unknownDevice(fmt.Sprintf("%s[%d].device", path, i), action.Device)
+			}
+		}
+	}
+
+	for i, cfg := range sequences {
+		sequencePath := fmt.Sprintf("$.sequencer.sequences[%d]", i)
+
+		if _, errs := sequence.ParseSequences([]config.SequenceConfig{cfg}); len(errs) > 0 {
+			for _, err := range errs {
+				issues = append(issues, Issue{Severity: SeverityError, Path: sequencePath, Message: err.Error()})
+			}
+			continue
+		}
+		if other, exists := names[cfg.Name]; exists {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Path:       sequencePath + ".name",
+				Message:    fmt.Sprintf("Name %q ist bereits in $.sequencer.sequences[%d] vergeben", cfg.Name, other),
+				Suggestion: "eindeutigen Namen verwenden",
+			})
+		}
+		names[cfg.Name] = i
+
+		walk(sequencePath+".abort_if", cfg.AbortIf)
+		actions(sequencePath+".abort_actions", cfg.AbortActions)
+		actions(sequencePath+".pause_actions", cfg.PauseActions)
+		for j, step := range cfg.Steps {
+			stepPath := fmt.Sprintf("%s.steps[%d]", sequencePath, j)
+			walk(stepPath+".until", step.Until)
+			walk(stepPath+".abort_if", step.AbortIf)
+			actions(stepPath+".actions", step.Actions)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// validateOperatingHours prüft die Wartungsintervalle und ihre Gerätereferenzen
+func validateOperatingHours(cfg config.OperatingHoursConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	var issues []Issue
+	if cfg.MaxGapSeconds < 0 {
+		issues = append(issues, Issue{Severity: SeverityError, Path: "$.operating_hours.max_gap_seconds", Message: "darf nicht negativ sein"})
+	}
+
+	names := make(map[string]int)
+	for i, interval := range cfg.Maintenance {
+		intervalPath := fmt.Sprintf("$.operating_hours.maintenance[%d]", i)
+
+		if _, errs := maintenance.ParseIntervals([]config.MaintenanceIntervalConfig{interval}); len(errs) > 0 {
+			for _, err := range errs {
+				issues = append(issues, Issue{Severity: SeverityError, Path: intervalPath, Message: err.Error()})
+			}
+			continue
+		}
+		if other, exists := names[interval.Name]; exists {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Path:       intervalPath + ".name",
+				Message:    fmt.Sprintf("Name %q ist bereits in $.operating_hours.maintenance[%d] vergeben", interval.Name, other),
+				Suggestion: "eindeutigen Namen verwenden",
+			})
+		}
+		names[interval.Name] = i
+
+		if interval.Device == config.OperatingHoursSystem {
+			continue
+		}
+		if issue, unknown := unknownDeviceIssue(intervalPath+".device", interval.Device, deviceIDs, idList); unknown {
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// validateArbitration prüft die Zeiten der Handbedienung und ihre Gerätereferenzen
+func validateArbitration(cfg config.ArbitrationConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	var issues []Issue
+	if err := arbitration.ValidateTimeouts(cfg); err != nil {
+		issues = append(issues, Issue{Severity: SeverityError, Path: "$.arbitration", Message: err.Error()})
+	}
+	for id := range cfg.Devices {
+		if issue, unknown := unknownDeviceIssue(fmt.Sprintf("$.arbitration.devices.%s", id), id, deviceIDs, idList); unknown {
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// validateAcquisition prüft die Einstellungen der Messwerterfassung und ihre Gerätereferenzen
+func validateAcquisition(cfg config.AcquisitionConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	var issues []Issue
+	for field, value := range map[string]int{
+		"read_timeout_seconds": cfg.ReadTimeoutSeconds,
+		"jitter_ms":            cfg.JitterMS,
+		"stagger_ms":           cfg.StaggerMS,
+	} {
+		if value < 0 {
+			issues = append(issues, Issue{Severity: SeverityError, Path: "$.acquisition." + field, Message: "darf nicht negativ sein"})
+		}
+	}
+
+	for id, deviceCfg := range cfg.Devices {
+		devicePath := fmt.Sprintf("$.acquisition.devices.%s", id)
+		if deviceCfg.PhaseOffsetMS < 0 {
+			issues = append(issues, Issue{Severity: SeverityError, Path: devicePath + ".phase_offset_ms", Message: "darf nicht negativ sein"})
+		}
+		if issue, unknown := unknownDeviceIssue(devicePath, id, deviceIDs, idList); unknown {
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// validatePHControl prüft die pH-Regelung und ihre Gerätereferenzen
+func validatePHControl(cfg config.PHControlConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var issues []Issue
+	for _, ref := range []struct{ key, id string }{
+		{"sensor", cfg.Sensor},
+		{"co2_valve", cfg.CO2Valve},
+		{"heating", cfg.Heating},
+		{"pump", cfg.Pump},
+	} {
+		path := "$.controllers.ph." + ref.key
+		if ref.id == "" {
+			if ref.key != "heating" {
+				issues = append(issues, Issue{
+					Severity:   SeverityError,
+					Path:       path,
+					Message:    "Pflichtfeld fehlt",
+					Suggestion: "ID des Geräts eintragen",
+				})
+			}
+			continue
+		}
+		if issue, unknown := unknownDeviceIssue(path, ref.id, deviceIDs, idList); unknown {
+			issues = append(issues, issue)
+		}
+	}
+
+	if cfg.Tolerance <= 0 {
+		issues = append(issues, Issue{Severity: SeverityError, Path: "$.controllers.ph.tolerance", Message: "Toleranz muss größer als 0 sein"})
+	}
+	if cfg.StopPH >= cfg.TargetPH-cfg.Tolerance {
+		issues = append(issues, Issue{
+			Severity:   SeverityWarning,
+			Path:       "$.controllers.ph.stop_ph",
+			Message:    fmt.Sprintf("Abschaltwert %.2f liegt nicht unter dem Regelfenster (%.2f)", cfg.StopPH, cfg.TargetPH-cfg.Tolerance),
+			Suggestion: "Abschaltwert unter target_ph - tolerance setzen",
+		})
+	}
+	for key, value := range map[string]int{
+		"high_delay_seconds": cfg.HighDelaySeconds,
+		"low_delay_seconds":  cfg.LowDelaySeconds,
+		"interval_seconds":   cfg.IntervalSeconds,
+	} {
+		if value <= 0 {
+			issues = append(issues, Issue{Severity: SeverityError, Path: "$.controllers.ph." + key, Message: "Wert muss größer als 0 sein"})
+		}
+	}
+	if cfg.CalibrationHighPH == cfg.CalibrationLowPH {
+		issues = append(issues, Issue{Severity: SeverityError, Path: "$.controllers.ph.calibration_high_ph", Message: "Pufferlösungen der Kalibrierung müssen sich unterscheiden"})
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// validatePID prüft die PID-Regler und ihre Gerätereferenzen
+func validatePID(controllers []config.PIDConfig, deviceIDs map[string]bool, idList []string) []Issue {
+	var issues []Issue
+	ids := make(map[string]int)
+
+	for i, cfg := range controllers {
+		cfg.SetDefaults()
+		pidPath := fmt.Sprintf("$.controllers.pid[%d]", i)
+
+		if cfg.ID == "" {
+			issues = append(issues, Issue{Severity: SeverityError, Path: pidPath + ".id", Message: "Pflichtfeld fehlt", Suggestion: "eindeutigen Namen eintragen, z.B. \"level_control\""})
+		} else if other, exists := ids[cfg.ID]; exists {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Path:       pidPath + ".id",
+				Message:    fmt.Sprintf("ID %q ist bereits in $.controllers.pid[%d] vergeben", cfg.ID, other),
+				Suggestion: "eindeutige ID verwenden",
+			})
+		} else {
+			ids[cfg.ID] = i
+		}
+
+		for _, ref := range []struct{ key, id string }{{"sensor", cfg.Sensor}, {"actor", cfg.Actor}} {
+			if ref.id == "" {
+				issues = append(issues, Issue{Severity: SeverityError, Path: pidPath + "." + ref.key, Message: "Pflichtfeld fehlt", Suggestion: "ID des Geräts eintragen"})
+				continue
+			}
+			if issue, unknown := unknownDeviceIssue(pidPath+"."+ref.key, ref.id, deviceIDs, idList); unknown {
+				issues = append(issues, issue)
+			}
+		}
+
+		if cfg.Output != config.PIDOutputPosition && cfg.Output != config.PIDOutputPWM {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Path:       pidPath + ".output",
+				Message:    fmt.Sprintf("unbekannte Ausgabe %q", cfg.Output),
+				Suggestion: fmt.Sprintf("%q oder %q verwenden", config.PIDOutputPosition, config.PIDOutputPWM),
+			})
+		}
+		if cfg.Mode != config.PIDModeAuto && cfg.Mode != config.PIDModeManual {
+			issues = append(issues, Issue{
+				Severity:   SeverityError,
+				Path:       pidPath + ".mode",
+				Message:    fmt.Sprintf("unbekannter Modus %q", cfg.Mode),
+				Suggestion: fmt.Sprintf("%q oder %q verwenden", config.PIDModeAuto, config.PIDModeManual),
+			})
+		}
+		if cfg.OutputMin >= cfg.OutputMax {
+			issues = append(issues, Issue{Severity: SeverityError, Path: pidPath + ".output_min", Message: "Untergrenze muss kleiner als output_max sein"})
+		}
+		for key, value := range map[string]float64{"kp": cfg.Kp, "ki": cfg.Ki, "kd": cfg.Kd, "rate_limit": cfg.RateLimit} {
+			if value < 0 {
+				issues = append(issues, Issue{Severity: SeverityError, Path: pidPath + "." + key, Message: "Wert darf nicht negativ sein", Suggestion: "Wirkrichtung über reverse umkehren"})
+			}
+		}
+		for key, value := range map[string]int{"interval_seconds": cfg.IntervalSeconds, "max_reading_age_seconds": cfg.MaxReadingAgeSeconds} {
+			if value <= 0 {
+				issues = append(issues, Issue{Severity: SeverityError, Path: pidPath + "." + key, Message: "Wert muss größer als 0 sein"})
+			}
+		}
+		if cfg.Output == config.PIDOutputPWM && 2*cfg.PWMMinPulseSeconds >= cfg.PWMPeriodSeconds {
+			issues = append(issues, Issue{
+				Severity:   SeverityWarning,
+				Path:       pidPath + ".pwm_min_pulse_seconds",
+				Message:    fmt.Sprintf("Mindestpuls %ds ist zu lang für die Periode %ds, das Relais schaltet nur ganz ein oder aus", cfg.PWMMinPulseSeconds, cfg.PWMPeriodSeconds),
+				Suggestion: "pwm_period_seconds verlängern oder pwm_min_pulse_seconds verkürzen",
+			})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// unknownDeviceIssue meldet eine Referenz auf ein nicht konfiguriertes Gerät. Ohne
+// Gerätekonfigurationen wird nichts gemeldet.
+func unknownDeviceIssue(path, id string, deviceIDs map[string]bool, idList []string) (Issue, bool) {
+	if len(deviceIDs) == 0 || deviceIDs[id] {
+		return Issue{}, false
+	}
+	issue := Issue{
+		Severity:   SeverityError,
+		Path:       path,
+		Message:    fmt.Sprintf("kein Gerät mit ID %q konfiguriert", id),
+		Suggestion: "ID einer Gerätekonfiguration verwenden",
+	}
+	if match := closestMatch(id, idList); match != "" {
+		issue.Suggestion = fmt.Sprintf("meinten Sie %q?", match)
+	}
+	return issue, true
+}
+
+// typeNames gibt die Typbezeichnungen des Katalogs zurück
+func (v *Validator) typeNames() []string {
+	var names []string
+	for _, descriptor := range v.catalog.Types() {
+		names = append(names, descriptor.Type)
+	}
+	return names
+}
+
+// decodeJSON dekodiert ein JSON-Dokument und meldet Syntaxfehler mit Zeile und Spalte
+func decodeJSON(data []byte, target interface{}) (Issue, bool) {
+	err := json.Unmarshal(data, target)
+	if err == nil {
+		return Issue{}, true
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := position(data, syntaxErr.Offset)
+		return Issue{
+			Severity:   SeverityError,
+			Path:       fmt.Sprintf("Zeile %d, Spalte %d", line, column),
+			Message:    fmt.Sprintf("ungültiges JSON: %v", syntaxErr),
+			Suggestion: "fehlende oder überzählige Kommas, Klammern und Anführungszeichen prüfen",
+		}, false
+	}
+
+	return typeErrorIssue(err), false
+}
+
+// typeErrorIssue wandelt einen Dekodierfehler in einen Befund mit JSON-Pfad um
+func typeErrorIssue(err error) Issue {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		path := "$"
+		if typeErr.Field != "" {
+			path = "$." + typeErr.Field
+		}
+		return Issue{
+			Severity:   SeverityError,
+			Path:       path,
+			Message:    fmt.Sprintf("erwartet %s, gefunden %s", typeErr.Type, typeErr.Value),
+			Suggestion: fmt.Sprintf("einen Wert vom Typ %s angeben", typeErr.Type),
+		}
+	}
+
+	return Issue{Severity: SeverityError, Message: err.Error()}
+}
+
+// position berechnet Zeile und Spalte (ab 1) eines Byte-Offsets
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// unknownFields warnt vor Feldern, die nicht zur Zielstruktur gehören und daher ignoriert werden
+func unknownFields(raw map[string]interface{}, structType reflect.Type, path string) []Issue {
+	fields := jsonFields(structType)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	var issues []Issue
+	for _, key := range sortedKeys(raw) {
+		fieldType, known := fields[key]
+		if !known {
+			issue := Issue{
+				Severity: SeverityWarning,
+				Path:     joinPath(path, key),
+				Message:  "unbekanntes Feld wird ignoriert",
+			}
+			if match := closestMatch(key, names); match != "" {
+				issue.Suggestion = fmt.Sprintf("meinten Sie %q?", match)
+			}
+			issues = append(issues, issue)
+			continue
+		}
+
+		// Verschachtelte Strukturen ebenfalls prüfen (Metadata wird über das Schema geprüft)
+		if fieldType.Kind() == reflect.Struct {
+			if nested, ok := raw[key].(map[string]interface{}); ok {
+				issues = append(issues, unknownFields(nested, fieldType, joinPath(path, key))...)
+			}
+		}
+	}
+
+	return issues
+}
+
+// jsonFields gibt die JSON-Namen und Typen der Felder einer Struktur zurück
+func jsonFields(structType reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// inDir prüft, ob ein relativer Pfad in dir oder einem Unterverzeichnis davon liegt
+func inDir(rel, dir string) bool {
+	return rel == dir || strings.HasPrefix(rel, dir+string(filepath.Separator))
+}
+
+// sortedKeys gibt die Schlüssel einer Map sortiert zurück
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedStrings gibt eine sortierte Kopie zurück
+func sortedStrings(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,272 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"owipex_reader/internal/device/creator"
+	"owipex_reader/internal/types"
+)
+
+// testCatalog erstellt einen Katalog mit je einem Sensor- und Aktortyp, deren Metadata-Schema
+// eine Modbus-Slave-ID verlangt
+func testCatalog(t *testing.T) *creator.Catalog {
+	t.Helper()
+
+	schema := types.Schema{
+		"type":     "object",
+		"required": []interface{}{"modbus"},
+		"properties": map[string]interface{}{
+			"modbus": map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"slave_id"},
+				"properties": map[string]interface{}{
+					"slave_id":  map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 247},
+					"baud_rate": map[string]interface{}{"type": "integer"},
+				},
+			},
+		},
+	}
+
+	catalog := creator.NewCatalog()
+	sensor := types.TypeDescriptor{Type: "test_sensor", Protocol: "modbus", ConfigDir: "sensors/test", MetadataSchema: schema}
+	if err := catalog.RegisterSensor(sensor, func(types.DeviceConfig) (types.Sensor, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+	actor := types.TypeDescriptor{Type: "test_relay", Protocol: "modbus", ConfigDir: "actuators/test", MetadataSchema: schema}
+	if err := catalog.RegisterActor(actor, func(types.DeviceConfig) (types.Actor, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+	return catalog
+}
+
+// Gültige Gerätekonfigurationen, aus denen die Testfälle einzelne Dateien ersetzen
+const (
+	validSensor = `{"id": "ph", "name": "pH", "type": "test_sensor", "protocol": "modbus", "enabled": true,
+		"metadata": {"modbus": {"slave_id": 1, "register_maps": {
+			"value": {"address": 0, "data_type": "float32"},
+			"temperature": {"address": 2, "data_type": "float32"}}}}}`
+	validActor = `{"id": "pump", "name": "Pumpe", "type": "test_relay", "protocol": "modbus", "enabled": true,
+		"metadata": {"modbus": {"slave_id": 2}, "relay": {"output": {"type": "coil", "address": 0}}}}`
+	validApp = `{"sensors": [{"id": "ph", "read_interval_seconds": 10}],
+		"interlocks": [{"name": "dry_run", "devices": ["pump"], "conditions": [{"device": "ph", "operator": ">", "value": 4}]}]}`
+)
+
+// writeFiles legt die Dateien (relativer Pfad -> Inhalt) unter dir an, leerer Inhalt löscht sie
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if content == "" {
+			os.Remove(path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// issueKeys gibt die Befunde als sortierte Schlüssel "datei|pfad|schwere" zurück, die Datei
+// relativ zu dir
+func issueKeys(t *testing.T, dir string, report Report) []string {
+	t.Helper()
+	keys := make([]string, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		file, err := filepath.Rel(dir, issue.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, filepath.ToSlash(file)+"|"+issue.Path+"|"+string(issue.Severity))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TestValidate prüft die Befunde je Fehlerklasse für gültige und ungültige Konfigurationen
+func TestValidate(t *testing.T) {
+	const (
+		sensorFile = "sensors/test/ph.json"
+		actorFile  = "actuators/test/pump.json"
+		appFile    = "config.json"
+	)
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{name: "gültige Konfiguration"},
+
+		// Dateien und JSON: Nicht lesbare Gerätedateien fehlen auch für die Referenzen der
+		// Anwendungskonfiguration
+		{
+			name:  "ungültiges JSON",
+			files: map[string]string{sensorFile: `{"id": "ph",}`},
+			want:  []string{sensorFile + "|Zeile 1, Spalte 14|error", appFile + "|$.interlocks[0].conditions[0].device|error", appFile + "|$.sensors[0].id|warning"},
+		},
+		{
+			name:  "falscher Feldtyp",
+			files: map[string]string{sensorFile: `{"id": 7}`},
+			want:  []string{sensorFile + "|$.id|error", appFile + "|$.interlocks[0].conditions[0].device|error", appFile + "|$.sensors[0].id|warning"},
+		},
+		{
+			name:  "unbekanntes Feld",
+			files: map[string]string{actorFile: strings.Replace(validActor, `"enabled"`, `"enabeld": true, "enabled"`, 1)},
+			want:  []string{actorFile + "|$.enabeld|warning"},
+		},
+		{
+			name:  "Datei außerhalb der Konfigurationsverzeichnisse",
+			files: map[string]string{"sensor/test/ph.json": validSensor, sensorFile: ""},
+			want:  []string{"sensor/test/ph.json||warning", appFile + "|$.interlocks[0].conditions[0].device|error", appFile + "|$.sensors[0].id|warning"},
+		},
+
+		// Pflichtfelder und Gerätetypen
+		{
+			name:  "fehlende ID und fehlender Name",
+			files: map[string]string{actorFile: strings.NewReplacer(`"id": "pump", `, "", `"name": "Pumpe", `, "").Replace(validActor)},
+			want:  []string{actorFile + "|$.id|error", actorFile + "|$.name|warning", appFile + "|$.interlocks[0].devices[0]|error"},
+		},
+		{
+			name:  "fehlender Gerätetyp",
+			files: map[string]string{actorFile: strings.Replace(validActor, `"type": "test_relay", `, "", 1)},
+			want:  []string{actorFile + "|$.type|error"},
+		},
+		{
+			name:  "unbekannter Gerätetyp",
+			files: map[string]string{actorFile: strings.Replace(validActor, "test_relay", "test_rellay", 1)},
+			want:  []string{actorFile + "|$.type|error"},
+		},
+		{
+			name:  "falsches Protokoll",
+			files: map[string]string{actorFile: strings.Replace(validActor, `"protocol": "modbus"`, `"protocol": "mqtt"`, 1)},
+			want:  []string{actorFile + "|$.protocol|error"},
+		},
+		{
+			name:  "Metadata verletzt das Schema",
+			files: map[string]string{actorFile: strings.Replace(validActor, `"slave_id": 2`, `"slave_id": 0`, 1)},
+			want:  []string{actorFile + "|$.metadata.modbus.slave_id|error"},
+		},
+		{
+			name:  "Pflichtfeld im Metadata fehlt",
+			files: map[string]string{actorFile: strings.Replace(validActor, `"slave_id": 2`, `"slave": 2`, 1)},
+			want:  []string{actorFile + "|$.metadata.modbus.slave_id|error"},
+		},
+
+		// Register und Bus
+		{
+			name:  "überlappende Register",
+			files: map[string]string{sensorFile: strings.Replace(validSensor, `"address": 2`, `"address": 1`, 1)},
+			want:  []string{sensorFile + "|$.metadata.modbus.register_maps.temperature|error"},
+		},
+		{
+			name:  "Slave-ID doppelt vergeben",
+			files: map[string]string{actorFile: strings.Replace(validActor, `"slave_id": 2`, `"slave_id": 1`, 1)},
+			want:  []string{sensorFile + "|$.metadata.modbus.slave_id|error"},
+		},
+		{
+			name:  "abweichende Baudrate",
+			files: map[string]string{actorFile: strings.Replace(validActor, `"slave_id": 2`, `"slave_id": 2, "baud_rate": 19200`, 1)},
+			want:  []string{sensorFile + "|$.metadata.modbus.baud_rate|error"},
+		},
+
+		// Dateiübergreifende Prüfungen
+		{
+			name:  "doppelte Geräte-ID",
+			files: map[string]string{"actuators/test/pump2.json": strings.Replace(validActor, `"slave_id": 2`, `"slave_id": 3`, 1)},
+			want:  []string{"actuators/test/pump2.json|$.id|error"},
+		},
+		{
+			name:  "Verriegelung mit unbekanntem Gerät",
+			files: map[string]string{appFile: strings.Replace(validApp, `"device": "ph"`, `"device": "pH"`, 1)},
+			want:  []string{appFile + "|$.interlocks[0].conditions[0].device|error"},
+		},
+		{
+			name:  "Leseintervall für unbekanntes Gerät",
+			files: map[string]string{appFile: strings.Replace(validApp, `{"id": "ph", `, `{"id": "orp", `, 1)},
+			want:  []string{appFile + "|$.sensors[0].id|warning"},
+		},
+		{
+			name:  "ungültige Verriegelung",
+			files: map[string]string{appFile: strings.Replace(validApp, `"operator": ">"`, `"operator": "=>"`, 1)},
+			want:  []string{appFile + "|$.interlocks[0]|error"},
+		},
+		{
+			name:  "unbekanntes Feld der Anwendungskonfiguration",
+			files: map[string]string{appFile: strings.Replace(validApp, `"sensors"`, `"sensor": [], "sensors"`, 1)},
+			want:  []string{appFile + "|$.sensor|warning"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			devices := filepath.Join(dir, "devices")
+			writeFiles(t, devices, map[string]string{sensorFile: validSensor, actorFile: validActor})
+			writeFiles(t, dir, map[string]string{appFile: validApp})
+
+			for name, content := range tt.files {
+				if name == appFile {
+					writeFiles(t, dir, map[string]string{name: content})
+				} else {
+					writeFiles(t, devices, map[string]string{name: content})
+				}
+			}
+
+			report := NewValidator(testCatalog(t)).Validate(filepath.Join(dir, appFile), devices)
+
+			got := issueKeys(t, dir, report)
+			want := make([]string, len(tt.want))
+			for i, key := range tt.want {
+				if !strings.HasPrefix(key, appFile) {
+					key = "devices/" + key
+				}
+				want[i] = key
+			}
+			sort.Strings(want)
+
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("Befunde:\n%s\nerwartet:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+				for _, issue := range report.Issues {
+					t.Log(issue)
+				}
+			}
+		})
+	}
+}
+
+// TestReportFailed prüft den Exit-Status von "reader validate" mit und ohne -strict
+func TestReportFailed(t *testing.T) {
+	warning := Issue{Severity: SeverityWarning, Message: "kein Anzeigename angegeben"}
+	failure := Issue{Severity: SeverityError, Message: "keine ID angegeben"}
+
+	tests := []struct {
+		name       string
+		issues     []Issue
+		wantFailed bool
+		wantStrict bool
+	}{
+		{name: "keine Befunde"},
+		{name: "nur Warnungen", issues: []Issue{warning}, wantStrict: true},
+		{name: "Fehler", issues: []Issue{failure}, wantFailed: true, wantStrict: true},
+		{name: "Fehler und Warnungen", issues: []Issue{warning, failure}, wantFailed: true, wantStrict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Report{Issues: tt.issues}
+			if failed := report.Failed(false); failed != tt.wantFailed {
+				t.Errorf("Failed(false) = %v, erwartet %v", failed, tt.wantFailed)
+			}
+			if failed := report.Failed(true); failed != tt.wantStrict {
+				t.Errorf("Failed(true) = %v, erwartet %v", failed, tt.wantStrict)
+			}
+		})
+	}
+}